@@ -30,7 +30,12 @@ func main() {
 	fmt.Printf("DATABASE_URL: %s\n\n", cfg.Database.URL)
 
 	// Create PostgreSQL repository
-	pgRepo, err := repository.NewPostgresRepository(cfg.Database.URL, appLogger.Logger)
+	pgRepo, err := repository.NewPostgresRepository(cfg.Database.URL, repository.PoolConfig{
+		MaxConns:          cfg.Database.MaxConns,
+		MinConns:          cfg.Database.MinConns,
+		HealthCheckPeriod: cfg.Database.HealthCheckPeriod,
+		StatementTimeout:  cfg.Database.StatementTimeout,
+	}, appLogger.Logger)
 	if err != nil {
 		log.Fatalf("Failed to connect to PostgreSQL: %v", err)
 	}
@@ -51,26 +56,26 @@ func main() {
 
 	// Test query: Get supermarket products
 	fmt.Println("=== Testing Supermarket Products Query ===")
-	products, err := pgRepo.QuerySupermarketProducts(ctx, map[string]interface{}{}, 5, 0)
+	products, err := pgRepo.ListSupermarketProducts(ctx, repository.ListingFilter{}, repository.Pagination{Limit: 5})
 	if err != nil {
 		log.Fatalf("Failed to query products: %v", err)
 	}
 
 	fmt.Printf("Found %d products:\n", len(products))
 	for i, product := range products {
-		fmt.Printf("%d. %s - $%.2f (Category: %s, Stock: %d)\n",
+		fmt.Printf("%d. %s - %.2f (Store: %s, Stock: %.0f)\n",
 			i+1,
-			product["name"],
-			product["price"],
-			product["category"],
-			product["stock"],
+			product.Name,
+			product.Price,
+			product.StoreName,
+			product.StockQuantity,
 		)
 	}
 	fmt.Println()
 
 	// Test query: Get movies
 	fmt.Println("=== Testing Movies Query ===")
-	movies, err := pgRepo.QueryMovies(ctx, map[string]interface{}{}, 5, 0)
+	movies, err := pgRepo.QueryMovies(ctx, repository.MovieFilter{}, 5, 0)
 	if err != nil {
 		log.Fatalf("Failed to query movies: %v", err)
 	}
@@ -79,16 +84,16 @@ func main() {
 	for i, movie := range movies {
 		fmt.Printf("%d. %s (%s) - Rating: %.1f\n",
 			i+1,
-			movie["title"],
-			movie["genre"],
-			movie["rating"],
+			movie.Title,
+			movie.Genre,
+			movie.Rating,
 		)
 	}
 	fmt.Println()
 
 	// Test query: Get medicines
 	fmt.Println("=== Testing Medicines Query ===")
-	medicines, err := pgRepo.QueryMedicines(ctx, map[string]interface{}{}, 5, 0)
+	medicines, err := pgRepo.ListMedicines(ctx, repository.ListingFilter{}, repository.Pagination{Limit: 5})
 	if err != nil {
 		log.Fatalf("Failed to query medicines: %v", err)
 	}
@@ -96,43 +101,48 @@ func main() {
 	fmt.Printf("Found %d medicines:\n", len(medicines))
 	for i, medicine := range medicines {
 		rxRequired := "No"
-		if medicine["prescription_required"].(bool) {
+		if medicine.RequiresPrescription {
 			rxRequired = "Yes"
 		}
-		fmt.Printf("%d. %s - $%.2f (Rx Required: %s, Stock: %d)\n",
+		fmt.Printf("%d. %s - %.2f (Rx Required: %s, Stock: %.0f)\n",
 			i+1,
-			medicine["name"],
-			medicine["price"],
+			medicine.Name,
+			medicine.Price,
 			rxRequired,
-			medicine["stock"],
+			medicine.StockQuantity,
 		)
 	}
 	fmt.Println()
 
 	// Test query: Get product by ID
-	fmt.Println("=== Testing Get Product By ID ===")
-	product, err := pgRepo.GetSupermarketProductByID(ctx, 1)
-	if err != nil {
-		log.Fatalf("Failed to get product by ID: %v", err)
-	}
+	if len(products) > 0 {
+		fmt.Println("=== Testing Get Product By ID ===")
+		product, err := pgRepo.GetSupermarketProduct(ctx, products[0].StoreProductID)
+		if err != nil {
+			log.Fatalf("Failed to get product by ID: %v", err)
+		}
 
-	fmt.Printf("Product ID 1:\n")
-	fmt.Printf("  Name: %s\n", product["name"])
-	fmt.Printf("  Category: %s\n", product["category"])
-	fmt.Printf("  Price: $%.2f\n", product["price"])
-	fmt.Printf("  Stock: %d\n", product["stock"])
-	fmt.Printf("  Description: %s\n", product["description"])
-	fmt.Println()
+		fmt.Printf("Product %s:\n", product.StoreProductID)
+		fmt.Printf("  Name: %s\n", product.Name)
+		fmt.Printf("  SKU: %s\n", product.SKU)
+		fmt.Printf("  Price: %.2f\n", product.Price)
+		fmt.Printf("  Stock: %.0f\n", product.StockQuantity)
+		fmt.Printf("  Variations: %d\n", len(product.Variations))
+		fmt.Println()
+	}
 
 	// Test custom query
 	fmt.Println("=== Testing Custom Query ===")
-	results, err := pgRepo.ExecuteQuery(ctx, "SELECT COUNT(*) as total FROM supermarket_products")
+	type productCount struct {
+		Total int64 `db:"total"`
+	}
+	results, err := repository.ExecuteQuery[productCount](ctx, pgRepo, "SELECT COUNT(*) as total FROM store_products")
 	if err != nil {
 		log.Fatalf("Failed to execute custom query: %v", err)
 	}
 
 	if len(results) > 0 {
-		fmt.Printf("Total products in database: %v\n", results[0]["total"])
+		fmt.Printf("Total products in database: %d\n", results[0].Total)
 	}
 	fmt.Println()
 
@@ -11,6 +11,14 @@ type Config struct {
 	Redis    RedisConfig    `mapstructure:"redis"`
 	Database DatabaseConfig `mapstructure:"database"`
 	Logging  LoggingConfig  `mapstructure:"logging"`
+	Tracing  TracingConfig  `mapstructure:"tracing"`
+	Health   HealthConfig   `mapstructure:"health"`
+	GRPC     GRPCConfig     `mapstructure:"grpc"`
+	Realtime RealtimeConfig `mapstructure:"realtime"`
+	Webhooks WebhooksConfig `mapstructure:"webhooks"`
+	Jobs     JobsConfig     `mapstructure:"jobs"`
+	Tenancy  TenancyConfig  `mapstructure:"tenancy"`
+	I18n     I18nConfig     `mapstructure:"i18n"`
 }
 
 // ServerConfig holds server-related configuration
@@ -19,30 +27,290 @@ type ServerConfig struct {
 	ReadTimeout    time.Duration `mapstructure:"read_timeout" validate:"required"`
 	WriteTimeout   time.Duration `mapstructure:"write_timeout" validate:"required"`
 	RequestTimeout time.Duration `mapstructure:"request_timeout" validate:"required"`
-	BearerTokens   []string      `mapstructure:"bearer_tokens"` // Valid bearer tokens for API authentication
+	// BearerTokens are bootstrap tokens accepted with every scope, for issuing the first API
+	// keys; callers otherwise authenticate with keys from the api_keys table, which are
+	// cached in Redis for APIKeyCacheTTL
+	BearerTokens []string `mapstructure:"bearer_tokens"`
+	// AdminTokens, when set, are the only credentials the admin routes admit, and admit to
+	// nothing else; without them platform admin keys holding the admin scope reach them
+	AdminTokens    []string      `mapstructure:"admin_tokens"`
+	APIKeyCacheTTL time.Duration `mapstructure:"api_key_cache_ttl" validate:"required"`
+	// PushSigningSecrets maps ERP store IDs to shared secrets; product pushes and stock
+	// updates for those stores must carry an X-Signature HMAC of their body under it
+	PushSigningSecrets map[string]string `mapstructure:"push_signing_secrets"`
+	// PushAllowedCIDRs, when set, are the only addresses (CIDR blocks or single IPs)
+	// product pushes and stock updates are accepted from, over HTTP and gRPC. API keys may
+	// be issued with allowed CIDRs of their own, which narrow them further
+	PushAllowedCIDRs []string `mapstructure:"push_allowed_cidrs"`
+	// TrustedProxies are the load balancers and proxies whose X-Forwarded-For header names
+	// the client's address; unset, push allow-lists check the connection's address and
+	// everything else trusts every proxy, so the header can be forged
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
+	// CSVImportTemplates are column mappings CSV imports may name, by template name; each
+	// maps field names (id, sku, price...) to the headers of their columns
+	CSVImportTemplates map[string]map[string]string `mapstructure:"csv_import_templates"`
+	// IdempotencyTTL is how long responses to writes sent with an Idempotency-Key are
+	// replayed to retries with the same key
+	IdempotencyTTL time.Duration `mapstructure:"idempotency_ttl" validate:"required"`
+	// Request bodies are limited to MaxBodySize bytes; product pushes, CSV imports and stock
+	// updates, which carry a store's whole catalog, to MaxPushBodySize
+	MaxBodySize     int64 `mapstructure:"max_body_size" validate:"min=1"`
+	MaxPushBodySize int64 `mapstructure:"max_push_body_size" validate:"min=1"`
+	// GzipResponses gzips responses of at least GzipMinSize bytes whose media type starts
+	// with one of GzipTypes, for clients sending Accept-Encoding: gzip
+	GzipResponses bool     `mapstructure:"gzip_responses"`
+	GzipMinSize   int      `mapstructure:"gzip_min_size" validate:"min=0"`
+	GzipTypes     []string `mapstructure:"gzip_types"`
+	// CacheControl sends GET responses served from the cache with Cache-Control: max-age
+	// set to the time left on their entries, up to CacheControlMaxAge if it is positive,
+	// so CDNs and clients' HTTP caches can reuse them
+	CacheControl       bool          `mapstructure:"cache_control"`
+	CacheControlMaxAge time.Duration `mapstructure:"cache_control_max_age" validate:"min=0"`
+	// CORSAllowedOrigins are the origins browsers may call the API from, such as
+	// https://shop.example.com; * allows any
+	CORSAllowedOrigins []string `mapstructure:"cors_allowed_origins"`
+	// RateLimit limits how often each caller may send API requests
+	RateLimit RateLimitConfig `mapstructure:"rate_limit"`
+	// WatchConfig reloads the reloadable settings whenever the config file changes, as
+	// SIGHUP always does
+	WatchConfig bool `mapstructure:"watch_config"`
+	// TLS, when enabled, serves HTTPS and HTTP/2 on Port, for deployments without a load
+	// balancer terminating TLS
+	TLS TLSConfig `mapstructure:"tls"`
+}
+
+// RateLimitConfig lets each caller send Burst API requests at once, refilled at
+// RequestsPerSecond; zero RequestsPerSecond leaves callers unlimited. Callers are API keys
+// and tokens, or addresses when anonymous, and are limited separately in every tenant
+type RateLimitConfig struct {
+	RequestsPerSecond float64 `mapstructure:"requests_per_second" validate:"min=0"`
+	Burst             int     `mapstructure:"burst" validate:"min=0"`
+	// Tenants replaces the limit for callers in the given tenants, by tenant ID
+	Tenants map[string]TenantRateLimit `mapstructure:"tenants" validate:"dive"`
+}
+
+// TenantRateLimit is the rate limit of a tenant's callers
+type TenantRateLimit struct {
+	RequestsPerSecond float64 `mapstructure:"requests_per_second" validate:"min=0"`
+	Burst             int     `mapstructure:"burst" validate:"min=0"`
+}
+
+// TLSConfig holds the server's HTTPS configuration
+type TLSConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// CertFile and KeyFile are the PEM certificate chain and private key, re-read on
+	// reload so renewed certificates are served without a restart
+	CertFile string `mapstructure:"cert_file"`
+	KeyFile  string `mapstructure:"key_file"`
+	// AutocertDomains, instead of the files, obtain and renew certificates for the domains
+	// from Let's Encrypt, kept in AutocertCacheDir; Email is told of problems with them
+	AutocertDomains  []string `mapstructure:"autocert_domains"`
+	AutocertCacheDir string   `mapstructure:"autocert_cache_dir"`
+	AutocertEmail    string   `mapstructure:"autocert_email"`
+	// RedirectPort, when set, listens for plain HTTP and redirects it to HTTPS
+	RedirectPort string `mapstructure:"redirect_port"`
 }
 
 // SupabaseConfig holds Supabase connection configuration
 type SupabaseConfig struct {
 	URL    string `mapstructure:"url" validate:"required,url"`
 	APIKey string `mapstructure:"api_key" validate:"required"`
+	Schema string `mapstructure:"schema"` // Postgres schema served by PostgREST; empty means public
+	// Domains maps a domain (movies, pharmacy) to a Supabase project or schema of its own;
+	// settings it leaves empty are taken from the default project above
+	Domains map[string]SupabaseProjectConfig `mapstructure:"domains" validate:"dive"`
+	// ForwardUserToken runs Supabase queries with the caller's JWT from the Authorization
+	// header instead of the API key, so RLS policies apply; cache keys are partitioned per caller
+	ForwardUserToken bool `mapstructure:"forward_user_token"`
+	// JWTSecret verifies Supabase access tokens so signed-in users are authorized by the
+	// role in their app_metadata; empty accepts API keys only
+	JWTSecret string `mapstructure:"jwt_secret"`
+	// Storage bucket for uploaded product images. A private bucket's images are served
+	// through signed URLs valid for StorageSignedURLTTL
+	StorageBucket       string        `mapstructure:"storage_bucket" validate:"required"`
+	StoragePublic       bool          `mapstructure:"storage_public"`
+	StorageSignedURLTTL time.Duration `mapstructure:"storage_signed_url_ttl" validate:"required"`
+	StorageMaxUpload    int64         `mapstructure:"storage_max_upload" validate:"min=1"` // Largest accepted upload in bytes
+	// Transient failures are retried up to RetryMaxAttempts times; BreakerThreshold
+	// consecutive ones short-circuit queries for BreakerCooldown
+	RetryMaxAttempts int           `mapstructure:"retry_max_attempts" validate:"min=1"`
+	BreakerThreshold int           `mapstructure:"breaker_threshold" validate:"min=1"`
+	BreakerCooldown  time.Duration `mapstructure:"breaker_cooldown" validate:"required"`
+	// PostgresFallback reads from the Postgres pool, which must connect to the default
+	// project's database, while Supabase is unavailable; RLS policies don't apply there
+	PostgresFallback bool `mapstructure:"postgres_fallback"`
+	// Each HTTP call to Supabase, retries included, gives up after RequestTimeout
+	RequestTimeout time.Duration `mapstructure:"request_timeout" validate:"required"`
+	UserAgent      string        `mapstructure:"user_agent"` // Sent with every call to Supabase
+}
+
+// SupabaseProjectConfig holds the Supabase project a domain's queries are sent to
+type SupabaseProjectConfig struct {
+	URL    string `mapstructure:"url" validate:"omitempty,url"`
+	APIKey string `mapstructure:"api_key"`
+	Schema string `mapstructure:"schema"`
+}
+
+// Project returns the Supabase project for domain, filling the settings its entry in
+// Domains leaves empty from the default project
+func (c SupabaseConfig) Project(domain string) SupabaseProjectConfig {
+	project := c.Domains[domain]
+	if project.URL == "" {
+		project.URL = c.URL
+	}
+	if project.APIKey == "" {
+		project.APIKey = c.APIKey
+	}
+	if project.Schema == "" {
+		project.Schema = c.Schema
+	}
+	return project
 }
 
 // RedisConfig holds Redis connection configuration
 type RedisConfig struct {
-	Host     string        `mapstructure:"host" validate:"required"`
-	Port     string        `mapstructure:"port" validate:"required"`
-	Password string        `mapstructure:"password"`
-	DB       int           `mapstructure:"db"`
-	TTL      time.Duration `mapstructure:"ttl" validate:"required"`
+	Host          string        `mapstructure:"host" validate:"required"`
+	Port          string        `mapstructure:"port" validate:"required"`
+	Password      string        `mapstructure:"password"`
+	DB            int           `mapstructure:"db"`
+	TTL           time.Duration `mapstructure:"ttl" validate:"required"`
+	KeyPrefix     string        `mapstructure:"key_prefix"`                              // Namespace prepended to every cache key (e.g. "staging")
+	TTLJitter     float64       `mapstructure:"ttl_jitter" validate:"min=0,max=1"`       // Random TTL spread as a fraction (0.1 = ±10%)
+	SchemaVersion int           `mapstructure:"schema_version" validate:"min=1,max=255"` // Stamped on cached values; bump when response shapes change
+	// Circuit breaker: trips after BreakerThreshold consecutive failures and
+	// short-circuits cache calls for BreakerCooldown
+	BreakerThreshold int           `mapstructure:"breaker_threshold" validate:"min=1"`
+	BreakerCooldown  time.Duration `mapstructure:"breaker_cooldown" validate:"required"`
+	MaxValueSize     int           `mapstructure:"max_value_size" validate:"min=0"` // Values larger than this (bytes) are not cached; 0 disables
+	// Connection pool tuning
+	PoolSize     int           `mapstructure:"pool_size" validate:"min=1"`
+	MinIdleConns int           `mapstructure:"min_idle_conns" validate:"min=0,ltefield=PoolSize"`
+	MaxRetries   int           `mapstructure:"max_retries" validate:"min=-1"` // 0 or -1 disables retries
+	DialTimeout  time.Duration `mapstructure:"dial_timeout" validate:"required"`
+	ReadTimeout  time.Duration `mapstructure:"read_timeout" validate:"required"`
+	WriteTimeout time.Duration `mapstructure:"write_timeout" validate:"required"`
+	// Refresh-ahead: keys read at least RefreshMinHits times per RefreshInterval
+	// are reloaded shortly before they expire
+	RefreshAhead    bool          `mapstructure:"refresh_ahead"`
+	RefreshInterval time.Duration `mapstructure:"refresh_interval" validate:"required"`
+	RefreshMinHits  int64         `mapstructure:"refresh_min_hits" validate:"min=1"`
+	RefreshMaxKeys  int64         `mapstructure:"refresh_max_keys" validate:"min=1"`
+	// Expired results are kept for StaleGrace past their TTL and served, marked stale,
+	// while Supabase or Postgres is unavailable (0 keeps none)
+	StaleGrace time.Duration `mapstructure:"stale_grace" validate:"min=0"`
+	// Callers with a read:catalog API key may pick the TTL of their reads
+	// with ?cache_ttl=<seconds>, clamped to between MinTTLOverride and MaxTTLOverride
+	MinTTLOverride time.Duration `mapstructure:"min_ttl_override" validate:"required"`
+	MaxTTLOverride time.Duration `mapstructure:"max_ttl_override" validate:"required,gtefield=MinTTLOverride"`
 }
 
 // DatabaseConfig holds PostgreSQL connection configuration
 type DatabaseConfig struct {
-	URL string `mapstructure:"url" validate:"required"`
+	URL                  string  `mapstructure:"url" validate:"required"`
+	ReadReplicaURL       string  `mapstructure:"read_replica_url"`
+	FuzzySearchThreshold float64 `mapstructure:"fuzzy_search_threshold" validate:"gt=0,lte=1"`
+	PushChunkSize        int     `mapstructure:"push_chunk_size" validate:"min=1"`
+	ChangeNotifications  bool    `mapstructure:"change_notifications"`
+	RetryMaxAttempts     int     `mapstructure:"retry_max_attempts" validate:"min=1"`
+
+	// Reads go to the primary while ReadReplicaURL is more than ReplicaMaxLag behind, and
+	// for ReplicaMaxLag after a write, so caches refilled after it don't miss it
+	ReplicaMaxLag time.Duration `mapstructure:"replica_max_lag" validate:"required"`
+
+	// Connection pool; MaxConns and MinConns of 0 keep pool_max_conns and pool_min_conns
+	// from URL, or the pgx defaults, and StatementTimeout of 0 leaves the server's
+	// statement_timeout in place
+	MaxConns          int32         `mapstructure:"max_conns" validate:"min=0"`
+	MinConns          int32         `mapstructure:"min_conns" validate:"min=0"`
+	HealthCheckPeriod time.Duration `mapstructure:"health_check_period" validate:"required"`
+	StatementTimeout  time.Duration `mapstructure:"statement_timeout"`
+}
+
+// TracingConfig holds OpenTelemetry tracing configuration
+// When enabled, spans for requests, Redis commands, SQL statements and Supabase calls are
+// exported to the OTLP/HTTP collector at Endpoint
+type TracingConfig struct {
+	Enabled     bool    `mapstructure:"enabled"`
+	Endpoint    string  `mapstructure:"endpoint" validate:"required_if=Enabled true"` // host:port
+	Insecure    bool    `mapstructure:"insecure"`                                     // Plain HTTP instead of HTTPS
+	ServiceName string  `mapstructure:"service_name" validate:"required"`
+	SampleRatio float64 `mapstructure:"sample_ratio" validate:"min=0,max=1"` // New traces recorded; sampled incoming traces always are
+}
+
+// HealthConfig holds readiness probe configuration
+// /readyz checks each dependency within its own timeout; one named in Optional that fails
+// reports the service degraded but still ready
+type HealthConfig struct {
+	RedisTimeout    time.Duration `mapstructure:"redis_timeout" validate:"required"`
+	PostgresTimeout time.Duration `mapstructure:"postgres_timeout" validate:"required"`
+	SupabaseTimeout time.Duration `mapstructure:"supabase_timeout" validate:"required"`
+	Optional        []string      `mapstructure:"optional" validate:"dive,oneof=redis postgres supabase"`
+}
+
+// GRPCConfig holds configuration of the gRPC API for ERP connectors, served on its own port
+// alongside the HTTP API when enabled
+type GRPCConfig struct {
+	Enabled        bool   `mapstructure:"enabled"`
+	Port           string `mapstructure:"port" validate:"required_if=Enabled true"`
+	MaxRecvMsgSize int    `mapstructure:"max_recv_msg_size" validate:"min=0"` // Bytes per message; 0 keeps gRPC's 4 MiB
+}
+
+// RealtimeConfig holds configuration of the WebSocket and Server-Sent Events endpoints
+// streaming store events, which writes publish over Redis pub/sub when enabled
+type RealtimeConfig struct {
+	Enabled        bool          `mapstructure:"enabled"`
+	MaxConnections int           `mapstructure:"max_connections" validate:"min=0"` // Per instance, of both kinds; 0 is unlimited
+	PingInterval   time.Duration `mapstructure:"ping_interval" validate:"required_if=Enabled true"`
+}
+
+// WebhooksConfig holds configuration of outbound webhooks. When enabled, writes queue
+// events for the subscriptions managed under /admin/webhooks, and a worker sends them
+type WebhooksConfig struct {
+	Enabled      bool          `mapstructure:"enabled"`
+	PollInterval time.Duration `mapstructure:"poll_interval" validate:"required_if=Enabled true"`
+	Timeout      time.Duration `mapstructure:"timeout" validate:"required_if=Enabled true"` // Per attempt
+	MaxAttempts  int           `mapstructure:"max_attempts" validate:"min=1"`               // Before a delivery fails for good
+	Concurrency  int           `mapstructure:"concurrency" validate:"min=1"`                // Deliveries sent at once, per instance
+	Retention    time.Duration `mapstructure:"retention"`                                   // Of finished deliveries; 0 keeps them
+}
+
+// JobsConfig holds configuration of the background job pool, which runs jobs queued on
+// Redis by any instance
+type JobsConfig struct {
+	Enabled         bool          `mapstructure:"enabled"`
+	Concurrency     int           `mapstructure:"concurrency" validate:"min=1"`                         // Jobs run at once, per instance
+	MaxAttempts     int           `mapstructure:"max_attempts" validate:"min=1"`                        // Before a job is dead-lettered
+	ClaimAfter      time.Duration `mapstructure:"claim_after" validate:"required_if=Enabled true"`      // Jobs running longer are assumed lost and run again
+	ShutdownTimeout time.Duration `mapstructure:"shutdown_timeout" validate:"required_if=Enabled true"` // Wait for running jobs on shutdown
+}
+
+// TenancyConfig holds configuration of multi-tenancy. When enabled, every request is
+// scoped to a tenant - the one its API key or JWT belongs to, else the one named by the
+// Header header, else DefaultTenant - and Postgres row-level security and the cache keep
+// each tenant's data apart. Requires migrations/add_multi_tenancy.sql
+type TenancyConfig struct {
+	Enabled       bool   `mapstructure:"enabled"`
+	Header        string `mapstructure:"header" validate:"required_if=Enabled true"`
+	DefaultTenant string `mapstructure:"default_tenant"` // For anonymous callers naming none; unset refuses them
+}
+
+// I18nConfig holds configuration of error message translation. When enabled, the messages
+// of error responses are translated into the language of each request's Accept-Language
+// header, from the built-in catalog extended with the <locale>.json files in CatalogDir
+type I18nConfig struct {
+	Enabled    bool   `mapstructure:"enabled"`
+	CatalogDir string `mapstructure:"catalog_dir"`
 }
 
 // LoggingConfig holds logging configuration
 type LoggingConfig struct {
 	Level string `mapstructure:"level" validate:"required,oneof=debug info warn error"`
+	// Requests to SkipPaths, such as health probes, aren't logged, and only SampleRatio of
+	// the rest are; requests that fail always are
+	SkipPaths   []string `mapstructure:"skip_paths"`
+	SampleRatio float64  `mapstructure:"sample_ratio" validate:"min=0,max=1"`
+	// RequestHeaders logs each request's headers, with the values of RedactHeaders, and of
+	// Authorization, Proxy-Authorization and Cookie, redacted
+	RequestHeaders bool     `mapstructure:"request_headers"`
+	RedactHeaders  []string `mapstructure:"redact_headers"`
 }
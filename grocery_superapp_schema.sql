@@ -88,6 +88,7 @@ CREATE TABLE stores (
     is_sponsored BOOLEAN DEFAULT FALSE,
     accepts_cod BOOLEAN DEFAULT TRUE,
     has_in_store_prices BOOLEAN DEFAULT FALSE,
+    timezone VARCHAR(64) NOT NULL DEFAULT 'Asia/Kolkata', -- store_hours are local to it
 
     -- Timestamps
     created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
@@ -107,6 +108,36 @@ CREATE TABLE store_hours (
     created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
 );
 
+-- Store Holidays (dated exceptions to store_hours)
+CREATE TABLE store_holidays (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    store_id UUID NOT NULL REFERENCES stores(id) ON DELETE CASCADE,
+    date DATE NOT NULL,
+    is_closed BOOLEAN NOT NULL DEFAULT TRUE,
+    open_time TIME, -- Required unless is_closed
+    close_time TIME,
+    note TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(store_id, date),
+    CHECK (is_closed OR (open_time IS NOT NULL AND close_time IS NOT NULL))
+);
+
+-- Delivery Zones (radius or polygon, both stored as a polygon in area)
+CREATE TABLE delivery_zones (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    store_id UUID NOT NULL REFERENCES stores(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    zone_type VARCHAR(20) NOT NULL CHECK (zone_type IN ('radius', 'polygon')),
+    radius_km DECIMAL(6, 2), -- Radius zones only
+    center GEOGRAPHY(POINT, 4326), -- Radius zones only
+    area GEOGRAPHY(POLYGON, 4326) NOT NULL,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK (zone_type = 'polygon' OR (radius_km > 0 AND center IS NOT NULL))
+);
+
 -- Categories Table (Hierarchical)
 CREATE TABLE categories (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
@@ -133,6 +164,7 @@ CREATE TABLE brands (
     logo_url TEXT,
     website_url TEXT,
     is_active BOOLEAN DEFAULT TRUE,
+    merged_into UUID REFERENCES brands(id) ON DELETE SET NULL, -- Canonical brand this duplicate was merged into
     created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
 );
@@ -188,6 +220,9 @@ CREATE TABLE products (
     extracted_volume_ml DECIMAL(10, 3), -- Auto-extracted volume in ml
     extracted_weight_g DECIMAL(10, 3), -- Auto-extracted weight in grams
 
+    -- Full-text search (name, brand, manufacturer, description), maintained by trigger
+    search_vector TSVECTOR,
+
     -- Timestamps
     created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
@@ -766,6 +801,59 @@ CREATE TABLE store_impressions (
     created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
 );
 
+-- ============================================================
+-- AUDIT
+-- ============================================================
+
+-- Audit log of mutating API calls; before/after hold only changed fields
+CREATE TABLE audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    actor VARCHAR(100) NOT NULL,
+    endpoint VARCHAR(255) NOT NULL,
+    action VARCHAR(50) NOT NULL, -- 'product_push', 'product_update', 'stock_update', 'store_update', 'store_status', 'store_purge', 'brand_rename', 'brand_merge', 'store_hours', 'delivery_zone', 'store_tax'
+    entity_ids TEXT[] NOT NULL DEFAULT '{}', -- Store IDs, then external product IDs
+    before JSONB,
+    after JSONB,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- ============================================================
+-- WEBHOOKS
+-- ============================================================
+
+-- Outbound webhook subscriptions; secret signs deliveries, so it is stored as is
+CREATE TABLE webhook_subscriptions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(100) NOT NULL,
+    url TEXT NOT NULL,
+    secret VARCHAR(255) NOT NULL,
+    event_types TEXT[] NOT NULL, -- 'product.updated', 'stock.updated', 'stock.low', 'store.opened', 'store.closed'
+    store_id UUID REFERENCES stores(id) ON DELETE CASCADE, -- NULL: every store's events
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    created_by VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK (cardinality(event_types) > 0)
+);
+
+-- One delivery per event and subscription, retried until it succeeds or fails for good
+CREATE TABLE webhook_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    subscription_id UUID NOT NULL REFERENCES webhook_subscriptions(id) ON DELETE CASCADE,
+    event_id UUID NOT NULL,
+    event_type VARCHAR(50) NOT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- 'pending', 'succeeded', 'failed'
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_attempt_at TIMESTAMP WITH TIME ZONE,
+    response_status INTEGER,
+    last_error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    delivered_at TIMESTAMP WITH TIME ZONE,
+    UNIQUE (subscription_id, event_id)
+);
+
 -- ============================================================
 -- INDEXES FOR PERFORMANCE
 -- ============================================================
@@ -780,11 +868,15 @@ CREATE INDEX idx_stores_location ON stores USING GIST(location);
 CREATE INDEX idx_stores_city ON stores(city);
 CREATE INDEX idx_stores_is_active ON stores(is_active);
 CREATE INDEX idx_stores_rating ON stores(rating DESC);
+CREATE INDEX idx_store_hours_store_id ON store_hours(store_id, day_of_week);
+CREATE INDEX idx_delivery_zones_store_id ON delivery_zones(store_id);
+CREATE INDEX idx_delivery_zones_area ON delivery_zones USING GIST(area) WHERE is_active = true;
 
 -- Brand indexes
 CREATE INDEX idx_brands_slug ON brands(slug);
 CREATE INDEX idx_brands_normalized_name ON brands(normalized_name);
 CREATE INDEX idx_brands_is_active ON brands(is_active);
+CREATE INDEX idx_brands_merged_into ON brands(merged_into) WHERE merged_into IS NOT NULL;
 
 -- Product indexes
 CREATE INDEX idx_products_category_id ON products(category_id);
@@ -798,6 +890,7 @@ CREATE INDEX idx_products_ean ON products(ean) WHERE ean IS NOT NULL;
 -- Product matching indexes (for ERP integration)
 CREATE INDEX idx_products_name_trgm ON products USING gin(name gin_trgm_ops);
 CREATE INDEX idx_products_normalized_name_trgm ON products USING gin(normalized_name gin_trgm_ops);
+CREATE INDEX idx_products_search_vector ON products USING gin(search_vector);
 
 -- Tax indexes
 CREATE INDEX idx_taxes_tax_id ON taxes(tax_id);
@@ -839,6 +932,7 @@ CREATE INDEX idx_store_products_store_id ON store_products(store_id);
 CREATE INDEX idx_store_products_product_id ON store_products(product_id);
 CREATE INDEX idx_store_products_external_id ON store_products(external_id) WHERE external_id IS NOT NULL;
 CREATE INDEX idx_store_products_availability ON store_products(is_available, is_in_stock);
+CREATE INDEX idx_store_products_created_at_id ON store_products(created_at DESC, id DESC); -- keyset pagination
 
 -- Order indexes
 CREATE INDEX idx_orders_user_id ON orders(user_id);
@@ -879,6 +973,18 @@ CREATE INDEX idx_notifications_user_id ON notifications(user_id);
 CREATE INDEX idx_notifications_is_read ON notifications(is_read);
 CREATE INDEX idx_notifications_created_at ON notifications(created_at DESC);
 
+-- Audit indexes
+CREATE INDEX idx_audit_log_created_at ON audit_log(created_at DESC, id DESC);
+CREATE INDEX idx_audit_log_action ON audit_log(action, created_at DESC);
+CREATE INDEX idx_audit_log_actor ON audit_log(actor, created_at DESC);
+CREATE INDEX idx_audit_log_entity_ids ON audit_log USING gin(entity_ids);
+
+-- Webhook indexes
+CREATE INDEX idx_webhook_subscriptions_event_types ON webhook_subscriptions USING gin(event_types) WHERE is_active;
+CREATE INDEX idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX idx_webhook_deliveries_subscription ON webhook_deliveries(subscription_id, created_at DESC, id DESC);
+CREATE INDEX idx_webhook_deliveries_finished ON webhook_deliveries(created_at) WHERE status <> 'pending';
+
 -- ============================================================
 -- TRIGGERS FOR UPDATED_AT
 -- ============================================================
@@ -1235,12 +1341,12 @@ BEGIN
     
     v_normalized_name := normalize_product_name(p_brand_name);
     
-    -- Try exact name match
-    SELECT id INTO v_brand_id FROM brands WHERE name = p_brand_name LIMIT 1;
+    -- Try exact name match; merged brands resolve to their canonical brand
+    SELECT COALESCE(merged_into, id) INTO v_brand_id FROM brands WHERE name = p_brand_name LIMIT 1;
     IF v_brand_id IS NOT NULL THEN RETURN v_brand_id; END IF;
     
     -- Try normalized name match
-    SELECT id INTO v_brand_id FROM brands WHERE normalized_name = v_normalized_name LIMIT 1;
+    SELECT COALESCE(merged_into, id) INTO v_brand_id FROM brands WHERE normalized_name = v_normalized_name LIMIT 1;
     IF v_brand_id IS NOT NULL THEN RETURN v_brand_id; END IF;
     
     -- Create new brand
@@ -1258,6 +1364,110 @@ BEGIN
 END;
 $$ LANGUAGE plpgsql;
 
+-- ============================================================
+-- FULL-TEXT PRODUCT SEARCH
+-- ============================================================
+
+-- Function to build a product's search vector
+CREATE OR REPLACE FUNCTION build_product_search_vector(
+    p_name TEXT,
+    p_description TEXT,
+    p_manufacturer TEXT,
+    p_brand_id UUID
+)
+RETURNS TSVECTOR AS $$
+DECLARE
+    v_brand_name TEXT;
+BEGIN
+    IF p_brand_id IS NOT NULL THEN
+        SELECT name INTO v_brand_name FROM brands WHERE id = p_brand_id;
+    END IF;
+
+    RETURN setweight(to_tsvector('english', COALESCE(p_name, '')), 'A')
+        || setweight(to_tsvector('english', COALESCE(v_brand_name, '')), 'B')
+        || setweight(to_tsvector('english', COALESCE(p_manufacturer, '')), 'C')
+        || setweight(to_tsvector('english', COALESCE(p_description, '')), 'D');
+END;
+$$ LANGUAGE plpgsql STABLE;
+
+-- Keep search_vector current when searchable product fields change
+CREATE OR REPLACE FUNCTION update_product_search_vector()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.search_vector := build_product_search_vector(NEW.name, NEW.description, NEW.manufacturer, NEW.brand_id);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trigger_update_product_search_vector ON products;
+CREATE TRIGGER trigger_update_product_search_vector
+    BEFORE INSERT OR UPDATE OF name, description, manufacturer, brand_id ON products
+    FOR EACH ROW
+    EXECUTE FUNCTION update_product_search_vector();
+
+-- Re-index a brand's products when the brand is renamed
+CREATE OR REPLACE FUNCTION refresh_brand_product_search_vectors()
+RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE products
+    SET search_vector = build_product_search_vector(name, description, manufacturer, brand_id)
+    WHERE brand_id = NEW.id;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trigger_refresh_brand_product_search_vectors ON brands;
+CREATE TRIGGER trigger_refresh_brand_product_search_vectors
+    AFTER UPDATE OF name ON brands
+    FOR EACH ROW
+    WHEN (OLD.name IS DISTINCT FROM NEW.name)
+    EXECUTE FUNCTION refresh_brand_product_search_vectors();
+
+-- ============================================================
+-- CATALOG CHANGE NOTIFICATIONS
+-- ============================================================
+
+-- 1. Products: one notification per statement
+CREATE OR REPLACE FUNCTION notify_product_change()
+RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM pg_notify('catalog_changes', json_build_object('table', TG_TABLE_NAME,
+            'origin', current_setting('application_name'))::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trigger_notify_product_change ON products;
+CREATE TRIGGER trigger_notify_product_change
+    AFTER INSERT OR UPDATE OR DELETE OR TRUNCATE ON products
+    FOR EACH STATEMENT
+    EXECUTE FUNCTION notify_product_change();
+
+-- 2. Store products: per row, so the notification names the store
+CREATE OR REPLACE FUNCTION notify_store_product_change()
+RETURNS TRIGGER AS $$
+DECLARE
+    v_store_id UUID;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        v_store_id := OLD.store_id;
+    ELSE
+        v_store_id := NEW.store_id;
+    END IF;
+
+    PERFORM pg_notify('catalog_changes',
+        json_build_object('table', TG_TABLE_NAME, 'store_id', v_store_id,
+            'origin', current_setting('application_name'))::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trigger_notify_store_product_change ON store_products;
+CREATE TRIGGER trigger_notify_store_product_change
+    AFTER INSERT OR UPDATE OR DELETE ON store_products
+    FOR EACH ROW
+    EXECUTE FUNCTION notify_store_product_change();
+
 -- Add comments
 COMMENT ON TABLE brands IS 'Normalized brand names for multi-ERP mapping. Different ERPs may use different brand names (Coca Cola, CocaCola, Coke) which map to the same brand.';
 COMMENT ON FUNCTION find_or_create_brand IS 'Finds existing brand by name or normalized name, or creates new brand if not found. Handles brand name variations across ERPs.';
@@ -1265,6 +1475,7 @@ COMMENT ON FUNCTION find_matching_product IS 'Three-layer product matching for E
 COMMENT ON FUNCTION normalize_product_name IS 'Normalizes product names by removing punctuation, filler words, and standardizing units for better matching.';
 COMMENT ON FUNCTION extract_volume_ml IS 'Extracts volume in milliliters from product name (e.g., "1L" → 1000, "500ml" → 500).';
 COMMENT ON FUNCTION extract_weight_g IS 'Extracts weight in grams from product name (e.g., "1kg" → 1000, "500g" → 500).';
+COMMENT ON FUNCTION build_product_search_vector IS 'Builds the weighted full-text vector for a product: name (A), brand (B), manufacturer (C), description (D).';
 COMMENT ON TABLE store_product_mappings IS 'Maps external ERP product identifiers to internal product IDs. Each store can have multiple ERP systems with different product IDs for the same product.';
 
 -- ============================================================
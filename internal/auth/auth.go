@@ -0,0 +1,203 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/yourusername/supabase-redis-middleware/internal/cache"
+	"github.com/yourusername/supabase-redis-middleware/internal/logger"
+	"github.com/yourusername/supabase-redis-middleware/internal/repository"
+	"github.com/yourusername/supabase-redis-middleware/internal/tenant"
+	"go.uber.org/zap"
+)
+
+// touchInterval bounds how often a key's last_used_at is written, per instance
+const touchInterval = time.Minute
+
+// Authentication failures, reported to the caller as 401s
+var (
+	ErrInvalidKey = errors.New("invalid API key")
+	ErrKeyRevoked = errors.New("API key has been revoked")
+	ErrKeyExpired = errors.New("API key has expired")
+)
+
+// KeyStore looks up issued API keys; *repository.PostgresRepository implements it
+type KeyStore interface {
+	FindAPIKeyByHash(ctx context.Context, keyHash string) (*repository.APIKey, error)
+	TouchAPIKey(ctx context.Context, id string) error
+}
+
+// Authenticator validates bearer credentials: API keys from the api_keys table and, once
+// EnableJWT is called, Supabase access tokens
+// Keys are looked up by hash and cached in Redis for the cache TTL, so validation rarely
+// reaches Postgres; revoking a key through Invalidate drops its cached copy. Bootstrap
+// tokens, the static server.bearer_tokens, are accepted as platform admins so the first
+// keys can be issued. Admin tokens, once EnableAdminTokens is called, are accepted for the
+// admin scope alone
+type Authenticator struct {
+	store       KeyStore
+	cache       cache.CacheService
+	cacheTTL    atomic.Int64 // A time.Duration
+	bootstrap   atomic.Pointer[[]string]
+	adminTokens []string
+	jwtSecret   []byte
+	log         *zap.Logger
+	now         func() time.Time
+
+	mu      sync.Mutex
+	touched map[string]time.Time // When each key's last_used_at was last written
+}
+
+// NewAuthenticator creates an Authenticator caching keys in cacheService for cacheTTL
+func NewAuthenticator(store KeyStore, cacheService cache.CacheService, cacheTTL time.Duration, bootstrapTokens []string, log *zap.Logger) *Authenticator {
+	a := &Authenticator{
+		store:   store,
+		cache:   cacheService,
+		log:     log,
+		now:     time.Now,
+		touched: make(map[string]time.Time),
+	}
+	a.SetCacheTTL(cacheTTL)
+	a.SetBootstrapTokens(bootstrapTokens)
+	return a
+}
+
+// SetCacheTTL changes how long keys looked up from now on are cached for
+func (a *Authenticator) SetCacheTTL(ttl time.Duration) {
+	a.cacheTTL.Store(int64(ttl))
+}
+
+// SetBootstrapTokens replaces the bootstrap tokens; requests already authenticated with a
+// dropped one finish as they started
+func (a *Authenticator) SetBootstrapTokens(tokens []string) {
+	a.bootstrap.Store(&tokens)
+}
+
+// EnableJWT accepts Supabase access tokens signed with secret, the project's JWT secret
+// Their role and store are read from app_metadata
+func (a *Authenticator) EnableJWT(secret string) {
+	a.jwtSecret = []byte(secret)
+}
+
+// EnableAdminTokens accepts tokens as admin tokens, holding the admin scope and nothing
+// else. The admin routes then admit admin tokens only, so no key issued to an ERP or store,
+// nor a bootstrap token, reaches them
+func (a *Authenticator) EnableAdminTokens(tokens []string) {
+	a.adminTokens = tokens
+}
+
+// SeparatesAdmin reports whether admin tokens are configured, and so are the only
+// credentials the admin routes admit. A nil Authenticator configures none
+func (a *Authenticator) SeparatesAdmin() bool {
+	return a != nil && len(a.adminTokens) > 0
+}
+
+// Authenticate returns the caller token identifies
+// Unknown API keys fail with ErrInvalidKey, revoked and expired keys with ErrKeyRevoked
+// and ErrKeyExpired, and JWTs that don't verify with ErrInvalidToken; any other error
+// means the key couldn't be looked up. Only tokens shaped like API keys are looked up, so
+// other bearer tokens, such as forwarded user JWTs, never reach Postgres. Keys are looked
+// up across tenants, since the key decides which tenant its caller acts for
+func (a *Authenticator) Authenticate(ctx context.Context, token string) (*Principal, error) {
+	ctx = tenant.WithID(ctx, "")
+	if token == "" {
+		return nil, ErrInvalidKey
+	}
+	for _, bootstrap := range *a.bootstrap.Load() {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(bootstrap)) == 1 {
+			principal := bootstrapPrincipal
+			return &principal, nil
+		}
+	}
+	for _, admin := range a.adminTokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(admin)) == 1 {
+			return adminPrincipal(token), nil
+		}
+	}
+	if isJWT(token) {
+		if a.jwtSecret == nil {
+			return nil, ErrInvalidToken
+		}
+		return verifyJWT(token, a.jwtSecret, a.now())
+	}
+	if !strings.HasPrefix(token, repository.APIKeyPrefix) {
+		return nil, ErrInvalidKey
+	}
+
+	keyHash := repository.HashAPIKey(token)
+	key, err := a.lookup(ctx, keyHash)
+	if err != nil {
+		return nil, err
+	}
+
+	now := a.now()
+	switch {
+	case key.RevokedAt != nil:
+		return nil, ErrKeyRevoked
+	case !key.Active(now):
+		return nil, ErrKeyExpired
+	}
+
+	a.touch(ctx, key.ID, now)
+	return keyPrincipal(key), nil
+}
+
+// Invalidate drops the cached copy of key, once it has been revoked
+func (a *Authenticator) Invalidate(ctx context.Context, key *repository.APIKey) {
+	ctx = tenant.WithID(ctx, "")
+	if err := a.cache.Delete(ctx, a.cacheKey(key.KeyHash)); err != nil {
+		logger.FromContext(ctx, a.log).Warn("Failed to drop cached API key", zap.String("key_id", key.ID), zap.Error(err))
+	}
+}
+
+// lookup returns the key with keyHash from the cache, or from the store on a miss
+func (a *Authenticator) lookup(ctx context.Context, keyHash string) (*repository.APIKey, error) {
+	cacheKey := a.cacheKey(keyHash)
+	if cached, _ := a.cache.Get(ctx, cacheKey); cached != nil {
+		var key repository.APIKey
+		if err := json.Unmarshal(cached, &key); err == nil {
+			return &key, nil
+		}
+	}
+
+	key, err := a.store.FindAPIKeyByHash(ctx, keyHash)
+	if err != nil && repository.GetStatusCode(err) == http.StatusNotFound {
+		return nil, ErrInvalidKey
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if data, err := json.Marshal(key); err == nil {
+		_ = a.cache.Set(ctx, cacheKey, data, time.Duration(a.cacheTTL.Load()))
+	}
+	return key, nil
+}
+
+// touch records that the key with id was used at now, at most once per touchInterval
+// A failed write is logged and never fails the request
+func (a *Authenticator) touch(ctx context.Context, id string, now time.Time) {
+	a.mu.Lock()
+	if now.Sub(a.touched[id]) < touchInterval {
+		a.mu.Unlock()
+		return
+	}
+	a.touched[id] = now
+	a.mu.Unlock()
+
+	if err := a.store.TouchAPIKey(ctx, id); err != nil {
+		logger.FromContext(ctx, a.log).Warn("Failed to record API key use", zap.String("key_id", id), zap.Error(err))
+	}
+}
+
+// cacheKey is the cache key holding the key with keyHash
+func (a *Authenticator) cacheKey(keyHash string) string {
+	return a.cache.GenerateKey(cache.DomainAPIKeys, map[string]string{"hash": keyHash})
+}
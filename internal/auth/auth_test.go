@@ -0,0 +1,348 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/yourusername/supabase-redis-middleware/internal/cache"
+	"github.com/yourusername/supabase-redis-middleware/internal/repository"
+	"go.uber.org/zap"
+)
+
+// mapCache keeps entries in a map; methods the authenticator doesn't use are left unimplemented
+type mapCache struct {
+	cache.CacheService
+	data map[string][]byte
+}
+
+func (m *mapCache) Get(ctx context.Context, key string) ([]byte, error) {
+	return m.data[key], nil
+}
+
+func (m *mapCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	m.data[key] = value
+	return nil
+}
+
+func (m *mapCache) Delete(ctx context.Context, key string) error {
+	delete(m.data, key)
+	return nil
+}
+
+func (m *mapCache) GenerateKey(domain string, params map[string]string) string {
+	return domain + ":" + params["hash"]
+}
+
+// mockKeyStore serves keys by hash, counting lookups and touches
+type mockKeyStore struct {
+	keys    map[string]*repository.APIKey
+	lookups int
+	touches int
+}
+
+func (m *mockKeyStore) FindAPIKeyByHash(ctx context.Context, keyHash string) (*repository.APIKey, error) {
+	m.lookups++
+	key, ok := m.keys[keyHash]
+	if !ok {
+		return nil, repository.NewNotFoundError("api_keys", keyHash)
+	}
+	copied := *key
+	return &copied, nil
+}
+
+func (m *mockKeyStore) TouchAPIKey(ctx context.Context, id string) error {
+	m.touches++
+	return nil
+}
+
+func newTestAuthenticator(keys map[string]*repository.APIKey, bootstrap ...string) (*Authenticator, *mockKeyStore) {
+	store := &mockKeyStore{keys: map[string]*repository.APIKey{}}
+	for token, key := range keys {
+		key.KeyHash = repository.HashAPIKey(token)
+		store.keys[key.KeyHash] = key
+	}
+	return NewAuthenticator(store, &mapCache{data: map[string][]byte{}}, time.Minute, bootstrap, zap.NewNop()), store
+}
+
+func TestAuthenticate_CachesKeys(t *testing.T) {
+	a, store := newTestAuthenticator(map[string]*repository.APIKey{
+		"gol_pos": {ID: "key-1", Role: repository.RoleERP, Scopes: []string{repository.ScopePushProducts}},
+	})
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		principal, err := a.Authenticate(ctx, "gol_pos")
+		if err != nil {
+			t.Fatalf("Authenticate() error = %v", err)
+		}
+		if principal.ID != "key:key-1" || principal.Role != repository.RoleERP || !principal.HasScope(repository.ScopePushProducts) {
+			t.Errorf("Authenticate() = %+v, want key:key-1 as erp with push:products", principal)
+		}
+	}
+
+	if store.lookups != 1 {
+		t.Errorf("store lookups = %d, want 1 (later calls served from the cache)", store.lookups)
+	}
+	if store.touches != 1 {
+		t.Errorf("last_used_at writes = %d, want 1 within the touch interval", store.touches)
+	}
+
+	a.now = func() time.Time { return time.Now().Add(2 * touchInterval) }
+	if _, err := a.Authenticate(ctx, "gol_pos"); err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+	if store.touches != 2 {
+		t.Errorf("last_used_at writes = %d, want 2 once the touch interval has passed", store.touches)
+	}
+}
+
+func TestAuthenticate_RejectsInactiveKeys(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+	a, _ := newTestAuthenticator(map[string]*repository.APIKey{
+		"gol_revoked":  {ID: "key-1", RevokedAt: &past},
+		"gol_expired":  {ID: "key-2", ExpiresAt: &past},
+		"gol_expiring": {ID: "key-3", ExpiresAt: &future},
+	})
+	ctx := context.Background()
+
+	tests := []struct {
+		token   string
+		wantErr error
+	}{
+		{"gol_revoked", ErrKeyRevoked},
+		{"gol_expired", ErrKeyExpired},
+		{"gol_expiring", nil},
+		{"gol_unknown", ErrInvalidKey},
+		{"", ErrInvalidKey},
+	}
+	for _, tt := range tests {
+		if _, err := a.Authenticate(ctx, tt.token); !errors.Is(err, tt.wantErr) {
+			t.Errorf("Authenticate(%q) error = %v, want %v", tt.token, err, tt.wantErr)
+		}
+	}
+}
+
+func TestInvalidate_DropsCachedKey(t *testing.T) {
+	a, store := newTestAuthenticator(map[string]*repository.APIKey{
+		"gol_pos": {ID: "key-1"},
+	})
+	ctx := context.Background()
+
+	if _, err := a.Authenticate(ctx, "gol_pos"); err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+
+	revoked := store.keys[repository.HashAPIKey("gol_pos")]
+	now := time.Now()
+	revoked.RevokedAt = &now
+	a.Invalidate(ctx, revoked)
+
+	if _, err := a.Authenticate(ctx, "gol_pos"); !errors.Is(err, ErrKeyRevoked) {
+		t.Errorf("Authenticate() after revoking error = %v, want ErrKeyRevoked", err)
+	}
+}
+
+func TestAuthenticate_BootstrapTokens(t *testing.T) {
+	a, store := newTestAuthenticator(nil, "bootstrap-secret")
+
+	principal, err := a.Authenticate(context.Background(), "bootstrap-secret")
+	if err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+	if principal.Role != repository.RolePlatformAdmin {
+		t.Errorf("bootstrap token role = %q, want %q", principal.Role, repository.RolePlatformAdmin)
+	}
+	for _, scope := range repository.APIKeyScopes {
+		if !principal.HasScope(scope) {
+			t.Errorf("bootstrap token lacks scope %s", scope)
+		}
+	}
+	if !principal.AllowsStore("any-store") {
+		t.Error("bootstrap token is bound to a store")
+	}
+	if store.lookups != 0 || store.touches != 0 {
+		t.Errorf("bootstrap token reached the store: %d lookups, %d touches", store.lookups, store.touches)
+	}
+}
+
+func TestSetBootstrapTokens(t *testing.T) {
+	a, _ := newTestAuthenticator(nil, "old-secret")
+	a.SetBootstrapTokens([]string{"new-secret"})
+
+	if _, err := a.Authenticate(context.Background(), "new-secret"); err != nil {
+		t.Errorf("Authenticate() with the new token error = %v", err)
+	}
+	if _, err := a.Authenticate(context.Background(), "old-secret"); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Authenticate() with the dropped token error = %v, want ErrInvalidKey", err)
+	}
+}
+
+func TestAuthenticate_AdminTokens(t *testing.T) {
+	if (*Authenticator)(nil).SeparatesAdmin() {
+		t.Error("SeparatesAdmin() = true for a nil Authenticator")
+	}
+	a, store := newTestAuthenticator(nil, "bootstrap-secret")
+	if a.SeparatesAdmin() {
+		t.Error("SeparatesAdmin() = true before admin tokens are enabled")
+	}
+	if _, err := a.Authenticate(context.Background(), "admin-secret"); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("admin token before EnableAdminTokens: error = %v, want %v", err, ErrInvalidKey)
+	}
+
+	a.EnableAdminTokens([]string{"admin-secret", "other-admin-secret"})
+	if !a.SeparatesAdmin() {
+		t.Error("SeparatesAdmin() = false with admin tokens enabled")
+	}
+
+	principal, err := a.Authenticate(context.Background(), "admin-secret")
+	if err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+	if !principal.Admin {
+		t.Error("admin token principal is not marked Admin")
+	}
+	if !principal.HasScope(repository.ScopeAdmin) || len(principal.Scopes) != 1 {
+		t.Errorf("admin token scopes = %v, want only %s", principal.Scopes, repository.ScopeAdmin)
+	}
+	other, err := a.Authenticate(context.Background(), "other-admin-secret")
+	if err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+	if other.ID == principal.ID {
+		t.Errorf("admin tokens share the audit actor %q", principal.ID)
+	}
+
+	bootstrap, err := a.Authenticate(context.Background(), "bootstrap-secret")
+	if err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+	if bootstrap.Admin {
+		t.Error("bootstrap token is marked Admin")
+	}
+	if store.lookups != 0 {
+		t.Errorf("admin tokens reached the store: %d lookups", store.lookups)
+	}
+}
+
+// signJWT returns an HS256 token for claims signed with secret
+func signJWT(t *testing.T, secret string, claims map[string]any) string {
+	t.Helper()
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatalf("marshal claims: %v", err)
+	}
+	unsigned := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`)) + "." +
+		base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestAuthenticate_JWTs(t *testing.T) {
+	a, store := newTestAuthenticator(nil)
+	a.EnableJWT("jwt-secret")
+	ctx := context.Background()
+	exp := time.Now().Add(time.Hour).Unix()
+
+	tests := []struct {
+		name       string
+		token      string
+		wantRole   string
+		wantStore  string
+		wantTenant string
+		wantErr    error
+	}{
+		{
+			name:      "store admin",
+			token:     signJWT(t, "jwt-secret", map[string]any{"sub": "user-1", "exp": exp, "app_metadata": map[string]any{"role": "store_admin", "store_id": "store-1"}}),
+			wantRole:  repository.RoleStoreAdmin,
+			wantStore: "store-1",
+		},
+		{
+			name:     "no role",
+			token:    signJWT(t, "jwt-secret", map[string]any{"sub": "user-2", "exp": exp, "role": "authenticated"}),
+			wantRole: repository.RoleConsumer,
+		},
+		{
+			name:     "unknown role",
+			token:    signJWT(t, "jwt-secret", map[string]any{"sub": "user-3", "exp": exp, "app_metadata": map[string]any{"role": "superuser"}}),
+			wantRole: repository.RoleConsumer,
+		},
+		{
+			name:     "store admin without a store",
+			token:    signJWT(t, "jwt-secret", map[string]any{"sub": "user-4", "exp": exp, "app_metadata": map[string]any{"role": "store_admin"}}),
+			wantRole: repository.RoleConsumer,
+		},
+		{
+			name:       "tenant",
+			token:      signJWT(t, "jwt-secret", map[string]any{"sub": "user-7", "exp": exp, "app_metadata": map[string]any{"role": "store_admin", "store_id": "store-1", "tenant_id": "acme"}}),
+			wantRole:   repository.RoleStoreAdmin,
+			wantStore:  "store-1",
+			wantTenant: "acme",
+		},
+		{
+			name:    "malformed tenant",
+			token:   signJWT(t, "jwt-secret", map[string]any{"sub": "user-8", "exp": exp, "app_metadata": map[string]any{"tenant_id": "Acme Mart"}}),
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name:    "expired",
+			token:   signJWT(t, "jwt-secret", map[string]any{"sub": "user-5", "exp": time.Now().Add(-time.Minute).Unix()}),
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name:    "wrong secret",
+			token:   signJWT(t, "other-secret", map[string]any{"sub": "user-6", "exp": exp}),
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name:    "no subject",
+			token:   signJWT(t, "jwt-secret", map[string]any{"exp": exp}),
+			wantErr: ErrInvalidToken,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, err := a.Authenticate(ctx, tt.token)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Authenticate() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if principal.Role != tt.wantRole {
+				t.Errorf("role = %q, want %q", principal.Role, tt.wantRole)
+			}
+			var storeID string
+			if principal.StoreID != nil {
+				storeID = *principal.StoreID
+			}
+			if storeID != tt.wantStore {
+				t.Errorf("store = %q, want %q", storeID, tt.wantStore)
+			}
+			if principal.TenantID != tt.wantTenant {
+				t.Errorf("tenant = %q, want %q", principal.TenantID, tt.wantTenant)
+			}
+		})
+	}
+
+	if store.lookups != 0 {
+		t.Errorf("JWTs reached the key store: %d lookups", store.lookups)
+	}
+}
+
+func TestAuthenticate_JWTsDisabled(t *testing.T) {
+	a, _ := newTestAuthenticator(nil)
+	token := signJWT(t, "jwt-secret", map[string]any{"sub": "user-1", "exp": time.Now().Add(time.Hour).Unix()})
+
+	if _, err := a.Authenticate(context.Background(), token); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Authenticate() without a JWT secret error = %v, want ErrInvalidToken", err)
+	}
+}
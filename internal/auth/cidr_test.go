@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/yourusername/supabase-redis-middleware/internal/repository"
+)
+
+func TestParseCIDRs(t *testing.T) {
+	prefixes, err := ParseCIDRs([]string{"203.0.113.0/24", " 198.51.100.7 ", "10.1.2.3/8", "2001:db8::/32", "::ffff:192.0.2.1"})
+	if err != nil {
+		t.Fatalf("ParseCIDRs() error = %v", err)
+	}
+	want := []string{"203.0.113.0/24", "198.51.100.7/32", "10.0.0.0/8", "2001:db8::/32", "192.0.2.1/32"}
+	if len(prefixes) != len(want) {
+		t.Fatalf("ParseCIDRs() = %v, want %v", prefixes, want)
+	}
+	for i, prefix := range prefixes {
+		if prefix.String() != want[i] {
+			t.Errorf("ParseCIDRs()[%d] = %s, want %s", i, prefix, want[i])
+		}
+	}
+
+	for _, value := range []string{"", "203.0.113.0/33", "erp.example.com", "203.0.113.0/"} {
+		if _, err := ParseCIDRs([]string{value}); err == nil {
+			t.Errorf("ParseCIDRs(%q) succeeded, want an error", value)
+		}
+	}
+}
+
+func TestContainsIP(t *testing.T) {
+	prefixes, _ := ParseCIDRs([]string{"203.0.113.0/24", "2001:db8::/32"})
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"203.0.113.9", true},
+		{"::ffff:203.0.113.9", true},
+		{"2001:db8::1", true},
+		{"198.51.100.1", false},
+		{"2001:db9::1", false},
+	}
+	for _, tt := range tests {
+		if got := ContainsIP(prefixes, netip.MustParseAddr(tt.addr)); got != tt.want {
+			t.Errorf("ContainsIP(%s) = %v, want %v", tt.addr, got, tt.want)
+		}
+	}
+	if ContainsIP(nil, netip.MustParseAddr("203.0.113.9")) {
+		t.Error("ContainsIP() with no prefixes = true, want false")
+	}
+}
+
+func TestPrincipal_AllowsIP(t *testing.T) {
+	addr := netip.MustParseAddr("203.0.113.9")
+
+	anywhere := keyPrincipal(&repository.APIKey{ID: "any"})
+	if !anywhere.AllowsIP(addr) {
+		t.Error("AllowsIP() for a key without allowed CIDRs = false, want true")
+	}
+
+	restricted := keyPrincipal(&repository.APIKey{ID: "erp", AllowedCIDRs: []string{"198.51.100.0/24", "203.0.113.0/24"}})
+	if !restricted.AllowsIP(addr) {
+		t.Error("AllowsIP() from an allowed block = false, want true")
+	}
+	if restricted.AllowsIP(netip.MustParseAddr("192.0.2.1")) {
+		t.Error("AllowsIP() from outside the allowed blocks = true, want false")
+	}
+
+	unreadable := keyPrincipal(&repository.APIKey{ID: "bad", AllowedCIDRs: []string{"not-a-cidr"}})
+	if unreadable.AllowsIP(addr) {
+		t.Error("AllowsIP() for a key with an unreadable allowlist = true, want false")
+	}
+}
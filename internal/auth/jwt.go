@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/yourusername/supabase-redis-middleware/internal/repository"
+	"github.com/yourusername/supabase-redis-middleware/internal/tenant"
+)
+
+// ErrInvalidToken is returned for a JWT that is malformed, wrongly signed or expired
+var ErrInvalidToken = errors.New("invalid or expired token")
+
+// jwtClaims are the claims of a Supabase access token this service reads
+// The role and tenant are taken from app_metadata, which only the service role can set,
+// never from the top-level role claim, which names the Postgres role
+type jwtClaims struct {
+	Subject     string `json:"sub"`
+	ExpiresAt   int64  `json:"exp"`
+	AppMetadata struct {
+		Role     string `json:"role"`
+		StoreID  string `json:"store_id"`
+		TenantID string `json:"tenant_id"`
+	} `json:"app_metadata"`
+}
+
+// isJWT reports whether token has the three dot-separated parts of a JWT
+func isJWT(token string) bool {
+	return strings.Count(token, ".") == 2
+}
+
+// verifyJWT checks token's HS256 signature with secret and its expiry at now, returning
+// the principal it identifies. Users without a recognized role are consumers, as are
+// store admins whose token names no store
+func verifyJWT(token string, secret []byte, now time.Time) (*Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidToken
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil || header.Alg != "HS256" {
+		return nil, ErrInvalidToken
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return nil, ErrInvalidToken
+	}
+
+	var claims jwtClaims
+	if err := decodeSegment(parts[1], &claims); err != nil || claims.Subject == "" {
+		return nil, ErrInvalidToken
+	}
+	if claims.ExpiresAt == 0 || !now.Before(time.Unix(claims.ExpiresAt, 0)) {
+		return nil, ErrInvalidToken
+	}
+
+	role := claims.AppMetadata.Role
+	if _, ok := repository.RoleScopes[role]; !ok || (role == repository.RoleStoreAdmin && claims.AppMetadata.StoreID == "") {
+		role = repository.RoleConsumer
+	}
+	principal := &Principal{
+		ID:     "user:" + claims.Subject,
+		Role:   role,
+		Scopes: repository.RoleScopes[role],
+	}
+	if storeID := claims.AppMetadata.StoreID; storeID != "" && role != repository.RolePlatformAdmin {
+		principal.StoreID = &storeID
+	}
+	if tenantID := claims.AppMetadata.TenantID; tenantID != "" {
+		if !tenant.Valid(tenantID) {
+			return nil, ErrInvalidToken
+		}
+		principal.TenantID = tenantID
+	}
+	return principal, nil
+}
+
+// decodeSegment decodes a base64url JSON segment of a JWT into v
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
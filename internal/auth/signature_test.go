@@ -0,0 +1,36 @@
+package auth
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestVerifySignature(t *testing.T) {
+	body := []byte(`{"store_id":"STORE-001","products":[]}`)
+	signature := Sign(body, "shared-secret")
+
+	tests := []struct {
+		name      string
+		body      []byte
+		secret    string
+		signature string
+		want      bool
+	}{
+		{"valid", body, "shared-secret", signature, true},
+		{"sha256 prefix", body, "shared-secret", "sha256=" + signature, true},
+		{"uppercase hex", body, "shared-secret", "sha256=" + strings.ToUpper(signature), true},
+		{"tampered body", []byte(`{"store_id":"STORE-001","products":[{}]}`), "shared-secret", signature, false},
+		{"other secret", body, "other-secret", signature, false},
+		{"not hex", body, "shared-secret", "not-a-signature", false},
+		{"empty", body, "shared-secret", "", false},
+	}
+	for _, tt := range tests {
+		if got := VerifySignature(tt.body, tt.secret, tt.signature); got != tt.want {
+			t.Errorf("%s: VerifySignature() = %v, want %v", tt.name, got, tt.want)
+		}
+		if got, err := VerifyReaderSignature(bytes.NewReader(tt.body), tt.secret, tt.signature); got != tt.want || err != nil {
+			t.Errorf("%s: VerifyReaderSignature() = %v, %v, want %v", tt.name, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package auth
+
+import (
+	"errors"
+
+	"github.com/yourusername/supabase-redis-middleware/internal/repository"
+	"github.com/yourusername/supabase-redis-middleware/internal/tenant"
+)
+
+// Tenant resolution failures
+var (
+	ErrInvalidTenant    = errors.New("tenant ID is invalid")                 // A 400
+	ErrTenantRequired   = errors.New("tenant ID is required")                // A 400
+	ErrTenantNotAllowed = errors.New("credentials belong to another tenant") // A 403
+)
+
+// ResolveTenant returns the tenant a request acts for, given its caller (nil if
+// anonymous), the tenant it asked for (the tenant header; "" if none) and the deployment's
+// default tenant ("" if none)
+// A caller with a tenant acts for it, and may not ask for another. A platform admin
+// without one acts for the tenant it asks for, or for none - a system context seeing
+// every tenant - if it asks for none. Anyone else acts for the tenant asked for, falling
+// back to the default
+func ResolveTenant(principal *Principal, requested, defaultTenant string) (string, error) {
+	if requested != "" && !tenant.Valid(requested) {
+		return "", ErrInvalidTenant
+	}
+	if principal != nil && principal.TenantID != "" {
+		if requested != "" && requested != principal.TenantID {
+			return "", ErrTenantNotAllowed
+		}
+		return principal.TenantID, nil
+	}
+	if principal != nil && principal.HasRole(repository.RolePlatformAdmin) {
+		return requested, nil
+	}
+	if requested != "" {
+		return requested, nil
+	}
+	if defaultTenant != "" {
+		return defaultTenant, nil
+	}
+	return "", ErrTenantRequired
+}
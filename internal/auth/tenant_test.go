@@ -0,0 +1,47 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/yourusername/supabase-redis-middleware/internal/repository"
+)
+
+func TestResolveTenant(t *testing.T) {
+	acme := "acme"
+	acmeKey := keyPrincipal(&repository.APIKey{ID: "erp", Role: repository.RoleERP, TenantID: &acme})
+	platformKey := keyPrincipal(&repository.APIKey{ID: "ops", Role: repository.RolePlatformAdmin})
+	consumer := &Principal{ID: "user:1", Role: repository.RoleConsumer}
+
+	tests := []struct {
+		name          string
+		principal     *Principal
+		requested     string
+		defaultTenant string
+		want          string
+		wantErr       error
+	}{
+		{name: "key's tenant", principal: acmeKey, want: "acme"},
+		{name: "key's tenant asked for", principal: acmeKey, requested: "acme", want: "acme"},
+		{name: "another tenant asked for", principal: acmeKey, requested: "other", wantErr: ErrTenantNotAllowed},
+		{name: "platform key without a tenant", principal: platformKey, defaultTenant: "default", want: ""},
+		{name: "platform key asking for a tenant", principal: platformKey, requested: "other", want: "other"},
+		{name: "bootstrap token", principal: &bootstrapPrincipal, requested: "acme", want: "acme"},
+		{name: "user without a tenant", principal: consumer, requested: "acme", want: "acme"},
+		{name: "anonymous", requested: "acme", defaultTenant: "default", want: "acme"},
+		{name: "anonymous falling back to the default", defaultTenant: "default", want: "default"},
+		{name: "anonymous without a default", wantErr: ErrTenantRequired},
+		{name: "malformed tenant", requested: "Acme:1", defaultTenant: "default", wantErr: ErrInvalidTenant},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveTenant(tt.principal, tt.requested, tt.defaultTenant)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ResolveTenant() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ResolveTenant() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ErrCircuitOpen is returned by operations that cannot degrade silently while Redis is tripped
+var ErrCircuitOpen = errors.New("redis circuit breaker is open")
+
+const (
+	defaultBreakerThreshold = 5
+	defaultBreakerCooldown  = 30 * time.Second
+)
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+func (s breakerState) String() string {
+	switch s {
+	case breakerOpen:
+		return "open"
+	case breakerHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// circuitBreaker stops calling Redis after consecutive failures so requests
+// don't each pay the dial/read timeout while it is down
+type circuitBreaker struct {
+	mu        sync.Mutex
+	state     breakerState
+	failures  int
+	openedAt  time.Time
+	probing   bool
+	threshold int
+	cooldown  time.Duration
+	logger    *zap.Logger
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration, logger *zap.Logger) *circuitBreaker {
+	return &circuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		logger:    logger,
+	}
+}
+
+// allow reports whether a Redis call may proceed
+// After the cool-down a single probe is let through in the half-open state
+func (b *circuitBreaker) allow() bool {
+	if b == nil {
+		return true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.transition(breakerHalfOpen)
+		b.probing = true
+		return true
+	case breakerHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// success records a successful Redis call
+func (b *circuitBreaker) success() {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.probing = false
+	if b.state != breakerClosed {
+		b.transition(breakerClosed)
+	}
+}
+
+// failure records a failed Redis call, tripping the breaker at the threshold
+func (b *circuitBreaker) failure() {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.probing = false
+	if b.state == breakerHalfOpen || (b.state == breakerClosed && b.failures >= b.threshold) {
+		b.openedAt = time.Now()
+		b.transition(breakerOpen)
+	}
+}
+
+// abandoned records a Redis call given up by its caller, which says nothing about Redis
+// A probe that is given up frees the slot for the next one
+func (b *circuitBreaker) abandoned() {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// record routes an operation result to success or failure
+// Calls ended by their context being canceled or timing out are neither
+func (b *circuitBreaker) record(err error) {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		b.abandoned()
+		return
+	}
+	if err != nil {
+		b.failure()
+		return
+	}
+	b.success()
+}
+
+// currentState returns the breaker state name
+func (b *circuitBreaker) currentState() string {
+	if b == nil {
+		return breakerClosed.String()
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state.String()
+}
+
+// transition changes state and logs it; callers must hold the mutex
+func (b *circuitBreaker) transition(to breakerState) {
+	from := b.state
+	b.state = to
+
+	fields := []zap.Field{
+		zap.String("from", from.String()),
+		zap.String("to", to.String()),
+		zap.Int("consecutive_failures", b.failures),
+	}
+	if to == breakerOpen {
+		b.logger.Warn("Redis circuit breaker opened, short-circuiting cache calls",
+			append(fields, zap.Duration("cooldown", b.cooldown))...)
+		return
+	}
+	b.logger.Info("Redis circuit breaker state changed", fields...)
+}
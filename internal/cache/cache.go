@@ -5,10 +5,12 @@ import (
 	"crypto/sha256"
 	"encoding/json"
 	"fmt"
+	"math/rand/v2"
 	"sort"
 	"strings"
 	"time"
 
+	"github.com/redis/go-redis/extra/redisotel/v9"
 	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
 )
@@ -18,34 +20,102 @@ type CacheService interface {
 	Get(ctx context.Context, key string) ([]byte, error)
 	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
 	Delete(ctx context.Context, key string) error
+	DeleteByPattern(ctx context.Context, pattern string) (int64, error)
+	InspectKeys(ctx context.Context, pattern string, limit int) ([]KeyInfo, error)
+	GetMany(ctx context.Context, keys []string) (map[string][]byte, error)
+	SetMany(ctx context.Context, entries map[string][]byte, ttl time.Duration) error
+	GetOrSet(ctx context.Context, key string, ttl time.Duration, loader func() ([]byte, error)) ([]byte, error)
+	AcquireLock(ctx context.Context, name string, ttl time.Duration) (*Lock, error)
+	ReleaseLock(ctx context.Context, lock *Lock) error
 	GenerateKey(domain string, params map[string]string) string
+	Stats() map[string]DomainStats
 	Close() error
 }
 
 // RedisCache implements CacheService using Redis
 type RedisCache struct {
-	client *redis.Client
-	logger *zap.Logger
+	client        *redis.Client
+	logger        *zap.Logger
+	stats         *statsRecorder
+	keyPrefix     string
+	ttlJitter     float64
+	schemaVersion byte
+	breaker       *circuitBreaker
+	maxValueSize  int
 }
 
-// NewRedisCache creates a new Redis cache service with connection pooling
-func NewRedisCache(host, port, password string, db int, logger *zap.Logger) (*RedisCache, error) {
-	addr := fmt.Sprintf("%s:%s", host, port)
-	
-	client := redis.NewClient(&redis.Options{
-		Addr:         addr,
-		Password:     password,
-		DB:           db,
+// PoolOptions tunes the Redis connection pool; a zero PoolSize or timeout falls back to
+// DefaultPoolOptions
+type PoolOptions struct {
+	PoolSize     int
+	MinIdleConns int // 0 keeps no idle connections
+	MaxRetries   int // 0 or -1 makes no retries
+	DialTimeout  time.Duration
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+}
+
+// DefaultPoolOptions returns the pool settings used when none are configured
+func DefaultPoolOptions() PoolOptions {
+	return PoolOptions{
 		PoolSize:     10,
 		MinIdleConns: 5,
 		MaxRetries:   3,
 		DialTimeout:  5 * time.Second,
 		ReadTimeout:  3 * time.Second,
 		WriteTimeout: 3 * time.Second,
+	}
+}
+
+// withDefaults fills unset fields from DefaultPoolOptions, and spells no retries as go-redis
+// does, which takes a MaxRetries of 0 for its default of 3
+func (o PoolOptions) withDefaults() PoolOptions {
+	d := DefaultPoolOptions()
+	if o.PoolSize <= 0 {
+		o.PoolSize = d.PoolSize
+	}
+	if o.MinIdleConns < 0 {
+		o.MinIdleConns = 0
+	}
+	if o.MaxRetries == 0 {
+		o.MaxRetries = -1
+	}
+	if o.DialTimeout <= 0 {
+		o.DialTimeout = d.DialTimeout
+	}
+	if o.ReadTimeout <= 0 {
+		o.ReadTimeout = d.ReadTimeout
+	}
+	if o.WriteTimeout <= 0 {
+		o.WriteTimeout = d.WriteTimeout
+	}
+	return o
+}
+
+// NewRedisCache creates a new Redis cache service with the default connection pool
+func NewRedisCache(host, port, password string, db int, logger *zap.Logger) (*RedisCache, error) {
+	return NewRedisCacheWithPool(host, port, password, db, DefaultPoolOptions(), logger)
+}
+
+// NewRedisCacheWithPool creates a new Redis cache service with a tuned connection pool
+func NewRedisCacheWithPool(host, port, password string, db int, pool PoolOptions, logger *zap.Logger) (*RedisCache, error) {
+	addr := fmt.Sprintf("%s:%s", host, port)
+	pool = pool.withDefaults()
+
+	client := redis.NewClient(&redis.Options{
+		Addr:         addr,
+		Password:     password,
+		DB:           db,
+		PoolSize:     pool.PoolSize,
+		MinIdleConns: pool.MinIdleConns,
+		MaxRetries:   pool.MaxRetries,
+		DialTimeout:  pool.DialTimeout,
+		ReadTimeout:  pool.ReadTimeout,
+		WriteTimeout: pool.WriteTimeout,
 	})
 
 	// Test connection
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), pool.DialTimeout)
 	defer cancel()
 
 	if err := client.Ping(ctx).Err(); err != nil {
@@ -58,24 +128,129 @@ func NewRedisCache(host, port, password string, db int, logger *zap.Logger) (*Re
 		logger.Info("Successfully connected to Redis",
 			zap.String("addr", addr),
 			zap.Int("db", db),
+			zap.Int("pool_size", pool.PoolSize),
+			zap.Int("min_idle_conns", pool.MinIdleConns),
 		)
 	}
 
 	return &RedisCache{
-		client: client,
-		logger: logger,
+		client:        client,
+		logger:        logger,
+		stats:         newStatsRecorder(),
+		schemaVersion: DefaultSchemaVersion,
+		breaker:       newCircuitBreaker(defaultBreakerThreshold, defaultBreakerCooldown, logger),
 	}, nil
 }
 
+// EnableTracing records a span for every Redis command and pipeline
+// Must be called before the cache is used
+func (r *RedisCache) EnableTracing() error {
+	return redisotel.InstrumentTracing(r.client)
+}
+
+// SetCircuitBreaker configures how many consecutive Redis failures trip the breaker
+// and how long calls are short-circuited before a probe is attempted
+// Must be called before the cache is used
+func (r *RedisCache) SetCircuitBreaker(threshold int, cooldown time.Duration) {
+	r.breaker = newCircuitBreaker(threshold, cooldown, r.logger)
+}
+
+// CircuitState returns the Redis circuit breaker state (closed, open or half-open)
+func (r *RedisCache) CircuitState() string {
+	return r.breaker.currentState()
+}
+
+// SetKeyPrefix namespaces all generated keys, locks and pattern deletions
+// Must be called before the cache is used
+func (r *RedisCache) SetKeyPrefix(prefix string) {
+	prefix = strings.TrimSuffix(prefix, ":")
+	if prefix != "" {
+		prefix += ":"
+	}
+	r.keyPrefix = prefix
+	r.stats.keyPrefix = prefix
+}
+
+// SetSchemaVersion sets the version stamped on every cached value
+// Entries written under a different version are treated as misses, so bumping it
+// invalidates the whole fleet's cache without a flush. Must be called before use
+func (r *RedisCache) SetSchemaVersion(version byte) {
+	r.schemaVersion = version
+}
+
+// SetMaxValueSize skips caching values larger than maxBytes (0 disables the guard)
+// Must be called before the cache is used
+func (r *RedisCache) SetMaxValueSize(maxBytes int) {
+	r.maxValueSize = maxBytes
+}
+
+// oversized reports (and logs) whether value exceeds the configured max size
+func (r *RedisCache) oversized(key string, value []byte) bool {
+	if r.maxValueSize <= 0 || len(value) <= r.maxValueSize {
+		return false
+	}
+
+	r.stats.recordOversized(key)
+	r.logger.Warn("Skipping cache write for oversized value",
+		zap.String("key", key),
+		zap.Int("size_bytes", len(value)),
+		zap.Int("max_bytes", r.maxValueSize),
+	)
+	return true
+}
+
+// SetTTLJitter spreads expirations by a random fraction of the TTL (0.1 = ±10%)
+// Must be called before the cache is used
+func (r *RedisCache) SetTTLJitter(fraction float64) {
+	r.ttlJitter = fraction
+}
+
+// jitterTTL applies the configured random jitter to ttl
+func (r *RedisCache) jitterTTL(ttl time.Duration) time.Duration {
+	if r.ttlJitter <= 0 || ttl <= 0 {
+		return ttl
+	}
+
+	spread := float64(ttl) * r.ttlJitter
+	jittered := time.Duration(float64(ttl) + (rand.Float64()*2-1)*spread)
+	if jittered < time.Second {
+		return time.Second
+	}
+	return jittered
+}
+
 // Get retrieves a value from cache by key
+// Get, Set, Delete and their batch forms read and write ctx's tenant's copy of a key, and
+// record the entries they read and write in ctx's Freshness, if any
 func (r *RedisCache) Get(ctx context.Context, key string) ([]byte, error) {
-	val, err := r.client.Get(ctx, key).Result()
+	key = r.scopedKey(ctx, key)
+	if !r.breaker.allow() {
+		r.stats.recordError(key)
+		return nil, nil
+	}
+
+	freshness := freshnessFrom(ctx)
+	var val string
+	var err error
+	var remaining *redis.DurationCmd
+	if freshness != nil {
+		pipe := r.client.Pipeline()
+		get := pipe.Get(ctx, key)
+		remaining = pipe.PTTL(ctx, key)
+		_, _ = pipe.Exec(ctx)
+		val, err = get.Result()
+	} else {
+		val, err = r.client.Get(ctx, key).Result()
+	}
+	r.breaker.record(ignoreNil(err))
 	if err != nil {
 		if err == redis.Nil {
 			// Cache miss - not an error condition
+			r.stats.recordMiss(key)
 			return nil, nil
 		}
 		// Redis error - log warning and return nil to allow graceful degradation
+		r.stats.recordError(key)
 		r.logger.Warn("Redis GET operation failed",
 			zap.String("key", key),
 			zap.Error(err),
@@ -83,14 +258,38 @@ func (r *RedisCache) Get(ctx context.Context, key string) ([]byte, error) {
 		return nil, nil
 	}
 
-	return []byte(val), nil
+	payload, ok := unwrapEnvelope(r.schemaVersion, []byte(val))
+	if !ok {
+		// Written by a different release - treat as a miss so the caller overwrites it
+		r.stats.recordMiss(key)
+		return nil, nil
+	}
+
+	r.stats.recordHit(key, len(payload))
+	if remaining != nil {
+		freshness.observe(remaining.Val())
+	}
+	return payload, nil
 }
 
 // Set stores a value in cache with TTL
 func (r *RedisCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
-	err := r.client.Set(ctx, key, value, ttl).Err()
+	key = r.scopedKey(ctx, key)
+	if r.oversized(key, value) {
+		return nil
+	}
+
+	if !r.breaker.allow() {
+		r.stats.recordError(key)
+		return nil
+	}
+
+	ttl = r.jitterTTL(ttl)
+	err := r.client.Set(ctx, key, wrapEnvelope(r.schemaVersion, value), ttl).Err()
+	r.breaker.record(err)
 	if err != nil {
 		// Log warning but don't fail the operation
+		r.stats.recordError(key)
 		r.logger.Warn("Redis SET operation failed",
 			zap.String("key", key),
 			zap.Duration("ttl", ttl),
@@ -99,13 +298,23 @@ func (r *RedisCache) Set(ctx context.Context, key string, value []byte, ttl time
 		return nil // Graceful degradation
 	}
 
+	r.stats.recordWrite(key, len(value))
+	freshnessFrom(ctx).observe(ttl)
 	return nil
 }
 
 // Delete removes a value from cache
 func (r *RedisCache) Delete(ctx context.Context, key string) error {
+	key = r.scopedKey(ctx, key)
+	if !r.breaker.allow() {
+		r.stats.recordError(key)
+		return nil
+	}
+
 	err := r.client.Del(ctx, key).Err()
+	r.breaker.record(err)
 	if err != nil {
+		r.stats.recordError(key)
 		r.logger.Warn("Redis DELETE operation failed",
 			zap.String("key", key),
 			zap.Error(err),
@@ -116,11 +325,278 @@ func (r *RedisCache) Delete(ctx context.Context, key string) error {
 	return nil
 }
 
+// scanBatchSize is the COUNT hint for SCAN and the number of keys unlinked per call
+const scanBatchSize = 500
+
+// DeleteByPattern removes all keys matching a glob pattern (e.g. "supermarket:*")
+// The key prefix is applied automatically, and in a tenant's context only its keys
+// match. Uses incremental SCAN and UNLINK in batches so large namespaces never block
+// Redis the way KEYS would
+func (r *RedisCache) DeleteByPattern(ctx context.Context, pattern string) (int64, error) {
+	if !r.breaker.allow() {
+		return 0, ErrCircuitOpen
+	}
+
+	var deleted int64
+	for _, match := range r.scanMatches(ctx, pattern) {
+		n, err := r.deleteMatching(ctx, match)
+		deleted += n
+		if err != nil {
+			return deleted, err
+		}
+	}
+	return deleted, nil
+}
+
+// deleteMatching removes all keys matching the full SCAN pattern match
+func (r *RedisCache) deleteMatching(ctx context.Context, match string) (int64, error) {
+	var deleted int64
+	var cursor uint64
+
+	for {
+		keys, next, err := r.client.Scan(ctx, cursor, match, scanBatchSize).Result()
+		r.breaker.record(err)
+		if err != nil {
+			r.logger.Warn("Redis SCAN operation failed",
+				zap.String("pattern", match),
+				zap.Int64("deleted", deleted),
+				zap.Error(err),
+			)
+			return deleted, fmt.Errorf("failed to scan keys matching %s: %w", match, err)
+		}
+
+		if len(keys) > 0 {
+			n, err := r.client.Unlink(ctx, keys...).Result()
+			if err != nil {
+				r.logger.Warn("Redis UNLINK operation failed",
+					zap.String("pattern", match),
+					zap.Int("keys", len(keys)),
+					zap.Error(err),
+				)
+				return deleted, fmt.Errorf("failed to delete keys matching %s: %w", match, err)
+			}
+			deleted += n
+		}
+
+		cursor = next
+		if cursor == 0 {
+			break
+		}
+	}
+
+	r.logger.Info("Deleted cache keys by pattern",
+		zap.String("pattern", match),
+		zap.Int64("deleted", deleted),
+	)
+
+	return deleted, nil
+}
+
+// KeyInfo describes a cached key for debugging
+type KeyInfo struct {
+	Key        string `json:"key"`
+	TTLSeconds int64  `json:"ttl_seconds"` // -1 when the key has no expiry
+	SizeBytes  int64  `json:"size_bytes"`
+}
+
+// InspectKeys returns up to limit keys matching pattern with their remaining TTL and value size
+// The key prefix is applied automatically and stripped from the returned keys; in a
+// tenant's context only its keys match, and are returned without the tenant segment
+func (r *RedisCache) InspectKeys(ctx context.Context, pattern string, limit int) ([]KeyInfo, error) {
+	if !r.breaker.allow() {
+		return nil, ErrCircuitOpen
+	}
+
+	var keys []string
+	for _, match := range r.scanMatches(ctx, pattern) {
+		var cursor uint64
+		for len(keys) < limit {
+			batch, next, err := r.client.Scan(ctx, cursor, match, scanBatchSize).Result()
+			r.breaker.record(err)
+			if err != nil {
+				return nil, fmt.Errorf("failed to scan keys matching %s: %w", match, err)
+			}
+			keys = append(keys, batch...)
+
+			cursor = next
+			if cursor == 0 {
+				break
+			}
+		}
+	}
+	if len(keys) > limit {
+		keys = keys[:limit]
+	}
+
+	infos := make([]KeyInfo, 0, len(keys))
+	if len(keys) == 0 {
+		return infos, nil
+	}
+
+	pipe := r.client.Pipeline()
+	ttlCmds := make([]*redis.DurationCmd, len(keys))
+	sizeCmds := make([]*redis.IntCmd, len(keys))
+	for i, key := range keys {
+		ttlCmds[i] = pipe.PTTL(ctx, key)
+		sizeCmds[i] = pipe.StrLen(ctx, key)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		r.logger.Warn("Redis key inspection pipeline failed", zap.String("pattern", pattern), zap.Error(err))
+	}
+
+	for i, key := range keys {
+		ttl, err := ttlCmds[i].Result()
+		if err != nil || ttl == -2 {
+			// Expired between SCAN and PTTL
+			continue
+		}
+
+		ttlSeconds := int64(-1)
+		if ttl >= 0 {
+			ttlSeconds = int64(ttl.Round(time.Second) / time.Second)
+		}
+
+		infos = append(infos, KeyInfo{
+			Key:        strings.TrimPrefix(key, r.scopePrefix(ctx)),
+			TTLSeconds: ttlSeconds,
+			SizeBytes:  sizeCmds[i].Val(),
+		})
+	}
+
+	return infos, nil
+}
+
+// GetMany retrieves multiple values in a single round trip using MGET
+// Missing keys are omitted from the returned map
+func (r *RedisCache) GetMany(ctx context.Context, keys []string) (map[string][]byte, error) {
+	result := make(map[string][]byte, len(keys))
+	if len(keys) == 0 {
+		return result, nil
+	}
+
+	if !r.breaker.allow() {
+		for _, key := range keys {
+			r.stats.recordError(key)
+		}
+		return result, nil
+	}
+
+	scoped := make([]string, len(keys))
+	for i, key := range keys {
+		scoped[i] = r.scopedKey(ctx, key)
+	}
+
+	freshness := freshnessFrom(ctx)
+	var values []interface{}
+	var err error
+	var remaining []*redis.DurationCmd
+	if freshness != nil {
+		pipe := r.client.Pipeline()
+		mget := pipe.MGet(ctx, scoped...)
+		remaining = make([]*redis.DurationCmd, len(scoped))
+		for i, key := range scoped {
+			remaining[i] = pipe.PTTL(ctx, key)
+		}
+		_, _ = pipe.Exec(ctx)
+		values, err = mget.Result()
+	} else {
+		values, err = r.client.MGet(ctx, scoped...).Result()
+	}
+	r.breaker.record(err)
+	if err != nil {
+		for _, key := range keys {
+			r.stats.recordError(key)
+		}
+		r.logger.Warn("Redis MGET operation failed",
+			zap.Int("keys", len(keys)),
+			zap.Error(err),
+		)
+		return result, nil // Graceful degradation
+	}
+
+	for i, value := range values {
+		str, ok := value.(string)
+		if !ok {
+			r.stats.recordMiss(keys[i])
+			continue
+		}
+		payload, ok := unwrapEnvelope(r.schemaVersion, []byte(str))
+		if !ok {
+			r.stats.recordMiss(keys[i])
+			continue
+		}
+		r.stats.recordHit(keys[i], len(payload))
+		if remaining != nil {
+			freshness.observe(remaining[i].Val())
+		}
+		result[keys[i]] = payload
+	}
+
+	return result, nil
+}
+
+// SetMany stores multiple values with the same TTL using a pipeline
+func (r *RedisCache) SetMany(ctx context.Context, entries map[string][]byte, ttl time.Duration) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	if !r.breaker.allow() {
+		for key := range entries {
+			r.stats.recordError(key)
+		}
+		return nil
+	}
+
+	pipe := r.client.Pipeline()
+	written := make([]string, 0, len(entries)) // Keys in the order their SETs were queued
+	ttls := make([]time.Duration, 0, len(entries))
+	for key, value := range entries {
+		if r.oversized(key, value) {
+			continue
+		}
+		jittered := r.jitterTTL(ttl)
+		pipe.Set(ctx, r.scopedKey(ctx, key), wrapEnvelope(r.schemaVersion, value), jittered)
+		written = append(written, key)
+		ttls = append(ttls, jittered)
+	}
+
+	cmds, err := pipe.Exec(ctx)
+	r.breaker.record(err)
+	if err != nil {
+		r.logger.Warn("Redis pipelined SET operation failed",
+			zap.Int("keys", len(entries)),
+			zap.Duration("ttl", ttl),
+			zap.Error(err),
+		)
+	}
+
+	for i, cmd := range cmds {
+		key := written[i]
+		if cmd.Err() != nil {
+			r.stats.recordError(key)
+			continue
+		}
+		r.stats.recordWrite(key, len(entries[key]))
+		freshnessFrom(ctx).observe(ttls[i])
+	}
+
+	return nil // Graceful degradation
+}
+
+// ignoreNil treats redis.Nil (key not found) as success for breaker accounting
+func ignoreNil(err error) error {
+	if err == redis.Nil {
+		return nil
+	}
+	return err
+}
+
 // GenerateKey creates a consistent cache key from domain and parameters
 // Uses consistent hashing to ensure parameter order doesn't affect the key
 func (r *RedisCache) GenerateKey(domain string, params map[string]string) string {
 	if len(params) == 0 {
-		return domain
+		return r.keyPrefix + domain
 	}
 
 	// Sort parameter keys for consistency
@@ -141,7 +617,12 @@ func (r *RedisCache) GenerateKey(domain string, params map[string]string) string
 	hash := sha256.Sum256([]byte(paramStr))
 	hashStr := fmt.Sprintf("%x", hash[:8]) // Use first 8 bytes of hash
 
-	return fmt.Sprintf("%s:%s", domain, hashStr)
+	return fmt.Sprintf("%s%s:%s", r.keyPrefix, domain, hashStr)
+}
+
+// Stats returns hit, miss, error and byte counters grouped by key domain
+func (r *RedisCache) Stats() map[string]DomainStats {
+	return r.stats.snapshot()
 }
 
 // Close closes the Redis connection
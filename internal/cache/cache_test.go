@@ -2,9 +2,14 @@ package cache
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/yourusername/supabase-redis-middleware/internal/tenant"
 	"go.uber.org/zap"
 )
 
@@ -85,6 +90,320 @@ func TestGenerateKeyConsistency(t *testing.T) {
 	}
 }
 
+func TestGenerateKeyPrefix(t *testing.T) {
+	logger := setupTestLogger()
+	cache := &RedisCache{logger: logger, stats: newStatsRecorder()}
+	cache.SetKeyPrefix("staging")
+
+	if key := cache.GenerateKey("supermarket", nil); key != "staging:supermarket" {
+		t.Errorf("GenerateKey() = %v, want staging:supermarket", key)
+	}
+
+	key := cache.GenerateKey("supermarket", map[string]string{"id": "1"})
+	if key[:len("staging:supermarket:")] != "staging:supermarket:" {
+		t.Errorf("GenerateKey() = %v, want staging:supermarket:<hash> prefix", key)
+	}
+
+	// Stats should be reported under the unprefixed domain
+	cache.stats.recordMiss(key)
+	if _, ok := cache.Stats()["supermarket"]; !ok {
+		t.Errorf("Stats() should strip key prefix from domains, got %v", cache.Stats())
+	}
+
+	if lock := cache.lockKey(key); lock != "staging:lock:"+key[len("staging:"):] {
+		t.Errorf("lockKey() = %v, want prefix applied once", lock)
+	}
+}
+
+func TestScopedKey(t *testing.T) {
+	cache := &RedisCache{logger: setupTestLogger(), stats: newStatsRecorder()}
+	cache.SetKeyPrefix("staging")
+	key := cache.GenerateKey("supermarket", map[string]string{"id": "1"})
+
+	system := context.Background()
+	if got := cache.scopedKey(system, key); got != key {
+		t.Errorf("scopedKey() in a system context = %v, want %v", got, key)
+	}
+
+	acme := tenant.WithID(system, "acme")
+	want := "staging:tenant:acme:" + key[len("staging:"):]
+	if got := cache.scopedKey(acme, key); got != want {
+		t.Errorf("scopedKey() = %v, want %v", got, want)
+	}
+
+	if got := cache.scanMatches(acme, "supermarket:*"); len(got) != 1 || got[0] != "staging:tenant:acme:supermarket:*" {
+		t.Errorf("scanMatches() for a tenant = %v, want only its keys", got)
+	}
+	if got := cache.scanMatches(system, "supermarket:*"); len(got) != 2 || got[1] != "staging:tenant:*:supermarket:*" {
+		t.Errorf("scanMatches() in a system context = %v, want unscoped and every tenant's keys", got)
+	}
+}
+
+func TestRedisCache_TenantScopedKeys(t *testing.T) {
+	logger := setupTestLogger()
+
+	cache, err := NewRedisCache("localhost", "6379", "", 0, logger)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer cache.Close()
+
+	ctx := context.Background()
+
+	if err := cache.client.Ping(ctx).Err(); err != nil {
+		t.Skip("Redis not available, skipping integration test")
+	}
+
+	acme, other := tenant.WithID(ctx, "acme"), tenant.WithID(ctx, "other")
+	key := cache.GenerateKey("tenanttest", map[string]string{"id": "1"})
+	cache.Set(acme, key, []byte("acme"), 10*time.Second)
+	cache.Set(other, key, []byte("other"), 10*time.Second)
+	defer cache.DeleteByPattern(ctx, "tenanttest:*")
+
+	if value, _ := cache.Get(acme, key); string(value) != "acme" {
+		t.Errorf("Get() for acme = %s, want acme", value)
+	}
+	if value, _ := cache.Get(ctx, key); value != nil {
+		t.Errorf("Get() in a system context = %s, want a miss", value)
+	}
+
+	if deleted, _ := cache.DeleteByPattern(acme, "tenanttest:*"); deleted != 1 {
+		t.Errorf("DeleteByPattern() for acme deleted %d keys, want 1", deleted)
+	}
+	if value, _ := cache.Get(other, key); string(value) != "other" {
+		t.Errorf("Get() for other after acme's invalidation = %s, want other", value)
+	}
+
+	if deleted, _ := cache.DeleteByPattern(ctx, "tenanttest:*"); deleted != 1 {
+		t.Errorf("DeleteByPattern() in a system context deleted %d keys, want 1", deleted)
+	}
+}
+
+func TestFreshness(t *testing.T) {
+	ctx, freshness := WithFreshness(context.Background())
+	if _, ok := freshness.MaxAge(); ok {
+		t.Fatal("MaxAge() reported an entry before any was recorded")
+	}
+
+	freshnessFrom(ctx).observe(time.Minute)
+	freshnessFrom(ctx).observe(-1) // No expiry
+	freshnessFrom(ctx).observe(30 * time.Second)
+	freshnessFrom(ctx).observe(2 * time.Minute)
+	if got, ok := freshness.MaxAge(); !ok || got != 30*time.Second {
+		t.Errorf("MaxAge() = %v, %v, want 30s, true", got, ok)
+	}
+
+	MarkStale(ctx)
+	if got, ok := freshness.MaxAge(); !ok || got != 0 {
+		t.Errorf("MaxAge() after MarkStale = %v, %v, want 0, true", got, ok)
+	}
+
+	// Without a Freshness nothing is recorded
+	MarkStale(context.Background())
+	freshnessFrom(context.Background()).observe(time.Minute)
+}
+
+func TestRedisCache_Freshness(t *testing.T) {
+	logger := setupTestLogger()
+
+	cache, err := NewRedisCache("localhost", "6379", "", 0, logger)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer cache.Close()
+
+	if err := cache.client.Ping(context.Background()).Err(); err != nil {
+		t.Skip("Redis not available, skipping integration test")
+	}
+
+	short := cache.GenerateKey("freshnesstest", map[string]string{"id": "1"})
+	long := cache.GenerateKey("freshnesstest", map[string]string{"id": "2"})
+	cache.Set(context.Background(), short, []byte("short"), 10*time.Second)
+	cache.Set(context.Background(), long, []byte("long"), time.Minute)
+	defer cache.DeleteByPattern(context.Background(), "freshnesstest:*")
+
+	ctx, freshness := WithFreshness(context.Background())
+	if value, _ := cache.Get(ctx, long); string(value) != "long" {
+		t.Fatalf("Get() = %s, want long", value)
+	}
+	if got, ok := freshness.MaxAge(); !ok || got <= 10*time.Second || got > time.Minute {
+		t.Errorf("MaxAge() after Get() = %v, %v, want up to 1m", got, ok)
+	}
+
+	cache.GetMany(ctx, []string{short, long})
+	if got, ok := freshness.MaxAge(); !ok || got > 10*time.Second {
+		t.Errorf("MaxAge() after GetMany() = %v, %v, want up to 10s", got, ok)
+	}
+
+	ctx, freshness = WithFreshness(context.Background())
+	cache.Set(ctx, short, []byte("short"), 5*time.Second)
+	if got, ok := freshness.MaxAge(); !ok || got != 5*time.Second {
+		t.Errorf("MaxAge() after Set() = %v, %v, want 5s, true", got, ok)
+	}
+}
+
+func TestJitterTTL(t *testing.T) {
+	cache := &RedisCache{logger: setupTestLogger()}
+
+	if got := cache.jitterTTL(time.Minute); got != time.Minute {
+		t.Errorf("jitterTTL() without jitter = %v, want %v", got, time.Minute)
+	}
+
+	cache.SetTTLJitter(0.1)
+	ttl := 100 * time.Second
+	varied := false
+	for i := 0; i < 100; i++ {
+		got := cache.jitterTTL(ttl)
+		if got < 90*time.Second || got > 110*time.Second {
+			t.Fatalf("jitterTTL() = %v, want within ±10%% of %v", got, ttl)
+		}
+		if got != ttl {
+			varied = true
+		}
+	}
+	if !varied {
+		t.Error("jitterTTL() should vary the TTL")
+	}
+}
+
+func TestEnvelope(t *testing.T) {
+	payload := []byte(`{"id":"1"}`)
+	wrapped := wrapEnvelope(2, payload)
+
+	got, ok := unwrapEnvelope(2, wrapped)
+	if !ok || string(got) != string(payload) {
+		t.Errorf("unwrapEnvelope() = %v, %v, want %v, true", string(got), ok, string(payload))
+	}
+
+	if _, ok := unwrapEnvelope(3, wrapped); ok {
+		t.Error("unwrapEnvelope() should reject a different schema version")
+	}
+
+	// Raw JSON cached before versioning must be treated as a miss
+	if _, ok := unwrapEnvelope(2, payload); ok {
+		t.Error("unwrapEnvelope() should reject unversioned values")
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	breaker := newCircuitBreaker(3, 50*time.Millisecond, setupTestLogger())
+	failure := errors.New("dial tcp: connection refused")
+
+	for i := 0; i < 2; i++ {
+		breaker.record(failure)
+	}
+	if !breaker.allow() {
+		t.Fatal("breaker should stay closed below the threshold")
+	}
+
+	breaker.record(failure)
+	if breaker.allow() {
+		t.Fatal("breaker should open after reaching the threshold")
+	}
+	if breaker.currentState() != "open" {
+		t.Errorf("currentState() = %v, want open", breaker.currentState())
+	}
+
+	time.Sleep(60 * time.Millisecond)
+
+	// Exactly one probe is allowed after the cool-down
+	if !breaker.allow() {
+		t.Fatal("breaker should allow a probe after the cool-down")
+	}
+	if breaker.allow() {
+		t.Fatal("breaker should allow only one concurrent probe")
+	}
+
+	// A failed probe reopens immediately
+	breaker.record(failure)
+	if breaker.allow() {
+		t.Fatal("breaker should reopen after a failed probe")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	breaker.allow()
+	breaker.record(nil)
+	if breaker.currentState() != "closed" || !breaker.allow() {
+		t.Errorf("breaker should close after a successful probe, got %v", breaker.currentState())
+	}
+}
+
+func TestCircuitBreaker_IgnoresContextErrors(t *testing.T) {
+	breaker := newCircuitBreaker(2, 50*time.Millisecond, setupTestLogger())
+	canceled := fmt.Errorf("redis get: %w", context.Canceled)
+
+	for i := 0; i < 5; i++ {
+		breaker.record(canceled)
+		breaker.record(context.DeadlineExceeded)
+	}
+	if breaker.currentState() != "closed" || !breaker.allow() {
+		t.Fatalf("breaker should stay closed on canceled calls, got %v", breaker.currentState())
+	}
+
+	// Abandoned calls don't reset the count of real failures either
+	failure := errors.New("dial tcp: connection refused")
+	breaker.record(failure)
+	breaker.record(canceled)
+	breaker.record(failure)
+	if breaker.currentState() != "open" {
+		t.Fatalf("currentState() = %v, want open", breaker.currentState())
+	}
+
+	// An abandoned probe frees the slot for the next
+	time.Sleep(60 * time.Millisecond)
+	if !breaker.allow() {
+		t.Fatal("breaker should allow a probe after the cool-down")
+	}
+	breaker.record(context.DeadlineExceeded)
+	if breaker.currentState() != "half-open" || !breaker.allow() {
+		t.Errorf("breaker should allow another probe after an abandoned one, got %v", breaker.currentState())
+	}
+}
+
+func TestPoolOptionsWithDefaults(t *testing.T) {
+	got := PoolOptions{PoolSize: 50, MaxRetries: -1}.withDefaults()
+	want := DefaultPoolOptions()
+	want.PoolSize = 50
+	want.MinIdleConns = 0
+	want.MaxRetries = -1
+
+	if got != want {
+		t.Errorf("withDefaults() = %+v, want %+v", got, want)
+	}
+
+	if got := DefaultPoolOptions().withDefaults(); got != DefaultPoolOptions() {
+		t.Errorf("withDefaults() of the defaults = %+v, want them unchanged", got)
+	}
+
+	explicit := PoolOptions{PoolSize: 20, MinIdleConns: 0, MaxRetries: 0}.withDefaults()
+	if explicit.MinIdleConns != 0 {
+		t.Errorf("withDefaults() MinIdleConns = %d, want an explicit 0 kept", explicit.MinIdleConns)
+	}
+	if explicit.MaxRetries != -1 {
+		t.Errorf("withDefaults() MaxRetries = %d, want an explicit 0 to make no retries", explicit.MaxRetries)
+	}
+}
+
+func TestRedisCache_MaxValueSize(t *testing.T) {
+	cache := &RedisCache{logger: setupTestLogger(), stats: newStatsRecorder()}
+	cache.SetMaxValueSize(4)
+
+	if cache.oversized("supermarket:a", []byte("1234")) {
+		t.Error("oversized() should allow values at the limit")
+	}
+	if !cache.oversized("supermarket:a", []byte("12345")) {
+		t.Error("oversized() should reject values over the limit")
+	}
+	if got := cache.Stats()["supermarket"].Oversized; got != 1 {
+		t.Errorf("Stats() oversized = %d, want 1", got)
+	}
+
+	// Oversized writes never reach Redis, so no client is needed
+	if err := cache.Set(context.Background(), "supermarket:a", []byte("12345"), time.Second); err != nil {
+		t.Errorf("Set() with oversized value error = %v", err)
+	}
+}
+
 func TestRedisCache_GetSetDelete(t *testing.T) {
 	logger := setupTestLogger()
 	
@@ -193,6 +512,503 @@ func TestRedisCache_GracefulDegradation(t *testing.T) {
 	if err != nil {
 		t.Errorf("Delete() should not fail with unavailable Redis, got error: %v", err)
 	}
+
+	err = cache.SetMany(ctx, map[string][]byte{testKey: testValue}, 10*time.Second)
+	if err != nil {
+		t.Errorf("SetMany() should not fail with unavailable Redis, got error: %v", err)
+	}
+
+	results, err := cache.GetMany(ctx, []string{testKey})
+	if err != nil {
+		t.Errorf("GetMany() should not fail with unavailable Redis, got error: %v", err)
+	}
+	if len(results) != 0 {
+		t.Errorf("GetMany() with unavailable Redis should return no entries, got: %v", results)
+	}
+}
+
+func TestRedisCache_GetManySetMany(t *testing.T) {
+	logger := setupTestLogger()
+
+	cache, err := NewRedisCache("localhost", "6379", "", 0, logger)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer cache.Close()
+
+	ctx := context.Background()
+
+	if err := cache.client.Ping(ctx).Err(); err != nil {
+		t.Skip("Redis not available, skipping integration test")
+	}
+
+	entries := map[string][]byte{
+		"test:many:1": []byte("one"),
+		"test:many:2": []byte("two"),
+	}
+
+	if err := cache.SetMany(ctx, entries, 10*time.Second); err != nil {
+		t.Errorf("SetMany() error = %v", err)
+	}
+
+	result, err := cache.GetMany(ctx, []string{"test:many:1", "test:many:missing", "test:many:2"})
+	if err != nil {
+		t.Errorf("GetMany() error = %v", err)
+	}
+	if len(result) != 2 {
+		t.Errorf("GetMany() returned %d entries, want 2", len(result))
+	}
+	if string(result["test:many:2"]) != "two" {
+		t.Errorf("GetMany() test:many:2 = %v, want two", string(result["test:many:2"]))
+	}
+	if _, ok := result["test:many:missing"]; ok {
+		t.Error("GetMany() should omit missing keys")
+	}
+
+	for key := range entries {
+		cache.Delete(ctx, key)
+	}
+}
+
+func TestRedisCache_GetOrSet(t *testing.T) {
+	logger := setupTestLogger()
+
+	cache, err := NewRedisCache("localhost", "6379", "", 0, logger)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer cache.Close()
+
+	ctx := context.Background()
+
+	if err := cache.client.Ping(ctx).Err(); err != nil {
+		t.Skip("Redis not available, skipping integration test")
+	}
+
+	testKey := "test:getorset:key"
+	defer cache.Delete(ctx, testKey)
+
+	var calls int32
+	loader := func() ([]byte, error) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(100 * time.Millisecond)
+		return []byte("loaded"), nil
+	}
+
+	// Concurrent callers should share a single loader invocation
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, err := cache.GetOrSet(ctx, testKey, 10*time.Second, loader)
+			if err != nil {
+				t.Errorf("GetOrSet() error = %v", err)
+			}
+			if string(value) != "loaded" {
+				t.Errorf("GetOrSet() = %v, want loaded", string(value))
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("loader called %d times, want 1", got)
+	}
+}
+
+func TestRedisCache_GetOrSetLoaderError(t *testing.T) {
+	logger := setupTestLogger()
+
+	cache, err := NewRedisCache("invalid-host", "9999", "", 0, logger)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer cache.Close()
+
+	loaderErr := errors.New("upstream failed")
+	_, err = cache.GetOrSet(context.Background(), "test:key", time.Second, func() ([]byte, error) {
+		return nil, loaderErr
+	})
+	if !errors.Is(err, loaderErr) {
+		t.Errorf("GetOrSet() error = %v, want %v", err, loaderErr)
+	}
+}
+
+func TestRedisCache_AcquireReleaseLock(t *testing.T) {
+	logger := setupTestLogger()
+
+	cache, err := NewRedisCache("localhost", "6379", "", 0, logger)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer cache.Close()
+
+	ctx := context.Background()
+
+	if err := cache.client.Ping(ctx).Err(); err != nil {
+		t.Skip("Redis not available, skipping integration test")
+	}
+
+	lock, err := cache.AcquireLock(ctx, "test:lock", 5*time.Second)
+	if err != nil {
+		t.Fatalf("AcquireLock() error = %v", err)
+	}
+
+	if _, err := cache.AcquireLock(ctx, "test:lock", 5*time.Second); !errors.Is(err, ErrLockNotAcquired) {
+		t.Errorf("second AcquireLock() error = %v, want ErrLockNotAcquired", err)
+	}
+
+	// A foreign token must not release the lock
+	foreign := &Lock{Name: lock.Name, Token: "someone-else"}
+	if err := cache.ReleaseLock(ctx, foreign); !errors.Is(err, ErrLockNotHeld) {
+		t.Errorf("ReleaseLock() with foreign token error = %v, want ErrLockNotHeld", err)
+	}
+
+	if err := cache.ReleaseLock(ctx, lock); err != nil {
+		t.Errorf("ReleaseLock() error = %v", err)
+	}
+
+	relocked, err := cache.AcquireLock(ctx, "test:lock", 5*time.Second)
+	if err != nil {
+		t.Errorf("AcquireLock() after release error = %v", err)
+	}
+	cache.ReleaseLock(ctx, relocked)
+}
+
+func TestRedisCache_AcquireLockUnavailable(t *testing.T) {
+	logger := setupTestLogger()
+
+	cache, err := NewRedisCache("invalid-host", "9999", "", 0, logger)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer cache.Close()
+
+	// Locks must not degrade silently when Redis is down
+	_, err = cache.AcquireLock(context.Background(), "test:lock", time.Second)
+	if err == nil || errors.Is(err, ErrLockNotAcquired) {
+		t.Errorf("AcquireLock() with unavailable Redis error = %v, want connection error", err)
+	}
+}
+
+func TestRedisCache_Stream(t *testing.T) {
+	logger := setupTestLogger()
+
+	cache, err := NewRedisCache("localhost", "6379", "", 0, logger)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer cache.Close()
+
+	ctx := context.Background()
+
+	if err := cache.client.Ping(ctx).Err(); err != nil {
+		t.Skip("Redis not available, skipping integration test")
+	}
+
+	stream := "test:events"
+	cache.client.Del(ctx, cache.streamKey(stream))
+	defer cache.client.Del(ctx, cache.streamKey(stream))
+
+	if err := cache.EnsureGroup(ctx, stream, "workers"); err != nil {
+		t.Fatalf("EnsureGroup() error = %v", err)
+	}
+	// Creating an existing group is a no-op
+	if err := cache.EnsureGroup(ctx, stream, "workers"); err != nil {
+		t.Errorf("second EnsureGroup() error = %v", err)
+	}
+
+	id, err := cache.Publish(ctx, stream, map[string]interface{}{"type": "import"})
+	if err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	messages, err := cache.ReadGroup(ctx, stream, "workers", "a", 10, 100*time.Millisecond)
+	if err != nil {
+		t.Fatalf("ReadGroup() error = %v", err)
+	}
+	if len(messages) != 1 || messages[0].ID != id || messages[0].Values["type"] != "import" {
+		t.Fatalf("ReadGroup() = %+v, want message %s", messages, id)
+	}
+
+	// Unacknowledged messages can be claimed by another consumer
+	claimed, err := cache.ClaimPending(ctx, stream, "workers", "b", 0, 10)
+	if err != nil {
+		t.Fatalf("ClaimPending() error = %v", err)
+	}
+	if len(claimed) != 1 || claimed[0].ID != id {
+		t.Errorf("ClaimPending() = %+v, want message %s", claimed, id)
+	}
+
+	if err := cache.Ack(ctx, stream, "workers", id); err != nil {
+		t.Errorf("Ack() error = %v", err)
+	}
+
+	claimed, err = cache.ClaimPending(ctx, stream, "workers", "b", 0, 10)
+	if err != nil {
+		t.Fatalf("ClaimPending() after ack error = %v", err)
+	}
+	if len(claimed) != 0 {
+		t.Errorf("ClaimPending() after ack = %+v, want none", claimed)
+	}
+
+	empty, err := cache.ReadGroup(ctx, stream, "workers", "a", 10, 10*time.Millisecond)
+	if err != nil || len(empty) != 0 {
+		t.Errorf("ReadGroup() on drained stream = %+v, %v, want empty", empty, err)
+	}
+}
+
+func TestRedisCache_EventLog(t *testing.T) {
+	logger := setupTestLogger()
+
+	cache, err := NewRedisCache("localhost", "6379", "", 0, logger)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer cache.Close()
+
+	ctx := context.Background()
+
+	if err := cache.client.Ping(ctx).Err(); err != nil {
+		t.Skip("Redis not available, skipping integration test")
+	}
+
+	stream := "test:log"
+	cache.client.Del(ctx, cache.streamKey(stream))
+	defer cache.client.Del(ctx, cache.streamKey(stream))
+
+	var ids []string
+	for _, kind := range []string{"opened", "closed", "opened"} {
+		id, err := cache.Append(ctx, stream, 100, map[string]interface{}{"type": kind})
+		if err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+		ids = append(ids, id)
+	}
+
+	messages, err := cache.ReadAfter(ctx, stream, ids[0], 10)
+	if err != nil {
+		t.Fatalf("ReadAfter() error = %v", err)
+	}
+	if len(messages) != 2 || messages[0].ID != ids[1] || messages[1].Values["type"] != "opened" {
+		t.Errorf("ReadAfter() = %+v, want the events after %s", messages, ids[0])
+	}
+
+	messages, err = cache.ReadAfter(ctx, stream, ids[2], 10)
+	if err != nil || len(messages) != 0 {
+		t.Errorf("ReadAfter() the last event = %+v, %v, want none", messages, err)
+	}
+}
+
+func TestRedisCache_DelayQueue(t *testing.T) {
+	logger := setupTestLogger()
+
+	cache, err := NewRedisCache("localhost", "6379", "", 0, logger)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer cache.Close()
+
+	ctx := context.Background()
+
+	if err := cache.client.Ping(ctx).Err(); err != nil {
+		t.Skip("Redis not available, skipping integration test")
+	}
+
+	queue := "test:retries"
+	cache.client.Del(ctx, cache.delayKey(queue))
+	defer cache.client.Del(ctx, cache.delayKey(queue))
+
+	now := time.Now()
+	for item, at := range map[string]time.Time{"later": now.Add(time.Hour), "second": now.Add(-time.Second), "first": now.Add(-time.Minute)} {
+		if err := cache.ScheduleAt(ctx, queue, item, at); err != nil {
+			t.Fatalf("ScheduleAt(%s) error = %v", item, err)
+		}
+	}
+
+	items, err := cache.PopDue(ctx, queue, now, 10)
+	if err != nil {
+		t.Fatalf("PopDue() error = %v", err)
+	}
+	if len(items) != 2 || items[0] != "first" || items[1] != "second" {
+		t.Errorf("PopDue() = %v, want [first second]", items)
+	}
+
+	// Popped items are gone; items not yet due stay
+	items, err = cache.PopDue(ctx, queue, now, 10)
+	if err != nil || len(items) != 0 {
+		t.Errorf("PopDue() again = %v, %v, want none", items, err)
+	}
+	items, err = cache.PopDue(ctx, queue, now.Add(2*time.Hour), 10)
+	if err != nil || len(items) != 1 || items[0] != "later" {
+		t.Errorf("PopDue() later = %v, %v, want [later]", items, err)
+	}
+}
+
+func TestRedisCache_Broadcast(t *testing.T) {
+	logger := setupTestLogger()
+
+	cache, err := NewRedisCache("localhost", "6379", "", 0, logger)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer cache.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	if err := cache.client.Ping(ctx).Err(); err != nil {
+		t.Skip("Redis not available, skipping integration test")
+	}
+
+	sub := cache.PSubscribe(ctx, "test:store:*")
+	defer sub.Close()
+	// Wait for the subscription to be confirmed, so the message isn't sent before it
+	if _, err := sub.pubsub.Receive(ctx); err != nil {
+		t.Fatalf("subscribe error = %v", err)
+	}
+
+	if err := cache.Broadcast(ctx, "test:store:1", []byte(`{"type":"stock"}`)); err != nil {
+		t.Fatalf("Broadcast() error = %v", err)
+	}
+
+	msg, err := sub.Receive(ctx)
+	if err != nil {
+		t.Fatalf("Receive() error = %v", err)
+	}
+	if msg.Channel != "test:store:1" || string(msg.Payload) != `{"type":"stock"}` {
+		t.Errorf("Receive() = %s %s, want test:store:1 and the message", msg.Channel, msg.Payload)
+	}
+}
+
+func TestRefresher_RefreshesHotKeys(t *testing.T) {
+	logger := setupTestLogger()
+
+	cache, err := NewRedisCache("localhost", "6379", "", 0, logger)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer cache.Close()
+
+	ctx := context.Background()
+
+	if err := cache.client.Ping(ctx).Err(); err != nil {
+		t.Skip("Redis not available, skipping integration test")
+	}
+
+	refresher := NewRefresher(cache, RefresherOptions{Interval: time.Second, MinHits: 2, MaxKeys: 10}, logger)
+	key := "test:refresh:hot"
+	loads := 0
+	refresh := func(ctx context.Context) error {
+		loads++
+		return cache.Set(ctx, key, []byte("fresh"), time.Minute)
+	}
+
+	// Accesses land in the previous window from the refresher's point of view
+	previous := refresher.windowKey(time.Now().Add(-time.Second))
+	cache.client.ZIncrBy(ctx, previous, 3, key)
+	defer cache.client.Del(ctx, previous, key)
+	refresher.Track(ctx, key, refresh)
+
+	// Key about to expire is refreshed
+	cache.Set(ctx, key, []byte("stale"), 500*time.Millisecond)
+	refresher.refreshHotKeys(ctx)
+
+	if loads != 1 {
+		t.Fatalf("loader called %d times, want 1", loads)
+	}
+	if value, _ := cache.Get(ctx, key); string(value) != "fresh" {
+		t.Errorf("Get() after refresh = %s, want fresh", value)
+	}
+
+	// Freshly refreshed key is left alone
+	refresher.refreshHotKeys(ctx)
+	if loads != 1 {
+		t.Errorf("loader called %d times after second cycle, want 1", loads)
+	}
+}
+
+func TestRedisCache_PublishUnavailable(t *testing.T) {
+	logger := setupTestLogger()
+
+	cache, err := NewRedisCache("invalid-host", "9999", "", 0, logger)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer cache.Close()
+
+	// Queue operations must not degrade silently when Redis is down
+	if _, err := cache.Publish(context.Background(), "test:events", map[string]interface{}{"a": 1}); err == nil {
+		t.Error("Publish() with unavailable Redis should return an error")
+	}
+}
+
+func TestRedisCache_DeleteByPattern(t *testing.T) {
+	logger := setupTestLogger()
+
+	cache, err := NewRedisCache("localhost", "6379", "", 0, logger)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer cache.Close()
+
+	ctx := context.Background()
+
+	if err := cache.client.Ping(ctx).Err(); err != nil {
+		t.Skip("Redis not available, skipping integration test")
+	}
+
+	cache.SetKeyPrefix("testprefix")
+	for i := 0; i < 3; i++ {
+		cache.Set(ctx, cache.GenerateKey("patterntest", map[string]string{"id": fmt.Sprint(i)}), []byte("x"), 10*time.Second)
+	}
+	keep := cache.GenerateKey("otherdomain", map[string]string{"id": "1"})
+	cache.Set(ctx, keep, []byte("x"), 10*time.Second)
+	defer cache.Delete(ctx, keep)
+
+	deleted, err := cache.DeleteByPattern(ctx, "patterntest:*")
+	if err != nil {
+		t.Fatalf("DeleteByPattern() error = %v", err)
+	}
+	if deleted != 3 {
+		t.Errorf("DeleteByPattern() deleted %d keys, want 3", deleted)
+	}
+
+	if value, _ := cache.Get(ctx, keep); value == nil {
+		t.Error("DeleteByPattern() should not delete keys outside the pattern")
+	}
+}
+
+func TestRedisCache_InspectKeys(t *testing.T) {
+	logger := setupTestLogger()
+
+	cache, err := NewRedisCache("localhost", "6379", "", 0, logger)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer cache.Close()
+
+	ctx := context.Background()
+
+	if err := cache.client.Ping(ctx).Err(); err != nil {
+		t.Skip("Redis not available, skipping integration test")
+	}
+
+	key := cache.GenerateKey("inspecttest", map[string]string{"id": "1"})
+	cache.Set(ctx, key, []byte("hello"), 30*time.Second)
+	defer cache.Delete(ctx, key)
+
+	infos, err := cache.InspectKeys(ctx, "inspecttest:*", 10)
+	if err != nil {
+		t.Fatalf("InspectKeys() error = %v", err)
+	}
+	if len(infos) != 1 {
+		t.Fatalf("InspectKeys() returned %d keys, want 1", len(infos))
+	}
+	if infos[0].Key != key || infos[0].TTLSeconds <= 0 || infos[0].SizeBytes == 0 {
+		t.Errorf("InspectKeys() = %+v, want key %s with positive TTL and size", infos[0], key)
+	}
 }
 
 func TestRedisCache_TTL(t *testing.T) {
@@ -266,3 +1082,49 @@ func TestMarshalUnmarshalJSON(t *testing.T) {
 		t.Errorf("UnmarshalJSON() = %v, want %v", result, original)
 	}
 }
+
+func TestStatsRecorder(t *testing.T) {
+	stats := newStatsRecorder()
+
+	stats.recordHit("supermarket:abc", 100)
+	stats.recordHit("supermarket:def", 50)
+	stats.recordMiss("supermarket:ghi")
+	stats.recordWrite("supermarket:ghi", 70)
+	stats.recordError("pharmacy:xyz")
+
+	snapshot := stats.snapshot()
+
+	supermarket, ok := snapshot["supermarket"]
+	if !ok {
+		t.Fatal("snapshot() should contain supermarket domain")
+	}
+	if supermarket.Hits != 2 || supermarket.Misses != 1 {
+		t.Errorf("supermarket hits/misses = %d/%d, want 2/1", supermarket.Hits, supermarket.Misses)
+	}
+	if supermarket.BytesRead != 150 || supermarket.BytesWritten != 70 {
+		t.Errorf("supermarket bytes read/written = %d/%d, want 150/70", supermarket.BytesRead, supermarket.BytesWritten)
+	}
+	if supermarket.HitRatio < 0.66 || supermarket.HitRatio > 0.67 {
+		t.Errorf("supermarket hit ratio = %v, want ~0.667", supermarket.HitRatio)
+	}
+
+	pharmacy := snapshot["pharmacy"]
+	if pharmacy.Errors != 1 || pharmacy.HitRatio != 0 {
+		t.Errorf("pharmacy errors/ratio = %d/%v, want 1/0", pharmacy.Errors, pharmacy.HitRatio)
+	}
+}
+
+func TestKeyDomain(t *testing.T) {
+	tests := map[string]string{
+		"supermarket:abc123": "supermarket",
+		"movies":             "movies",
+		"health:check:redis": "health",
+		"tenant:acme:nearby": "nearby",
+	}
+
+	for key, want := range tests {
+		if got := keyDomain(key); got != want {
+			t.Errorf("keyDomain(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
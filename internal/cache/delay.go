@@ -0,0 +1,64 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// DelayQueue holds items until a time, on a Redis sorted set scored by that time
+// Like queues, it returns Redis failures rather than degrading silently
+type DelayQueue interface {
+	ScheduleAt(ctx context.Context, queue, item string, at time.Time) error
+	PopDue(ctx context.Context, queue string, now time.Time, count int64) ([]string, error)
+}
+
+// popDueScript removes and returns up to ARGV[2] items due by ARGV[1], earliest first, so
+// each item is popped by one caller however many poll the queue
+var popDueScript = redis.NewScript(`
+local items = redis.call("ZRANGEBYSCORE", KEYS[1], "-inf", ARGV[1], "LIMIT", 0, ARGV[2])
+if #items > 0 then
+	redis.call("ZREM", KEYS[1], unpack(items))
+end
+return items
+`)
+
+// ScheduleAt adds item to queue, due at at; scheduling an item already queued moves it
+func (r *RedisCache) ScheduleAt(ctx context.Context, queue, item string, at time.Time) error {
+	if !r.breaker.allow() {
+		return ErrCircuitOpen
+	}
+
+	err := r.client.ZAdd(ctx, r.delayKey(queue), redis.Z{Score: float64(at.UnixMilli()), Member: item}).Err()
+	r.breaker.record(err)
+	if err != nil {
+		return fmt.Errorf("failed to schedule on %s: %w", queue, err)
+	}
+
+	return nil
+}
+
+// PopDue removes and returns up to count items of queue due by now, earliest first
+func (r *RedisCache) PopDue(ctx context.Context, queue string, now time.Time, count int64) ([]string, error) {
+	if !r.breaker.allow() {
+		return nil, ErrCircuitOpen
+	}
+
+	items, err := popDueScript.Run(ctx, r.client, []string{r.delayKey(queue)},
+		strconv.FormatInt(now.UnixMilli(), 10), count).StringSlice()
+	r.breaker.record(ignoreNil(err))
+	if err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("failed to pop due items of %s: %w", queue, err)
+	}
+
+	return items, nil
+}
+
+// delayKey returns the Redis key used to store a named delay queue
+func (r *RedisCache) delayKey(name string) string {
+	return r.keyPrefix + "delayed:" + strings.TrimPrefix(name, r.keyPrefix)
+}
@@ -0,0 +1,23 @@
+package cache
+
+// envelopeMagic marks a value written with a schema version header
+// Values cached before versioning (raw JSON) never start with this byte
+const envelopeMagic byte = 0xCE
+
+// DefaultSchemaVersion is used until SetSchemaVersion is called
+const DefaultSchemaVersion = 1
+
+// wrapEnvelope prefixes value with the magic byte and schema version
+func wrapEnvelope(version byte, value []byte) []byte {
+	wrapped := make([]byte, 0, len(value)+2)
+	wrapped = append(wrapped, envelopeMagic, version)
+	return append(wrapped, value...)
+}
+
+// unwrapEnvelope returns the payload if value carries the expected schema version
+func unwrapEnvelope(version byte, value []byte) ([]byte, bool) {
+	if len(value) < 2 || value[0] != envelopeMagic || value[1] != version {
+		return nil, false
+	}
+	return value[2:], true
+}
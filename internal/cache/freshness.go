@@ -0,0 +1,67 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type freshnessKey struct{}
+
+// Freshness records how long the cache entries read and written for a request stay
+// fresh, so its response can tell HTTP caches how long they may reuse it
+type Freshness struct {
+	mu        sync.Mutex
+	remaining time.Duration
+	recorded  bool
+	stale     bool
+}
+
+// WithFreshness returns ctx with a Freshness recording the entries Get, Set and their
+// batch forms read and write with it. Reads with it also ask Redis for the time left
+// on each entry found, in the same round trip
+func WithFreshness(ctx context.Context) (context.Context, *Freshness) {
+	f := &Freshness{}
+	return context.WithValue(ctx, freshnessKey{}, f), f
+}
+
+// MarkStale records that the response to the request of ctx serves an expired copy, one
+// HTTP caches must not reuse. Does nothing without a Freshness
+func MarkStale(ctx context.Context) {
+	if f := freshnessFrom(ctx); f != nil {
+		f.mu.Lock()
+		f.stale = true
+		f.mu.Unlock()
+	}
+}
+
+// freshnessFrom returns the Freshness attached to ctx, or nil
+func freshnessFrom(ctx context.Context) *Freshness {
+	f, _ := ctx.Value(freshnessKey{}).(*Freshness)
+	return f
+}
+
+// observe records an entry with ttl left before it expires; negative TTLs, Redis's
+// answer for keys without an expiry or that no longer exist, are ignored
+func (f *Freshness) observe(ttl time.Duration) {
+	if f == nil || ttl < 0 {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.recorded || ttl < f.remaining {
+		f.remaining = ttl
+	}
+	f.recorded = true
+}
+
+// MaxAge returns the shortest time left on the entries recorded, or zero if the response
+// serves a stale copy, reporting whether any entry was recorded
+func (f *Freshness) MaxAge() (time.Duration, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.stale {
+		return 0, true
+	}
+	return f.remaining, f.recorded
+}
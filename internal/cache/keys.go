@@ -0,0 +1,67 @@
+package cache
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+// Cache domains shared by read paths and write-side invalidation
+const (
+	DomainSupermarket = "supermarket"
+	DomainPharmacy    = "pharmacy"
+	DomainProducts    = "products"
+	DomainSearch      = "search"
+	DomainCategories  = "categories"
+	DomainLookup      = "lookup"
+	DomainNearby      = "nearby"
+	// DomainAPIKeys holds API keys by hash, for validating callers without a query
+	DomainAPIKeys = "apikey"
+	// DomainPushes holds the fingerprint of each store's last applied product push
+	DomainPushes = "push"
+	// DomainIdempotency holds responses stored for replay to retries with an Idempotency-Key
+	DomainIdempotency = "idempotency"
+	// DomainStores holds every StoreDomain; clearing it clears all stores
+	DomainStores = "store"
+	// DomainRPC holds every RPCDomain; clearing it clears all database function results
+	DomainRPC = "rpc"
+)
+
+// StoreDomain returns the cache domain for data scoped to a single store
+// Keys generated under it look like store:<id>:<hash>, so one pattern clears a store
+func StoreDomain(storeID string) string {
+	return DomainStores + ":" + storeID
+}
+
+// RPCDomain returns the cache domain for the results of a database function
+func RPCDomain(fn string) string {
+	return DomainRPC + ":" + fn
+}
+
+// DomainPattern returns the glob matching every parameterised key in a domain
+// The bare domain key (generated without params) must be deleted separately
+func DomainPattern(domain string) string {
+	return domain + ":*"
+}
+
+// InvalidateDomains clears every cached key in the given domains
+// Failures are logged but never fail the write that triggered them; entries
+// still expire on their own TTL
+func InvalidateDomains(ctx context.Context, cacheService CacheService, logger *zap.Logger, domains ...string) {
+	if cacheService == nil {
+		return
+	}
+
+	for _, domain := range domains {
+		if domain == "" {
+			continue
+		}
+
+		_ = cacheService.Delete(ctx, cacheService.GenerateKey(domain, nil))
+		if _, err := cacheService.DeleteByPattern(ctx, DomainPattern(domain)); err != nil {
+			logger.Warn("Failed to invalidate cache domain",
+				zap.String("domain", domain),
+				zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// loaderLockTTL bounds how long a single instance may hold the loader lock
+	loaderLockTTL = 10 * time.Second
+
+	// loaderPollInterval is how often waiting instances re-check the cache
+	loaderPollInterval = 50 * time.Millisecond
+)
+
+// GetOrSet returns the cached value for key, running loader on a miss
+// Only one instance across the fleet runs the loader for a given key at a time;
+// the others poll the cache until the value appears or the lock expires
+func (r *RedisCache) GetOrSet(ctx context.Context, key string, ttl time.Duration, loader func() ([]byte, error)) ([]byte, error) {
+	if value, _ := r.Get(ctx, key); value != nil {
+		return value, nil
+	}
+
+	lock, err := r.AcquireLock(ctx, key, loaderLockTTL)
+	if err == nil {
+		defer r.releaseLoaderLock(context.WithoutCancel(ctx), lock)
+		return r.loadAndSet(ctx, key, ttl, loader)
+	}
+	if !errors.Is(err, ErrLockNotAcquired) {
+		// Redis unavailable - load directly without coordination
+		r.logger.Warn("Redis lock acquisition failed, loading without lock",
+			zap.String("key", key),
+			zap.Error(err),
+		)
+		return r.loadAndSet(ctx, key, ttl, loader)
+	}
+
+	// Another instance is loading - wait for its result
+	deadline := time.NewTimer(loaderLockTTL)
+	defer deadline.Stop()
+	ticker := time.NewTicker(loaderPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-deadline.C:
+			r.logger.Warn("Timed out waiting for cache loader, loading directly",
+				zap.String("key", key),
+			)
+			return r.loadAndSet(ctx, key, ttl, loader)
+		case <-ticker.C:
+			if value, _ := r.Get(ctx, key); value != nil {
+				return value, nil
+			}
+			// Lock released or expired without a value (loader failed) - take over
+			if exists, err := r.client.Exists(ctx, r.lockKey(r.scopedKey(ctx, key))).Result(); err == nil && exists == 0 {
+				return r.loadAndSet(ctx, key, ttl, loader)
+			}
+		}
+	}
+}
+
+// loadAndSet runs the loader and caches its result
+func (r *RedisCache) loadAndSet(ctx context.Context, key string, ttl time.Duration, loader func() ([]byte, error)) ([]byte, error) {
+	value, err := loader()
+	if err != nil {
+		return nil, err
+	}
+
+	_ = r.Set(ctx, key, value, ttl)
+	return value, nil
+}
+
+// releaseLoaderLock releases the loader lock, logging instead of failing
+func (r *RedisCache) releaseLoaderLock(ctx context.Context, lock *Lock) {
+	if err := r.ReleaseLock(ctx, lock); err != nil {
+		r.logger.Warn("Failed to release cache loader lock",
+			zap.String("lock", lock.Name),
+			zap.Error(err),
+		)
+	}
+}
@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrLockNotAcquired is returned when a lock is already held by another owner
+var ErrLockNotAcquired = errors.New("lock is held by another owner")
+
+// ErrLockNotHeld is returned when releasing a lock that expired or was taken over
+var ErrLockNotHeld = errors.New("lock is no longer held")
+
+// Lock represents a held distributed lock
+type Lock struct {
+	Name      string
+	Token     string
+	ExpiresAt time.Time
+}
+
+// releaseLockScript deletes the lock only if it is still owned by the caller's token
+var releaseLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// AcquireLock takes a fleet-wide lock using SET NX with a random owner token
+// Unlike Get/Set, lock operations do not degrade silently: a Redis failure is returned
+// so callers never assume exclusivity they don't have. Like keys, locks taken in a
+// tenant's context are that tenant's
+func (r *RedisCache) AcquireLock(ctx context.Context, name string, ttl time.Duration) (*Lock, error) {
+	if !r.breaker.allow() {
+		return nil, ErrCircuitOpen
+	}
+
+	token := newLockToken()
+
+	acquired, err := r.client.SetNX(ctx, r.lockKey(r.scopedKey(ctx, name)), token, ttl).Result()
+	r.breaker.record(err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire lock %s: %w", name, err)
+	}
+	if !acquired {
+		return nil, ErrLockNotAcquired
+	}
+
+	return &Lock{
+		Name:      name,
+		Token:     token,
+		ExpiresAt: time.Now().Add(ttl),
+	}, nil
+}
+
+// ReleaseLock releases a lock if it is still owned by the caller
+// ctx must be scoped to the tenant the lock was acquired for
+func (r *RedisCache) ReleaseLock(ctx context.Context, lock *Lock) error {
+	if lock == nil {
+		return nil
+	}
+
+	deleted, err := releaseLockScript.Run(ctx, r.client, []string{r.lockKey(r.scopedKey(ctx, lock.Name))}, lock.Token).Int()
+	if err != nil {
+		return fmt.Errorf("failed to release lock %s: %w", lock.Name, err)
+	}
+	if deleted == 0 {
+		return ErrLockNotHeld
+	}
+
+	return nil
+}
+
+// lockKey returns the Redis key used to store a named lock
+func (r *RedisCache) lockKey(name string) string {
+	return r.keyPrefix + "lock:" + strings.TrimPrefix(name, r.keyPrefix)
+}
+
+// newLockToken generates a random token identifying the lock owner
+func newLockToken() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
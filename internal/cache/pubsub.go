@@ -0,0 +1,72 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Broadcaster fans messages out to every instance subscribed to their channel, over Redis
+// pub/sub. Unlike streams, delivery is at most once: messages published while an instance
+// isn't subscribed, or can't reach Redis, are lost to it
+type Broadcaster interface {
+	Broadcast(ctx context.Context, channel string, message []byte) error
+	PSubscribe(ctx context.Context, pattern string) *Subscription
+}
+
+// Broadcast is a message received on a subscription
+type Broadcast struct {
+	Channel string
+	Payload []byte
+}
+
+// Subscription receives the messages of the channels matching a pattern
+// It reconnects by itself after Redis errors, missing the messages sent meanwhile
+type Subscription struct {
+	pubsub *redis.PubSub
+	prefix string
+}
+
+// Broadcast publishes message to channel's subscribers
+func (r *RedisCache) Broadcast(ctx context.Context, channel string, message []byte) error {
+	if !r.breaker.allow() {
+		return ErrCircuitOpen
+	}
+
+	err := r.client.Publish(ctx, r.channelKey(channel), message).Err()
+	r.breaker.record(err)
+	if err != nil {
+		return fmt.Errorf("failed to broadcast to channel %s: %w", channel, err)
+	}
+
+	return nil
+}
+
+// PSubscribe subscribes to the channels matching pattern, a glob as in Redis PSUBSCRIBE
+func (r *RedisCache) PSubscribe(ctx context.Context, pattern string) *Subscription {
+	return &Subscription{
+		pubsub: r.client.PSubscribe(ctx, r.channelKey(pattern)),
+		prefix: r.channelKey(""),
+	}
+}
+
+// Receive waits for the next message, until ctx is done
+func (s *Subscription) Receive(ctx context.Context) (Broadcast, error) {
+	msg, err := s.pubsub.ReceiveMessage(ctx)
+	if err != nil {
+		return Broadcast{}, err
+	}
+	return Broadcast{Channel: strings.TrimPrefix(msg.Channel, s.prefix), Payload: []byte(msg.Payload)}, nil
+}
+
+// Close unsubscribes
+func (s *Subscription) Close() error {
+	return s.pubsub.Close()
+}
+
+// channelKey returns the Redis pub/sub channel used for a named channel
+func (r *RedisCache) channelKey(name string) string {
+	return r.keyPrefix + "channel:" + strings.TrimPrefix(name, r.keyPrefix)
+}
@@ -0,0 +1,191 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/yourusername/supabase-redis-middleware/internal/tenant"
+	"go.uber.org/zap"
+)
+
+// RefresherOptions controls which keys the refresher keeps warm
+type RefresherOptions struct {
+	Interval time.Duration // How often hot keys are checked; also the access-count window
+	MinHits  int64         // Accesses per window for a key to count as hot
+	MaxKeys  int64         // Upper bound on keys refreshed per cycle
+}
+
+// refreshEntry knows how to rebuild a cached value
+type refreshEntry struct {
+	tenant   string // The tenant it was tracked for, which its refresh runs as
+	refresh  func(ctx context.Context) error
+	lastSeen time.Time
+}
+
+// Refresher proactively reloads frequently accessed keys shortly before they expire
+// Access counts are kept in Redis so hotness is measured fleet-wide, while loaders are
+// registered in-process; each instance refreshes the hot keys it knows how to build
+type Refresher struct {
+	cache   *RedisCache
+	opts    RefresherOptions
+	logger  *zap.Logger
+	loaders sync.Map // Redis key -> refreshEntry
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewRefresher creates a refresher; call Start to run it
+func NewRefresher(cache *RedisCache, opts RefresherOptions, logger *zap.Logger) *Refresher {
+	return &Refresher{
+		cache:  cache,
+		opts:   opts,
+		logger: logger,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+}
+
+// Track records an access to key and remembers how to reload it: refresh reloads the key
+// and caches it, along with whatever is cached alongside it, as the lookup did
+// Tracking failures are logged and otherwise ignored; they only affect warmness
+// Keys tracked in a tenant's context are that tenant's, and are reloaded in its context
+func (f *Refresher) Track(ctx context.Context, key string, refresh func(ctx context.Context) error) {
+	entry := refreshEntry{tenant: tenant.FromContext(ctx), refresh: refresh, lastSeen: time.Now()}
+	key = f.cache.scopedKey(ctx, key)
+	f.loaders.Store(key, entry)
+
+	if !f.cache.breaker.allow() {
+		return
+	}
+
+	windowKey := f.windowKey(time.Now())
+	pipe := f.cache.client.Pipeline()
+	pipe.ZIncrBy(ctx, windowKey, 1, key)
+	pipe.Expire(ctx, windowKey, 2*f.opts.Interval)
+	_, err := pipe.Exec(ctx)
+	f.cache.breaker.record(err)
+	if err != nil {
+		f.logger.Warn("Failed to track cache key access",
+			zap.String("key", key),
+			zap.Error(err),
+		)
+	}
+}
+
+// Start runs the refresh loop in the background until Stop is called
+func (f *Refresher) Start() {
+	go func() {
+		defer close(f.done)
+
+		ticker := time.NewTicker(f.opts.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-f.stop:
+				return
+			case <-ticker.C:
+				f.refreshHotKeys(context.Background())
+				f.pruneLoaders()
+			}
+		}
+	}()
+}
+
+// Stop halts the refresh loop and waits for an in-flight cycle to finish
+func (f *Refresher) Stop() {
+	close(f.stop)
+	<-f.done
+}
+
+// refreshHotKeys reloads hot keys from the last complete window that are close to expiry
+func (f *Refresher) refreshHotKeys(ctx context.Context) {
+	if !f.cache.breaker.allow() {
+		return
+	}
+
+	previous := f.windowKey(time.Now().Add(-f.opts.Interval))
+	hot, err := f.cache.client.ZRevRangeByScore(ctx, previous, &redis.ZRangeBy{
+		Min:   strconv.FormatInt(f.opts.MinHits, 10),
+		Max:   "+inf",
+		Count: f.opts.MaxKeys,
+	}).Result()
+	f.cache.breaker.record(err)
+	if err != nil {
+		f.logger.Warn("Failed to read hot cache keys", zap.Error(err))
+		return
+	}
+
+	refreshed := 0
+	for _, key := range hot {
+		value, ok := f.loaders.Load(key)
+		if !ok {
+			continue
+		}
+		if f.refresh(ctx, key, value.(refreshEntry)) {
+			refreshed++
+		}
+	}
+
+	if refreshed > 0 {
+		f.logger.Debug("Refreshed hot cache keys",
+			zap.Int("hot", len(hot)),
+			zap.Int("refreshed", refreshed),
+		)
+	}
+}
+
+// refresh reloads a single key if it expires before the next cycle could catch it
+func (f *Refresher) refresh(ctx context.Context, key string, entry refreshEntry) bool {
+	remaining, err := f.cache.client.PTTL(ctx, key).Result()
+	// -1 means the key has no expiry; a missing key (-2) is re-warmed
+	if err != nil || remaining == -1 || remaining > 2*f.opts.Interval {
+		return false
+	}
+
+	// Only one instance refreshes a given key per cycle
+	lock, err := f.cache.AcquireLock(ctx, "refresh:"+key, f.opts.Interval)
+	if err != nil {
+		if !errors.Is(err, ErrLockNotAcquired) {
+			f.logger.Warn("Failed to acquire refresh lock", zap.String("key", key), zap.Error(err))
+		}
+		return false
+	}
+	defer f.cache.ReleaseLock(ctx, lock)
+
+	loadCtx, cancel := context.WithTimeout(tenant.WithID(ctx, entry.tenant), loaderLockTTL)
+	defer cancel()
+
+	if err := entry.refresh(loadCtx); err != nil {
+		f.logger.Warn("Cache refresh-ahead loader failed",
+			zap.String("key", key),
+			zap.Error(err),
+		)
+		return false
+	}
+	return true
+}
+
+// pruneLoaders forgets loaders for keys not accessed in the last two windows
+// so one-off queries don't accumulate in memory
+func (f *Refresher) pruneLoaders() {
+	cutoff := time.Now().Add(-2 * f.opts.Interval)
+	f.loaders.Range(func(key, value any) bool {
+		if value.(refreshEntry).lastSeen.Before(cutoff) {
+			f.loaders.Delete(key)
+		}
+		return true
+	})
+}
+
+// windowKey returns the sorted set holding access counts for the window containing t
+func (f *Refresher) windowKey(t time.Time) string {
+	window := t.UnixNano() / int64(f.opts.Interval)
+	return fmt.Sprintf("%shot:%d", f.cache.keyPrefix, window)
+}
@@ -0,0 +1,121 @@
+package cache
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// DomainStats holds cache counters for a single key domain
+type DomainStats struct {
+	Hits         uint64  `json:"hits"`
+	Misses       uint64  `json:"misses"`
+	Errors       uint64  `json:"errors"`
+	BytesRead    uint64  `json:"bytes_read"`
+	BytesWritten uint64  `json:"bytes_written"`
+	Oversized    uint64  `json:"oversized"` // Writes skipped for exceeding the max value size
+	HitRatio     float64 `json:"hit_ratio"`
+}
+
+// domainCounters holds the live atomic counters for a domain
+type domainCounters struct {
+	hits         atomic.Uint64
+	misses       atomic.Uint64
+	errors       atomic.Uint64
+	bytesRead    atomic.Uint64
+	bytesWritten atomic.Uint64
+	oversized    atomic.Uint64
+}
+
+// statsRecorder tracks cache activity per domain
+// Domains are derived from the key prefix so no caller changes are needed
+type statsRecorder struct {
+	mu        sync.RWMutex
+	domains   map[string]*domainCounters
+	keyPrefix string
+}
+
+func newStatsRecorder() *statsRecorder {
+	return &statsRecorder{
+		domains: make(map[string]*domainCounters),
+	}
+}
+
+// counters returns the counters for the domain owning key, creating them on first use
+func (s *statsRecorder) counters(key string) *domainCounters {
+	domain := keyDomain(strings.TrimPrefix(key, s.keyPrefix))
+
+	s.mu.RLock()
+	c, ok := s.domains[domain]
+	s.mu.RUnlock()
+	if ok {
+		return c
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if c, ok = s.domains[domain]; !ok {
+		c = &domainCounters{}
+		s.domains[domain] = c
+	}
+	return c
+}
+
+func (s *statsRecorder) recordHit(key string, size int) {
+	c := s.counters(key)
+	c.hits.Add(1)
+	c.bytesRead.Add(uint64(size))
+}
+
+func (s *statsRecorder) recordMiss(key string) {
+	s.counters(key).misses.Add(1)
+}
+
+func (s *statsRecorder) recordError(key string) {
+	s.counters(key).errors.Add(1)
+}
+
+func (s *statsRecorder) recordWrite(key string, size int) {
+	s.counters(key).bytesWritten.Add(uint64(size))
+}
+
+func (s *statsRecorder) recordOversized(key string) {
+	s.counters(key).oversized.Add(1)
+}
+
+// snapshot returns a point-in-time copy of all domain counters
+func (s *statsRecorder) snapshot() map[string]DomainStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make(map[string]DomainStats, len(s.domains))
+	for domain, c := range s.domains {
+		stats := DomainStats{
+			Hits:         c.hits.Load(),
+			Misses:       c.misses.Load(),
+			Errors:       c.errors.Load(),
+			BytesRead:    c.bytesRead.Load(),
+			BytesWritten: c.bytesWritten.Load(),
+			Oversized:    c.oversized.Load(),
+		}
+		if lookups := stats.Hits + stats.Misses; lookups > 0 {
+			stats.HitRatio = float64(stats.Hits) / float64(lookups)
+		}
+		result[domain] = stats
+	}
+	return result
+}
+
+// keyDomain extracts the domain portion of a cache key (everything before the first colon)
+// A tenant's keys count towards the same domains as unscoped ones
+func keyDomain(key string) string {
+	if rest, ok := strings.CutPrefix(key, tenantSegment); ok {
+		if _, unscoped, ok := strings.Cut(rest, ":"); ok {
+			key = unscoped
+		}
+	}
+	if idx := strings.Index(key, ":"); idx >= 0 {
+		return key[:idx]
+	}
+	return key
+}
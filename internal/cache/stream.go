@@ -0,0 +1,183 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// defaultStreamMaxLen caps each stream (approximately) so unconsumed events can't grow unbounded
+const defaultStreamMaxLen = 10000
+
+// StreamQueue is a lightweight at-least-once queue on top of Redis Streams and consumer groups
+// Like locks, queue operations do not degrade silently: Redis failures are returned to the caller
+type StreamQueue interface {
+	Publish(ctx context.Context, stream string, values map[string]interface{}) (string, error)
+	EnsureGroup(ctx context.Context, stream, group string) error
+	ReadGroup(ctx context.Context, stream, group, consumer string, count int64, block time.Duration) ([]StreamMessage, error)
+	Ack(ctx context.Context, stream, group string, ids ...string) error
+	ClaimPending(ctx context.Context, stream, group, consumer string, minIdle time.Duration, count int64) ([]StreamMessage, error)
+}
+
+// EventLog is a capped log of events on Redis Streams, replayed by readers from the last
+// event they saw rather than consumed through a group. Like queues, it returns Redis failures
+type EventLog interface {
+	Append(ctx context.Context, stream string, maxLen int64, values map[string]interface{}) (string, error)
+	ReadAfter(ctx context.Context, stream, afterID string, count int64) ([]StreamMessage, error)
+}
+
+// StreamMessage is a single entry read from a stream
+type StreamMessage struct {
+	ID     string
+	Values map[string]interface{}
+}
+
+// Publish appends an event to a stream and returns its ID
+func (r *RedisCache) Publish(ctx context.Context, stream string, values map[string]interface{}) (string, error) {
+	if !r.breaker.allow() {
+		return "", ErrCircuitOpen
+	}
+
+	id, err := r.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: r.streamKey(stream),
+		MaxLen: defaultStreamMaxLen,
+		Approx: true,
+		Values: values,
+	}).Result()
+	r.breaker.record(err)
+	if err != nil {
+		return "", fmt.Errorf("failed to publish to stream %s: %w", stream, err)
+	}
+
+	return id, nil
+}
+
+// Append adds an event to a stream, trimming it to about maxLen events, and returns its ID
+func (r *RedisCache) Append(ctx context.Context, stream string, maxLen int64, values map[string]interface{}) (string, error) {
+	if !r.breaker.allow() {
+		return "", ErrCircuitOpen
+	}
+
+	id, err := r.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: r.streamKey(stream),
+		MaxLen: maxLen,
+		Approx: true,
+		Values: values,
+	}).Result()
+	r.breaker.record(err)
+	if err != nil {
+		return "", fmt.Errorf("failed to append to stream %s: %w", stream, err)
+	}
+
+	return id, nil
+}
+
+// ReadAfter returns up to count events following afterID, oldest first
+// Events already trimmed from the stream are not returned
+func (r *RedisCache) ReadAfter(ctx context.Context, stream, afterID string, count int64) ([]StreamMessage, error) {
+	if !r.breaker.allow() {
+		return nil, ErrCircuitOpen
+	}
+
+	messages, err := r.client.XRangeN(ctx, r.streamKey(stream), "("+afterID, "+", count).Result()
+	r.breaker.record(err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stream %s: %w", stream, err)
+	}
+
+	return toStreamMessages(messages), nil
+}
+
+// EnsureGroup creates a consumer group (and the stream) if it doesn't exist yet
+// New groups start at the end of the stream, so only events published afterwards are delivered
+func (r *RedisCache) EnsureGroup(ctx context.Context, stream, group string) error {
+	err := r.client.XGroupCreateMkStream(ctx, r.streamKey(stream), group, "$").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return fmt.Errorf("failed to create consumer group %s on stream %s: %w", group, stream, err)
+	}
+
+	return nil
+}
+
+// ReadGroup delivers up to count new messages to a consumer, blocking for at most block
+// Returns an empty slice (not an error) when no messages arrive in time
+func (r *RedisCache) ReadGroup(ctx context.Context, stream, group, consumer string, count int64, block time.Duration) ([]StreamMessage, error) {
+	if !r.breaker.allow() {
+		return nil, ErrCircuitOpen
+	}
+
+	streams, err := r.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    group,
+		Consumer: consumer,
+		Streams:  []string{r.streamKey(stream), ">"},
+		Count:    count,
+		Block:    block,
+	}).Result()
+	r.breaker.record(ignoreNil(err))
+	if errors.Is(err, redis.Nil) {
+		return []StreamMessage{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from stream %s: %w", stream, err)
+	}
+
+	messages := []StreamMessage{}
+	for _, s := range streams {
+		messages = append(messages, toStreamMessages(s.Messages)...)
+	}
+
+	return messages, nil
+}
+
+// Ack marks messages as processed so they are removed from the group's pending list
+func (r *RedisCache) Ack(ctx context.Context, stream, group string, ids ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	if err := r.client.XAck(ctx, r.streamKey(stream), group, ids...).Err(); err != nil {
+		return fmt.Errorf("failed to ack %d messages on stream %s: %w", len(ids), stream, err)
+	}
+
+	return nil
+}
+
+// ClaimPending takes over messages delivered to other consumers but left unacknowledged
+// for at least minIdle, e.g. because that consumer crashed mid-processing
+func (r *RedisCache) ClaimPending(ctx context.Context, stream, group, consumer string, minIdle time.Duration, count int64) ([]StreamMessage, error) {
+	if !r.breaker.allow() {
+		return nil, ErrCircuitOpen
+	}
+
+	claimed, _, err := r.client.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+		Stream:   r.streamKey(stream),
+		Group:    group,
+		Consumer: consumer,
+		MinIdle:  minIdle,
+		Start:    "0-0",
+		Count:    count,
+	}).Result()
+	r.breaker.record(err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim pending messages on stream %s: %w", stream, err)
+	}
+
+	return toStreamMessages(claimed), nil
+}
+
+// streamKey returns the Redis key used to store a named stream
+func (r *RedisCache) streamKey(name string) string {
+	return r.keyPrefix + "stream:" + strings.TrimPrefix(name, r.keyPrefix)
+}
+
+func toStreamMessages(in []redis.XMessage) []StreamMessage {
+	out := make([]StreamMessage, 0, len(in))
+	for _, m := range in {
+		out = append(out, StreamMessage{ID: m.ID, Values: m.Values})
+	}
+	return out
+}
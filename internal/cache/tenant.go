@@ -0,0 +1,40 @@
+package cache
+
+import (
+	"context"
+	"strings"
+
+	"github.com/yourusername/supabase-redis-middleware/internal/tenant"
+)
+
+// tenantSegment follows the key prefix in every key scoped to a tenant
+const tenantSegment = "tenant:"
+
+// scopedKey returns the Redis key holding key for ctx's tenant: key itself in a system
+// context, otherwise key with tenant:<id>: after the key prefix, so tenants sharing a
+// deployment never read each other's entries
+func (r *RedisCache) scopedKey(ctx context.Context, key string) string {
+	id := tenant.FromContext(ctx)
+	if id == "" {
+		return key
+	}
+	return r.keyPrefix + tenantSegment + id + ":" + strings.TrimPrefix(key, r.keyPrefix)
+}
+
+// scopePrefix returns what precedes a pattern in the keys ctx may see
+func (r *RedisCache) scopePrefix(ctx context.Context) string {
+	if id := tenant.FromContext(ctx); id != "" {
+		return r.keyPrefix + tenantSegment + id + ":"
+	}
+	return r.keyPrefix
+}
+
+// scanMatches returns the SCAN patterns for a pattern: the tenant's keys matching it, or
+// in a system context those outside any tenant and every tenant's, so invalidating a
+// domain there clears it for all tenants
+func (r *RedisCache) scanMatches(ctx context.Context, pattern string) []string {
+	if tenant.FromContext(ctx) != "" {
+		return []string{r.scopePrefix(ctx) + pattern}
+	}
+	return []string{r.keyPrefix + pattern, r.keyPrefix + tenantSegment + "*:" + pattern}
+}
@@ -0,0 +1,29 @@
+package cache
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// TTL is a cache TTL that can be changed while the services caching with it run, as it is
+// when the configuration is reloaded. Entries already cached keep the TTL they were set with
+type TTL struct {
+	d atomic.Int64
+}
+
+// NewTTL returns a TTL of d
+func NewTTL(d time.Duration) *TTL {
+	t := &TTL{}
+	t.Set(d)
+	return t
+}
+
+// Get returns the current TTL
+func (t *TTL) Get() time.Duration {
+	return time.Duration(t.d.Load())
+}
+
+// Set changes the TTL entries are cached for from now on
+func (t *TTL) Set(d time.Duration) {
+	t.d.Store(int64(d))
+}
@@ -0,0 +1,172 @@
+package graphql
+
+// Document is a parsed GraphQL request document
+type Document struct {
+	Operations []*Operation
+	Fragments  []*Fragment
+}
+
+// operation returns the operation named name, or the only operation when name is empty
+func (d *Document) operation(name string) (*Operation, error) {
+	if name == "" {
+		if len(d.Operations) != 1 {
+			return nil, &Error{Message: "Must provide operationName when the document has several operations"}
+		}
+		return d.Operations[0], nil
+	}
+	for _, op := range d.Operations {
+		if op.Name == name {
+			return op, nil
+		}
+	}
+	return nil, &Error{Message: `Unknown operation named "` + name + `"`}
+}
+
+// fragment returns the fragment named name, or nil
+func (d *Document) fragment(name string) *Fragment {
+	for _, f := range d.Fragments {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// Operation is a query, mutation or subscription definition
+type Operation struct {
+	Type         string // "query", "mutation" or "subscription"
+	Name         string
+	Variables    []*VariableDefinition
+	Directives   []*Directive
+	SelectionSet []Selection
+	Loc          Location
+}
+
+// VariableDefinition declares one of an operation's variables
+type VariableDefinition struct {
+	Name    string
+	Type    *TypeRef
+	Default *Value // nil without a default
+	Loc     Location
+}
+
+// TypeRef is a type as written in a variable definition
+type TypeRef struct {
+	Name    string   // Set for named types
+	Elem    *TypeRef // Set for list types
+	NonNull bool
+	Loc     Location
+}
+
+// String renders the type as written, e.g. [ID!]!
+func (t *TypeRef) String() string {
+	s := t.Name
+	if t.Elem != nil {
+		s = "[" + t.Elem.String() + "]"
+	}
+	if t.NonNull {
+		s += "!"
+	}
+	return s
+}
+
+// Fragment is a named fragment definition
+type Fragment struct {
+	Name          string
+	TypeCondition string
+	Directives    []*Directive
+	SelectionSet  []Selection
+	Loc           Location
+}
+
+// Selection is a *FieldNode, *FragmentSpread or *InlineFragment
+type Selection interface {
+	location() Location
+}
+
+// FieldNode is a field selected in a query
+type FieldNode struct {
+	Alias        string // Empty without an alias
+	Name         string
+	Arguments    []*ArgumentNode
+	Directives   []*Directive
+	SelectionSet []Selection
+	Loc          Location
+}
+
+// ResponseKey returns the key the field's value is reported under: its alias, else its name
+func (f *FieldNode) ResponseKey() string {
+	if f.Alias != "" {
+		return f.Alias
+	}
+	return f.Name
+}
+
+// FragmentSpread spreads a named fragment into a selection set
+type FragmentSpread struct {
+	Name       string
+	Directives []*Directive
+	Loc        Location
+}
+
+// InlineFragment is a selection set with an optional type condition
+type InlineFragment struct {
+	TypeCondition string // Empty without a condition
+	Directives    []*Directive
+	SelectionSet  []Selection
+	Loc           Location
+}
+
+func (f *FieldNode) location() Location      { return f.Loc }
+func (f *FragmentSpread) location() Location { return f.Loc }
+func (f *InlineFragment) location() Location { return f.Loc }
+
+// ArgumentNode is an argument given to a field or directive
+type ArgumentNode struct {
+	Name  string
+	Value *Value
+	Loc   Location
+}
+
+// Directive is a directive applied to a selection or definition, such as @skip
+type Directive struct {
+	Name      string
+	Arguments []*ArgumentNode
+	Loc       Location
+}
+
+// ValueKind identifies the kind of a literal Value
+type ValueKind int
+
+const (
+	VariableValue ValueKind = iota
+	IntValue
+	FloatValue
+	StringValue
+	BooleanValue
+	NullValue
+	EnumValue
+	ListValue
+	ObjectValue
+)
+
+// Value is an input value as written in a query
+type Value struct {
+	Kind   ValueKind
+	Raw    string // The variable name, number, string contents, true/false or enum name
+	List   []*Value
+	Fields []*ObjectField
+	Loc    Location
+}
+
+// ObjectField is a field of an input object literal
+type ObjectField struct {
+	Name  string
+	Value *Value
+}
+
+// Location is a 1-based line and column in the query
+type Location struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
@@ -0,0 +1,285 @@
+package catalog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	"github.com/yourusername/supabase-redis-middleware/internal/cache"
+	"github.com/yourusername/supabase-redis-middleware/internal/graphql"
+	"github.com/yourusername/supabase-redis-middleware/internal/logger"
+	"github.com/yourusername/supabase-redis-middleware/internal/repository"
+	"go.uber.org/zap"
+)
+
+// Repository is the Postgres-backed catalog data access the schema resolves with
+type Repository interface {
+	GetStoresByIDs(ctx context.Context, ids []string) ([]repository.Store, error)
+	GetProductsByIDs(ctx context.Context, ids []string) ([]repository.Product, error)
+	ListListingsByStores(ctx context.Context, storeIDs []string, filter repository.ListingFilter, pagination repository.Pagination) ([]repository.StoreListing, error)
+	ListListingsByProducts(ctx context.Context, productIDs []string) ([]repository.StoreListing, error)
+	ListVariationsByStoreProducts(ctx context.Context, storeProductIDs []string) ([]repository.Variation, error)
+	ListCategoryTree(ctx context.Context, storeID string) ([]*repository.CategoryNode, error)
+	FindNearbyStores(ctx context.Context, q repository.NearbyStoresQuery) ([]repository.NearbyStore, error)
+}
+
+// storePage identifies a page of one store's listings
+type storePage struct {
+	StoreID     string
+	CategoryID  string
+	InStockOnly bool
+	Limit       int
+	Offset      int
+}
+
+// loaders are the dataloaders of one request
+// Each batches the loads of a query level into one cache round trip and one query for
+// the misses. Values are cached in the domains the writes changing them clear: stores in
+// DomainNearby, which store updates clear; products in DomainProducts; a store's
+// listings in its StoreDomain; product prices and variations in DomainLookup, which
+// pushes, stock updates and listing changes clear; and the category tree in
+// DomainCategories
+type loaders struct {
+	stores          *graphql.Loader[string, repository.Store]
+	products        *graphql.Loader[string, repository.Product]
+	storeListings   *graphql.Loader[storePage, []repository.StoreListing]
+	productListings *graphql.Loader[string, []repository.StoreListing]
+	variations      *graphql.Loader[string, []repository.Variation]
+	categories      *graphql.Loader[string, *repository.CategoryNode]
+	// tree loads the roots of the category tree, under its only key
+	tree *graphql.Loader[struct{}, []*repository.CategoryNode]
+}
+
+type loadersKey struct{}
+
+func withLoaders(ctx context.Context, l *loaders) context.Context {
+	return context.WithValue(ctx, loadersKey{}, l)
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
+
+// Errors resolvers report when a batch fails; the cause is logged, not shown to clients
+var (
+	errLoadStores     = errors.New("Failed to load stores")
+	errLoadProducts   = errors.New("Failed to load products")
+	errLoadListings   = errors.New("Failed to load listings")
+	errLoadVariations = errors.New("Failed to load variations")
+	errLoadCategories = errors.New("Failed to load categories")
+)
+
+// newLoaders creates the loaders for a request with context ctx
+func (s *Schema) newLoaders(ctx context.Context) *loaders {
+	l := &loaders{}
+
+	l.stores = graphql.NewLoader(ctx, cachedBatch(s, func(id string) string {
+		return s.cache.GenerateKey(cache.DomainNearby, map[string]string{"graphql": "store", "id": id})
+	}, func(ctx context.Context, ids []string) (map[string]repository.Store, error) {
+		stores, err := s.repo.GetStoresByIDs(ctx, ids)
+		if err != nil {
+			s.log(ctx).Error("Failed to load stores", zap.Int("ids", len(ids)), zap.Error(err))
+			return nil, errLoadStores
+		}
+		byID := make(map[string]repository.Store, len(stores))
+		for _, store := range stores {
+			byID[store.ID] = store
+		}
+		return byID, nil
+	}))
+
+	l.products = graphql.NewLoader(ctx, cachedBatch(s, func(id string) string {
+		return s.cache.GenerateKey(cache.DomainProducts, map[string]string{"graphql": "product", "id": id})
+	}, func(ctx context.Context, ids []string) (map[string]repository.Product, error) {
+		products, err := s.repo.GetProductsByIDs(ctx, ids)
+		if err != nil {
+			s.log(ctx).Error("Failed to load products", zap.Int("ids", len(ids)), zap.Error(err))
+			return nil, errLoadProducts
+		}
+		byID := make(map[string]repository.Product, len(products))
+		for _, product := range products {
+			byID[product.ID] = product
+		}
+		return byID, nil
+	}))
+
+	l.storeListings = graphql.NewLoader(ctx, cachedBatch(s, func(page storePage) string {
+		return s.cache.GenerateKey(cache.StoreDomain(page.StoreID), map[string]string{
+			"graphql":     "listings",
+			"category_id": page.CategoryID,
+			"in_stock":    strconv.FormatBool(page.InStockOnly),
+			"limit":       strconv.Itoa(page.Limit),
+			"offset":      strconv.Itoa(page.Offset),
+		})
+	}, s.loadStorePages))
+
+	l.productListings = graphql.NewLoader(ctx, cachedBatch(s, func(id string) string {
+		return s.cache.GenerateKey(cache.DomainLookup, map[string]string{"graphql": "prices", "product_id": id})
+	}, func(ctx context.Context, ids []string) (map[string][]repository.StoreListing, error) {
+		listings, err := s.repo.ListListingsByProducts(ctx, ids)
+		if err != nil {
+			s.log(ctx).Error("Failed to load product prices", zap.Int("products", len(ids)), zap.Error(err))
+			return nil, errLoadListings
+		}
+		return groupBy(ids, listings, func(l repository.StoreListing) string { return l.ProductID }), nil
+	}))
+
+	l.variations = graphql.NewLoader(ctx, cachedBatch(s, func(id string) string {
+		return s.cache.GenerateKey(cache.DomainLookup, map[string]string{"graphql": "variations", "store_product_id": id})
+	}, func(ctx context.Context, ids []string) (map[string][]repository.Variation, error) {
+		variations, err := s.repo.ListVariationsByStoreProducts(ctx, ids)
+		if err != nil {
+			s.log(ctx).Error("Failed to load variations", zap.Int("store_products", len(ids)), zap.Error(err))
+			return nil, errLoadVariations
+		}
+		return groupBy(ids, variations, func(v repository.Variation) string { return v.StoreProductID }), nil
+	}))
+
+	treeKey := s.cache.GenerateKey(cache.DomainCategories, map[string]string{"graphql": "tree"})
+	l.tree = graphql.NewLoader(ctx, cachedBatch(s, func(struct{}) string {
+		return treeKey
+	}, func(ctx context.Context, _ []struct{}) (map[struct{}][]*repository.CategoryNode, error) {
+		tree, err := s.repo.ListCategoryTree(ctx, "")
+		if err != nil {
+			s.log(ctx).Error("Failed to load category tree", zap.Error(err))
+			return nil, errLoadCategories
+		}
+		return map[struct{}][]*repository.CategoryNode{{}: tree}, nil
+	}))
+
+	// Categories are looked up in the tree, which is read once whatever they are
+	l.categories = graphql.NewLoader(ctx, func(ctx context.Context, ids []string) (map[string]*repository.CategoryNode, error) {
+		tree, err := l.categoryTree()
+		if err != nil {
+			return nil, err
+		}
+		byID := make(map[string]*repository.CategoryNode)
+		var index func(nodes []*repository.CategoryNode)
+		index = func(nodes []*repository.CategoryNode) {
+			for _, node := range nodes {
+				byID[node.ID] = node
+				index(node.Children)
+			}
+		}
+		index(tree)
+		return byID, nil
+	})
+
+	return l
+}
+
+// categoryTree returns the roots of the active category tree
+func (l *loaders) categoryTree() ([]*repository.CategoryNode, error) {
+	roots, err := l.tree.Load(struct{}{})()
+	if err != nil {
+		return nil, err
+	}
+	tree, _ := roots.([]*repository.CategoryNode)
+	return tree, nil
+}
+
+// loadStorePages loads pages of several stores' listings, with a query for each distinct
+// page asked for
+func (s *Schema) loadStorePages(ctx context.Context, pages []storePage) (map[storePage][]repository.StoreListing, error) {
+	type pageShape struct {
+		filter     repository.ListingFilter
+		pagination repository.Pagination
+	}
+	var shapes []pageShape
+	storeIDs := make(map[pageShape][]string)
+	for _, page := range pages {
+		shape := pageShape{
+			filter:     repository.ListingFilter{CategoryID: page.CategoryID, InStockOnly: page.InStockOnly},
+			pagination: repository.Pagination{Limit: page.Limit, Offset: page.Offset},
+		}
+		if _, ok := storeIDs[shape]; !ok {
+			shapes = append(shapes, shape)
+		}
+		storeIDs[shape] = append(storeIDs[shape], page.StoreID)
+	}
+
+	result := make(map[storePage][]repository.StoreListing, len(pages))
+	for _, shape := range shapes {
+		listings, err := s.repo.ListListingsByStores(ctx, storeIDs[shape], shape.filter, shape.pagination)
+		if err != nil {
+			s.log(ctx).Error("Failed to load store listings", zap.Int("stores", len(storeIDs[shape])), zap.Error(err))
+			return nil, errLoadListings
+		}
+		byStore := groupBy(storeIDs[shape], listings, func(l repository.StoreListing) string { return l.StoreID })
+		for storeID, page := range byStore {
+			result[storePage{
+				StoreID:     storeID,
+				CategoryID:  shape.filter.CategoryID,
+				InStockOnly: shape.filter.InStockOnly,
+				Limit:       shape.pagination.Limit,
+				Offset:      shape.pagination.Offset,
+			}] = page
+		}
+	}
+	return result, nil
+}
+
+// groupBy groups values by key, with an empty group for each of keys without any
+func groupBy[V any](keys []string, values []V, key func(V) string) map[string][]V {
+	groups := make(map[string][]V, len(keys))
+	for _, k := range keys {
+		groups[k] = []V{}
+	}
+	for _, v := range values {
+		groups[key(v)] = append(groups[key(v)], v)
+	}
+	return groups
+}
+
+// cachedBatch wraps batch so keys are read from the cache first, in one round trip, and
+// the values fetched for the rest are cached for the schema's TTL under key(k)
+func cachedBatch[K comparable, V any](s *Schema, key func(K) string, batch graphql.BatchFunc[K, V]) graphql.BatchFunc[K, V] {
+	return func(ctx context.Context, keys []K) (map[K]V, error) {
+		cacheKeys := make(map[K]string, len(keys))
+		list := make([]string, len(keys))
+		for i, k := range keys {
+			cacheKeys[k] = key(k)
+			list[i] = cacheKeys[k]
+		}
+
+		cached, _ := s.cache.GetMany(ctx, list)
+		values := make(map[K]V, len(keys))
+		var misses []K
+		for _, k := range keys {
+			var v V
+			if data, ok := cached[cacheKeys[k]]; ok && json.Unmarshal(data, &v) == nil {
+				values[k] = v
+				continue
+			}
+			misses = append(misses, k)
+		}
+		if len(misses) == 0 {
+			return values, nil
+		}
+
+		fetched, err := batch(ctx, misses)
+		if err != nil {
+			return nil, err
+		}
+		entries := make(map[string][]byte, len(fetched))
+		for _, k := range misses {
+			v, ok := fetched[k]
+			if !ok {
+				continue
+			}
+			values[k] = v
+			if data, err := json.Marshal(v); err == nil {
+				entries[cacheKeys[k]] = data
+			}
+		}
+		if len(entries) > 0 {
+			_ = s.cache.SetMany(ctx, entries, s.ttl.Get())
+		}
+		return values, nil
+	}
+}
+
+func (s *Schema) log(ctx context.Context) *zap.Logger {
+	return logger.FromContext(ctx, s.logger)
+}
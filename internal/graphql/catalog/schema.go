@@ -0,0 +1,399 @@
+// Package catalog is the GraphQL schema of the catalog: stores, their listings and
+// variations, catalog products with every store's price for them, and categories
+// Nested fields are resolved through per-request dataloaders, so a query costs a
+// cache round trip, and a Postgres query for the misses, per level rather than per object
+package catalog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/yourusername/supabase-redis-middleware/internal/cache"
+	"github.com/yourusername/supabase-redis-middleware/internal/graphql"
+	"github.com/yourusername/supabase-redis-middleware/internal/repository"
+	"go.uber.org/zap"
+)
+
+const (
+	// maxDepth bounds how deeply queries may nest fields
+	maxDepth = 8
+	// maxIDs bounds how many IDs a query may look up at once
+	maxIDs = 100
+
+	defaultPageSize = 20
+	maxPageSize     = 100
+
+	defaultNearbyRadiusKm = 5.0
+	maxNearbyRadiusKm     = 50.0
+)
+
+// Schema is the executable catalog schema
+type Schema struct {
+	schema *graphql.Schema
+	repo   Repository
+	cache  cache.CacheService
+	ttl    *cache.TTL
+	logger *zap.Logger
+}
+
+// NewSchema creates the catalog schema, resolving from repo through cacheService, where
+// loaded values are cached for ttl
+func NewSchema(repo Repository, cacheService cache.CacheService, ttl *cache.TTL, logger *zap.Logger) (*Schema, error) {
+	s := &Schema{repo: repo, cache: cacheService, ttl: ttl, logger: logger}
+
+	store := &graphql.Object{Name: "Store", Description: "An active store"}
+	listing := &graphql.Object{Name: "Listing", Description: "A product as listed in a store, at the store's price and stock"}
+	variation := &graphql.Object{Name: "Variation", Description: "A variation of a listing, such as a size, with its own price and stock"}
+	product := &graphql.Object{Name: "Product", Description: "An active catalog product, independent of any store"}
+	category := &graphql.Object{Name: "Category", Description: "An active category, with the number of available listings in it"}
+
+	store.Fields = []*graphql.Field{
+		{Name: "id", Type: graphql.NewNonNull(graphql.ID)},
+		{Name: "name", Type: graphql.NewNonNull(graphql.String)},
+		{Name: "slug", Type: graphql.NewNonNull(graphql.String)},
+		{Name: "description", Type: graphql.String},
+		{Name: "storeType", Type: graphql.NewNonNull(graphql.String)},
+		{Name: "phone", Type: graphql.String},
+		{Name: "email", Type: graphql.String},
+		{Name: "addressLine1", Type: graphql.NewNonNull(graphql.String)},
+		{Name: "city", Type: graphql.NewNonNull(graphql.String)},
+		{Name: "state", Type: graphql.String},
+		{Name: "postalCode", Type: graphql.String},
+		{Name: "country", Type: graphql.NewNonNull(graphql.String)},
+		{Name: "latitude", Type: graphql.NewNonNull(graphql.Float)},
+		{Name: "longitude", Type: graphql.NewNonNull(graphql.Float)},
+		{Name: "rating", Type: graphql.NewNonNull(graphql.Float)},
+		{Name: "totalRatings", Type: graphql.Int},
+		{Name: "minOrderAmount", Type: graphql.NewNonNull(graphql.Float)},
+		{Name: "deliveryFee", Type: graphql.NewNonNull(graphql.Float)},
+		{Name: "estimatedDeliveryTime", Type: graphql.Int, Description: "Minutes"},
+		{Name: "isOpen", Type: graphql.NewNonNull(graphql.Boolean)},
+		{Name: "distanceKm", Type: graphql.Float, Description: "Distance from the point searched; only set in nearbyStores"},
+		{
+			Name:        "products",
+			Description: "The store's available listings, newest first",
+			Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(listing))),
+			Args: []*graphql.Argument{
+				{Name: "categoryId", Type: graphql.ID},
+				{Name: "inStockOnly", Type: graphql.Boolean, DefaultValue: false},
+				{Name: "limit", Type: graphql.Int, DefaultValue: defaultPageSize, Description: fmt.Sprintf("At most %d", maxPageSize)},
+				{Name: "offset", Type: graphql.Int, DefaultValue: 0},
+			},
+			Resolve: resolveStoreProducts,
+		},
+	}
+
+	listing.Fields = []*graphql.Field{
+		{Name: "id", Type: graphql.NewNonNull(graphql.ID), Description: "The store product ID"},
+		{Name: "storeId", Type: graphql.NewNonNull(graphql.ID)},
+		{Name: "productId", Type: graphql.NewNonNull(graphql.ID)},
+		{Name: "sku", Type: graphql.NewNonNull(graphql.String)},
+		{Name: "name", Type: graphql.NewNonNull(graphql.String)},
+		{Name: "slug", Type: graphql.NewNonNull(graphql.String)},
+		{Name: "description", Type: graphql.String},
+		{Name: "unit", Type: graphql.String},
+		{Name: "unitQuantity", Type: graphql.Float},
+		{Name: "primaryImageUrl", Type: graphql.String},
+		{Name: "price", Type: graphql.NewNonNull(graphql.Float)},
+		{Name: "salePrice", Type: graphql.Float},
+		{Name: "currency", Type: graphql.String},
+		{Name: "stockQuantity", Type: graphql.NewNonNull(graphql.Float)},
+		{Name: "isInStock", Type: graphql.NewNonNull(graphql.Boolean)},
+		{Name: "brandName", Type: graphql.String},
+		{Name: "updatedAt", Type: graphql.NewNonNull(graphql.String)},
+		{
+			Name: "store",
+			Type: store,
+			Resolve: func(ctx context.Context, p graphql.ResolveParams) (any, error) {
+				return loadersFrom(ctx).stores.Load(p.Source.(repository.StoreListing).StoreID), nil
+			},
+		},
+		{
+			Name: "product",
+			Type: product,
+			Resolve: func(ctx context.Context, p graphql.ResolveParams) (any, error) {
+				return loadersFrom(ctx).products.Load(p.Source.(repository.StoreListing).ProductID), nil
+			},
+		},
+		{
+			Name: "category",
+			Type: category,
+			Resolve: func(ctx context.Context, p graphql.ResolveParams) (any, error) {
+				return loadCategory(ctx, p.Source.(repository.StoreListing).CategoryID), nil
+			},
+		},
+		{
+			Name: "variations",
+			Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(variation))),
+			Resolve: func(ctx context.Context, p graphql.ResolveParams) (any, error) {
+				return loadersFrom(ctx).variations.Load(p.Source.(repository.StoreListing).StoreProductID), nil
+			},
+		},
+	}
+
+	variation.Fields = []*graphql.Field{
+		{Name: "id", Type: graphql.NewNonNull(graphql.ID)},
+		{Name: "externalId", Type: graphql.String},
+		{Name: "name", Type: graphql.NewNonNull(graphql.String)},
+		{Name: "displayName", Type: graphql.NewNonNull(graphql.String)},
+		{Name: "price", Type: graphql.NewNonNull(graphql.Float)},
+		{Name: "salePrice", Type: graphql.Float},
+		{Name: "stockQuantity", Type: graphql.Float},
+		{Name: "isInStock", Type: graphql.NewNonNull(graphql.Boolean)},
+		{Name: "isDefault", Type: graphql.NewNonNull(graphql.Boolean)},
+		{Name: "displayOrder", Type: graphql.NewNonNull(graphql.Int)},
+	}
+
+	product.Fields = []*graphql.Field{
+		{Name: "id", Type: graphql.NewNonNull(graphql.ID)},
+		{Name: "sku", Type: graphql.NewNonNull(graphql.String)},
+		{Name: "name", Type: graphql.NewNonNull(graphql.String)},
+		{Name: "slug", Type: graphql.NewNonNull(graphql.String)},
+		{Name: "description", Type: graphql.String},
+		{Name: "brandId", Type: graphql.ID},
+		{Name: "basePrice", Type: graphql.NewNonNull(graphql.Float)},
+		{Name: "salePrice", Type: graphql.Float},
+		{Name: "currency", Type: graphql.String},
+		{Name: "unit", Type: graphql.String},
+		{Name: "unitQuantity", Type: graphql.Float},
+		{Name: "primaryImageUrl", Type: graphql.String},
+		{Name: "manufacturer", Type: graphql.String},
+		{Name: "barcode", Type: graphql.String},
+		{Name: "ean", Type: graphql.String},
+		{Name: "isFeatured", Type: graphql.NewNonNull(graphql.Boolean)},
+		{Name: "requiresPrescription", Type: graphql.NewNonNull(graphql.Boolean)},
+		{
+			Name: "category",
+			Type: category,
+			Resolve: func(ctx context.Context, p graphql.ResolveParams) (any, error) {
+				return loadCategory(ctx, p.Source.(repository.Product).CategoryID), nil
+			},
+		},
+		{
+			Name:        "prices",
+			Description: "The product's available listings in every active store, cheapest first",
+			Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(listing))),
+			Resolve: func(ctx context.Context, p graphql.ResolveParams) (any, error) {
+				return loadersFrom(ctx).productListings.Load(p.Source.(repository.Product).ID), nil
+			},
+		},
+	}
+
+	category.Fields = []*graphql.Field{
+		{Name: "id", Type: graphql.NewNonNull(graphql.ID)},
+		{Name: "name", Type: graphql.NewNonNull(graphql.String)},
+		{Name: "slug", Type: graphql.NewNonNull(graphql.String)},
+		{Name: "description", Type: graphql.String},
+		{Name: "iconUrl", Type: graphql.String},
+		{Name: "imageUrl", Type: graphql.String},
+		{Name: "displayOrder", Type: graphql.NewNonNull(graphql.Int)},
+		{Name: "depth", Type: graphql.NewNonNull(graphql.Int), Description: "0 for top-level categories"},
+		{Name: "productCount", Type: graphql.NewNonNull(graphql.Int), Description: "Available listings in the category itself"},
+		{Name: "totalProductCount", Type: graphql.NewNonNull(graphql.Int), Description: "Available listings in the category and its subcategories"},
+		{
+			Name: "parent",
+			Type: category,
+			Resolve: func(ctx context.Context, p graphql.ResolveParams) (any, error) {
+				return loadCategory(ctx, p.Source.(repository.CategoryNode).ParentID), nil
+			},
+		},
+		{
+			Name: "children",
+			Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(category))),
+			Resolve: func(ctx context.Context, p graphql.ResolveParams) (any, error) {
+				return p.Source.(repository.CategoryNode).Children, nil
+			},
+		},
+	}
+
+	query := &graphql.Object{
+		Name: "Query",
+		Fields: []*graphql.Field{
+			{
+				Name: "store",
+				Type: store,
+				Args: []*graphql.Argument{{Name: "id", Type: graphql.NewNonNull(graphql.ID)}},
+				Resolve: func(ctx context.Context, p graphql.ResolveParams) (any, error) {
+					id, err := uuidArg(p, "id")
+					if err != nil {
+						return nil, err
+					}
+					return loadersFrom(ctx).stores.Load(id), nil
+				},
+			},
+			{
+				Name:        "stores",
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(store))),
+				Description: fmt.Sprintf("The active stores among ids, in their order; at most %d", maxIDs),
+				Args:        []*graphql.Argument{{Name: "ids", Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.ID)))}},
+				Resolve: func(ctx context.Context, p graphql.ResolveParams) (any, error) {
+					ids, err := uuidsArg(p, "ids")
+					if err != nil {
+						return nil, err
+					}
+					return loadersFrom(ctx).stores.LoadMany(ids), nil
+				},
+			},
+			{
+				Name:        "nearbyStores",
+				Description: "Active stores within radiusKm of a point, nearest first",
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(store))),
+				Args: []*graphql.Argument{
+					{Name: "lat", Type: graphql.NewNonNull(graphql.Float)},
+					{Name: "lng", Type: graphql.NewNonNull(graphql.Float)},
+					{Name: "radiusKm", Type: graphql.Float, DefaultValue: defaultNearbyRadiusKm, Description: fmt.Sprintf("At most %g", maxNearbyRadiusKm)},
+					{Name: "storeType", Type: graphql.String},
+					{Name: "limit", Type: graphql.Int, DefaultValue: defaultPageSize, Description: fmt.Sprintf("At most %d", maxPageSize)},
+				},
+				Resolve: s.resolveNearbyStores,
+			},
+			{
+				Name: "product",
+				Type: product,
+				Args: []*graphql.Argument{{Name: "id", Type: graphql.NewNonNull(graphql.ID)}},
+				Resolve: func(ctx context.Context, p graphql.ResolveParams) (any, error) {
+					id, err := uuidArg(p, "id")
+					if err != nil {
+						return nil, err
+					}
+					return loadersFrom(ctx).products.Load(id), nil
+				},
+			},
+			{
+				Name:        "products",
+				Description: fmt.Sprintf("The active products among ids, in their order; at most %d", maxIDs),
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(product))),
+				Args:        []*graphql.Argument{{Name: "ids", Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.ID)))}},
+				Resolve: func(ctx context.Context, p graphql.ResolveParams) (any, error) {
+					ids, err := uuidsArg(p, "ids")
+					if err != nil {
+						return nil, err
+					}
+					return loadersFrom(ctx).products.LoadMany(ids), nil
+				},
+			},
+			{
+				Name:        "categories",
+				Description: "The top-level categories; their children give the rest of the tree",
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(category))),
+				Resolve: func(ctx context.Context, p graphql.ResolveParams) (any, error) {
+					return loadersFrom(ctx).tree.Load(struct{}{}), nil
+				},
+			},
+			{
+				Name: "category",
+				Type: category,
+				Args: []*graphql.Argument{{Name: "id", Type: graphql.NewNonNull(graphql.ID)}},
+				Resolve: func(ctx context.Context, p graphql.ResolveParams) (any, error) {
+					id := p.Args["id"].(string)
+					return loadCategory(ctx, &id), nil
+				},
+			},
+		},
+	}
+
+	schema, err := graphql.NewSchema(query, maxDepth)
+	if err != nil {
+		return nil, err
+	}
+	s.schema = schema
+	return s, nil
+}
+
+// Execute runs a query with a fresh set of loaders, so nothing loaded outlives the request
+// but what was cached
+func (s *Schema) Execute(ctx context.Context, req graphql.Request) *graphql.Response {
+	return s.schema.Execute(withLoaders(ctx, s.newLoaders(ctx)), req)
+}
+
+// SDL renders the schema in the GraphQL schema definition language
+func (s *Schema) SDL() string {
+	return s.schema.SDL()
+}
+
+// resolveStoreProducts resolves a page of a store's listings
+func resolveStoreProducts(ctx context.Context, p graphql.ResolveParams) (any, error) {
+	var storeID string
+	switch store := p.Source.(type) {
+	case repository.Store:
+		storeID = store.ID
+	case repository.NearbyStore:
+		storeID = store.ID
+	}
+
+	page := storePage{StoreID: storeID, Limit: p.Args["limit"].(int), Offset: p.Args["offset"].(int)}
+	if page.Limit < 1 || page.Limit > maxPageSize {
+		return nil, fmt.Errorf("limit must be between 1 and %d", maxPageSize)
+	}
+	if page.Offset < 0 {
+		return nil, errors.New("offset must be a non-negative integer")
+	}
+	if id, ok := p.Args["categoryId"].(string); ok {
+		page.CategoryID = id
+	}
+	page.InStockOnly, _ = p.Args["inStockOnly"].(bool)
+
+	return loadersFrom(ctx).storeListings.Load(page), nil
+}
+
+// resolveNearbyStores searches for stores around a point, as GET /stores/nearby does
+func (s *Schema) resolveNearbyStores(ctx context.Context, p graphql.ResolveParams) (any, error) {
+	q := repository.NearbyStoresQuery{
+		Lat:      p.Args["lat"].(float64),
+		Lng:      p.Args["lng"].(float64),
+		RadiusKm: p.Args["radiusKm"].(float64),
+		Limit:    p.Args["limit"].(int),
+	}
+	q.StoreType, _ = p.Args["storeType"].(string)
+
+	switch {
+	case q.Lat < -90 || q.Lat > 90 || q.Lng < -180 || q.Lng > 180:
+		return nil, errors.New("lat must be between -90 and 90 and lng between -180 and 180")
+	case q.RadiusKm <= 0 || q.RadiusKm > maxNearbyRadiusKm:
+		return nil, fmt.Errorf("radiusKm must be greater than 0 and at most %g", maxNearbyRadiusKm)
+	case q.Limit < 1 || q.Limit > maxPageSize:
+		return nil, fmt.Errorf("limit must be between 1 and %d", maxPageSize)
+	}
+
+	stores, err := s.repo.FindNearbyStores(ctx, q)
+	if err != nil {
+		s.log(ctx).Error("Failed to find nearby stores", zap.Error(err))
+		return nil, errors.New("Failed to search nearby stores")
+	}
+	return stores, nil
+}
+
+// loadCategory loads the category with id, or resolves to null without one
+func loadCategory(ctx context.Context, id *string) any {
+	if id == nil {
+		return nil
+	}
+	return loadersFrom(ctx).categories.Load(*id)
+}
+
+// uuidArg returns the UUID argument name
+func uuidArg(p graphql.ResolveParams, name string) (string, error) {
+	id := p.Args[name].(string)
+	if _, err := uuid.Parse(id); err != nil {
+		return "", fmt.Errorf("%s must be a UUID", name)
+	}
+	return id, nil
+}
+
+// uuidsArg returns the list of UUIDs argument name
+func uuidsArg(p graphql.ResolveParams, name string) ([]string, error) {
+	values := p.Args[name].([]any)
+	if len(values) > maxIDs {
+		return nil, fmt.Errorf("%s may list at most %d IDs", name, maxIDs)
+	}
+	ids := make([]string, len(values))
+	for i, v := range values {
+		ids[i] = v.(string)
+		if _, err := uuid.Parse(ids[i]); err != nil {
+			return nil, fmt.Errorf("%s must list UUIDs", name)
+		}
+	}
+	return ids, nil
+}
@@ -0,0 +1,302 @@
+package catalog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yourusername/supabase-redis-middleware/internal/cache"
+	"github.com/yourusername/supabase-redis-middleware/internal/graphql"
+	"github.com/yourusername/supabase-redis-middleware/internal/repository"
+	"go.uber.org/zap"
+)
+
+const (
+	storeA   = "11111111-1111-1111-1111-111111111111"
+	storeB   = "22222222-2222-2222-2222-222222222222"
+	productA = "33333333-3333-3333-3333-333333333333"
+	dairy    = "44444444-4444-4444-4444-444444444444"
+	butter   = "55555555-5555-5555-5555-555555555555"
+)
+
+// fakeRepository serves a small catalog and records the calls made of it
+type fakeRepository struct {
+	calls []string
+	err   error
+}
+
+func (r *fakeRepository) call(name string) {
+	r.calls = append(r.calls, name)
+}
+
+var testStores = map[string]repository.Store{
+	storeA: {ID: storeA, Name: "Downtown Market", StoreType: "supermarket"},
+	storeB: {ID: storeB, Name: "Corner Pharmacy", StoreType: "pharmacy"},
+}
+
+func (r *fakeRepository) GetStoresByIDs(ctx context.Context, ids []string) ([]repository.Store, error) {
+	r.call("GetStoresByIDs")
+	var out []repository.Store
+	for _, id := range ids {
+		if store, ok := testStores[id]; ok {
+			out = append(out, store)
+		}
+	}
+	return out, r.err
+}
+
+func (r *fakeRepository) GetProductsByIDs(ctx context.Context, ids []string) ([]repository.Product, error) {
+	r.call("GetProductsByIDs")
+	var out []repository.Product
+	for _, id := range ids {
+		if id == productA {
+			out = append(out, repository.Product{ID: productA, Name: "Amul Butter", CategoryID: strPtr(butter)})
+		}
+	}
+	return out, r.err
+}
+
+func (r *fakeRepository) ListListingsByStores(ctx context.Context, storeIDs []string, filter repository.ListingFilter, pagination repository.Pagination) ([]repository.StoreListing, error) {
+	r.call("ListListingsByStores")
+	var out []repository.StoreListing
+	for _, id := range storeIDs {
+		out = append(out, listing(id))
+	}
+	return out, r.err
+}
+
+func (r *fakeRepository) ListListingsByProducts(ctx context.Context, productIDs []string) ([]repository.StoreListing, error) {
+	r.call("ListListingsByProducts")
+	return []repository.StoreListing{listing(storeB), listing(storeA)}, r.err
+}
+
+func (r *fakeRepository) ListVariationsByStoreProducts(ctx context.Context, storeProductIDs []string) ([]repository.Variation, error) {
+	r.call("ListVariationsByStoreProducts")
+	var out []repository.Variation
+	for _, id := range storeProductIDs {
+		out = append(out, repository.Variation{ID: "v-" + id, StoreProductID: id, DisplayName: "500 g", Price: 270})
+	}
+	return out, r.err
+}
+
+func (r *fakeRepository) ListCategoryTree(ctx context.Context, storeID string) ([]*repository.CategoryNode, error) {
+	r.call("ListCategoryTree")
+	child := &repository.CategoryNode{CategorySummary: repository.CategorySummary{ID: butter, ParentID: strPtr(dairy), Name: "Butter"}, Depth: 1, Children: []*repository.CategoryNode{}}
+	return []*repository.CategoryNode{{
+		CategorySummary: repository.CategorySummary{ID: dairy, Name: "Dairy"},
+		Children:        []*repository.CategoryNode{child},
+	}}, r.err
+}
+
+func (r *fakeRepository) FindNearbyStores(ctx context.Context, q repository.NearbyStoresQuery) ([]repository.NearbyStore, error) {
+	r.call("FindNearbyStores")
+	return []repository.NearbyStore{
+		{Store: testStores[storeA], DistanceKm: 1.2},
+		{Store: testStores[storeB], DistanceKm: 2.8},
+	}, r.err
+}
+
+func listing(storeID string) repository.StoreListing {
+	return repository.StoreListing{
+		StoreProductID: "sp-" + storeID[:1],
+		StoreID:        storeID,
+		ProductID:      productA,
+		Name:           "Amul Butter",
+		Price:          56,
+		CategoryID:     strPtr(butter),
+		UpdatedAt:      time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+}
+
+func strPtr(s string) *string { return &s }
+
+// mapCache is an in-memory cache; methods the schema doesn't use are left unimplemented
+type mapCache struct {
+	cache.CacheService
+	entries  map[string][]byte
+	getManys int
+}
+
+func (c *mapCache) GetMany(ctx context.Context, keys []string) (map[string][]byte, error) {
+	c.getManys++
+	out := make(map[string][]byte)
+	for _, k := range keys {
+		if v, ok := c.entries[k]; ok {
+			out[k] = v
+		}
+	}
+	return out, nil
+}
+
+func (c *mapCache) SetMany(ctx context.Context, entries map[string][]byte, ttl time.Duration) error {
+	for k, v := range entries {
+		c.entries[k] = v
+	}
+	return nil
+}
+
+func (c *mapCache) GenerateKey(domain string, params map[string]string) string {
+	parts := []string{domain}
+	for k, v := range params {
+		parts = append(parts, k+"="+v)
+	}
+	sort.Strings(parts[1:])
+	return strings.Join(parts, ":")
+}
+
+func newTestSchema(t *testing.T) (*Schema, *fakeRepository, *mapCache) {
+	t.Helper()
+	repo := &fakeRepository{}
+	c := &mapCache{entries: make(map[string][]byte)}
+	s, err := NewSchema(repo, c, cache.NewTTL(time.Minute), zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewSchema() error = %v", err)
+	}
+	return s, repo, c
+}
+
+func assertData(t *testing.T, resp *graphql.Response, want string) {
+	t.Helper()
+	if len(resp.Errors) > 0 {
+		t.Fatalf("errors = %+v", resp.Errors)
+	}
+	var got, w any
+	if err := json.Unmarshal(resp.Data, &got); err != nil {
+		t.Fatalf("data %s is not JSON: %v", resp.Data, err)
+	}
+	if err := json.Unmarshal([]byte(want), &w); err != nil {
+		t.Fatalf("want %s is not JSON: %v", want, err)
+	}
+	if !reflect.DeepEqual(got, w) {
+		t.Errorf("data = %s, want %s", resp.Data, want)
+	}
+}
+
+const nearbyQuery = `{
+	nearbyStores(lat: 12.97, lng: 77.59) {
+		name distanceKm
+		products(limit: 5) {
+			id price updatedAt
+			category { name parent { name } }
+			variations { displayName price }
+		}
+	}
+}`
+
+func TestNearbyStoresLoadsOneQueryPerLevel(t *testing.T) {
+	s, repo, c := newTestSchema(t)
+
+	resp := s.Execute(context.Background(), graphql.Request{Query: nearbyQuery})
+	assertData(t, resp, `{"nearbyStores": [
+		{"name": "Downtown Market", "distanceKm": 1.2, "products": [{
+			"id": "sp-1", "price": 56, "updatedAt": "2026-01-02T03:04:05Z",
+			"category": {"name": "Butter", "parent": {"name": "Dairy"}},
+			"variations": [{"displayName": "500 g", "price": 270}]
+		}]},
+		{"name": "Corner Pharmacy", "distanceKm": 2.8, "products": [{
+			"id": "sp-2", "price": 56, "updatedAt": "2026-01-02T03:04:05Z",
+			"category": {"name": "Butter", "parent": {"name": "Dairy"}},
+			"variations": [{"displayName": "500 g", "price": 270}]
+		}]}
+	]}`)
+	want := []string{"FindNearbyStores", "ListListingsByStores", "ListCategoryTree", "ListVariationsByStoreProducts"}
+	if !reflect.DeepEqual(repo.calls, want) {
+		t.Errorf("calls = %v, want %v", repo.calls, want)
+	}
+
+	// The listings, tree and variations are now cached, so only the search is repeated
+	repo.calls = nil
+	getManys := c.getManys
+	resp = s.Execute(context.Background(), graphql.Request{Query: nearbyQuery})
+	if len(resp.Errors) > 0 {
+		t.Fatalf("errors = %+v", resp.Errors)
+	}
+	if want := []string{"FindNearbyStores"}; !reflect.DeepEqual(repo.calls, want) {
+		t.Errorf("cached calls = %v, want %v", repo.calls, want)
+	}
+	if got := c.getManys - getManys; got != 3 {
+		t.Errorf("cache round trips = %d, want 3", got)
+	}
+}
+
+func TestProductPricesAndStores(t *testing.T) {
+	s, repo, _ := newTestSchema(t)
+
+	resp := s.Execute(context.Background(), graphql.Request{
+		Query: `query ($ids: [ID!]!) {
+			products(ids: $ids) { name prices { price store { name } } }
+		}`,
+		Variables: map[string]any{"ids": []any{productA, storeA}},
+	})
+	assertData(t, resp, `{"products": [{"name": "Amul Butter", "prices": [
+		{"price": 56, "store": {"name": "Corner Pharmacy"}},
+		{"price": 56, "store": {"name": "Downtown Market"}}
+	]}]}`)
+	want := []string{"GetProductsByIDs", "ListListingsByProducts", "GetStoresByIDs"}
+	if !reflect.DeepEqual(repo.calls, want) {
+		t.Errorf("calls = %v, want %v", repo.calls, want)
+	}
+}
+
+func TestCategoryLookups(t *testing.T) {
+	s, repo, _ := newTestSchema(t)
+
+	resp := s.Execute(context.Background(), graphql.Request{Query: `{
+		categories { name children { name } }
+		butter: category(id: "` + butter + `") { name depth parent { name } }
+		missing: category(id: "nope") { name }
+	}`})
+	assertData(t, resp, `{
+		"categories": [{"name": "Dairy", "children": [{"name": "Butter"}]}],
+		"butter": {"name": "Butter", "depth": 1, "parent": {"name": "Dairy"}},
+		"missing": null
+	}`)
+	if want := []string{"ListCategoryTree"}; !reflect.DeepEqual(repo.calls, want) {
+		t.Errorf("calls = %v, want %v", repo.calls, want)
+	}
+}
+
+func TestArgumentErrors(t *testing.T) {
+	s, repo, _ := newTestSchema(t)
+
+	resp := s.Execute(context.Background(), graphql.Request{Query: `{
+		store(id: "not-a-uuid") { name }
+		stores(ids: ["` + storeA + `"]) { name products(limit: 500) { id } }
+	}`})
+	if !resp.Executed() {
+		t.Fatalf("errors = %+v, want the query run", resp.Errors)
+	}
+	var messages []string
+	for _, err := range resp.Errors {
+		messages = append(messages, err.Message)
+	}
+	want := []string{"id must be a UUID", "limit must be between 1 and 100"}
+	if !reflect.DeepEqual(messages, want) {
+		t.Errorf("errors = %v, want %v", messages, want)
+	}
+	if want := []string{"GetStoresByIDs"}; !reflect.DeepEqual(repo.calls, want) {
+		t.Errorf("calls = %v, want %v", repo.calls, want)
+	}
+
+	// nearbyStores can't be null, so its error nulls the whole result
+	resp = s.Execute(context.Background(), graphql.Request{Query: `{ nearbyStores(lat: 91, lng: 0) { name } }`})
+	if string(resp.Data) != "null" || len(resp.Errors) != 1 || !strings.HasPrefix(resp.Errors[0].Message, "lat must be") {
+		t.Errorf("response = %s %+v, want null data and the lat error", resp.Data, resp.Errors)
+	}
+}
+
+func TestRepositoryErrorsAreNotShown(t *testing.T) {
+	s, repo, _ := newTestSchema(t)
+	repo.err = errors.New("connection refused")
+
+	resp := s.Execute(context.Background(), graphql.Request{Query: `{ store(id: "` + storeA + `") { name } }`})
+	if len(resp.Errors) != 1 || resp.Errors[0].Message != "Failed to load stores" {
+		t.Fatalf("errors = %+v, want Failed to load stores", resp.Errors)
+	}
+	assertData(t, &graphql.Response{Data: resp.Data}, `{"store": null}`)
+}
@@ -0,0 +1,13 @@
+package graphql
+
+// Error is an error as reported in a response's errors list
+// Path is set for errors resolving a field, Locations for those caused by part of the query
+type Error struct {
+	Message   string     `json:"message"`
+	Locations []Location `json:"locations,omitempty"`
+	Path      []any      `json:"path,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
@@ -0,0 +1,454 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Request is a GraphQL request, as POSTed or given in a GET query string
+type Request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// Response is a request's result
+// Data is absent when the request failed parsing or validation and so never ran, and
+// null when an error reached the root of a query that did
+type Response struct {
+	Errors []*Error        `json:"errors,omitempty"`
+	Data   json.RawMessage `json:"data,omitempty"`
+}
+
+// Executed reports whether the request ran, rather than being refused as invalid
+func (r *Response) Executed() bool {
+	return r.Data != nil
+}
+
+// Execute parses, validates and runs a query
+// Fields that fail are null in the data, with an error giving their path; a non-null
+// field that fails nulls its nearest nullable parent instead
+func (s *Schema) Execute(ctx context.Context, req Request) *Response {
+	doc, err := Parse(req.Query)
+	if err != nil {
+		return &Response{Errors: []*Error{err.(*Error)}}
+	}
+	if errs := validate(s, doc); len(errs) > 0 {
+		return &Response{Errors: errs}
+	}
+	op, err := doc.operation(req.OperationName)
+	if err != nil {
+		return &Response{Errors: []*Error{err.(*Error)}}
+	}
+	vars, errs := s.coerceVariables(op, req.Variables)
+	if len(errs) > 0 {
+		return &Response{Errors: errs}
+	}
+
+	e := &executor{ctx: ctx, doc: doc, vars: vars}
+	var data any
+	root := &cell{set: func(v any) { data = v }}
+	result := newOrderedMap()
+	root.set(result)
+	e.executeFields(s.query, nil, e.collectFields(s.query, op.SelectionSet, nil), result, root, nil)
+	e.drain()
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return &Response{Errors: append(e.errs, &Error{Message: "Failed to encode the result"}), Data: json.RawMessage("null")}
+	}
+	return &Response{Errors: e.errs, Data: raw}
+}
+
+// coerceVariables coerces the variables given with a request to the operation's
+// declared types, applying defaults
+func (s *Schema) coerceVariables(op *Operation, given map[string]any) (map[string]any, []*Error) {
+	vars := make(map[string]any, len(op.Variables))
+	var errs []*Error
+	for _, def := range op.Variables {
+		t, _ := s.inputType(def.Type) // Validation has checked it
+
+		value, ok := given[def.Name]
+		if !ok {
+			if def.Default != nil {
+				value, ok = literal(def.Default, nil), true
+			} else if isNonNull(t) {
+				errs = append(errs, &Error{
+					Message:   fmt.Sprintf(`Variable "$%s" of required type "%s" was not provided`, def.Name, t),
+					Locations: []Location{def.Loc},
+				})
+				continue
+			}
+		}
+		if !ok {
+			continue
+		}
+
+		coerced, err := coerceInput(value, t)
+		if err != nil {
+			errs = append(errs, &Error{
+				Message:   fmt.Sprintf(`Variable "$%s" got an invalid value: %s`, def.Name, err.Error()),
+				Locations: []Location{def.Loc},
+			})
+			continue
+		}
+		vars[def.Name] = coerced
+	}
+	return vars, errs
+}
+
+// cell is a position in the result that a value is written to: the data, an object's
+// field or a list's item
+type cell struct {
+	parent *cell
+	// nonNull is whether the position's type is non-null, so that writing null to it
+	// nulls the parent instead
+	nonNull bool
+	set     func(any)
+	nulled  bool
+}
+
+// detached reports whether the cell or one of its parents was nulled, so its value no
+// longer matters
+func (c *cell) detached() bool {
+	for ; c != nil; c = c.parent {
+		if c.nulled {
+			return true
+		}
+	}
+	return false
+}
+
+// deferred is a field whose resolver returned a Thunk, completed with the rest of its level
+type deferred struct {
+	cell   *cell
+	typ    Type
+	fields []*FieldNode
+	path   []any
+	thunk  Thunk
+}
+
+// executor runs one operation
+// Fields are resolved a level at a time: resolvers that return a Thunk are only called
+// back once every other field of their level has been resolved, so the loads they queued
+// are batched together
+type executor struct {
+	ctx     context.Context
+	doc     *Document
+	vars    map[string]any
+	errs    []*Error
+	pending []*deferred
+}
+
+// drain completes deferred fields a level at a time, until none are left
+func (e *executor) drain() {
+	for len(e.pending) > 0 {
+		level := e.pending
+		e.pending = nil
+		for _, d := range level {
+			if d.cell.detached() {
+				continue
+			}
+			value, err := d.thunk()
+			e.completeValue(d.cell, d.typ, d.fields, d.path, value, err)
+		}
+	}
+}
+
+// fieldGroups are the fields selected on an object, grouped by response key in order
+type fieldGroups struct {
+	keys   []string
+	fields map[string][]*FieldNode
+}
+
+// collectFields groups the fields selected on t, leaving out those skipped by @skip and
+// @include and looking through fragments
+func (e *executor) collectFields(t *Object, selections []Selection, groups *fieldGroups) *fieldGroups {
+	if groups == nil {
+		groups = &fieldGroups{fields: make(map[string][]*FieldNode)}
+	}
+	for _, sel := range selections {
+		switch sel := sel.(type) {
+		case *FieldNode:
+			if !e.included(sel.Directives) {
+				continue
+			}
+			key := sel.ResponseKey()
+			if _, ok := groups.fields[key]; !ok {
+				groups.keys = append(groups.keys, key)
+			}
+			groups.fields[key] = append(groups.fields[key], sel)
+		case *InlineFragment:
+			if e.included(sel.Directives) && (sel.TypeCondition == "" || sel.TypeCondition == t.Name) {
+				e.collectFields(t, sel.SelectionSet, groups)
+			}
+		case *FragmentSpread:
+			if !e.included(sel.Directives) {
+				continue
+			}
+			if f := e.doc.fragment(sel.Name); f != nil && f.TypeCondition == t.Name && e.included(f.Directives) {
+				e.collectFields(t, f.SelectionSet, groups)
+			}
+		}
+	}
+	return groups
+}
+
+// included evaluates @skip and @include
+func (e *executor) included(directives []*Directive) bool {
+	for _, d := range directives {
+		if len(d.Arguments) == 0 {
+			continue
+		}
+		condition, _ := literal(d.Arguments[0].Value, e.vars).(bool)
+		if (d.Name == "skip" && condition) || (d.Name == "include" && !condition) {
+			return false
+		}
+	}
+	return true
+}
+
+// executeFields resolves the fields selected on an object of type t, whose Go value is
+// source, into out
+func (e *executor) executeFields(t *Object, source any, groups *fieldGroups, out *orderedMap, objectCell *cell, path []any) {
+	for _, key := range groups.keys {
+		fields := groups.fields[key]
+		if fields[0].Name == "__typename" {
+			out.set(key, t.Name)
+			continue
+		}
+
+		def := t.Field(fields[0].Name)
+		out.set(key, nil)
+		fieldCell := &cell{parent: objectCell, nonNull: isNonNull(def.Type), set: func(v any) { out.set(key, v) }}
+		fieldPath := append(path[:len(path):len(path)], key)
+
+		args, err := e.argumentValues(def.Args, fields[0].Arguments)
+		if err != nil {
+			e.completeValue(fieldCell, def.Type, fields, fieldPath, nil, err)
+			continue
+		}
+		resolve := def.Resolve
+		if resolve == nil {
+			resolve = structField(def.Name)
+		}
+		value, err := resolve(e.ctx, ResolveParams{Source: source, Args: args})
+		e.completeValue(fieldCell, def.Type, fields, fieldPath, value, err)
+	}
+}
+
+// argumentValues coerces a field's arguments, applying defaults
+func (e *executor) argumentValues(defs []*Argument, given []*ArgumentNode) (map[string]any, error) {
+	args := make(map[string]any, len(defs))
+	for _, def := range defs {
+		var node *ArgumentNode
+		for _, arg := range given {
+			if arg.Name == def.Name {
+				node = arg
+			}
+		}
+
+		// Arguments given a variable without a value are treated as not given
+		if node == nil || (node.Value.Kind == VariableValue && !hasKey(e.vars, node.Value.Raw)) {
+			if def.DefaultValue != nil {
+				args[def.Name] = def.DefaultValue
+			} else if isNonNull(def.Type) {
+				return nil, fmt.Errorf(`Argument "%s" of required type "%s" was not provided`, def.Name, def.Type)
+			}
+			continue
+		}
+
+		value, err := coerceInput(literal(node.Value, e.vars), def.Type)
+		if err != nil {
+			return nil, fmt.Errorf(`Argument "%s" got an invalid value: %w`, def.Name, err)
+		}
+		args[def.Name] = value
+	}
+	return args, nil
+}
+
+// completeValue writes a resolved value to c as typ requires, resolving the fields of
+// objects; a Thunk is deferred to the next level
+func (e *executor) completeValue(c *cell, typ Type, fields []*FieldNode, path []any, value any, err error) {
+	if err != nil {
+		e.fieldError(c, fields, path, err.Error())
+		return
+	}
+	if thunk, ok := value.(Thunk); ok {
+		e.pending = append(e.pending, &deferred{cell: c, typ: typ, fields: fields, path: path, thunk: thunk})
+		return
+	}
+
+	rv := indirect(reflect.ValueOf(value))
+	if nn, ok := typ.(*NonNull); ok {
+		if !rv.IsValid() {
+			e.fieldError(c, fields, path, "Cannot return null for non-nullable field")
+			return
+		}
+		typ = nn.OfType
+	}
+	if !rv.IsValid() {
+		c.set(nil)
+		return
+	}
+
+	switch t := typ.(type) {
+	case *Scalar:
+		out, ok := t.serialize(rv)
+		if !ok {
+			e.fieldError(c, fields, path, fmt.Sprintf("%s cannot represent a value of type %s", t.Name, rv.Type()))
+			return
+		}
+		c.set(out)
+	case *List:
+		if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+			e.fieldError(c, fields, path, fmt.Sprintf("Expected a list, got a value of type %s", rv.Type()))
+			return
+		}
+		items := make([]any, rv.Len())
+		c.set(items)
+		itemNonNull := isNonNull(t.OfType)
+		for i := range items {
+			item := &cell{parent: c, nonNull: itemNonNull, set: func(v any) { items[i] = v }}
+			e.completeValue(item, t.OfType, fields, append(path[:len(path):len(path)], i), rv.Index(i).Interface(), nil)
+		}
+	case *Object:
+		out := newOrderedMap()
+		c.set(out)
+		groups := &fieldGroups{fields: make(map[string][]*FieldNode)}
+		for _, f := range fields {
+			e.collectFields(t, f.SelectionSet, groups)
+		}
+		e.executeFields(t, rv.Interface(), groups, out, c, path)
+	}
+}
+
+// fieldError reports an error for the field at path and nulls it, or its nearest
+// nullable parent if it is non-null
+func (e *executor) fieldError(c *cell, fields []*FieldNode, path []any, message string) {
+	e.errs = append(e.errs, &Error{Message: message, Locations: []Location{fields[0].Loc}, Path: path})
+	for c.nonNull && c.parent != nil {
+		c = c.parent
+	}
+	c.set(nil)
+	c.nulled = true
+}
+
+// indirect follows pointers and interfaces, returning the zero Value for nil
+func indirect(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	if v.IsValid() && (v.Kind() == reflect.Slice || v.Kind() == reflect.Map) && v.IsNil() {
+		return reflect.Value{}
+	}
+	return v
+}
+
+func hasKey(m map[string]any, key string) bool {
+	_, ok := m[key]
+	return ok
+}
+
+// structFieldIndexes caches, per struct type, the index of the field with each json tag
+var structFieldIndexes sync.Map // reflect.Type -> map[string][]int
+
+// structField returns the default resolver of a field: it reads the struct field of the
+// source whose json tag is the field's name in snake_case, looking through embedded
+// structs, and resolves to null when there is none
+func structField(name string) ResolveFunc {
+	tag := snakeCase(name)
+	return func(ctx context.Context, p ResolveParams) (any, error) {
+		v := indirect(reflect.ValueOf(p.Source))
+		if !v.IsValid() || v.Kind() != reflect.Struct {
+			return nil, nil
+		}
+		index, ok := jsonFields(v.Type())[tag]
+		if !ok {
+			return nil, nil
+		}
+		return v.FieldByIndex(index).Interface(), nil
+	}
+}
+
+func jsonFields(t reflect.Type) map[string][]int {
+	if cached, ok := structFieldIndexes.Load(t); ok {
+		return cached.(map[string][]int)
+	}
+	fields := make(map[string][]int)
+	for _, f := range reflect.VisibleFields(t) {
+		if !f.IsExported() || f.Anonymous {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		// The shallowest field wins, as encoding/json has it
+		if existing, ok := fields[name]; !ok || len(f.Index) < len(existing) {
+			fields[name] = f.Index
+		}
+	}
+	structFieldIndexes.Store(t, fields)
+	return fields
+}
+
+// snakeCase converts a camelCase field name to the snake_case of the JSON API
+func snakeCase(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		if r >= 'A' && r <= 'Z' {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r += 'a' - 'A'
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// orderedMap is an object in the result, encoded with its keys in the order selected
+type orderedMap struct {
+	keys   []string
+	values map[string]any
+}
+
+func newOrderedMap() *orderedMap {
+	return &orderedMap{values: make(map[string]any)}
+}
+
+func (m *orderedMap) set(key string, value any) {
+	if _, ok := m.values[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = value
+}
+
+func (m *orderedMap) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, key := range m.keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		k, _ := json.Marshal(key)
+		b.Write(k)
+		b.WriteByte(':')
+		v, err := json.Marshal(m.values[key])
+		if err != nil {
+			return nil, err
+		}
+		b.Write(v)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
@@ -0,0 +1,335 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type testAuthor struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type testBook struct {
+	ID       string  `json:"id"`
+	Title    string  `json:"title"`
+	AuthorID string  `json:"author_id"`
+	Price    float64 `json:"price"`
+}
+
+type testLoadersKey struct{}
+
+// newTestSchema returns a schema of books and their authors, whose authors are loaded
+// with the loader in the context
+func newTestSchema(t *testing.T) *Schema {
+	t.Helper()
+	author := &Object{Name: "Author", Description: "Someone who writes books"}
+	book := &Object{Name: "Book"}
+	author.Fields = []*Field{
+		{Name: "id", Type: NewNonNull(ID)},
+		{Name: "name", Type: NewNonNull(String)},
+	}
+	book.Fields = []*Field{
+		{Name: "id", Type: NewNonNull(ID)},
+		{Name: "title", Type: NewNonNull(String)},
+		{Name: "price", Type: Float},
+		{
+			Name: "author",
+			Type: author,
+			Resolve: func(ctx context.Context, p ResolveParams) (any, error) {
+				return ctx.Value(testLoadersKey{}).(*Loader[string, testAuthor]).Load(p.Source.(testBook).AuthorID), nil
+			},
+		},
+		{
+			Name: "requiredAuthor",
+			Type: NewNonNull(author),
+			Resolve: func(ctx context.Context, p ResolveParams) (any, error) {
+				return ctx.Value(testLoadersKey{}).(*Loader[string, testAuthor]).Load(p.Source.(testBook).AuthorID), nil
+			},
+		},
+		{
+			Name: "failing",
+			Type: String,
+			Resolve: func(ctx context.Context, p ResolveParams) (any, error) {
+				return nil, errors.New("Failed to load")
+			},
+		},
+	}
+
+	books := []testBook{
+		{ID: "b1", Title: "One", AuthorID: "a1", Price: 10.5},
+		{ID: "b2", Title: "Two", AuthorID: "a2", Price: 8},
+		{ID: "b3", Title: "Three", AuthorID: "a1"},
+		{ID: "b4", Title: "Orphan", AuthorID: "missing"},
+	}
+	query := &Object{
+		Name: "Query",
+		Fields: []*Field{
+			{
+				Name: "books",
+				Type: NewNonNull(NewList(NewNonNull(book))),
+				Args: []*Argument{
+					{Name: "limit", Type: Int, DefaultValue: 10, Description: "At most 10"},
+					{Name: "ids", Type: NewList(NewNonNull(ID))},
+				},
+				Resolve: func(ctx context.Context, p ResolveParams) (any, error) {
+					var out []testBook
+					ids, _ := p.Args["ids"].([]any)
+					for _, b := range books {
+						if len(out) == p.Args["limit"].(int) {
+							break
+						}
+						if ids != nil && !containsAny(ids, b.ID) {
+							continue
+						}
+						out = append(out, b)
+					}
+					return out, nil
+				},
+			},
+			{
+				Name: "book",
+				Type: book,
+				Args: []*Argument{{Name: "id", Type: NewNonNull(ID)}},
+				Resolve: func(ctx context.Context, p ResolveParams) (any, error) {
+					for _, b := range books {
+						if b.ID == p.Args["id"] {
+							return &b, nil
+						}
+					}
+					return nil, nil
+				},
+			},
+		},
+	}
+
+	s, err := NewSchema(query, 4)
+	if err != nil {
+		t.Fatalf("NewSchema() error = %v", err)
+	}
+	return s
+}
+
+func containsAny(values []any, v any) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+// execute runs query with a fresh author loader and returns the response and the batches
+// the loader fetched
+func execute(t *testing.T, s *Schema, req Request) (*Response, [][]string) {
+	t.Helper()
+	authors := map[string]testAuthor{"a1": {ID: "a1", Name: "Ann"}, "a2": {ID: "a2", Name: "Bob"}}
+	var batches [][]string
+	ctx := context.Background()
+	loader := NewLoader(ctx, func(ctx context.Context, keys []string) (map[string]testAuthor, error) {
+		batches = append(batches, keys)
+		out := make(map[string]testAuthor)
+		for _, k := range keys {
+			if a, ok := authors[k]; ok {
+				out[k] = a
+			}
+		}
+		return out, nil
+	})
+	return s.Execute(context.WithValue(ctx, testLoadersKey{}, loader), req), batches
+}
+
+func assertJSON(t *testing.T, got json.RawMessage, want string) {
+	t.Helper()
+	var g, w any
+	if err := json.Unmarshal(got, &g); err != nil {
+		t.Fatalf("data %s is not JSON: %v", got, err)
+	}
+	if err := json.Unmarshal([]byte(want), &w); err != nil {
+		t.Fatalf("want %s is not JSON: %v", want, err)
+	}
+	if !reflect.DeepEqual(g, w) {
+		t.Errorf("data = %s, want %s", got, want)
+	}
+}
+
+func TestExecuteBatchesLoadsPerLevel(t *testing.T) {
+	s := newTestSchema(t)
+	resp, batches := execute(t, s, Request{Query: `{
+		books(limit: 3) { title author { name } }
+	}`})
+	if len(resp.Errors) > 0 {
+		t.Fatalf("errors = %+v", resp.Errors)
+	}
+	assertJSON(t, resp.Data, `{"books": [
+		{"title": "One", "author": {"name": "Ann"}},
+		{"title": "Two", "author": {"name": "Bob"}},
+		{"title": "Three", "author": {"name": "Ann"}}
+	]}`)
+	if want := [][]string{{"a1", "a2"}}; !reflect.DeepEqual(batches, want) {
+		t.Errorf("batches = %v, want %v", batches, want)
+	}
+}
+
+func TestExecuteFragmentsAliasesAndVariables(t *testing.T) {
+	s := newTestSchema(t)
+	resp, _ := execute(t, s, Request{
+		Query: `query Books($ids: [ID!], $withPrice: Boolean!) {
+			picked: books(ids: $ids) { ...BookFields price @include(if: $withPrice) }
+			one: book(id: "b2") { ... on Book { __typename id } }
+		}
+		fragment BookFields on Book { id title }`,
+		Variables: map[string]any{"ids": []any{"b1", "b3"}, "withPrice": false},
+	})
+	if len(resp.Errors) > 0 {
+		t.Fatalf("errors = %+v", resp.Errors)
+	}
+	assertJSON(t, resp.Data, `{
+		"picked": [{"id": "b1", "title": "One"}, {"id": "b3", "title": "Three"}],
+		"one": {"__typename": "Book", "id": "b2"}
+	}`)
+	if !strings.HasPrefix(string(resp.Data), `{"picked":`) {
+		t.Errorf("data = %s, want fields in query order", resp.Data)
+	}
+}
+
+func TestExecuteCoercesSingleValueToList(t *testing.T) {
+	s := newTestSchema(t)
+	resp, _ := execute(t, s, Request{
+		Query:     `query ($ids: [ID!]) { books(ids: $ids) { id } }`,
+		Variables: map[string]any{"ids": "b2"},
+	})
+	if len(resp.Errors) > 0 {
+		t.Fatalf("errors = %+v", resp.Errors)
+	}
+	assertJSON(t, resp.Data, `{"books": [{"id": "b2"}]}`)
+}
+
+func TestExecuteFieldErrors(t *testing.T) {
+	s := newTestSchema(t)
+
+	t.Run("nullable field is nulled", func(t *testing.T) {
+		resp, _ := execute(t, s, Request{Query: `{ book(id: "b1") { id failing } }`})
+		assertJSON(t, resp.Data, `{"book": {"id": "b1", "failing": null}}`)
+		if len(resp.Errors) != 1 || resp.Errors[0].Message != "Failed to load" {
+			t.Fatalf("errors = %+v, want the resolver's", resp.Errors)
+		}
+		if want := []any{"book", "failing"}; !reflect.DeepEqual(resp.Errors[0].Path, want) {
+			t.Errorf("path = %v, want %v", resp.Errors[0].Path, want)
+		}
+	})
+
+	t.Run("missing value of nullable field", func(t *testing.T) {
+		resp, _ := execute(t, s, Request{Query: `{ book(id: "b4") { author { name } } }`})
+		if len(resp.Errors) > 0 {
+			t.Fatalf("errors = %+v", resp.Errors)
+		}
+		assertJSON(t, resp.Data, `{"book": {"author": null}}`)
+	})
+
+	t.Run("non-null field nulls its parent", func(t *testing.T) {
+		resp, _ := execute(t, s, Request{Query: `{ book(id: "b4") { id requiredAuthor { name } } }`})
+		assertJSON(t, resp.Data, `{"book": null}`)
+		if len(resp.Errors) != 1 {
+			t.Fatalf("errors = %+v, want 1", resp.Errors)
+		}
+		if want := []any{"book", "requiredAuthor"}; !reflect.DeepEqual(resp.Errors[0].Path, want) {
+			t.Errorf("path = %v, want %v", resp.Errors[0].Path, want)
+		}
+	})
+
+	t.Run("null propagates to the root", func(t *testing.T) {
+		resp, _ := execute(t, s, Request{Query: `{ books { requiredAuthor { name } } }`})
+		if !resp.Executed() || string(resp.Data) != "null" {
+			t.Errorf("data = %s, want null", resp.Data)
+		}
+		if len(resp.Errors) != 1 {
+			t.Fatalf("errors = %+v, want 1", resp.Errors)
+		}
+		if want := []any{"books", 3, "requiredAuthor"}; !reflect.DeepEqual(resp.Errors[0].Path, want) {
+			t.Errorf("path = %v, want %v", resp.Errors[0].Path, want)
+		}
+	})
+}
+
+func TestExecuteRefusesInvalidRequests(t *testing.T) {
+	s := newTestSchema(t)
+	tests := []struct {
+		name    string
+		req     Request
+		message string
+	}{
+		{"syntax", Request{Query: `{ books { id }`}, "Syntax error"},
+		{"unknown field", Request{Query: `{ books { isbn } }`}, `Cannot query field "isbn" on type "Book"`},
+		{"unknown argument", Request{Query: `{ books(first: 1) { id } }`}, `Unknown argument "first"`},
+		{"missing argument", Request{Query: `{ book { id } }`}, `Argument "id" of type "ID!" is required`},
+		{"bad literal", Request{Query: `{ books(limit: "ten") { id } }`}, "Int"},
+		{"leaf without selection", Request{Query: `{ book(id: "b1") }`}, "must have a selection"},
+		{"selection on leaf", Request{Query: `{ books { id { x } } }`}, "must not have a selection"},
+		{"mutation", Request{Query: `mutation { books { id } }`}, "mutation"},
+		{"unknown fragment", Request{Query: `{ books { ...Missing } }`}, `Unknown fragment "Missing"`},
+		{"unused fragment", Request{Query: `{ books { id } } fragment F on Book { id }`}, `Fragment "F" is never used`},
+		{"fragment cycle", Request{Query: `{ books { ...A } } fragment A on Book { ...B } fragment B on Book { ...A }`}, `Fragment "A" spreads itself`},
+		{"conflicting aliases", Request{Query: `{ books { x: id x: title } }`}, `"x"`},
+		{"undefined variable", Request{Query: `{ books(limit: $n) { id } }`}, `"$n" is not defined`},
+		{"unused variable", Request{Query: `query ($n: Int) { books { id } }`}, `"$n" is never used`},
+		{"variable type", Request{Query: `query ($n: String) { books(limit: $n) { id } }`}, "$n"},
+		{"missing variable", Request{Query: `query ($id: ID!) { book(id: $id) { id } }`}, "$id"},
+		{"variable value", Request{Query: `query ($n: Int) { books(limit: $n) { id } }`, Variables: map[string]any{"n": "ten"}}, "$n"},
+		{"operation name", Request{Query: `query A { books { id } } query B { books { id } }`}, "operationName"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, _ := execute(t, s, tt.req)
+			if resp.Executed() {
+				t.Fatalf("data = %s, want the request refused", resp.Data)
+			}
+			if len(resp.Errors) == 0 || !strings.Contains(resp.Errors[0].Message, tt.message) {
+				t.Errorf("errors = %+v, want one containing %q", resp.Errors, tt.message)
+			}
+		})
+	}
+}
+
+func TestExecuteRefusesDeepQueries(t *testing.T) {
+	node := &Object{Name: "Node"}
+	node.Fields = []*Field{
+		{Name: "id", Type: ID},
+		{Name: "next", Type: node, Resolve: func(ctx context.Context, p ResolveParams) (any, error) { return p.Source, nil }},
+	}
+	s, err := NewSchema(&Object{Name: "Query", Fields: []*Field{
+		{Name: "node", Type: node, Resolve: func(ctx context.Context, p ResolveParams) (any, error) { return struct{ ID string }{"n"}, nil }},
+	}}, 3)
+	if err != nil {
+		t.Fatalf("NewSchema() error = %v", err)
+	}
+
+	resp := s.Execute(context.Background(), Request{Query: `{ node { next { next { id } } } }`})
+	if resp.Executed() || len(resp.Errors) != 1 || !strings.Contains(resp.Errors[0].Message, "at most 3") {
+		t.Errorf("response = %+v, want the query refused for its depth", resp)
+	}
+	resp = s.Execute(context.Background(), Request{Query: `{ node { next { id } } }`})
+	if !resp.Executed() || len(resp.Errors) > 0 {
+		t.Errorf("errors = %+v, want the query run", resp.Errors)
+	}
+}
+
+func TestSDL(t *testing.T) {
+	sdl := newTestSchema(t).SDL()
+	for _, want := range []string{
+		"type Query {\n",
+		"  books(\n    \"\"\"At most 10\"\"\"\n    limit: Int = 10\n    ids: [ID!]\n  ): [Book!]!\n",
+		"  book(id: ID!): Book\n",
+		"\"\"\"Someone who writes books\"\"\"\ntype Author {\n",
+		"  requiredAuthor: Author!\n",
+	} {
+		if !strings.Contains(sdl, want) {
+			t.Errorf("SDL missing %q:\n%s", want, sdl)
+		}
+	}
+}
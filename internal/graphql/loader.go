@@ -0,0 +1,90 @@
+package graphql
+
+import "context"
+
+// BatchFunc loads the values of keys at once; keys missing from the result load as null
+type BatchFunc[K comparable, V any] func(ctx context.Context, keys []K) (map[K]V, error)
+
+// Loader batches and caches the loads of one request
+// Keys queued by Load are fetched together the first time one of their thunks is
+// called, which the executor only does once the whole level has been resolved; each key
+// is fetched at most once per request. Loaders aren't safe for concurrent use, nor
+// need to be: a request is executed on one goroutine
+type Loader[K comparable, V any] struct {
+	ctx     context.Context
+	batch   BatchFunc[K, V]
+	queued  []K
+	results map[K]*loadResult[V]
+}
+
+type loadResult[V any] struct {
+	value V
+	found bool
+	err   error
+	done  bool
+}
+
+// NewLoader creates a loader fetching with batch, for a request with context ctx
+func NewLoader[K comparable, V any](ctx context.Context, batch BatchFunc[K, V]) *Loader[K, V] {
+	return &Loader[K, V]{ctx: ctx, batch: batch, results: make(map[K]*loadResult[V])}
+}
+
+// Load queues key and returns a thunk resolving to its value, or null if it has none
+func (l *Loader[K, V]) Load(key K) Thunk {
+	result, ok := l.results[key]
+	if !ok {
+		result = &loadResult[V]{}
+		l.results[key] = result
+		l.queued = append(l.queued, key)
+	}
+
+	return func() (any, error) {
+		if !result.done {
+			l.dispatch()
+		}
+		if result.err != nil || !result.found {
+			return nil, result.err
+		}
+		return result.value, nil
+	}
+}
+
+// LoadMany queues keys and returns a thunk resolving to their values, in order, leaving
+// out keys without one
+func (l *Loader[K, V]) LoadMany(keys []K) Thunk {
+	thunks := make([]Thunk, len(keys))
+	for i, key := range keys {
+		thunks[i] = l.Load(key)
+	}
+
+	return func() (any, error) {
+		values := make([]V, 0, len(keys))
+		for _, thunk := range thunks {
+			value, err := thunk()
+			if err != nil {
+				return nil, err
+			}
+			if value != nil {
+				values = append(values, value.(V))
+			}
+		}
+		return values, nil
+	}
+}
+
+// dispatch fetches every queued key
+func (l *Loader[K, V]) dispatch() {
+	keys := l.queued
+	l.queued = nil
+	if len(keys) == 0 {
+		return
+	}
+
+	values, err := l.batch(l.ctx, keys)
+	for _, key := range keys {
+		result := l.results[key]
+		result.done = true
+		result.err = err
+		result.value, result.found = values[key]
+	}
+}
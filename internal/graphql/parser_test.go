@@ -0,0 +1,103 @@
+package graphql
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	doc, err := Parse(`
+		# Stores near a point
+		query Nearby($lat: Float!, $ids: [ID!] = ["a", "b"]) {
+			first: store(id: "1") { ...StoreFields }
+			stores(ids: $ids) @include(if: true) {
+				... on Store { name }
+			}
+		}
+
+		fragment StoreFields on Store {
+			name
+			description(format: """
+				Plain
+				  text
+			""")
+		}
+	`)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if len(doc.Operations) != 1 || len(doc.Fragments) != 1 {
+		t.Fatalf("got %d operations and %d fragments, want 1 and 1", len(doc.Operations), len(doc.Fragments))
+	}
+
+	op := doc.Operations[0]
+	if op.Name != "Nearby" || len(op.Variables) != 2 {
+		t.Fatalf("operation = %q with %d variables, want Nearby with 2", op.Name, len(op.Variables))
+	}
+	if got := op.Variables[1].Type.String(); got != "[ID!]" {
+		t.Errorf("$ids type = %s, want [ID!]", got)
+	}
+	if def := op.Variables[1].Default; def == nil || def.Kind != ListValue || len(def.List) != 2 {
+		t.Errorf("$ids default = %+v, want a list of 2", def)
+	}
+
+	first, ok := op.SelectionSet[0].(*FieldNode)
+	if !ok || first.ResponseKey() != "first" || first.Name != "store" {
+		t.Fatalf("first selection = %+v, want store aliased first", op.SelectionSet[0])
+	}
+	if _, ok := first.SelectionSet[0].(*FragmentSpread); !ok {
+		t.Errorf("store selection = %T, want a fragment spread", first.SelectionSet[0])
+	}
+	stores := op.SelectionSet[1].(*FieldNode)
+	if len(stores.Directives) != 1 || stores.Directives[0].Name != "include" {
+		t.Errorf("stores directives = %+v, want @include", stores.Directives)
+	}
+	if inline, ok := stores.SelectionSet[0].(*InlineFragment); !ok || inline.TypeCondition != "Store" {
+		t.Errorf("stores selection = %+v, want an inline fragment on Store", stores.SelectionSet[0])
+	}
+
+	description := doc.Fragments[0].SelectionSet[1].(*FieldNode)
+	if got := description.Arguments[0].Value.Raw; got != "Plain\n  text" {
+		t.Errorf("block string = %q, want dedented %q", got, "Plain\n  text")
+	}
+}
+
+func TestParseShorthandQuery(t *testing.T) {
+	doc, err := Parse(`{ categories { id } }`)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if op := doc.Operations[0]; op.Type != "query" || op.Name != "" {
+		t.Errorf("operation = %s %q, want an anonymous query", op.Type, op.Name)
+	}
+}
+
+func TestParseSyntaxErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		line  int
+	}{
+		{"empty", "", 1},
+		{"unclosed selection", "{ store(id: \"1\") {\n name", 2},
+		{"unterminated string", `{ store(id: "1) { name } }`, 1},
+		{"missing argument value", "{\n  store(id: ) { name }\n}", 2},
+		{"variable in default", "query ($a: Int = $b) { categories { id } }", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.query)
+			if err == nil {
+				t.Fatal("Parse() error = nil, want a syntax error")
+			}
+			gqlErr := err.(*Error)
+			if !strings.HasPrefix(gqlErr.Message, "Syntax error") {
+				t.Errorf("message = %q, want a syntax error", gqlErr.Message)
+			}
+			if len(gqlErr.Locations) != 1 || gqlErr.Locations[0].Line != tt.line {
+				t.Errorf("locations = %+v, want line %d", gqlErr.Locations, tt.line)
+			}
+		})
+	}
+}
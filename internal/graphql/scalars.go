@@ -0,0 +1,168 @@
+package graphql
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// Scalar is a leaf type; only the built-in scalars exist
+type Scalar struct {
+	Name        string
+	Description string
+	// serialize converts a resolved value to its result, reporting whether it could
+	serialize func(v reflect.Value) (any, bool)
+	// parse coerces a variable or literal value, reporting whether it could
+	parse func(v any) (any, bool)
+}
+
+func (s *Scalar) String() string { return s.Name }
+
+// The built-in scalars. Resolved values may be any Go value of a matching kind, or a
+// pointer to one; String also formats time.Time as RFC 3339
+var (
+	String = &Scalar{
+		Name:        "String",
+		Description: "UTF-8 text",
+		serialize:   serializeString,
+		parse: func(v any) (any, bool) {
+			s, ok := v.(string)
+			return s, ok
+		},
+	}
+	Int = &Scalar{
+		Name:        "Int",
+		Description: "A signed 32-bit integer",
+		serialize:   serializeInt,
+		parse:       parseInt,
+	}
+	Float = &Scalar{
+		Name:        "Float",
+		Description: "A double-precision floating point number",
+		serialize:   serializeFloat,
+		parse:       parseFloat,
+	}
+	Boolean = &Scalar{
+		Name:        "Boolean",
+		Description: "true or false",
+		serialize: func(v reflect.Value) (any, bool) {
+			if v.Kind() != reflect.Bool {
+				return nil, false
+			}
+			return v.Bool(), true
+		},
+		parse: func(v any) (any, bool) {
+			b, ok := v.(bool)
+			return b, ok
+		},
+	}
+	ID = &Scalar{
+		Name:        "ID",
+		Description: "A unique identifier, serialized as a string",
+		serialize: func(v reflect.Value) (any, bool) {
+			if n, ok := serializeInt(v); ok {
+				return strconv.Itoa(n.(int)), true
+			}
+			return serializeString(v)
+		},
+		parse: func(v any) (any, bool) {
+			if s, ok := v.(string); ok {
+				return s, true
+			}
+			if n, ok := parseInt(v); ok {
+				return strconv.Itoa(n.(int)), true
+			}
+			return nil, false
+		},
+	}
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func serializeString(v reflect.Value) (any, bool) {
+	if v.Type() == timeType {
+		return v.Interface().(time.Time).Format(time.RFC3339Nano), true
+	}
+	if v.Kind() != reflect.String {
+		return nil, false
+	}
+	return v.String(), true
+}
+
+func serializeInt(v reflect.Value) (any, bool) {
+	var n float64
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n = float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n = float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		n = v.Float()
+	default:
+		return nil, false
+	}
+	if n != math.Trunc(n) || n < math.MinInt32 || n > math.MaxInt32 {
+		return nil, false
+	}
+	return int(n), true
+}
+
+func serializeFloat(v reflect.Value) (any, bool) {
+	var f float64
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		f = float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		f = float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		f = v.Float()
+	default:
+		return nil, false
+	}
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil, false
+	}
+	return f, true
+}
+
+// parseInt accepts integers from literals (int64) and JSON variables (float64 or json.Number)
+func parseInt(v any) (any, bool) {
+	var n float64
+	switch x := v.(type) {
+	case int:
+		n = float64(x)
+	case int64:
+		n = float64(x)
+	case float64:
+		n = x
+	case json.Number:
+		i, err := x.Int64()
+		if err != nil {
+			return nil, false
+		}
+		n = float64(i)
+	default:
+		return nil, false
+	}
+	if n != math.Trunc(n) || n < math.MinInt32 || n > math.MaxInt32 {
+		return nil, false
+	}
+	return int(n), true
+}
+
+func parseFloat(v any) (any, bool) {
+	switch x := v.(type) {
+	case int:
+		return float64(x), true
+	case int64:
+		return float64(x), true
+	case float64:
+		return x, !math.IsNaN(x) && !math.IsInf(x, 0)
+	case json.Number:
+		f, err := x.Float64()
+		return f, err == nil
+	}
+	return nil, false
+}
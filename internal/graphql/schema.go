@@ -0,0 +1,198 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Type is a GraphQL type: a *Scalar, *Object, *List or *NonNull
+type Type interface {
+	// String renders the type as written in a schema, e.g. [Store!]!
+	String() string
+}
+
+// Object is an object type, whose fields are resolved from a Go value
+// Fields is a slice so that types can refer to each other: declare the objects first,
+// then set their fields
+type Object struct {
+	Name        string
+	Description string
+	Fields      []*Field
+}
+
+func (o *Object) String() string { return o.Name }
+
+// Field returns the field named name, or nil
+func (o *Object) Field(name string) *Field {
+	for _, f := range o.Fields {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// ResolveParams is what a resolver is given
+type ResolveParams struct {
+	// Source is the Go value of the object the field belongs to, with pointers followed;
+	// nil for Query fields
+	Source any
+	// Args holds the field's arguments, coerced to their types, with defaults applied
+	// Arguments that weren't given and have no default are absent
+	Args map[string]any
+}
+
+// ResolveFunc resolves a field's value from its source object and arguments
+// It may return a Thunk to have the value computed once the rest of the field's level
+// has been resolved, which is how loaders batch the loads of a whole level
+type ResolveFunc func(ctx context.Context, p ResolveParams) (any, error)
+
+// Thunk computes a deferred field value
+type Thunk func() (any, error)
+
+// Field is a field of an object type
+type Field struct {
+	Name        string
+	Description string
+	Type        Type
+	Args        []*Argument
+	// Resolve resolves the field; nil reads the struct field of the source whose json
+	// tag is the field name in snake_case, so types can mirror the REST API's JSON
+	Resolve ResolveFunc
+}
+
+// Argument is an argument of a field
+type Argument struct {
+	Name        string
+	Description string
+	Type        Type
+	// DefaultValue is used when the argument isn't given; nil means it has none
+	DefaultValue any
+}
+
+// List is a list of values of OfType
+type List struct {
+	OfType Type
+}
+
+// NewList returns the list type of t
+func NewList(t Type) *List { return &List{OfType: t} }
+
+func (l *List) String() string { return "[" + l.OfType.String() + "]" }
+
+// NonNull is OfType, but never null
+type NonNull struct {
+	OfType Type
+}
+
+// NewNonNull returns the non-null type of t
+func NewNonNull(t Type) *NonNull { return &NonNull{OfType: t} }
+
+func (n *NonNull) String() string { return n.OfType.String() + "!" }
+
+// Schema is an executable schema of read-only queries
+type Schema struct {
+	query *Object
+	// maxDepth bounds how deeply queries may nest fields; zero doesn't bound them
+	maxDepth int
+	// types holds every named type reachable from the query type, in the order found
+	types []Type
+}
+
+// NewSchema creates a schema whose queries start at query
+// Queries nesting fields more than maxDepth deep are refused; zero allows any depth
+func NewSchema(query *Object, maxDepth int) (*Schema, error) {
+	s := &Schema{query: query, maxDepth: maxDepth}
+	for _, scalar := range []*Scalar{String, Int, Float, Boolean, ID} {
+		s.types = append(s.types, scalar)
+	}
+	if err := s.addObject(query); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// addObject adds o and every type reachable from it, checking names are unique
+func (s *Schema) addObject(o *Object) error {
+	if existing := s.Type(o.Name); existing != nil {
+		if existing != Type(o) {
+			return fmt.Errorf("graphql: two types are named %s", o.Name)
+		}
+		return nil
+	}
+	if o.Name == "" || len(o.Fields) == 0 {
+		return errors.New("graphql: object types need a name and fields")
+	}
+	s.types = append(s.types, o)
+
+	seen := make(map[string]bool, len(o.Fields))
+	for _, f := range o.Fields {
+		if f.Type == nil || seen[f.Name] {
+			return fmt.Errorf("graphql: field %s.%s is duplicated or has no type", o.Name, f.Name)
+		}
+		seen[f.Name] = true
+
+		for _, a := range f.Args {
+			if !isInputType(a.Type) {
+				return fmt.Errorf("graphql: argument %s of %s.%s must have an input type", a.Name, o.Name, f.Name)
+			}
+		}
+		switch named := namedType(f.Type).(type) {
+		case *Object:
+			if err := s.addObject(named); err != nil {
+				return err
+			}
+		case *Scalar:
+			if s.Type(named.Name) != Type(named) {
+				return fmt.Errorf("graphql: field %s.%s has an unknown scalar type", o.Name, f.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// Type returns the named type called name, or nil
+func (s *Schema) Type(name string) Type {
+	for _, t := range s.types {
+		if namedTypeName(t) == name {
+			return t
+		}
+	}
+	return nil
+}
+
+// namedType unwraps list and non-null types down to the named type
+func namedType(t Type) Type {
+	for {
+		switch wrapped := t.(type) {
+		case *List:
+			t = wrapped.OfType
+		case *NonNull:
+			t = wrapped.OfType
+		default:
+			return t
+		}
+	}
+}
+
+func namedTypeName(t Type) string {
+	switch named := t.(type) {
+	case *Scalar:
+		return named.Name
+	case *Object:
+		return named.Name
+	}
+	return ""
+}
+
+// isInputType reports whether t may type arguments and variables; only scalars can
+func isInputType(t Type) bool {
+	_, ok := namedType(t).(*Scalar)
+	return ok
+}
+
+func isNonNull(t Type) bool {
+	_, ok := t.(*NonNull)
+	return ok
+}
@@ -0,0 +1,71 @@
+package graphql
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// SDL renders the schema in the GraphQL schema definition language, for clients and
+// tools generating types from it. The built-in scalars are implied, so left out
+func (s *Schema) SDL() string {
+	var b strings.Builder
+	for _, t := range s.types {
+		o, ok := t.(*Object)
+		if !ok {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		writeDescription(&b, "", o.Description)
+		b.WriteString("type " + o.Name + " {\n")
+		for _, f := range o.Fields {
+			writeDescription(&b, "  ", f.Description)
+			b.WriteString("  " + f.Name)
+			writeArguments(&b, f.Args)
+			b.WriteString(": " + f.Type.String() + "\n")
+		}
+		b.WriteString("}\n")
+	}
+	return b.String()
+}
+
+// writeArguments writes a field's arguments, one per line when any is described
+func writeArguments(b *strings.Builder, args []*Argument) {
+	if len(args) == 0 {
+		return
+	}
+
+	described := false
+	for _, a := range args {
+		described = described || a.Description != ""
+	}
+
+	b.WriteString("(")
+	for i, a := range args {
+		switch {
+		case described:
+			b.WriteString("\n")
+			writeDescription(b, "    ", a.Description)
+			b.WriteString("    ")
+		case i > 0:
+			b.WriteString(", ")
+		}
+		b.WriteString(a.Name + ": " + a.Type.String())
+		if a.DefaultValue != nil {
+			value, _ := json.Marshal(a.DefaultValue)
+			b.WriteString(" = " + string(value))
+		}
+	}
+	if described {
+		b.WriteString("\n  ")
+	}
+	b.WriteString(")")
+}
+
+func writeDescription(b *strings.Builder, indent, description string) {
+	if description == "" {
+		return
+	}
+	b.WriteString(indent + `"""` + strings.ReplaceAll(description, `"""`, `\"""`) + `"""` + "\n")
+}
@@ -0,0 +1,520 @@
+package graphql
+
+import (
+	"fmt"
+	"strings"
+)
+
+// validator checks a document against the schema before anything is executed, so
+// queries either fail as a whole or run with only resolvers able to fail
+type validator struct {
+	schema *Schema
+	doc    *Document
+	errs   []*Error
+}
+
+// variableUse is a variable referenced where a value of type is expected
+type variableUse struct {
+	value *Value
+	typ   Type
+	// hasDefault is whether the argument it's passed to has a default of its own
+	hasDefault bool
+}
+
+func validate(s *Schema, doc *Document) []*Error {
+	v := &validator{schema: s, doc: doc}
+	v.operations()
+	if !v.fragments() {
+		// Cyclic fragments would send the walks below round in circles
+		return v.errs
+	}
+
+	for _, f := range doc.Fragments {
+		if t, ok := s.Type(f.TypeCondition).(*Object); ok {
+			v.selectionSet(t, f.SelectionSet)
+		}
+	}
+	for _, op := range doc.Operations {
+		if op.Type != "query" {
+			continue
+		}
+		v.selectionSet(s.query, op.SelectionSet)
+		v.directives(op.Directives)
+		v.variables(op)
+		if s.maxDepth > 0 {
+			if depth := v.depth(op.SelectionSet); depth > s.maxDepth {
+				v.errorf(op.Loc, "Query is nested %d fields deep; at most %d are allowed", depth, s.maxDepth)
+			}
+		}
+	}
+	return v.errs
+}
+
+func (v *validator) errorf(loc Location, format string, args ...any) {
+	v.errs = append(v.errs, &Error{Message: fmt.Sprintf(format, args...), Locations: []Location{loc}})
+}
+
+// operations checks operation names are unique, an anonymous operation stands alone
+// and only queries are sent
+func (v *validator) operations() {
+	names := make(map[string]bool)
+	for _, op := range v.doc.Operations {
+		switch {
+		case op.Name == "" && len(v.doc.Operations) > 1:
+			v.errorf(op.Loc, "An anonymous operation must be the only operation in the document")
+		case op.Name != "" && names[op.Name]:
+			v.errorf(op.Loc, `There can be only one operation named "%s"`, op.Name)
+		}
+		names[op.Name] = true
+
+		if op.Type != "query" {
+			v.errorf(op.Loc, "Only queries are supported, not %ss", op.Type)
+		}
+	}
+}
+
+// fragments checks fragments are uniquely named, apply to an object type, are used and
+// don't spread themselves. Returns false if any do spread themselves
+func (v *validator) fragments() bool {
+	names := make(map[string]bool)
+	for _, f := range v.doc.Fragments {
+		if names[f.Name] {
+			v.errorf(f.Loc, `There can be only one fragment named "%s"`, f.Name)
+		}
+		names[f.Name] = true
+		if _, ok := v.schema.Type(f.TypeCondition).(*Object); !ok {
+			v.errorf(f.Loc, `Fragment "%s" is on unknown type "%s"`, f.Name, f.TypeCondition)
+		}
+	}
+
+	// A fragment is used if an operation spreads it, directly or through other fragments
+	used := make(map[string]bool)
+	var use func(s *FragmentSpread)
+	use = func(s *FragmentSpread) {
+		if used[s.Name] {
+			return
+		}
+		used[s.Name] = true
+		if f := v.doc.fragment(s.Name); f != nil {
+			v.spreads(f.SelectionSet, use)
+		}
+	}
+	for _, op := range v.doc.Operations {
+		v.spreads(op.SelectionSet, use)
+	}
+	acyclic := true
+	for _, f := range v.doc.Fragments {
+		if !used[f.Name] {
+			v.errorf(f.Loc, `Fragment "%s" is never used`, f.Name)
+		}
+		if v.spreadsItself(f, f, map[string]bool{}) {
+			v.errorf(f.Loc, `Fragment "%s" spreads itself`, f.Name)
+			acyclic = false
+		}
+	}
+	return acyclic
+}
+
+// spreads calls fn for each fragment spread directly in selections
+func (v *validator) spreads(selections []Selection, fn func(*FragmentSpread)) {
+	for _, sel := range selections {
+		switch sel := sel.(type) {
+		case *FieldNode:
+			v.spreads(sel.SelectionSet, fn)
+		case *InlineFragment:
+			v.spreads(sel.SelectionSet, fn)
+		case *FragmentSpread:
+			fn(sel)
+		}
+	}
+}
+
+// spreadsItself reports whether f spreads target, directly or through other fragments
+func (v *validator) spreadsItself(target, f *Fragment, visited map[string]bool) bool {
+	found := false
+	v.spreads(f.SelectionSet, func(s *FragmentSpread) {
+		if found {
+			return
+		}
+		if s.Name == target.Name {
+			found = true
+			return
+		}
+		if visited[s.Name] {
+			return
+		}
+		visited[s.Name] = true
+		if next := v.doc.fragment(s.Name); next != nil {
+			found = v.spreadsItself(target, next, visited)
+		}
+	})
+	return found
+}
+
+// selectionSet checks selections are valid on t
+func (v *validator) selectionSet(t *Object, selections []Selection) {
+	for _, sel := range selections {
+		switch sel := sel.(type) {
+		case *FieldNode:
+			v.field(t, sel)
+		case *InlineFragment:
+			v.directives(sel.Directives)
+			if sel.TypeCondition != "" && sel.TypeCondition != t.Name {
+				v.errorf(sel.Loc, `Fragment on "%s" can never apply to type "%s"`, sel.TypeCondition, t.Name)
+				continue
+			}
+			v.selectionSet(t, sel.SelectionSet)
+		case *FragmentSpread:
+			v.directives(sel.Directives)
+			f := v.doc.fragment(sel.Name)
+			if f == nil {
+				v.errorf(sel.Loc, `Unknown fragment "%s"`, sel.Name)
+			} else if f.TypeCondition != t.Name && v.schema.Type(f.TypeCondition) != nil {
+				v.errorf(sel.Loc, `Fragment "%s" on "%s" can never apply to type "%s"`, f.Name, f.TypeCondition, t.Name)
+			}
+		}
+	}
+	v.mergeable(t, selections)
+}
+
+func (v *validator) field(t *Object, f *FieldNode) {
+	v.directives(f.Directives)
+	if f.Name == "__typename" {
+		if len(f.Arguments) > 0 || f.SelectionSet != nil {
+			v.errorf(f.Loc, `Field "__typename" takes no arguments or selections`)
+		}
+		return
+	}
+
+	def := t.Field(f.Name)
+	if def == nil {
+		v.errorf(f.Loc, `Cannot query field "%s" on type "%s"`, f.Name, t.Name)
+		return
+	}
+	v.arguments(f.Loc, `field "`+t.Name+"."+f.Name+`"`, def.Args, f.Arguments)
+
+	switch named := namedType(def.Type).(type) {
+	case *Object:
+		if f.SelectionSet == nil {
+			v.errorf(f.Loc, `Field "%s" of type "%s" must have a selection of subfields`, f.Name, def.Type)
+			return
+		}
+		v.selectionSet(named, f.SelectionSet)
+	default:
+		if f.SelectionSet != nil {
+			v.errorf(f.Loc, `Field "%s" of type "%s" must not have a selection of subfields`, f.Name, def.Type)
+		}
+	}
+}
+
+// arguments checks given arguments are defined, given once, fit their types, and that
+// required ones are given
+func (v *validator) arguments(loc Location, owner string, defs []*Argument, given []*ArgumentNode) {
+	seen := make(map[string]bool, len(given))
+	for _, arg := range given {
+		if seen[arg.Name] {
+			v.errorf(arg.Loc, `Argument "%s" of %s is given more than once`, arg.Name, owner)
+			continue
+		}
+		seen[arg.Name] = true
+
+		def := argument(defs, arg.Name)
+		if def == nil {
+			v.errorf(arg.Loc, `Unknown argument "%s" of %s`, arg.Name, owner)
+			continue
+		}
+		if msg := literalError(arg.Value, def.Type); msg != "" {
+			v.errorf(arg.Value.Loc, `Argument "%s" of %s has an invalid value: %s`, arg.Name, owner, msg)
+		}
+	}
+
+	for _, def := range defs {
+		if isNonNull(def.Type) && def.DefaultValue == nil && !seen[def.Name] {
+			v.errorf(loc, `Argument "%s" of type "%s" is required by %s`, def.Name, def.Type, owner)
+		}
+	}
+}
+
+// skipIncludeArgs are the arguments of @skip and @include
+var skipIncludeArgs = []*Argument{{Name: "if", Type: NewNonNull(Boolean)}}
+
+func (v *validator) directives(directives []*Directive) {
+	for _, d := range directives {
+		if d.Name != "skip" && d.Name != "include" {
+			v.errorf(d.Loc, `Unknown directive "@%s"`, d.Name)
+			continue
+		}
+		v.arguments(d.Loc, `directive "@`+d.Name+`"`, skipIncludeArgs, d.Arguments)
+	}
+}
+
+// mergeable checks fields reported under the same key on t are the same field with the
+// same arguments, so there is one value to report
+func (v *validator) mergeable(t *Object, selections []Selection) {
+	byKey := make(map[string]*FieldNode)
+	v.eachField(t, selections, map[string]bool{}, func(f *FieldNode) {
+		key := f.ResponseKey()
+		first, ok := byKey[key]
+		if !ok {
+			byKey[key] = f
+			return
+		}
+		if first.Name != f.Name {
+			v.errorf(f.Loc, `Fields "%s" conflict because "%s" and "%s" are different fields`, key, first.Name, f.Name)
+		} else if printArguments(first.Arguments) != printArguments(f.Arguments) {
+			v.errorf(f.Loc, `Fields "%s" conflict because they have different arguments`, key)
+		}
+	})
+}
+
+// eachField calls fn for each field selected on t, looking through fragments
+func (v *validator) eachField(t *Object, selections []Selection, visited map[string]bool, fn func(*FieldNode)) {
+	for _, sel := range selections {
+		switch sel := sel.(type) {
+		case *FieldNode:
+			fn(sel)
+		case *InlineFragment:
+			if sel.TypeCondition == "" || sel.TypeCondition == t.Name {
+				v.eachField(t, sel.SelectionSet, visited, fn)
+			}
+		case *FragmentSpread:
+			if f := v.doc.fragment(sel.Name); f != nil && f.TypeCondition == t.Name && !visited[sel.Name] {
+				visited[sel.Name] = true
+				v.eachField(t, f.SelectionSet, visited, fn)
+			}
+		}
+	}
+}
+
+// printArguments renders arguments for comparison
+func printArguments(args []*ArgumentNode) string {
+	var b strings.Builder
+	for _, arg := range args {
+		b.WriteString(arg.Name + ":" + printValue(arg.Value) + ",")
+	}
+	return b.String()
+}
+
+func printValue(v *Value) string {
+	switch v.Kind {
+	case VariableValue:
+		return "$" + v.Raw
+	case StringValue:
+		return fmt.Sprintf("%q", v.Raw)
+	case ListValue:
+		items := make([]string, len(v.List))
+		for i, item := range v.List {
+			items[i] = printValue(item)
+		}
+		return "[" + strings.Join(items, ",") + "]"
+	case ObjectValue:
+		fields := make([]string, len(v.Fields))
+		for i, f := range v.Fields {
+			fields[i] = f.Name + ":" + printValue(f.Value)
+		}
+		return "{" + strings.Join(fields, ",") + "}"
+	}
+	return v.Raw
+}
+
+// variables checks op's variable definitions and their uses in op and the fragments it spreads
+func (v *validator) variables(op *Operation) {
+	defs := make(map[string]*VariableDefinition, len(op.Variables))
+	types := make(map[string]Type, len(op.Variables))
+	for _, def := range op.Variables {
+		if defs[def.Name] != nil {
+			v.errorf(def.Loc, `There can be only one variable named "$%s"`, def.Name)
+			continue
+		}
+		defs[def.Name] = def
+
+		t, err := v.schema.inputType(def.Type)
+		if err != nil {
+			v.errorf(def.Type.Loc, `Variable "$%s" %s`, def.Name, err.Error())
+			continue
+		}
+		types[def.Name] = t
+		if def.Default != nil {
+			if msg := literalError(def.Default, t); msg != "" {
+				v.errorf(def.Default.Loc, `Variable "$%s" has an invalid default value: %s`, def.Name, msg)
+			}
+		}
+	}
+
+	used := make(map[string]bool)
+	for _, use := range v.variableUses(op) {
+		name := use.value.Raw
+		used[name] = true
+		def := defs[name]
+		if def == nil {
+			v.errorf(use.value.Loc, `Variable "$%s" is not defined by operation "%s"`, name, op.Name)
+			continue
+		}
+		t, ok := types[name]
+		if !ok {
+			continue
+		}
+		hasDefault := use.hasDefault || (def.Default != nil && def.Default.Kind != NullValue)
+		if !variableFits(t, use.typ, hasDefault) {
+			v.errorf(use.value.Loc, `Variable "$%s" of type "%s" used in position expecting type "%s"`, name, t, use.typ)
+		}
+	}
+	for _, def := range op.Variables {
+		if !used[def.Name] {
+			v.errorf(def.Loc, `Variable "$%s" is never used in operation "%s"`, def.Name, op.Name)
+		}
+	}
+}
+
+// variableUses lists the variables used in op, including by the fragments it spreads
+func (v *validator) variableUses(op *Operation) []variableUse {
+	var uses []variableUse
+	visited := make(map[string]bool)
+
+	var inArgs func(defs []*Argument, args []*ArgumentNode)
+	inArgs = func(defs []*Argument, args []*ArgumentNode) {
+		for _, arg := range args {
+			if def := argument(defs, arg.Name); def != nil {
+				uses = valueUses(uses, arg.Value, def.Type, def.DefaultValue != nil)
+			}
+		}
+	}
+	inDirectives := func(directives []*Directive) {
+		for _, d := range directives {
+			inArgs(skipIncludeArgs, d.Arguments)
+		}
+	}
+
+	var walk func(t *Object, selections []Selection)
+	walk = func(t *Object, selections []Selection) {
+		for _, sel := range selections {
+			switch sel := sel.(type) {
+			case *FieldNode:
+				inDirectives(sel.Directives)
+				if def := t.Field(sel.Name); def != nil {
+					inArgs(def.Args, sel.Arguments)
+					if named, ok := namedType(def.Type).(*Object); ok {
+						walk(named, sel.SelectionSet)
+					}
+				}
+			case *InlineFragment:
+				inDirectives(sel.Directives)
+				walk(t, sel.SelectionSet)
+			case *FragmentSpread:
+				inDirectives(sel.Directives)
+				if f := v.doc.fragment(sel.Name); f != nil && !visited[sel.Name] {
+					visited[sel.Name] = true
+					inDirectives(f.Directives)
+					walk(t, f.SelectionSet)
+				}
+			}
+		}
+	}
+	inDirectives(op.Directives)
+	walk(v.schema.query, op.SelectionSet)
+	return uses
+}
+
+// valueUses adds the variables in value, expected to be of type t, to uses
+func valueUses(uses []variableUse, value *Value, t Type, hasDefault bool) []variableUse {
+	switch value.Kind {
+	case VariableValue:
+		return append(uses, variableUse{value: value, typ: t, hasDefault: hasDefault})
+	case ListValue:
+		elem := t
+		if nn, ok := elem.(*NonNull); ok {
+			elem = nn.OfType
+		}
+		if list, ok := elem.(*List); ok {
+			elem = list.OfType
+		}
+		for _, item := range value.List {
+			uses = valueUses(uses, item, elem, false)
+		}
+	}
+	return uses
+}
+
+// variableFits reports whether a variable of type varType may be used where locType is
+// expected; a nullable variable may fill a non-null position when a default covers it
+func variableFits(varType, locType Type, hasDefault bool) bool {
+	if loc, ok := locType.(*NonNull); ok {
+		if vn, ok := varType.(*NonNull); ok {
+			return typesCompatible(vn.OfType, loc.OfType)
+		}
+		return hasDefault && typesCompatible(varType, loc.OfType)
+	}
+	return typesCompatible(varType, locType)
+}
+
+func typesCompatible(varType, locType Type) bool {
+	if loc, ok := locType.(*NonNull); ok {
+		vn, ok := varType.(*NonNull)
+		return ok && typesCompatible(vn.OfType, loc.OfType)
+	}
+	if vn, ok := varType.(*NonNull); ok {
+		return typesCompatible(vn.OfType, locType)
+	}
+	if loc, ok := locType.(*List); ok {
+		vl, ok := varType.(*List)
+		return ok && typesCompatible(vl.OfType, loc.OfType)
+	}
+	if _, ok := varType.(*List); ok {
+		return false
+	}
+	return varType == locType
+}
+
+// depth returns how deeply selections nest fields, looking through fragments
+func (v *validator) depth(selections []Selection) int {
+	deepest := 0
+	for _, sel := range selections {
+		d := 0
+		switch sel := sel.(type) {
+		case *FieldNode:
+			d = 1 + v.depth(sel.SelectionSet)
+		case *InlineFragment:
+			d = v.depth(sel.SelectionSet)
+		case *FragmentSpread:
+			if f := v.doc.fragment(sel.Name); f != nil {
+				d = v.depth(f.SelectionSet)
+			}
+		}
+		deepest = max(deepest, d)
+	}
+	return deepest
+}
+
+// inputType resolves a variable's declared type against the schema
+func (s *Schema) inputType(ref *TypeRef) (Type, error) {
+	var t Type
+	if ref.Elem != nil {
+		elem, err := s.inputType(ref.Elem)
+		if err != nil {
+			return nil, err
+		}
+		t = NewList(elem)
+	} else {
+		t = s.Type(ref.Name)
+		if t == nil {
+			return nil, fmt.Errorf(`has unknown type "%s"`, ref.Name)
+		}
+		if !isInputType(t) {
+			return nil, fmt.Errorf(`cannot be of non-input type "%s"`, ref.Name)
+		}
+	}
+	if ref.NonNull {
+		t = NewNonNull(t)
+	}
+	return t, nil
+}
+
+// argument returns the argument named name among defs, or nil
+func argument(defs []*Argument, name string) *Argument {
+	for _, a := range defs {
+		if a.Name == name {
+			return a
+		}
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// enumLiteral is an enum value written in a query; no input type accepts one
+type enumLiteral string
+
+// literal converts a value as written in a query to a Go value, substituting the
+// operation's coerced variables; variables without a value are nil
+func literal(v *Value, vars map[string]any) any {
+	switch v.Kind {
+	case VariableValue:
+		return vars[v.Raw]
+	case IntValue:
+		n, err := strconv.ParseInt(v.Raw, 10, 64)
+		if err != nil {
+			// Out of int64 range, and so of every scalar's
+			return v.Raw
+		}
+		return n
+	case FloatValue:
+		f, _ := strconv.ParseFloat(v.Raw, 64)
+		return f
+	case StringValue:
+		return v.Raw
+	case BooleanValue:
+		return v.Raw == "true"
+	case EnumValue:
+		return enumLiteral(v.Raw)
+	case ListValue:
+		items := make([]any, len(v.List))
+		for i, item := range v.List {
+			items[i] = literal(item, vars)
+		}
+		return items
+	case ObjectValue:
+		fields := make(map[string]any, len(v.Fields))
+		for _, f := range v.Fields {
+			fields[f.Name] = literal(f.Value, vars)
+		}
+		return fields
+	}
+	return nil
+}
+
+// coerceInput coerces a Go value to the input type t
+// A value that isn't a list is coerced as a list of that one value, as the spec requires
+func coerceInput(v any, t Type) (any, error) {
+	if nn, ok := t.(*NonNull); ok {
+		if v == nil {
+			return nil, fmt.Errorf("Expected a non-null value of type %s", t)
+		}
+		return coerceInput(v, nn.OfType)
+	}
+	if v == nil {
+		return nil, nil
+	}
+
+	switch t := t.(type) {
+	case *List:
+		items, ok := v.([]any)
+		if !ok {
+			item, err := coerceInput(v, t.OfType)
+			if err != nil {
+				return nil, err
+			}
+			return []any{item}, nil
+		}
+		coerced := make([]any, len(items))
+		for i, item := range items {
+			c, err := coerceInput(item, t.OfType)
+			if err != nil {
+				return nil, fmt.Errorf("In item %d: %w", i, err)
+			}
+			coerced[i] = c
+		}
+		return coerced, nil
+	case *Scalar:
+		if c, ok := t.parse(v); ok {
+			return c, nil
+		}
+		return nil, fmt.Errorf("Expected a value of type %s", t.Name)
+	}
+	return nil, fmt.Errorf("Type %s is not an input type", t)
+}
+
+// literalError checks a literal argument value against its type, returning why it
+// doesn't fit or "". Variables fit anywhere here; their types are checked separately
+func literalError(v *Value, t Type) string {
+	if v.Kind == VariableValue {
+		return ""
+	}
+	if nn, ok := t.(*NonNull); ok {
+		if v.Kind == NullValue {
+			return "Expected a non-null value of type " + t.String()
+		}
+		return literalError(v, nn.OfType)
+	}
+	if v.Kind == NullValue {
+		return ""
+	}
+
+	switch t := t.(type) {
+	case *List:
+		if v.Kind != ListValue {
+			return literalError(v, t.OfType)
+		}
+		for _, item := range v.List {
+			if msg := literalError(item, t.OfType); msg != "" {
+				return msg
+			}
+		}
+		return ""
+	case *Scalar:
+		fits := false
+		switch t {
+		case Int:
+			_, fits = Int.parse(literal(v, nil))
+			fits = fits && v.Kind == IntValue
+		case Float:
+			fits = v.Kind == IntValue || v.Kind == FloatValue
+		case String:
+			fits = v.Kind == StringValue
+		case Boolean:
+			fits = v.Kind == BooleanValue
+		case ID:
+			_, intFits := Int.parse(literal(v, nil))
+			fits = v.Kind == StringValue || (v.Kind == IntValue && intFits)
+		}
+		if !fits {
+			return "Expected a value of type " + t.Name
+		}
+		return ""
+	}
+	return "Type " + t.String() + " is not an input type"
+}
@@ -0,0 +1,206 @@
+package grpcapi
+
+import (
+	"github.com/yourusername/supabase-redis-middleware/internal/grpcapi/golv1"
+	"github.com/yourusername/supabase-redis-middleware/internal/handlers"
+	"github.com/yourusername/supabase-redis-middleware/internal/repository"
+)
+
+// pushRequest starts the push a header opens; its batches are added by appendBatch
+func pushRequest(header *golv1.PushHeader) handlers.PushProductsRequest {
+	details := header.GetStoreDetails()
+	return handlers.PushProductsRequest{
+		StoreDetails: handlers.StoreDetails{
+			StoreID: details.GetStoreId(),
+			Name:    details.GetName(),
+			Address: handlers.Address{
+				Line1:      details.GetAddress().GetLine1(),
+				City:       details.GetAddress().GetCity(),
+				State:      details.GetAddress().GetState(),
+				PostalCode: details.GetAddress().GetPostalCode(),
+			},
+			Location: handlers.Location{
+				Lat: details.GetLocation().GetLat(),
+				Lng: details.GetLocation().GetLng(),
+			},
+		},
+		SyncMode: header.GetSyncMode(),
+	}
+}
+
+// appendBatch adds a batch's items to req, after those of earlier batches
+func appendBatch(req *handlers.PushProductsRequest, batch *golv1.PushBatch) {
+	for _, c := range batch.GetCategories() {
+		req.Categories = append(req.Categories, handlers.Category{
+			ID:           c.GetId(),
+			ParentID:     c.ParentId,
+			Name:         c.GetName(),
+			Slug:         c.GetSlug(),
+			Description:  c.GetDescription(),
+			DisplayOrder: int(c.GetDisplayOrder()),
+			IsActive:     c.GetIsActive(),
+		})
+	}
+	for _, t := range batch.GetTaxes() {
+		req.Taxes = append(req.Taxes, handlers.Tax{
+			ID:          t.GetId(),
+			Name:        t.GetName(),
+			TaxID:       t.GetTaxId(),
+			Description: t.GetDescription(),
+			Rate:        t.GetRate(),
+			TaxType:     t.GetTaxType(),
+			IsInclusive: t.GetIsInclusive(),
+			IsActive:    t.GetIsActive(),
+		})
+	}
+	for _, p := range batch.GetProducts() {
+		req.Products = append(req.Products, handlers.Product{
+			ID:              p.GetId(),
+			SKU:             p.GetSku(),
+			Name:            p.GetName(),
+			Slug:            p.GetSlug(),
+			Description:     p.GetDescription(),
+			CategoryID:      p.GetCategoryId(),
+			Price:           p.GetPrice(),
+			Currency:        p.GetCurrency(),
+			Unit:            p.GetUnit(),
+			UnitQuantity:    p.GetUnitQuantity(),
+			PrimaryImageURL: p.GetPrimaryImageUrl(),
+			Images:          p.GetImages(),
+			Brand:           p.GetBrand(),
+			Manufacturer:    p.GetManufacturer(),
+			Barcode:         p.GetBarcode(),
+			EAN:             p.GetEan(),
+			Taxes:           p.GetTaxes(),
+			IsActive:        p.GetIsActive(),
+			IsFeatured:      p.GetIsFeatured(),
+			IsCustomizable:  p.GetIsCustomizable(),
+			IsAddon:         p.GetIsAddon(),
+		})
+	}
+	for _, v := range batch.GetVariations() {
+		req.Variations = append(req.Variations, handlers.Variation{
+			ID:          v.GetId(),
+			ProductID:   v.GetProductId(),
+			Name:        v.GetName(),
+			DisplayName: v.GetDisplayName(),
+			Price:       v.GetPrice(),
+			IsDefault:   v.GetIsDefault(),
+		})
+	}
+	for _, sp := range batch.GetStoreProducts() {
+		req.StoreProducts = append(req.StoreProducts, handlers.StoreProduct{
+			ProductID:     sp.GetProductId(),
+			Price:         sp.GetPrice(),
+			StockQuantity: sp.GetStockQuantity(),
+			IsInStock:     sp.GetIsInStock(),
+			Taxes:         sp.GetTaxes(),
+		})
+	}
+}
+
+// pushResponse reports a push's committed counts; the caller sets its status
+func pushResponse(result *repository.UpsertResult) *golv1.PushProductsResponse {
+	resp := &golv1.PushProductsResponse{
+		StoreId:                  result.StoreID,
+		ProductsCreated:          int32(result.Created),
+		ProductsUpdated:          int32(result.Updated),
+		VariationsProcessed:      int32(result.VariationsProcessed),
+		StoreProductsProcessed:   int32(result.StoreProductsProcessed),
+		TaxesProcessed:           int32(result.TaxesProcessed),
+		ChunksCommitted:          int32(len(result.Chunks)),
+		ChunksTotal:              int32(result.TotalChunks),
+		StoreProductsDeactivated: int32(result.Deactivated),
+	}
+	for _, chunk := range result.Chunks {
+		resp.ProductsCommitted += int32(chunk.Products - chunk.Failed)
+	}
+	for _, f := range result.Failures {
+		resp.Failures = append(resp.Failures, &golv1.PushFailure{
+			Index:      int32(f.Index),
+			ExternalId: f.ExternalProductID,
+			Reason:     f.Reason,
+		})
+	}
+	for _, m := range result.Matches {
+		resp.Matches = append(resp.Matches, &golv1.ProductMatch{
+			Index:      int32(m.Index),
+			ExternalId: m.ExternalProductID,
+			Action:     m.Action,
+			ProductId:  m.ProductID,
+			MatchType:  m.MatchType,
+			Confidence: m.Confidence,
+		})
+	}
+	return resp
+}
+
+// stockRequest converts a stock update to the HTTP API's
+func stockRequest(in *golv1.UpdateStockRequest) handlers.UpdateStockRequest {
+	req := handlers.UpdateStockRequest{StoreID: in.GetStoreId()}
+	for _, p := range in.GetProducts() {
+		product := handlers.StockProductUpdate{
+			ID:            p.GetId(),
+			StockQuantity: p.GetStockQuantity(),
+			IsAvailable:   p.GetIsAvailable(),
+			Price:         p.GetPrice(),
+		}
+		for _, v := range p.GetVariants() {
+			product.Variants = append(product.Variants, handlers.StockVariantUpdate{
+				ID:            v.GetId(),
+				StockQuantity: v.GetStockQuantity(),
+				IsAvailable:   v.GetIsAvailable(),
+				Price:         v.GetPrice(),
+			})
+		}
+		req.Products = append(req.Products, product)
+	}
+	return req
+}
+
+// storeListing converts a store catalog listing for GetStoreCatalog
+func storeListing(l repository.StoreProductListing) *golv1.StoreListing {
+	listing := &golv1.StoreListing{
+		Id:              l.StoreProductID,
+		StoreId:         l.StoreID,
+		ProductId:       l.ProductID,
+		Sku:             l.SKU,
+		Name:            l.Name,
+		Slug:            l.Slug,
+		Description:     l.Description,
+		Unit:            l.Unit,
+		UnitQuantity:    l.UnitQuantity,
+		PrimaryImageUrl: l.PrimaryImageURL,
+		Price:           l.Price,
+		SalePrice:       l.SalePrice,
+		Currency:        l.Currency,
+		StockQuantity:   l.StockQuantity,
+		IsInStock:       l.IsInStock,
+		CategoryId:      l.CategoryID,
+		BrandId:         l.BrandID,
+	}
+	for _, v := range l.Variations {
+		listing.Variations = append(listing.Variations, &golv1.ListingVariation{
+			Id:            v.ID,
+			ExternalId:    v.ExternalID,
+			Name:          v.Name,
+			DisplayName:   v.DisplayName,
+			Price:         v.Price,
+			SalePrice:     v.SalePrice,
+			StockQuantity: v.StockQuantity,
+			IsInStock:     v.IsInStock,
+			IsDefault:     v.IsDefault,
+		})
+	}
+	for _, t := range l.Taxes {
+		listing.Taxes = append(listing.Taxes, &golv1.ListingTax{
+			Id:          t.ID,
+			TaxId:       t.TaxID,
+			Name:        t.Name,
+			Rate:        t.Rate,
+			TaxType:     t.TaxType,
+			IsInclusive: t.IsInclusive,
+		})
+	}
+	return listing
+}
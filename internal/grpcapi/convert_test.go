@@ -0,0 +1,68 @@
+package grpcapi
+
+import (
+	"testing"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/yourusername/supabase-redis-middleware/internal/grpcapi/golv1"
+	"github.com/yourusername/supabase-redis-middleware/internal/repository"
+)
+
+func TestPushRequestFromBatches(t *testing.T) {
+	parent := "cat-root"
+	req := pushRequest(&golv1.PushHeader{
+		StoreDetails: &golv1.StoreDetails{
+			StoreId:  "erp-1",
+			Name:     "Corner Store",
+			Address:  &golv1.Address{Line1: "1 Main St", City: "Pune", State: "MH", PostalCode: "411001"},
+			Location: &golv1.Location{Lat: 18.5, Lng: 73.8},
+		},
+		SyncMode: "full",
+	})
+	appendBatch(&req, &golv1.PushBatch{
+		Categories: []*golv1.Category{{Id: "cat-1", ParentId: &parent, Name: "Dairy", Slug: "dairy", DisplayOrder: 2}},
+		Products:   []*golv1.Product{{Id: "p1", Sku: "SKU1", Name: "Milk", Price: 30}},
+	})
+	appendBatch(&req, &golv1.PushBatch{
+		Products:      []*golv1.Product{{Id: "p2", Sku: "SKU2", Name: "Curd", Price: 40}},
+		StoreProducts: []*golv1.StoreProduct{{ProductId: "p2", Price: 38, IsInStock: true}},
+	})
+
+	if req.StoreDetails.StoreID != "erp-1" || req.StoreDetails.Address.City != "Pune" || req.SyncMode != "full" {
+		t.Errorf("header = %+v", req.StoreDetails)
+	}
+	if len(req.Products) != 2 || req.Products[0].ID != "p1" || req.Products[1].ID != "p2" {
+		t.Errorf("products = %+v, want p1 then p2", req.Products)
+	}
+	if c := req.Categories[0]; c.ParentID == nil || *c.ParentID != parent || c.DisplayOrder != 2 {
+		t.Errorf("category = %+v", c)
+	}
+	if len(req.StoreProducts) != 1 || req.StoreProducts[0].Price != 38 {
+		t.Errorf("store products = %+v", req.StoreProducts)
+	}
+	if err := binding.Validator.ValidateStruct(req); err != nil {
+		t.Errorf("ValidateStruct() = %v, want a valid push", err)
+	}
+}
+
+func TestPushResponse(t *testing.T) {
+	resp := pushResponse(&repository.UpsertResult{
+		StoreID:     "store-uuid",
+		Created:     3,
+		Updated:     1,
+		TotalChunks: 3,
+		Chunks: []repository.ChunkProgress{
+			{Index: 0, Products: 2, Failed: 0},
+			{Index: 1, Products: 2, Failed: 1},
+		},
+		Failures: []repository.PushFailure{{Index: 3, ExternalProductID: "p4", Reason: "bad price"}},
+	})
+
+	if resp.ProductsCommitted != 3 || resp.ChunksCommitted != 2 || resp.ChunksTotal != 3 {
+		t.Errorf("progress = %d products in %d/%d chunks, want 3 in 2/3",
+			resp.ProductsCommitted, resp.ChunksCommitted, resp.ChunksTotal)
+	}
+	if len(resp.Failures) != 1 || resp.Failures[0].Index != 3 || resp.Failures[0].ExternalId != "p4" {
+		t.Errorf("failures = %v", resp.Failures)
+	}
+}
@@ -0,0 +1,7 @@
+// Package grpcapi serves the CatalogSync gRPC API, defined in proto/gol/v1, for ERP
+// connectors. Its pushes, stock updates and catalog reads go through the handlers and
+// repository serving the HTTP API, so the two behave alike
+package grpcapi
+
+// Regenerate golv1 after editing the proto, with protoc-gen-go and protoc-gen-go-grpc installed
+//go:generate protoc -I ../../proto --go_out=../.. --go_opt=module=github.com/yourusername/supabase-redis-middleware --go-grpc_out=../.. --go-grpc_opt=module=github.com/yourusername/supabase-redis-middleware gol/v1/catalog_sync.proto
@@ -0,0 +1,2362 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        (unknown)
+// source: gol/v1/catalog_sync.proto
+
+package golv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type PushProductsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Payload:
+	//
+	//	*PushProductsRequest_Header
+	//	*PushProductsRequest_Batch
+	Payload       isPushProductsRequest_Payload `protobuf_oneof:"payload"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PushProductsRequest) Reset() {
+	*x = PushProductsRequest{}
+	mi := &file_gol_v1_catalog_sync_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PushProductsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PushProductsRequest) ProtoMessage() {}
+
+func (x *PushProductsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gol_v1_catalog_sync_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PushProductsRequest.ProtoReflect.Descriptor instead.
+func (*PushProductsRequest) Descriptor() ([]byte, []int) {
+	return file_gol_v1_catalog_sync_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *PushProductsRequest) GetPayload() isPushProductsRequest_Payload {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *PushProductsRequest) GetHeader() *PushHeader {
+	if x != nil {
+		if x, ok := x.Payload.(*PushProductsRequest_Header); ok {
+			return x.Header
+		}
+	}
+	return nil
+}
+
+func (x *PushProductsRequest) GetBatch() *PushBatch {
+	if x != nil {
+		if x, ok := x.Payload.(*PushProductsRequest_Batch); ok {
+			return x.Batch
+		}
+	}
+	return nil
+}
+
+type isPushProductsRequest_Payload interface {
+	isPushProductsRequest_Payload()
+}
+
+type PushProductsRequest_Header struct {
+	Header *PushHeader `protobuf:"bytes,1,opt,name=header,proto3,oneof"`
+}
+
+type PushProductsRequest_Batch struct {
+	Batch *PushBatch `protobuf:"bytes,2,opt,name=batch,proto3,oneof"`
+}
+
+func (*PushProductsRequest_Header) isPushProductsRequest_Payload() {}
+
+func (*PushProductsRequest_Batch) isPushProductsRequest_Payload() {}
+
+// PushHeader opens a push with the store and the options the HTTP API takes as query
+// parameters.
+type PushHeader struct {
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	StoreDetails *StoreDetails          `protobuf:"bytes,1,opt,name=store_details,json=storeDetails,proto3" json:"store_details,omitempty"`
+	// "full" deactivates the store's listings missing from the push; "incremental", the
+	// default, leaves them.
+	SyncMode string `protobuf:"bytes,2,opt,name=sync_mode,json=syncMode,proto3" json:"sync_mode,omitempty"`
+	// Skip and report invalid products and products that fail to save, committing the rest.
+	Partial bool `protobuf:"varint,3,opt,name=partial,proto3" json:"partial,omitempty"`
+	// Report what the push would change without changing it.
+	DryRun        bool `protobuf:"varint,4,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PushHeader) Reset() {
+	*x = PushHeader{}
+	mi := &file_gol_v1_catalog_sync_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PushHeader) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PushHeader) ProtoMessage() {}
+
+func (x *PushHeader) ProtoReflect() protoreflect.Message {
+	mi := &file_gol_v1_catalog_sync_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PushHeader.ProtoReflect.Descriptor instead.
+func (*PushHeader) Descriptor() ([]byte, []int) {
+	return file_gol_v1_catalog_sync_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *PushHeader) GetStoreDetails() *StoreDetails {
+	if x != nil {
+		return x.StoreDetails
+	}
+	return nil
+}
+
+func (x *PushHeader) GetSyncMode() string {
+	if x != nil {
+		return x.SyncMode
+	}
+	return ""
+}
+
+func (x *PushHeader) GetPartial() bool {
+	if x != nil {
+		return x.Partial
+	}
+	return false
+}
+
+func (x *PushHeader) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
+	}
+	return false
+}
+
+// PushBatch carries part of a push. Batches are concatenated in order, so products keep
+// their position across batches in failures and matches.
+type PushBatch struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Categories    []*Category            `protobuf:"bytes,1,rep,name=categories,proto3" json:"categories,omitempty"`
+	Taxes         []*Tax                 `protobuf:"bytes,2,rep,name=taxes,proto3" json:"taxes,omitempty"`
+	Products      []*Product             `protobuf:"bytes,3,rep,name=products,proto3" json:"products,omitempty"`
+	Variations    []*Variation           `protobuf:"bytes,4,rep,name=variations,proto3" json:"variations,omitempty"`
+	StoreProducts []*StoreProduct        `protobuf:"bytes,5,rep,name=store_products,json=storeProducts,proto3" json:"store_products,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PushBatch) Reset() {
+	*x = PushBatch{}
+	mi := &file_gol_v1_catalog_sync_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PushBatch) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PushBatch) ProtoMessage() {}
+
+func (x *PushBatch) ProtoReflect() protoreflect.Message {
+	mi := &file_gol_v1_catalog_sync_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PushBatch.ProtoReflect.Descriptor instead.
+func (*PushBatch) Descriptor() ([]byte, []int) {
+	return file_gol_v1_catalog_sync_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *PushBatch) GetCategories() []*Category {
+	if x != nil {
+		return x.Categories
+	}
+	return nil
+}
+
+func (x *PushBatch) GetTaxes() []*Tax {
+	if x != nil {
+		return x.Taxes
+	}
+	return nil
+}
+
+func (x *PushBatch) GetProducts() []*Product {
+	if x != nil {
+		return x.Products
+	}
+	return nil
+}
+
+func (x *PushBatch) GetVariations() []*Variation {
+	if x != nil {
+		return x.Variations
+	}
+	return nil
+}
+
+func (x *PushBatch) GetStoreProducts() []*StoreProduct {
+	if x != nil {
+		return x.StoreProducts
+	}
+	return nil
+}
+
+type StoreDetails struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	StoreId       string                 `protobuf:"bytes,1,opt,name=store_id,json=storeId,proto3" json:"store_id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Address       *Address               `protobuf:"bytes,3,opt,name=address,proto3" json:"address,omitempty"`
+	Location      *Location              `protobuf:"bytes,4,opt,name=location,proto3" json:"location,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StoreDetails) Reset() {
+	*x = StoreDetails{}
+	mi := &file_gol_v1_catalog_sync_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StoreDetails) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StoreDetails) ProtoMessage() {}
+
+func (x *StoreDetails) ProtoReflect() protoreflect.Message {
+	mi := &file_gol_v1_catalog_sync_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StoreDetails.ProtoReflect.Descriptor instead.
+func (*StoreDetails) Descriptor() ([]byte, []int) {
+	return file_gol_v1_catalog_sync_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *StoreDetails) GetStoreId() string {
+	if x != nil {
+		return x.StoreId
+	}
+	return ""
+}
+
+func (x *StoreDetails) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *StoreDetails) GetAddress() *Address {
+	if x != nil {
+		return x.Address
+	}
+	return nil
+}
+
+func (x *StoreDetails) GetLocation() *Location {
+	if x != nil {
+		return x.Location
+	}
+	return nil
+}
+
+type Address struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Line1         string                 `protobuf:"bytes,1,opt,name=line1,proto3" json:"line1,omitempty"`
+	City          string                 `protobuf:"bytes,2,opt,name=city,proto3" json:"city,omitempty"`
+	State         string                 `protobuf:"bytes,3,opt,name=state,proto3" json:"state,omitempty"`
+	PostalCode    string                 `protobuf:"bytes,4,opt,name=postal_code,json=postalCode,proto3" json:"postal_code,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Address) Reset() {
+	*x = Address{}
+	mi := &file_gol_v1_catalog_sync_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Address) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Address) ProtoMessage() {}
+
+func (x *Address) ProtoReflect() protoreflect.Message {
+	mi := &file_gol_v1_catalog_sync_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Address.ProtoReflect.Descriptor instead.
+func (*Address) Descriptor() ([]byte, []int) {
+	return file_gol_v1_catalog_sync_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Address) GetLine1() string {
+	if x != nil {
+		return x.Line1
+	}
+	return ""
+}
+
+func (x *Address) GetCity() string {
+	if x != nil {
+		return x.City
+	}
+	return ""
+}
+
+func (x *Address) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+func (x *Address) GetPostalCode() string {
+	if x != nil {
+		return x.PostalCode
+	}
+	return ""
+}
+
+type Location struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Lat           float64                `protobuf:"fixed64,1,opt,name=lat,proto3" json:"lat,omitempty"`
+	Lng           float64                `protobuf:"fixed64,2,opt,name=lng,proto3" json:"lng,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Location) Reset() {
+	*x = Location{}
+	mi := &file_gol_v1_catalog_sync_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Location) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Location) ProtoMessage() {}
+
+func (x *Location) ProtoReflect() protoreflect.Message {
+	mi := &file_gol_v1_catalog_sync_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Location.ProtoReflect.Descriptor instead.
+func (*Location) Descriptor() ([]byte, []int) {
+	return file_gol_v1_catalog_sync_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Location) GetLat() float64 {
+	if x != nil {
+		return x.Lat
+	}
+	return 0
+}
+
+func (x *Location) GetLng() float64 {
+	if x != nil {
+		return x.Lng
+	}
+	return 0
+}
+
+type Category struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	ParentId      *string                `protobuf:"bytes,2,opt,name=parent_id,json=parentId,proto3,oneof" json:"parent_id,omitempty"`
+	Name          string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	Slug          string                 `protobuf:"bytes,4,opt,name=slug,proto3" json:"slug,omitempty"`
+	Description   string                 `protobuf:"bytes,5,opt,name=description,proto3" json:"description,omitempty"`
+	DisplayOrder  int32                  `protobuf:"varint,6,opt,name=display_order,json=displayOrder,proto3" json:"display_order,omitempty"`
+	IsActive      bool                   `protobuf:"varint,7,opt,name=is_active,json=isActive,proto3" json:"is_active,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Category) Reset() {
+	*x = Category{}
+	mi := &file_gol_v1_catalog_sync_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Category) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Category) ProtoMessage() {}
+
+func (x *Category) ProtoReflect() protoreflect.Message {
+	mi := &file_gol_v1_catalog_sync_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Category.ProtoReflect.Descriptor instead.
+func (*Category) Descriptor() ([]byte, []int) {
+	return file_gol_v1_catalog_sync_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Category) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Category) GetParentId() string {
+	if x != nil && x.ParentId != nil {
+		return *x.ParentId
+	}
+	return ""
+}
+
+func (x *Category) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Category) GetSlug() string {
+	if x != nil {
+		return x.Slug
+	}
+	return ""
+}
+
+func (x *Category) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Category) GetDisplayOrder() int32 {
+	if x != nil {
+		return x.DisplayOrder
+	}
+	return 0
+}
+
+func (x *Category) GetIsActive() bool {
+	if x != nil {
+		return x.IsActive
+	}
+	return false
+}
+
+type Tax struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	TaxId         string                 `protobuf:"bytes,3,opt,name=tax_id,json=taxId,proto3" json:"tax_id,omitempty"`
+	Description   string                 `protobuf:"bytes,4,opt,name=description,proto3" json:"description,omitempty"`
+	Rate          float64                `protobuf:"fixed64,5,opt,name=rate,proto3" json:"rate,omitempty"`
+	TaxType       string                 `protobuf:"bytes,6,opt,name=tax_type,json=taxType,proto3" json:"tax_type,omitempty"`
+	IsInclusive   bool                   `protobuf:"varint,7,opt,name=is_inclusive,json=isInclusive,proto3" json:"is_inclusive,omitempty"`
+	IsActive      bool                   `protobuf:"varint,8,opt,name=is_active,json=isActive,proto3" json:"is_active,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Tax) Reset() {
+	*x = Tax{}
+	mi := &file_gol_v1_catalog_sync_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Tax) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Tax) ProtoMessage() {}
+
+func (x *Tax) ProtoReflect() protoreflect.Message {
+	mi := &file_gol_v1_catalog_sync_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Tax.ProtoReflect.Descriptor instead.
+func (*Tax) Descriptor() ([]byte, []int) {
+	return file_gol_v1_catalog_sync_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Tax) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Tax) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Tax) GetTaxId() string {
+	if x != nil {
+		return x.TaxId
+	}
+	return ""
+}
+
+func (x *Tax) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Tax) GetRate() float64 {
+	if x != nil {
+		return x.Rate
+	}
+	return 0
+}
+
+func (x *Tax) GetTaxType() string {
+	if x != nil {
+		return x.TaxType
+	}
+	return ""
+}
+
+func (x *Tax) GetIsInclusive() bool {
+	if x != nil {
+		return x.IsInclusive
+	}
+	return false
+}
+
+func (x *Tax) GetIsActive() bool {
+	if x != nil {
+		return x.IsActive
+	}
+	return false
+}
+
+type Product struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Id              string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Sku             string                 `protobuf:"bytes,2,opt,name=sku,proto3" json:"sku,omitempty"`
+	Name            string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	Slug            string                 `protobuf:"bytes,4,opt,name=slug,proto3" json:"slug,omitempty"`
+	Description     string                 `protobuf:"bytes,5,opt,name=description,proto3" json:"description,omitempty"`
+	CategoryId      string                 `protobuf:"bytes,6,opt,name=category_id,json=categoryId,proto3" json:"category_id,omitempty"`
+	Price           float64                `protobuf:"fixed64,7,opt,name=price,proto3" json:"price,omitempty"`
+	Currency        string                 `protobuf:"bytes,8,opt,name=currency,proto3" json:"currency,omitempty"`
+	Unit            string                 `protobuf:"bytes,9,opt,name=unit,proto3" json:"unit,omitempty"`
+	UnitQuantity    float64                `protobuf:"fixed64,10,opt,name=unit_quantity,json=unitQuantity,proto3" json:"unit_quantity,omitempty"`
+	PrimaryImageUrl string                 `protobuf:"bytes,11,opt,name=primary_image_url,json=primaryImageUrl,proto3" json:"primary_image_url,omitempty"`
+	Images          []string               `protobuf:"bytes,12,rep,name=images,proto3" json:"images,omitempty"`
+	Brand           string                 `protobuf:"bytes,13,opt,name=brand,proto3" json:"brand,omitempty"`
+	Manufacturer    string                 `protobuf:"bytes,14,opt,name=manufacturer,proto3" json:"manufacturer,omitempty"`
+	Barcode         string                 `protobuf:"bytes,15,opt,name=barcode,proto3" json:"barcode,omitempty"`
+	Ean             string                 `protobuf:"bytes,16,opt,name=ean,proto3" json:"ean,omitempty"`
+	Taxes           []string               `protobuf:"bytes,17,rep,name=taxes,proto3" json:"taxes,omitempty"`
+	IsActive        bool                   `protobuf:"varint,18,opt,name=is_active,json=isActive,proto3" json:"is_active,omitempty"`
+	IsFeatured      bool                   `protobuf:"varint,19,opt,name=is_featured,json=isFeatured,proto3" json:"is_featured,omitempty"`
+	IsCustomizable  bool                   `protobuf:"varint,20,opt,name=is_customizable,json=isCustomizable,proto3" json:"is_customizable,omitempty"`
+	IsAddon         bool                   `protobuf:"varint,21,opt,name=is_addon,json=isAddon,proto3" json:"is_addon,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *Product) Reset() {
+	*x = Product{}
+	mi := &file_gol_v1_catalog_sync_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Product) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Product) ProtoMessage() {}
+
+func (x *Product) ProtoReflect() protoreflect.Message {
+	mi := &file_gol_v1_catalog_sync_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Product.ProtoReflect.Descriptor instead.
+func (*Product) Descriptor() ([]byte, []int) {
+	return file_gol_v1_catalog_sync_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *Product) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Product) GetSku() string {
+	if x != nil {
+		return x.Sku
+	}
+	return ""
+}
+
+func (x *Product) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Product) GetSlug() string {
+	if x != nil {
+		return x.Slug
+	}
+	return ""
+}
+
+func (x *Product) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Product) GetCategoryId() string {
+	if x != nil {
+		return x.CategoryId
+	}
+	return ""
+}
+
+func (x *Product) GetPrice() float64 {
+	if x != nil {
+		return x.Price
+	}
+	return 0
+}
+
+func (x *Product) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+func (x *Product) GetUnit() string {
+	if x != nil {
+		return x.Unit
+	}
+	return ""
+}
+
+func (x *Product) GetUnitQuantity() float64 {
+	if x != nil {
+		return x.UnitQuantity
+	}
+	return 0
+}
+
+func (x *Product) GetPrimaryImageUrl() string {
+	if x != nil {
+		return x.PrimaryImageUrl
+	}
+	return ""
+}
+
+func (x *Product) GetImages() []string {
+	if x != nil {
+		return x.Images
+	}
+	return nil
+}
+
+func (x *Product) GetBrand() string {
+	if x != nil {
+		return x.Brand
+	}
+	return ""
+}
+
+func (x *Product) GetManufacturer() string {
+	if x != nil {
+		return x.Manufacturer
+	}
+	return ""
+}
+
+func (x *Product) GetBarcode() string {
+	if x != nil {
+		return x.Barcode
+	}
+	return ""
+}
+
+func (x *Product) GetEan() string {
+	if x != nil {
+		return x.Ean
+	}
+	return ""
+}
+
+func (x *Product) GetTaxes() []string {
+	if x != nil {
+		return x.Taxes
+	}
+	return nil
+}
+
+func (x *Product) GetIsActive() bool {
+	if x != nil {
+		return x.IsActive
+	}
+	return false
+}
+
+func (x *Product) GetIsFeatured() bool {
+	if x != nil {
+		return x.IsFeatured
+	}
+	return false
+}
+
+func (x *Product) GetIsCustomizable() bool {
+	if x != nil {
+		return x.IsCustomizable
+	}
+	return false
+}
+
+func (x *Product) GetIsAddon() bool {
+	if x != nil {
+		return x.IsAddon
+	}
+	return false
+}
+
+type Variation struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	ProductId     string                 `protobuf:"bytes,2,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
+	Name          string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	DisplayName   string                 `protobuf:"bytes,4,opt,name=display_name,json=displayName,proto3" json:"display_name,omitempty"`
+	Price         float64                `protobuf:"fixed64,5,opt,name=price,proto3" json:"price,omitempty"`
+	IsDefault     bool                   `protobuf:"varint,6,opt,name=is_default,json=isDefault,proto3" json:"is_default,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Variation) Reset() {
+	*x = Variation{}
+	mi := &file_gol_v1_catalog_sync_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Variation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Variation) ProtoMessage() {}
+
+func (x *Variation) ProtoReflect() protoreflect.Message {
+	mi := &file_gol_v1_catalog_sync_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Variation.ProtoReflect.Descriptor instead.
+func (*Variation) Descriptor() ([]byte, []int) {
+	return file_gol_v1_catalog_sync_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *Variation) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Variation) GetProductId() string {
+	if x != nil {
+		return x.ProductId
+	}
+	return ""
+}
+
+func (x *Variation) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Variation) GetDisplayName() string {
+	if x != nil {
+		return x.DisplayName
+	}
+	return ""
+}
+
+func (x *Variation) GetPrice() float64 {
+	if x != nil {
+		return x.Price
+	}
+	return 0
+}
+
+func (x *Variation) GetIsDefault() bool {
+	if x != nil {
+		return x.IsDefault
+	}
+	return false
+}
+
+type StoreProduct struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ProductId     string                 `protobuf:"bytes,1,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
+	Price         float64                `protobuf:"fixed64,2,opt,name=price,proto3" json:"price,omitempty"`
+	StockQuantity float64                `protobuf:"fixed64,3,opt,name=stock_quantity,json=stockQuantity,proto3" json:"stock_quantity,omitempty"`
+	IsInStock     bool                   `protobuf:"varint,4,opt,name=is_in_stock,json=isInStock,proto3" json:"is_in_stock,omitempty"`
+	Taxes         []string               `protobuf:"bytes,5,rep,name=taxes,proto3" json:"taxes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StoreProduct) Reset() {
+	*x = StoreProduct{}
+	mi := &file_gol_v1_catalog_sync_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StoreProduct) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StoreProduct) ProtoMessage() {}
+
+func (x *StoreProduct) ProtoReflect() protoreflect.Message {
+	mi := &file_gol_v1_catalog_sync_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StoreProduct.ProtoReflect.Descriptor instead.
+func (*StoreProduct) Descriptor() ([]byte, []int) {
+	return file_gol_v1_catalog_sync_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *StoreProduct) GetProductId() string {
+	if x != nil {
+		return x.ProductId
+	}
+	return ""
+}
+
+func (x *StoreProduct) GetPrice() float64 {
+	if x != nil {
+		return x.Price
+	}
+	return 0
+}
+
+func (x *StoreProduct) GetStockQuantity() float64 {
+	if x != nil {
+		return x.StockQuantity
+	}
+	return 0
+}
+
+func (x *StoreProduct) GetIsInStock() bool {
+	if x != nil {
+		return x.IsInStock
+	}
+	return false
+}
+
+func (x *StoreProduct) GetTaxes() []string {
+	if x != nil {
+		return x.Taxes
+	}
+	return nil
+}
+
+type PushProductsResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// "success"; "unchanged" when the push matched the store's last one and nothing was
+	// written; "incomplete" when it stopped partway, with the chunks committed before.
+	Status                   string          `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	StoreId                  string          `protobuf:"bytes,2,opt,name=store_id,json=storeId,proto3" json:"store_id,omitempty"`
+	Fingerprint              string          `protobuf:"bytes,3,opt,name=fingerprint,proto3" json:"fingerprint,omitempty"`
+	DryRun                   bool            `protobuf:"varint,4,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`
+	ProductsCreated          int32           `protobuf:"varint,5,opt,name=products_created,json=productsCreated,proto3" json:"products_created,omitempty"`
+	ProductsUpdated          int32           `protobuf:"varint,6,opt,name=products_updated,json=productsUpdated,proto3" json:"products_updated,omitempty"`
+	VariationsProcessed      int32           `protobuf:"varint,7,opt,name=variations_processed,json=variationsProcessed,proto3" json:"variations_processed,omitempty"`
+	StoreProductsProcessed   int32           `protobuf:"varint,8,opt,name=store_products_processed,json=storeProductsProcessed,proto3" json:"store_products_processed,omitempty"`
+	TaxesProcessed           int32           `protobuf:"varint,9,opt,name=taxes_processed,json=taxesProcessed,proto3" json:"taxes_processed,omitempty"`
+	ProductsCommitted        int32           `protobuf:"varint,10,opt,name=products_committed,json=productsCommitted,proto3" json:"products_committed,omitempty"`
+	ChunksCommitted          int32           `protobuf:"varint,11,opt,name=chunks_committed,json=chunksCommitted,proto3" json:"chunks_committed,omitempty"`
+	ChunksTotal              int32           `protobuf:"varint,12,opt,name=chunks_total,json=chunksTotal,proto3" json:"chunks_total,omitempty"`
+	StoreProductsDeactivated int32           `protobuf:"varint,13,opt,name=store_products_deactivated,json=storeProductsDeactivated,proto3" json:"store_products_deactivated,omitempty"`
+	Failures                 []*PushFailure  `protobuf:"bytes,14,rep,name=failures,proto3" json:"failures,omitempty"`
+	Matches                  []*ProductMatch `protobuf:"bytes,15,rep,name=matches,proto3" json:"matches,omitempty"`
+	unknownFields            protoimpl.UnknownFields
+	sizeCache                protoimpl.SizeCache
+}
+
+func (x *PushProductsResponse) Reset() {
+	*x = PushProductsResponse{}
+	mi := &file_gol_v1_catalog_sync_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PushProductsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PushProductsResponse) ProtoMessage() {}
+
+func (x *PushProductsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_gol_v1_catalog_sync_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PushProductsResponse.ProtoReflect.Descriptor instead.
+func (*PushProductsResponse) Descriptor() ([]byte, []int) {
+	return file_gol_v1_catalog_sync_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *PushProductsResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *PushProductsResponse) GetStoreId() string {
+	if x != nil {
+		return x.StoreId
+	}
+	return ""
+}
+
+func (x *PushProductsResponse) GetFingerprint() string {
+	if x != nil {
+		return x.Fingerprint
+	}
+	return ""
+}
+
+func (x *PushProductsResponse) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
+	}
+	return false
+}
+
+func (x *PushProductsResponse) GetProductsCreated() int32 {
+	if x != nil {
+		return x.ProductsCreated
+	}
+	return 0
+}
+
+func (x *PushProductsResponse) GetProductsUpdated() int32 {
+	if x != nil {
+		return x.ProductsUpdated
+	}
+	return 0
+}
+
+func (x *PushProductsResponse) GetVariationsProcessed() int32 {
+	if x != nil {
+		return x.VariationsProcessed
+	}
+	return 0
+}
+
+func (x *PushProductsResponse) GetStoreProductsProcessed() int32 {
+	if x != nil {
+		return x.StoreProductsProcessed
+	}
+	return 0
+}
+
+func (x *PushProductsResponse) GetTaxesProcessed() int32 {
+	if x != nil {
+		return x.TaxesProcessed
+	}
+	return 0
+}
+
+func (x *PushProductsResponse) GetProductsCommitted() int32 {
+	if x != nil {
+		return x.ProductsCommitted
+	}
+	return 0
+}
+
+func (x *PushProductsResponse) GetChunksCommitted() int32 {
+	if x != nil {
+		return x.ChunksCommitted
+	}
+	return 0
+}
+
+func (x *PushProductsResponse) GetChunksTotal() int32 {
+	if x != nil {
+		return x.ChunksTotal
+	}
+	return 0
+}
+
+func (x *PushProductsResponse) GetStoreProductsDeactivated() int32 {
+	if x != nil {
+		return x.StoreProductsDeactivated
+	}
+	return 0
+}
+
+func (x *PushProductsResponse) GetFailures() []*PushFailure {
+	if x != nil {
+		return x.Failures
+	}
+	return nil
+}
+
+func (x *PushProductsResponse) GetMatches() []*ProductMatch {
+	if x != nil {
+		return x.Matches
+	}
+	return nil
+}
+
+type PushFailure struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Index         int32                  `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
+	ExternalId    string                 `protobuf:"bytes,2,opt,name=external_id,json=externalId,proto3" json:"external_id,omitempty"`
+	Reason        string                 `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PushFailure) Reset() {
+	*x = PushFailure{}
+	mi := &file_gol_v1_catalog_sync_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PushFailure) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PushFailure) ProtoMessage() {}
+
+func (x *PushFailure) ProtoReflect() protoreflect.Message {
+	mi := &file_gol_v1_catalog_sync_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PushFailure.ProtoReflect.Descriptor instead.
+func (*PushFailure) Descriptor() ([]byte, []int) {
+	return file_gol_v1_catalog_sync_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *PushFailure) GetIndex() int32 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *PushFailure) GetExternalId() string {
+	if x != nil {
+		return x.ExternalId
+	}
+	return ""
+}
+
+func (x *PushFailure) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type ProductMatch struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Index         int32                  `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
+	ExternalId    string                 `protobuf:"bytes,2,opt,name=external_id,json=externalId,proto3" json:"external_id,omitempty"`
+	Action        string                 `protobuf:"bytes,3,opt,name=action,proto3" json:"action,omitempty"`
+	ProductId     string                 `protobuf:"bytes,4,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
+	MatchType     string                 `protobuf:"bytes,5,opt,name=match_type,json=matchType,proto3" json:"match_type,omitempty"`
+	Confidence    float64                `protobuf:"fixed64,6,opt,name=confidence,proto3" json:"confidence,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProductMatch) Reset() {
+	*x = ProductMatch{}
+	mi := &file_gol_v1_catalog_sync_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProductMatch) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProductMatch) ProtoMessage() {}
+
+func (x *ProductMatch) ProtoReflect() protoreflect.Message {
+	mi := &file_gol_v1_catalog_sync_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProductMatch.ProtoReflect.Descriptor instead.
+func (*ProductMatch) Descriptor() ([]byte, []int) {
+	return file_gol_v1_catalog_sync_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ProductMatch) GetIndex() int32 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *ProductMatch) GetExternalId() string {
+	if x != nil {
+		return x.ExternalId
+	}
+	return ""
+}
+
+func (x *ProductMatch) GetAction() string {
+	if x != nil {
+		return x.Action
+	}
+	return ""
+}
+
+func (x *ProductMatch) GetProductId() string {
+	if x != nil {
+		return x.ProductId
+	}
+	return ""
+}
+
+func (x *ProductMatch) GetMatchType() string {
+	if x != nil {
+		return x.MatchType
+	}
+	return ""
+}
+
+func (x *ProductMatch) GetConfidence() float64 {
+	if x != nil {
+		return x.Confidence
+	}
+	return 0
+}
+
+type UpdateStockRequest struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	StoreId  string                 `protobuf:"bytes,1,opt,name=store_id,json=storeId,proto3" json:"store_id,omitempty"`
+	Products []*StockProductUpdate  `protobuf:"bytes,2,rep,name=products,proto3" json:"products,omitempty"`
+	// Apply the updates and roll them back, reporting which IDs matched.
+	DryRun        bool `protobuf:"varint,3,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateStockRequest) Reset() {
+	*x = UpdateStockRequest{}
+	mi := &file_gol_v1_catalog_sync_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateStockRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateStockRequest) ProtoMessage() {}
+
+func (x *UpdateStockRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gol_v1_catalog_sync_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateStockRequest.ProtoReflect.Descriptor instead.
+func (*UpdateStockRequest) Descriptor() ([]byte, []int) {
+	return file_gol_v1_catalog_sync_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *UpdateStockRequest) GetStoreId() string {
+	if x != nil {
+		return x.StoreId
+	}
+	return ""
+}
+
+func (x *UpdateStockRequest) GetProducts() []*StockProductUpdate {
+	if x != nil {
+		return x.Products
+	}
+	return nil
+}
+
+func (x *UpdateStockRequest) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
+	}
+	return false
+}
+
+type StockProductUpdate struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	StockQuantity float64                `protobuf:"fixed64,2,opt,name=stock_quantity,json=stockQuantity,proto3" json:"stock_quantity,omitempty"`
+	IsAvailable   bool                   `protobuf:"varint,3,opt,name=is_available,json=isAvailable,proto3" json:"is_available,omitempty"`
+	Price         float64                `protobuf:"fixed64,4,opt,name=price,proto3" json:"price,omitempty"`
+	Variants      []*StockVariantUpdate  `protobuf:"bytes,5,rep,name=variants,proto3" json:"variants,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StockProductUpdate) Reset() {
+	*x = StockProductUpdate{}
+	mi := &file_gol_v1_catalog_sync_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StockProductUpdate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StockProductUpdate) ProtoMessage() {}
+
+func (x *StockProductUpdate) ProtoReflect() protoreflect.Message {
+	mi := &file_gol_v1_catalog_sync_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StockProductUpdate.ProtoReflect.Descriptor instead.
+func (*StockProductUpdate) Descriptor() ([]byte, []int) {
+	return file_gol_v1_catalog_sync_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *StockProductUpdate) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *StockProductUpdate) GetStockQuantity() float64 {
+	if x != nil {
+		return x.StockQuantity
+	}
+	return 0
+}
+
+func (x *StockProductUpdate) GetIsAvailable() bool {
+	if x != nil {
+		return x.IsAvailable
+	}
+	return false
+}
+
+func (x *StockProductUpdate) GetPrice() float64 {
+	if x != nil {
+		return x.Price
+	}
+	return 0
+}
+
+func (x *StockProductUpdate) GetVariants() []*StockVariantUpdate {
+	if x != nil {
+		return x.Variants
+	}
+	return nil
+}
+
+type StockVariantUpdate struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	StockQuantity float64                `protobuf:"fixed64,2,opt,name=stock_quantity,json=stockQuantity,proto3" json:"stock_quantity,omitempty"`
+	IsAvailable   bool                   `protobuf:"varint,3,opt,name=is_available,json=isAvailable,proto3" json:"is_available,omitempty"`
+	Price         float64                `protobuf:"fixed64,4,opt,name=price,proto3" json:"price,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StockVariantUpdate) Reset() {
+	*x = StockVariantUpdate{}
+	mi := &file_gol_v1_catalog_sync_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StockVariantUpdate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StockVariantUpdate) ProtoMessage() {}
+
+func (x *StockVariantUpdate) ProtoReflect() protoreflect.Message {
+	mi := &file_gol_v1_catalog_sync_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StockVariantUpdate.ProtoReflect.Descriptor instead.
+func (*StockVariantUpdate) Descriptor() ([]byte, []int) {
+	return file_gol_v1_catalog_sync_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *StockVariantUpdate) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *StockVariantUpdate) GetStockQuantity() float64 {
+	if x != nil {
+		return x.StockQuantity
+	}
+	return 0
+}
+
+func (x *StockVariantUpdate) GetIsAvailable() bool {
+	if x != nil {
+		return x.IsAvailable
+	}
+	return false
+}
+
+func (x *StockVariantUpdate) GetPrice() float64 {
+	if x != nil {
+		return x.Price
+	}
+	return 0
+}
+
+type UpdateStockResponse struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	DryRun             bool                   `protobuf:"varint,1,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`
+	ProductsUpdated    int32                  `protobuf:"varint,2,opt,name=products_updated,json=productsUpdated,proto3" json:"products_updated,omitempty"`
+	ProductsNotFound   int32                  `protobuf:"varint,3,opt,name=products_not_found,json=productsNotFound,proto3" json:"products_not_found,omitempty"`
+	VariantsUpdated    int32                  `protobuf:"varint,4,opt,name=variants_updated,json=variantsUpdated,proto3" json:"variants_updated,omitempty"`
+	VariantsNotFound   int32                  `protobuf:"varint,5,opt,name=variants_not_found,json=variantsNotFound,proto3" json:"variants_not_found,omitempty"`
+	NotFoundProductIds []string               `protobuf:"bytes,6,rep,name=not_found_product_ids,json=notFoundProductIds,proto3" json:"not_found_product_ids,omitempty"`
+	NotFoundVariantIds []string               `protobuf:"bytes,7,rep,name=not_found_variant_ids,json=notFoundVariantIds,proto3" json:"not_found_variant_ids,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *UpdateStockResponse) Reset() {
+	*x = UpdateStockResponse{}
+	mi := &file_gol_v1_catalog_sync_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateStockResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateStockResponse) ProtoMessage() {}
+
+func (x *UpdateStockResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_gol_v1_catalog_sync_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateStockResponse.ProtoReflect.Descriptor instead.
+func (*UpdateStockResponse) Descriptor() ([]byte, []int) {
+	return file_gol_v1_catalog_sync_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *UpdateStockResponse) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
+	}
+	return false
+}
+
+func (x *UpdateStockResponse) GetProductsUpdated() int32 {
+	if x != nil {
+		return x.ProductsUpdated
+	}
+	return 0
+}
+
+func (x *UpdateStockResponse) GetProductsNotFound() int32 {
+	if x != nil {
+		return x.ProductsNotFound
+	}
+	return 0
+}
+
+func (x *UpdateStockResponse) GetVariantsUpdated() int32 {
+	if x != nil {
+		return x.VariantsUpdated
+	}
+	return 0
+}
+
+func (x *UpdateStockResponse) GetVariantsNotFound() int32 {
+	if x != nil {
+		return x.VariantsNotFound
+	}
+	return 0
+}
+
+func (x *UpdateStockResponse) GetNotFoundProductIds() []string {
+	if x != nil {
+		return x.NotFoundProductIds
+	}
+	return nil
+}
+
+func (x *UpdateStockResponse) GetNotFoundVariantIds() []string {
+	if x != nil {
+		return x.NotFoundVariantIds
+	}
+	return nil
+}
+
+type GetStoreCatalogRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The store's ERP ID, as pushed in store_details.store_id.
+	StoreId       string `protobuf:"bytes,1,opt,name=store_id,json=storeId,proto3" json:"store_id,omitempty"`
+	CategoryId    string `protobuf:"bytes,2,opt,name=category_id,json=categoryId,proto3" json:"category_id,omitempty"`
+	InStockOnly   bool   `protobuf:"varint,3,opt,name=in_stock_only,json=inStockOnly,proto3" json:"in_stock_only,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetStoreCatalogRequest) Reset() {
+	*x = GetStoreCatalogRequest{}
+	mi := &file_gol_v1_catalog_sync_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStoreCatalogRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStoreCatalogRequest) ProtoMessage() {}
+
+func (x *GetStoreCatalogRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gol_v1_catalog_sync_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStoreCatalogRequest.ProtoReflect.Descriptor instead.
+func (*GetStoreCatalogRequest) Descriptor() ([]byte, []int) {
+	return file_gol_v1_catalog_sync_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *GetStoreCatalogRequest) GetStoreId() string {
+	if x != nil {
+		return x.StoreId
+	}
+	return ""
+}
+
+func (x *GetStoreCatalogRequest) GetCategoryId() string {
+	if x != nil {
+		return x.CategoryId
+	}
+	return ""
+}
+
+func (x *GetStoreCatalogRequest) GetInStockOnly() bool {
+	if x != nil {
+		return x.InStockOnly
+	}
+	return false
+}
+
+type StoreListing struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Id              string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	StoreId         string                 `protobuf:"bytes,2,opt,name=store_id,json=storeId,proto3" json:"store_id,omitempty"`
+	ProductId       string                 `protobuf:"bytes,3,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
+	Sku             string                 `protobuf:"bytes,4,opt,name=sku,proto3" json:"sku,omitempty"`
+	Name            string                 `protobuf:"bytes,5,opt,name=name,proto3" json:"name,omitempty"`
+	Slug            string                 `protobuf:"bytes,6,opt,name=slug,proto3" json:"slug,omitempty"`
+	Description     *string                `protobuf:"bytes,7,opt,name=description,proto3,oneof" json:"description,omitempty"`
+	Unit            *string                `protobuf:"bytes,8,opt,name=unit,proto3,oneof" json:"unit,omitempty"`
+	UnitQuantity    *float64               `protobuf:"fixed64,9,opt,name=unit_quantity,json=unitQuantity,proto3,oneof" json:"unit_quantity,omitempty"`
+	PrimaryImageUrl *string                `protobuf:"bytes,10,opt,name=primary_image_url,json=primaryImageUrl,proto3,oneof" json:"primary_image_url,omitempty"`
+	Price           float64                `protobuf:"fixed64,11,opt,name=price,proto3" json:"price,omitempty"`
+	SalePrice       *float64               `protobuf:"fixed64,12,opt,name=sale_price,json=salePrice,proto3,oneof" json:"sale_price,omitempty"`
+	Currency        *string                `protobuf:"bytes,13,opt,name=currency,proto3,oneof" json:"currency,omitempty"`
+	StockQuantity   float64                `protobuf:"fixed64,14,opt,name=stock_quantity,json=stockQuantity,proto3" json:"stock_quantity,omitempty"`
+	IsInStock       bool                   `protobuf:"varint,15,opt,name=is_in_stock,json=isInStock,proto3" json:"is_in_stock,omitempty"`
+	CategoryId      *string                `protobuf:"bytes,16,opt,name=category_id,json=categoryId,proto3,oneof" json:"category_id,omitempty"`
+	BrandId         *string                `protobuf:"bytes,17,opt,name=brand_id,json=brandId,proto3,oneof" json:"brand_id,omitempty"`
+	Variations      []*ListingVariation    `protobuf:"bytes,18,rep,name=variations,proto3" json:"variations,omitempty"`
+	Taxes           []*ListingTax          `protobuf:"bytes,19,rep,name=taxes,proto3" json:"taxes,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *StoreListing) Reset() {
+	*x = StoreListing{}
+	mi := &file_gol_v1_catalog_sync_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StoreListing) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StoreListing) ProtoMessage() {}
+
+func (x *StoreListing) ProtoReflect() protoreflect.Message {
+	mi := &file_gol_v1_catalog_sync_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StoreListing.ProtoReflect.Descriptor instead.
+func (*StoreListing) Descriptor() ([]byte, []int) {
+	return file_gol_v1_catalog_sync_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *StoreListing) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *StoreListing) GetStoreId() string {
+	if x != nil {
+		return x.StoreId
+	}
+	return ""
+}
+
+func (x *StoreListing) GetProductId() string {
+	if x != nil {
+		return x.ProductId
+	}
+	return ""
+}
+
+func (x *StoreListing) GetSku() string {
+	if x != nil {
+		return x.Sku
+	}
+	return ""
+}
+
+func (x *StoreListing) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *StoreListing) GetSlug() string {
+	if x != nil {
+		return x.Slug
+	}
+	return ""
+}
+
+func (x *StoreListing) GetDescription() string {
+	if x != nil && x.Description != nil {
+		return *x.Description
+	}
+	return ""
+}
+
+func (x *StoreListing) GetUnit() string {
+	if x != nil && x.Unit != nil {
+		return *x.Unit
+	}
+	return ""
+}
+
+func (x *StoreListing) GetUnitQuantity() float64 {
+	if x != nil && x.UnitQuantity != nil {
+		return *x.UnitQuantity
+	}
+	return 0
+}
+
+func (x *StoreListing) GetPrimaryImageUrl() string {
+	if x != nil && x.PrimaryImageUrl != nil {
+		return *x.PrimaryImageUrl
+	}
+	return ""
+}
+
+func (x *StoreListing) GetPrice() float64 {
+	if x != nil {
+		return x.Price
+	}
+	return 0
+}
+
+func (x *StoreListing) GetSalePrice() float64 {
+	if x != nil && x.SalePrice != nil {
+		return *x.SalePrice
+	}
+	return 0
+}
+
+func (x *StoreListing) GetCurrency() string {
+	if x != nil && x.Currency != nil {
+		return *x.Currency
+	}
+	return ""
+}
+
+func (x *StoreListing) GetStockQuantity() float64 {
+	if x != nil {
+		return x.StockQuantity
+	}
+	return 0
+}
+
+func (x *StoreListing) GetIsInStock() bool {
+	if x != nil {
+		return x.IsInStock
+	}
+	return false
+}
+
+func (x *StoreListing) GetCategoryId() string {
+	if x != nil && x.CategoryId != nil {
+		return *x.CategoryId
+	}
+	return ""
+}
+
+func (x *StoreListing) GetBrandId() string {
+	if x != nil && x.BrandId != nil {
+		return *x.BrandId
+	}
+	return ""
+}
+
+func (x *StoreListing) GetVariations() []*ListingVariation {
+	if x != nil {
+		return x.Variations
+	}
+	return nil
+}
+
+func (x *StoreListing) GetTaxes() []*ListingTax {
+	if x != nil {
+		return x.Taxes
+	}
+	return nil
+}
+
+type ListingVariation struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	ExternalId    *string                `protobuf:"bytes,2,opt,name=external_id,json=externalId,proto3,oneof" json:"external_id,omitempty"`
+	Name          string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	DisplayName   string                 `protobuf:"bytes,4,opt,name=display_name,json=displayName,proto3" json:"display_name,omitempty"`
+	Price         float64                `protobuf:"fixed64,5,opt,name=price,proto3" json:"price,omitempty"`
+	SalePrice     *float64               `protobuf:"fixed64,6,opt,name=sale_price,json=salePrice,proto3,oneof" json:"sale_price,omitempty"`
+	StockQuantity *float64               `protobuf:"fixed64,7,opt,name=stock_quantity,json=stockQuantity,proto3,oneof" json:"stock_quantity,omitempty"`
+	IsInStock     bool                   `protobuf:"varint,8,opt,name=is_in_stock,json=isInStock,proto3" json:"is_in_stock,omitempty"`
+	IsDefault     bool                   `protobuf:"varint,9,opt,name=is_default,json=isDefault,proto3" json:"is_default,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListingVariation) Reset() {
+	*x = ListingVariation{}
+	mi := &file_gol_v1_catalog_sync_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListingVariation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListingVariation) ProtoMessage() {}
+
+func (x *ListingVariation) ProtoReflect() protoreflect.Message {
+	mi := &file_gol_v1_catalog_sync_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListingVariation.ProtoReflect.Descriptor instead.
+func (*ListingVariation) Descriptor() ([]byte, []int) {
+	return file_gol_v1_catalog_sync_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *ListingVariation) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *ListingVariation) GetExternalId() string {
+	if x != nil && x.ExternalId != nil {
+		return *x.ExternalId
+	}
+	return ""
+}
+
+func (x *ListingVariation) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ListingVariation) GetDisplayName() string {
+	if x != nil {
+		return x.DisplayName
+	}
+	return ""
+}
+
+func (x *ListingVariation) GetPrice() float64 {
+	if x != nil {
+		return x.Price
+	}
+	return 0
+}
+
+func (x *ListingVariation) GetSalePrice() float64 {
+	if x != nil && x.SalePrice != nil {
+		return *x.SalePrice
+	}
+	return 0
+}
+
+func (x *ListingVariation) GetStockQuantity() float64 {
+	if x != nil && x.StockQuantity != nil {
+		return *x.StockQuantity
+	}
+	return 0
+}
+
+func (x *ListingVariation) GetIsInStock() bool {
+	if x != nil {
+		return x.IsInStock
+	}
+	return false
+}
+
+func (x *ListingVariation) GetIsDefault() bool {
+	if x != nil {
+		return x.IsDefault
+	}
+	return false
+}
+
+type ListingTax struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	TaxId         string                 `protobuf:"bytes,2,opt,name=tax_id,json=taxId,proto3" json:"tax_id,omitempty"`
+	Name          string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	Rate          float64                `protobuf:"fixed64,4,opt,name=rate,proto3" json:"rate,omitempty"`
+	TaxType       string                 `protobuf:"bytes,5,opt,name=tax_type,json=taxType,proto3" json:"tax_type,omitempty"`
+	IsInclusive   bool                   `protobuf:"varint,6,opt,name=is_inclusive,json=isInclusive,proto3" json:"is_inclusive,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListingTax) Reset() {
+	*x = ListingTax{}
+	mi := &file_gol_v1_catalog_sync_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListingTax) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListingTax) ProtoMessage() {}
+
+func (x *ListingTax) ProtoReflect() protoreflect.Message {
+	mi := &file_gol_v1_catalog_sync_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListingTax.ProtoReflect.Descriptor instead.
+func (*ListingTax) Descriptor() ([]byte, []int) {
+	return file_gol_v1_catalog_sync_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *ListingTax) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *ListingTax) GetTaxId() string {
+	if x != nil {
+		return x.TaxId
+	}
+	return ""
+}
+
+func (x *ListingTax) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ListingTax) GetRate() float64 {
+	if x != nil {
+		return x.Rate
+	}
+	return 0
+}
+
+func (x *ListingTax) GetTaxType() string {
+	if x != nil {
+		return x.TaxType
+	}
+	return ""
+}
+
+func (x *ListingTax) GetIsInclusive() bool {
+	if x != nil {
+		return x.IsInclusive
+	}
+	return false
+}
+
+var File_gol_v1_catalog_sync_proto protoreflect.FileDescriptor
+
+const file_gol_v1_catalog_sync_proto_rawDesc = "" +
+	"\n" +
+	"\x19gol/v1/catalog_sync.proto\x12\x06gol.v1\"y\n" +
+	"\x13PushProductsRequest\x12,\n" +
+	"\x06header\x18\x01 \x01(\v2\x12.gol.v1.PushHeaderH\x00R\x06header\x12)\n" +
+	"\x05batch\x18\x02 \x01(\v2\x11.gol.v1.PushBatchH\x00R\x05batchB\t\n" +
+	"\apayload\"\x97\x01\n" +
+	"\n" +
+	"PushHeader\x129\n" +
+	"\rstore_details\x18\x01 \x01(\v2\x14.gol.v1.StoreDetailsR\fstoreDetails\x12\x1b\n" +
+	"\tsync_mode\x18\x02 \x01(\tR\bsyncMode\x12\x18\n" +
+	"\apartial\x18\x03 \x01(\bR\apartial\x12\x17\n" +
+	"\adry_run\x18\x04 \x01(\bR\x06dryRun\"\xfd\x01\n" +
+	"\tPushBatch\x120\n" +
+	"\n" +
+	"categories\x18\x01 \x03(\v2\x10.gol.v1.CategoryR\n" +
+	"categories\x12!\n" +
+	"\x05taxes\x18\x02 \x03(\v2\v.gol.v1.TaxR\x05taxes\x12+\n" +
+	"\bproducts\x18\x03 \x03(\v2\x0f.gol.v1.ProductR\bproducts\x121\n" +
+	"\n" +
+	"variations\x18\x04 \x03(\v2\x11.gol.v1.VariationR\n" +
+	"variations\x12;\n" +
+	"\x0estore_products\x18\x05 \x03(\v2\x14.gol.v1.StoreProductR\rstoreProducts\"\x96\x01\n" +
+	"\fStoreDetails\x12\x19\n" +
+	"\bstore_id\x18\x01 \x01(\tR\astoreId\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12)\n" +
+	"\aaddress\x18\x03 \x01(\v2\x0f.gol.v1.AddressR\aaddress\x12,\n" +
+	"\blocation\x18\x04 \x01(\v2\x10.gol.v1.LocationR\blocation\"j\n" +
+	"\aAddress\x12\x14\n" +
+	"\x05line1\x18\x01 \x01(\tR\x05line1\x12\x12\n" +
+	"\x04city\x18\x02 \x01(\tR\x04city\x12\x14\n" +
+	"\x05state\x18\x03 \x01(\tR\x05state\x12\x1f\n" +
+	"\vpostal_code\x18\x04 \x01(\tR\n" +
+	"postalCode\".\n" +
+	"\bLocation\x12\x10\n" +
+	"\x03lat\x18\x01 \x01(\x01R\x03lat\x12\x10\n" +
+	"\x03lng\x18\x02 \x01(\x01R\x03lng\"\xd6\x01\n" +
+	"\bCategory\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12 \n" +
+	"\tparent_id\x18\x02 \x01(\tH\x00R\bparentId\x88\x01\x01\x12\x12\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\x12\x12\n" +
+	"\x04slug\x18\x04 \x01(\tR\x04slug\x12 \n" +
+	"\vdescription\x18\x05 \x01(\tR\vdescription\x12#\n" +
+	"\rdisplay_order\x18\x06 \x01(\x05R\fdisplayOrder\x12\x1b\n" +
+	"\tis_active\x18\a \x01(\bR\bisActiveB\f\n" +
+	"\n" +
+	"_parent_id\"\xd1\x01\n" +
+	"\x03Tax\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x15\n" +
+	"\x06tax_id\x18\x03 \x01(\tR\x05taxId\x12 \n" +
+	"\vdescription\x18\x04 \x01(\tR\vdescription\x12\x12\n" +
+	"\x04rate\x18\x05 \x01(\x01R\x04rate\x12\x19\n" +
+	"\btax_type\x18\x06 \x01(\tR\ataxType\x12!\n" +
+	"\fis_inclusive\x18\a \x01(\bR\visInclusive\x12\x1b\n" +
+	"\tis_active\x18\b \x01(\bR\bisActive\"\xc3\x04\n" +
+	"\aProduct\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x10\n" +
+	"\x03sku\x18\x02 \x01(\tR\x03sku\x12\x12\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\x12\x12\n" +
+	"\x04slug\x18\x04 \x01(\tR\x04slug\x12 \n" +
+	"\vdescription\x18\x05 \x01(\tR\vdescription\x12\x1f\n" +
+	"\vcategory_id\x18\x06 \x01(\tR\n" +
+	"categoryId\x12\x14\n" +
+	"\x05price\x18\a \x01(\x01R\x05price\x12\x1a\n" +
+	"\bcurrency\x18\b \x01(\tR\bcurrency\x12\x12\n" +
+	"\x04unit\x18\t \x01(\tR\x04unit\x12#\n" +
+	"\runit_quantity\x18\n" +
+	" \x01(\x01R\funitQuantity\x12*\n" +
+	"\x11primary_image_url\x18\v \x01(\tR\x0fprimaryImageUrl\x12\x16\n" +
+	"\x06images\x18\f \x03(\tR\x06images\x12\x14\n" +
+	"\x05brand\x18\r \x01(\tR\x05brand\x12\"\n" +
+	"\fmanufacturer\x18\x0e \x01(\tR\fmanufacturer\x12\x18\n" +
+	"\abarcode\x18\x0f \x01(\tR\abarcode\x12\x10\n" +
+	"\x03ean\x18\x10 \x01(\tR\x03ean\x12\x14\n" +
+	"\x05taxes\x18\x11 \x03(\tR\x05taxes\x12\x1b\n" +
+	"\tis_active\x18\x12 \x01(\bR\bisActive\x12\x1f\n" +
+	"\vis_featured\x18\x13 \x01(\bR\n" +
+	"isFeatured\x12'\n" +
+	"\x0fis_customizable\x18\x14 \x01(\bR\x0eisCustomizable\x12\x19\n" +
+	"\bis_addon\x18\x15 \x01(\bR\aisAddon\"\xa6\x01\n" +
+	"\tVariation\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1d\n" +
+	"\n" +
+	"product_id\x18\x02 \x01(\tR\tproductId\x12\x12\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\x12!\n" +
+	"\fdisplay_name\x18\x04 \x01(\tR\vdisplayName\x12\x14\n" +
+	"\x05price\x18\x05 \x01(\x01R\x05price\x12\x1d\n" +
+	"\n" +
+	"is_default\x18\x06 \x01(\bR\tisDefault\"\xa0\x01\n" +
+	"\fStoreProduct\x12\x1d\n" +
+	"\n" +
+	"product_id\x18\x01 \x01(\tR\tproductId\x12\x14\n" +
+	"\x05price\x18\x02 \x01(\x01R\x05price\x12%\n" +
+	"\x0estock_quantity\x18\x03 \x01(\x01R\rstockQuantity\x12\x1e\n" +
+	"\vis_in_stock\x18\x04 \x01(\bR\tisInStock\x12\x14\n" +
+	"\x05taxes\x18\x05 \x03(\tR\x05taxes\"\x8c\x05\n" +
+	"\x14PushProductsResponse\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\x12\x19\n" +
+	"\bstore_id\x18\x02 \x01(\tR\astoreId\x12 \n" +
+	"\vfingerprint\x18\x03 \x01(\tR\vfingerprint\x12\x17\n" +
+	"\adry_run\x18\x04 \x01(\bR\x06dryRun\x12)\n" +
+	"\x10products_created\x18\x05 \x01(\x05R\x0fproductsCreated\x12)\n" +
+	"\x10products_updated\x18\x06 \x01(\x05R\x0fproductsUpdated\x121\n" +
+	"\x14variations_processed\x18\a \x01(\x05R\x13variationsProcessed\x128\n" +
+	"\x18store_products_processed\x18\b \x01(\x05R\x16storeProductsProcessed\x12'\n" +
+	"\x0ftaxes_processed\x18\t \x01(\x05R\x0etaxesProcessed\x12-\n" +
+	"\x12products_committed\x18\n" +
+	" \x01(\x05R\x11productsCommitted\x12)\n" +
+	"\x10chunks_committed\x18\v \x01(\x05R\x0fchunksCommitted\x12!\n" +
+	"\fchunks_total\x18\f \x01(\x05R\vchunksTotal\x12<\n" +
+	"\x1astore_products_deactivated\x18\r \x01(\x05R\x18storeProductsDeactivated\x12/\n" +
+	"\bfailures\x18\x0e \x03(\v2\x13.gol.v1.PushFailureR\bfailures\x12.\n" +
+	"\amatches\x18\x0f \x03(\v2\x14.gol.v1.ProductMatchR\amatches\"\\\n" +
+	"\vPushFailure\x12\x14\n" +
+	"\x05index\x18\x01 \x01(\x05R\x05index\x12\x1f\n" +
+	"\vexternal_id\x18\x02 \x01(\tR\n" +
+	"externalId\x12\x16\n" +
+	"\x06reason\x18\x03 \x01(\tR\x06reason\"\xbb\x01\n" +
+	"\fProductMatch\x12\x14\n" +
+	"\x05index\x18\x01 \x01(\x05R\x05index\x12\x1f\n" +
+	"\vexternal_id\x18\x02 \x01(\tR\n" +
+	"externalId\x12\x16\n" +
+	"\x06action\x18\x03 \x01(\tR\x06action\x12\x1d\n" +
+	"\n" +
+	"product_id\x18\x04 \x01(\tR\tproductId\x12\x1d\n" +
+	"\n" +
+	"match_type\x18\x05 \x01(\tR\tmatchType\x12\x1e\n" +
+	"\n" +
+	"confidence\x18\x06 \x01(\x01R\n" +
+	"confidence\"\x80\x01\n" +
+	"\x12UpdateStockRequest\x12\x19\n" +
+	"\bstore_id\x18\x01 \x01(\tR\astoreId\x126\n" +
+	"\bproducts\x18\x02 \x03(\v2\x1a.gol.v1.StockProductUpdateR\bproducts\x12\x17\n" +
+	"\adry_run\x18\x03 \x01(\bR\x06dryRun\"\xbc\x01\n" +
+	"\x12StockProductUpdate\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12%\n" +
+	"\x0estock_quantity\x18\x02 \x01(\x01R\rstockQuantity\x12!\n" +
+	"\fis_available\x18\x03 \x01(\bR\visAvailable\x12\x14\n" +
+	"\x05price\x18\x04 \x01(\x01R\x05price\x126\n" +
+	"\bvariants\x18\x05 \x03(\v2\x1a.gol.v1.StockVariantUpdateR\bvariants\"\x84\x01\n" +
+	"\x12StockVariantUpdate\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12%\n" +
+	"\x0estock_quantity\x18\x02 \x01(\x01R\rstockQuantity\x12!\n" +
+	"\fis_available\x18\x03 \x01(\bR\visAvailable\x12\x14\n" +
+	"\x05price\x18\x04 \x01(\x01R\x05price\"\xc6\x02\n" +
+	"\x13UpdateStockResponse\x12\x17\n" +
+	"\adry_run\x18\x01 \x01(\bR\x06dryRun\x12)\n" +
+	"\x10products_updated\x18\x02 \x01(\x05R\x0fproductsUpdated\x12,\n" +
+	"\x12products_not_found\x18\x03 \x01(\x05R\x10productsNotFound\x12)\n" +
+	"\x10variants_updated\x18\x04 \x01(\x05R\x0fvariantsUpdated\x12,\n" +
+	"\x12variants_not_found\x18\x05 \x01(\x05R\x10variantsNotFound\x121\n" +
+	"\x15not_found_product_ids\x18\x06 \x03(\tR\x12notFoundProductIds\x121\n" +
+	"\x15not_found_variant_ids\x18\a \x03(\tR\x12notFoundVariantIds\"x\n" +
+	"\x16GetStoreCatalogRequest\x12\x19\n" +
+	"\bstore_id\x18\x01 \x01(\tR\astoreId\x12\x1f\n" +
+	"\vcategory_id\x18\x02 \x01(\tR\n" +
+	"categoryId\x12\"\n" +
+	"\rin_stock_only\x18\x03 \x01(\bR\vinStockOnly\"\xf3\x05\n" +
+	"\fStoreListing\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x19\n" +
+	"\bstore_id\x18\x02 \x01(\tR\astoreId\x12\x1d\n" +
+	"\n" +
+	"product_id\x18\x03 \x01(\tR\tproductId\x12\x10\n" +
+	"\x03sku\x18\x04 \x01(\tR\x03sku\x12\x12\n" +
+	"\x04name\x18\x05 \x01(\tR\x04name\x12\x12\n" +
+	"\x04slug\x18\x06 \x01(\tR\x04slug\x12%\n" +
+	"\vdescription\x18\a \x01(\tH\x00R\vdescription\x88\x01\x01\x12\x17\n" +
+	"\x04unit\x18\b \x01(\tH\x01R\x04unit\x88\x01\x01\x12(\n" +
+	"\runit_quantity\x18\t \x01(\x01H\x02R\funitQuantity\x88\x01\x01\x12/\n" +
+	"\x11primary_image_url\x18\n" +
+	" \x01(\tH\x03R\x0fprimaryImageUrl\x88\x01\x01\x12\x14\n" +
+	"\x05price\x18\v \x01(\x01R\x05price\x12\"\n" +
+	"\n" +
+	"sale_price\x18\f \x01(\x01H\x04R\tsalePrice\x88\x01\x01\x12\x1f\n" +
+	"\bcurrency\x18\r \x01(\tH\x05R\bcurrency\x88\x01\x01\x12%\n" +
+	"\x0estock_quantity\x18\x0e \x01(\x01R\rstockQuantity\x12\x1e\n" +
+	"\vis_in_stock\x18\x0f \x01(\bR\tisInStock\x12$\n" +
+	"\vcategory_id\x18\x10 \x01(\tH\x06R\n" +
+	"categoryId\x88\x01\x01\x12\x1e\n" +
+	"\bbrand_id\x18\x11 \x01(\tH\aR\abrandId\x88\x01\x01\x128\n" +
+	"\n" +
+	"variations\x18\x12 \x03(\v2\x18.gol.v1.ListingVariationR\n" +
+	"variations\x12(\n" +
+	"\x05taxes\x18\x13 \x03(\v2\x12.gol.v1.ListingTaxR\x05taxesB\x0e\n" +
+	"\f_descriptionB\a\n" +
+	"\x05_unitB\x10\n" +
+	"\x0e_unit_quantityB\x14\n" +
+	"\x12_primary_image_urlB\r\n" +
+	"\v_sale_priceB\v\n" +
+	"\t_currencyB\x0e\n" +
+	"\f_category_idB\v\n" +
+	"\t_brand_id\"\xd6\x02\n" +
+	"\x10ListingVariation\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12$\n" +
+	"\vexternal_id\x18\x02 \x01(\tH\x00R\n" +
+	"externalId\x88\x01\x01\x12\x12\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\x12!\n" +
+	"\fdisplay_name\x18\x04 \x01(\tR\vdisplayName\x12\x14\n" +
+	"\x05price\x18\x05 \x01(\x01R\x05price\x12\"\n" +
+	"\n" +
+	"sale_price\x18\x06 \x01(\x01H\x01R\tsalePrice\x88\x01\x01\x12*\n" +
+	"\x0estock_quantity\x18\a \x01(\x01H\x02R\rstockQuantity\x88\x01\x01\x12\x1e\n" +
+	"\vis_in_stock\x18\b \x01(\bR\tisInStock\x12\x1d\n" +
+	"\n" +
+	"is_default\x18\t \x01(\bR\tisDefaultB\x0e\n" +
+	"\f_external_idB\r\n" +
+	"\v_sale_priceB\x11\n" +
+	"\x0f_stock_quantity\"\x99\x01\n" +
+	"\n" +
+	"ListingTax\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x15\n" +
+	"\x06tax_id\x18\x02 \x01(\tR\x05taxId\x12\x12\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\x12\x12\n" +
+	"\x04rate\x18\x04 \x01(\x01R\x04rate\x12\x19\n" +
+	"\btax_type\x18\x05 \x01(\tR\ataxType\x12!\n" +
+	"\fis_inclusive\x18\x06 \x01(\bR\visInclusive2\xed\x01\n" +
+	"\vCatalogSync\x12K\n" +
+	"\fPushProducts\x12\x1b.gol.v1.PushProductsRequest\x1a\x1c.gol.v1.PushProductsResponse(\x01\x12F\n" +
+	"\vUpdateStock\x12\x1a.gol.v1.UpdateStockRequest\x1a\x1b.gol.v1.UpdateStockResponse\x12I\n" +
+	"\x0fGetStoreCatalog\x12\x1e.gol.v1.GetStoreCatalogRequest\x1a\x14.gol.v1.StoreListing0\x01BPZNgithub.com/yourusername/supabase-redis-middleware/internal/grpcapi/golv1;golv1b\x06proto3"
+
+var (
+	file_gol_v1_catalog_sync_proto_rawDescOnce sync.Once
+	file_gol_v1_catalog_sync_proto_rawDescData []byte
+)
+
+func file_gol_v1_catalog_sync_proto_rawDescGZIP() []byte {
+	file_gol_v1_catalog_sync_proto_rawDescOnce.Do(func() {
+		file_gol_v1_catalog_sync_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_gol_v1_catalog_sync_proto_rawDesc), len(file_gol_v1_catalog_sync_proto_rawDesc)))
+	})
+	return file_gol_v1_catalog_sync_proto_rawDescData
+}
+
+var file_gol_v1_catalog_sync_proto_msgTypes = make([]protoimpl.MessageInfo, 22)
+var file_gol_v1_catalog_sync_proto_goTypes = []any{
+	(*PushProductsRequest)(nil),    // 0: gol.v1.PushProductsRequest
+	(*PushHeader)(nil),             // 1: gol.v1.PushHeader
+	(*PushBatch)(nil),              // 2: gol.v1.PushBatch
+	(*StoreDetails)(nil),           // 3: gol.v1.StoreDetails
+	(*Address)(nil),                // 4: gol.v1.Address
+	(*Location)(nil),               // 5: gol.v1.Location
+	(*Category)(nil),               // 6: gol.v1.Category
+	(*Tax)(nil),                    // 7: gol.v1.Tax
+	(*Product)(nil),                // 8: gol.v1.Product
+	(*Variation)(nil),              // 9: gol.v1.Variation
+	(*StoreProduct)(nil),           // 10: gol.v1.StoreProduct
+	(*PushProductsResponse)(nil),   // 11: gol.v1.PushProductsResponse
+	(*PushFailure)(nil),            // 12: gol.v1.PushFailure
+	(*ProductMatch)(nil),           // 13: gol.v1.ProductMatch
+	(*UpdateStockRequest)(nil),     // 14: gol.v1.UpdateStockRequest
+	(*StockProductUpdate)(nil),     // 15: gol.v1.StockProductUpdate
+	(*StockVariantUpdate)(nil),     // 16: gol.v1.StockVariantUpdate
+	(*UpdateStockResponse)(nil),    // 17: gol.v1.UpdateStockResponse
+	(*GetStoreCatalogRequest)(nil), // 18: gol.v1.GetStoreCatalogRequest
+	(*StoreListing)(nil),           // 19: gol.v1.StoreListing
+	(*ListingVariation)(nil),       // 20: gol.v1.ListingVariation
+	(*ListingTax)(nil),             // 21: gol.v1.ListingTax
+}
+var file_gol_v1_catalog_sync_proto_depIdxs = []int32{
+	1,  // 0: gol.v1.PushProductsRequest.header:type_name -> gol.v1.PushHeader
+	2,  // 1: gol.v1.PushProductsRequest.batch:type_name -> gol.v1.PushBatch
+	3,  // 2: gol.v1.PushHeader.store_details:type_name -> gol.v1.StoreDetails
+	6,  // 3: gol.v1.PushBatch.categories:type_name -> gol.v1.Category
+	7,  // 4: gol.v1.PushBatch.taxes:type_name -> gol.v1.Tax
+	8,  // 5: gol.v1.PushBatch.products:type_name -> gol.v1.Product
+	9,  // 6: gol.v1.PushBatch.variations:type_name -> gol.v1.Variation
+	10, // 7: gol.v1.PushBatch.store_products:type_name -> gol.v1.StoreProduct
+	4,  // 8: gol.v1.StoreDetails.address:type_name -> gol.v1.Address
+	5,  // 9: gol.v1.StoreDetails.location:type_name -> gol.v1.Location
+	12, // 10: gol.v1.PushProductsResponse.failures:type_name -> gol.v1.PushFailure
+	13, // 11: gol.v1.PushProductsResponse.matches:type_name -> gol.v1.ProductMatch
+	15, // 12: gol.v1.UpdateStockRequest.products:type_name -> gol.v1.StockProductUpdate
+	16, // 13: gol.v1.StockProductUpdate.variants:type_name -> gol.v1.StockVariantUpdate
+	20, // 14: gol.v1.StoreListing.variations:type_name -> gol.v1.ListingVariation
+	21, // 15: gol.v1.StoreListing.taxes:type_name -> gol.v1.ListingTax
+	0,  // 16: gol.v1.CatalogSync.PushProducts:input_type -> gol.v1.PushProductsRequest
+	14, // 17: gol.v1.CatalogSync.UpdateStock:input_type -> gol.v1.UpdateStockRequest
+	18, // 18: gol.v1.CatalogSync.GetStoreCatalog:input_type -> gol.v1.GetStoreCatalogRequest
+	11, // 19: gol.v1.CatalogSync.PushProducts:output_type -> gol.v1.PushProductsResponse
+	17, // 20: gol.v1.CatalogSync.UpdateStock:output_type -> gol.v1.UpdateStockResponse
+	19, // 21: gol.v1.CatalogSync.GetStoreCatalog:output_type -> gol.v1.StoreListing
+	19, // [19:22] is the sub-list for method output_type
+	16, // [16:19] is the sub-list for method input_type
+	16, // [16:16] is the sub-list for extension type_name
+	16, // [16:16] is the sub-list for extension extendee
+	0,  // [0:16] is the sub-list for field type_name
+}
+
+func init() { file_gol_v1_catalog_sync_proto_init() }
+func file_gol_v1_catalog_sync_proto_init() {
+	if File_gol_v1_catalog_sync_proto != nil {
+		return
+	}
+	file_gol_v1_catalog_sync_proto_msgTypes[0].OneofWrappers = []any{
+		(*PushProductsRequest_Header)(nil),
+		(*PushProductsRequest_Batch)(nil),
+	}
+	file_gol_v1_catalog_sync_proto_msgTypes[6].OneofWrappers = []any{}
+	file_gol_v1_catalog_sync_proto_msgTypes[19].OneofWrappers = []any{}
+	file_gol_v1_catalog_sync_proto_msgTypes[20].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_gol_v1_catalog_sync_proto_rawDesc), len(file_gol_v1_catalog_sync_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   22,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_gol_v1_catalog_sync_proto_goTypes,
+		DependencyIndexes: file_gol_v1_catalog_sync_proto_depIdxs,
+		MessageInfos:      file_gol_v1_catalog_sync_proto_msgTypes,
+	}.Build()
+	File_gol_v1_catalog_sync_proto = out.File
+	file_gol_v1_catalog_sync_proto_goTypes = nil
+	file_gol_v1_catalog_sync_proto_depIdxs = nil
+}
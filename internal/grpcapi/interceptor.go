@@ -0,0 +1,223 @@
+package grpcapi
+
+import (
+	"context"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yourusername/supabase-redis-middleware/internal/auth"
+	"github.com/yourusername/supabase-redis-middleware/internal/grpcapi/golv1"
+	"github.com/yourusername/supabase-redis-middleware/internal/logger"
+	"github.com/yourusername/supabase-redis-middleware/internal/repository"
+	"github.com/yourusername/supabase-redis-middleware/internal/tenant"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// requestIDKey is the metadata key carrying a call's request ID, both ways
+const requestIDKey = "x-request-id"
+
+// writeScopes maps each write method to the scope it requires, as the HTTP API maps the
+// routes it serves; methods missing from it are public reads
+var writeScopes = map[string]string{
+	golv1.CatalogSync_PushProducts_FullMethodName: repository.ScopePushProducts,
+	golv1.CatalogSync_UpdateStock_FullMethodName:  repository.ScopeWriteStock,
+}
+
+// writeRoles are the roles admitted to write methods, as to the HTTP product writes
+var writeRoles = []string{repository.RoleERP, repository.RolePlatformAdmin}
+
+// unauthenticatedMessages are the messages refusing callers whose credentials failed
+// authentication; any other error means the caller's key couldn't be looked up
+var unauthenticatedMessages = map[error]string{
+	auth.ErrInvalidKey:   "Invalid bearer token",
+	auth.ErrKeyRevoked:   "API key has been revoked",
+	auth.ErrKeyExpired:   "API key has expired",
+	auth.ErrInvalidToken: "Invalid or expired token",
+}
+
+// tenantErrors are the codes and messages refusing calls whose tenant can't be resolved
+var tenantErrors = map[error]struct {
+	code    codes.Code
+	message string
+}{
+	auth.ErrInvalidTenant:    {codes.InvalidArgument, "Tenant ID must be up to 64 lowercase letters, digits, hyphens and underscores"},
+	auth.ErrTenantRequired:   {codes.InvalidArgument, "A tenant ID is required"},
+	auth.ErrTenantNotAllowed: {codes.PermissionDenied, "These credentials belong to another tenant"},
+}
+
+// interceptor gives each call a request ID and logger, authorizes writes, scopes calls to
+// their tenant and logs the outcome
+type interceptor struct {
+	auth          *auth.Authenticator
+	allowedCIDRs  []netip.Prefix // Addresses writes are admitted from; empty for any
+	tenancy       bool
+	tenantKey     string // Metadata key naming the tenant; lowercase
+	defaultTenant string
+	logger        *zap.Logger
+}
+
+func (i *interceptor) unary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	ctx, err := i.begin(ctx, info.FullMethod)
+	var resp any
+	if err == nil {
+		resp, err = handler(ctx, req)
+	}
+	i.finish(ctx, info.FullMethod, start, err)
+	return resp, err
+}
+
+func (i *interceptor) stream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	ctx, err := i.begin(ss.Context(), info.FullMethod)
+	if err == nil {
+		err = handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+	}
+	i.finish(ctx, info.FullMethod, start, err)
+	return err
+}
+
+// begin attaches the call's request ID and logger to ctx, echoing the ID in the response
+// header, for write methods the authorized caller and audit actor, and with tenancy the
+// call's tenant
+func (i *interceptor) begin(ctx context.Context, method string) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	id := first(md, requestIDKey)
+	if id == "" || len(id) > 128 {
+		id = uuid.NewString()
+	}
+	_ = grpc.SetHeader(ctx, metadata.Pairs(requestIDKey, id))
+	ctx = repository.WithRequestID(ctx, id)
+	ctx = logger.WithContext(ctx, i.logger.With(zap.String("request_id", id)))
+
+	if scope, write := writeScopes[method]; write {
+		var err error
+		if ctx, err = i.authorize(ctx, method, first(md, "authorization"), scope); err != nil {
+			return ctx, err
+		}
+	}
+	if i.tenancy {
+		return i.scopeToTenant(ctx, method, first(md, i.tenantKey))
+	}
+	return ctx, nil
+}
+
+// scopeToTenant scopes ctx to the tenant the call acts for, as TenantMiddleware does for
+// the HTTP API. Reads carry no principal, so they act for the tenant they name
+func (i *interceptor) scopeToTenant(ctx context.Context, method, requested string) (context.Context, error) {
+	id, err := auth.ResolveTenant(auth.PrincipalFrom(ctx), requested, i.defaultTenant)
+	if err != nil {
+		logger.FromContext(ctx, i.logger).Warn("tenant not resolved", zap.String("method", method), zap.String("tenant", requested), zap.Error(err))
+		e := tenantErrors[err]
+		return ctx, status.Error(e.code, e.message)
+	}
+
+	// Writes acting for every tenant could link one tenant's rows to another's
+	if _, write := writeScopes[method]; write && id == "" {
+		logger.FromContext(ctx, i.logger).Warn("write without a tenant", zap.String("method", method))
+		return ctx, status.Error(codes.InvalidArgument, "Writes must name a tenant in "+i.tenantKey+" metadata")
+	}
+
+	ctx = tenant.WithID(ctx, id)
+	if id != "" {
+		ctx = logger.WithContext(ctx, logger.FromContext(ctx, i.logger).With(zap.String("tenant_id", id)))
+	}
+	return ctx, nil
+}
+
+// authorize admits callers with a write role and scope, as RequireRole and RequireScope do
+// for the HTTP API, and attaches them to ctx as the principal and audit actor
+func (i *interceptor) authorize(ctx context.Context, method, authorization, scope string) (context.Context, error) {
+	log := logger.FromContext(ctx, i.logger)
+
+	token, ok := strings.CutPrefix(authorization, "Bearer ")
+	switch {
+	case authorization == "":
+		return ctx, status.Error(codes.Unauthenticated, "Missing authorization metadata")
+	case !ok || token == "":
+		return ctx, status.Error(codes.Unauthenticated, "Invalid authorization format. Expected: Bearer <token>")
+	}
+
+	principal, err := i.auth.Authenticate(ctx, token)
+	if err != nil {
+		if message, ok := unauthenticatedMessages[err]; ok {
+			log.Warn("unauthenticated call", zap.String("method", method), zap.Error(err))
+			return ctx, status.Error(codes.Unauthenticated, message)
+		}
+		log.Error("failed to validate API key", zap.String("method", method), zap.Error(err))
+		return ctx, status.Error(codes.Unavailable, "Failed to validate credentials")
+	}
+
+	if !principal.HasRole(writeRoles...) {
+		log.Warn("role not admitted", zap.String("method", method), zap.String("principal", principal.ID), zap.String("role", principal.Role))
+		return ctx, status.Error(codes.PermissionDenied, "The "+principal.Role+" role may not use this method")
+	}
+	if !principal.HasScope(scope) {
+		log.Warn("caller lacks scope", zap.String("method", method), zap.String("principal", principal.ID), zap.String("scope", scope))
+		return ctx, status.Error(codes.PermissionDenied, "Caller lacks the "+scope+" scope")
+	}
+	if len(i.allowedCIDRs) > 0 || len(principal.AllowedCIDRs) > 0 {
+		addr, ok := peerAddr(ctx)
+		if !ok || (len(i.allowedCIDRs) > 0 && !auth.ContainsIP(i.allowedCIDRs, addr)) || !principal.AllowsIP(addr) {
+			log.Warn("caller IP not allowed", zap.String("method", method), zap.String("principal", principal.ID), zap.Stringer("client_ip", addr))
+			return ctx, status.Error(codes.PermissionDenied, "Calls from this IP address are not allowed")
+		}
+	}
+
+	// Bootstrap tokens are shared, so, as over HTTP, they are audited by their hash
+	actor := principal.ID
+	if principal.ID == "bootstrap" {
+		actor = auth.TokenActor(token)
+	}
+	ctx = auth.WithPrincipal(ctx, principal)
+	ctx = repository.WithAuditActor(ctx, repository.AuditActor{Actor: actor, Endpoint: "GRPC " + method})
+	return ctx, nil
+}
+
+// finish logs a completed call with its status code and duration
+func (i *interceptor) finish(ctx context.Context, method string, start time.Time, err error) {
+	logger.FromContext(ctx, i.logger).Info("grpc call completed",
+		zap.String("method", method),
+		zap.String("code", status.Code(err).String()),
+		zap.Duration("duration", time.Since(start)))
+}
+
+// peerAddr returns the IP address the call came from, as IPAllowlistMiddleware reads it
+// for HTTP, without its port; ok is false if the connection has none, such as an
+// in-process or Unix socket connection
+func peerAddr(ctx context.Context) (addr netip.Addr, ok bool) {
+	p, found := peer.FromContext(ctx)
+	if !found || p.Addr == nil {
+		return addr, false
+	}
+	addrPort, err := netip.ParseAddrPort(p.Addr.String())
+	if err != nil {
+		return addr, false
+	}
+	return addrPort.Addr(), true
+}
+
+// first returns the first value of key in md, or ""
+func first(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// contextStream is a server stream whose context carries what the interceptor attached
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
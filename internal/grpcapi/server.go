@@ -0,0 +1,272 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/yourusername/supabase-redis-middleware/internal/auth"
+	"github.com/yourusername/supabase-redis-middleware/internal/cache"
+	"github.com/yourusername/supabase-redis-middleware/internal/grpcapi/golv1"
+	"github.com/yourusername/supabase-redis-middleware/internal/handlers"
+	"github.com/yourusername/supabase-redis-middleware/internal/logger"
+	"github.com/yourusername/supabase-redis-middleware/internal/realtime"
+	"github.com/yourusername/supabase-redis-middleware/internal/repository"
+	"github.com/yourusername/supabase-redis-middleware/internal/webhooks"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// catalogPageSize is how many listings GetStoreCatalog reads from Postgres at a time
+const catalogPageSize = 200
+
+// Dependencies contains everything the gRPC API needs
+type Dependencies struct {
+	PgRepo *repository.PostgresRepository
+	Cache  cache.CacheService
+	Logger *zap.Logger
+	// Auth identifies callers by the bearer API key or Supabase JWT in their metadata
+	Auth *auth.Authenticator
+	// PushSigningSecrets maps ERP store IDs to the secrets their pushes and stock updates
+	// must be signed with. Signatures cover the HTTP body, so those stores' writes are
+	// refused here
+	PushSigningSecrets map[string]string
+	// PushAllowedCIDRs, if set, are the only addresses writes are admitted from, as for
+	// the HTTP pushes; an API key's own allowed CIDRs narrow them further
+	PushAllowedCIDRs []netip.Prefix
+	// Tenancy scopes every call to a tenant, as for the HTTP API: the caller's own, or
+	// the one named by the TenantHeader metadata key, falling back to DefaultTenant
+	Tenancy       bool
+	TenantHeader  string
+	DefaultTenant string
+	// MaxRecvMsgSize bounds each message received, in bytes; zero keeps gRPC's 4 MiB
+	MaxRecvMsgSize int
+	// Events publishes the listing changes of pushes and stock updates; nil publishes none
+	Events *realtime.Publisher
+	// Webhooks queues the webhook events of pushes and stock updates; nil queues none
+	Webhooks *webhooks.Dispatcher
+}
+
+// catalogSync implements golv1.CatalogSyncServer with the handlers serving the HTTP API,
+// so both APIs match, save and invalidate the same way
+type catalogSync struct {
+	golv1.UnimplementedCatalogSyncServer
+	pgRepo   *repository.PostgresRepository
+	products *handlers.ProductHandler
+	stock    *handlers.StockHandler
+	signed   map[string]bool // Lowercased ERP IDs of stores whose writes must be signed
+	logger   *zap.Logger
+}
+
+// NewServer creates a gRPC server serving the CatalogSync service
+// Every call is logged and carries a request ID; writes are authenticated and authorized
+// as the HTTP API authorizes their routes
+func NewServer(deps Dependencies) *grpc.Server {
+	signed := make(map[string]bool, len(deps.PushSigningSecrets))
+	for storeID := range deps.PushSigningSecrets {
+		signed[strings.ToLower(storeID)] = true
+	}
+
+	guard := &interceptor{
+		auth:          deps.Auth,
+		allowedCIDRs:  deps.PushAllowedCIDRs,
+		tenancy:       deps.Tenancy,
+		tenantKey:     strings.ToLower(deps.TenantHeader),
+		defaultTenant: deps.DefaultTenant,
+		logger:        deps.Logger,
+	}
+	opts := []grpc.ServerOption{
+		grpc.UnaryInterceptor(guard.unary),
+		grpc.StreamInterceptor(guard.stream),
+	}
+	if deps.MaxRecvMsgSize > 0 {
+		opts = append(opts, grpc.MaxRecvMsgSize(deps.MaxRecvMsgSize))
+	}
+
+	server := grpc.NewServer(opts...)
+	golv1.RegisterCatalogSyncServer(server, &catalogSync{
+		pgRepo:   deps.PgRepo,
+		products: handlers.NewProductHandler(deps.PgRepo, deps.Cache, deps.Events, deps.Webhooks, deps.Logger),
+		stock:    handlers.NewStockHandler(deps.PgRepo, deps.Cache, deps.Events, deps.Webhooks, deps.Logger),
+		signed:   signed,
+		logger:   deps.Logger,
+	})
+	return server
+}
+
+// PushProducts reads a push's header and batches, then applies it as POST /products/push does
+// A push that stopped after committing some chunks is answered with status "incomplete"
+// and the committed counts, so the ERP can resume from the first uncommitted product
+func (s *catalogSync) PushProducts(stream golv1.CatalogSync_PushProductsServer) error {
+	ctx := stream.Context()
+
+	first, err := stream.Recv()
+	if errors.Is(err, io.EOF) {
+		return status.Error(codes.InvalidArgument, "Push must start with a header")
+	}
+	if err != nil {
+		return err
+	}
+	header := first.GetHeader()
+	if header == nil {
+		return status.Error(codes.InvalidArgument, "Push must start with a header")
+	}
+
+	req := pushRequest(header)
+	for {
+		msg, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		batch := msg.GetBatch()
+		if batch == nil {
+			return status.Error(codes.InvalidArgument, "Only the first message of a push may be a header")
+		}
+		appendBatch(&req, batch)
+	}
+
+	if err := binding.Validator.ValidateStruct(req); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err := s.allowsWrite(ctx, req.StoreDetails.StoreID); err != nil {
+		return err
+	}
+
+	outcome, err := s.products.Push(ctx, req, header.GetPartial(), header.GetDryRun())
+	var pushErr *handlers.PushError
+	if errors.As(err, &pushErr) {
+		if pushErr.Result == nil {
+			return status.Error(statusCode(pushErr.StatusCode), pushErr.Message)
+		}
+		resp := pushResponse(pushErr.Result)
+		resp.Status = "incomplete"
+		return stream.SendAndClose(resp)
+	}
+
+	if outcome.Unchanged {
+		return stream.SendAndClose(&golv1.PushProductsResponse{
+			Status:      "unchanged",
+			StoreId:     outcome.StoreID,
+			Fingerprint: outcome.Fingerprint,
+		})
+	}
+	resp := pushResponse(outcome.Result)
+	resp.Status = "success"
+	resp.Fingerprint = outcome.Fingerprint
+	resp.DryRun = header.GetDryRun()
+	return stream.SendAndClose(resp)
+}
+
+// UpdateStock applies stock and price updates as POST /products/stock does
+func (s *catalogSync) UpdateStock(ctx context.Context, in *golv1.UpdateStockRequest) (*golv1.UpdateStockResponse, error) {
+	req := stockRequest(in)
+	if err := binding.Validator.ValidateStruct(req); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err := s.allowsWrite(ctx, req.StoreID); err != nil {
+		return nil, err
+	}
+
+	result, err := s.stock.Update(ctx, req, in.GetDryRun())
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Failed to update stock")
+	}
+
+	return &golv1.UpdateStockResponse{
+		DryRun:             in.GetDryRun(),
+		ProductsUpdated:    int32(result.Updated),
+		ProductsNotFound:   int32(result.NotFound),
+		VariantsUpdated:    int32(result.VariantsUpdated),
+		VariantsNotFound:   int32(result.VariantsNotFound),
+		NotFoundProductIds: result.NotFoundIDs,
+		NotFoundVariantIds: result.VariantsNotFoundIDs,
+	}, nil
+}
+
+// GetStoreCatalog streams the visible listings of the store with an ERP ID, a page at a time
+func (s *catalogSync) GetStoreCatalog(in *golv1.GetStoreCatalogRequest, stream golv1.CatalogSync_GetStoreCatalogServer) error {
+	ctx := stream.Context()
+	if in.GetStoreId() == "" {
+		return status.Error(codes.InvalidArgument, "store_id is required")
+	}
+
+	storeID, err := s.pgRepo.StoreIDByExternalID(ctx, in.GetStoreId())
+	if err != nil {
+		return repositoryStatus(err, "Failed to look up store")
+	}
+
+	filter := repository.ListingFilter{CategoryID: in.GetCategoryId(), InStockOnly: in.GetInStockOnly()}
+	pagination := repository.Pagination{Limit: catalogPageSize, Keyset: true}
+	for {
+		page, err := s.pgRepo.ListStoreProducts(ctx, storeID, filter, pagination)
+		if err != nil {
+			logger.FromContext(ctx, s.logger).Error("Failed to list store catalog", zap.String("store_id", storeID), zap.Error(err))
+			return repositoryStatus(err, "Failed to list store catalog")
+		}
+		for _, l := range page {
+			if err := stream.Send(storeListing(l)); err != nil {
+				return err
+			}
+		}
+		if len(page) < catalogPageSize {
+			return nil
+		}
+		cursor := page[len(page)-1].Cursor()
+		pagination.After = &cursor
+	}
+}
+
+// allowsWrite refuses writes to a store other than the one the caller is bound to, and
+// to stores whose writes must be signed
+func (s *catalogSync) allowsWrite(ctx context.Context, externalStoreID string) error {
+	if principal := auth.PrincipalFrom(ctx); principal != nil && !principal.AllowsExternalStore(externalStoreID) {
+		return status.Error(codes.PermissionDenied, "Caller is bound to another store")
+	}
+	if s.signed[strings.ToLower(externalStoreID)] {
+		return status.Error(codes.FailedPrecondition, "Store's writes must be signed; use the HTTP API")
+	}
+	return nil
+}
+
+// repositoryStatus converts a repository error to a gRPC status, with message unless the
+// record wasn't found
+func repositoryStatus(err error, message string) error {
+	code := statusCode(repository.GetStatusCode(err))
+	if code == codes.NotFound {
+		return status.Error(code, "Store not found")
+	}
+	return status.Error(code, message)
+}
+
+// statusCode maps the HTTP status the HTTP API would answer with to a gRPC code
+func statusCode(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.Aborted
+	case http.StatusUnprocessableEntity:
+		return codes.FailedPrecondition
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	case http.StatusGatewayTimeout:
+		return codes.DeadlineExceeded
+	default:
+		return codes.Internal
+	}
+}
@@ -0,0 +1,183 @@
+package grpcapi
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/yourusername/supabase-redis-middleware/internal/auth"
+	"github.com/yourusername/supabase-redis-middleware/internal/cache"
+	"github.com/yourusername/supabase-redis-middleware/internal/grpcapi/golv1"
+	"github.com/yourusername/supabase-redis-middleware/internal/repository"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// nopCache caches nothing; methods the authenticator doesn't use are left unimplemented
+type nopCache struct {
+	cache.CacheService
+}
+
+func (nopCache) Get(ctx context.Context, key string) ([]byte, error) { return nil, nil }
+
+func (nopCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return nil
+}
+
+func (nopCache) GenerateKey(domain string, params map[string]string) string { return domain }
+
+// keyStore serves one API key
+type keyStore struct {
+	key *repository.APIKey
+}
+
+func (s keyStore) FindAPIKeyByHash(ctx context.Context, keyHash string) (*repository.APIKey, error) {
+	if keyHash != s.key.KeyHash {
+		return nil, repository.NewNotFoundError("api_keys", keyHash)
+	}
+	return s.key, nil
+}
+
+func (keyStore) TouchAPIKey(ctx context.Context, id string) error { return nil }
+
+const (
+	testBootstrap = "bootstrap-token"
+	testConsumer  = repository.APIKeyPrefix + "consumer"
+)
+
+// dial serves the API over an in-memory listener and returns a client of it
+// No Postgres repository is given, so calls must be refused before reaching it
+func dial(t *testing.T) golv1.CatalogSyncClient {
+	t.Helper()
+	return dialWith(t, nil)
+}
+
+// dialWith is dial with the server's dependencies changed by configure
+func dialWith(t *testing.T, configure func(*Dependencies)) golv1.CatalogSyncClient {
+	t.Helper()
+	store := keyStore{key: &repository.APIKey{
+		ID:      "consumer-key",
+		KeyHash: repository.HashAPIKey(testConsumer),
+		Role:    repository.RoleConsumer,
+	}}
+	authenticator := auth.NewAuthenticator(store, nopCache{}, time.Minute, []string{testBootstrap}, zap.NewNop())
+
+	listener := bufconn.Listen(1 << 20)
+	deps := Dependencies{
+		Logger:             zap.NewNop(),
+		Auth:               authenticator,
+		PushSigningSecrets: map[string]string{"ERP-SIGNED": "secret"},
+	}
+	if configure != nil {
+		configure(&deps)
+	}
+	server := NewServer(deps)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return golv1.NewCatalogSyncClient(conn)
+}
+
+func withToken(token string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+}
+
+func TestUpdateStockAuthorization(t *testing.T) {
+	client := dial(t)
+	req := &golv1.UpdateStockRequest{
+		StoreId:  "erp-signed",
+		Products: []*golv1.StockProductUpdate{{Id: "p1", StockQuantity: 3}},
+	}
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		code    codes.Code
+		message string
+	}{
+		{"no credentials", context.Background(), codes.Unauthenticated, "Missing authorization metadata"},
+		{"unknown token", withToken("nope"), codes.Unauthenticated, "Invalid bearer token"},
+		{"role not admitted", withToken(testConsumer), codes.PermissionDenied, "The consumer role may not use this method"},
+		{"signed store", withToken(testBootstrap), codes.FailedPrecondition, "Store's writes must be signed; use the HTTP API"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.UpdateStock(tt.ctx, req)
+			st := status.Convert(err)
+			if st.Code() != tt.code || st.Message() != tt.message {
+				t.Errorf("UpdateStock() = %v %q, want %v %q", st.Code(), st.Message(), tt.code, tt.message)
+			}
+		})
+	}
+}
+
+func TestUpdateStockValidation(t *testing.T) {
+	client := dial(t)
+	_, err := client.UpdateStock(withToken(testBootstrap), &golv1.UpdateStockRequest{StoreId: "erp-1"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("UpdateStock() without products = %v, want InvalidArgument", err)
+	}
+}
+
+func TestPushProductsNeedsHeader(t *testing.T) {
+	client := dial(t)
+	stream, err := client.PushProducts(withToken(testBootstrap))
+	if err != nil {
+		t.Fatal(err)
+	}
+	batch := &golv1.PushProductsRequest{Payload: &golv1.PushProductsRequest_Batch{Batch: &golv1.PushBatch{}}}
+	if err := stream.Send(batch); err != nil {
+		t.Fatal(err)
+	}
+	_, err = stream.CloseAndRecv()
+	if st := status.Convert(err); st.Code() != codes.InvalidArgument || st.Message() != "Push must start with a header" {
+		t.Errorf("CloseAndRecv() = %v, want InvalidArgument for a missing header", err)
+	}
+}
+
+func TestRequestIDEchoed(t *testing.T) {
+	client := dial(t)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-request-id", "req-42")
+	var header metadata.MD
+	_, _ = client.UpdateStock(ctx, &golv1.UpdateStockRequest{}, grpc.Header(&header))
+	if got := header.Get("x-request-id"); len(got) != 1 || got[0] != "req-42" {
+		t.Errorf("x-request-id header = %v, want [req-42]", got)
+	}
+}
+
+func TestWritesNeedTenant(t *testing.T) {
+	client := dialWith(t, func(deps *Dependencies) {
+		deps.Tenancy = true
+		deps.TenantHeader = "X-Tenant-ID"
+		deps.DefaultTenant = "default"
+	})
+	req := &golv1.UpdateStockRequest{
+		StoreId:  "erp-signed",
+		Products: []*golv1.StockProductUpdate{{Id: "p1", StockQuantity: 3}},
+	}
+
+	// The bootstrap token is a platform credential, acting for every tenant unless it names one
+	_, err := client.UpdateStock(withToken(testBootstrap), req)
+	if st := status.Convert(err); st.Code() != codes.InvalidArgument || st.Message() != "Writes must name a tenant in x-tenant-id metadata" {
+		t.Errorf("UpdateStock() without a tenant = %v, want InvalidArgument", err)
+	}
+
+	ctx := metadata.AppendToOutgoingContext(withToken(testBootstrap), "x-tenant-id", "acme")
+	_, err = client.UpdateStock(ctx, req)
+	if code := status.Code(err); code != codes.FailedPrecondition {
+		t.Errorf("UpdateStock() for a tenant = %v, want it past tenancy to the signature check", err)
+	}
+}
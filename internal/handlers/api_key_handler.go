@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/yourusername/supabase-redis-middleware/internal/auth"
+	"github.com/yourusername/supabase-redis-middleware/internal/repository"
+	"go.uber.org/zap"
+)
+
+type APIKeyHandler struct {
+	pgRepo        *repository.PostgresRepository
+	authenticator *auth.Authenticator
+	logger        *zap.Logger
+}
+
+func NewAPIKeyHandler(pgRepo *repository.PostgresRepository, authenticator *auth.Authenticator, logger *zap.Logger) *APIKeyHandler {
+	return &APIKeyHandler{
+		pgRepo:        pgRepo,
+		authenticator: authenticator,
+		logger:        logger,
+	}
+}
+
+// CreateAPIKeyRequest is the body of POST /admin/api-keys
+// A key with a store_id may only write that store's data, and one with allowed_cidrs may
+// only push products and stock from those addresses. Its scopes must be among its role's;
+// store admins must have a store_id and platform admins can't
+type CreateAPIKeyRequest struct {
+	Name         string     `json:"name" binding:"required,max=100"`
+	Role         string     `json:"role" binding:"required,oneof=erp store_admin platform_admin"`
+	Scopes       []string   `json:"scopes" binding:"required,min=1,dive,oneof=read:catalog push:products write:stock write:stores admin"`
+	StoreID      *string    `json:"store_id" binding:"omitempty,uuid"`
+	AllowedCIDRs []string   `json:"allowed_cidrs" binding:"max=50"`
+	ExpiresAt    *time.Time `json:"expires_at"`
+}
+
+// IssuedAPIKey is an issued key with the key itself, returned only when it is issued
+type IssuedAPIKey struct {
+	*repository.APIKey
+	Key string `json:"key"`
+}
+
+// CreateAPIKey issues an API key; the key is in the response and can't be retrieved later
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	var req CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidRequest(c, err)
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		invalidInput(c, "name must not be blank")
+		return
+	}
+	for _, scope := range req.Scopes {
+		if !slices.Contains(repository.RoleScopes[req.Role], scope) {
+			invalidInput(c, "the "+req.Role+" role can't have the "+scope+" scope")
+			return
+		}
+	}
+	if req.Role == repository.RoleStoreAdmin && req.StoreID == nil {
+		invalidInput(c, "store_admin keys must be bound to a store_id")
+		return
+	}
+	if req.Role == repository.RolePlatformAdmin && req.StoreID != nil {
+		invalidInput(c, "platform_admin keys can't be bound to a store")
+		return
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		invalidInput(c, "expires_at must be in the future")
+		return
+	}
+	prefixes, err := auth.ParseCIDRs(req.AllowedCIDRs)
+	if err != nil {
+		invalidInput(c, "allowed_cidrs: "+err.Error())
+		return
+	}
+	allowed := make([]string, len(prefixes))
+	for i, prefix := range prefixes {
+		allowed[i] = prefix.String()
+	}
+
+	slices.Sort(req.Scopes)
+	slices.Sort(allowed)
+	key, secret, err := h.pgRepo.CreateAPIKey(c.Request.Context(), repository.APIKeyInput{
+		Name:         name,
+		Role:         req.Role,
+		Scopes:       slices.Compact(req.Scopes),
+		StoreID:      req.StoreID,
+		AllowedCIDRs: slices.Compact(allowed),
+		ExpiresAt:    req.ExpiresAt,
+	})
+	if err != nil {
+		requestLogger(c, h.logger).Error("Failed to create API key", zap.String("name", name), zap.Error(err))
+		writeStoreError(c, err, "STORE_NOT_FOUND", "Failed to create API key")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"data":    IssuedAPIKey{APIKey: key, Key: secret},
+		"message": "API key created; store the key now, it won't be shown again",
+	})
+}
+
+// ListAPIKeys lists issued API keys, newest first
+// GET /api/v1/admin/api-keys?include_revoked=true&limit=20&offset=0
+func (h *APIKeyHandler) ListAPIKeys(c *gin.Context) {
+	includeRevoked, ok := optionalBool(c, "include_revoked")
+	if !ok {
+		return
+	}
+
+	list, ok := listQuery(c)
+	if !ok {
+		return
+	}
+
+	keys, err := h.pgRepo.ListAPIKeys(c.Request.Context(), includeRevoked, list.Pagination)
+	if err != nil {
+		writeStoreError(c, err, "API_KEY_NOT_FOUND", "Failed to list API keys")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   keys,
+	})
+}
+
+// RevokeAPIKey revokes an API key; requests made with it are refused from then on
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	keyID := c.Param("id")
+	if _, err := uuid.Parse(keyID); err != nil {
+		invalidUUID(c, "id")
+		return
+	}
+
+	key, err := h.pgRepo.RevokeAPIKey(c.Request.Context(), keyID)
+	if err != nil {
+		requestLogger(c, h.logger).Error("Failed to revoke API key", zap.String("key_id", keyID), zap.Error(err))
+		writeStoreError(c, err, "API_KEY_NOT_FOUND", "Failed to revoke API key")
+		return
+	}
+
+	h.authenticator.Invalidate(c.Request.Context(), key)
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"data":    key,
+		"message": "API key revoked successfully",
+	})
+}
+
+// allowsExternalStore reports whether the request's caller may write the store with ERP
+// ID externalID, writing a 403 if they may not. Callers bound to a store are checked here
+// for routes naming the store in their body or query rather than their path
+func allowsExternalStore(c *gin.Context, externalID string) bool {
+	if principal := auth.PrincipalFrom(c.Request.Context()); principal == nil || principal.AllowsExternalStore(externalID) {
+		return true
+	}
+	writeError(c, http.StatusForbidden, "FORBIDDEN", "Caller is bound to another store", nil)
+	return false
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/supabase-redis-middleware/internal/repository"
+	"go.uber.org/zap"
+)
+
+type AuditHandler struct {
+	pgRepo *repository.PostgresRepository
+	logger *zap.Logger
+}
+
+func NewAuditHandler(pgRepo *repository.PostgresRepository, logger *zap.Logger) *AuditHandler {
+	return &AuditHandler{
+		pgRepo: pgRepo,
+		logger: logger,
+	}
+}
+
+// ListAuditEntries returns recorded pushes, stock updates, store and brand changes, newest first
+// GET /api/v1/admin/audit?action=store_status&actor=token:ab12cd34ef56&entity_id=STORE001&since=2026-01-01T00:00:00Z
+func (h *AuditHandler) ListAuditEntries(c *gin.Context) {
+	filter := repository.AuditFilter{
+		Action:   c.Query("action"),
+		Actor:    c.Query("actor"),
+		EntityID: c.Query("entity_id"),
+	}
+
+	switch filter.Action {
+	case "", repository.AuditProductPush, repository.AuditProductUpdate, repository.AuditStockUpdate,
+		repository.AuditStoreUpdate, repository.AuditStoreStatus, repository.AuditStorePurge,
+		repository.AuditStoreHours, repository.AuditDeliveryZone, repository.AuditStoreTax,
+		repository.AuditBrandRename, repository.AuditBrandMerge:
+	default:
+		invalidInput(c, "action must be one of product_push, product_update, stock_update, store_update, store_status, store_purge, store_hours, delivery_zone, store_tax, brand_rename, brand_merge")
+		return
+	}
+
+	var ok bool
+	if filter.Since, ok = optionalTime(c, "since"); !ok {
+		return
+	}
+	if filter.Until, ok = optionalTime(c, "until"); !ok {
+		return
+	}
+
+	list, ok := listQuery(c)
+	if !ok {
+		return
+	}
+
+	entries, err := h.pgRepo.ListAuditEntries(c.Request.Context(), filter, list.Pagination)
+	if err != nil {
+		requestLogger(c, h.logger).Error("Failed to list audit entries", zap.Error(err))
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to list audit entries", nil)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   entries,
+	})
+}
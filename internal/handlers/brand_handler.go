@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/yourusername/supabase-redis-middleware/internal/cache"
+	"github.com/yourusername/supabase-redis-middleware/internal/repository"
+	"go.uber.org/zap"
+)
+
+type BrandHandler struct {
+	pgRepo *repository.PostgresRepository
+	cache  cache.CacheService
+	logger *zap.Logger
+}
+
+func NewBrandHandler(pgRepo *repository.PostgresRepository, cacheService cache.CacheService, logger *zap.Logger) *BrandHandler {
+	return &BrandHandler{
+		pgRepo: pgRepo,
+		cache:  cacheService,
+		logger: logger,
+	}
+}
+
+// RenameBrandRequest is the body of PUT /admin/brands/:id
+type RenameBrandRequest struct {
+	Name string `json:"name" binding:"required,max=255"`
+}
+
+// MergeBrandsRequest is the body of POST /admin/brands/merge
+type MergeBrandsRequest struct {
+	CanonicalID  string   `json:"canonical_id" binding:"required,uuid"`
+	DuplicateIDs []string `json:"duplicate_ids" binding:"required,min=1,max=100,dive,uuid"`
+}
+
+// ListBrands lists brands by name, with the number of products using each
+// GET /api/v1/admin/brands?search=nestle&include_merged=true&limit=20&offset=0
+func (h *BrandHandler) ListBrands(c *gin.Context) {
+	filter := repository.BrandFilter{Search: strings.TrimSpace(c.Query("search"))}
+
+	var ok bool
+	if filter.IncludeMerged, ok = optionalBool(c, "include_merged"); !ok {
+		return
+	}
+
+	list, ok := listQuery(c)
+	if !ok {
+		return
+	}
+
+	brands, err := h.pgRepo.ListBrands(c.Request.Context(), filter, list.Pagination)
+	if err != nil {
+		writeBrandError(c, err, "Failed to list brands")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   brands,
+	})
+}
+
+// RenameBrand changes a brand's name and slug
+func (h *BrandHandler) RenameBrand(c *gin.Context) {
+	brandID := c.Param("id")
+	if _, err := uuid.Parse(brandID); err != nil {
+		invalidUUID(c, "id")
+		return
+	}
+
+	var req RenameBrandRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidRequest(c, err)
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		invalidInput(c, "name must not be blank")
+		return
+	}
+
+	brand, err := h.pgRepo.RenameBrand(c.Request.Context(), brandID, name)
+	if err != nil {
+		requestLogger(c, h.logger).Error("Failed to rename brand", zap.String("brand_id", brandID), zap.Error(err))
+		writeBrandError(c, err, "Failed to rename brand")
+		return
+	}
+
+	cache.InvalidateDomains(c.Request.Context(), h.cache, h.logger, brandDomains()...)
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"data":    brand,
+		"message": "Brand renamed successfully",
+	})
+}
+
+// MergeBrands moves the products of duplicate brands to a canonical brand
+// and deactivates the duplicates, all in one transaction
+func (h *BrandHandler) MergeBrands(c *gin.Context) {
+	var req MergeBrandsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidRequest(c, err)
+		return
+	}
+
+	seen := map[string]bool{}
+	for _, id := range req.DuplicateIDs {
+		if id == req.CanonicalID {
+			invalidInput(c, "duplicate_ids must not include canonical_id")
+			return
+		}
+		if seen[id] {
+			invalidInput(c, "duplicate_ids must not repeat a brand")
+			return
+		}
+		seen[id] = true
+	}
+
+	result, err := h.pgRepo.MergeBrands(c.Request.Context(), req.CanonicalID, req.DuplicateIDs)
+	if err != nil {
+		requestLogger(c, h.logger).Error("Failed to merge brands", zap.String("canonical_id", req.CanonicalID), zap.Error(err))
+		writeBrandError(c, err, "Failed to merge brands")
+		return
+	}
+
+	cache.InvalidateDomains(c.Request.Context(), h.cache, h.logger, brandDomains()...)
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"data":    result,
+		"message": "Brands merged successfully",
+	})
+}
+
+// brandDomains returns the cache domains showing brand names: every listing and search result
+func brandDomains() []string {
+	return []string{cache.DomainProducts, cache.DomainStores, cache.DomainSupermarket, cache.DomainPharmacy, cache.DomainSearch, cache.DomainLookup}
+}
+
+// writeBrandError writes a brand repository error, keeping its not-found or conflict
+// status and message; anything else is a 500 with the given message
+func writeBrandError(c *gin.Context, err error, message string) {
+	status, code := http.StatusInternalServerError, "INTERNAL_ERROR"
+	var repoErr *repository.RepositoryError
+	if errors.As(err, &repoErr) {
+		switch repoErr.StatusCode {
+		case http.StatusNotFound:
+			status, code, message = http.StatusNotFound, "BRAND_NOT_FOUND", repoErr.Message
+		case http.StatusConflict:
+			status, code, message = http.StatusConflict, "BRAND_CONFLICT", repoErr.Message
+		}
+	}
+
+	writeError(c, status, code, message, nil)
+}
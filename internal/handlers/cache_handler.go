@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/supabase-redis-middleware/internal/cache"
+	"github.com/yourusername/supabase-redis-middleware/internal/i18n"
+	"go.uber.org/zap"
+)
+
+type CacheHandler struct {
+	cache  cache.CacheService
+	logger *zap.Logger
+}
+
+func NewCacheHandler(cacheService cache.CacheService, logger *zap.Logger) *CacheHandler {
+	return &CacheHandler{
+		cache:  cacheService,
+		logger: logger,
+	}
+}
+
+// InvalidatePattern deletes all cache keys matching a glob pattern
+// DELETE /api/v1/admin/cache?pattern=supermarket:*
+func (h *CacheHandler) InvalidatePattern(c *gin.Context) {
+	pattern := c.Query("pattern")
+	if pattern == "" || pattern == "*" {
+		writeError(c, http.StatusBadRequest, "INVALID_INPUT", "A pattern narrower than * is required (e.g. supermarket:*)", nil)
+		return
+	}
+
+	deleted, err := h.cache.DeleteByPattern(c.Request.Context(), pattern)
+	if err != nil {
+		requestLogger(c, h.logger).Error("Failed to invalidate cache pattern", zap.String("pattern", pattern), zap.Error(err))
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":       "CACHE_UNAVAILABLE",
+				"message":    i18n.T(c.Request.Context(), "CACHE_UNAVAILABLE", "Failed to invalidate cache keys", nil),
+				"request_id": requestID(c),
+			},
+			"data": gin.H{
+				"deleted": deleted,
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"pattern": pattern,
+			"deleted": deleted,
+		},
+		"message": "Cache invalidated successfully",
+	})
+}
+
+// ListKeys returns cached keys for a domain with TTL remaining and value size
+// GET /api/v1/admin/cache/keys?domain=supermarket&limit=100
+func (h *CacheHandler) ListKeys(c *gin.Context) {
+	domain := c.Query("domain")
+	if domain == "" {
+		writeError(c, http.StatusBadRequest, "INVALID_INPUT", "domain query parameter is required", nil)
+		return
+	}
+
+	list, ok := listQuery(c)
+	if !ok {
+		return
+	}
+
+	keys, err := h.cache.InspectKeys(c.Request.Context(), cache.DomainPattern(domain), list.Pagination.Limit)
+	if err != nil {
+		requestLogger(c, h.logger).Error("Failed to inspect cache keys", zap.String("domain", domain), zap.Error(err))
+		writeError(c, http.StatusServiceUnavailable, "CACHE_UNAVAILABLE", "Failed to inspect cache keys", nil)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"domain": domain,
+			"count":  len(keys),
+			"keys":   keys,
+		},
+	})
+}
+
+// GetStats returns cache hit/miss statistics per domain
+// GET /api/v1/admin/cache/stats
+func (h *CacheHandler) GetStats(c *gin.Context) {
+	domains := h.cache.Stats()
+
+	var total cache.DomainStats
+	for _, stats := range domains {
+		total.Hits += stats.Hits
+		total.Misses += stats.Misses
+		total.Errors += stats.Errors
+		total.BytesRead += stats.BytesRead
+		total.BytesWritten += stats.BytesWritten
+		total.Oversized += stats.Oversized
+	}
+	if lookups := total.Hits + total.Misses; lookups > 0 {
+		total.HitRatio = float64(total.Hits) / float64(lookups)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"domains": domains,
+			"total":   total,
+		},
+	})
+}
@@ -0,0 +1,15 @@
+package handlers
+
+import "github.com/yourusername/supabase-redis-middleware/internal/cache"
+
+// storeDomains returns the cache domains for a store under each of its identifiers
+// Stores are addressed by internal UUID on read paths and by ERP external ID on writes
+func storeDomains(storeIDs ...string) []string {
+	domains := make([]string, 0, len(storeIDs))
+	for _, id := range storeIDs {
+		if id != "" {
+			domains = append(domains, cache.StoreDomain(id))
+		}
+	}
+	return domains
+}
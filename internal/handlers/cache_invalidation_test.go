@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/yourusername/supabase-redis-middleware/internal/cache"
+	"github.com/yourusername/supabase-redis-middleware/internal/repository"
+	"go.uber.org/zap"
+)
+
+func TestStoreDomains(t *testing.T) {
+	tests := []struct {
+		name     string
+		storeIDs []string
+		want     []string
+	}{
+		{name: "UUID and external ID", storeIDs: []string{"uuid-1", "ERP-1"}, want: []string{"store:uuid-1", "store:ERP-1"}},
+		{name: "empty IDs skipped", storeIDs: []string{"", "ERP-1", ""}, want: []string{"store:ERP-1"}},
+		{name: "none", want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := storeDomains(tt.storeIDs...); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("storeDomains() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteInvalidation(t *testing.T) {
+	// cached holds an entry per domain a write may or may not clear
+	cached := []string{
+		"apikey:k",
+		"categories", "categories:q",
+		"lookup:q",
+		"nearby:q",
+		"pharmacy:q",
+		"products", "products:q",
+		"push:uuid-1",
+		"search:q",
+		"store:ERP-1:q",
+		"store:uuid-1", "store:uuid-1:q",
+		"store:uuid-10:q",
+		"supermarket:q",
+	}
+
+	tests := []struct {
+		name    string
+		domains []string
+		want    []string
+	}{
+		{
+			name: "product push",
+			domains: pushedDomains(&repository.UpsertResult{StoreID: "uuid-1"},
+				PushProductsRequest{StoreDetails: StoreDetails{StoreID: "ERP-1"}}),
+			want: []string{"apikey:k", "push:uuid-1", "store:uuid-10:q"},
+		},
+		{
+			name: "stock update",
+			domains: stockDomains(&repository.StockUpdateResult{StoreID: "uuid-1"},
+				UpdateStockRequest{StoreID: "ERP-1"}),
+			want: []string{"apikey:k", "categories", "categories:q", "nearby:q", "products", "products:q", "push:uuid-1", "store:uuid-10:q"},
+		},
+		{
+			name: "stock update of an unknown store",
+			domains: stockDomains(&repository.StockUpdateResult{},
+				UpdateStockRequest{StoreID: "ERP-1"}),
+			want: []string{"apikey:k", "categories", "categories:q", "nearby:q", "products", "products:q", "push:uuid-1",
+				"store:uuid-1", "store:uuid-10:q", "store:uuid-1:q"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			c := newMapCache()
+			for _, key := range cached {
+				_ = c.Set(ctx, key, []byte("cached"), time.Minute)
+			}
+
+			cache.InvalidateDomains(ctx, c, zap.NewNop(), tt.domains...)
+
+			if got := c.keys(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("keys after invalidating %v = %v, want %v", tt.domains, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/yourusername/supabase-redis-middleware/internal/i18n"
+	"github.com/yourusername/supabase-redis-middleware/internal/repository"
+	"go.uber.org/zap"
+)
+
+// catalogExportFlushRows is how many rows an export writes between flushes
+const catalogExportFlushRows = 200
+
+// catalogExportColumns are the columns of a CSV export: the fields a CSV import reads,
+// so an export can be imported as it is, then what only an export reports
+var catalogExportColumns = append(slices.Clone(csvFields),
+	"sale_price", "tax_rate", "category_name", "product_id", "store_product_id", "updated_at")
+
+// ExportStoreProducts streams a store's whole catalog - every available listing, with its
+// prices, stock and taxes - as a CSV attachment or as NDJSON, one listing per row or line,
+// flushing as it goes. A CSV export can be imported again as it is.
+// A failure after rows were sent can't change the status: the response ends early, with a
+// final error line for NDJSON
+// Query: format (csv or ndjson; default csv)
+func (h *StoreHandler) ExportStoreProducts(c *gin.Context) {
+	storeID := c.Param("id")
+	if _, err := uuid.Parse(storeID); err != nil {
+		invalidUUID(c, "id")
+		return
+	}
+
+	format := c.DefaultQuery("format", "csv")
+	if format != "csv" && format != "ndjson" {
+		invalidInput(c, "format must be csv or ndjson")
+		return
+	}
+
+	log := requestLogger(c, h.logger).With(zap.String("store_id", storeID), zap.String("format", format))
+	export := &catalogExport{c: c, storeID: storeID, format: format}
+	err := h.pgRepo.ExportStoreCatalog(c.Request.Context(), storeID, export.write)
+	if err == nil {
+		err = export.finish()
+	}
+	if err != nil {
+		if !export.started {
+			log.Error("Failed to export store catalog", zap.Error(err))
+			writeStoreError(c, err, "STORE_NOT_FOUND", "Failed to export store catalog")
+			return
+		}
+		log.Error("Store catalog export ended early", zap.Int("rows", export.rows), zap.Error(err))
+		export.fail()
+		return
+	}
+	log.Info("Exported store catalog", zap.Int("rows", export.rows))
+}
+
+// catalogExport writes the rows of an export as they are read. Its headers are sent with
+// the first row, so a failure before then can still be reported as an error response
+type catalogExport struct {
+	c       *gin.Context
+	storeID string
+	format  string
+	csv     *csv.Writer
+	json    *json.Encoder
+	started bool
+	rows    int
+}
+
+// start sends the response headers, and the header row of a CSV export
+func (e *catalogExport) start() error {
+	e.started = true
+	if e.format == "ndjson" {
+		e.c.Header("Content-Type", NDJSONContentType)
+		e.c.Status(http.StatusOK)
+		e.json = json.NewEncoder(e.c.Writer)
+		return nil
+	}
+
+	e.c.Header("Content-Type", "text/csv; charset=utf-8")
+	e.c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="catalog-%s.csv"`, e.storeID))
+	e.c.Status(http.StatusOK)
+	e.csv = csv.NewWriter(e.c.Writer)
+	return e.csv.Write(catalogExportColumns)
+}
+
+// write writes one row, flushing every catalogExportFlushRows rows
+func (e *catalogExport) write(row repository.CatalogExportRow) error {
+	if !e.started {
+		if err := e.start(); err != nil {
+			return err
+		}
+	}
+
+	var err error
+	if e.json != nil {
+		err = e.json.Encode(row)
+	} else {
+		err = e.csv.Write(catalogExportRecord(row))
+	}
+	if err != nil {
+		return err
+	}
+
+	e.rows++
+	if e.rows%catalogExportFlushRows == 0 {
+		return e.flush()
+	}
+	return nil
+}
+
+// finish flushes what's left, sending the headers first if there were no rows
+func (e *catalogExport) finish() error {
+	if !e.started {
+		if err := e.start(); err != nil {
+			return err
+		}
+	}
+	return e.flush()
+}
+
+// flush sends the rows written so far to the client
+func (e *catalogExport) flush() error {
+	if e.csv != nil {
+		e.csv.Flush()
+		if err := e.csv.Error(); err != nil {
+			return err
+		}
+	}
+	e.c.Writer.Flush()
+	return nil
+}
+
+// fail ends an export that failed after it started; an NDJSON export ends with an error
+// line, so a client can tell it from a complete one
+func (e *catalogExport) fail() {
+	if e.json != nil {
+		e.json.Encode(gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":       "EXPORT_FAILED",
+				"message":    i18n.T(e.c.Request.Context(), "EXPORT_FAILED", fmt.Sprintf("Export failed after %d rows", e.rows), i18n.Params{"rows": e.rows}),
+				"request_id": requestID(e.c),
+			},
+		})
+	}
+	e.flush()
+}
+
+// catalogExportRecord formats a row as the cells of catalogExportColumns
+func catalogExportRecord(row repository.CatalogExportRow) []string {
+	return []string{
+		stringOrEmpty(row.ExternalID),
+		row.SKU,
+		row.Name,
+		row.Slug,
+		stringOrEmpty(row.Description),
+		stringOrEmpty(row.CategoryID),
+		formatFloat(row.Price),
+		stringOrEmpty(row.Currency),
+		stringOrEmpty(row.Unit),
+		optionalFloatString(row.UnitQuantity),
+		stringOrEmpty(row.PrimaryImageURL),
+		strings.Join(row.Images, csvListSeparator),
+		stringOrEmpty(row.Brand),
+		stringOrEmpty(row.Manufacturer),
+		stringOrEmpty(row.Barcode),
+		stringOrEmpty(row.EAN),
+		strings.Join(row.Taxes, csvListSeparator),
+		strconv.FormatBool(row.IsActive),
+		strconv.FormatBool(row.IsFeatured),
+		strconv.FormatBool(row.IsCustomizable),
+		strconv.FormatBool(row.IsAddon),
+		formatFloat(row.StorePrice),
+		formatFloat(row.StockQuantity),
+		strconv.FormatBool(row.IsInStock),
+		optionalFloatString(row.SalePrice),
+		formatFloat(row.TaxRate),
+		stringOrEmpty(row.CategoryName),
+		row.ProductID,
+		row.StoreProductID,
+		row.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+// formatFloat formats v in as few digits as represent it exactly
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// optionalFloatString formats *v, or "" for nil
+func optionalFloatString(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return formatFloat(*v)
+}
@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/yourusername/supabase-redis-middleware/internal/service"
+	"go.uber.org/zap"
+)
+
+type CategoryHandler struct {
+	catalog service.CatalogService
+	logger  *zap.Logger
+}
+
+func NewCategoryHandler(catalog service.CatalogService, logger *zap.Logger) *CategoryHandler {
+	return &CategoryHandler{
+		catalog: catalog,
+		logger:  logger,
+	}
+}
+
+// GetCategoryTree returns the active category hierarchy with available listing counts
+// Query: store_id (optional) counts only that store's listings
+func (h *CategoryHandler) GetCategoryTree(c *gin.Context) {
+	storeID := c.Query("store_id")
+	if storeID != "" {
+		if _, err := uuid.Parse(storeID); err != nil {
+			invalidUUID(c, "store_id")
+			return
+		}
+	}
+
+	resp, _ := h.catalog.ListCategoryTree(c.Request.Context(), storeID)
+	WriteServiceResponse(c, resp)
+}
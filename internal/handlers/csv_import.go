@@ -0,0 +1,274 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// csvFields are the fields a CSV import sets, by the JSON name of what they map to in a
+// push: a product's fields, then store_price, stock_quantity and is_in_stock for its listing
+var csvFields = []string{
+	"id", "sku", "name", "slug", "description", "category_id", "price", "currency",
+	"unit", "unit_quantity", "primary_image_url", "images", "brand", "manufacturer",
+	"barcode", "ean", "taxes", "is_active", "is_featured", "is_customizable", "is_addon",
+	"store_price", "stock_quantity", "is_in_stock",
+}
+
+// csvRequiredFields must be mapped to a column, and set in every row
+var csvRequiredFields = []string{"id", "sku", "name", "price"}
+
+// csvListSeparator separates the image URLs and tax IDs in a cell
+const csvListSeparator = "|"
+
+// maxCSVRowErrors bounds the row errors reported; rows past it are still counted
+const maxCSVRowErrors = 100
+
+// CSVRowError is a problem with one row of an import
+// Line is the file line the row starts on, counting the header as line 1
+type CSVRowError struct {
+	Line    int    `json:"line"`
+	Column  string `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+// csvImport accumulates the products of an import as its rows are read
+type csvImport struct {
+	Products      []Product
+	StoreProducts []StoreProduct
+	Lines         []int         // The line each product's row starts on
+	Errors        []CSVRowError // At most maxCSVRowErrors
+	RowsRead      int
+	RowsFailed    int
+}
+
+// csvColumns maps fields to the positions of their columns, and back to their headers
+type csvColumns struct {
+	index   map[string]int
+	headers []string
+}
+
+// newCSVColumns locates each field's column in header. mapping names each field's column
+// header; without one, columns are headed by the field names. Headers are matched
+// case-insensitively, ignoring surrounding space
+func newCSVColumns(header []string, mapping map[string]string) (*csvColumns, error) {
+	positions := make(map[string]int, len(header))
+	for i, h := range header {
+		// Spreadsheets often save UTF-8 with a byte order mark
+		if i == 0 {
+			h = strings.TrimPrefix(h, "\ufeff")
+		}
+		key := strings.ToLower(strings.TrimSpace(h))
+		if _, dup := positions[key]; dup && key != "" {
+			return nil, fmt.Errorf("column %q appears twice in the header", strings.TrimSpace(h))
+		}
+		positions[key] = i
+	}
+
+	columns := &csvColumns{index: map[string]int{}, headers: slices.Clone(header)}
+	if len(mapping) == 0 {
+		for _, field := range csvFields {
+			if i, ok := positions[field]; ok {
+				columns.index[field] = i
+			}
+		}
+	} else {
+		for field, column := range mapping {
+			if !slices.Contains(csvFields, field) {
+				return nil, fmt.Errorf("mapping names unknown field %q", field)
+			}
+			i, ok := positions[strings.ToLower(strings.TrimSpace(column))]
+			if !ok {
+				return nil, fmt.Errorf("column %q mapped to %s is not in the header", column, field)
+			}
+			columns.index[field] = i
+		}
+	}
+
+	for _, field := range csvRequiredFields {
+		if _, ok := columns.index[field]; !ok {
+			return nil, fmt.Errorf("no column is mapped to %s; %s are required", field, strings.Join(csvRequiredFields, ", "))
+		}
+	}
+	return columns, nil
+}
+
+// readCSVImport reads a CSV file a row at a time, validating each as it is read, so the
+// file itself is never held in memory. A row with problems is left out and its problems
+// recorded; an error is returned only if the file can't be read as CSV at all
+func readCSVImport(r io.Reader, mapping map[string]string) (*csvImport, error) {
+	reader := csv.NewReader(r)
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("file is empty; the first row must be the header")
+	}
+	if err != nil {
+		return nil, err
+	}
+	columns, err := newCSVColumns(header, mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	imp := &csvImport{Errors: []CSVRowError{}}
+	seen := map[string]int{} // Product ID to the line it was first seen on
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return imp, nil
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) && errors.Is(err, csv.ErrFieldCount) {
+			imp.RowsRead++
+			imp.fail([]CSVRowError{{Line: parseErr.StartLine, Message: fmt.Sprintf("has %d columns; the header has %d", len(record), len(header))}})
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		imp.RowsRead++
+		line, _ := reader.FieldPos(0)
+
+		product, listing, problems := columns.parseRow(record, line)
+		if first, dup := seen[product.ID]; dup && product.ID != "" {
+			problems = append(problems, CSVRowError{Line: line, Column: columns.header("id"), Message: fmt.Sprintf("repeats the product on line %d", first)})
+		}
+		if len(problems) > 0 {
+			imp.fail(problems)
+			continue
+		}
+		seen[product.ID] = line
+		imp.Products = append(imp.Products, product)
+		imp.StoreProducts = append(imp.StoreProducts, listing)
+		imp.Lines = append(imp.Lines, line)
+	}
+}
+
+// fail records a row left out for problems
+func (imp *csvImport) fail(problems []CSVRowError) {
+	imp.RowsFailed++
+	room := maxCSVRowErrors - len(imp.Errors)
+	imp.Errors = append(imp.Errors, problems[:min(room, len(problems))]...)
+}
+
+// header returns the header of field's column
+func (cols *csvColumns) header(field string) string {
+	return strings.TrimSpace(strings.TrimPrefix(cols.headers[cols.index[field]], "\ufeff"))
+}
+
+// parseRow converts a row to a product and its listing, with a problem for each cell
+// that isn't valid
+func (cols *csvColumns) parseRow(record []string, line int) (Product, StoreProduct, []CSVRowError) {
+	var problems []CSVRowError
+	cell := func(field string) string {
+		i, ok := cols.index[field]
+		if !ok {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+	fail := func(field, message string) {
+		problems = append(problems, CSVRowError{Line: line, Column: cols.header(field), Message: message})
+	}
+	number := func(field string, fallback float64) float64 {
+		raw := cell(field)
+		if raw == "" {
+			return fallback
+		}
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil || v < 0 {
+			fail(field, "must be a non-negative number")
+			return fallback
+		}
+		return v
+	}
+	price := func(field string, fallback float64) float64 {
+		raw := cell(field)
+		if raw == "" {
+			return fallback
+		}
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil || v <= 0 {
+			fail(field, "must be a number greater than 0")
+			return fallback
+		}
+		return v
+	}
+	flag := func(field string, fallback bool) bool {
+		raw := cell(field)
+		if raw == "" {
+			return fallback
+		}
+		v, err := strconv.ParseBool(raw)
+		if err != nil {
+			fail(field, "must be true or false")
+			return fallback
+		}
+		return v
+	}
+
+	product := Product{
+		ID:              cell("id"),
+		SKU:             cell("sku"),
+		Name:            cell("name"),
+		Slug:            cell("slug"),
+		Description:     cell("description"),
+		CategoryID:      cell("category_id"),
+		Currency:        cell("currency"),
+		Unit:            cell("unit"),
+		UnitQuantity:    number("unit_quantity", 0),
+		PrimaryImageURL: cell("primary_image_url"),
+		Images:          csvList(cell("images")),
+		Brand:           cell("brand"),
+		Manufacturer:    cell("manufacturer"),
+		Barcode:         cell("barcode"),
+		EAN:             cell("ean"),
+		Taxes:           csvList(cell("taxes")),
+		// Unlike a push, a row is active unless it says otherwise
+		IsActive:       flag("is_active", true),
+		IsFeatured:     flag("is_featured", false),
+		IsCustomizable: flag("is_customizable", false),
+		IsAddon:        flag("is_addon", false),
+	}
+	for _, field := range []string{"id", "sku", "name"} {
+		if cell(field) == "" {
+			fail(field, "is required")
+		}
+	}
+	if cell("price") == "" {
+		fail("price", "is required")
+	}
+	product.Price = price("price", 0)
+
+	stock := number("stock_quantity", 0)
+	_, hasStock := cols.index["stock_quantity"]
+	listing := StoreProduct{
+		ProductID:     product.ID,
+		Price:         price("store_price", product.Price),
+		StockQuantity: stock,
+		// Without a stock column, listings are in stock as they are for a push without store_products
+		IsInStock: flag("is_in_stock", !hasStock || stock > 0),
+		Taxes:     product.Taxes,
+	}
+	return product, listing, problems
+}
+
+// csvList splits a cell of values separated by csvListSeparator, dropping empty ones
+func csvList(raw string) []string {
+	if raw == "" {
+		return nil
+	}
+	var values []string
+	for _, v := range strings.Split(raw, csvListSeparator) {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
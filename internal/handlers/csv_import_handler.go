@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/supabase-redis-middleware/internal/i18n"
+	"github.com/yourusername/supabase-redis-middleware/internal/repository"
+	"go.uber.org/zap"
+)
+
+// maxCSVMappingSize bounds the template and mapping parts of an import
+const maxCSVMappingSize = 64 << 10
+
+// CSVImportHandler imports a store's catalog from a CSV file, as a push without
+// categories, taxes or variations
+type CSVImportHandler struct {
+	products  *ProductHandler
+	pgRepo    *repository.PostgresRepository
+	templates map[string]map[string]string
+	logger    *zap.Logger
+}
+
+// NewCSVImportHandler creates a handler pushing imports through products. templates are
+// column mappings by name, each mapping fields to their column headers
+func NewCSVImportHandler(products *ProductHandler, pgRepo *repository.PostgresRepository, templates map[string]map[string]string, logger *zap.Logger) *CSVImportHandler {
+	return &CSVImportHandler{
+		products:  products,
+		pgRepo:    pgRepo,
+		templates: templates,
+		logger:    logger,
+	}
+}
+
+// ImportCSV pushes the products in a multipart "file" part, one per row, to the store
+// named by store_id, which must already exist. A "template" part naming a configured
+// mapping, or a "mapping" part with one as JSON, may precede the file to map fields to
+// columns; otherwise columns are headed by the field names.
+// Rows are validated as they are read. A file with invalid rows is refused with each
+// row's problems, unless partial=true, which pushes the valid rows and reports the rest
+// Query: store_id, partial, dry_run, sync_mode (incremental or full)
+func (h *CSVImportHandler) ImportCSV(c *gin.Context) {
+	externalID := c.Query("store_id")
+	if externalID == "" {
+		invalidInput(c, "store_id is required")
+		return
+	}
+	if !allowsExternalStore(c, externalID) {
+		return
+	}
+	partial, ok := optionalBool(c, "partial")
+	if !ok {
+		return
+	}
+	dryRun, ok := optionalBool(c, "dry_run")
+	if !ok {
+		return
+	}
+	syncMode := c.Query("sync_mode")
+	if syncMode != "" && syncMode != "incremental" && syncMode != "full" {
+		invalidInput(c, "sync_mode must be incremental or full")
+		return
+	}
+
+	ctx := c.Request.Context()
+	log := requestLogger(c, h.logger).With(zap.String("store_id", externalID))
+
+	// Imports update a store's catalog; its details are kept as they are
+	storeID, err := h.pgRepo.StoreIDByExternalID(ctx, externalID)
+	if err != nil {
+		writeStoreError(c, err, "STORE_NOT_FOUND", "Failed to look up store")
+		return
+	}
+	store, err := h.pgRepo.GetStoreByID(ctx, storeID)
+	if err != nil {
+		writeStoreError(c, err, "STORE_NOT_FOUND", "Failed to look up store")
+		return
+	}
+
+	imp, ok := h.readUpload(c)
+	if !ok {
+		return
+	}
+	log.Info("Read CSV import",
+		zap.Int("rows", imp.RowsRead),
+		zap.Int("rows_failed", imp.RowsFailed))
+
+	switch {
+	case imp.RowsRead == 0:
+		invalidInput(c, "file has no rows after the header")
+		return
+	case imp.RowsFailed > 0 && (!partial || syncMode == "full" || len(imp.Products) == 0):
+		// A full sync would deactivate the listings of the rows left out
+		key, message := "INVALID_CSV", "File has invalid rows; nothing was imported"
+		if syncMode == "full" && partial {
+			key, message = "INVALID_CSV.full_sync", "File has invalid rows, which a full sync can't skip; nothing was imported"
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":       "INVALID_CSV",
+				"message":    i18n.T(c.Request.Context(), key, message, nil),
+				"request_id": requestID(c),
+			},
+			"data": csvRowSummary(imp),
+		})
+		return
+	}
+
+	req := PushProductsRequest{
+		Products:      imp.Products,
+		StoreProducts: imp.StoreProducts,
+		StoreDetails:  csvStoreDetails(externalID, store),
+		SyncMode:      syncMode,
+	}
+	outcome, err := h.products.Push(ctx, req, partial, dryRun)
+	writePushOutcome(c, outcome, err, dryRun, func(data gin.H) {
+		for key, value := range csvRowSummary(imp) {
+			data[key] = value
+		}
+		if failures, ok := data["failures"].([]gin.H); ok {
+			for _, failure := range failures {
+				failure["line"] = imp.Lines[failure["index"].(int)]
+			}
+		}
+	})
+}
+
+// readUpload reads the template, mapping and file parts of an import, writing a 400 or
+// 413 if they can't be read. Parts after the file are ignored
+func (h *CSVImportHandler) readUpload(c *gin.Context) (*csvImport, bool) {
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		invalidInput(c, "body must be multipart/form-data with a file part")
+		return nil, false
+	}
+
+	var mapping map[string]string
+	var mapped string
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			invalidInput(c, "file is required")
+			return nil, false
+		}
+		if err != nil {
+			if !writeBodyTooLarge(c, err) {
+				invalidInput(c, "body must be multipart/form-data with a file part")
+			}
+			return nil, false
+		}
+
+		switch name := part.FormName(); name {
+		case "template", "mapping":
+			if mapped != "" {
+				invalidInput(c, "send one template or mapping, not both")
+				return nil, false
+			}
+			mapped = name
+			raw, err := io.ReadAll(io.LimitReader(part, maxCSVMappingSize))
+			if err != nil {
+				if !writeBodyTooLarge(c, err) {
+					invalidInput(c, name+" could not be read")
+				}
+				return nil, false
+			}
+			if name == "template" {
+				template, ok := h.templates[strings.ToLower(strings.TrimSpace(string(raw)))]
+				if !ok {
+					invalidInput(c, "unknown template "+strings.TrimSpace(string(raw)))
+					return nil, false
+				}
+				mapping = template
+			} else if err := json.Unmarshal(raw, &mapping); err != nil {
+				invalidInput(c, "mapping must be a JSON object of field names to column headers")
+				return nil, false
+			}
+
+		case "file":
+			imp, err := readCSVImport(part, mapping)
+			if err != nil {
+				requestLogger(c, h.logger).Warn("Invalid CSV import", zap.Error(err))
+				if !writeBodyTooLarge(c, err) {
+					invalidInput(c, "file: "+err.Error())
+				}
+				return nil, false
+			}
+			return imp, true
+
+		default:
+			part.Close()
+		}
+	}
+}
+
+// csvStoreDetails returns a store's details as a push carries them, so the push leaves
+// them as they are
+func csvStoreDetails(externalID string, store *repository.StoreDetail) StoreDetails {
+	details := StoreDetails{
+		StoreID:  externalID,
+		Name:     store.Name,
+		Address:  Address{Line1: store.AddressLine1, City: store.City},
+		Location: Location{Lat: store.Latitude, Lng: store.Longitude},
+	}
+	if store.State != nil {
+		details.Address.State = *store.State
+	}
+	if store.PostalCode != nil {
+		details.Address.PostalCode = *store.PostalCode
+	}
+	return details
+}
+
+// csvRowSummary reports the rows an import read and left out
+func csvRowSummary(imp *csvImport) gin.H {
+	return gin.H{
+		"rows_read":   imp.RowsRead,
+		"rows_failed": imp.RowsFailed,
+		"row_errors":  imp.Errors,
+	}
+}
@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/yourusername/supabase-redis-middleware/internal/repository"
+	"go.uber.org/zap"
+)
+
+// DeliveryZoneRequest is the body of POST /stores/:id/delivery-zones
+// Radius zones take radius_km and an optional center (default: the store's location);
+// polygon zones take a ring of [lng, lat] points, as in GeoJSON
+type DeliveryZoneRequest struct {
+	Name      string       `json:"name" binding:"required,max=100"`
+	ZoneType  string       `json:"zone_type" binding:"required,oneof=radius polygon"`
+	RadiusKm  float64      `json:"radius_km" binding:"omitempty,gt=0,lte=50"`
+	CenterLat *float64     `json:"center_lat" binding:"omitempty,min=-90,max=90"`
+	CenterLng *float64     `json:"center_lng" binding:"omitempty,min=-180,max=180"`
+	Polygon   [][2]float64 `json:"polygon" binding:"omitempty,min=3,max=1000"`
+}
+
+// ListDeliveryZones lists a store's delivery zones
+func (h *StoreHandler) ListDeliveryZones(c *gin.Context) {
+	storeID := c.Param("id")
+	if _, err := uuid.Parse(storeID); err != nil {
+		invalidUUID(c, "id")
+		return
+	}
+
+	zones, err := h.pgRepo.ListDeliveryZones(c.Request.Context(), storeID)
+	if err != nil {
+		requestLogger(c, h.logger).Error("Failed to list delivery zones", zap.String("store_id", storeID), zap.Error(err))
+		writeStoreError(c, err, "STORE_NOT_FOUND", "Failed to list delivery zones")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   zones,
+	})
+}
+
+// CreateDeliveryZone adds a radius or polygon delivery zone to a store
+func (h *StoreHandler) CreateDeliveryZone(c *gin.Context) {
+	storeID := c.Param("id")
+	if _, err := uuid.Parse(storeID); err != nil {
+		invalidUUID(c, "id")
+		return
+	}
+
+	var req DeliveryZoneRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidRequest(c, err)
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		invalidInput(c, "name must not be blank")
+		return
+	}
+
+	input := repository.DeliveryZoneInput{Name: name, ZoneType: req.ZoneType}
+	switch req.ZoneType {
+	case repository.DeliveryZoneRadius:
+		if req.RadiusKm == 0 || req.Polygon != nil {
+			invalidInput(c, "radius zones take radius_km and no polygon")
+			return
+		}
+		if (req.CenterLat == nil) != (req.CenterLng == nil) {
+			invalidInput(c, "center_lat and center_lng must be given together")
+			return
+		}
+		input.RadiusKm, input.CenterLat, input.CenterLng = req.RadiusKm, req.CenterLat, req.CenterLng
+	case repository.DeliveryZonePolygon:
+		if req.Polygon == nil || req.RadiusKm != 0 || req.CenterLat != nil || req.CenterLng != nil {
+			invalidInput(c, "polygon zones take a polygon and no radius_km or center")
+			return
+		}
+		for i, point := range req.Polygon {
+			if point[0] < -180 || point[0] > 180 || point[1] < -90 || point[1] > 90 {
+				invalidInput(c, fmt.Sprintf("polygon[%d] must be [lng, lat] with lng between -180 and 180 and lat between -90 and 90", i))
+				return
+			}
+		}
+		input.Polygon = req.Polygon
+	}
+
+	zone, err := h.pgRepo.CreateDeliveryZone(c.Request.Context(), storeID, input)
+	if err != nil {
+		requestLogger(c, h.logger).Error("Failed to create delivery zone", zap.String("store_id", storeID), zap.Error(err))
+		writeStoreError(c, err, "STORE_NOT_FOUND", "Failed to create delivery zone")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"data":    zone,
+		"message": "Delivery zone created successfully",
+	})
+}
+
+// DeleteDeliveryZone removes one of a store's delivery zones
+func (h *StoreHandler) DeleteDeliveryZone(c *gin.Context) {
+	storeID, zoneID := c.Param("id"), c.Param("zoneId")
+	if _, err := uuid.Parse(storeID); err != nil {
+		invalidUUID(c, "id")
+		return
+	}
+	if _, err := uuid.Parse(zoneID); err != nil {
+		invalidUUID(c, "zoneId")
+		return
+	}
+
+	if err := h.pgRepo.DeleteDeliveryZone(c.Request.Context(), storeID, zoneID); err != nil {
+		requestLogger(c, h.logger).Error("Failed to delete delivery zone", zap.String("zone_id", zoneID), zap.Error(err))
+		writeStoreError(c, err, "ZONE_NOT_FOUND", "Failed to delete delivery zone")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Delivery zone deleted successfully",
+	})
+}
+
+// CheckDelivery reports whether a store delivers to a point
+// Query: lat, lng (required)
+func (h *StoreHandler) CheckDelivery(c *gin.Context) {
+	storeID := c.Param("id")
+	if _, err := uuid.Parse(storeID); err != nil {
+		invalidUUID(c, "id")
+		return
+	}
+
+	lat, lng, ok := parseLatLng(c)
+	if !ok {
+		return
+	}
+
+	coverage, err := h.pgRepo.CheckDelivery(c.Request.Context(), storeID, lat, lng)
+	if err != nil {
+		requestLogger(c, h.logger).Error("Failed to check delivery coverage", zap.String("store_id", storeID), zap.Error(err))
+		writeStoreError(c, err, "STORE_NOT_FOUND", "Failed to check delivery coverage")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   coverage,
+	})
+}
+
+// FindServingStores lists active stores delivering to a point, nearest first
+// Query: lat, lng (required), store_type, limit
+func (h *StoreHandler) FindServingStores(c *gin.Context) {
+	lat, lng, ok := parseLatLng(c)
+	if !ok {
+		return
+	}
+
+	list, ok := listQuery(c)
+	if !ok {
+		return
+	}
+
+	stores, err := h.pgRepo.FindServingStores(c.Request.Context(), repository.ServingStoresQuery{
+		Lat:       lat,
+		Lng:       lng,
+		StoreType: c.Query("store_type"),
+		Limit:     list.Pagination.Limit,
+	})
+	if err != nil {
+		requestLogger(c, h.logger).Error("Failed to find serving stores", zap.Error(err))
+		writeStoreError(c, err, "STORE_NOT_FOUND", "Failed to search serving stores")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   stores,
+	})
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestDeliveryZoneHandlers_InvalidRequests(t *testing.T) {
+	const (
+		store = "/stores/550e8400-e29b-41d4-a716-446655440000"
+		zones = "/stores/:id/delivery-zones"
+	)
+	h := &StoreHandler{}
+
+	tests := []struct {
+		name        string
+		handler     gin.HandlerFunc
+		method      string
+		route       string
+		target      string
+		body        string
+		wantMessage string
+	}{
+		{"list with invalid store", h.ListDeliveryZones, http.MethodGet, zones, "/stores/abc/delivery-zones", "", "id must be a valid UUID"},
+		{"create without type", h.CreateDeliveryZone, http.MethodPost, zones, store + "/delivery-zones", `{"name":"Nearby","radius_km":2}`, "zone_type"},
+		{"create with blank name", h.CreateDeliveryZone, http.MethodPost, zones, store + "/delivery-zones", `{"name":"  ","zone_type":"radius","radius_km":2}`, "name must not be blank"},
+		{"radius over 50km", h.CreateDeliveryZone, http.MethodPost, zones, store + "/delivery-zones", `{"name":"Far","zone_type":"radius","radius_km":51}`, "radius_km"},
+		{"radius without radius_km", h.CreateDeliveryZone, http.MethodPost, zones, store + "/delivery-zones", `{"name":"Nearby","zone_type":"radius"}`, "radius zones take radius_km and no polygon"},
+		{"radius with half a center", h.CreateDeliveryZone, http.MethodPost, zones, store + "/delivery-zones", `{"name":"Nearby","zone_type":"radius","radius_km":2,"center_lat":12.9}`, "center_lat and center_lng must be given together"},
+		{"polygon with a radius", h.CreateDeliveryZone, http.MethodPost, zones, store + "/delivery-zones", `{"name":"Area","zone_type":"polygon","radius_km":2,"polygon":[[77.5,12.9],[77.6,12.9],[77.6,13.0]]}`, "polygon zones take a polygon and no radius_km or center"},
+		{"polygon with two points", h.CreateDeliveryZone, http.MethodPost, zones, store + "/delivery-zones", `{"name":"Area","zone_type":"polygon","polygon":[[77.5,12.9],[77.6,12.9]]}`, "polygon"},
+		{"polygon point out of range", h.CreateDeliveryZone, http.MethodPost, zones, store + "/delivery-zones", `{"name":"Area","zone_type":"polygon","polygon":[[77.5,12.9],[77.6,95],[77.6,13.0]]}`, "polygon[1] must be [lng, lat]"},
+		{"delete with invalid zone", h.DeleteDeliveryZone, http.MethodDelete, zones + "/:zoneId", store + "/delivery-zones/zone-1", "", "zoneId must be a valid UUID"},
+		{"check without lat", h.CheckDelivery, http.MethodGet, "/stores/:id/delivers-to", store + "/delivers-to?lng=77.6", "", "lat is required"},
+		{"serving with lng out of range", h.FindServingStores, http.MethodGet, "/stores/serving", "/stores/serving?lat=12.9&lng=181", "", "lng is required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(t, tt.handler, tt.method, tt.route, tt.target, tt.body)
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want 400: %s", w.Code, w.Body.String())
+			}
+			resp := decodeError(t, w)
+			if resp.Error.Code != "INVALID_INPUT" || !strings.Contains(resp.Error.Message, tt.wantMessage) {
+				t.Errorf("error = %s %q, want INVALID_INPUT mentioning %q", resp.Error.Code, resp.Error.Message, tt.wantMessage)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/supabase-redis-middleware/internal/graphql"
+	"github.com/yourusername/supabase-redis-middleware/internal/graphql/catalog"
+	"go.uber.org/zap"
+)
+
+// GraphQLHandler serves read-only GraphQL queries of the catalog
+type GraphQLHandler struct {
+	schema *catalog.Schema
+	logger *zap.Logger
+}
+
+func NewGraphQLHandler(schema *catalog.Schema, logger *zap.Logger) *GraphQLHandler {
+	return &GraphQLHandler{
+		schema: schema,
+		logger: logger,
+	}
+}
+
+// Query runs a GraphQL query
+// POST takes a JSON body of query, operationName and variables; GET takes them as query
+// parameters, with variables JSON-encoded. Queries that fail to parse or validate are
+// answered 400 with only errors; those that ran are answered 200 with data, and errors
+// for any fields that failed
+func (h *GraphQLHandler) Query(c *gin.Context) {
+	var req graphql.Request
+	if c.Request.Method == http.MethodGet {
+		req.Query = c.Query("query")
+		req.OperationName = c.Query("operationName")
+		if raw := c.Query("variables"); raw != "" {
+			if err := json.Unmarshal([]byte(raw), &req.Variables); err != nil {
+				graphQLError(c, "variables must be a JSON object")
+				return
+			}
+		}
+	} else if err := c.ShouldBindJSON(&req); err != nil {
+		graphQLError(c, "Body must be a JSON object with query, operationName and variables")
+		return
+	}
+	if req.Query == "" {
+		graphQLError(c, "query is required")
+		return
+	}
+
+	resp := h.schema.Execute(c.Request.Context(), req)
+	if !resp.Executed() {
+		c.JSON(http.StatusBadRequest, resp)
+		return
+	}
+	if len(resp.Errors) > 0 {
+		requestLogger(c, h.logger).Warn("GraphQL query had field errors",
+			zap.String("operation", req.OperationName),
+			zap.Int("errors", len(resp.Errors)),
+			zap.String("first_error", resp.Errors[0].Message))
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// Schema returns the schema in the GraphQL schema definition language
+func (h *GraphQLHandler) Schema(c *gin.Context) {
+	c.String(http.StatusOK, h.schema.SDL())
+}
+
+// graphQLError writes a 400 carrying message as a GraphQL error, the shape GraphQL
+// clients read errors in
+func graphQLError(c *gin.Context, message string) {
+	c.JSON(http.StatusBadRequest, graphql.Response{Errors: []*graphql.Error{{Message: message}}})
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/supabase-redis-middleware/internal/repository"
+)
+
+// serve routes one request to handler, registered under route, and returns the response
+// Handlers are built without repositories, so a request must be refused before reaching one
+func serve(t *testing.T, handler gin.HandlerFunc, method, route, target, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Handle(method, route, handler)
+
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req := httptest.NewRequest(method, target, reader)
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// errorResponse is the payload of an error response
+type errorResponse struct {
+	Status string `json:"status"`
+	Error  struct {
+		Code    string                  `json:"code"`
+		Message string                  `json:"message"`
+		Errors  []repository.FieldError `json:"errors"`
+	} `json:"error"`
+}
+
+// decodeError decodes an error response
+func decodeError(t *testing.T, w *httptest.ResponseRecorder) errorResponse {
+	t.Helper()
+	var resp errorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response %s: %v", w.Body.String(), err)
+	}
+	return resp
+}
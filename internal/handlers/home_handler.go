@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/supabase-redis-middleware/internal/service"
+	"go.uber.org/zap"
+)
+
+type HomeHandler struct {
+	catalog service.CatalogService
+	logger  *zap.Logger
+}
+
+func NewHomeHandler(catalog service.CatalogService, logger *zap.Logger) *HomeHandler {
+	return &HomeHandler{
+		catalog: catalog,
+		logger:  logger,
+	}
+}
+
+// GetHome returns the home screen: stores near the caller, featured products and categories
+// Query: lat, lng (required)
+func (h *HomeHandler) GetHome(c *gin.Context) {
+	lat, lng, ok := parseLatLng(c)
+	if !ok {
+		return
+	}
+
+	resp, _ := h.catalog.Home(c.Request.Context(), lat, lng)
+	WriteServiceResponse(c, resp)
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"net/http"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/supabase-redis-middleware/internal/jobs"
+	"go.uber.org/zap"
+)
+
+// streamEntryID matches Redis stream entry IDs, such as 1760520000000-0, and 0
+var streamEntryID = regexp.MustCompile(`^\d+(-\d+)?$`)
+
+type JobsHandler struct {
+	pool   *jobs.Pool
+	logger *zap.Logger
+}
+
+func NewJobsHandler(pool *jobs.Pool, logger *zap.Logger) *JobsHandler {
+	return &JobsHandler{
+		pool:   pool,
+		logger: logger,
+	}
+}
+
+// ListDeadJobs lists background jobs that failed every attempt, oldest first, with the
+// error of the last
+// GET /api/v1/admin/jobs/dead?after=1760520000000-0&limit=20
+// Pass the entry_id of the last job listed as after for the next page
+func (h *JobsHandler) ListDeadJobs(c *gin.Context) {
+	after := c.DefaultQuery("after", "0")
+	if !streamEntryID.MatchString(after) {
+		invalidInput(c, "after must be the entry_id of a dead job")
+		return
+	}
+	list, ok := listQuery(c)
+	if !ok {
+		return
+	}
+
+	dead, err := h.pool.DeadJobs(c.Request.Context(), after, int64(list.Pagination.Limit))
+	if err != nil {
+		requestLogger(c, h.logger).Error("Failed to list dead jobs", zap.Error(err))
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to list dead jobs", nil)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   dead,
+	})
+}
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/yourusername/supabase-redis-middleware/internal/i18n"
+	"github.com/yourusername/supabase-redis-middleware/internal/repository"
+)
+
+const (
+	defaultProductPageSize = 20
+	maxProductPageSize     = 100
+)
+
+// parseListingFilter reads the listing query parameters shared by the catalog endpoints:
+// store_id, category_id, brand_id, search, in_stock, min_price, max_price
+// Writes a 400 and returns false on invalid input
+func parseListingFilter(c *gin.Context) (repository.ListingFilter, bool) {
+	filter := repository.ListingFilter{
+		StoreID:    c.Query("store_id"),
+		CategoryID: c.Query("category_id"),
+		BrandID:    c.Query("brand_id"),
+		Search:     c.Query("search"),
+	}
+
+	for name, value := range map[string]string{
+		"store_id":    filter.StoreID,
+		"category_id": filter.CategoryID,
+		"brand_id":    filter.BrandID,
+	} {
+		if value == "" {
+			continue
+		}
+		if _, err := uuid.Parse(value); err != nil {
+			invalidUUID(c, name)
+			return filter, false
+		}
+	}
+
+	var ok bool
+	if filter.InStockOnly, ok = optionalBool(c, "in_stock"); !ok {
+		return filter, false
+	}
+	if filter.MinPrice, ok = optionalFloat(c, "min_price"); !ok {
+		return filter, false
+	}
+	if filter.MaxPrice, ok = optionalFloat(c, "max_price"); !ok {
+		return filter, false
+	}
+
+	return filter, true
+}
+
+// optionalBool reads an optional boolean query parameter, writing a 400 on invalid input
+func optionalBool(c *gin.Context, name string) (bool, bool) {
+	raw := c.Query(name)
+	if raw == "" {
+		return false, true
+	}
+
+	v, err := strconv.ParseBool(raw)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, "INVALID_INPUT.bool", name+" must be true or false", i18n.Params{"name": name})
+		return false, false
+	}
+	return v, true
+}
+
+// optionalFloat reads an optional numeric query parameter, writing a 400 on invalid input
+func optionalFloat(c *gin.Context, name string) (*float64, bool) {
+	raw := c.Query(name)
+	if raw == "" {
+		return nil, true
+	}
+
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil || v < 0 {
+		writeError(c, http.StatusBadRequest, "INVALID_INPUT.non_negative", name+" must be a non-negative number", i18n.Params{"name": name})
+		return nil, false
+	}
+	return &v, true
+}
+
+// optionalTime reads an optional RFC 3339 timestamp query parameter, writing a 400 on invalid input
+func optionalTime(c *gin.Context, name string) (*time.Time, bool) {
+	raw := c.Query(name)
+	if raw == "" {
+		return nil, true
+	}
+
+	v, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, "INVALID_INPUT.timestamp", name+" must be an RFC 3339 timestamp (e.g. 2026-01-01T00:00:00Z)", i18n.Params{"name": name})
+		return nil, false
+	}
+	return &v, true
+}
+
+// parseLatLng reads the required lat and lng query parameters, writing a 400 on invalid input
+func parseLatLng(c *gin.Context) (float64, float64, bool) {
+	lat, err := strconv.ParseFloat(c.Query("lat"), 64)
+	if err != nil || lat < -90 || lat > 90 {
+		writeError(c, http.StatusBadRequest, "INVALID_INPUT.lat", "lat is required and must be between -90 and 90", nil)
+		return 0, 0, false
+	}
+
+	lng, err := strconv.ParseFloat(c.Query("lng"), 64)
+	if err != nil || lng < -180 || lng > 180 {
+		writeError(c, http.StatusBadRequest, "INVALID_INPUT.lng", "lng is required and must be between -180 and 180", nil)
+		return 0, 0, false
+	}
+
+	return lat, lng, true
+}
+
+// maxFields is the most keys the fields query parameter may list
+const maxFields = 50
+
+// parseFields reads the comma-separated fields query parameter, writing a 400 on invalid
+// input. No parameter returns no fields, selecting every key
+func parseFields(c *gin.Context) ([]string, bool) {
+	value, ok := c.GetQuery("fields")
+	if !ok {
+		return nil, true
+	}
+
+	var fields []string
+	seen := make(map[string]bool)
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			writeError(c, http.StatusBadRequest, "INVALID_INPUT.fields", "fields must be a comma-separated list of non-empty names", nil)
+			return nil, false
+		}
+		if !seen[field] {
+			seen[field] = true
+			fields = append(fields, field)
+		}
+	}
+	if len(fields) > maxFields {
+		writeError(c, http.StatusBadRequest, "INVALID_INPUT.fields_max", fmt.Sprintf("fields may list at most %d names", maxFields), i18n.Params{"max": maxFields})
+		return nil, false
+	}
+	return fields, true
+}
+
+// writeBodyTooLarge writes a 413 PAYLOAD_TOO_LARGE error if err is from reading past the
+// route's body limit, reporting whether it was
+func writeBodyTooLarge(c *gin.Context, err error) bool {
+	var maxBytesErr *http.MaxBytesError
+	if !errors.As(err, &maxBytesErr) {
+		return false
+	}
+	writeError(c, http.StatusRequestEntityTooLarge, "PAYLOAD_TOO_LARGE",
+		"Request body must be at most "+strconv.FormatInt(maxBytesErr.Limit, 10)+" bytes", i18n.Params{"limit": maxBytesErr.Limit})
+	return true
+}
+
+// invalidInput writes a 400 INVALID_INPUT error
+func invalidInput(c *gin.Context, message string) {
+	writeError(c, http.StatusBadRequest, "INVALID_INPUT", message, nil)
+}
+
+// invalidUUID writes a 400 INVALID_INPUT error for a parameter that isn't a valid UUID
+func invalidUUID(c *gin.Context, name string) {
+	writeError(c, http.StatusBadRequest, "INVALID_INPUT.uuid", name+" must be a valid UUID", i18n.Params{"name": name})
+}
+
+// writeError writes an error payload. key is the error code, or the code and the name of
+// one of its messages (INVALID_INPUT.uuid): message, in English, is translated by it into
+// the language of the request, filling in params
+func writeError(c *gin.Context, status int, key, message string, params i18n.Params) {
+	code, _, _ := strings.Cut(key, ".")
+	c.JSON(status, gin.H{
+		"status": "error",
+		"error": gin.H{
+			"code":       code,
+			"message":    i18n.T(c.Request.Context(), key, message, params),
+			"request_id": requestID(c),
+		},
+	})
+}
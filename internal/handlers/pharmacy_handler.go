@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/yourusername/supabase-redis-middleware/internal/service"
+	"go.uber.org/zap"
+)
+
+type PharmacyHandler struct {
+	catalog service.CatalogService
+	logger  *zap.Logger
+}
+
+func NewPharmacyHandler(catalog service.CatalogService, logger *zap.Logger) *PharmacyHandler {
+	return &PharmacyHandler{
+		catalog: catalog,
+		logger:  logger,
+	}
+}
+
+// ListMedicines lists medicines available in pharmacy stores
+// Query: the catalog listing filters plus prescription_required, limit, offset
+func (h *PharmacyHandler) ListMedicines(c *gin.Context) {
+	list, ok := listQuery(c)
+	if !ok {
+		return
+	}
+	filter := list.Filter
+
+	if c.Query("prescription_required") != "" {
+		required, ok := optionalBool(c, "prescription_required")
+		if !ok {
+			return
+		}
+		filter.RequiresPrescription = &required
+	}
+
+	resp, _ := h.catalog.ListMedicines(c.Request.Context(), filter, list.Pagination)
+	WriteServiceResponse(c, resp)
+}
+
+// GetMedicine retrieves a single medicine listing with its variations
+func (h *PharmacyHandler) GetMedicine(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		invalidUUID(c, "id")
+		return
+	}
+
+	resp, _ := h.catalog.GetMedicine(c.Request.Context(), id)
+	WriteServiceResponse(c, resp)
+}
+
+// ListCategories lists categories that have medicines available
+func (h *PharmacyHandler) ListCategories(c *gin.Context) {
+	resp, _ := h.catalog.ListPharmacyCategories(c.Request.Context())
+	WriteServiceResponse(c, resp)
+}
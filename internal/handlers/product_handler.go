@@ -1,25 +1,46 @@
 package handlers
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"sort"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/yourusername/supabase-redis-middleware/internal/cache"
+	"github.com/yourusername/supabase-redis-middleware/internal/i18n"
+	"github.com/yourusername/supabase-redis-middleware/internal/logger"
+	"github.com/yourusername/supabase-redis-middleware/internal/realtime"
 	"github.com/yourusername/supabase-redis-middleware/internal/repository"
+	"github.com/yourusername/supabase-redis-middleware/internal/webhooks"
+	"go.opentelemetry.io/otel"
 	"go.uber.org/zap"
 )
 
 type ProductHandler struct {
-	pgRepo *repository.PostgresRepository
-	logger *zap.Logger
+	pgRepo   *repository.PostgresRepository
+	cache    cache.CacheService
+	events   *realtime.Publisher
+	webhooks *webhooks.Dispatcher
+	logger   *zap.Logger
 }
 
-func NewProductHandler(pgRepo *repository.PostgresRepository, logger *zap.Logger) *ProductHandler {
+func NewProductHandler(pgRepo *repository.PostgresRepository, cacheService cache.CacheService, events *realtime.Publisher, dispatcher *webhooks.Dispatcher, logger *zap.Logger) *ProductHandler {
 	return &ProductHandler{
-		pgRepo: pgRepo,
-		logger: logger,
+		pgRepo:   pgRepo,
+		cache:    cacheService,
+		events:   events,
+		webhooks: dispatcher,
+		logger:   logger,
 	}
 }
 
+// pushTracer records how long decoding a push takes, apart from writing it
+var pushTracer = otel.Tracer("github.com/yourusername/supabase-redis-middleware/internal/handlers")
+
 // PushProductsRequest represents the incoming payload structure
 type PushProductsRequest struct {
 	Categories    []Category     `json:"categories"`
@@ -28,6 +49,9 @@ type PushProductsRequest struct {
 	Variations    []Variation    `json:"variations"`
 	StoreProducts []StoreProduct `json:"store_products"`
 	StoreDetails  StoreDetails   `json:"store_details" binding:"required"`
+	// SyncMode "full" deactivates the store's listings missing from store_products
+	// (or products, when store_products is omitted); "incremental", the default, leaves them
+	SyncMode string `json:"sync_mode" binding:"omitempty,oneof=incremental full"`
 }
 
 type Category struct {
@@ -112,105 +136,368 @@ type Location struct {
 }
 
 // PushProducts handles bulk product upsert
+// With ?partial=true, invalid products and products that fail to save are skipped
+// and reported in failures[] while the rest are committed.
+// With ?dry_run=true the whole push runs in a transaction that is rolled back, and the
+// response lists what each product would have matched or created.
+// With "sync_mode": "full" in the body, the store's listings missing from the push are
+// marked unavailable once it has fully committed.
+// A payload identical to the store's last fully applied push is skipped with status "unchanged"
+// The payload is decoded a product at a time; one over the route's body limit is refused with 413
+// A push sent as application/x-ndjson is written in batches as it is read; see pushNDJSON
 func (h *ProductHandler) PushProducts(c *gin.Context) {
-	var req PushProductsRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		h.logger.Error("Invalid request payload", zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{
-			"status": "error",
-			"error": gin.H{
-				"code":    "INVALID_INPUT",
-				"message": err.Error(),
-			},
-		})
+	partial, ok := optionalBool(c, "partial")
+	if !ok {
+		return
+	}
+	dryRun, ok := optionalBool(c, "dry_run")
+	if !ok {
+		return
+	}
+	if c.ContentType() == NDJSONContentType {
+		h.pushNDJSON(c, partial, dryRun)
 		return
 	}
 
-	// Validate store exists or create/update it
-	storeInput := repository.StoreDetailsInput{
-		StoreID: req.StoreDetails.StoreID,
-		Name:    req.StoreDetails.Name,
-		Address: repository.AddressInput{
-			Line1:      req.StoreDetails.Address.Line1,
-			City:       req.StoreDetails.Address.City,
-			State:      req.StoreDetails.Address.State,
-			PostalCode: req.StoreDetails.Address.PostalCode,
-		},
-		Location: repository.LocationInput{
-			Lat: req.StoreDetails.Location.Lat,
-			Lng: req.StoreDetails.Location.Lng,
-		},
+	_, span := pushTracer.Start(c.Request.Context(), "push.decode")
+	req, err := decodePushRequest(c.Request.Body)
+	span.End()
+	if err != nil {
+		requestLogger(c, h.logger).Error("Invalid request payload", zap.Error(err))
+		invalidRequest(c, err)
+		return
 	}
-	if err := h.pgRepo.UpsertStore(c.Request.Context(), storeInput); err != nil {
-		h.logger.Error("Failed to upsert store", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{
+	if !allowsExternalStore(c, req.StoreDetails.StoreID) {
+		return
+	}
+
+	outcome, err := h.Push(c.Request.Context(), req, partial, dryRun)
+	writePushOutcome(c, outcome, err, dryRun, nil)
+}
+
+// writePushOutcome writes the response to a push Push applied or refused. decorate, if
+// set, may add to the data of a response with a summary
+func writePushOutcome(c *gin.Context, outcome *PushOutcome, err error, dryRun bool, decorate func(data gin.H)) {
+	var pushErr *PushError
+	if errors.As(err, &pushErr) {
+		ctx := c.Request.Context()
+		message := i18n.T(ctx, pushErr.Code, pushErr.Message, nil)
+		problems := localizeFieldErrors(ctx, pushErr.Errors)
+		if len(problems) > 0 && i18n.Locale(ctx) != "" {
+			message = describeFieldErrors(ctx, problems)
+		}
+		body := gin.H{
 			"status": "error",
 			"error": gin.H{
-				"code":    "STORE_UPSERT_FAILED",
-				"message": "Failed to create or update store",
+				"code":       pushErr.Code,
+				"message":    message,
+				"request_id": requestID(c),
 			},
-		})
+		}
+		if len(problems) > 0 {
+			body["error"].(gin.H)["errors"] = problems
+		}
+		if pushErr.Result != nil {
+			data := pushSummary(pushErr.Result)
+			if decorate != nil {
+				decorate(data)
+			}
+			body["data"] = data
+		}
+		c.JSON(pushErr.StatusCode, body)
 		return
 	}
 
-	// Upsert categories
-	if len(req.Categories) > 0 {
-		categoryInputs := make([]repository.CategoryInput, len(req.Categories))
-		for i, cat := range req.Categories {
-			categoryInputs[i] = repository.CategoryInput{
-				ID:           cat.ID,
-				ParentID:     cat.ParentID,
-				Name:         cat.Name,
-				Slug:         cat.Slug,
-				Description:  cat.Description,
-				DisplayOrder: cat.DisplayOrder,
-				IsActive:     cat.IsActive,
+	switch {
+	case outcome.Unchanged:
+		c.JSON(http.StatusOK, gin.H{
+			"status": "unchanged",
+			"data": gin.H{
+				"store_id":    outcome.StoreID,
+				"fingerprint": outcome.Fingerprint,
+			},
+			"message": "Payload is identical to the store's last push; nothing was written",
+		})
+	case dryRun:
+		data := dryRunSummary(outcome.Result)
+		if decorate != nil {
+			decorate(data)
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"status":  "success",
+			"data":    data,
+			"message": "Dry run completed; no changes were saved",
+		})
+	default:
+		data := pushSummary(outcome.Result)
+		if decorate != nil {
+			decorate(data)
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"status":  "success",
+			"data":    data,
+			"message": "Products pushed successfully",
+		})
+	}
+}
+
+// PushOutcome is what Push did with a push that didn't fail
+// An unchanged push carries only the store's ID and the payload's fingerprint
+type PushOutcome struct {
+	Unchanged   bool
+	StoreID     string
+	Fingerprint string
+	Result      *repository.UpsertResult
+}
+
+// PushError is a push that stopped, with the status, code and message it is reported with
+// Result holds the chunks committed before it stopped, if any were
+type PushError struct {
+	StatusCode int
+	Code       string
+	Message    string
+	Errors     []repository.FieldError // The invalid fields of an INVALID_INPUT
+	Result     *repository.UpsertResult
+	Err        error
+}
+
+func (e *PushError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %v", e.Message, e.Err)
+	}
+	return e.Message
+}
+
+func (e *PushError) Unwrap() error {
+	return e.Err
+}
+
+// Push applies a validated push to its store, as PushProducts does for the HTTP API, and
+// clears the caches it made stale. Failures are *PushError. The caller must already have
+// checked that it may write req's store
+func (h *ProductHandler) Push(ctx context.Context, req PushProductsRequest, partial, dryRun bool) (*PushOutcome, error) {
+	log := logger.FromContext(ctx, h.logger)
+
+	// A retry of the store's last applied push has nothing to write. Otherwise the fingerprint
+	// is cleared first, since a push that stops early still changes the store
+	var fingerprint string
+	if !dryRun {
+		fingerprint = pushFingerprint(req, partial)
+		if storeID, err := h.pgRepo.StoreIDByExternalID(ctx, req.StoreDetails.StoreID); err == nil {
+			if lastPushFingerprint(ctx, h.cache, storeID) == fingerprint {
+				log.Info("Skipped unchanged product push", zap.String("store_id", req.StoreDetails.StoreID))
+				return &PushOutcome{Unchanged: true, StoreID: storeID, Fingerprint: fingerprint}, nil
 			}
+			forgetPush(ctx, h.cache, storeID)
 		}
-		if err := h.pgRepo.UpsertCategories(c.Request.Context(), categoryInputs); err != nil {
-			h.logger.Error("Failed to upsert categories", zap.Error(err))
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"status": "error",
-				"error": gin.H{
-					"code":    "CATEGORY_UPSERT_FAILED",
-					"message": "Failed to create or update categories",
-				},
-			})
-			return
+	}
+
+	storeInput := toStoreInput(req.StoreDetails)
+	categoryInputs := toCategoryInputs(req.Categories)
+	taxInputs := toTaxInputs(req.Taxes)
+
+	// The store, categories and taxes are saved together, so a failure leaves none of them
+	// changed. Products are committed in chunks after them so a large push makes progress.
+	// A dry run writes all of it in its own transaction below
+	if !dryRun {
+		if err := h.savePushStore(ctx, storeInput, categoryInputs, taxInputs); err != nil {
+			return nil, err
+		}
+	}
+
+	// In partial mode invalid products are set aside up front; kept maps the
+	// remaining products back to their position in the payload
+	products := req.Products
+	kept := make([]int, len(req.Products))
+	for i := range kept {
+		kept[i] = i
+	}
+	var failures []repository.PushFailure
+	if partial {
+		products, kept, failures = validateProducts(req.Products)
+	} else if _, _, invalid := validateProducts(req.Products); len(invalid) > 0 {
+		return nil, invalidProductsError(invalid)
+	}
+
+	productInputs := toProductInputs(products)
+	variationInputs := toVariationInputs(req.Variations)
+
+	// If store_products array is provided, use it; otherwise auto-generate from products
+	var storeProductInputs []repository.StoreProductInput
+	if len(req.StoreProducts) > 0 {
+		storeProductInputs = toStoreProductInputs(req.StoreDetails.StoreID, req.StoreProducts)
+	} else {
+		storeProductInputs = listingsOf(req.StoreDetails.StoreID, products)
+	}
+
+	// Products set aside as invalid are still in the ERP's catalog, so a full sync keeps their listings
+	opts := repository.PushOptions{PartialSuccess: partial, FullSync: req.SyncMode == "full"}
+	for _, f := range failures {
+		opts.Retain = append(opts.Retain, f.ExternalProductID)
+	}
+
+	if dryRun {
+		result, err := h.pgRepo.DryRunProductPush(ctx, repository.ProductPush{
+			Store:         storeInput,
+			Categories:    categoryInputs,
+			Taxes:         taxInputs,
+			Products:      productInputs,
+			Variations:    variationInputs,
+			StoreProducts: storeProductInputs,
+		}, opts)
+		if err != nil {
+			// The error is what a real push would have stopped on, so it is returned as is
+			log.Warn("Dry run product push failed", zap.Error(err))
+			return nil, &PushError{StatusCode: http.StatusUnprocessableEntity, Code: "DRY_RUN_FAILED", Message: err.Error(), Err: err}
+		}
+
+		result.Failures = failures
+		for i := range result.Matches {
+			result.Matches[i].Index = kept[result.Matches[i].Index]
+		}
+		return &PushOutcome{Result: result}, nil
+	}
+
+	// Upsert products (main operation)
+	result, err := h.pgRepo.UpsertProductsWithMatching(
+		ctx,
+		req.StoreDetails.StoreID,
+		productInputs,
+		variationInputs,
+		storeProductInputs,
+		opts,
+	)
+	if result != nil {
+		result.Failures = mergePushFailures(failures, result.Failures, kept)
+	}
+	if err != nil && (result == nil || len(result.Chunks) == 0) {
+		log.Error("Failed to upsert products", zap.Error(err))
+		return nil, &PushError{StatusCode: http.StatusInternalServerError, Code: "PRODUCT_UPSERT_FAILED", Message: "Failed to create or update products", Err: err}
+	}
+	if err != nil {
+		// Earlier chunks are committed: report them so the ERP can resume from the first
+		// uncommitted product, and clear caches that may now be stale
+		log.Error("Product push partially committed", zap.Error(err))
+		cache.InvalidateDomains(ctx, h.cache, h.logger, pushedDomains(result, req)...)
+		h.events.ListingsChanged(ctx, realtime.EventProductsPushed, result.StoreID, pushedListings(storeProductInputs))
+		h.webhooks.ProductsUpdated(ctx, result.StoreID, savedProducts(result))
+		return nil, &PushError{
+			StatusCode: http.StatusInternalServerError,
+			Code:       "PRODUCT_UPSERT_INCOMPLETE",
+			Message:    "Push did not complete; the chunks listed in data were committed",
+			Result:     result,
+			Err:        err,
 		}
 	}
 
-	// Upsert taxes
-	if len(req.Taxes) > 0 {
-		taxInputs := make([]repository.TaxInput, len(req.Taxes))
-		for i, tax := range req.Taxes {
-			taxInputs[i] = repository.TaxInput{
-				ID:          tax.ID,
-				Name:        tax.Name,
-				TaxID:       tax.TaxID,
-				Description: tax.Description,
-				Rate:        tax.Rate,
-				TaxType:     tax.TaxType,
-				IsInclusive: tax.IsInclusive,
-				IsActive:    tax.IsActive,
+	cache.InvalidateDomains(ctx, h.cache, h.logger, pushedDomains(result, req)...)
+	h.events.ListingsChanged(ctx, realtime.EventProductsPushed, result.StoreID, pushedListings(storeProductInputs))
+	h.webhooks.ProductsUpdated(ctx, result.StoreID, savedProducts(result))
+
+	// A push with skipped products is applied again on retry, so their failures are reported
+	if len(result.Failures) == 0 {
+		rememberPush(ctx, h.cache, result.StoreID, fingerprint)
+	}
+
+	log.Info("Successfully pushed products",
+		zap.Int("products_failed", len(result.Failures)),
+		zap.Int("products_created", result.Created),
+		zap.Int("products_updated", result.Updated),
+		zap.Int("variations_processed", result.VariationsProcessed),
+		zap.Int("store_products_processed", result.StoreProductsProcessed),
+		zap.Int("taxes_processed", result.TaxesProcessed),
+		zap.Int("store_products_deactivated", result.Deactivated))
+
+	return &PushOutcome{StoreID: result.StoreID, Fingerprint: fingerprint, Result: result}, nil
+}
+
+// savePushStore saves a push's store, categories and taxes together, so a failure leaves
+// none of them changed. Failures are *PushError
+func (h *ProductHandler) savePushStore(ctx context.Context, storeInput repository.StoreDetailsInput, categoryInputs []repository.CategoryInput, taxInputs []repository.TaxInput) error {
+	code, message := "STORE_UPSERT_FAILED", "Failed to create or update store"
+	err := h.pgRepo.WithTx(ctx, func(tx *repository.PostgresRepository) error {
+		code, message = "STORE_UPSERT_FAILED", "Failed to create or update store"
+		if err := tx.UpsertStore(ctx, storeInput); err != nil {
+			return err
+		}
+		if len(categoryInputs) > 0 {
+			code, message = "CATEGORY_UPSERT_FAILED", "Failed to create or update categories"
+			if err := tx.UpsertCategories(ctx, categoryInputs); err != nil {
+				return err
 			}
 		}
-		if err := h.pgRepo.UpsertTaxes(c.Request.Context(), taxInputs, req.StoreDetails.StoreID); err != nil {
-			h.logger.Error("Failed to upsert taxes", zap.Error(err))
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"status": "error",
-				"error": gin.H{
-					"code":    "TAX_UPSERT_FAILED",
-					"message": "Failed to create or update taxes",
-				},
-			})
-			return
+		if len(taxInputs) > 0 {
+			code, message = "TAX_UPSERT_FAILED", "Failed to create or update taxes"
+			if err := tx.UpsertTaxes(ctx, taxInputs, storeInput.StoreID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logger.FromContext(ctx, h.logger).Error("Failed to upsert store, categories and taxes", zap.String("code", code), zap.Error(err))
+		return &PushError{StatusCode: http.StatusInternalServerError, Code: code, Message: message, Err: err}
+	}
+	return nil
+}
+
+// toStoreInput maps a push's store details to the repository's
+func toStoreInput(details StoreDetails) repository.StoreDetailsInput {
+	return repository.StoreDetailsInput{
+		StoreID: details.StoreID,
+		Name:    details.Name,
+		Address: repository.AddressInput{
+			Line1:      details.Address.Line1,
+			City:       details.Address.City,
+			State:      details.Address.State,
+			PostalCode: details.Address.PostalCode,
+		},
+		Location: repository.LocationInput{
+			Lat: details.Location.Lat,
+			Lng: details.Location.Lng,
+		},
+	}
+}
+
+// toCategoryInputs maps a push's categories to the repository's
+func toCategoryInputs(categories []Category) []repository.CategoryInput {
+	categoryInputs := make([]repository.CategoryInput, len(categories))
+	for i, cat := range categories {
+		categoryInputs[i] = repository.CategoryInput{
+			ID:           cat.ID,
+			ParentID:     cat.ParentID,
+			Name:         cat.Name,
+			Slug:         cat.Slug,
+			Description:  cat.Description,
+			DisplayOrder: cat.DisplayOrder,
+			IsActive:     cat.IsActive,
 		}
 	}
+	return categoryInputs
+}
 
-	// Convert products - map payload fields to internal structure
-	productInputs := make([]repository.ProductInput, len(req.Products))
-	for i, prod := range req.Products {
+// toTaxInputs maps a push's taxes to the repository's
+func toTaxInputs(taxes []Tax) []repository.TaxInput {
+	taxInputs := make([]repository.TaxInput, len(taxes))
+	for i, tax := range taxes {
+		taxInputs[i] = repository.TaxInput{
+			ID:          tax.ID,
+			Name:        tax.Name,
+			TaxID:       tax.TaxID,
+			Description: tax.Description,
+			Rate:        tax.Rate,
+			TaxType:     tax.TaxType,
+			IsInclusive: tax.IsInclusive,
+			IsActive:    tax.IsActive,
+		}
+	}
+	return taxInputs
+}
+
+// toProductInputs maps a push's products to the repository's
+func toProductInputs(products []Product) []repository.ProductInput {
+	productInputs := make([]repository.ProductInput, len(products))
+	for i, prod := range products {
 		// Generate slug if not provided
 		slug := prod.Slug
 		if slug == "" {
@@ -240,10 +527,13 @@ func (h *ProductHandler) PushProducts(c *gin.Context) {
 			IsAddon:           prod.IsAddon,
 		}
 	}
+	return productInputs
+}
 
-	// Convert variations
-	variationInputs := make([]repository.VariationInput, len(req.Variations))
-	for i, v := range req.Variations {
+// toVariationInputs maps a push's variations to the repository's
+func toVariationInputs(variations []Variation) []repository.VariationInput {
+	variationInputs := make([]repository.VariationInput, len(variations))
+	for i, v := range variations {
 		variationInputs[i] = repository.VariationInput{
 			ExternalID:        v.ID, // Map variation ID to external_id
 			ExternalProductID: v.ProductID,
@@ -253,77 +543,279 @@ func (h *ProductHandler) PushProducts(c *gin.Context) {
 			IsDefault:         v.IsDefault,
 		}
 	}
+	return variationInputs
+}
 
-	// Convert store products
-	// If store_products array is provided, use it; otherwise auto-generate from products
-	var storeProductInputs []repository.StoreProductInput
+// toStoreProductInputs maps a push's store products, for the store with ERP ID storeID, to
+// the repository's
+func toStoreProductInputs(storeID string, storeProducts []StoreProduct) []repository.StoreProductInput {
+	storeProductInputs := make([]repository.StoreProductInput, len(storeProducts))
+	for i, sp := range storeProducts {
+		storeProductInputs[i] = repository.StoreProductInput{
+			ExternalProductID:    sp.ProductID,
+			ExternalStoreProduct: "",
+			StoreID:              storeID,
+			Price:                sp.Price,
+			StockQuantity:        sp.StockQuantity,
+			IsInStock:            sp.IsInStock,
+			Taxes:                sp.Taxes,
+		}
+	}
+	return storeProductInputs
+}
 
-	if len(req.StoreProducts) > 0 {
-		// Use provided store_products
-		storeProductInputs = make([]repository.StoreProductInput, len(req.StoreProducts))
-		for i, sp := range req.StoreProducts {
-			storeProductInputs[i] = repository.StoreProductInput{
-				ExternalProductID:    sp.ProductID,
-				ExternalStoreProduct: "",
-				StoreID:              req.StoreDetails.StoreID,
-				Price:                sp.Price,
-				StockQuantity:        sp.StockQuantity,
-				IsInStock:            sp.IsInStock,
-				Taxes:                sp.Taxes,
-			}
+// listingsOf makes the listings of products for the store with ERP ID storeID, for a
+// push without store products
+func listingsOf(storeID string, products []Product) []repository.StoreProductInput {
+	storeProductInputs := make([]repository.StoreProductInput, len(products))
+	for i, prod := range products {
+		storeProductInputs[i] = repository.StoreProductInput{
+			ExternalProductID:    prod.ID,
+			ExternalStoreProduct: "",
+			StoreID:              storeID,
+			Price:                prod.Price,
+			StockQuantity:        0,          // Default stock
+			IsInStock:            true,       // Default in stock
+			Taxes:                prod.Taxes, // Use taxes from product
 		}
-	} else {
-		// Auto-generate store_products from products
-		storeProductInputs = make([]repository.StoreProductInput, len(req.Products))
-		for i, prod := range req.Products {
-			storeProductInputs[i] = repository.StoreProductInput{
-				ExternalProductID:    prod.ID,
-				ExternalStoreProduct: "",
-				StoreID:              req.StoreDetails.StoreID,
-				Price:                prod.Price,
-				StockQuantity:        0,          // Default stock
-				IsInStock:            true,       // Default in stock
-				Taxes:                prod.Taxes, // Use taxes from product
-			}
+	}
+	return storeProductInputs
+}
+
+// UpdateProductRequest is the body of PATCH /products/:external_id; omitted fields are unchanged
+type UpdateProductRequest struct {
+	Price       *float64 `json:"price" binding:"omitempty,gt=0"`
+	Name        *string  `json:"name" binding:"omitempty,max=255"`
+	Description *string  `json:"description"`
+	Images      []string `json:"images" binding:"omitempty,dive,url"`
+	IsAvailable *bool    `json:"is_available"`
+	IsInStock   *bool    `json:"is_in_stock"`
+	IsFeatured  *bool    `json:"is_featured"`
+}
+
+// UpdateProduct corrects a few fields of a pushed product without a full push
+// Query: store_id (required), the ERP store ID the product was pushed to
+func (h *ProductHandler) UpdateProduct(c *gin.Context) {
+	externalID := c.Param("external_id")
+	storeID := c.Query("store_id")
+	if storeID == "" {
+		invalidInput(c, "store_id is required")
+		return
+	}
+	if !allowsExternalStore(c, storeID) {
+		return
+	}
+
+	var req UpdateProductRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidRequest(c, err)
+		return
+	}
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			invalidInput(c, "name must not be blank")
+			return
 		}
+		req.Name = &name
 	}
 
-	// Upsert products (main operation)
-	result, err := h.pgRepo.UpsertProductsWithMatching(
-		c.Request.Context(),
-		req.StoreDetails.StoreID,
-		productInputs,
-		variationInputs,
-		storeProductInputs,
-	)
+	listing, err := h.pgRepo.UpdateStoreProduct(c.Request.Context(), storeID, externalID, repository.ProductUpdate{
+		Price:       req.Price,
+		Name:        req.Name,
+		Description: req.Description,
+		Images:      req.Images,
+		IsAvailable: req.IsAvailable,
+		IsInStock:   req.IsInStock,
+		IsFeatured:  req.IsFeatured,
+	})
 	if err != nil {
-		h.logger.Error("Failed to upsert products", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status": "error",
-			"error": gin.H{
-				"code":    "PRODUCT_UPSERT_FAILED",
-				"message": "Failed to create or update products",
-			},
-		})
+		requestLogger(c, h.logger).Error("Failed to update product",
+			zap.String("store_id", storeID),
+			zap.String("external_id", externalID),
+			zap.Error(err))
+		writeStoreError(c, err, "PRODUCT_NOT_FOUND", "Failed to update product")
 		return
 	}
 
-	h.logger.Info("Successfully pushed products",
-		zap.Int("products_created", result.Created),
-		zap.Int("products_updated", result.Updated),
-		zap.Int("variations_processed", result.VariationsProcessed),
-		zap.Int("store_products_processed", result.StoreProductsProcessed),
-		zap.Int("taxes_processed", result.TaxesProcessed))
+	// Name, description and images belong to the catalog product, which other stores share
+	domains := append(storeDomains(listing.StoreID, storeID),
+		cache.DomainProducts, cache.DomainSupermarket, cache.DomainPharmacy, cache.DomainSearch, cache.DomainLookup)
+	cache.InvalidateDomains(c.Request.Context(), h.cache, h.logger, domains...)
+	forgetPush(c.Request.Context(), h.cache, listing.StoreID)
+	h.webhooks.ProductsUpdated(c.Request.Context(), listing.StoreID, []string{externalID})
 
 	c.JSON(http.StatusOK, gin.H{
-		"status": "success",
-		"data": gin.H{
-			"products_created":         result.Created,
-			"products_updated":         result.Updated,
-			"variations_processed":     result.VariationsProcessed,
-			"store_products_processed": result.StoreProductsProcessed,
-			"taxes_processed":          result.TaxesProcessed,
-		},
-		"message": "Products pushed successfully",
+		"status":  "success",
+		"data":    listing,
+		"message": "Product updated successfully",
 	})
 }
+
+// pushedDomains returns the cache domains a push may have changed
+// Product data is shared across stores through matching, so catalog-wide
+// namespaces are cleared along with everything cached for this store
+func pushedDomains(result *repository.UpsertResult, req PushProductsRequest) []string {
+	return append(storeDomains(result.StoreID, req.StoreDetails.StoreID),
+		cache.DomainProducts, cache.DomainSupermarket, cache.DomainPharmacy, cache.DomainSearch, cache.DomainCategories, cache.DomainLookup,
+		cache.DomainNearby)
+}
+
+// pushedListings returns the ERP external IDs of a push's store products
+// Those of products that failed, or of chunks that didn't commit, are included: their
+// events carry the listings' state as read after the push, so they report nothing false
+func pushedListings(storeProducts []repository.StoreProductInput) []string {
+	ids := make([]string, len(storeProducts))
+	for i, sp := range storeProducts {
+		ids[i] = sp.ExternalProductID
+	}
+	return ids
+}
+
+// savedProducts returns the ERP external IDs of the products a push saved; unlike
+// pushedListings it leaves out those that failed or weren't committed, as webhook events
+// carry no state to correct them by
+func savedProducts(result *repository.UpsertResult) []string {
+	ids := make([]string, 0, len(result.Matches))
+	for _, match := range result.Matches {
+		ids = append(ids, match.ExternalProductID)
+	}
+	return ids
+}
+
+// pushSummary reports a push's committed counts and per-chunk progress
+func pushSummary(result *repository.UpsertResult) gin.H {
+	chunks := make([]gin.H, len(result.Chunks))
+	productsCommitted := 0
+	for i, chunk := range result.Chunks {
+		productsCommitted += chunk.Products - chunk.Failed
+		chunks[i] = gin.H{
+			"index":            chunk.Index,
+			"products":         chunk.Products,
+			"products_created": chunk.Created,
+			"products_updated": chunk.Updated,
+			"products_failed":  chunk.Failed,
+			"duration_ms":      chunk.Duration.Milliseconds(),
+		}
+	}
+
+	return gin.H{
+		"products_created":           result.Created,
+		"products_updated":           result.Updated,
+		"variations_processed":       result.VariationsProcessed,
+		"store_products_processed":   result.StoreProductsProcessed,
+		"taxes_processed":            result.TaxesProcessed,
+		"products_committed":         productsCommitted,
+		"chunks_committed":           len(result.Chunks),
+		"chunks_total":               result.TotalChunks,
+		"chunks":                     chunks,
+		"products_failed":            len(result.Failures),
+		"failures":                   pushFailures(result.Failures),
+		"store_products_deactivated": result.Deactivated,
+	}
+}
+
+// dryRunSummary reports what a rolled-back push would have done, product by product
+func dryRunSummary(result *repository.UpsertResult) gin.H {
+	matches := make([]gin.H, len(result.Matches))
+	for i, m := range result.Matches {
+		match := gin.H{
+			"index":       m.Index,
+			"external_id": m.ExternalProductID,
+			"action":      m.Action,
+		}
+		if m.Action == "update" {
+			match["product_id"] = m.ProductID
+			match["match_type"] = m.MatchType
+			match["confidence"] = m.Confidence
+		}
+		matches[i] = match
+	}
+
+	return gin.H{
+		"dry_run":                    true,
+		"products_created":           result.Created,
+		"products_updated":           result.Updated,
+		"variations_processed":       result.VariationsProcessed,
+		"store_products_processed":   result.StoreProductsProcessed,
+		"taxes_processed":            result.TaxesProcessed,
+		"matches":                    matches,
+		"products_failed":            len(result.Failures),
+		"failures":                   pushFailures(result.Failures),
+		"store_products_deactivated": result.Deactivated,
+	}
+}
+
+// pushFailures renders skipped products for a push response
+func pushFailures(failures []repository.PushFailure) []gin.H {
+	out := make([]gin.H, len(failures))
+	for i, f := range failures {
+		out[i] = gin.H{
+			"index":       f.Index,
+			"external_id": f.ExternalProductID,
+			"reason":      f.Reason,
+		}
+		if len(f.Errors) > 0 {
+			out[i]["errors"] = f.Errors
+		}
+	}
+	return out
+}
+
+// validateProducts checks each product's binding rules individually
+// Returns the valid products, their payload positions and a failure for each invalid one
+func validateProducts(products []Product) ([]Product, []int, []repository.PushFailure) {
+	var valid []Product
+	var kept []int
+	var failures []repository.PushFailure
+	for i, prod := range products {
+		if err := binding.Validator.ValidateStruct(prod); err != nil {
+			failures = append(failures, invalidProduct(i, prod.ID, err))
+			continue
+		}
+		valid = append(valid, prod)
+		kept = append(kept, i)
+	}
+	return valid, kept, failures
+}
+
+// invalidProduct is the failure of the product at index, which failed validation with err
+func invalidProduct(index int, externalID string, err error) repository.PushFailure {
+	problems := fieldErrors(err)
+	return repository.PushFailure{
+		Index:             index,
+		ExternalProductID: externalID,
+		Reason:            summarizeFieldErrors(problems),
+		Errors:            problems,
+	}
+}
+
+// invalidProductsError refuses a push whose products failed validation, listing every
+// invalid field by its path in the payload
+func invalidProductsError(invalid []repository.PushFailure) *PushError {
+	var problems []repository.FieldError
+	for _, f := range invalid {
+		for _, problem := range f.Errors {
+			problem.Field = joinPath(fmt.Sprintf("products[%d]", f.Index), problem.Field)
+			problems = append(problems, problem)
+		}
+	}
+	return &PushError{
+		StatusCode: http.StatusBadRequest,
+		Code:       "INVALID_INPUT",
+		Message:    summarizeFieldErrors(problems),
+		Errors:     problems,
+	}
+}
+
+// mergePushFailures combines validation failures with save failures, whose indexes are
+// positions among the kept products, into one list ordered by payload position
+func mergePushFailures(invalid, failed []repository.PushFailure, kept []int) []repository.PushFailure {
+	merged := append([]repository.PushFailure{}, invalid...)
+	for _, f := range failed {
+		f.Index = kept[f.Index]
+		merged = append(merged, f)
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i].Index < merged[j].Index })
+	return merged
+}
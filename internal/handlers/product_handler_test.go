@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestUpdateProduct_InvalidRequests(t *testing.T) {
+	h := &ProductHandler{}
+
+	tests := []struct {
+		name        string
+		target      string
+		body        string
+		wantMessage string
+	}{
+		{"missing store", "/products/P1", `{"price":10}`, "store_id is required"},
+		{"blank name", "/products/P1?store_id=S1", `{"name":" "}`, "name must not be blank"},
+		{"zero price", "/products/P1?store_id=S1", `{"price":0}`, "price"},
+		{"invalid image", "/products/P1?store_id=S1", `{"images":["https://example.com/a.jpg","not a url"]}`, "images[1]"},
+		{"malformed body", "/products/P1?store_id=S1", `{"price":`, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(t, h.UpdateProduct, http.MethodPatch, "/products/:external_id", tt.target, tt.body)
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want 400: %s", w.Code, w.Body.String())
+			}
+			resp := decodeError(t, w)
+			if resp.Error.Code != "INVALID_INPUT" || !strings.Contains(resp.Error.Message, tt.wantMessage) {
+				t.Errorf("error = %s %q, want INVALID_INPUT mentioning %q", resp.Error.Code, resp.Error.Message, tt.wantMessage)
+			}
+		})
+	}
+}
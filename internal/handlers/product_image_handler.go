@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/yourusername/supabase-redis-middleware/internal/cache"
+	"github.com/yourusername/supabase-redis-middleware/internal/i18n"
+	"github.com/yourusername/supabase-redis-middleware/internal/repository"
+	"github.com/yourusername/supabase-redis-middleware/internal/service"
+	"go.uber.org/zap"
+)
+
+// multipartOverhead is the room left in an upload's body for its multipart headers
+const multipartOverhead = 64 << 10
+
+// imageExtensions maps the image types accepted for upload to their file extensions
+var imageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/webp": ".webp",
+	"image/gif":  ".gif",
+}
+
+// ProductImageHandler uploads product images to Supabase Storage
+type ProductImageHandler struct {
+	pgRepo       *repository.PostgresRepository
+	storage      *repository.StorageClient
+	cache        cache.CacheService
+	maxUpload    int64
+	signedURLTTL time.Duration
+	logger       *zap.Logger
+}
+
+func NewProductImageHandler(pgRepo *repository.PostgresRepository, storage *repository.StorageClient, cacheService cache.CacheService, maxUpload int64, signedURLTTL time.Duration, logger *zap.Logger) *ProductImageHandler {
+	return &ProductImageHandler{
+		pgRepo:       pgRepo,
+		storage:      storage,
+		cache:        cacheService,
+		maxUpload:    maxUpload,
+		signedURLTTL: signedURLTTL,
+		logger:       logger,
+	}
+}
+
+// productImageResponse is a product image with a signed URL when its bucket is private
+type productImageResponse struct {
+	*repository.ProductImage
+	SignedURL       string     `json:"signed_url,omitempty"`
+	SignedExpiresAt *time.Time `json:"signed_url_expires_at,omitempty"`
+}
+
+// UploadProductImage streams a multipart "file" part to the storage bucket and records
+// the image's URL in product_images
+// Query: primary=true makes the image the product's primary image; the first image always is
+func (h *ProductImageHandler) UploadProductImage(c *gin.Context) {
+	productID := c.Param("id")
+	if _, err := uuid.Parse(productID); err != nil {
+		invalidUUID(c, "id")
+		return
+	}
+	primary := false
+	if raw := c.Query("primary"); raw != "" {
+		var err error
+		if primary, err = strconv.ParseBool(raw); err != nil {
+			invalidInput(c, "primary must be true or false")
+			return
+		}
+	}
+	if h.storage == nil {
+		writeImageError(c, repository.NewConnectionError(errors.New("image storage is not configured")), "Image storage is unavailable")
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.maxUpload+multipartOverhead)
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		invalidInput(c, "body must be multipart/form-data with a file part")
+		return
+	}
+
+	// The file is streamed from the request to storage, never held in memory or on disk
+	var path string
+	for path == "" {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			invalidInput(c, "file is required")
+			return
+		}
+		if err != nil {
+			h.writeUploadError(c, err)
+			return
+		}
+		if part.FormName() != "file" {
+			part.Close()
+			continue
+		}
+
+		contentType := part.Header.Get("Content-Type")
+		ext, ok := imageExtensions[contentType]
+		if !ok {
+			invalidInput(c, "file must be a JPEG, PNG, WebP or GIF image")
+			return
+		}
+
+		path = productID + "/" + uuid.NewString() + ext
+		if err := h.storage.Upload(c.Request.Context(), path, contentType, part); err != nil {
+			requestLogger(c, h.logger).Error("Failed to upload product image",
+				zap.String("product_id", productID),
+				zap.String("path", path),
+				zap.Error(err))
+			h.writeUploadError(c, err)
+			return
+		}
+	}
+
+	image, err := h.pgRepo.AddProductImage(c.Request.Context(), productID, h.storage.ObjectURL(path), primary)
+	if err != nil {
+		requestLogger(c, h.logger).Error("Failed to record product image", zap.String("product_id", productID), zap.Error(err))
+		// The upload is removed so a failed request leaves no orphaned object
+		if removeErr := h.storage.Remove(c.Request.Context(), path); removeErr != nil {
+			requestLogger(c, h.logger).Warn("Failed to remove orphaned product image",
+				zap.String("path", path),
+				zap.Error(removeErr))
+		}
+		writeImageError(c, err, "Failed to record product image")
+		return
+	}
+
+	cache.InvalidateDomains(c.Request.Context(), h.cache, h.logger,
+		service.CatalogChangeDomains(repository.CatalogChange{Table: "products"})...)
+
+	resp, err := h.imageResponse(c, image, path)
+	if err != nil {
+		// The image is stored; only its signed URL is missing
+		requestLogger(c, h.logger).Warn("Failed to sign product image URL", zap.String("path", path), zap.Error(err))
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"data":    resp,
+		"message": "Product image uploaded successfully",
+	})
+}
+
+// GetProductImageSignedURL returns a fresh signed URL for an image in a private bucket
+func (h *ProductImageHandler) GetProductImageSignedURL(c *gin.Context) {
+	productID, imageID := c.Param("id"), c.Param("imageId")
+	if _, err := uuid.Parse(productID); err != nil {
+		invalidUUID(c, "id")
+		return
+	}
+	if _, err := uuid.Parse(imageID); err != nil {
+		invalidUUID(c, "imageId")
+		return
+	}
+	if h.storage == nil {
+		writeImageError(c, repository.NewConnectionError(errors.New("image storage is not configured")), "Image storage is unavailable")
+		return
+	}
+
+	image, err := h.pgRepo.GetProductImage(c.Request.Context(), productID, imageID)
+	if err != nil {
+		writeImageError(c, err, "Failed to get product image")
+		return
+	}
+	path, ok := h.storage.PathFromURL(image.ImageURL)
+	if !ok {
+		invalidInput(c, "image is not stored in the "+h.storage.Bucket()+" bucket")
+		return
+	}
+
+	resp, err := h.imageResponse(c, image, path)
+	if err != nil {
+		requestLogger(c, h.logger).Error("Failed to sign product image URL", zap.String("path", path), zap.Error(err))
+		writeImageError(c, err, "Failed to sign product image URL")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   resp,
+	})
+}
+
+// imageResponse adds a signed URL to image when the bucket is private
+func (h *ProductImageHandler) imageResponse(c *gin.Context, image *repository.ProductImage, path string) (productImageResponse, error) {
+	resp := productImageResponse{ProductImage: image}
+	if h.storage.Public() {
+		return resp, nil
+	}
+
+	expiresAt := time.Now().Add(h.signedURLTTL).UTC()
+	signedURL, err := h.storage.SignedURL(c.Request.Context(), path, h.signedURLTTL)
+	if err != nil {
+		return resp, err
+	}
+	resp.SignedURL, resp.SignedExpiresAt = signedURL, &expiresAt
+	return resp, nil
+}
+
+// writeUploadError writes the error for a failed upload, 413 when the body was too large
+func (h *ProductImageHandler) writeUploadError(c *gin.Context, err error) {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		writeError(c, http.StatusRequestEntityTooLarge, "FILE_TOO_LARGE", "file must be at most "+strconv.FormatInt(h.maxUpload, 10)+" bytes", i18n.Params{"limit": h.maxUpload})
+		return
+	}
+	writeImageError(c, err, "Failed to upload product image")
+}
+
+// writeImageError writes a repository or storage error for the image endpoints
+func writeImageError(c *gin.Context, err error, message string) {
+	status, code := http.StatusInternalServerError, "INTERNAL_ERROR"
+	var repoErr *repository.RepositoryError
+	if errors.As(err, &repoErr) {
+		switch repoErr.StatusCode {
+		case http.StatusNotFound:
+			status, code, message = http.StatusNotFound, "NOT_FOUND", repoErr.Message
+		case http.StatusConflict:
+			status, code, message = http.StatusConflict, "IMAGE_CONFLICT", repoErr.Message
+		case http.StatusBadRequest:
+			status, code, message = http.StatusBadRequest, "INVALID_INPUT", repoErr.Message
+		case http.StatusServiceUnavailable:
+			status, code = http.StatusServiceUnavailable, "SERVICE_UNAVAILABLE"
+		case http.StatusGatewayTimeout:
+			status, code = http.StatusGatewayTimeout, "TIMEOUT"
+		}
+	}
+
+	writeError(c, status, code, message, nil)
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+)
+
+// decodePushRequest decodes a push payload from r one array element at a time, then
+// validates it as ShouldBindJSON would. A json.Decoder reads a whole value before decoding
+// it, so decoding the payload at once would hold the raw body in memory next to the
+// products decoded from it; this way only one product's JSON is held at a time
+func decodePushRequest(r io.Reader) (PushProductsRequest, error) {
+	var req PushProductsRequest
+	dec := json.NewDecoder(r)
+
+	if err := expectDelim(dec, '{'); err != nil {
+		return req, err
+	}
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return req, err
+		}
+		// Object keys are always strings; like encoding/json, match them case-insensitively
+		field := token.(string)
+		switch strings.ToLower(field) {
+		case "products":
+			err = decodeArray(dec, &req.Products)
+		case "variations":
+			err = decodeArray(dec, &req.Variations)
+		case "store_products":
+			err = decodeArray(dec, &req.StoreProducts)
+		case "categories":
+			err = decodeArray(dec, &req.Categories)
+		case "taxes":
+			err = decodeArray(dec, &req.Taxes)
+		case "store_details":
+			err = dec.Decode(&req.StoreDetails)
+		case "sync_mode":
+			err = dec.Decode(&req.SyncMode)
+		default:
+			var skipped json.RawMessage
+			err = dec.Decode(&skipped)
+		}
+		if err != nil {
+			return req, atPath(field, err)
+		}
+	}
+	if err := expectDelim(dec, '}'); err != nil {
+		return req, err
+	}
+
+	return req, binding.Validator.ValidateStruct(req)
+}
+
+// decodeArray decodes a JSON array into items one element at a time; null leaves it nil
+func decodeArray[T any](dec *json.Decoder, items *[]T) error {
+	token, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if token == nil {
+		*items = nil
+		return nil
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return errors.New("must be an array")
+	}
+
+	*items = []T{}
+	for dec.More() {
+		var item T
+		if err := dec.Decode(&item); err != nil {
+			return atPath(fmt.Sprintf("[%d]", len(*items)), err)
+		}
+		*items = append(*items, item)
+	}
+	_, err = dec.Token()
+	return err
+}
+
+// expectDelim reads the next token, failing unless it is delim
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	token, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if token != delim {
+		return errors.New("body must be a JSON object")
+	}
+	return nil
+}
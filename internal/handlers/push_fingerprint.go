@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/yourusername/supabase-redis-middleware/internal/cache"
+	"github.com/yourusername/supabase-redis-middleware/internal/repository"
+	"go.uber.org/zap"
+)
+
+// pushFingerprintTTL bounds how long a push is remembered, and so how long writes made
+// directly to the database can go unnoticed by an identical retry
+const pushFingerprintTTL = 24 * time.Hour
+
+// pushFingerprint hashes a push payload as bound, so whitespace, key order and unknown
+// fields don't change it, together with the partial flag that changes how it is applied
+// Each element is encoded into the hash on its own, so no copy of a large push is built
+func pushFingerprint(req PushProductsRequest, partial bool) string {
+	hash := sha256.New()
+	enc := json.NewEncoder(hash)
+	// Encoding bound JSON back can't fail
+	_ = enc.Encode(partial)
+	_ = enc.Encode(req.StoreDetails)
+	_ = enc.Encode(req.SyncMode)
+	encodeEach(enc, req.Categories)
+	encodeEach(enc, req.Taxes)
+	encodeEach(enc, req.Products)
+	encodeEach(enc, req.Variations)
+	encodeEach(enc, req.StoreProducts)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// encodeEach encodes the number of items and then each of them, so where one list ends
+// and the next begins is part of the hash
+func encodeEach[T any](enc *json.Encoder, items []T) {
+	_ = enc.Encode(len(items))
+	for _, item := range items {
+		_ = enc.Encode(item)
+	}
+}
+
+// pushDomain is the cache domain of a store's push fingerprint. Its bare key holds the
+// fingerprint, so as a pattern it matches that key alone in every tenant
+func pushDomain(storeID string) string {
+	return cache.DomainPushes + ":" + storeID
+}
+
+// pushFingerprintKey is the cache key holding the fingerprint of a store's last applied push
+func pushFingerprintKey(cacheService cache.CacheService, storeID string) string {
+	return cacheService.GenerateKey(pushDomain(storeID), nil)
+}
+
+// lastPushFingerprint returns the fingerprint of a store's last applied push, or "" if it
+// isn't known
+func lastPushFingerprint(ctx context.Context, cacheService cache.CacheService, storeID string) string {
+	if cacheService == nil {
+		return ""
+	}
+	fingerprint, _ := cacheService.Get(ctx, pushFingerprintKey(cacheService, storeID))
+	return string(fingerprint)
+}
+
+// rememberPush records the fingerprint of a push that was applied in full
+func rememberPush(ctx context.Context, cacheService cache.CacheService, storeID, fingerprint string) {
+	if cacheService == nil {
+		return
+	}
+	_ = cacheService.Set(ctx, pushFingerprintKey(cacheService, storeID), []byte(fingerprint), pushFingerprintTTL)
+}
+
+// forgetPush clears the fingerprint of a store's last push after its data changed some
+// other way, so the next push is applied even if it is identical
+func forgetPush(ctx context.Context, cacheService cache.CacheService, storeID string) {
+	if cacheService == nil || storeID == "" {
+		return
+	}
+	_ = cacheService.Delete(ctx, pushFingerprintKey(cacheService, storeID))
+}
+
+// ForgetChangedPushes clears the push fingerprints made stale by a catalog change written
+// outside the service, which the handlers' own forgetPush calls never see. A store product
+// change clears its store's fingerprint for every tenant; a product change, or missed
+// notifications, clear them all. Changes the service made itself are ignored, since the
+// push that wrote them has just remembered its fingerprint
+// Call it from a context outside any tenant
+func ForgetChangedPushes(ctx context.Context, cacheService cache.CacheService, logger *zap.Logger, change repository.CatalogChange) {
+	if cacheService == nil || !change.External {
+		return
+	}
+	if change.Table != "store_products" || change.StoreID == "" {
+		cache.InvalidateDomains(ctx, cacheService, logger, cache.DomainPushes)
+		return
+	}
+	if _, err := cacheService.DeleteByPattern(ctx, pushDomain(change.StoreID)); err != nil {
+		logger.Warn("Failed to clear push fingerprint",
+			zap.String("store_id", change.StoreID),
+			zap.Error(err))
+	}
+}
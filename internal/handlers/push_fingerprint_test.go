@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"path"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/yourusername/supabase-redis-middleware/internal/cache"
+	"github.com/yourusername/supabase-redis-middleware/internal/repository"
+	"go.uber.org/zap"
+)
+
+// mapCache keeps entries in a map under their generated keys; methods the fingerprint
+// helpers don't use are left unimplemented
+type mapCache struct {
+	cache.CacheService
+	entries map[string][]byte
+}
+
+func newMapCache() *mapCache {
+	return &mapCache{entries: map[string][]byte{}}
+}
+
+func (m *mapCache) Get(ctx context.Context, key string) ([]byte, error) {
+	return m.entries[key], nil
+}
+
+func (m *mapCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	m.entries[key] = value
+	return nil
+}
+
+func (m *mapCache) Delete(ctx context.Context, key string) error {
+	delete(m.entries, key)
+	return nil
+}
+
+func (m *mapCache) DeleteByPattern(ctx context.Context, pattern string) (int64, error) {
+	var deleted int64
+	for key := range m.entries {
+		if ok, _ := path.Match(pattern, key); ok {
+			delete(m.entries, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func (m *mapCache) GenerateKey(domain string, params map[string]string) string {
+	return domain
+}
+
+// keys returns the cached keys in order
+func (m *mapCache) keys() []string {
+	keys := make([]string, 0, len(m.entries))
+	for key := range m.entries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// bindPush decodes a push body as the handler binds it
+func bindPush(t *testing.T, body string) PushProductsRequest {
+	t.Helper()
+	var req PushProductsRequest
+	if err := json.Unmarshal([]byte(body), &req); err != nil {
+		t.Fatalf("failed to decode push %s: %v", body, err)
+	}
+	return req
+}
+
+func TestPushFingerprint(t *testing.T) {
+	base := bindPush(t, `{"store_details":{"store_id":"S1","name":"Store"},"products":[{"id":"P1","sku":"A","name":"Milk","price":10},{"id":"P2","sku":"B","name":"Bread","price":5}]}`)
+	fingerprint := pushFingerprint(base, false)
+
+	tests := []struct {
+		name    string
+		body    string
+		partial bool
+		same    bool
+	}{
+		{
+			name: "whitespace, key order and unknown fields",
+			body: `{ "products": [ {"price": 10, "name": "Milk", "sku": "A", "id": "P1", "extra": true},
+				{"id": "P2", "sku": "B", "name": "Bread", "price": 5} ],
+				"store_details": {"name": "Store", "store_id": "S1"}, "erp_version": "2" }`,
+			same: true,
+		},
+		{
+			name:    "partial",
+			body:    `{"store_details":{"store_id":"S1","name":"Store"},"products":[{"id":"P1","sku":"A","name":"Milk","price":10},{"id":"P2","sku":"B","name":"Bread","price":5}]}`,
+			partial: true,
+		},
+		{
+			name: "changed price",
+			body: `{"store_details":{"store_id":"S1","name":"Store"},"products":[{"id":"P1","sku":"A","name":"Milk","price":11},{"id":"P2","sku":"B","name":"Bread","price":5}]}`,
+		},
+		{
+			name: "sync mode",
+			body: `{"store_details":{"store_id":"S1","name":"Store"},"sync_mode":"full","products":[{"id":"P1","sku":"A","name":"Milk","price":10},{"id":"P2","sku":"B","name":"Bread","price":5}]}`,
+		},
+		{
+			name: "product dropped",
+			body: `{"store_details":{"store_id":"S1","name":"Store"},"products":[{"id":"P1","sku":"A","name":"Milk","price":10}]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := pushFingerprint(bindPush(t, tt.body), tt.partial)
+			if (got == fingerprint) != tt.same {
+				t.Errorf("pushFingerprint() equal to the base push = %v, want %v", got == fingerprint, tt.same)
+			}
+		})
+	}
+}
+
+func TestRememberAndForgetPush(t *testing.T) {
+	ctx := context.Background()
+	c := newMapCache()
+
+	if got := lastPushFingerprint(ctx, c, "s1"); got != "" {
+		t.Errorf("lastPushFingerprint() before any push = %q, want none", got)
+	}
+	rememberPush(ctx, c, "s1", "abc")
+	rememberPush(ctx, c, "s2", "def")
+	if got := lastPushFingerprint(ctx, c, "s1"); got != "abc" {
+		t.Errorf("lastPushFingerprint() = %q, want abc", got)
+	}
+
+	forgetPush(ctx, c, "s1")
+	if got := lastPushFingerprint(ctx, c, "s1"); got != "" {
+		t.Errorf("lastPushFingerprint() after forgetPush() = %q, want none", got)
+	}
+	if got := lastPushFingerprint(ctx, c, "s2"); got != "def" {
+		t.Errorf("lastPushFingerprint() of another store = %q, want def", got)
+	}
+
+	// Without a cache nothing is remembered
+	rememberPush(ctx, nil, "s1", "abc")
+	forgetPush(ctx, nil, "s1")
+	if got := lastPushFingerprint(ctx, nil, "s1"); got != "" {
+		t.Errorf("lastPushFingerprint() without a cache = %q, want none", got)
+	}
+}
+
+func TestForgetChangedPushes(t *testing.T) {
+	tests := []struct {
+		name   string
+		change repository.CatalogChange
+		want   []string
+	}{
+		{
+			name:   "own store product change",
+			change: repository.CatalogChange{Table: "store_products", StoreID: "s1", Origin: "supabase-redis-middleware"},
+			want:   []string{"push:s1", "push:s2", "store:s1:cached"},
+		},
+		{
+			name:   "external store product change",
+			change: repository.CatalogChange{Table: "store_products", StoreID: "s1", External: true},
+			want:   []string{"push:s2", "store:s1:cached"},
+		},
+		{
+			name:   "external product change",
+			change: repository.CatalogChange{Table: "products", External: true},
+			want:   []string{"store:s1:cached"},
+		},
+		{
+			name:   "missed notifications",
+			change: repository.CatalogChange{External: true},
+			want:   []string{"store:s1:cached"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			c := newMapCache()
+			rememberPush(ctx, c, "s1", "abc")
+			rememberPush(ctx, c, "s2", "def")
+			_ = c.Set(ctx, "store:s1:cached", []byte("listing"), time.Minute)
+
+			ForgetChangedPushes(ctx, c, zap.NewNop(), tt.change)
+
+			got := c.keys()
+			if len(got) != len(tt.want) {
+				t.Fatalf("keys after ForgetChangedPushes() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("keys after ForgetChangedPushes() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
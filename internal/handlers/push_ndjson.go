@@ -0,0 +1,331 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/yourusername/supabase-redis-middleware/internal/cache"
+	"github.com/yourusername/supabase-redis-middleware/internal/i18n"
+	"github.com/yourusername/supabase-redis-middleware/internal/logger"
+	"github.com/yourusername/supabase-redis-middleware/internal/realtime"
+	"github.com/yourusername/supabase-redis-middleware/internal/repository"
+	"go.uber.org/zap"
+)
+
+// NDJSONContentType is the media type of pushes sent as a header line and a line per product
+const NDJSONContentType = "application/x-ndjson"
+
+const (
+	// ndjsonBatchSize is how many products of an NDJSON push are read before they are
+	// written, so the push holds no more than this many at once
+	ndjsonBatchSize = 500
+
+	// maxNDJSONLineSize bounds one line of an NDJSON push
+	maxNDJSONLineSize = 4 << 20
+)
+
+// PushHeader is the first line of an NDJSON push: a push without its products
+type PushHeader struct {
+	Categories   []Category   `json:"categories"`
+	Taxes        []Tax        `json:"taxes"`
+	StoreDetails StoreDetails `json:"store_details" binding:"required"`
+	// SyncMode "full" deactivates the store's listings missing from the push once every
+	// line is written; "incremental", the default, leaves them
+	SyncMode string `json:"sync_mode" binding:"omitempty,oneof=incremental full"`
+}
+
+// PushLine is a product line of an NDJSON push, with the product's variations and its
+// listing. Without store_product the listing is made from the product, as for a push
+// without store_products
+type PushLine struct {
+	Product
+	Variations   []Variation   `json:"variations"`
+	StoreProduct *StoreProduct `json:"store_product"`
+}
+
+// ndjsonPush is an NDJSON push being written a batch at a time
+type ndjsonPush struct {
+	h       *ProductHandler
+	storeID string // The store's ERP ID
+	stream  *repository.ProductPushStream
+
+	// The batch read but not yet written, with the position of each product in the push
+	products      []Product
+	variations    []Variation
+	storeProducts []StoreProduct
+	positions     []int
+
+	invalid []repository.PushFailure // Lines set aside in partial mode
+}
+
+// pushNDJSON applies an NDJSON push: a PushHeader line, then a PushLine per product. The
+// products are validated as they are read and written in batches of ndjsonBatchSize, so
+// memory doesn't grow with the catalog. Batches are committed as they are written: a push
+// stopped by an invalid line or a failed write reports what it committed, as a push that
+// fails part way does. Dry runs, which must hold the whole push, are refused
+func (h *ProductHandler) pushNDJSON(c *gin.Context, partial, dryRun bool) {
+	if dryRun {
+		invalidInput(c, "dry_run is not supported for NDJSON pushes; send the push as JSON")
+		return
+	}
+
+	scanner := bufio.NewScanner(c.Request.Body)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxNDJSONLineSize)
+	line := 0
+	next := func() ([]byte, bool) {
+		for scanner.Scan() {
+			line++
+			if text := bytes.TrimSpace(scanner.Bytes()); len(text) > 0 {
+				return text, true
+			}
+		}
+		return nil, false
+	}
+
+	text, ok := next()
+	if !ok {
+		if err := scanner.Err(); err != nil {
+			writePushOutcome(c, nil, ndjsonReadError(err, line), false, nil)
+			return
+		}
+		invalidInput(c, "body must start with a line of store_details")
+		return
+	}
+	var header PushHeader
+	err := json.Unmarshal(text, &header)
+	if err == nil {
+		err = binding.Validator.ValidateStruct(header)
+	}
+	if err != nil {
+		ctx := c.Request.Context()
+		problems := localizeFieldErrors(ctx, fieldErrors(err))
+		message := describeFieldErrors(ctx, problems)
+		invalidFields(c, i18n.T(ctx, "INVALID_INPUT.line", "line 1: "+message, i18n.Params{"line": 1, "message": message}), problems)
+		return
+	}
+	if !allowsExternalStore(c, header.StoreDetails.StoreID) {
+		return
+	}
+
+	ctx := c.Request.Context()
+	log := requestLogger(c, h.logger).With(zap.String("store_id", header.StoreDetails.StoreID))
+
+	// A streamed push can't be compared with the last until it has been read, so it is
+	// always applied
+	if storeID, err := h.pgRepo.StoreIDByExternalID(ctx, header.StoreDetails.StoreID); err == nil {
+		forgetPush(ctx, h.cache, storeID)
+	}
+	if err := h.savePushStore(ctx, toStoreInput(header.StoreDetails), toCategoryInputs(header.Categories), toTaxInputs(header.Taxes)); err != nil {
+		writePushOutcome(c, nil, err, false, nil)
+		return
+	}
+	stream, err := h.pgRepo.StartProductPush(ctx, header.StoreDetails.StoreID, repository.PushOptions{
+		PartialSuccess: partial,
+		FullSync:       header.SyncMode == "full",
+	})
+	if err != nil {
+		log.Error("Failed to start product push", zap.Error(err))
+		writePushOutcome(c, nil, &PushError{StatusCode: http.StatusInternalServerError, Code: "PRODUCT_UPSERT_FAILED", Message: "Failed to create or update products", Err: err}, false, nil)
+		return
+	}
+
+	push := &ndjsonPush{h: h, storeID: header.StoreDetails.StoreID, stream: stream}
+	read := 0
+	for {
+		text, ok := next()
+		if !ok {
+			break
+		}
+		position := read
+		read++
+
+		var item PushLine
+		err := json.Unmarshal(text, &item)
+		if err == nil {
+			err = validatePushLine(item)
+		}
+		if err != nil {
+			failure := invalidProduct(position, item.ID, err)
+			failure.Reason = fmt.Sprintf("line %d: %s", line, failure.Reason)
+			if !partial {
+				push.stop(c, &PushError{StatusCode: http.StatusBadRequest, Code: "INVALID_INPUT", Message: failure.Reason, Errors: failure.Errors, Err: err})
+				return
+			}
+			push.invalid = append(push.invalid, failure)
+			if item.ID != "" {
+				stream.Retain(item.ID)
+			}
+			continue
+		}
+
+		push.add(item, position)
+		if len(push.products) >= ndjsonBatchSize {
+			if err := push.flush(ctx); err != nil {
+				push.stop(c, &PushError{StatusCode: http.StatusInternalServerError, Code: "PRODUCT_UPSERT_INCOMPLETE", Message: "Push did not complete; the chunks listed in data were committed", Err: err})
+				return
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		push.stop(c, ndjsonReadError(err, line))
+		return
+	}
+	if header.SyncMode == "full" && read == 0 {
+		invalidInput(c, "a full sync must have at least one product line")
+		return
+	}
+
+	if err := push.flush(ctx); err != nil {
+		push.stop(c, &PushError{StatusCode: http.StatusInternalServerError, Code: "PRODUCT_UPSERT_INCOMPLETE", Message: "Push did not complete; the chunks listed in data were committed", Err: err})
+		return
+	}
+	result, err := stream.Finish(ctx)
+	push.mergeInvalid(result)
+	cache.InvalidateDomains(ctx, h.cache, h.logger, pushedDomains(result, PushProductsRequest{StoreDetails: header.StoreDetails})...)
+	if err != nil {
+		log.Error("Product push partially committed", zap.Error(err))
+		writePushOutcome(c, nil, &PushError{
+			StatusCode: http.StatusInternalServerError,
+			Code:       "PRODUCT_UPSERT_INCOMPLETE",
+			Message:    "Push did not complete; the chunks listed in data were committed",
+			Result:     result,
+			Err:        err,
+		}, false, nil)
+		return
+	}
+
+	log.Info("Successfully pushed products",
+		zap.Int("lines", line),
+		zap.Int("products_failed", len(result.Failures)),
+		zap.Int("products_created", result.Created),
+		zap.Int("products_updated", result.Updated),
+		zap.Int("store_products_deactivated", result.Deactivated))
+	writePushOutcome(c, &PushOutcome{StoreID: result.StoreID, Result: result}, nil, false, nil)
+}
+
+// validatePushLine checks a product line as a push's products are checked, and that its
+// variations belong to it
+func validatePushLine(item PushLine) error {
+	if err := binding.Validator.ValidateStruct(item); err != nil {
+		return err
+	}
+	for i, v := range item.Variations {
+		if v.ProductID != "" && v.ProductID != item.ID {
+			return atPath(fmt.Sprintf("variations[%d].product_id", i), fmt.Errorf("belongs to product %q, not this line's", v.ProductID))
+		}
+		v.ProductID = item.ID
+		if err := binding.Validator.ValidateStruct(v); err != nil {
+			return atPath(fmt.Sprintf("variations[%d]", i), err)
+		}
+	}
+	if sp := item.StoreProduct; sp != nil && sp.ProductID != "" && sp.ProductID != item.ID {
+		return atPath("store_product.product_id", fmt.Errorf("belongs to product %q, not this line's", sp.ProductID))
+	}
+	return nil
+}
+
+// add queues a valid product line at position for the next batch
+func (p *ndjsonPush) add(item PushLine, position int) {
+	p.products = append(p.products, item.Product)
+	for _, v := range item.Variations {
+		v.ProductID = item.ID
+		p.variations = append(p.variations, v)
+	}
+	if item.StoreProduct != nil {
+		sp := *item.StoreProduct
+		sp.ProductID = item.ID
+		p.storeProducts = append(p.storeProducts, sp)
+	} else {
+		p.storeProducts = append(p.storeProducts, StoreProduct{ProductID: item.ID, Price: item.Price, IsInStock: true, Taxes: item.Taxes})
+	}
+	p.positions = append(p.positions, position)
+}
+
+// flush writes the queued batch, then publishes its listings' changes. The matches and
+// failures it adds to the push's result are given the products' positions in the push,
+// which differ from the order they were written in once lines have been set aside
+func (p *ndjsonPush) flush(ctx context.Context) error {
+	if len(p.products) == 0 {
+		return nil
+	}
+	result := p.stream.Result()
+	matches, failures, written := len(result.Matches), len(result.Failures), 0
+	for _, chunk := range result.Chunks {
+		written += chunk.Products
+	}
+
+	storeProducts := toStoreProductInputs(p.storeID, p.storeProducts)
+	err := p.stream.Write(ctx, toProductInputs(p.products), toVariationInputs(p.variations), storeProducts)
+	for i := matches; i < len(result.Matches); i++ {
+		result.Matches[i].Index = p.positions[result.Matches[i].Index-written]
+	}
+	for i := failures; i < len(result.Failures); i++ {
+		result.Failures[i].Index = p.positions[result.Failures[i].Index-written]
+	}
+
+	saved := make([]string, 0, len(result.Matches)-matches)
+	for _, match := range result.Matches[matches:] {
+		saved = append(saved, match.ExternalProductID)
+	}
+	p.h.events.ListingsChanged(ctx, realtime.EventProductsPushed, result.StoreID, pushedListings(storeProducts))
+	p.h.webhooks.ProductsUpdated(ctx, result.StoreID, saved)
+
+	p.products, p.variations, p.storeProducts, p.positions = p.products[:0], p.variations[:0], p.storeProducts[:0], p.positions[:0]
+	return err
+}
+
+// mergeInvalid adds the lines set aside to result's failures, in push order
+func (p *ndjsonPush) mergeInvalid(result *repository.UpsertResult) {
+	if len(p.invalid) == 0 {
+		return
+	}
+	result.Failures = append(result.Failures, p.invalid...)
+	slices.SortFunc(result.Failures, func(a, b repository.PushFailure) int { return a.Index - b.Index })
+	p.invalid = nil
+}
+
+// stop ends a push that can't go on with pushErr, reporting what it committed, if anything
+func (p *ndjsonPush) stop(c *gin.Context, pushErr *PushError) {
+	ctx := c.Request.Context()
+	result := p.stream.Result()
+	p.mergeInvalid(result)
+	if len(result.Chunks) == 0 {
+		requestLogger(c, p.h.logger).Warn("Product push stopped", zap.String("store_id", p.storeID), zap.Error(pushErr.Err))
+		if pushErr.Code == "PRODUCT_UPSERT_INCOMPLETE" {
+			pushErr.Code, pushErr.Message = "PRODUCT_UPSERT_FAILED", "Failed to create or update products"
+		}
+		writePushOutcome(c, nil, pushErr, false, nil)
+		return
+	}
+
+	logger.FromContext(ctx, p.h.logger).Error("Product push partially committed", zap.String("store_id", p.storeID), zap.Error(pushErr.Err))
+	cache.InvalidateDomains(ctx, p.h.cache, p.h.logger, pushedDomains(result, PushProductsRequest{StoreDetails: StoreDetails{StoreID: p.storeID}})...)
+	if pushErr.Code != "PRODUCT_UPSERT_INCOMPLETE" {
+		pushErr.Message += "; the chunks listed in data were committed"
+	}
+	pushErr.Result = result
+	writePushOutcome(c, nil, pushErr, false, nil)
+}
+
+// ndjsonReadError is the error reported for an NDJSON push whose body couldn't be read
+// past line
+func ndjsonReadError(err error, line int) *PushError {
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxBytesErr):
+		return &PushError{StatusCode: http.StatusRequestEntityTooLarge, Code: "PAYLOAD_TOO_LARGE", Message: "Request body must be at most " + strconv.FormatInt(maxBytesErr.Limit, 10) + " bytes", Err: err}
+	case errors.Is(err, bufio.ErrTooLong):
+		return &PushError{StatusCode: http.StatusBadRequest, Code: "INVALID_INPUT", Message: fmt.Sprintf("line %d is longer than %d bytes", line+1, maxNDJSONLineSize), Err: err}
+	default:
+		return &PushError{StatusCode: http.StatusBadRequest, Code: "INVALID_INPUT", Message: "Failed to read request body", Err: err}
+	}
+}
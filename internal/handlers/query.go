@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/supabase-redis-middleware/internal/repository"
+)
+
+// listQueryKey is the gin context key ListQueryMiddleware stores a request's ListQuery under
+const listQueryKey = "list_query"
+
+// QueryRules are the list query parameters a route accepts
+type QueryRules struct {
+	DefaultLimit int      // Page size without limit; defaultProductPageSize if 0
+	MaxLimit     int      // Largest limit accepted; maxProductPageSize if 0
+	Cursor       bool     // Accepts cursor, for routes paged by keyset; others take offset only
+	Sorts        []string // Fields sort may name; a route with none refuses sort
+	Filters      bool     // Reads the catalog listing filters
+}
+
+// ListQuery is a list request's parsed query parameters
+type ListQuery struct {
+	Pagination repository.Pagination
+	Sort       []repository.OrderBy     // Keys in the order given; empty for the route's own order
+	Filter     repository.ListingFilter // Zero unless the route's rules read filters
+}
+
+// ListQueryMiddleware parses the query of a list route by rules before its handler runs,
+// which reads it with listQuery. Invalid parameters are a 400 and the handler isn't run
+func ListQueryMiddleware(rules QueryRules) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		query, ok := rules.parse(c)
+		if !ok {
+			c.Abort()
+			return
+		}
+		c.Set(listQueryKey, query)
+		c.Next()
+	}
+}
+
+// listQuery returns the ListQuery ListQueryMiddleware parsed for the request. A route
+// without the middleware is parsed with the zero rules - a default page, by offset, with
+// no sort or filters - writing a 400 and returning false on invalid input
+func listQuery(c *gin.Context) (ListQuery, bool) {
+	if v, ok := c.Get(listQueryKey); ok {
+		if query, ok := v.(ListQuery); ok {
+			return query, true
+		}
+	}
+	return QueryRules{}.parse(c)
+}
+
+// parse reads limit, offset, cursor, include_total, sort and, if the rules read them, the
+// listing filters, writing a 400 on invalid input
+func (r QueryRules) parse(c *gin.Context) (ListQuery, bool) {
+	var query ListQuery
+	var ok bool
+	if query.Pagination, ok = r.pagination(c); !ok {
+		return query, false
+	}
+	if query.Sort, ok = r.sort(c); !ok {
+		return query, false
+	}
+	if r.Filters {
+		if query.Filter, ok = parseListingFilter(c); !ok {
+			return query, false
+		}
+	}
+	return query, true
+}
+
+// pagination reads limit/offset/cursor query parameters, writing a 400 on invalid input
+// cursor is the next_cursor of a previous page and replaces offset;
+// include_total=true adds metadata.total_count
+func (r QueryRules) pagination(c *gin.Context) (repository.Pagination, bool) {
+	defaultLimit, maxLimit := r.DefaultLimit, r.MaxLimit
+	if maxLimit == 0 {
+		maxLimit = maxProductPageSize
+	}
+	if defaultLimit == 0 {
+		defaultLimit = min(defaultProductPageSize, maxLimit)
+	}
+	pagination := repository.Pagination{Limit: defaultLimit}
+
+	if limit := c.Query("limit"); limit != "" {
+		v, err := strconv.Atoi(limit)
+		if err != nil || v < 1 || v > maxLimit {
+			invalidInput(c, "limit must be between 1 and "+strconv.Itoa(maxLimit))
+			return pagination, false
+		}
+		pagination.Limit = v
+	}
+
+	if offset := c.Query("offset"); offset != "" {
+		v, err := strconv.Atoi(offset)
+		if err != nil || v < 0 {
+			invalidInput(c, "offset must be a non-negative integer")
+			return pagination, false
+		}
+		pagination.Offset = v
+	}
+
+	// An empty cursor starts paging by cursor from the newest listing
+	if cursor, ok := c.GetQuery("cursor"); ok {
+		if !r.Cursor {
+			invalidInput(c, "cursor is not supported by this endpoint; use offset")
+			return pagination, false
+		}
+		if pagination.Offset > 0 {
+			invalidInput(c, "cursor and offset cannot be combined")
+			return pagination, false
+		}
+		pagination.Keyset = true
+		if cursor != "" {
+			after, err := repository.DecodeCursor(cursor)
+			if err != nil {
+				invalidInput(c, "cursor is invalid")
+				return pagination, false
+			}
+			pagination.After = after
+		}
+	}
+
+	var ok bool
+	if pagination.IncludeTotal, ok = optionalBool(c, "include_total"); !ok {
+		return pagination, false
+	}
+
+	return pagination, true
+}
+
+// sort reads sort, a comma-separated list of fields, each descending if prefixed with "-"
+// (sort=-price,name), writing a 400 for a field the route can't sort by or one given twice
+func (r QueryRules) sort(c *gin.Context) ([]repository.OrderBy, bool) {
+	raw := c.Query("sort")
+	if raw == "" {
+		return nil, true
+	}
+	if len(r.Sorts) == 0 {
+		invalidInput(c, "sort is not supported by this endpoint")
+		return nil, false
+	}
+
+	var keys []repository.OrderBy
+	for _, field := range strings.Split(raw, ",") {
+		key := repository.OrderBy{Column: strings.TrimSpace(field), Direction: repository.SortAsc}
+		if rest, desc := strings.CutPrefix(key.Column, "-"); desc {
+			key.Column, key.Direction = rest, repository.SortDesc
+		}
+		if !slices.Contains(r.Sorts, key.Column) {
+			invalidInput(c, "sort must be a comma-separated list of "+strings.Join(r.Sorts, ", ")+", each optionally prefixed with -")
+			return nil, false
+		}
+		if slices.ContainsFunc(keys, func(k repository.OrderBy) bool { return k.Column == key.Column }) {
+			invalidInput(c, "sort names "+key.Column+" more than once")
+			return nil, false
+		}
+		keys = append(keys, key)
+	}
+	return keys, true
+}
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/supabase-redis-middleware/internal/repository"
+)
+
+// parseQuery parses the query string of a list request by rules, returning the recorded
+// response with the result
+func parseQuery(rules QueryRules, rawQuery string) (ListQuery, bool, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/list?"+rawQuery, nil)
+	query, ok := rules.parse(c)
+	return query, ok, w
+}
+
+func TestQueryRulesPagination(t *testing.T) {
+	cursor := repository.Cursor{CreatedAt: time.Date(2026, 2, 1, 10, 0, 0, 0, time.UTC), ID: "sp-9"}
+	keyset := QueryRules{Cursor: true}
+
+	tests := []struct {
+		name  string
+		rules QueryRules
+		query string
+		want  repository.Pagination
+	}{
+		{name: "defaults", query: "", want: repository.Pagination{Limit: defaultProductPageSize}},
+		{name: "route default", rules: QueryRules{DefaultLimit: 50, MaxLimit: 200}, want: repository.Pagination{Limit: 50}},
+		{name: "default capped by a smaller max", rules: QueryRules{MaxLimit: 10}, want: repository.Pagination{Limit: 10}},
+		{name: "limit and offset", query: "limit=5&offset=40", want: repository.Pagination{Limit: 5, Offset: 40}},
+		{name: "largest limit", query: "limit=100", want: repository.Pagination{Limit: maxProductPageSize}},
+		{name: "route's largest limit", rules: QueryRules{MaxLimit: 500}, query: "limit=500", want: repository.Pagination{Limit: 500}},
+		{name: "empty values", query: "limit=&offset=", want: repository.Pagination{Limit: defaultProductPageSize}},
+		{name: "include total", query: "include_total=true", want: repository.Pagination{Limit: defaultProductPageSize, IncludeTotal: true}},
+		{name: "empty cursor", rules: keyset, query: "cursor=", want: repository.Pagination{Limit: defaultProductPageSize, Keyset: true}},
+		{name: "empty cursor with offset 0", rules: keyset, query: "cursor=&offset=0", want: repository.Pagination{Limit: defaultProductPageSize, Keyset: true}},
+		{name: "cursor", rules: keyset, query: "limit=3&cursor=" + cursor.Encode(), want: repository.Pagination{Limit: 3, Keyset: true, After: &cursor}},
+		{name: "no cursor", rules: keyset, query: "offset=20", want: repository.Pagination{Limit: defaultProductPageSize, Offset: 20}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, ok, w := parseQuery(tt.rules, tt.query)
+			if !ok {
+				t.Fatalf("parse() failed: %s", w.Body.String())
+			}
+			got := query.Pagination
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parse() pagination = %+v, want %+v", got, tt.want)
+			}
+			if got.KeysetOrder() != tt.want.Keyset {
+				t.Errorf("KeysetOrder() = %v, want %v", got.KeysetOrder(), tt.want.Keyset)
+			}
+		})
+	}
+}
+
+func TestQueryRules_InvalidValues(t *testing.T) {
+	sorted := QueryRules{Sorts: []string{"name", "price"}}
+
+	tests := []struct {
+		name        string
+		rules       QueryRules
+		query       string
+		wantMessage string
+	}{
+		{"limit 0", QueryRules{}, "limit=0", "limit must be between 1 and 100"},
+		{"limit over the max", QueryRules{}, "limit=101", "limit must be between 1 and 100"},
+		{"limit over the route's max", QueryRules{MaxLimit: 25}, "limit=26", "limit must be between 1 and 25"},
+		{"limit not a number", QueryRules{}, "limit=ten", "limit must be between"},
+		{"negative offset", QueryRules{}, "offset=-1", "offset must be a non-negative integer"},
+		{"offset not a number", QueryRules{}, "offset=1.5", "offset must be a non-negative integer"},
+		{"cursor on an offset route", QueryRules{}, "cursor=", "cursor is not supported by this endpoint"},
+		{"cursor with offset", QueryRules{Cursor: true}, "cursor=&offset=20", "cursor and offset cannot be combined"},
+		{"invalid cursor", QueryRules{Cursor: true}, "cursor=not-a-cursor", "cursor is invalid"},
+		{"include_total not a boolean", QueryRules{}, "include_total=yes", "include_total must be true or false"},
+		{"sort on a route without sorts", QueryRules{}, "sort=name", "sort is not supported by this endpoint"},
+		{"unknown sort field", sorted, "sort=-rating", "sort must be a comma-separated list of name, price"},
+		{"sort field given twice", sorted, "sort=price,-price", "sort names price more than once"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, ok, w := parseQuery(tt.rules, tt.query)
+			if ok || w.Code != http.StatusBadRequest {
+				t.Fatalf("parse() = %v with status %d, want a 400", ok, w.Code)
+			}
+			resp := decodeError(t, w)
+			if resp.Error.Code != "INVALID_INPUT" || !strings.Contains(resp.Error.Message, tt.wantMessage) {
+				t.Errorf("error = %s %q, want INVALID_INPUT mentioning %q", resp.Error.Code, resp.Error.Message, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestQueryRulesSort(t *testing.T) {
+	rules := QueryRules{Sorts: []string{"name", "price", "created_at"}}
+
+	query, ok, w := parseQuery(rules, "sort=-price,%20name")
+	if !ok {
+		t.Fatalf("parse() failed: %s", w.Body.String())
+	}
+	want := []repository.OrderBy{
+		{Column: "price", Direction: repository.SortDesc},
+		{Column: "name", Direction: repository.SortAsc},
+	}
+	if !reflect.DeepEqual(query.Sort, want) {
+		t.Errorf("parse() sort = %+v, want %+v", query.Sort, want)
+	}
+
+	if query, _, _ := parseQuery(rules, ""); query.Sort != nil {
+		t.Errorf("parse() sort without sort = %+v, want the route's own order", query.Sort)
+	}
+}
+
+func TestListQueryMiddleware(t *testing.T) {
+	var got ListQuery
+	handler := func(c *gin.Context) {
+		got, _ = listQuery(c)
+		c.Status(http.StatusNoContent)
+	}
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/list", ListQueryMiddleware(QueryRules{DefaultLimit: 7, Cursor: true}), handler)
+	router.GET("/plain", handler)
+
+	tests := []struct {
+		name       string
+		target     string
+		wantStatus int
+		want       repository.Pagination
+	}{
+		{name: "parsed by the route's rules", target: "/list?cursor=", wantStatus: http.StatusNoContent, want: repository.Pagination{Limit: 7, Keyset: true}},
+		{name: "invalid query stops the handler", target: "/list?limit=0", wantStatus: http.StatusBadRequest},
+		{name: "route without the middleware", target: "/plain?offset=3", wantStatus: http.StatusNoContent, want: repository.Pagination{Limit: defaultProductPageSize, Offset: 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got = ListQuery{}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if !reflect.DeepEqual(got.Pagination, tt.want) {
+				t.Errorf("listQuery() pagination = %+v, want %+v", got.Pagination, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,255 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/yourusername/supabase-redis-middleware/internal/realtime"
+	"github.com/yourusername/supabase-redis-middleware/internal/repository"
+	"go.uber.org/zap"
+	"golang.org/x/net/websocket"
+)
+
+// realtimeWriteTimeout bounds each write to a WebSocket or event stream client
+const realtimeWriteTimeout = 10 * time.Second
+
+// RealtimeHandler streams stores' changes to WebSocket and Server-Sent Events clients
+type RealtimeHandler struct {
+	pgRepo       *repository.PostgresRepository
+	hub          *realtime.Hub
+	events       *realtime.Publisher
+	pingInterval time.Duration
+	logger       *zap.Logger
+}
+
+func NewRealtimeHandler(pgRepo *repository.PostgresRepository, hub *realtime.Hub, events *realtime.Publisher, pingInterval time.Duration, logger *zap.Logger) *RealtimeHandler {
+	return &RealtimeHandler{
+		pgRepo:       pgRepo,
+		hub:          hub,
+		events:       events,
+		pingInterval: pingInterval,
+		logger:       logger,
+	}
+}
+
+// StoreEvents upgrades to a WebSocket sending the store's stock, price and availability
+// changes as they are written, as JSON text messages
+// GET /ws/stores/:id
+// Clients needn't send anything. A client too slow to take its events is disconnected,
+// and should reconnect and re-read what it shows, as it should after any disconnection
+func (h *RealtimeHandler) StoreEvents(c *gin.Context) {
+	storeID := c.Param("id")
+	if _, err := uuid.Parse(storeID); err != nil {
+		invalidUUID(c, "id")
+		return
+	}
+	if !realtime.IsUpgrade(c.Request) {
+		invalidInput(c, "Connect with a WebSocket client")
+		return
+	}
+	if !h.storeExists(c, storeID) {
+		return
+	}
+
+	client, ok := h.subscribe(c, storeID)
+	if !ok {
+		return
+	}
+	defer h.hub.Unsubscribe(client)
+
+	// Any origin may connect, as any may read the API
+	server := websocket.Server{Handler: func(ws *websocket.Conn) {
+		h.serve(ws, client)
+	}}
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+// serve writes the client's events to ws, pinging it while idle, until either goes away
+func (h *RealtimeHandler) serve(ws *websocket.Conn, client *realtime.Client) {
+	defer ws.Close()
+
+	// Reading answers the client's pings and notices when it closes the connection
+	gone := make(chan struct{})
+	go func() {
+		defer close(gone)
+		var message []byte
+		for websocket.Message.Receive(ws, &message) == nil {
+		}
+	}()
+
+	ping := time.NewTicker(h.pingInterval)
+	defer ping.Stop()
+	for {
+		var err error
+		select {
+		case event := <-client.Events():
+			ws.SetWriteDeadline(time.Now().Add(realtimeWriteTimeout))
+			err = websocket.Message.Send(ws, string(event))
+		case <-ping.C:
+			ws.SetWriteDeadline(time.Now().Add(realtimeWriteTimeout))
+			ws.PayloadType = websocket.PingFrame
+			_, err = ws.Write(nil)
+			ws.PayloadType = websocket.TextFrame
+		case <-client.Closed():
+			return
+		case <-gone:
+			return
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// StoreStatusEvents streams the store's open/closed status, delivery terms and listing
+// availability changes as Server-Sent Events, each with an id, its type as event, and a
+// JSON-encoded StatusEvent as data
+// GET /api/v1/stores/:id/events
+// Header: Last-Event-ID (or query last_event_id) first sends the events after that one
+// still in the store's history. Comments are sent while idle to keep the stream open
+func (h *RealtimeHandler) StoreStatusEvents(c *gin.Context) {
+	storeID := c.Param("id")
+	if _, err := uuid.Parse(storeID); err != nil {
+		invalidUUID(c, "id")
+		return
+	}
+	if !realtime.IsEventStream(c.Request) {
+		invalidInput(c, "Accept text/event-stream")
+		return
+	}
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("last_event_id")
+	}
+	if lastEventID != "" && !realtime.ValidEventID(lastEventID) {
+		invalidInput(c, "Last-Event-ID must be the id of an event")
+		return
+	}
+	if !h.storeExists(c, storeID) {
+		return
+	}
+
+	// Subscribing before reading the history means no event falls between the two;
+	// events in both are sent once, as only those after the last sent are
+	client, ok := h.subscribe(c, realtime.StatusTopic(storeID))
+	if !ok {
+		return
+	}
+	defer h.hub.Unsubscribe(client)
+
+	ctx := c.Request.Context()
+	log := requestLogger(c, h.logger)
+	var missed []realtime.StatusEvent
+	if lastEventID != "" {
+		var err error
+		missed, err = h.events.StatusEventsAfter(ctx, storeID, lastEventID)
+		if err != nil {
+			log.Warn("Failed to read store status history", zap.String("store_id", storeID), zap.Error(err))
+		}
+	}
+
+	header := c.Writer.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	stream := &eventStream{writer: c.Writer, controller: http.NewResponseController(c.Writer)}
+	if err := stream.comment("connected"); err != nil {
+		return
+	}
+	for _, event := range missed {
+		if err := stream.send(event); err != nil {
+			return
+		}
+		lastEventID = event.ID
+	}
+
+	keepAlive := time.NewTicker(h.pingInterval)
+	defer keepAlive.Stop()
+	for {
+		var err error
+		select {
+		case payload := <-client.Events():
+			var event realtime.StatusEvent
+			if json.Unmarshal(payload, &event) != nil || !realtime.ValidEventID(event.ID) {
+				continue
+			}
+			if lastEventID != "" && !realtime.EventIDAfter(event.ID, lastEventID) {
+				continue
+			}
+			err = stream.send(event)
+			lastEventID = event.ID
+		case <-keepAlive.C:
+			err = stream.comment("keep-alive")
+		case <-client.Closed():
+			return
+		case <-ctx.Done():
+			return
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// storeExists reports whether store storeID exists, writing a 404 or 500 if not
+func (h *RealtimeHandler) storeExists(c *gin.Context, storeID string) bool {
+	stores, err := h.pgRepo.GetStoresByIDs(c.Request.Context(), []string{storeID})
+	if err != nil {
+		requestLogger(c, h.logger).Error("Failed to get store for events", zap.String("store_id", storeID), zap.Error(err))
+		writeError(c, http.StatusInternalServerError, "STORE_FETCH_FAILED", "Failed to get store", nil)
+		return false
+	}
+	if len(stores) == 0 {
+		writeError(c, http.StatusNotFound, "STORE_NOT_FOUND", "Store not found", nil)
+		return false
+	}
+	return true
+}
+
+// subscribe subscribes to topic's events, writing a 503 if the hub is full
+func (h *RealtimeHandler) subscribe(c *gin.Context, topic string) (*realtime.Client, bool) {
+	client, err := h.hub.Subscribe(topic)
+	if errors.Is(err, realtime.ErrTooManyClients) {
+		requestLogger(c, h.logger).Warn("Refusing store events connection", zap.String("topic", topic), zap.Error(err))
+		writeError(c, http.StatusServiceUnavailable, "TOO_MANY_CONNECTIONS", "Too many connections; retry later", nil)
+		return nil, false
+	}
+	return client, true
+}
+
+// eventStream writes Server-Sent Events, flushing each
+type eventStream struct {
+	writer     io.Writer
+	controller *http.ResponseController
+}
+
+// send writes event; its data is one line, as JSON encoding escapes newlines
+func (s *eventStream) send(event realtime.StatusEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return s.write(fmt.Sprintf("id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data))
+}
+
+// comment writes a comment, which clients ignore
+func (s *eventStream) comment(text string) error {
+	return s.write(": " + text + "\n\n")
+}
+
+func (s *eventStream) write(message string) error {
+	// Writers that can't set deadlines are still written to, bounded by the server's
+	_ = s.controller.SetWriteDeadline(time.Now().Add(realtimeWriteTimeout))
+	if _, err := io.WriteString(s.writer, message); err != nil {
+		return err
+	}
+	return s.controller.Flush()
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/supabase-redis-middleware/internal/i18n"
+	"github.com/yourusername/supabase-redis-middleware/internal/logger"
+	"github.com/yourusername/supabase-redis-middleware/internal/repository"
+	"github.com/yourusername/supabase-redis-middleware/internal/service"
+	"go.uber.org/zap"
+)
+
+// WriteServiceResponse writes a DomainService response, honouring conditional requests
+// When the client's If-None-Match matches the response ETag, 304 Not Modified is
+// returned without serializing the body. A fields query parameter projects the data
+// down to the listed keys, e.g. fields=id,name,price. A TTL override is reported in the metadata
+// and errors carry the request ID
+func WriteServiceResponse(c *gin.Context, resp *service.Response) {
+	if resp.Error != nil {
+		resp.Error.RequestID = requestID(c)
+		resp.Error.Message = i18n.T(c.Request.Context(), resp.Error.Code, resp.Error.Message, nil)
+		c.JSON(errorCodeToStatus(resp.Error.Code), resp)
+		return
+	}
+
+	if ttl := service.TTLOverrideFrom(c.Request.Context()); ttl > 0 && resp.Metadata != nil {
+		resp.Metadata.CacheTTL = int64(ttl / time.Second)
+	}
+
+	fields, ok := parseFields(c)
+	if !ok {
+		return
+	}
+	if err := resp.SelectFields(fields); err != nil {
+		c.JSON(http.StatusInternalServerError, &service.Response{
+			Status: "error",
+			Error:  &service.ErrorDetail{Code: "INTERNAL_ERROR", Message: err.Error(), RequestID: requestID(c)},
+		})
+		return
+	}
+
+	if resp.ETag != "" {
+		c.Header("ETag", resp.ETag)
+		if etagMatches(c.GetHeader("If-None-Match"), resp.ETag) {
+			c.Status(http.StatusNotModified)
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// etagMatches reports whether an If-None-Match header matches etag
+// Uses weak comparison as required for If-None-Match (RFC 9110 13.1.2)
+func etagMatches(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// errorCodeToStatus maps service error codes back to HTTP status codes
+func errorCodeToStatus(code string) int {
+	switch code {
+	case "NOT_FOUND":
+		return http.StatusNotFound
+	case "SERVICE_UNAVAILABLE":
+		return http.StatusServiceUnavailable
+	case "TIMEOUT":
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// requestLogger returns the logger of the request being served, whose lines carry its
+// request ID, or fallback outside of one
+func requestLogger(c *gin.Context, fallback *zap.Logger) *zap.Logger {
+	return logger.FromContext(c.Request.Context(), fallback)
+}
+
+// requestID returns the ID of the request being served, for error payloads
+func requestID(c *gin.Context) string {
+	return repository.RequestIDFrom(c.Request.Context())
+}
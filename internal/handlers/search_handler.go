@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/yourusername/supabase-redis-middleware/internal/repository"
+	"github.com/yourusername/supabase-redis-middleware/internal/service"
+	"go.uber.org/zap"
+)
+
+// maxSearchQueryLength bounds the q parameter passed to websearch_to_tsquery
+const maxSearchQueryLength = 200
+
+type SearchHandler struct {
+	catalog service.CatalogService
+	logger  *zap.Logger
+}
+
+func NewSearchHandler(catalog service.CatalogService, logger *zap.Logger) *SearchHandler {
+	return &SearchHandler{
+		catalog: catalog,
+		logger:  logger,
+	}
+}
+
+// maxLookupValueLength bounds the barcode, sku and ean lookup parameters
+const maxLookupValueLength = 100
+
+// LookupProduct resolves a product by exact barcode, sku or ean, returning the catalog
+// product with its price and stock in every store listing it
+// Query: exactly one of barcode, sku, ean
+func (h *SearchHandler) LookupProduct(c *gin.Context) {
+	var field, value string
+	for _, name := range []string{repository.LookupBarcode, repository.LookupSKU, repository.LookupEAN} {
+		v := strings.TrimSpace(c.Query(name))
+		if v == "" {
+			continue
+		}
+		if field != "" {
+			invalidInput(c, "only one of barcode, sku or ean may be given")
+			return
+		}
+		field, value = name, v
+	}
+
+	if field == "" || len(value) > maxLookupValueLength {
+		invalidInput(c, "one of barcode, sku or ean is required and must be at most 100 characters")
+		return
+	}
+
+	resp, _ := h.catalog.LookupProducts(c.Request.Context(), field, value)
+	WriteServiceResponse(c, resp)
+}
+
+// CompareProductPrices lists every active store carrying a product with its price, stock
+// and delivery fee, cheapest effective cost first
+// Query: lat, lng (optional, together) add each store's distance and delivery coverage
+func (h *SearchHandler) CompareProductPrices(c *gin.Context) {
+	productID := c.Param("id")
+	if _, err := uuid.Parse(productID); err != nil {
+		invalidUUID(c, "id")
+		return
+	}
+
+	var lat, lng *float64
+	if c.Query("lat") != "" || c.Query("lng") != "" {
+		latV, lngV, ok := parseLatLng(c)
+		if !ok {
+			return
+		}
+		lat, lng = &latV, &lngV
+	}
+
+	resp, _ := h.catalog.CompareProductPrices(c.Request.Context(), productID, lat, lng)
+	WriteServiceResponse(c, resp)
+}
+
+// SearchProducts runs a full-text search over products listed in active stores
+// Query: q (required), fuzzy, store_id, category_id, brand_id, in_stock, min_price, max_price, limit, offset
+func (h *SearchHandler) SearchProducts(c *gin.Context) {
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" || len(query) > maxSearchQueryLength {
+		invalidInput(c, "q is required and must be at most 200 characters")
+		return
+	}
+
+	fuzzy, ok := optionalBool(c, "fuzzy")
+	if !ok {
+		return
+	}
+
+	list, ok := listQuery(c)
+	if !ok {
+		return
+	}
+
+	resp, _ := h.catalog.SearchProducts(c.Request.Context(), query, list.Filter.StoreID, list.Filter, list.Pagination, fuzzy)
+	WriteServiceResponse(c, resp)
+}
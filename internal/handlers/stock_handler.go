@@ -1,22 +1,33 @@
 package handlers
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/yourusername/supabase-redis-middleware/internal/cache"
+	"github.com/yourusername/supabase-redis-middleware/internal/logger"
+	"github.com/yourusername/supabase-redis-middleware/internal/realtime"
 	"github.com/yourusername/supabase-redis-middleware/internal/repository"
+	"github.com/yourusername/supabase-redis-middleware/internal/webhooks"
 	"go.uber.org/zap"
 )
 
 type StockHandler struct {
-	pgRepo *repository.PostgresRepository
-	logger *zap.Logger
+	pgRepo   *repository.PostgresRepository
+	cache    cache.CacheService
+	events   *realtime.Publisher
+	webhooks *webhooks.Dispatcher
+	logger   *zap.Logger
 }
 
-func NewStockHandler(pgRepo *repository.PostgresRepository, logger *zap.Logger) *StockHandler {
+func NewStockHandler(pgRepo *repository.PostgresRepository, cacheService cache.CacheService, events *realtime.Publisher, dispatcher *webhooks.Dispatcher, logger *zap.Logger) *StockHandler {
 	return &StockHandler{
-		pgRepo: pgRepo,
-		logger: logger,
+		pgRepo:   pgRepo,
+		cache:    cacheService,
+		events:   events,
+		webhooks: dispatcher,
+		logger:   logger,
 	}
 }
 
@@ -45,20 +56,64 @@ type StockVariantUpdate struct {
 
 // UpdateStock handles bulk stock updates for a store
 // POST /api/v1/products/stock
+// With ?dry_run=true the updates are applied and rolled back, reporting which IDs matched
 func (h *StockHandler) UpdateStock(c *gin.Context) {
+	dryRun, ok := optionalBool(c, "dry_run")
+	if !ok {
+		return
+	}
+
 	var req UpdateStockRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		h.logger.Error("Invalid request payload", zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{
-			"status": "error",
-			"error": gin.H{
-				"code":    "INVALID_INPUT",
-				"message": err.Error(),
+		requestLogger(c, h.logger).Error("Invalid request payload", zap.Error(err))
+		invalidRequest(c, err)
+		return
+	}
+	if !allowsExternalStore(c, req.StoreID) {
+		return
+	}
+
+	result, err := h.Update(c.Request.Context(), req, dryRun)
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, "STOCK_UPDATE_FAILED", "Failed to update stock", nil)
+		return
+	}
+
+	if dryRun {
+		c.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data": gin.H{
+				"dry_run":               true,
+				"products_updated":      result.Updated,
+				"products_not_found":    result.NotFound,
+				"variants_updated":      result.VariantsUpdated,
+				"variants_not_found":    result.VariantsNotFound,
+				"not_found_product_ids": result.NotFoundIDs,
+				"not_found_variant_ids": result.VariantsNotFoundIDs,
 			},
+			"message": "Dry run completed; no stock was changed",
 		})
 		return
 	}
 
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"products_updated":   result.Updated,
+			"products_not_found": result.NotFound,
+			"variants_updated":   result.VariantsUpdated,
+			"variants_not_found": result.VariantsNotFound,
+		},
+		"message": "Stock updated successfully",
+	})
+}
+
+// Update applies a validated stock update to its store, as UpdateStock does for the HTTP
+// API, and clears the caches it made stale. The caller must already have checked that it
+// may write req's store
+func (h *StockHandler) Update(ctx context.Context, req UpdateStockRequest, dryRun bool) (*repository.StockUpdateResult, error) {
+	log := logger.FromContext(ctx, h.logger)
+
 	// Convert to repository type
 	repoProducts := make([]repository.StockProductUpdate, len(req.Products))
 	for i, p := range req.Products {
@@ -83,34 +138,62 @@ func (h *StockHandler) UpdateStock(c *gin.Context) {
 	}
 
 	// Update stock
-	result, err := h.pgRepo.BulkUpdateStock(c.Request.Context(), req.StoreID, repoProducts)
+	result, err := h.pgRepo.BulkUpdateStock(ctx, req.StoreID, repoProducts,
+		repository.StockUpdateOptions{DryRun: dryRun})
 	if err != nil {
-		h.logger.Error("Failed to update stock", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status": "error",
-			"error": gin.H{
-				"code":    "STOCK_UPDATE_FAILED",
-				"message": "Failed to update stock",
-			},
-		})
-		return
+		log.Error("Failed to update stock", zap.Error(err))
+		return nil, err
 	}
+	if dryRun {
+		return result, nil
+	}
+
+	cache.InvalidateDomains(ctx, h.cache, h.logger, stockDomains(result, req)...)
+	forgetPush(ctx, h.cache, result.StoreID)
+	h.events.ListingsChanged(ctx, realtime.EventStockUpdated, result.StoreID, updatedListings(req, result))
+	h.webhooks.StockUpdated(ctx, result.StoreID, updatedStockLevels(req, result))
 
-	h.logger.Info("Successfully updated stock",
+	log.Info("Successfully updated stock",
 		zap.String("store_id", req.StoreID),
 		zap.Int("products_updated", result.Updated),
 		zap.Int("products_not_found", result.NotFound),
 		zap.Int("variants_updated", result.VariantsUpdated),
 		zap.Int("variants_not_found", result.VariantsNotFound))
+	return result, nil
+}
 
-	c.JSON(http.StatusOK, gin.H{
-		"status": "success",
-		"data": gin.H{
-			"products_updated":   result.Updated,
-			"products_not_found": result.NotFound,
-			"variants_updated":   result.VariantsUpdated,
-			"variants_not_found": result.VariantsNotFound,
-		},
-		"message": "Stock updated successfully",
-	})
+// stockDomains returns the cache domains a stock update may have changed
+// Stock and price are store-scoped, but supermarket and pharmacy listings surface them too
+func stockDomains(result *repository.StockUpdateResult, req UpdateStockRequest) []string {
+	return append(storeDomains(result.StoreID, req.StoreID), cache.DomainSupermarket, cache.DomainPharmacy, cache.DomainSearch, cache.DomainLookup)
+}
+
+// updatedListings returns the ERP external IDs of the listings a stock update found
+func updatedListings(req UpdateStockRequest, result *repository.StockUpdateResult) []string {
+	notFound := make(map[string]bool, len(result.NotFoundIDs))
+	for _, id := range result.NotFoundIDs {
+		notFound[id] = true
+	}
+	ids := make([]string, 0, len(req.Products))
+	for _, p := range req.Products {
+		if !notFound[p.ID] {
+			ids = append(ids, p.ID)
+		}
+	}
+	return ids
+}
+
+// updatedStockLevels returns the stock levels a stock update set on the listings it found
+func updatedStockLevels(req UpdateStockRequest, result *repository.StockUpdateResult) []webhooks.StockLevel {
+	notFound := make(map[string]bool, len(result.NotFoundIDs))
+	for _, id := range result.NotFoundIDs {
+		notFound[id] = true
+	}
+	levels := make([]webhooks.StockLevel, 0, len(req.Products))
+	for _, p := range req.Products {
+		if !notFound[p.ID] {
+			levels = append(levels, webhooks.StockLevel{ExternalID: p.ID, StockQuantity: p.StockQuantity, IsAvailable: p.IsAvailable})
+		}
+	}
+	return levels
 }
@@ -1,22 +1,41 @@
 package handlers
 
 import (
+	"encoding/csv"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/yourusername/supabase-redis-middleware/internal/cache"
+	"github.com/yourusername/supabase-redis-middleware/internal/realtime"
 	"github.com/yourusername/supabase-redis-middleware/internal/repository"
+	"github.com/yourusername/supabase-redis-middleware/internal/service"
+	"github.com/yourusername/supabase-redis-middleware/internal/webhooks"
 	"go.uber.org/zap"
 )
 
 type StoreHandler struct {
-	pgRepo *repository.PostgresRepository
-	logger *zap.Logger
+	pgRepo   *repository.PostgresRepository
+	catalog  service.CatalogService
+	cache    cache.CacheService
+	events   *realtime.Publisher
+	webhooks *webhooks.Dispatcher
+	logger   *zap.Logger
 }
 
-func NewStoreHandler(pgRepo *repository.PostgresRepository, logger *zap.Logger) *StoreHandler {
+func NewStoreHandler(pgRepo *repository.PostgresRepository, catalog service.CatalogService, cacheService cache.CacheService, events *realtime.Publisher, dispatcher *webhooks.Dispatcher, logger *zap.Logger) *StoreHandler {
 	return &StoreHandler{
-		pgRepo: pgRepo,
-		logger: logger,
+		pgRepo:   pgRepo,
+		catalog:  catalog,
+		cache:    cacheService,
+		events:   events,
+		webhooks: dispatcher,
+		logger:   logger,
 	}
 }
 
@@ -26,14 +45,8 @@ func (h *StoreHandler) GetStoreBasicData(c *gin.Context) {
 
 	store, err := h.pgRepo.GetStoreByID(c.Request.Context(), storeID)
 	if err != nil {
-		h.logger.Error("Failed to get store", zap.String("store_id", storeID), zap.Error(err))
-		c.JSON(http.StatusNotFound, gin.H{
-			"status": "error",
-			"error": gin.H{
-				"code":    "STORE_NOT_FOUND",
-				"message": "Store not found",
-			},
-		})
+		requestLogger(c, h.logger).Error("Failed to get store", zap.String("store_id", storeID), zap.Error(err))
+		writeError(c, http.StatusNotFound, "STORE_NOT_FOUND", "Store not found", nil)
 		return
 	}
 
@@ -43,52 +56,88 @@ func (h *StoreHandler) GetStoreBasicData(c *gin.Context) {
 	})
 }
 
+const (
+	defaultNearbyRadiusKm = 5.0
+	maxNearbyRadiusKm     = 50.0
+)
+
+// FindNearbyStores lists active stores within a radius of a point, nearest first
+// Query: lat, lng (required), radius_km (default 5, max 50), store_type, limit
+func (h *StoreHandler) FindNearbyStores(c *gin.Context) {
+	lat, lng, ok := parseLatLng(c)
+	if !ok {
+		return
+	}
+
+	radiusKm := defaultNearbyRadiusKm
+	if raw := c.Query("radius_km"); raw != "" {
+		var err error
+		radiusKm, err = strconv.ParseFloat(raw, 64)
+		if err != nil || radiusKm <= 0 || radiusKm > maxNearbyRadiusKm {
+			invalidInput(c, "radius_km must be greater than 0 and at most 50")
+			return
+		}
+	}
+
+	list, ok := listQuery(c)
+	if !ok {
+		return
+	}
+
+	stores, err := h.pgRepo.FindNearbyStores(c.Request.Context(), repository.NearbyStoresQuery{
+		Lat:       lat,
+		Lng:       lng,
+		RadiusKm:  radiusKm,
+		StoreType: c.Query("store_type"),
+		Limit:     list.Pagination.Limit,
+	})
+	if err != nil {
+		requestLogger(c, h.logger).Error("Failed to find nearby stores", zap.Error(err))
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to search nearby stores", nil)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   stores,
+	})
+}
+
+// StoreStatusRequest sets a store's active and open flags; at least one must be set
+type StoreStatusRequest struct {
+	IsActive *bool `json:"is_active"`
+	IsOpen   *bool `json:"is_open"`
+}
+
 // UpdateStoreStatus updates store active/open status
 func (h *StoreHandler) UpdateStoreStatus(c *gin.Context) {
 	storeID := c.Param("id")
 
-	var input struct {
-		IsActive *bool `json:"is_active"`
-		IsOpen   *bool `json:"is_open"`
-	}
-
+	var input StoreStatusRequest
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"status": "error",
-			"error": gin.H{
-				"code":    "INVALID_INPUT",
-				"message": err.Error(),
-			},
-		})
+		invalidRequest(c, err)
 		return
 	}
 
 	if input.IsActive == nil && input.IsOpen == nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"status": "error",
-			"error": gin.H{
-				"code":    "INVALID_INPUT",
-				"message": "At least one of is_active or is_open must be provided",
-			},
-		})
+		writeError(c, http.StatusBadRequest, "INVALID_INPUT", "At least one of is_active or is_open must be provided", nil)
 		return
 	}
 
 	err := h.pgRepo.UpdateStoreStatus(c.Request.Context(), storeID, input.IsActive, input.IsOpen)
 	if err != nil {
-		h.logger.Error("Failed to update store status",
+		requestLogger(c, h.logger).Error("Failed to update store status",
 			zap.String("store_id", storeID),
 			zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status": "error",
-			"error": gin.H{
-				"code":    "UPDATE_FAILED",
-				"message": "Failed to update store status",
-			},
-		})
+		writeError(c, http.StatusInternalServerError, "UPDATE_FAILED", "Failed to update store status", nil)
 		return
 	}
 
+	// Inactive stores drop out of nearby results
+	cache.InvalidateDomains(c.Request.Context(), h.cache, h.logger, cache.DomainNearby)
+	h.events.StoreStatusChanged(c.Request.Context(), storeID)
+	h.webhooks.StoreStatusChanged(c.Request.Context(), storeID)
+
 	c.JSON(http.StatusOK, gin.H{
 		"status":  "success",
 		"message": "Store status updated successfully",
@@ -101,14 +150,8 @@ func (h *StoreHandler) GetStoreStatus(c *gin.Context) {
 
 	status, err := h.pgRepo.GetStoreStatus(c.Request.Context(), storeID)
 	if err != nil {
-		h.logger.Error("Failed to get store status", zap.String("store_id", storeID), zap.Error(err))
-		c.JSON(http.StatusNotFound, gin.H{
-			"status": "error",
-			"error": gin.H{
-				"code":    "STORE_NOT_FOUND",
-				"message": "Store not found",
-			},
-		})
+		requestLogger(c, h.logger).Error("Failed to get store status", zap.String("store_id", storeID), zap.Error(err))
+		writeError(c, http.StatusNotFound, "STORE_NOT_FOUND", "Store not found", nil)
 		return
 	}
 
@@ -124,33 +167,148 @@ func (h *StoreHandler) UpdateStoreDetails(c *gin.Context) {
 
 	var input repository.UpdateStoreDetailsInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"status": "error",
-			"error": gin.H{
-				"code":    "INVALID_INPUT",
-				"message": err.Error(),
-			},
-		})
+		invalidRequest(c, err)
 		return
 	}
 
 	err := h.pgRepo.UpdateStoreDetails(c.Request.Context(), storeID, input)
 	if err != nil {
-		h.logger.Error("Failed to update store details",
+		requestLogger(c, h.logger).Error("Failed to update store details",
 			zap.String("store_id", storeID),
 			zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status": "error",
-			"error": gin.H{
-				"code":    "UPDATE_FAILED",
-				"message": "Failed to update store details",
-			},
-		})
+		writeError(c, http.StatusInternalServerError, "UPDATE_FAILED", "Failed to update store details", nil)
 		return
 	}
 
+	cache.InvalidateDomains(c.Request.Context(), h.cache, h.logger, cache.DomainNearby)
+
+	// A push overwrites the store's name and address, so an identical one must be applied again
+	forgetPush(c.Request.Context(), h.cache, storeID)
+
+	if input.MinOrderAmount != nil || input.DeliveryFee != nil || input.EstimatedDeliveryTime != nil {
+		h.events.DeliveryChanged(c.Request.Context(), storeID)
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"status":  "success",
 		"message": "Store details updated successfully",
 	})
 }
+
+// ListStoreProducts lists a store's catalog with price, stock, variations and applied taxes
+// Query: category_id, brand_id, search, in_stock, min_price, max_price, limit, offset, cursor, include_total
+func (h *StoreHandler) ListStoreProducts(c *gin.Context) {
+	storeID := c.Param("id")
+	if _, err := uuid.Parse(storeID); err != nil {
+		invalidUUID(c, "id")
+		return
+	}
+
+	list, ok := listQuery(c)
+	if !ok {
+		return
+	}
+
+	resp, _ := h.catalog.ListStoreProducts(c.Request.Context(), storeID, list.Filter, list.Pagination)
+	WriteServiceResponse(c, resp)
+}
+
+// writeStoreError writes a store repository error: a not-found error is a 404 with
+// notFoundCode, a validation error a 400, a conflict a 409, and anything else a 500 with
+// the given message
+func writeStoreError(c *gin.Context, err error, notFoundCode, message string) {
+	status, code := http.StatusInternalServerError, "INTERNAL_ERROR"
+	var repoErr *repository.RepositoryError
+	if errors.As(err, &repoErr) {
+		switch repoErr.StatusCode {
+		case http.StatusNotFound:
+			status, code, message = http.StatusNotFound, notFoundCode, repoErr.Message
+		case http.StatusBadRequest:
+			status, code, message = http.StatusBadRequest, "INVALID_INPUT", repoErr.Message
+		case http.StatusConflict:
+			status, code, message = http.StatusConflict, "CONFLICT", repoErr.Message
+		}
+	}
+
+	writeError(c, status, code, message, nil)
+}
+
+// GetStockReport lists a store's out-of-stock and low-stock products for reordering
+// Query: threshold (default: each product's own low_stock_threshold), format (json or csv)
+func (h *StoreHandler) GetStockReport(c *gin.Context) {
+	storeID := c.Param("id")
+	if _, err := uuid.Parse(storeID); err != nil {
+		invalidUUID(c, "id")
+		return
+	}
+
+	threshold, ok := optionalFloat(c, "threshold")
+	if !ok {
+		return
+	}
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" {
+		invalidInput(c, "format must be json or csv")
+		return
+	}
+
+	report, err := h.pgRepo.GetStockReport(c.Request.Context(), storeID, threshold)
+	if err != nil {
+		requestLogger(c, h.logger).Error("Failed to get stock report", zap.String("store_id", storeID), zap.Error(err))
+		writeStoreError(c, err, "STORE_NOT_FOUND", "Failed to get stock report")
+		return
+	}
+
+	if format == "csv" {
+		writeStockReportCSV(c, report)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   report,
+	})
+}
+
+// writeStockReportCSV writes a stock report as a CSV attachment, one row per product
+func writeStockReportCSV(c *gin.Context, report *repository.StockReport) {
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="stock-report-%s.csv"`, report.StoreID))
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+	w.Write([]string{"status", "sku", "name", "brand", "category", "stock_quantity", "threshold", "external_id", "store_product_id", "updated_at"})
+	for _, item := range report.Items {
+		w.Write([]string{
+			item.Status,
+			csvText(item.SKU),
+			csvText(item.Name),
+			csvText(stringOrEmpty(item.BrandName)),
+			csvText(stringOrEmpty(item.CategoryName)),
+			strconv.FormatFloat(item.StockQuantity, 'f', -1, 64),
+			strconv.FormatFloat(item.Threshold, 'f', -1, 64),
+			csvText(stringOrEmpty(item.ExternalID)),
+			item.StoreProductID,
+			item.UpdatedAt.Format(time.RFC3339),
+		})
+	}
+	w.Flush()
+}
+
+// csvText returns a text cell that spreadsheets won't run as a formula: one starting
+// with =, +, -, @, tab or carriage return is prefixed with '
+func csvText(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// stringOrEmpty returns *s, or "" for nil
+func stringOrEmpty(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/supabase-redis-middleware/internal/repository"
+)
+
+func TestGetStockReport_InvalidRequests(t *testing.T) {
+	h := &StoreHandler{}
+	const report = "/stores/550e8400-e29b-41d4-a716-446655440000/stock-report"
+
+	tests := []struct {
+		name        string
+		target      string
+		wantMessage string
+	}{
+		{"invalid store", "/stores/abc/stock-report", "id must be a valid UUID"},
+		{"invalid threshold", report + "?threshold=few", "threshold"},
+		{"unknown format", report + "?format=xlsx", "format must be json or csv"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(t, h.GetStockReport, http.MethodGet, "/stores/:id/stock-report", tt.target, "")
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want 400: %s", w.Code, w.Body.String())
+			}
+			resp := decodeError(t, w)
+			if resp.Error.Code != "INVALID_INPUT" || !strings.Contains(resp.Error.Message, tt.wantMessage) {
+				t.Errorf("error = %s %q, want INVALID_INPUT mentioning %q", resp.Error.Code, resp.Error.Message, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestCSVText(t *testing.T) {
+	tests := []struct {
+		cell string
+		want string
+	}{
+		{"Milk", "Milk"},
+		{"", ""},
+		{"=HYPERLINK(\"http://evil\")", "'=HYPERLINK(\"http://evil\")"},
+		{"+1", "'+1"},
+		{"-1", "'-1"},
+		{"@SUM(A1)", "'@SUM(A1)"},
+		{"\tcmd", "'\tcmd"},
+		{"\rcmd", "'\rcmd"},
+		{"a=b", "a=b"},
+	}
+
+	for _, tt := range tests {
+		if got := csvText(tt.cell); got != tt.want {
+			t.Errorf("csvText(%q) = %q, want %q", tt.cell, got, tt.want)
+		}
+	}
+}
+
+func TestWriteStockReportCSV(t *testing.T) {
+	externalID, brand, formula := "P1", "Amul", "@SUM(A1)"
+	updated := time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC)
+	report := &repository.StockReport{
+		StoreID: "550e8400-e29b-41d4-a716-446655440000",
+		Items: []repository.StockReportItem{
+			{StoreProductID: "sp-1", ExternalID: &externalID, SKU: "MLK-1", Name: `Milk, "Full Cream"`, BrandName: &brand,
+				StockQuantity: 0, Threshold: 10, Status: repository.StockOutOfStock, UpdatedAt: updated},
+			{StoreProductID: "sp-2", SKU: "RICE-5", Name: "Rice 5kg", StockQuantity: 2.5, Threshold: 10,
+				Status: repository.StockLow, UpdatedAt: updated},
+			{StoreProductID: "sp-3", SKU: "-SKU", Name: `=HYPERLINK("http://evil")`, BrandName: &formula,
+				StockQuantity: -1, Threshold: 10, Status: repository.StockOutOfStock, UpdatedAt: updated},
+		},
+	}
+
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	writeStockReportCSV(c, report)
+
+	if got := w.Header().Get("Content-Type"); got != "text/csv; charset=utf-8" {
+		t.Errorf("Content-Type = %q, want text/csv", got)
+	}
+	if got := w.Header().Get("Content-Disposition"); !strings.Contains(got, "stock-report-"+report.StoreID+".csv") {
+		t.Errorf("Content-Disposition = %q, want the store's file name", got)
+	}
+
+	rows, err := csv.NewReader(w.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("failed to parse CSV: %v", err)
+	}
+	want := [][]string{
+		{"status", "sku", "name", "brand", "category", "stock_quantity", "threshold", "external_id", "store_product_id", "updated_at"},
+		{"out_of_stock", "MLK-1", `Milk, "Full Cream"`, "Amul", "", "0", "10", "P1", "sp-1", "2026-03-01T09:30:00Z"},
+		{"low_stock", "RICE-5", "Rice 5kg", "", "", "2.5", "10", "", "sp-2", "2026-03-01T09:30:00Z"},
+		{"out_of_stock", "'-SKU", `'=HYPERLINK("http://evil")`, "'@SUM(A1)", "", "-1", "10", "", "sp-3", "2026-03-01T09:30:00Z"},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("CSV rows = %v, want %v", rows, want)
+	}
+}
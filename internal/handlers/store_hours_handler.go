@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/yourusername/supabase-redis-middleware/internal/repository"
+	"go.uber.org/zap"
+)
+
+// StoreHoursRequest is the body of PUT /stores/:id/hours
+// Hours replaces every weekly window; an empty list clears them, leaving the store open
+// whenever is_open is set
+type StoreHoursRequest struct {
+	Timezone *string            `json:"timezone" binding:"omitempty,max=64"`
+	Hours    []StoreHoursWindow `json:"hours" binding:"max=50,dive"`
+}
+
+// StoreHoursWindow is one weekly opening window; times are HH:MM or HH:MM:SS in the
+// store's timezone, and a close_time at or before open_time runs past midnight
+type StoreHoursWindow struct {
+	DayOfWeek *int   `json:"day_of_week" binding:"required,min=0,max=6"`
+	OpenTime  string `json:"open_time"`
+	CloseTime string `json:"close_time"`
+	IsClosed  bool   `json:"is_closed"`
+}
+
+// StoreHolidayRequest is the body of PUT /stores/:id/hours/holidays/:date
+type StoreHolidayRequest struct {
+	IsClosed  bool    `json:"is_closed"`
+	OpenTime  *string `json:"open_time"`
+	CloseTime *string `json:"close_time"`
+	Note      *string `json:"note" binding:"omitempty,max=500"`
+}
+
+// GetStoreHours retrieves a store's weekly hours, upcoming holidays and whether it is open now
+func (h *StoreHandler) GetStoreHours(c *gin.Context) {
+	storeID := c.Param("id")
+	if _, err := uuid.Parse(storeID); err != nil {
+		invalidUUID(c, "id")
+		return
+	}
+
+	schedule, err := h.pgRepo.GetStoreSchedule(c.Request.Context(), storeID)
+	if err != nil {
+		requestLogger(c, h.logger).Error("Failed to get store hours", zap.String("store_id", storeID), zap.Error(err))
+		writeStoreError(c, err, "STORE_NOT_FOUND", "Failed to get store hours")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   schedule,
+	})
+}
+
+// ReplaceStoreHours replaces a store's weekly hours, and its timezone if given
+func (h *StoreHandler) ReplaceStoreHours(c *gin.Context) {
+	storeID := c.Param("id")
+	if _, err := uuid.Parse(storeID); err != nil {
+		invalidUUID(c, "id")
+		return
+	}
+
+	var req StoreHoursRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidRequest(c, err)
+		return
+	}
+	if req.Hours == nil {
+		invalidInput(c, "hours is required; send [] to clear the store's hours")
+		return
+	}
+	if req.Timezone != nil {
+		if _, err := time.LoadLocation(*req.Timezone); err != nil || *req.Timezone == "" || *req.Timezone == "Local" {
+			invalidInput(c, "timezone must be an IANA time zone such as Asia/Kolkata")
+			return
+		}
+	}
+
+	hours := make([]repository.StoreHoursInput, len(req.Hours))
+	for i, w := range req.Hours {
+		if w.IsClosed {
+			// store_hours requires times even on closed days
+			w.OpenTime, w.CloseTime = "00:00", "00:00"
+		} else if !validClock(w.OpenTime) || !validClock(w.CloseTime) {
+			invalidInput(c, fmt.Sprintf("hours[%d]: open_time and close_time must be HH:MM or HH:MM:SS", i))
+			return
+		}
+		hours[i] = repository.StoreHoursInput{
+			DayOfWeek: *w.DayOfWeek,
+			OpenTime:  w.OpenTime,
+			CloseTime: w.CloseTime,
+			IsClosed:  w.IsClosed,
+		}
+	}
+
+	if err := h.pgRepo.ReplaceStoreHours(c.Request.Context(), storeID, req.Timezone, hours); err != nil {
+		requestLogger(c, h.logger).Error("Failed to replace store hours", zap.String("store_id", storeID), zap.Error(err))
+		writeStoreError(c, err, "STORE_NOT_FOUND", "Failed to update store hours")
+		return
+	}
+
+	// New hours may open or close the store now
+	h.events.StoreStatusChanged(c.Request.Context(), storeID)
+
+	h.GetStoreHours(c)
+}
+
+// UpsertStoreHoliday sets a store's hours for one date, closed or with a special window
+func (h *StoreHandler) UpsertStoreHoliday(c *gin.Context) {
+	storeID, date, ok := storeHolidayParams(c)
+	if !ok {
+		return
+	}
+
+	var req StoreHolidayRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidRequest(c, err)
+		return
+	}
+	if req.IsClosed {
+		req.OpenTime, req.CloseTime = nil, nil
+	} else if req.OpenTime == nil || req.CloseTime == nil || !validClock(*req.OpenTime) || !validClock(*req.CloseTime) {
+		invalidInput(c, "open_time and close_time must be HH:MM or HH:MM:SS unless is_closed is true")
+		return
+	}
+
+	holiday, err := h.pgRepo.UpsertStoreHoliday(c.Request.Context(), storeID, repository.StoreHolidayInput{
+		Date:      date,
+		IsClosed:  req.IsClosed,
+		OpenTime:  req.OpenTime,
+		CloseTime: req.CloseTime,
+		Note:      req.Note,
+	})
+	if err != nil {
+		requestLogger(c, h.logger).Error("Failed to save store holiday", zap.String("store_id", storeID), zap.String("date", date), zap.Error(err))
+		writeStoreError(c, err, "STORE_NOT_FOUND", "Failed to save store holiday")
+		return
+	}
+
+	h.events.StoreStatusChanged(c.Request.Context(), storeID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"data":    holiday,
+		"message": "Store holiday saved successfully",
+	})
+}
+
+// DeleteStoreHoliday removes a store's holiday, restoring its weekly hours for that date
+func (h *StoreHandler) DeleteStoreHoliday(c *gin.Context) {
+	storeID, date, ok := storeHolidayParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.pgRepo.DeleteStoreHoliday(c.Request.Context(), storeID, date); err != nil {
+		requestLogger(c, h.logger).Error("Failed to delete store holiday", zap.String("store_id", storeID), zap.String("date", date), zap.Error(err))
+		writeStoreError(c, err, "HOLIDAY_NOT_FOUND", "Failed to delete store holiday")
+		return
+	}
+
+	h.events.StoreStatusChanged(c.Request.Context(), storeID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Store holiday deleted successfully",
+	})
+}
+
+// storeHolidayParams validates the :id and :date path parameters, writing a 400 if either is invalid
+func storeHolidayParams(c *gin.Context) (string, string, bool) {
+	storeID := c.Param("id")
+	if _, err := uuid.Parse(storeID); err != nil {
+		invalidUUID(c, "id")
+		return "", "", false
+	}
+
+	date := c.Param("date")
+	if _, err := time.Parse(time.DateOnly, date); err != nil {
+		invalidInput(c, "date must be YYYY-MM-DD")
+		return "", "", false
+	}
+
+	return storeID, date, true
+}
+
+// validClock reports whether clock is a time of day accepted by store_hours
+func validClock(clock string) bool {
+	_, err := repository.ParseClock(clock)
+	return err == nil
+}
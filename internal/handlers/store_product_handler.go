@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/yourusername/supabase-redis-middleware/internal/cache"
+	"github.com/yourusername/supabase-redis-middleware/internal/realtime"
+	"go.uber.org/zap"
+)
+
+// DeactivateStoreProduct takes a product pushed to a store by mistake out of its listings
+// Query: deactivate_product (also deactivate the catalog product if no other store has it available)
+func (h *StoreHandler) DeactivateStoreProduct(c *gin.Context) {
+	storeID, externalID, ok := storeListingParams(c)
+	if !ok {
+		return
+	}
+	deactivateProduct, ok := optionalBool(c, "deactivate_product")
+	if !ok {
+		return
+	}
+
+	removal, err := h.pgRepo.DeactivateStoreProduct(c.Request.Context(), storeID, externalID, deactivateProduct)
+	if err != nil {
+		requestLogger(c, h.logger).Error("Failed to deactivate store product",
+			zap.String("store_id", storeID),
+			zap.String("external_id", externalID),
+			zap.Error(err))
+		writeStoreError(c, err, "PRODUCT_NOT_FOUND", "Failed to deactivate product")
+		return
+	}
+
+	cache.InvalidateDomains(c.Request.Context(), h.cache, h.logger, listingDomains(storeID)...)
+	forgetPush(c.Request.Context(), h.cache, storeID)
+	h.events.AvailabilityChanged(c.Request.Context(), storeID, realtime.AvailabilityState{
+		StoreProductID: removal.StoreProductID,
+		ExternalID:     externalID,
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"data":    removal,
+		"message": "Product deactivated successfully",
+	})
+}
+
+// AvailabilityRequest is the body of PUT /stores/:id/products/:external_id/availability
+type AvailabilityRequest struct {
+	IsAvailable *bool `json:"is_available" binding:"required"`
+}
+
+// SetStoreProductAvailability takes a product off a store's listings, or puts it back, without
+// changing its stock
+func (h *StoreHandler) SetStoreProductAvailability(c *gin.Context) {
+	storeID, externalID, ok := storeListingParams(c)
+	if !ok {
+		return
+	}
+
+	var req AvailabilityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidRequest(c, err)
+		return
+	}
+
+	storeProductID, err := h.pgRepo.SetStoreProductAvailability(c.Request.Context(), storeID, externalID, *req.IsAvailable)
+	if err != nil {
+		requestLogger(c, h.logger).Error("Failed to set store product availability",
+			zap.String("store_id", storeID),
+			zap.String("external_id", externalID),
+			zap.Error(err))
+		writeStoreError(c, err, "PRODUCT_NOT_FOUND", "Failed to set product availability")
+		return
+	}
+
+	cache.InvalidateDomains(c.Request.Context(), h.cache, h.logger, listingDomains(storeID)...)
+	forgetPush(c.Request.Context(), h.cache, storeID)
+	h.events.AvailabilityChanged(c.Request.Context(), storeID, realtime.AvailabilityState{
+		StoreProductID: storeProductID,
+		ExternalID:     externalID,
+		IsAvailable:    *req.IsAvailable,
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"store_product_id": storeProductID,
+			"external_id":      externalID,
+			"is_available":     *req.IsAvailable,
+		},
+		"message": "Product availability updated successfully",
+	})
+}
+
+// storeListingParams validates the :id path parameter and returns it with :productId, the
+// product's ERP ID, writing a 400 if the store ID is invalid
+func storeListingParams(c *gin.Context) (string, string, bool) {
+	storeID, externalID := c.Param("id"), c.Param("productId")
+	if _, err := uuid.Parse(storeID); err != nil {
+		invalidUUID(c, "id")
+		return "", "", false
+	}
+	return storeID, externalID, true
+}
+
+// listingDomains returns the cache domains that show a store's listings
+func listingDomains(storeID string) []string {
+	return append(storeDomains(storeID),
+		cache.DomainProducts, cache.DomainSupermarket, cache.DomainPharmacy, cache.DomainSearch, cache.DomainLookup)
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestDeactivateStoreProduct_InvalidRequests(t *testing.T) {
+	h := &StoreHandler{}
+
+	tests := []struct {
+		name        string
+		target      string
+		wantMessage string
+	}{
+		{"invalid store", "/stores/abc/products/P1", "id must be a valid UUID"},
+		{"invalid flag", "/stores/550e8400-e29b-41d4-a716-446655440000/products/P1?deactivate_product=maybe", "deactivate_product must be true or false"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(t, h.DeactivateStoreProduct, http.MethodDelete, "/stores/:id/products/:productId", tt.target, "")
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want 400: %s", w.Code, w.Body.String())
+			}
+			resp := decodeError(t, w)
+			if resp.Error.Code != "INVALID_INPUT" || !strings.Contains(resp.Error.Message, tt.wantMessage) {
+				t.Errorf("error = %s %q, want INVALID_INPUT mentioning %q", resp.Error.Code, resp.Error.Message, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestSetStoreProductAvailability_InvalidRequests(t *testing.T) {
+	h := &StoreHandler{}
+	const listing = "/stores/550e8400-e29b-41d4-a716-446655440000/products/P1/availability"
+
+	tests := []struct {
+		name        string
+		target      string
+		body        string
+		wantMessage string
+	}{
+		{"invalid store", "/stores/abc/products/P1/availability", `{"is_available":false}`, "id must be a valid UUID"},
+		{"missing flag", listing, `{}`, "is_available"},
+		{"flag not a boolean", listing, `{"is_available":"no"}`, "is_available"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(t, h.SetStoreProductAvailability, http.MethodPut, "/stores/:id/products/:productId/availability", tt.target, tt.body)
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want 400: %s", w.Code, w.Body.String())
+			}
+			resp := decodeError(t, w)
+			if resp.Error.Code != "INVALID_INPUT" || !strings.Contains(resp.Error.Message, tt.wantMessage) {
+				t.Errorf("error = %s %q, want INVALID_INPUT mentioning %q", resp.Error.Code, resp.Error.Message, tt.wantMessage)
+			}
+		})
+	}
+}
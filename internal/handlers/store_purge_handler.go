@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/yourusername/supabase-redis-middleware/internal/cache"
+	"go.uber.org/zap"
+)
+
+// PurgeStore deletes an offboarded store and all of its data
+// Query: dry_run (count what would be deleted without deleting it)
+func (h *StoreHandler) PurgeStore(c *gin.Context) {
+	storeID := c.Param("id")
+	if _, err := uuid.Parse(storeID); err != nil {
+		invalidUUID(c, "id")
+		return
+	}
+	dryRun, ok := optionalBool(c, "dry_run")
+	if !ok {
+		return
+	}
+
+	purge, err := h.pgRepo.PurgeStore(c.Request.Context(), storeID, dryRun)
+	if err != nil {
+		requestLogger(c, h.logger).Error("Failed to purge store", zap.String("store_id", storeID), zap.Error(err))
+		writeStoreError(c, err, "STORE_NOT_FOUND", "Failed to purge store")
+		return
+	}
+
+	if dryRun {
+		c.JSON(http.StatusOK, gin.H{
+			"status":  "success",
+			"data":    purge,
+			"message": "Dry run completed; nothing was deleted",
+		})
+		return
+	}
+
+	domains := append(storeDomains(storeID, purge.ExternalID),
+		cache.DomainProducts, cache.DomainSupermarket, cache.DomainPharmacy, cache.DomainSearch,
+		cache.DomainCategories, cache.DomainLookup, cache.DomainNearby)
+	cache.InvalidateDomains(c.Request.Context(), h.cache, h.logger, domains...)
+	forgetPush(c.Request.Context(), h.cache, storeID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"data":    purge,
+		"message": "Store purged successfully",
+	})
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/yourusername/supabase-redis-middleware/internal/service"
+	"go.uber.org/zap"
+)
+
+type SupermarketHandler struct {
+	catalog service.CatalogService
+	logger  *zap.Logger
+}
+
+func NewSupermarketHandler(catalog service.CatalogService, logger *zap.Logger) *SupermarketHandler {
+	return &SupermarketHandler{
+		catalog: catalog,
+		logger:  logger,
+	}
+}
+
+// ListProducts lists products available in supermarket stores
+// Query: store_id, category_id, brand_id, search, in_stock, min_price, max_price, limit, offset
+func (h *SupermarketHandler) ListProducts(c *gin.Context) {
+	list, ok := listQuery(c)
+	if !ok {
+		return
+	}
+
+	resp, _ := h.catalog.ListSupermarketProducts(c.Request.Context(), list.Filter, list.Pagination)
+	WriteServiceResponse(c, resp)
+}
+
+// GetProduct retrieves a single supermarket product listing with its variations
+func (h *SupermarketHandler) GetProduct(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		invalidUUID(c, "id")
+		return
+	}
+
+	resp, _ := h.catalog.GetSupermarketProduct(c.Request.Context(), id)
+	WriteServiceResponse(c, resp)
+}
@@ -0,0 +1,254 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/yourusername/supabase-redis-middleware/internal/cache"
+	"github.com/yourusername/supabase-redis-middleware/internal/repository"
+	"go.uber.org/zap"
+)
+
+// UpdateTaxRequest is the body of PUT /stores/:id/taxes/:taxId; omitted fields are unchanged
+type UpdateTaxRequest struct {
+	Name        *string  `json:"name" binding:"omitempty,max=100"`
+	Description *string  `json:"description"`
+	Rate        *float64 `json:"rate" binding:"omitempty,gte=0,lte=999.99"`
+	TaxType     *string  `json:"tax_type" binding:"omitempty,oneof=percentage fixed"`
+	IsInclusive *bool    `json:"is_inclusive"`
+	IsActive    *bool    `json:"is_active"`
+}
+
+// AttachTaxRequest is the optional body of PUT /stores/:id/listings/:storeProductId/taxes/:taxId
+type AttachTaxRequest struct {
+	OverrideRate *float64 `json:"override_rate" binding:"omitempty,gte=0,lte=999.99"`
+}
+
+// ListStoreTaxes lists a store's taxes, active or not
+func (h *StoreHandler) ListStoreTaxes(c *gin.Context) {
+	storeID := c.Param("id")
+	if _, err := uuid.Parse(storeID); err != nil {
+		invalidUUID(c, "id")
+		return
+	}
+
+	taxes, err := h.pgRepo.ListStoreTaxes(c.Request.Context(), storeID)
+	if err != nil {
+		requestLogger(c, h.logger).Error("Failed to list taxes", zap.String("store_id", storeID), zap.Error(err))
+		writeStoreError(c, err, "STORE_NOT_FOUND", "Failed to list taxes")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   taxes,
+	})
+}
+
+// GetStoreTax retrieves one of a store's taxes
+func (h *StoreHandler) GetStoreTax(c *gin.Context) {
+	storeID, taxID, ok := storeTaxParams(c)
+	if !ok {
+		return
+	}
+
+	tax, err := h.pgRepo.GetStoreTax(c.Request.Context(), storeID, taxID)
+	if err != nil {
+		writeStoreError(c, err, "TAX_NOT_FOUND", "Failed to get tax")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   tax,
+	})
+}
+
+// UpdateStoreTax changes the fields given for one of a store's taxes
+func (h *StoreHandler) UpdateStoreTax(c *gin.Context) {
+	storeID, taxID, ok := storeTaxParams(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateTaxRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidRequest(c, err)
+		return
+	}
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			invalidInput(c, "name must not be blank")
+			return
+		}
+		req.Name = &name
+	}
+	if req.Rate != nil && *req.Rate > 100 && req.TaxType != nil && *req.TaxType == repository.TaxTypePercentage {
+		invalidInput(c, "rate must be at most 100 for a percentage tax")
+		return
+	}
+
+	tax, err := h.pgRepo.UpdateStoreTax(c.Request.Context(), storeID, taxID, repository.TaxUpdate{
+		Name:        req.Name,
+		Description: req.Description,
+		Rate:        req.Rate,
+		TaxType:     req.TaxType,
+		IsInclusive: req.IsInclusive,
+		IsActive:    req.IsActive,
+	})
+	if err != nil {
+		requestLogger(c, h.logger).Error("Failed to update tax", zap.String("tax_id", taxID), zap.Error(err))
+		writeStoreError(c, err, "TAX_NOT_FOUND", "Failed to update tax")
+		return
+	}
+
+	cache.InvalidateDomains(c.Request.Context(), h.cache, h.logger, cache.StoreDomain(storeID))
+	forgetPush(c.Request.Context(), h.cache, storeID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"data":    tax,
+		"message": "Tax updated successfully",
+	})
+}
+
+// DeactivateStoreTax stops a tax applying to any of the store's products
+// The tax and its product links are kept, so setting is_active restores it
+func (h *StoreHandler) DeactivateStoreTax(c *gin.Context) {
+	storeID, taxID, ok := storeTaxParams(c)
+	if !ok {
+		return
+	}
+
+	inactive := false
+	tax, err := h.pgRepo.UpdateStoreTax(c.Request.Context(), storeID, taxID, repository.TaxUpdate{IsActive: &inactive})
+	if err != nil {
+		requestLogger(c, h.logger).Error("Failed to deactivate tax", zap.String("tax_id", taxID), zap.Error(err))
+		writeStoreError(c, err, "TAX_NOT_FOUND", "Failed to deactivate tax")
+		return
+	}
+
+	cache.InvalidateDomains(c.Request.Context(), h.cache, h.logger, cache.StoreDomain(storeID))
+	forgetPush(c.Request.Context(), h.cache, storeID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"data":    tax,
+		"message": "Tax deactivated successfully",
+	})
+}
+
+// ListStoreProductTaxes lists the active taxes applied to a store product
+func (h *StoreHandler) ListStoreProductTaxes(c *gin.Context) {
+	storeID, storeProductID, ok := storeProductParams(c)
+	if !ok {
+		return
+	}
+
+	taxes, err := h.pgRepo.ListStoreProductTaxes(c.Request.Context(), storeID, storeProductID)
+	if err != nil {
+		writeStoreError(c, err, "PRODUCT_NOT_FOUND", "Failed to list product taxes")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   taxes,
+	})
+}
+
+// AttachStoreProductTax applies one of a store's taxes to one of its products
+func (h *StoreHandler) AttachStoreProductTax(c *gin.Context) {
+	storeID, storeProductID, ok := storeProductParams(c)
+	if !ok {
+		return
+	}
+	taxID := c.Param("taxId")
+	if _, err := uuid.Parse(taxID); err != nil {
+		invalidUUID(c, "taxId")
+		return
+	}
+
+	var req AttachTaxRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			invalidRequest(c, err)
+			return
+		}
+	}
+
+	applied, err := h.pgRepo.AttachStoreProductTax(c.Request.Context(), storeID, storeProductID, taxID, req.OverrideRate)
+	if err != nil {
+		requestLogger(c, h.logger).Error("Failed to attach tax", zap.String("store_product_id", storeProductID), zap.Error(err))
+		writeStoreError(c, err, "NOT_FOUND", "Failed to attach tax")
+		return
+	}
+
+	cache.InvalidateDomains(c.Request.Context(), h.cache, h.logger, cache.StoreDomain(storeID))
+	forgetPush(c.Request.Context(), h.cache, storeID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"data":    applied,
+		"message": "Tax attached successfully",
+	})
+}
+
+// DetachStoreProductTax stops applying a tax to a store product
+func (h *StoreHandler) DetachStoreProductTax(c *gin.Context) {
+	storeID, storeProductID, ok := storeProductParams(c)
+	if !ok {
+		return
+	}
+	taxID := c.Param("taxId")
+	if _, err := uuid.Parse(taxID); err != nil {
+		invalidUUID(c, "taxId")
+		return
+	}
+
+	if err := h.pgRepo.DetachStoreProductTax(c.Request.Context(), storeID, storeProductID, taxID); err != nil {
+		requestLogger(c, h.logger).Error("Failed to detach tax", zap.String("store_product_id", storeProductID), zap.Error(err))
+		writeStoreError(c, err, "NOT_FOUND", "Failed to detach tax")
+		return
+	}
+
+	cache.InvalidateDomains(c.Request.Context(), h.cache, h.logger, cache.StoreDomain(storeID))
+	forgetPush(c.Request.Context(), h.cache, storeID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Tax detached successfully",
+	})
+}
+
+// storeTaxParams validates the :id and :taxId path parameters, writing a 400 if either is invalid
+func storeTaxParams(c *gin.Context) (string, string, bool) {
+	storeID, taxID := c.Param("id"), c.Param("taxId")
+	if _, err := uuid.Parse(storeID); err != nil {
+		invalidUUID(c, "id")
+		return "", "", false
+	}
+	if _, err := uuid.Parse(taxID); err != nil {
+		invalidUUID(c, "taxId")
+		return "", "", false
+	}
+	return storeID, taxID, true
+}
+
+// storeProductParams validates the :id and :storeProductId path parameters, writing a 400
+// if either is invalid
+func storeProductParams(c *gin.Context) (string, string, bool) {
+	storeID, storeProductID := c.Param("id"), c.Param("storeProductId")
+	if _, err := uuid.Parse(storeID); err != nil {
+		invalidUUID(c, "id")
+		return "", "", false
+	}
+	if _, err := uuid.Parse(storeProductID); err != nil {
+		invalidUUID(c, "storeProductId")
+		return "", "", false
+	}
+	return storeID, storeProductID, true
+}
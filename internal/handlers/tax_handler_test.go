@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestTaxHandlers_InvalidRequests(t *testing.T) {
+	const (
+		store   = "550e8400-e29b-41d4-a716-446655440000"
+		tax     = "6ba7b810-9dad-11d1-80b4-00c04fd430c8"
+		listing = "6ba7b811-9dad-11d1-80b4-00c04fd430c8"
+	)
+	h := &StoreHandler{}
+
+	tests := []struct {
+		name        string
+		handler     gin.HandlerFunc
+		method      string
+		route       string
+		target      string
+		body        string
+		wantMessage string
+	}{
+		{"list with invalid store", h.ListStoreTaxes, http.MethodGet, "/stores/:id/taxes", "/stores/abc/taxes", "", "id must be a valid UUID"},
+		{"get with invalid tax", h.GetStoreTax, http.MethodGet, "/stores/:id/taxes/:taxId", "/stores/" + store + "/taxes/GST5", "", "taxId must be a valid UUID"},
+		{"update with blank name", h.UpdateStoreTax, http.MethodPut, "/stores/:id/taxes/:taxId", "/stores/" + store + "/taxes/" + tax, `{"name":"  "}`, "name must not be blank"},
+		{"update with negative rate", h.UpdateStoreTax, http.MethodPut, "/stores/:id/taxes/:taxId", "/stores/" + store + "/taxes/" + tax, `{"rate":-1}`, "rate"},
+		{"update with unknown type", h.UpdateStoreTax, http.MethodPut, "/stores/:id/taxes/:taxId", "/stores/" + store + "/taxes/" + tax, `{"tax_type":"slab"}`, "tax_type"},
+		{"update with percentage over 100", h.UpdateStoreTax, http.MethodPut, "/stores/:id/taxes/:taxId", "/stores/" + store + "/taxes/" + tax, `{"rate":120,"tax_type":"percentage"}`, "at most 100"},
+		{"deactivate with invalid store", h.DeactivateStoreTax, http.MethodDelete, "/stores/:id/taxes/:taxId", "/stores/abc/taxes/" + tax, "", "id must be a valid UUID"},
+		{"list product taxes with invalid listing", h.ListStoreProductTaxes, http.MethodGet, "/stores/:id/listings/:storeProductId/taxes", "/stores/" + store + "/listings/P1/taxes", "", "storeProductId must be a valid UUID"},
+		{"attach with invalid tax", h.AttachStoreProductTax, http.MethodPut, "/stores/:id/listings/:storeProductId/taxes/:taxId", "/stores/" + store + "/listings/" + listing + "/taxes/GST5", "", "taxId must be a valid UUID"},
+		{"attach with negative override", h.AttachStoreProductTax, http.MethodPut, "/stores/:id/listings/:storeProductId/taxes/:taxId", "/stores/" + store + "/listings/" + listing + "/taxes/" + tax, `{"override_rate":-2}`, "override_rate"},
+		{"detach with invalid listing", h.DetachStoreProductTax, http.MethodDelete, "/stores/:id/listings/:storeProductId/taxes/:taxId", "/stores/" + store + "/listings/P1/taxes/" + tax, "", "storeProductId must be a valid UUID"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(t, tt.handler, tt.method, tt.route, tt.target, tt.body)
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want 400: %s", w.Code, w.Body.String())
+			}
+			resp := decodeError(t, w)
+			if resp.Error.Code != "INVALID_INPUT" || !strings.Contains(resp.Error.Message, tt.wantMessage) {
+				t.Errorf("error = %s %q, want INVALID_INPUT mentioning %q", resp.Error.Code, resp.Error.Message, tt.wantMessage)
+			}
+		})
+	}
+}
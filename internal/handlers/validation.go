@@ -0,0 +1,270 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/yourusername/supabase-redis-middleware/internal/i18n"
+	"github.com/yourusername/supabase-redis-middleware/internal/repository"
+)
+
+// Validation errors name fields by their JSON names, as clients send them. The validator
+// caches each struct's field names the first time it validates one, so this must happen
+// before any request is bound
+func init() {
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(jsonFieldName)
+	}
+}
+
+// embeddedField names embedded structs, whose fields JSON promotes, so validatedField can
+// leave them out of paths
+const embeddedField = "~"
+
+// jsonFieldName returns the name field has in JSON, or "" to keep its Go name
+func jsonFieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	switch {
+	case name == "-":
+		return ""
+	case name == "" && field.Anonymous:
+		return embeddedField
+	}
+	return name
+}
+
+// fieldPath is an error decoding the value at a JSON path, such as products[3]
+type fieldPath struct {
+	path string
+	err  error
+}
+
+func (e *fieldPath) Error() string { return e.path + ": " + e.err.Error() }
+func (e *fieldPath) Unwrap() error { return e.err }
+
+// atPath reports err as an error decoding the value at path
+func atPath(path string, err error) error {
+	return &fieldPath{path: path, err: err}
+}
+
+// fieldErrors translates an error binding or validating a request into a problem per
+// field, named by its JSON path (products[3].price). Errors that aren't about one field,
+// such as malformed JSON, are a single problem with no field
+func fieldErrors(err error) []repository.FieldError {
+	prefix := ""
+	var at *fieldPath
+	for errors.As(err, &at) {
+		prefix = joinPath(prefix, at.path)
+		err = at.err
+	}
+
+	var invalid validator.ValidationErrors
+	if errors.As(err, &invalid) {
+		out := make([]repository.FieldError, len(invalid))
+		for i, fe := range invalid {
+			message, key, params := ruleMessage(fe)
+			out[i] = repository.FieldError{
+				Field:         joinPath(prefix, validatedField(fe)),
+				Rule:          fe.Tag(),
+				Message:       message,
+				MessageKey:    key,
+				MessageParams: params,
+			}
+		}
+		return out
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		name, jsonType := jsonTypeName(typeErr.Type)
+		return []repository.FieldError{{
+			Field:      joinPath(prefix, decodedField(typeErr.Field)),
+			Rule:       "type",
+			Message:    "must be " + name,
+			MessageKey: "type." + jsonType,
+		}}
+	}
+
+	// Truncated JSON ends the body mid-value rather than failing on a character
+	var syntaxErr *json.SyntaxError
+	rule := "invalid"
+	if errors.As(err, &syntaxErr) || errors.Is(err, io.ErrUnexpectedEOF) {
+		rule = "json"
+	}
+	return []repository.FieldError{{Field: prefix, Rule: rule, Message: err.Error(), MessageKey: "rule." + rule}}
+}
+
+// validatedField returns the JSON path of a field that failed validation, without the
+// request type it starts with or the embedded structs it was promoted from
+func validatedField(fe validator.FieldError) string {
+	_, path, _ := strings.Cut(fe.Namespace(), ".")
+	segments := strings.Split(path, ".")
+	return strings.Join(slices.DeleteFunc(segments, func(s string) bool { return s == embeddedField }), ".")
+}
+
+// decodedField returns the JSON path of a field encoding/json failed to decode, which
+// names array elements by their index (items.0.price), with the index in brackets as the
+// validator names it (items[0].price)
+func decodedField(field string) string {
+	segments := strings.Split(field, ".")
+	path := ""
+	for _, segment := range segments {
+		if _, err := strconv.Atoi(segment); err == nil {
+			segment = "[" + segment + "]"
+		}
+		path = joinPath(path, segment)
+	}
+	return path
+}
+
+// joinPath appends a field to a JSON path; indexes are appended as they are
+func joinPath(prefix, field string) string {
+	switch {
+	case prefix == "":
+		return field
+	case field == "" || strings.HasPrefix(field, "["):
+		return prefix + field
+	}
+	return prefix + "." + field
+}
+
+// ruleMessage describes the rule a field broke, returning with the description the key
+// and params that translate it
+func ruleMessage(fe validator.FieldError) (string, string, map[string]any) {
+	param := fe.Param()
+	countable := fe.Kind() == reflect.String || fe.Kind() == reflect.Slice || fe.Kind() == reflect.Map
+	unit := "items"
+	if fe.Kind() == reflect.String {
+		unit = "characters"
+	}
+	params := map[string]any{"param": param}
+
+	switch fe.Tag() {
+	case "required":
+		return "is required", "rule.required", nil
+	case "min":
+		if countable {
+			return fmt.Sprintf("must have at least %s %s", param, unit), "rule.min_" + unit, params
+		}
+		return "must be at least " + param, "rule.gte", params
+	case "max":
+		if countable {
+			return fmt.Sprintf("must have at most %s %s", param, unit), "rule.max_" + unit, params
+		}
+		return "must be at most " + param, "rule.lte", params
+	case "len":
+		return fmt.Sprintf("must have exactly %s %s", param, unit), "rule.len_" + unit, params
+	case "gt":
+		return "must be greater than " + param, "rule.gt", params
+	case "gte":
+		return "must be at least " + param, "rule.gte", params
+	case "lt":
+		return "must be less than " + param, "rule.lt", params
+	case "lte":
+		return "must be at most " + param, "rule.lte", params
+	case "oneof":
+		values := strings.Join(strings.Fields(param), ", ")
+		return "must be one of " + values, "rule.oneof", map[string]any{"values": values}
+	case "url":
+		return "must be a URL", "rule.url", nil
+	case "uuid":
+		return "must be a UUID", "rule.uuid", nil
+	case "email":
+		return "must be an email address", "rule.email", nil
+	}
+	return "must satisfy " + fe.Tag(), "rule.other", map[string]any{"rule": fe.Tag()}
+}
+
+// jsonTypeName names a Go type as the JSON type that decodes into it, returning the
+// type's name too
+func jsonTypeName(t reflect.Type) (string, string) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "a string", "string"
+	case reflect.Bool:
+		return "true or false", "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer", "integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number", "number"
+	case reflect.Slice, reflect.Array:
+		return "an array", "array"
+	}
+	return "an object", "object"
+}
+
+// invalidRequest writes a 400 INVALID_INPUT for a request that couldn't be bound or
+// validated, listing each problem under error.errors, or a 413 if its body was too large
+func invalidRequest(c *gin.Context, err error) {
+	if writeBodyTooLarge(c, err) {
+		return
+	}
+	problems := localizeFieldErrors(c.Request.Context(), fieldErrors(err))
+	invalidFields(c, describeFieldErrors(c.Request.Context(), problems), problems)
+}
+
+// invalidFields writes a 400 INVALID_INPUT with message, listing problems under error.errors
+func invalidFields(c *gin.Context, message string, problems []repository.FieldError) {
+	c.JSON(http.StatusBadRequest, gin.H{
+		"status": "error",
+		"error": gin.H{
+			"code":       "INVALID_INPUT",
+			"message":    message,
+			"request_id": requestID(c),
+			"errors":     problems,
+		},
+	})
+}
+
+// localizeFieldErrors returns problems with their messages translated into the language
+// of ctx
+func localizeFieldErrors(ctx context.Context, problems []repository.FieldError) []repository.FieldError {
+	out := make([]repository.FieldError, len(problems))
+	for i, problem := range problems {
+		if problem.MessageKey != "" {
+			problem.Message = i18n.T(ctx, problem.MessageKey, problem.Message, problem.MessageParams)
+		}
+		out[i] = problem
+	}
+	return out
+}
+
+// summarizeFieldErrors describes the first problem, and how many more there are
+func summarizeFieldErrors(problems []repository.FieldError) string {
+	return describeFieldErrors(context.Background(), problems)
+}
+
+// describeFieldErrors is summarizeFieldErrors in the language of ctx; problems' messages
+// must already be in it
+func describeFieldErrors(ctx context.Context, problems []repository.FieldError) string {
+	if len(problems) == 0 {
+		return i18n.T(ctx, "INVALID_INPUT", "Invalid request", nil)
+	}
+	first := problems[0].Message
+	if problems[0].Field != "" {
+		first = i18n.T(ctx, "INVALID_INPUT.field", problems[0].Field+" "+first, i18n.Params{"field": problems[0].Field, "message": first})
+	}
+	switch more := len(problems) - 1; more {
+	case 0:
+		return first
+	case 1:
+		return i18n.T(ctx, "INVALID_INPUT.one_more", first+" (and 1 more error)", i18n.Params{"message": first})
+	default:
+		return i18n.T(ctx, "INVALID_INPUT.more", fmt.Sprintf("%s (and %d more errors)", first, more), i18n.Params{"message": first, "count": more})
+	}
+}
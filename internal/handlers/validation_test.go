@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin/binding"
+)
+
+type validationItem struct {
+	SKU   string  `json:"sku" binding:"required"`
+	Price float64 `json:"price" binding:"gt=0"`
+}
+
+type validationNote struct {
+	Note string `json:"note" binding:"max=3"`
+}
+
+type validationRequest struct {
+	validationNote
+	Store struct {
+		ID   string `json:"id" binding:"required"`
+		Type string `json:"type" binding:"omitempty,oneof=supermarket pharmacy"`
+	} `json:"store"`
+	Items []validationItem `json:"items" binding:"required,min=1,dive"`
+	Tags  []string         `json:"tags" binding:"omitempty,dive,max=5"`
+}
+
+// problem is the part of a FieldError the tests compare
+type problem struct {
+	Field, Rule, Message string
+}
+
+func TestFieldErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		wrap func(error) error // As the push decoders report where in a payload the error is
+		want []problem
+	}{
+		{
+			name: "nested field",
+			body: `{"store":{"type":"bakery"},"items":[{"sku":"A","price":1}]}`,
+			want: []problem{
+				{"store.id", "required", "is required"},
+				{"store.type", "oneof", "must be one of supermarket, pharmacy"},
+			},
+		},
+		{
+			name: "array elements",
+			body: `{"store":{"id":"S1"},"items":[{"sku":"A","price":1},{"price":0}]}`,
+			want: []problem{
+				{"items[1].sku", "required", "is required"},
+				{"items[1].price", "gt", "must be greater than 0"},
+			},
+		},
+		{
+			name: "array of strings and an embedded field",
+			body: `{"note":"long","store":{"id":"S1"},"items":[{"sku":"A","price":1}],"tags":["ok","too long"]}`,
+			want: []problem{
+				{"note", "max", "must have at most 3 characters"},
+				{"tags[1]", "max", "must have at most 5 characters"},
+			},
+		},
+		{
+			name: "empty array",
+			body: `{"store":{"id":"S1"},"items":[]}`,
+			want: []problem{{"items", "min", "must have at least 1 items"}},
+		},
+		{
+			name: "wrong type in an array element",
+			body: `{"store":{"id":"S1"},"items":[{"sku":"A","price":"ten"}]}`,
+			want: []problem{{"items[0].price", "type", "must be a number"}},
+		},
+		{
+			name: "under a path",
+			body: `{"store":{"id":"S1"},"items":[{"price":1}]}`,
+			wrap: func(err error) error { return atPath("products", atPath("[3]", err)) },
+			want: []problem{{"products[3].items[0].sku", "required", "is required"}},
+		},
+		{
+			name: "wrong type under a path",
+			body: `{"store":{"id":7},"items":[{"sku":"A","price":1}]}`,
+			wrap: func(err error) error { return atPath("[2]", err) },
+			want: []problem{{"[2].store.id", "type", "must be a string"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req validationRequest
+			err := binding.JSON.BindBody([]byte(tt.body), &req)
+			if err == nil {
+				t.Fatal("BindBody() succeeded, want an error")
+			}
+			if tt.wrap != nil {
+				err = tt.wrap(err)
+			}
+
+			var got []problem
+			for _, fe := range fieldErrors(err) {
+				got = append(got, problem{fe.Field, fe.Rule, fe.Message})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("fieldErrors() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFieldErrors_NotAboutAField(t *testing.T) {
+	var req validationRequest
+	syntaxErr := binding.JSON.BindBody([]byte(`{"items":[`), &req)
+
+	tests := []struct {
+		name string
+		err  error
+		want problem
+	}{
+		{name: "malformed JSON", err: syntaxErr, want: problem{"", "json", syntaxErr.Error()}},
+		{name: "malformed JSON under a path", err: atPath("[4]", syntaxErr), want: problem{"[4]", "json", syntaxErr.Error()}},
+		{name: "empty body", err: binding.JSON.BindBody(nil, &req), want: problem{"", "invalid", "EOF"}},
+		{name: "other error", err: errors.New("body is empty"), want: problem{"", "invalid", "body is empty"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems := fieldErrors(tt.err)
+			if len(problems) != 1 {
+				t.Fatalf("fieldErrors() = %v, want one problem", problems)
+			}
+			if got := (problem{problems[0].Field, problems[0].Rule, problems[0].Message}); got != tt.want {
+				t.Errorf("fieldErrors() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDescribeFieldErrors(t *testing.T) {
+	var req validationRequest
+	err := binding.JSON.BindBody([]byte(`{"store":{},"items":[{"price":0}]}`), &req)
+	if err == nil {
+		t.Fatal("BindBody() succeeded, want an error")
+	}
+
+	if got, want := summarizeFieldErrors(fieldErrors(err)), "store.id is required (and 2 more errors)"; got != want {
+		t.Errorf("summarizeFieldErrors() = %q, want %q", got, want)
+	}
+	if got, want := summarizeFieldErrors(fieldErrors(errors.New("body is empty"))), "body is empty"; got != want {
+		t.Errorf("summarizeFieldErrors() of a non-field error = %q, want %q", got, want)
+	}
+}
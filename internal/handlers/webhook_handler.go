@@ -0,0 +1,283 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/yourusername/supabase-redis-middleware/internal/repository"
+	"github.com/yourusername/supabase-redis-middleware/internal/webhooks"
+	"go.uber.org/zap"
+)
+
+type WebhookHandler struct {
+	pgRepo *repository.PostgresRepository
+	logger *zap.Logger
+}
+
+func NewWebhookHandler(pgRepo *repository.PostgresRepository, logger *zap.Logger) *WebhookHandler {
+	return &WebhookHandler{
+		pgRepo: pgRepo,
+		logger: logger,
+	}
+}
+
+// CreateWebhookRequest is the body of POST /admin/webhooks
+// A subscription with a store_id is only sent that store's events. Without a secret, one
+// is generated
+type CreateWebhookRequest struct {
+	Name       string   `json:"name" binding:"required,max=100"`
+	URL        string   `json:"url" binding:"required,max=2000"`
+	Secret     string   `json:"secret" binding:"omitempty,min=16,max=200"`
+	EventTypes []string `json:"event_types" binding:"required,min=1"`
+	StoreID    *string  `json:"store_id" binding:"omitempty,uuid"`
+}
+
+// UpdateWebhookRequest is the body of PUT /admin/webhooks/:id; omitted fields are unchanged
+type UpdateWebhookRequest struct {
+	Name       *string  `json:"name" binding:"omitempty,max=100"`
+	URL        *string  `json:"url" binding:"omitempty,max=2000"`
+	Secret     *string  `json:"secret" binding:"omitempty,min=16,max=200"`
+	EventTypes []string `json:"event_types" binding:"omitempty,min=1"`
+	IsActive   *bool    `json:"is_active"`
+}
+
+// WebhookWithSecret is a subscription with its signing secret, returned only when the
+// secret is set
+type WebhookWithSecret struct {
+	*repository.WebhookSubscription
+	Secret string `json:"secret"`
+}
+
+// CreateWebhook subscribes a URL to event types; the signing secret is in the response and
+// can't be retrieved later
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	var req CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidRequest(c, err)
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		invalidInput(c, "name must not be blank")
+		return
+	}
+	if !validWebhookURL(c, req.URL) || !validEventTypes(c, req.EventTypes) {
+		return
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		var err error
+		if secret, err = webhooks.NewSecret(); err != nil {
+			requestLogger(c, h.logger).Error("Failed to generate webhook secret", zap.Error(err))
+			writeStoreError(c, err, "WEBHOOK_NOT_FOUND", "Failed to create webhook")
+			return
+		}
+	}
+
+	slices.Sort(req.EventTypes)
+	subscription, err := h.pgRepo.CreateWebhookSubscription(c.Request.Context(), repository.WebhookSubscriptionInput{
+		Name:       name,
+		URL:        req.URL,
+		Secret:     secret,
+		EventTypes: slices.Compact(req.EventTypes),
+		StoreID:    req.StoreID,
+	})
+	if err != nil {
+		requestLogger(c, h.logger).Error("Failed to create webhook", zap.String("name", name), zap.Error(err))
+		writeStoreError(c, err, "STORE_NOT_FOUND", "Failed to create webhook")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"data":    WebhookWithSecret{WebhookSubscription: subscription, Secret: secret},
+		"message": "Webhook created; store the secret now, it won't be shown again",
+	})
+}
+
+// ListWebhooks lists webhook subscriptions, newest first
+// GET /api/v1/admin/webhooks?limit=20&offset=0
+func (h *WebhookHandler) ListWebhooks(c *gin.Context) {
+	list, ok := listQuery(c)
+	if !ok {
+		return
+	}
+
+	subscriptions, err := h.pgRepo.ListWebhookSubscriptions(c.Request.Context(), list.Pagination)
+	if err != nil {
+		writeStoreError(c, err, "WEBHOOK_NOT_FOUND", "Failed to list webhooks")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   subscriptions,
+	})
+}
+
+// GetWebhook returns a webhook subscription
+func (h *WebhookHandler) GetWebhook(c *gin.Context) {
+	id, ok := webhookID(c)
+	if !ok {
+		return
+	}
+
+	subscription, err := h.pgRepo.GetWebhookSubscription(c.Request.Context(), id)
+	if err != nil {
+		writeStoreError(c, err, "WEBHOOK_NOT_FOUND", "Failed to get webhook")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   subscription,
+	})
+}
+
+// UpdateWebhook changes a webhook subscription; deliveries already queued are sent with its
+// new URL and secret. A new secret is returned with it
+func (h *WebhookHandler) UpdateWebhook(c *gin.Context) {
+	id, ok := webhookID(c)
+	if !ok {
+		return
+	}
+	var req UpdateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidRequest(c, err)
+		return
+	}
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			invalidInput(c, "name must not be blank")
+			return
+		}
+		req.Name = &name
+	}
+	if req.URL != nil && !validWebhookURL(c, *req.URL) {
+		return
+	}
+	if req.EventTypes != nil {
+		if !validEventTypes(c, req.EventTypes) {
+			return
+		}
+		slices.Sort(req.EventTypes)
+		req.EventTypes = slices.Compact(req.EventTypes)
+	}
+
+	subscription, err := h.pgRepo.UpdateWebhookSubscription(c.Request.Context(), id, repository.WebhookSubscriptionUpdate{
+		Name:       req.Name,
+		URL:        req.URL,
+		Secret:     req.Secret,
+		EventTypes: req.EventTypes,
+		IsActive:   req.IsActive,
+	})
+	if err != nil {
+		requestLogger(c, h.logger).Error("Failed to update webhook", zap.String("webhook_id", id), zap.Error(err))
+		writeStoreError(c, err, "WEBHOOK_NOT_FOUND", "Failed to update webhook")
+		return
+	}
+
+	var data any = subscription
+	if req.Secret != nil {
+		data = WebhookWithSecret{WebhookSubscription: subscription, Secret: *req.Secret}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"data":    data,
+		"message": "Webhook updated successfully",
+	})
+}
+
+// DeleteWebhook deletes a webhook subscription and its delivery log
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	id, ok := webhookID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.pgRepo.DeleteWebhookSubscription(c.Request.Context(), id); err != nil {
+		requestLogger(c, h.logger).Error("Failed to delete webhook", zap.String("webhook_id", id), zap.Error(err))
+		writeStoreError(c, err, "WEBHOOK_NOT_FOUND", "Failed to delete webhook")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Webhook deleted successfully",
+	})
+}
+
+// ListWebhookDeliveries lists a webhook's deliveries, newest first, with each one's
+// attempts, last response status and error
+// GET /api/v1/admin/webhooks/:id/deliveries?status=failed&limit=20&offset=0
+func (h *WebhookHandler) ListWebhookDeliveries(c *gin.Context) {
+	id, ok := webhookID(c)
+	if !ok {
+		return
+	}
+	status := c.Query("status")
+	switch status {
+	case "", repository.WebhookPending, repository.WebhookSucceeded, repository.WebhookFailed:
+	default:
+		invalidInput(c, "status must be pending, succeeded or failed")
+		return
+	}
+	list, ok := listQuery(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	// Distinguishes a missing subscription from one with no deliveries
+	if _, err := h.pgRepo.GetWebhookSubscription(ctx, id); err != nil {
+		writeStoreError(c, err, "WEBHOOK_NOT_FOUND", "Failed to list webhook deliveries")
+		return
+	}
+	deliveries, err := h.pgRepo.ListWebhookDeliveries(ctx, id, status, list.Pagination)
+	if err != nil {
+		writeStoreError(c, err, "WEBHOOK_NOT_FOUND", "Failed to list webhook deliveries")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   deliveries,
+	})
+}
+
+// webhookID returns the route's webhook ID, writing a 400 if it isn't a UUID
+func webhookID(c *gin.Context) (string, bool) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		invalidUUID(c, "id")
+		return "", false
+	}
+	return id, true
+}
+
+// validWebhookURL reports whether raw is an absolute http or https URL, writing a 400 if not
+func validWebhookURL(c *gin.Context, raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		invalidInput(c, "url must be an absolute http or https URL")
+		return false
+	}
+	return true
+}
+
+// validEventTypes reports whether every event type is known, writing a 400 if not
+func validEventTypes(c *gin.Context, eventTypes []string) bool {
+	for _, eventType := range eventTypes {
+		if !slices.Contains(webhooks.EventTypes, eventType) {
+			invalidInput(c, "unknown event type "+eventType+"; expected one of "+strings.Join(webhooks.EventTypes, ", "))
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,121 @@
+package https
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// Options configures where the server's TLS certificates come from: CertFile and KeyFile,
+// or Let's Encrypt for AutocertDomains
+type Options struct {
+	CertFile         string   // PEM certificate chain
+	KeyFile          string   // PEM private key of the certificate
+	AutocertDomains  []string // Domains to obtain and renew certificates for, instead of the files
+	AutocertCacheDir string   // Where obtained certificates are kept across restarts
+	AutocertEmail    string   // Contact for the ACME account, told of problems with certificates
+}
+
+// TLS terminates TLS for the HTTP server, negotiating HTTP/2 with clients that support it
+type TLS struct {
+	config   *tls.Config
+	manager  *autocert.Manager
+	certFile string
+	keyFile  string
+	cert     atomic.Pointer[tls.Certificate]
+}
+
+// New returns a TLS serving the certificate in opts.CertFile and opts.KeyFile, or, when
+// opts.AutocertDomains are set, certificates obtained from Let's Encrypt as clients first
+// connect to each domain
+func New(opts Options) (*TLS, error) {
+	if len(opts.AutocertDomains) > 0 {
+		manager := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(opts.AutocertDomains...),
+			Email:      opts.AutocertEmail,
+		}
+		if opts.AutocertCacheDir != "" {
+			manager.Cache = autocert.DirCache(opts.AutocertCacheDir)
+		}
+		// Offers h2, http/1.1 and acme-tls/1, for the CA's TLS-ALPN-01 challenges
+		config := manager.TLSConfig()
+		config.MinVersion = tls.VersionTLS12
+		return &TLS{config: config, manager: manager}, nil
+	}
+
+	if opts.CertFile == "" || opts.KeyFile == "" {
+		return nil, errors.New("a certificate and key file, or autocert domains, are required")
+	}
+	t := &TLS{certFile: opts.CertFile, keyFile: opts.KeyFile}
+	if err := t.Reload(); err != nil {
+		return nil, err
+	}
+	t.config = &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		NextProtos: []string{"h2", "http/1.1"},
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return t.cert.Load(), nil
+		},
+	}
+	return t, nil
+}
+
+// Config returns the TLS configuration for the HTTP server, which serves HTTP/2 when it
+// is started with ServeTLS or ListenAndServeTLS
+func (t *TLS) Config() *tls.Config {
+	return t.config
+}
+
+// Reload re-reads the certificate and key files, so a renewed certificate is served to
+// connections from then on. If they can't be read, the current certificate is kept. It
+// does nothing for certificates from Let's Encrypt, which are renewed as they near expiry
+func (t *TLS) Reload() error {
+	if t.manager != nil {
+		return nil
+	}
+	cert, err := tls.LoadX509KeyPair(t.certFile, t.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+	t.cert.Store(&cert)
+	return nil
+}
+
+// RedirectHandler redirects plain HTTP requests to the same URL over HTTPS on port. With
+// Let's Encrypt it first answers the CA's HTTP-01 challenges, which arrive on port 80
+func (t *TLS) RedirectHandler(port string) http.Handler {
+	redirect := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		host = strings.Trim(host, "[]")
+		if host == "" {
+			http.Error(w, "Host header required", http.StatusBadRequest)
+			return
+		}
+		if port != "443" {
+			host = net.JoinHostPort(host, port)
+		} else if strings.Contains(host, ":") {
+			host = "[" + host + "]"
+		}
+
+		// 308 keeps the method and body of writes; browsers cache 301 for GETs
+		status := http.StatusPermanentRedirect
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			status = http.StatusMovedPermanently
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), status)
+	})
+	if t.manager != nil {
+		return t.manager.HTTPHandler(redirect)
+	}
+	return redirect
+}
@@ -0,0 +1,164 @@
+package https
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeCertificate writes a self-signed certificate for 127.0.0.1 with serial to
+// certFile and its key to keyFile, and returns the certificate
+func writeCertificate(t *testing.T, certFile, keyFile string, serial int64) *x509.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		Subject:               pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func TestTLS_ServesHTTP2AndReloads(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	first := writeCertificate(t, certFile, keyFile, 1)
+
+	s, err := New(Options{CertFile: certFile, KeyFile: keyFile})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &http.Server{
+		Handler:   http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+		TLSConfig: s.Config(),
+	}
+	go server.ServeTLS(listener, "", "")
+	defer server.Close()
+
+	get := func(trusted *x509.Certificate) *http.Response {
+		t.Helper()
+		roots := x509.NewCertPool()
+		roots.AddCert(trusted)
+		client := &http.Client{Transport: &http.Transport{
+			TLSClientConfig:   &tls.Config{RootCAs: roots},
+			ForceAttemptHTTP2: true,
+		}}
+		defer client.CloseIdleConnections()
+		resp, err := client.Get("https://" + listener.Addr().String())
+		if err != nil {
+			t.Fatalf("GET error = %v", err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	if resp := get(first); resp.ProtoMajor != 2 {
+		t.Errorf("protocol = %s, want HTTP/2", resp.Proto)
+	}
+
+	second := writeCertificate(t, certFile, keyFile, 2)
+	if err := s.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if serial := get(second).TLS.PeerCertificates[0].SerialNumber; serial.Int64() != 2 {
+		t.Errorf("serial after Reload() = %v, want 2", serial)
+	}
+
+	if err := os.WriteFile(certFile, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Reload(); err == nil {
+		t.Error("Reload() of an invalid certificate succeeded, want an error")
+	}
+	if serial := get(second).TLS.PeerCertificates[0].SerialNumber; serial.Int64() != 2 {
+		t.Errorf("serial after a failed Reload() = %v, want 2", serial)
+	}
+}
+
+func TestTLS_RedirectHandler(t *testing.T) {
+	s, err := New(Options{AutocertDomains: []string{"api.example.com"}})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		host       string
+		port       string
+		wantStatus int
+		wantURL    string
+	}{
+		{name: "get", method: http.MethodGet, host: "api.example.com", port: "443", wantStatus: http.StatusMovedPermanently, wantURL: "https://api.example.com/api/v1/stores?page=2"},
+		{name: "write", method: http.MethodPost, host: "api.example.com:80", port: "443", wantStatus: http.StatusPermanentRedirect, wantURL: "https://api.example.com/api/v1/stores?page=2"},
+		{name: "other port", method: http.MethodGet, host: "api.example.com:8080", port: "8443", wantStatus: http.StatusMovedPermanently, wantURL: "https://api.example.com:8443/api/v1/stores?page=2"},
+		{name: "ipv6", method: http.MethodGet, host: "[::1]:80", port: "443", wantStatus: http.StatusMovedPermanently, wantURL: "https://[::1]/api/v1/stores?page=2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/api/v1/stores?page=2", nil)
+			req.Host = tt.host
+			rec := httptest.NewRecorder()
+			s.RedirectHandler(tt.port).ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := rec.Header().Get("Location"); got != tt.wantURL {
+				t.Errorf("Location = %q, want %q", got, tt.wantURL)
+			}
+		})
+	}
+}
+
+func TestNew_RequiresCertificate(t *testing.T) {
+	if _, err := New(Options{CertFile: "tls.crt"}); err == nil {
+		t.Error("New() without a key file succeeded, want an error")
+	}
+	if _, err := New(Options{CertFile: "missing.crt", KeyFile: "missing.key"}); err == nil {
+		t.Error("New() with missing files succeeded, want an error")
+	}
+}
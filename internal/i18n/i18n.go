@@ -0,0 +1,189 @@
+// Package i18n translates the messages of error responses into the language clients ask
+// for with Accept-Language. Error codes are the same in every language. English messages
+// are written where the errors are; a catalog holds their translations, by key
+package i18n
+
+import (
+	"cmp"
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+//go:embed locales/*.json
+var builtinLocales embed.FS
+
+// Params fill in the {name} placeholders of a message
+type Params map[string]any
+
+// placeholder matches a placeholder left unfilled
+var placeholder = regexp.MustCompile(`\{[a-z_]+\}`)
+
+// Catalog holds the translations of messages by locale and key
+// Keys are an error code (FORBIDDEN) for its general message, or the code followed by a
+// dot and a name (FORBIDDEN.scope) for one of its particular messages
+type Catalog struct {
+	messages map[string]map[string]string // By lowercase locale, then key
+}
+
+// NewCatalog returns the built-in catalog extended with the <locale>.json files in dir,
+// if set, each an object of messages by key; their messages replace built-in ones
+func NewCatalog(dir string) (*Catalog, error) {
+	c := &Catalog{messages: map[string]map[string]string{}}
+	if err := c.load(builtinLocales, "locales"); err != nil {
+		return nil, err
+	}
+	if dir != "" {
+		if err := c.load(os.DirFS(dir), "."); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// load adds the messages of the <locale>.json files in dir of fsys
+func (c *Catalog) load(fsys fs.FS, dir string) error {
+	files, err := fs.Glob(fsys, path.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return fmt.Errorf("failed to read message catalog %s: %w", file, err)
+		}
+		var messages map[string]string
+		if err := json.Unmarshal(data, &messages); err != nil {
+			return fmt.Errorf("invalid message catalog %s: %w", file, err)
+		}
+
+		locale := strings.ToLower(strings.TrimSuffix(path.Base(file), ".json"))
+		if c.messages[locale] == nil {
+			c.messages[locale] = map[string]string{}
+		}
+		for key, message := range messages {
+			c.messages[locale][key] = message
+		}
+	}
+	return nil
+}
+
+// Locales returns the locales c has messages in, sorted
+func (c *Catalog) Locales() []string {
+	locales := make([]string, 0, len(c.messages))
+	for locale := range c.messages {
+		locales = append(locales, locale)
+	}
+	slices.Sort(locales)
+	return locales
+}
+
+// Negotiate returns the locale of c an Accept-Language header prefers, or "" for English,
+// the language of messages without a translation. A language tag matches a locale of
+// the same tag, or of its primary language (hi-IN matches hi); English, or nothing
+// matching, leaves messages in English
+func (c *Catalog) Negotiate(acceptLanguage string) string {
+	type choice struct {
+		tag     string
+		quality float64
+	}
+	var choices []choice
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		quality := 1.0
+		if q, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			parsed, err := strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+		if tag == "" || tag == "*" || quality <= 0 {
+			continue
+		}
+		choices = append(choices, choice{tag, quality})
+	}
+	slices.SortStableFunc(choices, func(a, b choice) int { return cmp.Compare(b.quality, a.quality) })
+
+	for _, choice := range choices {
+		primary, _, _ := strings.Cut(choice.tag, "-")
+		switch {
+		case primary == "en":
+			return ""
+		case c.messages[choice.tag] != nil:
+			return choice.tag
+		case c.messages[primary] != nil:
+			return primary
+		}
+	}
+	return ""
+}
+
+// Message returns the message for key in locale with its placeholders filled in from
+// params, or the general message of the code key starts with if it has none, reporting
+// whether there was one with every placeholder filled
+func (c *Catalog) Message(locale, key string, params Params) (string, bool) {
+	messages := c.messages[locale]
+	message, ok := messages[key]
+	if !ok {
+		code, _, _ := strings.Cut(key, ".")
+		message, ok = messages[code]
+	}
+	if !ok {
+		return "", false
+	}
+
+	if len(params) > 0 {
+		replacements := make([]string, 0, 2*len(params))
+		for name, value := range params {
+			replacements = append(replacements, "{"+name+"}", fmt.Sprint(value))
+		}
+		message = strings.NewReplacer(replacements...).Replace(message)
+	}
+	if placeholder.MatchString(message) {
+		return "", false
+	}
+	return message, true
+}
+
+type localeKey struct{}
+
+// localized is the locale messages are translated into, and the catalog they come from
+type localized struct {
+	catalog *Catalog
+	locale  string
+}
+
+// WithLocale returns ctx whose messages are translated into locale from catalog; an
+// empty locale leaves them in English
+func WithLocale(ctx context.Context, catalog *Catalog, locale string) context.Context {
+	return context.WithValue(ctx, localeKey{}, localized{catalog: catalog, locale: locale})
+}
+
+// Locale returns the locale messages are translated into for ctx, or "" for English
+func Locale(ctx context.Context) string {
+	l, _ := ctx.Value(localeKey{}).(localized)
+	return l.locale
+}
+
+// T returns the message for key in the locale of ctx, with its placeholders filled in
+// from params, or text, the message in English, if the locale has none
+func T(ctx context.Context, key, text string, params Params) string {
+	l, _ := ctx.Value(localeKey{}).(localized)
+	if l.catalog == nil || l.locale == "" {
+		return text
+	}
+	if message, ok := l.catalog.Message(l.locale, key, params); ok {
+		return message
+	}
+	return text
+}
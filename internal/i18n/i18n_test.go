@@ -0,0 +1,122 @@
+package i18n
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCatalog_Negotiate(t *testing.T) {
+	catalog, err := NewCatalog("")
+	if err != nil {
+		t.Fatalf("NewCatalog() error = %v", err)
+	}
+
+	tests := []struct {
+		acceptLanguage string
+		want           string
+	}{
+		{acceptLanguage: "", want: ""},
+		{acceptLanguage: "hi", want: "hi"},
+		{acceptLanguage: "hi-IN", want: "hi"},
+		{acceptLanguage: "HI-in;q=0.8", want: "hi"},
+		{acceptLanguage: "en-IN,hi;q=0.9", want: ""},
+		{acceptLanguage: "en;q=0.5,hi;q=0.9", want: "hi"},
+		{acceptLanguage: "ta-IN,hi;q=0.7", want: "hi"},
+		{acceptLanguage: "ta-IN", want: ""},
+		{acceptLanguage: "hi;q=0", want: ""},
+		{acceptLanguage: "hi;q=abc", want: ""},
+		{acceptLanguage: "*", want: ""},
+	}
+	for _, tt := range tests {
+		if got := catalog.Negotiate(tt.acceptLanguage); got != tt.want {
+			t.Errorf("Negotiate(%q) = %q, want %q", tt.acceptLanguage, got, tt.want)
+		}
+	}
+}
+
+func TestCatalog_Message(t *testing.T) {
+	catalog, err := NewCatalog("")
+	if err != nil {
+		t.Fatalf("NewCatalog() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		key    string
+		params Params
+		want   string
+		wantOK bool
+	}{
+		{name: "code", key: "STORE_NOT_FOUND", want: "स्टोर नहीं मिला", wantOK: true},
+		{name: "params", key: "INVALID_INPUT.uuid", params: Params{"name": "id"}, want: "id एक मान्य UUID होना चाहिए", wantOK: true},
+		{name: "general message of code", key: "INVALID_INPUT.unknown", want: "अनुरोध अमान्य है", wantOK: true},
+		{name: "unfilled placeholder", key: "INVALID_INPUT.uuid"},
+		{name: "unknown", key: "rule.unknown"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := catalog.Message("hi", tt.key, tt.params)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("Message(hi, %s) = %q, %v, want %q, %v", tt.key, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestNewCatalog_Dir(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"hi.json": `{"STORE_NOT_FOUND": "दुकान नहीं मिली"}`,
+		"ta.json": `{"STORE_NOT_FOUND": "கடை கிடைக்கவில்லை"}`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	catalog, err := NewCatalog(dir)
+	if err != nil {
+		t.Fatalf("NewCatalog() error = %v", err)
+	}
+	if got := catalog.Locales(); len(got) != 2 || got[0] != "hi" || got[1] != "ta" {
+		t.Errorf("Locales() = %v, want [hi ta]", got)
+	}
+	if got, _ := catalog.Message("hi", "STORE_NOT_FOUND", nil); got != "दुकान नहीं मिली" {
+		t.Errorf("Message(hi, STORE_NOT_FOUND) = %q, want the message from dir", got)
+	}
+	if _, ok := catalog.Message("hi", "TIMEOUT", nil); !ok {
+		t.Error("Message(hi, TIMEOUT) found nothing, want the built-in message")
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "mr.json"), []byte(`["not", "an", "object"]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewCatalog(dir); err == nil {
+		t.Error("NewCatalog() with an invalid file succeeded, want an error")
+	}
+}
+
+func TestT(t *testing.T) {
+	catalog, err := NewCatalog("")
+	if err != nil {
+		t.Fatalf("NewCatalog() error = %v", err)
+	}
+	const text = "Store not found"
+
+	if got := T(context.Background(), "STORE_NOT_FOUND", text, nil); got != text {
+		t.Errorf("T() without a locale = %q, want %q", got, text)
+	}
+	if got := T(WithLocale(context.Background(), catalog, ""), "STORE_NOT_FOUND", text, nil); got != text {
+		t.Errorf("T() in English = %q, want %q", got, text)
+	}
+	ctx := WithLocale(context.Background(), catalog, "hi")
+	if got := T(ctx, "STORE_NOT_FOUND", text, nil); got != "स्टोर नहीं मिला" {
+		t.Errorf("T() in Hindi = %q, want the Hindi message", got)
+	}
+	if got := T(ctx, "rule.unknown", "must be shiny", nil); got != "must be shiny" {
+		t.Errorf("T() of a key without a message = %q, want the English text", got)
+	}
+}
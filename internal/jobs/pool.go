@@ -0,0 +1,489 @@
+// Package jobs runs background work on a Redis-backed queue shared by every instance.
+// Jobs are published to a Redis stream and read through a consumer group, so each is run
+// by one instance; failed jobs are retried with exponential backoff from a delay queue,
+// and those that fail every attempt are kept in a dead-letter stream
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yourusername/supabase-redis-middleware/internal/cache"
+	"go.uber.org/zap"
+)
+
+const (
+	// retryBaseDelay is the wait before the first retry; each later one waits twice as long
+	retryBaseDelay = 5 * time.Second
+	retryMaxDelay  = time.Hour
+
+	// readBlock bounds how long an idle pool waits for a job before checking it is stopping
+	readBlock = 2 * time.Second
+
+	// deadLetterLen caps the dead-letter stream (approximately)
+	deadLetterLen = 10000
+
+	// maxErrorLength bounds the error kept with a dead job
+	maxErrorLength = 1000
+
+	group = "workers"
+)
+
+// Broker is the Redis the pool queues jobs on
+type Broker interface {
+	cache.StreamQueue
+	cache.EventLog
+	cache.DelayQueue
+}
+
+// Job is a unit of work
+// ID is the same on every attempt; Attempt is 1 on the first
+type Job struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	Payload    json.RawMessage `json:"payload"`
+	Attempt    int             `json:"attempt"`
+	EnqueuedAt time.Time       `json:"enqueued_at"`
+}
+
+// DeadJob is a job that failed its last attempt, with why
+// EntryID is its ID in the dead-letter stream, to page by
+type DeadJob struct {
+	Job
+	Error   string    `json:"error"`
+	DiedAt  time.Time `json:"died_at"`
+	EntryID string    `json:"entry_id,omitempty"`
+}
+
+// Handler runs a job. A returned error retries it; handlers should stop when ctx is
+// canceled, as it is when the pool stops and its shutdown timeout passes
+type Handler func(ctx context.Context, job Job) error
+
+// ErrUnknownJobType is the error of jobs no handler is registered for. They are retried
+// like any failure, as during a rolling deploy other instances may know the type
+var ErrUnknownJobType = errors.New("no handler is registered for the job type")
+
+// PoolOptions controls how jobs are run
+type PoolOptions struct {
+	Queue           string        // Name of the stream jobs are queued on
+	Concurrency     int           // Jobs run at once, per instance
+	MaxAttempts     int           // Attempts before a job is dead-lettered
+	ClaimAfter      time.Duration // How long a job may run before it is assumed lost and run again
+	ShutdownTimeout time.Duration // How long Stop waits for running jobs before canceling them
+}
+
+// Pool runs queued jobs with their registered handlers. Register handlers, then Start;
+// jobs may be enqueued from any instance, whether or not it runs a pool
+type Pool struct {
+	broker   Broker
+	opts     PoolOptions
+	consumer string
+	logger   *zap.Logger
+
+	handlers map[string]Handler
+	active   sync.Map // IDs of the messages of running jobs
+	// grouped is set once the consumer group exists. Groups start at the end of the
+	// stream, so it must exist before the first job is published or that job is skipped
+	grouped atomic.Bool
+
+	stopIntake context.CancelFunc // Stops reading new jobs
+	cancelWork context.CancelFunc // Cancels running jobs
+	intake     sync.WaitGroup
+	running    sync.WaitGroup
+}
+
+// NewPool creates a pool; register handlers and call Start to run it
+func NewPool(broker Broker, opts PoolOptions, logger *zap.Logger) *Pool {
+	host, _ := os.Hostname()
+	return &Pool{
+		broker:   broker,
+		opts:     opts,
+		consumer: fmt.Sprintf("%s-%s", host, uuid.NewString()[:8]),
+		logger:   logger.With(zap.String("queue", opts.Queue)),
+		handlers: map[string]Handler{},
+	}
+}
+
+// Register sets the handler of jobType's jobs; it must be called before Start
+func (p *Pool) Register(jobType string, handler Handler) {
+	p.handlers[jobType] = handler
+}
+
+// Enqueue queues a job of jobType with payload encoded as JSON, returning its ID
+func (p *Pool) Enqueue(ctx context.Context, jobType string, payload any) (string, error) {
+	job, err := newJob(jobType, payload)
+	if err != nil {
+		return "", err
+	}
+	if err := p.publish(ctx, job); err != nil {
+		return "", err
+	}
+	return job.ID, nil
+}
+
+// EnqueueAt queues a job of jobType to run once at has passed, returning its ID
+func (p *Pool) EnqueueAt(ctx context.Context, jobType string, payload any, at time.Time) (string, error) {
+	job, err := newJob(jobType, payload)
+	if err != nil {
+		return "", err
+	}
+	if err := p.schedule(ctx, job, at); err != nil {
+		return "", err
+	}
+	return job.ID, nil
+}
+
+// DeadJobs returns up to count dead-lettered jobs, oldest first, after the entry with ID
+// after ("0" for the first). The dead-letter stream keeps the last 10000 or so
+func (p *Pool) DeadJobs(ctx context.Context, after string, count int64) ([]DeadJob, error) {
+	messages, err := p.broker.ReadAfter(ctx, p.deadQueue(), after, count)
+	if err != nil {
+		return nil, err
+	}
+	dead := make([]DeadJob, 0, len(messages))
+	for _, m := range messages {
+		var job DeadJob
+		encoded, _ := m.Values["job"].(string)
+		if err := json.Unmarshal([]byte(encoded), &job); err != nil {
+			continue
+		}
+		job.EntryID = m.ID
+		dead = append(dead, job)
+	}
+	return dead, nil
+}
+
+// Start runs queued jobs in the background until Stop is called
+func (p *Pool) Start() error {
+	if err := p.ensureGroup(context.Background()); err != nil {
+		return err
+	}
+
+	intake, stopIntake := context.WithCancel(context.Background())
+	work, cancelWork := context.WithCancel(context.Background())
+	p.stopIntake = stopIntake
+	p.cancelWork = cancelWork
+
+	p.intake.Add(2)
+	go p.read(intake, work)
+	go p.maintain(intake)
+
+	p.logger.Info("Job pool started",
+		zap.String("consumer", p.consumer),
+		zap.Int("concurrency", p.opts.Concurrency),
+		zap.Int("job_types", len(p.handlers)))
+	return nil
+}
+
+// Stop stops taking jobs and waits for those running, canceling them once the shutdown
+// timeout passes. Jobs cut short are run again, by any instance, after ClaimAfter
+func (p *Pool) Stop() {
+	p.stopIntake()
+	p.intake.Wait()
+
+	finished := make(chan struct{})
+	go func() {
+		p.running.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+	case <-time.After(p.opts.ShutdownTimeout):
+		p.logger.Warn("Canceling running jobs after the shutdown timeout")
+		p.cancelWork()
+		<-finished
+	}
+	p.cancelWork()
+	p.logger.Info("Job pool stopped")
+}
+
+// read takes jobs from the queue as slots free up, running each in its own goroutine
+func (p *Pool) read(intake, work context.Context) {
+	defer p.intake.Done()
+
+	slots := make(chan struct{}, p.opts.Concurrency)
+	for {
+		// Wait for a slot, then take the others free, to read as many jobs as can run
+		select {
+		case slots <- struct{}{}:
+		case <-intake.Done():
+			return
+		}
+		free := 1
+	fill:
+		for free < p.opts.Concurrency {
+			select {
+			case slots <- struct{}{}:
+				free++
+			default:
+				break fill
+			}
+		}
+
+		messages, err := p.broker.ReadGroup(intake, p.opts.Queue, group, p.consumer, int64(free), readBlock)
+		if err != nil && intake.Err() == nil {
+			p.logger.Warn("Failed to read jobs", zap.Error(err))
+			// Back off rather than spin while Redis is unavailable
+			select {
+			case <-time.After(readBlock):
+			case <-intake.Done():
+			}
+		}
+		for i := len(messages); i < free; i++ {
+			<-slots
+		}
+
+		for _, m := range messages {
+			p.running.Add(1)
+			p.active.Store(m.ID, true)
+			go func() {
+				defer p.running.Done()
+				defer func() { <-slots }()
+				defer p.active.Delete(m.ID)
+				p.process(work, m)
+			}()
+		}
+		if intake.Err() != nil {
+			return
+		}
+	}
+}
+
+// maintain moves retries and scheduled jobs that are due onto the queue, and requeues
+// jobs whose consumer stopped without finishing them
+func (p *Pool) maintain(intake context.Context) {
+	defer p.intake.Done()
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	lastClaim := time.Now()
+	for {
+		select {
+		case <-intake.Done():
+			return
+		case <-ticker.C:
+		}
+
+		p.releaseDue(intake)
+		if time.Since(lastClaim) >= p.opts.ClaimAfter/2 {
+			p.requeueLost(intake)
+			lastClaim = time.Now()
+		}
+	}
+}
+
+// releaseDue publishes the delayed jobs that are due
+func (p *Pool) releaseDue(ctx context.Context) {
+	items, err := p.broker.PopDue(ctx, p.delayQueue(), time.Now(), 100)
+	if err != nil {
+		if ctx.Err() == nil {
+			p.logger.Warn("Failed to read due jobs", zap.Error(err))
+		}
+		return
+	}
+	for _, item := range items {
+		var job Job
+		if err := json.Unmarshal([]byte(item), &job); err != nil {
+			p.logger.Error("Dropping undecodable delayed job", zap.Error(err))
+			continue
+		}
+		if err := p.publish(ctx, job); err != nil {
+			// Put it back, so it isn't lost
+			p.logger.Warn("Failed to queue due job", zap.String("job_id", job.ID), zap.Error(err))
+			_ = p.broker.ScheduleAt(context.Background(), p.delayQueue(), item, time.Now().Add(time.Second))
+		}
+	}
+}
+
+// requeueLost takes over jobs read ClaimAfter ago and never acknowledged, e.g. because
+// their instance crashed, and queues their next attempt. This instance's own jobs still
+// running are left to finish; claiming them only restarts their timeout
+func (p *Pool) requeueLost(ctx context.Context) {
+	messages, err := p.broker.ClaimPending(ctx, p.opts.Queue, group, p.consumer, p.opts.ClaimAfter, 100)
+	if err != nil {
+		if ctx.Err() == nil {
+			p.logger.Warn("Failed to claim lost jobs", zap.Error(err))
+		}
+		return
+	}
+	for _, m := range messages {
+		if _, running := p.active.Load(m.ID); running {
+			continue
+		}
+		job, err := decodeJob(m)
+		if err != nil {
+			p.logger.Error("Dropping undecodable job", zap.String("message_id", m.ID), zap.Error(err))
+			p.ack(m.ID)
+			continue
+		}
+		p.logger.Warn("Job was not finished in time; retrying",
+			zap.String("job_id", job.ID),
+			zap.String("type", job.Type),
+			zap.Int("attempt", job.Attempt))
+		p.settle(ctx, m.ID, job, errors.New("not finished within the claim timeout"))
+	}
+}
+
+// process runs a job read from the queue and settles its outcome
+func (p *Pool) process(ctx context.Context, m cache.StreamMessage) {
+	job, err := decodeJob(m)
+	if err != nil {
+		p.logger.Error("Dropping undecodable job", zap.String("message_id", m.ID), zap.Error(err))
+		p.ack(m.ID)
+		return
+	}
+
+	started := time.Now()
+	err = p.run(ctx, job)
+	if ctx.Err() != nil {
+		// Shutting down; the job is left pending, to be run again after ClaimAfter
+		return
+	}
+	if err == nil {
+		p.logger.Debug("Job finished",
+			zap.String("job_id", job.ID),
+			zap.String("type", job.Type),
+			zap.Duration("duration", time.Since(started)))
+	}
+	// Settling uses its own context, so a job that finished isn't run again
+	p.settle(context.Background(), m.ID, job, err)
+}
+
+// run calls the job's handler, turning panics into errors
+func (p *Pool) run(ctx context.Context, job Job) (err error) {
+	handler, ok := p.handlers[job.Type]
+	if !ok {
+		return ErrUnknownJobType
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			p.logger.Error("Job panicked",
+				zap.String("job_id", job.ID),
+				zap.String("type", job.Type),
+				zap.Any("panic", r),
+				zap.ByteString("stack", debug.Stack()))
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return handler(ctx, job)
+}
+
+// settle acknowledges a finished job, first scheduling its retry or dead-lettering it if
+// it failed; until then it stays pending, so a failure to record the outcome runs it again
+func (p *Pool) settle(ctx context.Context, messageID string, job Job, jobErr error) {
+	log := p.logger.With(zap.String("job_id", job.ID), zap.String("type", job.Type), zap.Int("attempt", job.Attempt))
+
+	switch {
+	case jobErr == nil:
+	case job.Attempt >= p.opts.MaxAttempts:
+		if err := p.bury(ctx, job, jobErr); err != nil {
+			log.Error("Failed to dead-letter job", zap.Error(err))
+			return
+		}
+		log.Error("Job failed for good; dead-lettered", zap.Error(jobErr))
+	default:
+		retryAt := time.Now().Add(RetryDelay(job.Attempt))
+		next := job
+		next.Attempt++
+		if err := p.schedule(ctx, next, retryAt); err != nil {
+			log.Error("Failed to schedule job retry", zap.Error(err))
+			return
+		}
+		log.Warn("Job failed; will retry", zap.Time("retry_at", retryAt), zap.Error(jobErr))
+	}
+	p.ack(messageID)
+}
+
+// bury adds a job to the dead-letter stream
+func (p *Pool) bury(ctx context.Context, job Job, jobErr error) error {
+	message := jobErr.Error()
+	if len(message) > maxErrorLength {
+		message = message[:maxErrorLength]
+	}
+	encoded, err := json.Marshal(DeadJob{Job: job, Error: message, DiedAt: time.Now().UTC()})
+	if err != nil {
+		return err
+	}
+	_, err = p.broker.Append(ctx, p.deadQueue(), deadLetterLen, map[string]interface{}{"job": string(encoded)})
+	return err
+}
+
+func (p *Pool) ack(messageID string) {
+	if err := p.broker.Ack(context.Background(), p.opts.Queue, group, messageID); err != nil {
+		p.logger.Warn("Failed to acknowledge job", zap.String("message_id", messageID), zap.Error(err))
+	}
+}
+
+func (p *Pool) publish(ctx context.Context, job Job) error {
+	if err := p.ensureGroup(ctx); err != nil {
+		return err
+	}
+	encoded, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	_, err = p.broker.Publish(ctx, p.opts.Queue, map[string]interface{}{"job": string(encoded)})
+	return err
+}
+
+func (p *Pool) ensureGroup(ctx context.Context) error {
+	if p.grouped.Load() {
+		return nil
+	}
+	if err := p.broker.EnsureGroup(ctx, p.opts.Queue, group); err != nil {
+		return err
+	}
+	p.grouped.Store(true)
+	return nil
+}
+
+func (p *Pool) schedule(ctx context.Context, job Job, at time.Time) error {
+	encoded, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	return p.broker.ScheduleAt(ctx, p.delayQueue(), string(encoded), at)
+}
+
+func (p *Pool) delayQueue() string { return p.opts.Queue + ":delayed" }
+
+func (p *Pool) deadQueue() string { return p.opts.Queue + ":dead" }
+
+func newJob(jobType string, payload any) (Job, error) {
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return Job{}, fmt.Errorf("failed to encode %s job: %w", jobType, err)
+	}
+	return Job{
+		ID:         uuid.NewString(),
+		Type:       jobType,
+		Payload:    encoded,
+		Attempt:    1,
+		EnqueuedAt: time.Now().UTC(),
+	}, nil
+}
+
+func decodeJob(m cache.StreamMessage) (Job, error) {
+	var job Job
+	encoded, _ := m.Values["job"].(string)
+	err := json.Unmarshal([]byte(encoded), &job)
+	return job, err
+}
+
+// RetryDelay returns how long to wait before retrying a job that failed its attempt'th
+// attempt: 5s after the first, doubling each time, at most an hour
+func RetryDelay(attempt int) time.Duration {
+	delay := retryBaseDelay
+	for i := 1; i < attempt && delay < retryMaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, retryMaxDelay)
+}
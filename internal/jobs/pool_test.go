@@ -0,0 +1,382 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yourusername/supabase-redis-middleware/internal/cache"
+	"go.uber.org/zap"
+)
+
+// memoryBroker is an in-process Broker with one stream per name and one consumer group
+type memoryBroker struct {
+	mu        sync.Mutex
+	seq       int
+	streams   map[string][]cache.StreamMessage
+	delivered map[string]int                  // Stream -> messages delivered to the group
+	pending   map[string]map[string]time.Time // Stream -> message ID -> delivered at
+	delayed   map[string]map[string]time.Time
+	groups    int
+}
+
+func newMemoryBroker() *memoryBroker {
+	return &memoryBroker{
+		streams:   map[string][]cache.StreamMessage{},
+		delivered: map[string]int{},
+		pending:   map[string]map[string]time.Time{},
+		delayed:   map[string]map[string]time.Time{},
+	}
+}
+
+func (b *memoryBroker) Publish(ctx context.Context, stream string, values map[string]interface{}) (string, error) {
+	return b.Append(ctx, stream, 0, values)
+}
+
+func (b *memoryBroker) Append(ctx context.Context, stream string, maxLen int64, values map[string]interface{}) (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.seq++
+	id := strconv.Itoa(b.seq) + "-0"
+	b.streams[stream] = append(b.streams[stream], cache.StreamMessage{ID: id, Values: values})
+	return id, nil
+}
+
+func (b *memoryBroker) ReadAfter(ctx context.Context, stream, afterID string, count int64) ([]cache.StreamMessage, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var out []cache.StreamMessage
+	for _, m := range b.streams[stream] {
+		if seqOf(m.ID) > seqOf(afterID) && int64(len(out)) < count {
+			out = append(out, m)
+		}
+	}
+	return out, nil
+}
+
+func (b *memoryBroker) EnsureGroup(ctx context.Context, stream, group string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.pending[stream] == nil {
+		b.pending[stream] = map[string]time.Time{}
+		b.groups++
+	}
+	return nil
+}
+
+func (b *memoryBroker) ReadGroup(ctx context.Context, stream, group, consumer string, count int64, block time.Duration) ([]cache.StreamMessage, error) {
+	deadline := time.Now().Add(block)
+	for {
+		b.mu.Lock()
+		var out []cache.StreamMessage
+		for b.delivered[stream] < len(b.streams[stream]) && int64(len(out)) < count {
+			m := b.streams[stream][b.delivered[stream]]
+			b.delivered[stream]++
+			b.pending[stream][m.ID] = time.Now()
+			out = append(out, m)
+		}
+		b.mu.Unlock()
+		if len(out) > 0 || time.Now().After(deadline) {
+			return out, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+}
+
+func (b *memoryBroker) Ack(ctx context.Context, stream, group string, ids ...string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, id := range ids {
+		delete(b.pending[stream], id)
+	}
+	return nil
+}
+
+func (b *memoryBroker) ClaimPending(ctx context.Context, stream, group, consumer string, minIdle time.Duration, count int64) ([]cache.StreamMessage, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var out []cache.StreamMessage
+	for _, m := range b.streams[stream] {
+		if at, ok := b.pending[stream][m.ID]; ok && time.Since(at) >= minIdle && int64(len(out)) < count {
+			b.pending[stream][m.ID] = time.Now()
+			out = append(out, m)
+		}
+	}
+	return out, nil
+}
+
+func (b *memoryBroker) ScheduleAt(ctx context.Context, queue, item string, at time.Time) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.delayed[queue] == nil {
+		b.delayed[queue] = map[string]time.Time{}
+	}
+	b.delayed[queue][item] = at
+	return nil
+}
+
+func (b *memoryBroker) PopDue(ctx context.Context, queue string, now time.Time, count int64) ([]string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var out []string
+	for item, at := range b.delayed[queue] {
+		if !at.After(now) && int64(len(out)) < count {
+			out = append(out, item)
+			delete(b.delayed[queue], item)
+		}
+	}
+	return out, nil
+}
+
+func (b *memoryBroker) delayedJobs(queue string) []Job {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var jobs []Job
+	for item := range b.delayed[queue] {
+		var job Job
+		_ = json.Unmarshal([]byte(item), &job)
+		jobs = append(jobs, job)
+	}
+	return jobs
+}
+
+func (b *memoryBroker) pendingCount(stream string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.pending[stream])
+}
+
+func seqOf(id string) int {
+	n, _ := strconv.Atoi(strings.TrimSuffix(id, "-0"))
+	return n
+}
+
+func testPool(broker Broker) *Pool {
+	return NewPool(broker, PoolOptions{
+		Queue:           "jobs",
+		Concurrency:     4,
+		MaxAttempts:     3,
+		ClaimAfter:      time.Minute,
+		ShutdownTimeout: time.Second,
+	}, zap.NewNop())
+}
+
+func TestPool_RunsJobs(t *testing.T) {
+	broker := newMemoryBroker()
+	pool := testPool(broker)
+
+	got := make(chan Job, 1)
+	pool.Register("sync.store", func(ctx context.Context, job Job) error {
+		got <- job
+		return nil
+	})
+
+	// Enqueued before the pool starts, as another instance may
+	id, err := pool.Enqueue(context.Background(), "sync.store", map[string]string{"store_id": "s1"})
+	if err != nil {
+		t.Fatalf("Enqueue failed: %v", err)
+	}
+	if err := pool.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer pool.Stop()
+
+	select {
+	case job := <-got:
+		if job.ID != id || job.Attempt != 1 || string(job.Payload) != `{"store_id":"s1"}` {
+			t.Errorf("unexpected job %+v", job)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the job to run")
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if n := broker.pendingCount("jobs"); n != 0 {
+		t.Errorf("expected the job to be acknowledged, %d pending", n)
+	}
+}
+
+func TestPool_SettleRetriesThenBuries(t *testing.T) {
+	broker := newMemoryBroker()
+	pool := testPool(broker)
+	ctx := context.Background()
+	failure := errors.New("store unreachable")
+
+	job := Job{ID: "job-1", Type: "sync.store", Payload: json.RawMessage(`{}`), Attempt: 1}
+	before := time.Now()
+	pool.settle(ctx, "1-0", job, failure)
+
+	retries := broker.delayedJobs("jobs:delayed")
+	if len(retries) != 1 || retries[0].ID != "job-1" || retries[0].Attempt != 2 {
+		t.Fatalf("expected attempt 2 to be scheduled, got %+v", retries)
+	}
+	if at := broker.delayed["jobs:delayed"]; len(at) != 1 {
+		t.Fatalf("expected one delayed item")
+	} else {
+		for _, due := range at {
+			if due.Before(before.Add(RetryDelay(1))) {
+				t.Errorf("expected the retry %v after the failure, due %v", RetryDelay(1), due)
+			}
+		}
+	}
+
+	job.Attempt = 3
+	pool.settle(ctx, "2-0", job, failure)
+	dead, err := pool.DeadJobs(ctx, "0", 10)
+	if err != nil {
+		t.Fatalf("DeadJobs failed: %v", err)
+	}
+	if len(dead) != 1 || dead[0].ID != "job-1" || dead[0].Attempt != 3 || dead[0].Error != failure.Error() || dead[0].EntryID == "" {
+		t.Errorf("expected the last attempt to be dead-lettered, got %+v", dead)
+	}
+	if len(broker.delayedJobs("jobs:delayed")) != 1 {
+		t.Error("expected no retry after the last attempt")
+	}
+}
+
+func TestPool_ReleasesDueJobs(t *testing.T) {
+	broker := newMemoryBroker()
+	pool := testPool(broker)
+	ctx := context.Background()
+
+	if _, err := pool.EnqueueAt(ctx, "sync.store", nil, time.Now().Add(-time.Second)); err != nil {
+		t.Fatalf("EnqueueAt failed: %v", err)
+	}
+	if _, err := pool.EnqueueAt(ctx, "sync.store", nil, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("EnqueueAt failed: %v", err)
+	}
+	pool.releaseDue(ctx)
+
+	if n := len(broker.streams["jobs"]); n != 1 {
+		t.Errorf("expected only the due job queued, got %d", n)
+	}
+	if n := len(broker.delayedJobs("jobs:delayed")); n != 1 {
+		t.Errorf("expected the later job to stay delayed, got %d", n)
+	}
+}
+
+func TestPool_RecoversPanics(t *testing.T) {
+	pool := testPool(newMemoryBroker())
+	pool.Register("boom", func(ctx context.Context, job Job) error {
+		panic("nil map")
+	})
+
+	if err := pool.run(context.Background(), Job{Type: "boom"}); err == nil {
+		t.Error("expected a panic to be returned as an error")
+	}
+	if err := pool.run(context.Background(), Job{Type: "unknown"}); !errors.Is(err, ErrUnknownJobType) {
+		t.Errorf("expected ErrUnknownJobType, got %v", err)
+	}
+}
+
+func TestPool_RequeuesLostJobs(t *testing.T) {
+	broker := newMemoryBroker()
+	pool := testPool(broker)
+	pool.opts.ClaimAfter = 0
+	ctx := context.Background()
+
+	lost, _ := pool.Enqueue(ctx, "sync.store", nil)
+	running, _ := pool.Enqueue(ctx, "sync.store", nil)
+	messages, _ := broker.ReadGroup(ctx, "jobs", group, "crashed", 10, 0)
+	if len(messages) != 2 {
+		t.Fatalf("expected 2 messages, got %d", len(messages))
+	}
+	// The second is still running here
+	pool.active.Store(messages[1].ID, true)
+
+	pool.requeueLost(ctx)
+
+	retries := broker.delayedJobs("jobs:delayed")
+	if len(retries) != 1 || retries[0].ID != lost || retries[0].Attempt != 2 {
+		t.Errorf("expected only the lost job %s retried, got %+v", lost, retries)
+	}
+	if n := broker.pendingCount("jobs"); n != 1 {
+		t.Errorf("expected the running job %s to stay pending, %d pending", running, n)
+	}
+}
+
+func TestPool_StopWaitsForRunningJobs(t *testing.T) {
+	broker := newMemoryBroker()
+	pool := testPool(broker)
+
+	started := make(chan struct{})
+	finished := make(chan struct{})
+	pool.Register("slow", func(ctx context.Context, job Job) error {
+		close(started)
+		time.Sleep(50 * time.Millisecond)
+		close(finished)
+		return nil
+	})
+	if err := pool.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if _, err := pool.Enqueue(context.Background(), "slow", nil); err != nil {
+		t.Fatalf("Enqueue failed: %v", err)
+	}
+	<-started
+	pool.Stop()
+
+	select {
+	case <-finished:
+	default:
+		t.Error("expected Stop to wait for the running job")
+	}
+	if n := broker.pendingCount("jobs"); n != 0 {
+		t.Errorf("expected the finished job to be acknowledged, %d pending", n)
+	}
+}
+
+func TestPool_StopCancelsJobsAfterTimeout(t *testing.T) {
+	broker := newMemoryBroker()
+	pool := testPool(broker)
+	pool.opts.ShutdownTimeout = 10 * time.Millisecond
+
+	started := make(chan struct{})
+	pool.Register("stuck", func(ctx context.Context, job Job) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if err := pool.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if _, err := pool.Enqueue(context.Background(), "stuck", nil); err != nil {
+		t.Fatalf("Enqueue failed: %v", err)
+	}
+	<-started
+	pool.Stop()
+
+	// Left pending, to be run again once claimed
+	if n := broker.pendingCount("jobs"); n != 1 {
+		t.Errorf("expected the canceled job to stay pending, %d pending", n)
+	}
+	if n := len(broker.delayedJobs("jobs:delayed")); n != 0 {
+		t.Errorf("expected no retry scheduled for a canceled job, got %d", n)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, 5 * time.Second},
+		{2, 10 * time.Second},
+		{4, 40 * time.Second},
+		{20, time.Hour},
+	}
+	for _, tc := range tests {
+		if got := RetryDelay(tc.attempt); got != tc.want {
+			t.Errorf("RetryDelay(%d) = %v, want %v", tc.attempt, got, tc.want)
+		}
+	}
+}
@@ -9,24 +9,15 @@ import (
 // Logger wraps zap.Logger to provide application-specific logging
 type Logger struct {
 	*zap.Logger
+	level zap.AtomicLevel
 }
 
 // NewLogger creates a new logger instance with the specified log level
 // Supported levels: debug, info, warn, error
 func NewLogger(level string) (*Logger, error) {
-	var zapLevel zap.AtomicLevel
-	
-	switch level {
-	case "debug":
-		zapLevel = zap.NewAtomicLevelAt(zap.DebugLevel)
-	case "info":
-		zapLevel = zap.NewAtomicLevelAt(zap.InfoLevel)
-	case "warn":
-		zapLevel = zap.NewAtomicLevelAt(zap.WarnLevel)
-	case "error":
-		zapLevel = zap.NewAtomicLevelAt(zap.ErrorLevel)
-	default:
-		return nil, fmt.Errorf("invalid log level: %s (must be debug, info, warn, or error)", level)
+	zapLevel, err := parseLevel(level)
+	if err != nil {
+		return nil, err
 	}
 
 	// Configure structured logging format
@@ -48,7 +39,32 @@ func NewLogger(level string) (*Logger, error) {
 		return nil, fmt.Errorf("failed to initialize logger: %w", err)
 	}
 
-	return &Logger{Logger: zapLogger}, nil
+	return &Logger{Logger: zapLogger, level: zapLevel}, nil
+}
+
+// parseLevel returns the level named debug, info, warn or error
+func parseLevel(level string) (zap.AtomicLevel, error) {
+	switch level {
+	case "debug":
+		return zap.NewAtomicLevelAt(zap.DebugLevel), nil
+	case "info":
+		return zap.NewAtomicLevelAt(zap.InfoLevel), nil
+	case "warn":
+		return zap.NewAtomicLevelAt(zap.WarnLevel), nil
+	case "error":
+		return zap.NewAtomicLevelAt(zap.ErrorLevel), nil
+	}
+	return zap.AtomicLevel{}, fmt.Errorf("invalid log level: %s (must be debug, info, warn, or error)", level)
+}
+
+// SetLevel changes the level of l, and of every logger derived from it, while they run
+func (l *Logger) SetLevel(level string) error {
+	parsed, err := parseLevel(level)
+	if err != nil {
+		return err
+	}
+	l.level.SetLevel(parsed.Level())
+	return nil
 }
 
 // NewDevelopmentLogger creates a logger optimized for development
@@ -64,12 +80,12 @@ func NewDevelopmentLogger() (*Logger, error) {
 		return nil, fmt.Errorf("failed to initialize development logger: %w", err)
 	}
 
-	return &Logger{Logger: zapLogger}, nil
+	return &Logger{Logger: zapLogger, level: config.Level}, nil
 }
 
 // WithFields returns a logger with additional fields
 func (l *Logger) WithFields(fields ...zap.Field) *Logger {
-	return &Logger{Logger: l.With(fields...)}
+	return &Logger{Logger: l.With(fields...), level: l.level}
 }
 
 // Sync flushes any buffered log entries
@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ServiceMetrics records how the domain service serves reads, so what the cache saves can
+// be measured: lookups by result, Supabase latency on misses, and JSON encoding and
+// decoding time. It implements service.Metrics and is a prometheus.Collector
+// The service hit ratio is rate(service_cache_lookups_total{result="hit"}) over all lookups
+type ServiceMetrics struct {
+	lookups       *prometheus.CounterVec
+	repository    *prometheus.HistogramVec
+	serialization *prometheus.HistogramVec
+}
+
+// NewServiceMetrics creates the domain service metrics, to be registered with the registry
+func NewServiceMetrics() *ServiceMetrics {
+	return &ServiceMetrics{
+		lookups: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "service_cache_lookups_total",
+			Help: "Number of domain service cache lookups by result (hit or miss)",
+		}, []string{"domain", "operation", "result"}),
+		repository: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "service_repository_duration_seconds",
+			Help:    "Time taken by Supabase to serve domain service cache misses",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"domain", "operation", "outcome"}),
+		serialization: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "service_serialization_duration_seconds",
+			Help:    "Time taken encoding fetched results and decoding cached ones",
+			Buckets: prometheus.ExponentialBuckets(0.00001, 4, 10), // 10µs to ~2.6s
+		}, []string{"domain", "operation"}),
+	}
+}
+
+// ObserveCacheLookup counts a cache lookup for operation on domain
+func (m *ServiceMetrics) ObserveCacheLookup(domain, operation string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	m.lookups.WithLabelValues(domain, operation, result).Inc()
+}
+
+// ObserveRepository records how long a Supabase call for operation on domain took
+func (m *ServiceMetrics) ObserveRepository(domain, operation string, duration time.Duration, err error) {
+	outcome := "success"
+	if err != nil {
+		outcome = "error"
+	}
+	m.repository.WithLabelValues(domain, operation, outcome).Observe(duration.Seconds())
+}
+
+// ObserveSerialization records how long encoding or decoding a result for operation on domain took
+func (m *ServiceMetrics) ObserveSerialization(domain, operation string, duration time.Duration) {
+	m.serialization.WithLabelValues(domain, operation).Observe(duration.Seconds())
+}
+
+// Describe implements prometheus.Collector
+func (m *ServiceMetrics) Describe(ch chan<- *prometheus.Desc) {
+	m.lookups.Describe(ch)
+	m.repository.Describe(ch)
+	m.serialization.Describe(ch)
+}
+
+// Collect implements prometheus.Collector
+func (m *ServiceMetrics) Collect(ch chan<- prometheus.Metric) {
+	m.lookups.Collect(ch)
+	m.repository.Collect(ch)
+	m.serialization.Collect(ch)
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"slices"
+	"strings"
+	"sync/atomic"
+)
+
+// AllowedOrigins are the origins browsers may call the API from, which can be changed
+// while the server runs, as they are when the configuration is reloaded
+type AllowedOrigins struct {
+	origins atomic.Pointer[[]string]
+}
+
+// NewAllowedOrigins returns AllowedOrigins of origins, such as https://shop.example.com;
+// * allows any origin
+func NewAllowedOrigins(origins []string) *AllowedOrigins {
+	o := &AllowedOrigins{}
+	o.Set(origins)
+	return o
+}
+
+// Set replaces the allowed origins
+func (o *AllowedOrigins) Set(origins []string) {
+	normalized := make([]string, len(origins))
+	for i, origin := range origins {
+		normalized[i] = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(origin), "/"))
+	}
+	o.origins.Store(&normalized)
+}
+
+// Allows reports whether browsers may call the API from origin, the value of a request's
+// Origin header
+func (o *AllowedOrigins) Allows(origin string) bool {
+	origins := *o.origins.Load()
+	return slices.Contains(origins, "*") || slices.Contains(origins, strings.ToLower(origin))
+}
@@ -1,16 +1,86 @@
 package middleware
 
 import (
+	"math/rand/v2"
+	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/yourusername/supabase-redis-middleware/internal/logger"
 	"go.uber.org/zap"
 )
 
+// redactedValue is logged in place of the value of a redacted header
+const redactedValue = "[REDACTED]"
+
+// credentialHeaders carry credentials, so their values are never logged
+var credentialHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie"}
+
+// loggingOptions configures LoggingMiddleware
+type loggingOptions struct {
+	skipPaths   map[string]bool
+	sampleRatio float64
+	headers     bool
+	redacted    map[string]bool // Canonical header names
+}
+
+// LoggingOption configures LoggingMiddleware
+type LoggingOption func(*loggingOptions)
+
+// WithSkipPaths leaves requests to paths, such as health probes and /metrics, out of the
+// logs unless they fail
+func WithSkipPaths(paths ...string) LoggingOption {
+	return func(o *loggingOptions) {
+		for _, path := range paths {
+			o.skipPaths[path] = true
+		}
+	}
+}
+
+// WithSampling logs only a ratio (0 to 1) of the requests that don't fail
+func WithSampling(ratio float64) LoggingOption {
+	return func(o *loggingOptions) {
+		o.sampleRatio = ratio
+	}
+}
+
+// WithHeaders logs the headers of each request with its incoming request line
+func WithHeaders() LoggingOption {
+	return func(o *loggingOptions) {
+		o.headers = true
+	}
+}
+
+// WithRedactedHeaders logs the values of headers as [REDACTED], as those of
+// Authorization, Proxy-Authorization and Cookie always are
+func WithRedactedHeaders(headers ...string) LoggingOption {
+	return func(o *loggingOptions) {
+		for _, header := range headers {
+			o.redacted[http.CanonicalHeaderKey(header)] = true
+		}
+	}
+}
+
 // LoggingMiddleware creates a Gin middleware that logs all incoming requests
 // and their responses with structured logging
-func LoggingMiddleware(logger *zap.Logger) gin.HandlerFunc {
+// Lines are written with the request's logger, so they carry its request ID. Requests
+// left out by WithSkipPaths or WithSampling are still logged once they complete if they
+// failed, with a 5xx or with errors recorded by their handlers
+func LoggingMiddleware(base *zap.Logger, opts ...LoggingOption) gin.HandlerFunc {
+	options := loggingOptions{
+		skipPaths:   map[string]bool{},
+		sampleRatio: 1,
+		redacted:    map[string]bool{},
+	}
+	WithRedactedHeaders(credentialHeaders...)(&options)
+	for _, opt := range opts {
+		opt(&options)
+	}
+
 	return func(c *gin.Context) {
+		log := logger.FromContext(c.Request.Context(), base)
+
 		// Start timer
 		start := time.Now()
 
@@ -19,13 +89,22 @@ func LoggingMiddleware(logger *zap.Logger) gin.HandlerFunc {
 		method := c.Request.Method
 		clientIP := c.ClientIP()
 
+		// Skipped and unsampled requests are only logged if they fail
+		quiet := options.skipPaths[path] || (options.sampleRatio < 1 && rand.Float64() >= options.sampleRatio)
+
 		// Log incoming request
-		logger.Info("incoming request",
-			zap.String("method", method),
-			zap.String("path", path),
-			zap.String("client_ip", clientIP),
-			zap.Time("timestamp", start),
-		)
+		if !quiet {
+			fields := []zap.Field{
+				zap.String("method", method),
+				zap.String("path", path),
+				zap.String("client_ip", clientIP),
+				zap.Time("timestamp", start),
+			}
+			if options.headers {
+				fields = append(fields, zap.Any("headers", options.loggedHeaders(c.Request.Header)))
+			}
+			log.Info("incoming request", fields...)
+		}
 
 		// Process request
 		c.Next()
@@ -35,9 +114,12 @@ func LoggingMiddleware(logger *zap.Logger) gin.HandlerFunc {
 
 		// Get response status
 		status := c.Writer.Status()
+		if quiet && status < http.StatusInternalServerError && len(c.Errors) == 0 {
+			return
+		}
 
 		// Log response with duration
-		logger.Info("request completed",
+		log.Info("request completed",
 			zap.String("method", method),
 			zap.String("path", path),
 			zap.String("client_ip", clientIP),
@@ -49,7 +131,7 @@ func LoggingMiddleware(logger *zap.Logger) gin.HandlerFunc {
 		// Log errors if any occurred
 		if len(c.Errors) > 0 {
 			for _, err := range c.Errors {
-				logger.Error("request error",
+				log.Error("request error",
 					zap.String("method", method),
 					zap.String("path", path),
 					zap.String("error", err.Error()),
@@ -58,3 +140,17 @@ func LoggingMiddleware(logger *zap.Logger) gin.HandlerFunc {
 		}
 	}
 }
+
+// loggedHeaders returns header as it is logged, one value per name with redacted
+// headers' values replaced
+func (o *loggingOptions) loggedHeaders(header http.Header) map[string]string {
+	logged := make(map[string]string, len(header))
+	for name, values := range header {
+		if o.redacted[http.CanonicalHeaderKey(name)] {
+			logged[name] = redactedValue
+			continue
+		}
+		logged[name] = strings.Join(values, ", ")
+	}
+	return logged
+}
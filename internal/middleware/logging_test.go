@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLoggingMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name      string
+		opts      []LoggingOption
+		path      string
+		wantLines []string
+	}{
+		{name: "logged", path: "/ok", wantLines: []string{"incoming request", "request completed"}},
+		{name: "skipped path", opts: []LoggingOption{WithSkipPaths("/ok")}, path: "/ok"},
+		{name: "skipped path failing", opts: []LoggingOption{WithSkipPaths("/fail")}, path: "/fail", wantLines: []string{"request completed"}},
+		{name: "unsampled", opts: []LoggingOption{WithSampling(0)}, path: "/ok"},
+		{name: "unsampled failing", opts: []LoggingOption{WithSampling(0)}, path: "/fail", wantLines: []string{"request completed"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, logs := observer.New(zap.InfoLevel)
+			router := gin.New()
+			router.Use(LoggingMiddleware(zap.New(core), tt.opts...))
+			router.GET("/ok", func(c *gin.Context) { c.Status(http.StatusOK) })
+			router.GET("/fail", func(c *gin.Context) { c.Status(http.StatusServiceUnavailable) })
+
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			entries := logs.All()
+			if len(entries) != len(tt.wantLines) {
+				t.Fatalf("logged %d lines, want %v", len(entries), tt.wantLines)
+			}
+			for i, entry := range entries {
+				if entry.Message != tt.wantLines[i] {
+					t.Errorf("line %d = %q, want %q", i, entry.Message, tt.wantLines[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLoggingMiddleware_Headers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	core, logs := observer.New(zap.InfoLevel)
+	router := gin.New()
+	router.Use(LoggingMiddleware(zap.New(core), WithHeaders(), WithRedactedHeaders("x-signature")))
+	router.GET("/ok", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	req := httptest.NewRequest(http.MethodGet, "/ok", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("X-Signature", "sha256=abc")
+	req.Header.Set("Accept", "application/json")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	incoming := logs.FilterMessage("incoming request").All()
+	if len(incoming) != 1 {
+		t.Fatalf("logged %d incoming request lines, want 1", len(incoming))
+	}
+	headers, ok := incoming[0].ContextMap()["headers"].(map[string]string)
+	if !ok {
+		t.Fatalf("headers = %#v, want a map", incoming[0].ContextMap()["headers"])
+	}
+	want := map[string]string{
+		"Authorization": redactedValue,
+		"X-Signature":   redactedValue,
+		"Accept":        "application/json",
+	}
+	for name, value := range want {
+		if headers[name] != value {
+			t.Errorf("headers[%s] = %q, want %q", name, headers[name], value)
+		}
+	}
+}
@@ -0,0 +1,1272 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/netip"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/yourusername/supabase-redis-middleware/internal/auth"
+	"github.com/yourusername/supabase-redis-middleware/internal/cache"
+	"github.com/yourusername/supabase-redis-middleware/internal/i18n"
+	"github.com/yourusername/supabase-redis-middleware/internal/logger"
+	"github.com/yourusername/supabase-redis-middleware/internal/realtime"
+	"github.com/yourusername/supabase-redis-middleware/internal/repository"
+	"github.com/yourusername/supabase-redis-middleware/internal/service"
+	"github.com/yourusername/supabase-redis-middleware/internal/tenant"
+	"go.uber.org/zap"
+)
+
+// TimeoutMiddleware creates a middleware that enforces request timeout
+// The request's context is cancelled at the deadline, which aborts the database and
+// Supabase calls made with it. Responses are held back until the handler returns, so a
+// timed-out request gets a 504 and nothing the handler writes afterwards; a response the
+// handler flushes is sent from then on as it is written, and can no longer be replaced.
+// The handler runs on its own goroutine but is always waited for, and its panics are
+// raised again here for the recovery middleware
+func TimeoutMiddleware(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// WebSockets and event streams outlive any request timeout; their handlers bound
+		// their own writes
+		if realtime.IsLongLived(c.Request) {
+			c.Next()
+			return
+		}
+
+		// Create context with timeout
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+
+		// Replace request context with timeout context
+		c.Request = c.Request.WithContext(ctx)
+		id := requestID(c)
+		message := i18n.T(ctx, "TIMEOUT", "Request timeout exceeded", nil)
+
+		original := c.Writer
+		writer := &timeoutWriter{ResponseWriter: original, header: original.Header().Clone(), status: http.StatusOK}
+		c.Writer = writer
+
+		// Receives the handler's panic, or nil once it returns
+		done := make(chan any, 1)
+		go func() {
+			defer func() {
+				done <- recover()
+			}()
+			c.Next()
+		}()
+
+		var panicked any
+		select {
+		case panicked = <-done:
+		case <-ctx.Done():
+			if ctx.Err() == context.DeadlineExceeded {
+				writer.timeout(id, message)
+			}
+			// The handler's work is cancelled with the context; wait for it to return, as
+			// the gin.Context is reused once this middleware does
+			panicked = <-done
+		}
+
+		c.Writer = original
+		if panicked != nil {
+			panic(panicked)
+		}
+		writer.finish()
+	}
+}
+
+// timeoutWriter holds back a response until its handler returns or flushes it, so a
+// timeout can still replace it with a 504. Once either has happened, the other writes
+// nothing
+type timeoutWriter struct {
+	gin.ResponseWriter
+	mu       sync.Mutex
+	header   http.Header
+	status   int
+	body     bytes.Buffer
+	written  bool // The handler wrote a status or body
+	flushed  bool // Sent through; writes now pass straight to ResponseWriter
+	timedOut bool
+}
+
+func (w *timeoutWriter) Header() http.Header {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.flushed {
+		return w.ResponseWriter.Header()
+	}
+	return w.header
+}
+
+func (w *timeoutWriter) WriteHeader(code int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	switch {
+	case w.flushed:
+		w.ResponseWriter.WriteHeader(code)
+	case code > 0 && !w.timedOut && w.body.Len() == 0:
+		w.status = code
+	}
+}
+
+func (w *timeoutWriter) WriteHeaderNow() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.flushed {
+		w.ResponseWriter.WriteHeaderNow()
+		return
+	}
+	w.written = true
+}
+
+func (w *timeoutWriter) Write(data []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	switch {
+	case w.flushed:
+		return w.ResponseWriter.Write(data)
+	case w.timedOut:
+		return 0, http.ErrHandlerTimeout
+	}
+	w.written = true
+	return w.body.Write(data)
+}
+
+func (w *timeoutWriter) WriteString(data string) (int, error) {
+	return w.Write([]byte(data))
+}
+
+// Status reports 504 once the request has timed out, as the client sees it
+func (w *timeoutWriter) Status() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	switch {
+	case w.flushed:
+		return w.ResponseWriter.Status()
+	case w.timedOut:
+		return http.StatusGatewayTimeout
+	}
+	return w.status
+}
+
+func (w *timeoutWriter) Size() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	switch {
+	case w.flushed:
+		return w.ResponseWriter.Size()
+	case !w.written:
+		return -1
+	}
+	return w.body.Len()
+}
+
+func (w *timeoutWriter) Written() bool {
+	return w.Size() != -1
+}
+
+// Flush sends the response held back so far, unless the request has timed out
+func (w *timeoutWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timedOut {
+		return
+	}
+	w.send()
+	w.ResponseWriter.Flush()
+}
+
+// finish sends the response held back once the handler has returned
+func (w *timeoutWriter) finish() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.timedOut {
+		w.send()
+	}
+}
+
+// timeout replaces the response with a 504 and flushes it to the client, unless the
+// response was already flushed
+func (w *timeoutWriter) timeout(requestID, message string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.flushed {
+		return
+	}
+	w.timedOut = true
+
+	body, _ := json.Marshal(gin.H{
+		"status": "error",
+		"error": gin.H{
+			"code":       "TIMEOUT",
+			"message":    message,
+			"request_id": requestID,
+		},
+	})
+	w.ResponseWriter.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.ResponseWriter.WriteHeader(http.StatusGatewayTimeout)
+	w.ResponseWriter.Write(body)
+	// Sent now, not once the handler has returned
+	w.ResponseWriter.Flush()
+}
+
+// send writes the held-back headers, status and body through; w.mu must be held
+func (w *timeoutWriter) send() {
+	if w.flushed {
+		return
+	}
+	w.flushed = true
+
+	header := w.ResponseWriter.Header()
+	for key := range header {
+		if _, ok := w.header[key]; !ok {
+			delete(header, key)
+		}
+	}
+	for key, values := range w.header {
+		header[key] = values
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	if w.written {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+	if w.body.Len() > 0 {
+		w.ResponseWriter.Write(w.body.Bytes())
+	}
+}
+
+// Reasons a request carries no principal, besides the authenticator's
+var (
+	errMissingAuthorization = errors.New("missing authorization header")
+	errAuthorizationFormat  = errors.New("invalid authorization format")
+	errEmptyBearerToken     = errors.New("empty bearer token")
+)
+
+// unauthorizedMessages are the messages of the 401s refusing requests without a principal,
+// by the key of their translations
+var unauthorizedMessages = map[error]struct{ key, text string }{
+	errMissingAuthorization: {"UNAUTHORIZED.missing", "Missing authorization header"},
+	errAuthorizationFormat:  {"UNAUTHORIZED.format", "Invalid authorization format. Expected: Bearer <token>"},
+	errEmptyBearerToken:     {"UNAUTHORIZED.empty", "Empty bearer token"},
+	auth.ErrInvalidKey:      {"UNAUTHORIZED.invalid_key", "Invalid bearer token"},
+	auth.ErrKeyRevoked:      {"UNAUTHORIZED.revoked", "API key has been revoked"},
+	auth.ErrKeyExpired:      {"UNAUTHORIZED.expired", "API key has expired"},
+	auth.ErrInvalidToken:    {"UNAUTHORIZED.invalid_token", "Invalid or expired token"},
+}
+
+// authErrorKey is the gin context key holding why a request carries no principal
+const authErrorKey = "auth_error"
+
+// AuthenticateMiddleware identifies the caller from their bearer API key or Supabase JWT
+// and attaches them to the request context; they become the audit actor, unless they
+// used a bootstrap token. Requests are never refused here, since reads are public:
+// RequireRole and RequireScope refuse those that can't reach a route, with the reason
+// authentication failed
+func AuthenticateMiddleware(authenticator *auth.Authenticator, base *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authHeader := c.GetHeader("Authorization")
+		token, ok := strings.CutPrefix(authHeader, "Bearer ")
+		switch {
+		case authHeader == "":
+			c.Set(authErrorKey, errMissingAuthorization)
+		case !ok:
+			c.Set(authErrorKey, errAuthorizationFormat)
+		case token == "":
+			c.Set(authErrorKey, errEmptyBearerToken)
+		default:
+			principal, err := authenticator.Authenticate(c.Request.Context(), token)
+			if err != nil {
+				c.Set(authErrorKey, err)
+				break
+			}
+
+			ctx := auth.WithPrincipal(c.Request.Context(), principal)
+			if principal.ID != "bootstrap" {
+				ctx = repository.WithAuditActor(ctx, repository.AuditActor{
+					Actor:    principal.ID,
+					Endpoint: c.Request.Method + " " + c.FullPath(),
+				})
+			}
+			c.Request = c.Request.WithContext(ctx)
+
+			logger.FromContext(ctx, base).Debug("caller authenticated",
+				zap.String("path", c.Request.URL.Path),
+				zap.String("principal", principal.ID),
+				zap.String("role", principal.Role))
+		}
+
+		c.Next()
+	}
+}
+
+// RequireRole creates a middleware admitting only authenticated callers with one of roles
+// to a route group
+func RequireRole(base *zap.Logger, roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := requirePrincipal(c, base)
+		if !ok {
+			return
+		}
+
+		if !principal.HasRole(roles...) {
+			logger.FromContext(c.Request.Context(), base).Warn("role not admitted",
+				zap.String("path", c.Request.URL.Path),
+				zap.String("principal", principal.ID),
+				zap.String("role", principal.Role))
+			abortWithError(c, http.StatusForbidden, "FORBIDDEN.role", "The "+principal.Role+" role may not use this endpoint", i18n.Params{"role": principal.Role})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireScope creates a middleware admitting only authenticated callers holding scope
+// A caller bound to a store is refused unless the route's storeParam, if any, names that store
+func RequireScope(base *zap.Logger, scope, storeParam string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := requirePrincipal(c, base)
+		if !ok {
+			return
+		}
+
+		log := logger.FromContext(c.Request.Context(), base)
+		if !principal.HasScope(scope) {
+			log.Warn("caller lacks scope",
+				zap.String("path", c.Request.URL.Path),
+				zap.String("principal", principal.ID),
+				zap.String("scope", scope))
+			abortWithError(c, http.StatusForbidden, "FORBIDDEN.scope", "Caller lacks the "+scope+" scope", i18n.Params{"scope": scope})
+			return
+		}
+		if storeParam != "" && !principal.AllowsStore(c.Param(storeParam)) {
+			log.Warn("caller bound to another store",
+				zap.String("path", c.Request.URL.Path),
+				zap.String("principal", principal.ID))
+			abortWithError(c, http.StatusForbidden, "FORBIDDEN.store", "Caller is bound to another store", nil)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireAdminToken creates a middleware admitting only callers authenticated by an admin
+// token, for the admin routes once admin tokens are configured
+func RequireAdminToken(base *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := requirePrincipal(c, base)
+		if !ok {
+			return
+		}
+
+		if !principal.Admin {
+			logger.FromContext(c.Request.Context(), base).Warn("admin token required",
+				zap.String("path", c.Request.URL.Path),
+				zap.String("principal", principal.ID),
+				zap.String("role", principal.Role))
+			abortWithError(c, http.StatusForbidden, "FORBIDDEN.admin_token", "Admin endpoints require an admin token", nil)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// IPAllowlistMiddleware creates a middleware admitting only callers whose IP address is in
+// allowed, unless it is empty, and in their API key's allowed CIDRs, if it has any, for
+// the ERP pushes. It must follow authentication. The address is the connection's, or with
+// trustProxies gin's ClientIP, read from X-Forwarded-For when the connection comes from a
+// trusted proxy; only set it once the proxies are configured, as gin trusts any by default
+func IPAllowlistMiddleware(allowed []netip.Prefix, trustProxies bool, base *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := requirePrincipal(c, base)
+		if !ok {
+			return
+		}
+
+		clientIP := c.RemoteIP()
+		if trustProxies {
+			clientIP = c.ClientIP()
+		}
+		addr, err := netip.ParseAddr(clientIP)
+		if err != nil || (len(allowed) > 0 && !auth.ContainsIP(allowed, addr)) || !principal.AllowsIP(addr) {
+			logger.FromContext(c.Request.Context(), base).Warn("caller IP not allowed",
+				zap.String("path", c.Request.URL.Path),
+				zap.String("principal", principal.ID),
+				zap.String("client_ip", clientIP))
+			abortWithError(c, http.StatusForbidden, "IP_NOT_ALLOWED", "Requests from this IP address are not allowed", nil)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// tenantErrors are the statuses, codes and messages of the responses refusing requests
+// whose tenant can't be resolved
+var tenantErrors = map[error]struct {
+	status  int
+	code    string
+	message string
+}{
+	auth.ErrInvalidTenant:    {http.StatusBadRequest, "INVALID_TENANT", "Tenant ID must be up to 64 lowercase letters, digits, hyphens and underscores"},
+	auth.ErrTenantRequired:   {http.StatusBadRequest, "TENANT_REQUIRED", "A tenant ID is required"},
+	auth.ErrTenantNotAllowed: {http.StatusForbidden, "TENANT_NOT_ALLOWED", "These credentials belong to another tenant"},
+}
+
+// TenantMiddleware scopes the request context to the tenant the caller acts for, resolved
+// by auth.ResolveTenant from their credentials, the header naming a tenant, and
+// defaultTenant; repositories and the cache read that tenant's data alone. It must
+// follow authentication. Requests whose tenant can't be resolved are refused
+func TenantMiddleware(header, defaultTenant string, base *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		principal := auth.PrincipalFrom(ctx)
+		id, err := auth.ResolveTenant(principal, c.GetHeader(header), defaultTenant)
+		if err != nil {
+			fields := []zap.Field{zap.String("path", c.Request.URL.Path), zap.String("tenant", c.GetHeader(header))}
+			if principal != nil {
+				fields = append(fields, zap.String("principal", principal.ID))
+			}
+			logger.FromContext(ctx, base).Warn("tenant not resolved", append(fields, zap.Error(err))...)
+			e := tenantErrors[err]
+			abortWithError(c, e.status, e.code, e.message, nil)
+			return
+		}
+
+		ctx = tenant.WithID(ctx, id)
+		if id != "" {
+			ctx = logger.WithContext(ctx, logger.FromContext(ctx, base).With(zap.String("tenant_id", id)))
+		}
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// RequireTenantMiddleware refuses requests acting for no tenant, which TenantMiddleware
+// allows platform credentials naming none. Writes resolve stores, categories, brands and
+// products by natural keys that only a tenant makes unique, so acting for every tenant
+// they could link one tenant's rows to another's. It must follow TenantMiddleware
+func RequireTenantMiddleware(header string, base *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if tenant.FromContext(c.Request.Context()) != "" {
+			c.Next()
+			return
+		}
+
+		logger.FromContext(c.Request.Context(), base).Warn("write without a tenant",
+			zap.String("path", c.Request.URL.Path))
+		abortWithError(c, http.StatusBadRequest, "TENANT_REQUIRED.write", "Writes must name a tenant in "+header, i18n.Params{"header": header})
+	}
+}
+
+// requirePrincipal returns the authenticated caller of the request, refusing the request
+// with the reason authentication failed if there is none
+func requirePrincipal(c *gin.Context, base *zap.Logger) (*auth.Principal, bool) {
+	if principal := auth.PrincipalFrom(c.Request.Context()); principal != nil {
+		return principal, true
+	}
+
+	value, _ := c.Get(authErrorKey)
+	err, _ := value.(error)
+	if err == nil {
+		err = errMissingAuthorization
+	}
+	log := logger.FromContext(c.Request.Context(), base)
+
+	if message, ok := unauthorizedMessages[err]; ok {
+		log.Warn("unauthenticated request",
+			zap.String("path", c.Request.URL.Path),
+			zap.String("client_ip", c.ClientIP()),
+			zap.Error(err))
+		abortWithError(c, http.StatusUnauthorized, message.key, message.text, nil)
+		return nil, false
+	}
+
+	log.Error("failed to validate API key", zap.String("path", c.Request.URL.Path), zap.Error(err))
+	abortWithError(c, http.StatusServiceUnavailable, "SERVICE_UNAVAILABLE.api_keys", "API keys can't be validated right now", nil)
+	return nil, false
+}
+
+// DecompressMiddleware creates a middleware decoding request bodies sent with
+// Content-Encoding: gzip, so handlers read, and body limits and signatures apply to, the
+// decoded payload. Bodies with any other encoding are refused with 415
+func DecompressMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch strings.ToLower(strings.TrimSpace(c.GetHeader("Content-Encoding"))) {
+		case "", "identity":
+			c.Next()
+			return
+		case "gzip", "x-gzip":
+		default:
+			abortWithError(c, http.StatusUnsupportedMediaType, "UNSUPPORTED_ENCODING", "Content-Encoding must be gzip or identity", nil)
+			return
+		}
+
+		reader, err := gzip.NewReader(c.Request.Body)
+		if err != nil {
+			abortWithError(c, http.StatusBadRequest, "INVALID_INPUT.gzip", "Body is not valid gzip", nil)
+			return
+		}
+		defer reader.Close()
+
+		c.Request.Body = reader
+		c.Request.ContentLength = -1
+		c.Request.Header.Del("Content-Encoding")
+		c.Request.Header.Del("Content-Length")
+
+		c.Next()
+	}
+}
+
+// gzipWriters reuses gzip writers across responses, as each allocates sizable tables
+var gzipWriters = sync.Pool{
+	New: func() any { return gzip.NewWriter(io.Discard) },
+}
+
+// CompressMiddleware creates a middleware gzipping responses for clients whose
+// Accept-Encoding allows it. Only responses of at least minSize bytes whose media type
+// starts with one of types are compressed, so small payloads and images go out as they are
+func CompressMiddleware(minSize int, types []string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Writer.Header().Add("Vary", "Accept-Encoding")
+		if c.Request.Method == http.MethodHead || !acceptsGzip(c.GetHeader("Accept-Encoding")) || realtime.IsLongLived(c.Request) {
+			c.Next()
+			return
+		}
+
+		writer := &gzipWriter{ResponseWriter: c.Writer, minSize: minSize, types: types}
+		c.Writer = writer
+		defer writer.finish()
+
+		c.Next()
+	}
+}
+
+// acceptsGzip reports whether an Accept-Encoding header allows a gzipped response
+func acceptsGzip(header string) bool {
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding != "gzip" && coding != "*" {
+			continue
+		}
+		q, found := strings.CutPrefix(strings.TrimSpace(params), "q=")
+		if !found {
+			return true
+		}
+		if weight, err := strconv.ParseFloat(q, 64); err == nil && weight > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// gzipWriter holds back the start of a response until it knows whether to gzip it: once
+// minSize bytes are written, or the response ends or is flushed, its size and content
+// type decide
+type gzipWriter struct {
+	gin.ResponseWriter
+	minSize int
+	types   []string
+	pending []byte
+	decided bool
+	gz      *gzip.Writer
+}
+
+func (w *gzipWriter) Write(data []byte) (int, error) {
+	if w.decided {
+		if w.gz != nil {
+			return w.gz.Write(data)
+		}
+		return w.ResponseWriter.Write(data)
+	}
+
+	w.pending = append(w.pending, data...)
+	if len(w.pending) >= w.minSize {
+		if err := w.decide(); err != nil {
+			return 0, err
+		}
+	}
+	return len(data), nil
+}
+
+func (w *gzipWriter) WriteString(data string) (int, error) {
+	return w.Write([]byte(data))
+}
+
+// WriteHeaderNow waits for the decision, which may still add Content-Encoding
+func (w *gzipWriter) WriteHeaderNow() {
+	if w.decided {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
+
+func (w *gzipWriter) Flush() {
+	if !w.decided {
+		_ = w.decide()
+	}
+	if w.gz != nil {
+		_ = w.gz.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// decide starts the response, gzipped if enough of it is held back and its type is
+// compressible, and writes what was held back
+func (w *gzipWriter) decide() error {
+	w.decided = true
+	header := w.Header()
+	if len(w.pending) >= w.minSize && len(w.pending) > 0 && header.Get("Content-Encoding") == "" &&
+		compressibleType(header.Get("Content-Type"), w.types) {
+		header.Set("Content-Encoding", "gzip")
+		header.Del("Content-Length")
+		w.gz = gzipWriters.Get().(*gzip.Writer)
+		w.gz.Reset(w.ResponseWriter)
+	}
+
+	pending := w.pending
+	w.pending = nil
+	if len(pending) == 0 {
+		return nil
+	}
+	_, err := w.Write(pending)
+	return err
+}
+
+// finish writes what is still held back and ends the gzip stream
+func (w *gzipWriter) finish() {
+	if !w.decided {
+		_ = w.decide()
+	}
+	w.ResponseWriter.WriteHeaderNow()
+	if w.gz != nil {
+		_ = w.gz.Close()
+		gzipWriters.Put(w.gz)
+		w.gz = nil
+	}
+}
+
+// compressibleType reports whether contentType's media type starts with one of types
+func compressibleType(contentType string, types []string) bool {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	for _, t := range types {
+		if t != "" && strings.HasPrefix(mediaType, strings.ToLower(t)) {
+			return true
+		}
+	}
+	return false
+}
+
+// BodyLimitMiddleware creates a middleware limiting request bodies to maxBytes, or to the
+// limit routeLimits gives the matched route; a limit of zero leaves the route's handler
+// to limit its body itself. Bodies declaring a larger Content-Length are refused at once,
+// others once reading them passes the limit
+func BodyLimitMiddleware(maxBytes int64, routeLimits map[string]int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit := maxBytes
+		if routeLimit, ok := routeLimits[c.FullPath()]; ok {
+			limit = routeLimit
+		}
+		if limit <= 0 || c.Request.Body == nil || c.Request.Body == http.NoBody {
+			c.Next()
+			return
+		}
+
+		if c.Request.ContentLength > limit {
+			abortWithError(c, http.StatusRequestEntityTooLarge, "PAYLOAD_TOO_LARGE", bodyTooLargeMessage(limit), i18n.Params{"limit": limit})
+			return
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+
+		c.Next()
+	}
+}
+
+// abortUnreadableBody refuses a request whose body couldn't be read: with 413 if it passed
+// the body limit, and 400 otherwise
+func abortUnreadableBody(c *gin.Context, err error) {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		abortWithError(c, http.StatusRequestEntityTooLarge, "PAYLOAD_TOO_LARGE", bodyTooLargeMessage(maxBytesErr.Limit), i18n.Params{"limit": maxBytesErr.Limit})
+		return
+	}
+	abortWithError(c, http.StatusBadRequest, "INVALID_INPUT.body", "Failed to read request body", nil)
+}
+
+// bodyTooLargeMessage is the message of a request refused for a body over limit bytes
+func bodyTooLargeMessage(limit int64) string {
+	return "Request body must be at most " + strconv.FormatInt(limit, 10) + " bytes"
+}
+
+// SignatureMiddleware creates a middleware verifying the X-Signature header of ERP payloads
+// before they are bound. storeField is the dotted path of the ERP store ID in the body, and
+// payloads for a store with a secret in secrets must be signed with it; other stores' may
+// be sent unsigned, but while there are secrets payloads naming no store are refused.
+// Store IDs are matched case-insensitively, as viper lowercases the keys of configured
+// maps. The body is spooled to a temporary file and verified there, so it isn't held in
+// memory and the handler commits nothing of a payload that turns out to be forged
+func SignatureMiddleware(secrets map[string]string, storeField string, base *zap.Logger) gin.HandlerFunc {
+	// An NDJSON push names its store in its first line, the first JSON value of the body
+	return signatureMiddleware(secrets, func(c *gin.Context, body io.Reader) string {
+		return bodyField(body, storeField)
+	}, base)
+}
+
+// QuerySignatureMiddleware is SignatureMiddleware for payloads that aren't JSON, such as
+// CSV uploads, whose ERP store ID is the query parameter param
+func QuerySignatureMiddleware(secrets map[string]string, param string, base *zap.Logger) gin.HandlerFunc {
+	return signatureMiddleware(secrets, func(c *gin.Context, _ io.Reader) string {
+		return c.Query(param)
+	}, base)
+}
+
+// signatureMiddleware verifies the signatures of payloads for the store storeOf names
+func signatureMiddleware(secrets map[string]string, storeOf func(c *gin.Context, body io.Reader) string, base *zap.Logger) gin.HandlerFunc {
+	storeSecrets := make(map[string]string, len(secrets))
+	for storeID, secret := range secrets {
+		storeSecrets[strings.ToLower(storeID)] = secret
+	}
+
+	return func(c *gin.Context) {
+		if len(storeSecrets) == 0 {
+			c.Next()
+			return
+		}
+
+		body, ok := spoolBody(c, base)
+		if !ok {
+			return
+		}
+		defer removeSpool(body)
+		if !rewindSpool(c, body, base) {
+			return
+		}
+
+		// A payload whose store can't be told might be for a store with a secret
+		storeID := storeOf(c, body)
+		if storeID == "" {
+			abortWithError(c, http.StatusBadRequest, "INVALID_INPUT.store", "Request must name the store it is for", nil)
+			return
+		}
+		if !rewindSpool(c, body, base) {
+			return
+		}
+		secret, ok := storeSecrets[strings.ToLower(storeID)]
+		if !ok {
+			c.Next()
+			return
+		}
+
+		signature := c.GetHeader(auth.SignatureHeader)
+		valid := false
+		if signature != "" {
+			var err error
+			if valid, err = auth.VerifyReaderSignature(body, secret, signature); err != nil {
+				logger.FromContext(c.Request.Context(), base).Error("Failed to read spooled request body", zap.Error(err))
+				abortWithError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to read request body", nil)
+				return
+			}
+		}
+		if !valid {
+			logger.FromContext(c.Request.Context(), base).Warn("rejected payload signature",
+				zap.String("path", c.Request.URL.Path),
+				zap.String("store_id", storeID),
+				zap.Bool("signed", signature != ""))
+			if signature == "" {
+				abortWithError(c, http.StatusUnauthorized, "INVALID_SIGNATURE.missing", "Missing X-Signature header", nil)
+			} else {
+				abortWithError(c, http.StatusUnauthorized, "INVALID_SIGNATURE", "Invalid X-Signature header", nil)
+			}
+			return
+		}
+		if !rewindSpool(c, body, base) {
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// spoolBody copies the request body to a temporary file and hands the file to the handler
+// in its place, so the body can be read more than once without being held in memory. The
+// request is refused if the body can't be read or passes the body limit. The file is left
+// at its end: the caller must rewind it before reading, and remove it once the request is done
+func spoolBody(c *gin.Context, base *zap.Logger) (*os.File, bool) {
+	spool, err := os.CreateTemp("", "request-body-*")
+	if err != nil {
+		logger.FromContext(c.Request.Context(), base).Error("Failed to spool request body", zap.Error(err))
+		abortWithError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to read request body", nil)
+		return nil, false
+	}
+	if c.Request.Body != nil {
+		if _, err := io.Copy(spool, c.Request.Body); err != nil {
+			removeSpool(spool)
+			abortUnreadableBody(c, err)
+			return nil, false
+		}
+	}
+	c.Request.Body = io.NopCloser(spool)
+	return spool, true
+}
+
+// rewindSpool moves a spooled body back to its start, refusing the request if it can't
+func rewindSpool(c *gin.Context, spool *os.File, base *zap.Logger) bool {
+	if _, err := spool.Seek(0, io.SeekStart); err != nil {
+		logger.FromContext(c.Request.Context(), base).Error("Failed to rewind spooled request body", zap.Error(err))
+		abortWithError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to read request body", nil)
+		return false
+	}
+	return true
+}
+
+// removeSpool closes and deletes a spooled body
+func removeSpool(spool *os.File) {
+	_ = spool.Close()
+	_ = os.Remove(spool.Name())
+}
+
+// bodyField returns the string at the dotted path field of the first JSON value read from
+// body, or "" if there is none or the body isn't JSON. Keys are matched as binding matches
+// them: case-insensitively, the last of several matching keys winning. The body is read a
+// token at a time, so values on the way, such as a push's products, aren't held in memory
+func bodyField(body io.Reader, field string) string {
+	value, _ := fieldValue(json.NewDecoder(body), strings.Split(field, "."))
+	return value
+}
+
+// fieldValue reads the next JSON value from dec, returning the string at path within it,
+// or "" if there is none, and whether the value could be read
+func fieldValue(dec *json.Decoder, path []string) (string, bool) {
+	token, err := dec.Token()
+	if err != nil {
+		return "", false
+	}
+	delim, isDelim := token.(json.Delim)
+	if len(path) == 0 || delim != '{' {
+		if isDelim && !skipRest(dec) {
+			return "", false
+		}
+		value, _ := token.(string)
+		if len(path) > 0 {
+			value = ""
+		}
+		return value, true
+	}
+
+	var value string
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return "", false
+		}
+		rest := []string(nil) // Values under other keys are read past
+		if name, _ := key.(string); strings.EqualFold(name, path[0]) {
+			rest = path[1:]
+		}
+		found, ok := fieldValue(dec, rest)
+		if !ok {
+			return "", false
+		}
+		if rest != nil {
+			value = found
+		}
+	}
+	_, err = dec.Token() // The object's closing brace
+	return value, err == nil
+}
+
+// skipRest reads the rest of the object or array whose opening delimiter dec just read
+func skipRest(dec *json.Decoder) bool {
+	for depth := 1; depth > 0; {
+		token, err := dec.Token()
+		if err != nil {
+			return false
+		}
+		switch token {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+	}
+	return true
+}
+
+// abortWithError writes an error payload and stops the request. key is the error code,
+// or the code and the name of one of its messages (FORBIDDEN.scope): message, in English,
+// is translated by it into the language of the request, filling in params
+func abortWithError(c *gin.Context, status int, key, message string, params i18n.Params) {
+	code, _, _ := strings.Cut(key, ".")
+	message = i18n.T(c.Request.Context(), key, message, params)
+	c.JSON(status, gin.H{
+		"status": "error",
+		"error": gin.H{
+			"code":       code,
+			"message":    message,
+			"request_id": requestID(c),
+		},
+	})
+	c.Abort()
+}
+
+// idempotencyLockTTL bounds how long a request holding an idempotency key can keep
+// retries with the same key waiting, should its instance die before releasing it
+const idempotencyLockTTL = 5 * time.Minute
+
+// idempotentResponse is a response stored for replay to retries with the same
+// Idempotency-Key
+type idempotentResponse struct {
+	Fingerprint string `json:"fingerprint"`
+	Status      int    `json:"status"`
+	ContentType string `json:"content_type"`
+	Body        []byte `json:"body"`
+}
+
+// recordingWriter keeps a copy of the response body it writes
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *recordingWriter) WriteString(data string) (int, error) {
+	w.body.WriteString(data)
+	return w.ResponseWriter.WriteString(data)
+}
+
+// IdempotencyMiddleware creates a middleware honoring the Idempotency-Key header of
+// authenticated POST, PUT and PATCH requests. The first response under a key, unless it
+// is a 5xx, is stored in Redis by caller and key for ttl and replayed to retries, marked
+// with Idempotent-Replayed; a retry with another method, path or body is refused, as is
+// one arriving while the first is still being handled. Without Redis requests are handled
+// as if they had no key
+func IdempotencyMiddleware(cacheService cache.CacheService, ttl time.Duration, base *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("Idempotency-Key")
+		principal := auth.PrincipalFrom(c.Request.Context())
+		if key == "" || principal == nil || cacheService == nil || ttl <= 0 {
+			c.Next()
+			return
+		}
+		switch c.Request.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch:
+		default:
+			c.Next()
+			return
+		}
+		if len(key) > 255 {
+			abortWithError(c, http.StatusBadRequest, "INVALID_INPUT.idempotency_key", "Idempotency-Key must be at most 255 characters", nil)
+			return
+		}
+
+		fingerprint := fingerprintBody(c)
+
+		ctx := c.Request.Context()
+		log := logger.FromContext(ctx, base)
+		cacheKey := cacheService.GenerateKey(cache.DomainIdempotency, map[string]string{"caller": principal.ID, "key": key})
+
+		if replayIdempotentResponse(c, cacheService, cacheKey, fingerprint) {
+			return
+		}
+
+		lock, err := cacheService.AcquireLock(ctx, cacheKey, idempotencyLockTTL)
+		switch {
+		case errors.Is(err, cache.ErrLockNotAcquired):
+			abortWithError(c, http.StatusConflict, "IDEMPOTENCY_IN_PROGRESS", "A request with this Idempotency-Key is still being handled", nil)
+			return
+		case err != nil:
+			log.Warn("idempotency unavailable, handling request without it", zap.String("path", c.Request.URL.Path), zap.Error(err))
+			c.Next()
+			return
+		}
+		defer func() {
+			// The request's context may have timed out; release the lock regardless
+			_ = cacheService.ReleaseLock(context.WithoutCancel(ctx), lock)
+		}()
+
+		// A retry may have finished between the lookup and taking the lock
+		if replayIdempotentResponse(c, cacheService, cacheKey, fingerprint) {
+			return
+		}
+
+		writer := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+
+		if writer.Status() >= http.StatusInternalServerError {
+			return
+		}
+		// The handler may have left some of the body unread; a body that can't be read to
+		// its end can't be told from a retry's, so its response isn't kept
+		requestFingerprint, err := fingerprint()
+		if err != nil {
+			return
+		}
+		stored, _ := json.Marshal(idempotentResponse{
+			Fingerprint: requestFingerprint,
+			Status:      writer.Status(),
+			ContentType: writer.Header().Get("Content-Type"),
+			Body:        writer.body.Bytes(),
+		})
+		_ = cacheService.Set(context.WithoutCancel(ctx), cacheKey, stored, ttl)
+	}
+}
+
+// fingerprintBody returns a function fingerprinting the request by its method, URI and
+// body. The body is hashed as it is read, by the handler or by the function itself, which
+// reads whatever the handler left; so it isn't held in memory, and its size is bounded by
+// BodyLimitMiddleware alone
+func fingerprintBody(c *gin.Context) func() (string, error) {
+	prefix := c.Request.Method + " " + c.Request.URL.RequestURI() + " "
+	hash := sha256.New()
+	body := c.Request.Body
+	if body == nil {
+		body = http.NoBody
+	}
+	tee := io.TeeReader(body, hash)
+	c.Request.Body = struct {
+		io.Reader
+		io.Closer
+	}{tee, body}
+
+	var fingerprint string
+	var err error
+	done := false
+	return func() (string, error) {
+		if !done {
+			done = true
+			if _, err = io.Copy(io.Discard, tee); err == nil {
+				fingerprint = prefix + hex.EncodeToString(hash.Sum(nil))
+			}
+		}
+		return fingerprint, err
+	}
+}
+
+// replayIdempotentResponse writes the response stored under cacheKey, or refuses the
+// request if it was stored for another request, reporting whether there was one. The
+// request is only fingerprinted, reading its body, if there is one
+func replayIdempotentResponse(c *gin.Context, cacheService cache.CacheService, cacheKey string, fingerprint func() (string, error)) bool {
+	data, _ := cacheService.Get(c.Request.Context(), cacheKey)
+	if data == nil {
+		return false
+	}
+	var stored idempotentResponse
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return false
+	}
+
+	requestFingerprint, err := fingerprint()
+	if err != nil {
+		abortUnreadableBody(c, err)
+		return true
+	}
+	if stored.Fingerprint != requestFingerprint {
+		abortWithError(c, http.StatusUnprocessableEntity, "IDEMPOTENCY_KEY_REUSED", "Idempotency-Key was already used for another request", nil)
+		return true
+	}
+	c.Header("Idempotent-Replayed", "true")
+	c.Data(stored.Status, stored.ContentType, stored.Body)
+	c.Abort()
+	return true
+}
+
+// AuditActorMiddleware attaches the caller and matched route to the request context
+// for the repository's audit log. The bearer token itself is never stored: callers are
+// identified by auth.TokenActor
+func AuditActorMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actor := "anonymous"
+		if token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); ok && token != "" {
+			actor = auth.TokenActor(token)
+		}
+
+		ctx := repository.WithAuditActor(c.Request.Context(), repository.AuditActor{
+			Actor:    actor,
+			Endpoint: c.Request.Method + " " + c.FullPath(),
+		})
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+	}
+}
+
+// RequestIDMiddleware attaches the request's X-Request-ID, or a new one if it has none, to
+// the request context and echoes it in the response, so Supabase calls made for the request
+// carry it and can be traced. A logger adding it to every line, derived from base, is
+// attached alongside for the handlers, services and repositories serving the request
+func RequestIDMiddleware(base *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader("X-Request-ID")
+		if id == "" || len(id) > 128 {
+			id = uuid.NewString()
+		}
+		c.Header("X-Request-ID", id)
+		ctx := repository.WithRequestID(c.Request.Context(), id)
+		ctx = logger.WithContext(ctx, base.With(zap.String("request_id", id)))
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// LocaleMiddleware translates the messages of the request's error responses into the
+// language of its Accept-Language header, if catalog has it; codes stay the same
+func LocaleMiddleware(catalog *i18n.Catalog) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		locale := catalog.Negotiate(c.GetHeader("Accept-Language"))
+		c.Request = c.Request.WithContext(i18n.WithLocale(c.Request.Context(), catalog, locale))
+		c.Next()
+	}
+}
+
+// CacheTTLMiddleware lets trusted callers, those holding the read:catalog scope, choose
+// how long their reads are cached with the cache_ttl query parameter, in seconds, clamped
+// to between minTTL and maxTTL as they are when it is read. Other callers' cache_ttl is ignored
+func CacheTTLMiddleware(minTTL, maxTTL *cache.TTL) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		raw, ok := c.GetQuery("cache_ttl")
+		principal := auth.PrincipalFrom(c.Request.Context())
+		if !ok || principal == nil || !principal.HasScope(repository.ScopeReadCatalog) {
+			c.Next()
+			return
+		}
+
+		seconds, err := strconv.Atoi(raw)
+		if err != nil || seconds <= 0 {
+			abortWithError(c, http.StatusBadRequest, "INVALID_INPUT.cache_ttl", "cache_ttl must be a positive number of seconds", nil)
+			return
+		}
+
+		ttl := min(max(time.Duration(seconds)*time.Second, minTTL.Get()), maxTTL.Get())
+		c.Request = c.Request.WithContext(service.WithTTLOverride(c.Request.Context(), ttl))
+		c.Next()
+	}
+}
+
+// CacheControlMiddleware lets CDNs and clients' HTTP caches reuse successful GET
+// responses served from the Redis cache for as long as the entries they were served from
+// stay fresh. Such a response is sent with Cache-Control: max-age set to the shortest time
+// left on those entries, capped at maxAge if it is positive, and revalidated with its
+// ETag once it expires. It is public for anonymous callers, varying by the vary headers,
+// and private for authenticated ones, whose responses may depend on who they are. A
+// response serving a stale copy gets max-age=0; responses read from no cache entry, and
+// those setting Cache-Control themselves, are left as they are
+func CacheControlMiddleware(maxAge time.Duration, vary ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet {
+			c.Next()
+			return
+		}
+
+		ctx, freshness := cache.WithFreshness(c.Request.Context())
+		c.Request = c.Request.WithContext(ctx)
+		writer := &cacheControlWriter{ResponseWriter: c.Writer}
+		writer.stamp = func() {
+			remaining, ok := freshness.MaxAge()
+			status := writer.Status()
+			if !ok || (status != http.StatusOK && status != http.StatusNotModified) || writer.Header().Get("Cache-Control") != "" {
+				return
+			}
+			if maxAge > 0 {
+				remaining = min(remaining, maxAge)
+			}
+
+			visibility := "public"
+			if auth.PrincipalFrom(c.Request.Context()) != nil || c.GetHeader("Authorization") != "" {
+				visibility = "private"
+			}
+			writer.Header().Set("Cache-Control", visibility+", max-age="+strconv.FormatInt(int64(remaining/time.Second), 10))
+			if visibility == "public" {
+				for _, header := range vary {
+					writer.Header().Add("Vary", header)
+				}
+			}
+		}
+		c.Writer = writer
+		defer func() { c.Writer = writer.ResponseWriter }()
+
+		c.Next()
+		// Responses without a body, such as a 304, are only sent once the handler returns
+		writer.stampOnce()
+	}
+}
+
+// cacheControlWriter stamps Cache-Control on a response just before its headers are sent,
+// once the handler has read what it is served from
+type cacheControlWriter struct {
+	gin.ResponseWriter
+	stamp   func()
+	stamped bool
+}
+
+func (w *cacheControlWriter) stampOnce() {
+	if !w.stamped {
+		w.stamped = true
+		w.stamp()
+	}
+}
+
+func (w *cacheControlWriter) WriteHeaderNow() {
+	w.stampOnce()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *cacheControlWriter) Write(data []byte) (int, error) {
+	w.stampOnce()
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *cacheControlWriter) WriteString(data string) (int, error) {
+	w.stampOnce()
+	return w.ResponseWriter.WriteString(data)
+}
+
+func (w *cacheControlWriter) Flush() {
+	w.stampOnce()
+	w.ResponseWriter.Flush()
+}
+
+// DomainMiddleware attaches domain to the request context, so a Supabase registry sends
+// the request's queries to the project configured for it
+func DomainMiddleware(domain string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(repository.WithDomain(c.Request.Context(), domain))
+		c.Next()
+	}
+}
+
+// UserTokenMiddleware attaches the caller's bearer token to the request context, so a
+// Supabase repository created WithUserTokens queries as the caller and RLS policies apply
+func UserTokenMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); ok && token != "" {
+			c.Request = c.Request.WithContext(repository.WithAccessToken(c.Request.Context(), token))
+		}
+		c.Next()
+	}
+}
+
+// requestID returns the ID of the request being served, for error payloads
+func requestID(c *gin.Context) string {
+	return repository.RequestIDFrom(c.Request.Context())
+}
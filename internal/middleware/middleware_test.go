@@ -0,0 +1,512 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/supabase-redis-middleware/internal/auth"
+	"github.com/yourusername/supabase-redis-middleware/internal/cache"
+	"github.com/yourusername/supabase-redis-middleware/internal/i18n"
+	"github.com/yourusername/supabase-redis-middleware/internal/repository"
+	"github.com/yourusername/supabase-redis-middleware/internal/tenant"
+	"go.uber.org/zap"
+)
+
+func TestLocaleMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	catalog, err := i18n.NewCatalog("")
+	if err != nil {
+		t.Fatalf("NewCatalog() error = %v", err)
+	}
+	router := gin.New()
+	router.Use(LocaleMiddleware(catalog))
+	router.GET("/scoped", func(c *gin.Context) {
+		abortWithError(c, http.StatusForbidden, "FORBIDDEN.scope", "Caller lacks the admin scope", i18n.Params{"scope": "admin"})
+	})
+
+	tests := []struct {
+		acceptLanguage string
+		want           string
+	}{
+		{acceptLanguage: "", want: "Caller lacks the admin scope"},
+		{acceptLanguage: "en-US", want: "Caller lacks the admin scope"},
+		{acceptLanguage: "hi-IN,en;q=0.5", want: "कॉलर के पास admin scope नहीं है"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/scoped", nil)
+		req.Header.Set("Accept-Language", tt.acceptLanguage)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		var body struct {
+			Error struct {
+				Code    string `json:"code"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("invalid body %s: %v", rec.Body, err)
+		}
+		if body.Error.Code != "FORBIDDEN" {
+			t.Errorf("Accept-Language %q: code = %q, want FORBIDDEN", tt.acceptLanguage, body.Error.Code)
+		}
+		if body.Error.Message != tt.want {
+			t.Errorf("Accept-Language %q: message = %q, want %q", tt.acceptLanguage, body.Error.Message, tt.want)
+		}
+	}
+}
+
+func TestAllowedOrigins(t *testing.T) {
+	origins := NewAllowedOrigins([]string{"https://shop.example.com/"})
+
+	tests := []struct {
+		origin string
+		want   bool
+	}{
+		{origin: "https://shop.example.com", want: true},
+		{origin: "https://SHOP.example.com", want: true},
+		{origin: "http://shop.example.com"},
+		{origin: "https://evil.example.com"},
+	}
+	for _, tt := range tests {
+		if got := origins.Allows(tt.origin); got != tt.want {
+			t.Errorf("Allows(%q) = %v, want %v", tt.origin, got, tt.want)
+		}
+	}
+
+	origins.Set([]string{"*"})
+	if !origins.Allows("https://evil.example.com") {
+		t.Error("Allows() with * = false, want true")
+	}
+}
+
+func TestSignatureMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	const secret = "store-secret"
+	router := gin.New()
+	router.POST("/stock", SignatureMiddleware(map[string]string{"STORE-001": secret}, "store_id", zap.NewNop()), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+	router.POST("/push", SignatureMiddleware(map[string]string{"STORE-001": secret}, "store_details.store_id", zap.NewNop()), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	tests := []struct {
+		name   string
+		path   string
+		body   string
+		signed bool
+		want   int
+	}{
+		{name: "signed", path: "/stock", body: `{"store_id":"STORE-001"}`, signed: true, want: http.StatusNoContent},
+		{name: "unsigned", path: "/stock", body: `{"store_id":"STORE-001"}`, want: http.StatusUnauthorized},
+		{name: "store without a secret", path: "/stock", body: `{"store_id":"STORE-002"}`, want: http.StatusNoContent},
+		{name: "mixed-case key", path: "/stock", body: `{"STORE_ID":"store-001"}`, want: http.StatusUnauthorized},
+		{name: "duplicate keys", path: "/stock", body: `{"store_id":"STORE-002","Store_ID":"STORE-001"}`, want: http.StatusUnauthorized},
+		{name: "duplicate keys, last without a secret", path: "/stock", body: `{"Store_ID":"STORE-001","store_id":"STORE-002"}`, want: http.StatusNoContent},
+		{name: "mixed-case nested keys", path: "/push", body: `{"Store_Details":{"STORE_ID":"STORE-001"}}`, want: http.StatusUnauthorized},
+		{name: "duplicate nested objects", path: "/push", body: `{"store_details":{"store_id":"STORE-002"},"STORE_DETAILS":{"store_id":"STORE-001"}}`, want: http.StatusUnauthorized},
+		{name: "store after other values", path: "/stock", body: `{"items":[{"store_id":"STORE-002"}],"note":{"a":[1,{}]},"store_id":"STORE-001"}`, want: http.StatusUnauthorized},
+		{name: "NDJSON, signed", path: "/push", body: "{\"store_details\":{\"store_id\":\"STORE-001\"}}\n{\"sku\":\"A\"}\n", signed: true, want: http.StatusNoContent},
+		{name: "NDJSON, unsigned", path: "/push", body: "{\"store_details\":{\"store_id\":\"STORE-001\"}}\n{\"sku\":\"A\"}\n", want: http.StatusUnauthorized},
+		{name: "no store", path: "/stock", body: `{"items":[]}`, want: http.StatusBadRequest},
+		{name: "not an object", path: "/stock", body: `["STORE-001"]`, want: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.signed {
+				req.Header.Set(auth.SignatureHeader, auth.Sign([]byte(tt.body), secret))
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d; body %s", rec.Code, tt.want, rec.Body)
+			}
+		})
+	}
+}
+
+func TestSignatureMiddleware_PassesBodyOn(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	const secret = "store-secret"
+	var body strings.Builder
+	body.WriteString(`{"store_details":{"store_id":"STORE-001"}}` + "\n")
+	for i := 0; i < 10000; i++ {
+		fmt.Fprintf(&body, `{"sku":"SKU-%05d","quantity":%d}`+"\n", i, i)
+	}
+
+	var got []byte
+	router := gin.New()
+	router.POST("/push", SignatureMiddleware(map[string]string{"STORE-001": secret}, "store_details.store_id", zap.NewNop()), func(c *gin.Context) {
+		got, _ = io.ReadAll(c.Request.Body)
+		c.Status(http.StatusNoContent)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/push", strings.NewReader(body.String()))
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	req.Header.Set(auth.SignatureHeader, auth.Sign([]byte(body.String()), secret))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want 204; body %s", rec.Code, rec.Body)
+	}
+	if string(got) != body.String() {
+		t.Errorf("handler read %d bytes, want the whole %d-byte body", len(got), body.Len())
+	}
+}
+
+func TestIPAllowlistMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	allowed := []netip.Prefix{netip.MustParsePrefix("203.0.113.0/24")}
+	keyCIDRs := []netip.Prefix{netip.MustParsePrefix("198.51.100.7/32")}
+	newRouter := func(trustProxies bool, principal *auth.Principal) *gin.Engine {
+		router := gin.New()
+		if trustProxies {
+			if err := router.SetTrustedProxies([]string{"10.0.0.0/8"}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		router.Use(func(c *gin.Context) {
+			c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), principal))
+		})
+		router.POST("/push", IPAllowlistMiddleware(allowed, trustProxies, zap.NewNop()), func(c *gin.Context) {
+			c.Status(http.StatusNoContent)
+		})
+		return router
+	}
+
+	tests := []struct {
+		name         string
+		trustProxies bool
+		keyCIDRs     []netip.Prefix
+		remoteAddr   string
+		forwardedFor string
+		want         int
+	}{
+		{name: "allowed connection", remoteAddr: "203.0.113.9:4000", want: http.StatusNoContent},
+		{name: "other connection", remoteAddr: "192.0.2.1:4000", want: http.StatusForbidden},
+		{name: "spoofed X-Forwarded-For", remoteAddr: "192.0.2.1:4000", forwardedFor: "203.0.113.9", want: http.StatusForbidden},
+		{name: "spoofed X-Forwarded-For for a key", keyCIDRs: keyCIDRs, remoteAddr: "203.0.113.9:4000", forwardedFor: "198.51.100.7", want: http.StatusForbidden},
+		{name: "trusted proxy", trustProxies: true, remoteAddr: "10.1.2.3:4000", forwardedFor: "203.0.113.9", want: http.StatusNoContent},
+		{name: "untrusted proxy", trustProxies: true, remoteAddr: "192.0.2.1:4000", forwardedFor: "203.0.113.9", want: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal := &auth.Principal{ID: "key-1", AllowedCIDRs: tt.keyCIDRs}
+			req := httptest.NewRequest(http.MethodPost, "/push", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.forwardedFor != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwardedFor)
+			}
+			rec := httptest.NewRecorder()
+			newRouter(tt.trustProxies, principal).ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestTenantMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	platform := &auth.Principal{ID: "platform", Role: repository.RolePlatformAdmin}
+	acmeERP := &auth.Principal{ID: "acme-erp", Role: repository.RoleERP, TenantID: "acme"}
+
+	tests := []struct {
+		name          string
+		principal     *auth.Principal
+		header        string
+		defaultTenant string
+		wantStatus    int
+		wantTenant    string
+	}{
+		{name: "key's tenant", principal: acmeERP, wantStatus: http.StatusOK, wantTenant: "acme"},
+		{name: "key naming its own tenant", principal: acmeERP, header: "acme", wantStatus: http.StatusOK, wantTenant: "acme"},
+		{name: "key naming another tenant", principal: acmeERP, header: "globex", wantStatus: http.StatusForbidden},
+		{name: "platform naming a tenant", principal: platform, header: "globex", wantStatus: http.StatusOK, wantTenant: "globex"},
+		{name: "platform naming none", principal: platform, defaultTenant: "default", wantStatus: http.StatusOK, wantTenant: ""},
+		{name: "anonymous naming a tenant", header: "globex", wantStatus: http.StatusOK, wantTenant: "globex"},
+		{name: "anonymous with a default", defaultTenant: "default", wantStatus: http.StatusOK, wantTenant: "default"},
+		{name: "anonymous without a default", wantStatus: http.StatusBadRequest},
+		{name: "invalid tenant", header: "Not A Tenant", defaultTenant: "default", wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotTenant string
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				if tt.principal != nil {
+					c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), tt.principal))
+				}
+			})
+			router.GET("/stores", TenantMiddleware("X-Tenant-ID", tt.defaultTenant, zap.NewNop()), func(c *gin.Context) {
+				gotTenant = tenant.FromContext(c.Request.Context())
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/stores", nil)
+			if tt.header != "" {
+				req.Header.Set("X-Tenant-ID", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d; body %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if gotTenant != tt.wantTenant {
+				t.Errorf("tenant = %q, want %q", gotTenant, tt.wantTenant)
+			}
+		})
+	}
+}
+
+func TestRequireTenantMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	platform := &auth.Principal{ID: "platform", Role: repository.RolePlatformAdmin}
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), platform))
+	})
+	router.POST("/push", TenantMiddleware("X-Tenant-ID", "default", zap.NewNop()), RequireTenantMiddleware("X-Tenant-ID", zap.NewNop()), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/push", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "Writes must name a tenant in X-Tenant-ID") {
+		t.Errorf("write without a tenant = %d %s, want 400 TENANT_REQUIRED", rec.Code, rec.Body)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/push", nil)
+	req.Header.Set("X-Tenant-ID", "acme")
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("write for a tenant = %d, want 204", rec.Code)
+	}
+}
+
+func TestTimeoutMiddleware_TimesOutBeforeWrite(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(TimeoutMiddleware(20 * time.Millisecond))
+	router.GET("/slow", func(c *gin.Context) {
+		<-c.Request.Context().Done()
+		c.Header("X-Late", "true")
+		c.JSON(http.StatusOK, gin.H{"status": "success"})
+	})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/slow", nil))
+
+	if rec.Code != http.StatusGatewayTimeout {
+		t.Errorf("status = %d, want 504", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), `"code":"TIMEOUT"`) || strings.Contains(rec.Body.String(), "success") {
+		t.Errorf("body = %s, want only the TIMEOUT error", rec.Body)
+	}
+	if rec.Header().Get("X-Late") != "" {
+		t.Error("headers set after the timeout should not be sent")
+	}
+}
+
+func TestTimeoutMiddleware_SendsTimeoutBeforeHandlerReturns(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// The handler ignores its context, so it only returns once released
+	release := make(chan struct{})
+	returned := make(chan struct{})
+	router := gin.New()
+	router.Use(TimeoutMiddleware(20 * time.Millisecond))
+	router.GET("/stuck", func(c *gin.Context) {
+		defer close(returned)
+		<-release
+	})
+	server := httptest.NewServer(router)
+	defer server.Close()
+	defer close(release)
+
+	client := &http.Client{Timeout: 2 * time.Second}
+	resp, err := client.Get(server.URL + "/stuck")
+	if err != nil {
+		t.Fatalf("GET error = %v", err)
+	}
+	defer resp.Body.Close()
+
+	select {
+	case <-returned:
+		t.Fatal("the 504 arrived only after the handler returned")
+	default:
+	}
+	if resp.StatusCode != http.StatusGatewayTimeout {
+		t.Errorf("status = %d, want 504", resp.StatusCode)
+	}
+}
+
+func TestTimeoutMiddleware_KeepsFlushedResponse(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(TimeoutMiddleware(20 * time.Millisecond))
+	router.GET("/stream", func(c *gin.Context) {
+		c.String(http.StatusOK, "first;")
+		c.Writer.Flush()
+		<-c.Request.Context().Done()
+		c.String(http.StatusOK, "second")
+	})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stream", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d, want the flushed 200", rec.Code)
+	}
+	if got := rec.Body.String(); got != "first;second" {
+		t.Errorf("body = %q, want %q", got, "first;second")
+	}
+}
+
+func TestTimeoutMiddleware_RaisesPanic(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(gin.RecoveryWithWriter(io.Discard))
+	router.Use(TimeoutMiddleware(time.Second))
+	router.GET("/panic", func(c *gin.Context) {
+		c.Header("X-Partial", "true")
+		panic("handler failed")
+	})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/panic", nil))
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want 500 from the recovery middleware", rec.Code)
+	}
+	if rec.Header().Get("X-Partial") != "" {
+		t.Error("headers of a panicking handler should not be sent")
+	}
+}
+
+// memoryCache is the part of a CacheService the idempotency middleware uses, in memory
+type memoryCache struct {
+	cache.CacheService
+	entries map[string][]byte
+}
+
+func (m *memoryCache) Get(_ context.Context, key string) ([]byte, error) {
+	return m.entries[key], nil
+}
+
+func (m *memoryCache) Set(_ context.Context, key string, value []byte, _ time.Duration) error {
+	m.entries[key] = value
+	return nil
+}
+
+func (m *memoryCache) AcquireLock(_ context.Context, name string, _ time.Duration) (*cache.Lock, error) {
+	return &cache.Lock{Name: name}, nil
+}
+
+func (m *memoryCache) ReleaseLock(context.Context, *cache.Lock) error {
+	return nil
+}
+
+func (m *memoryCache) GenerateKey(domain string, params map[string]string) string {
+	return domain + ":" + params["caller"] + ":" + params["key"]
+}
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handled := 0
+	var read []string
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), &auth.Principal{ID: "key:1"}))
+	})
+	router.Use(BodyLimitMiddleware(64, nil))
+	router.Use(IdempotencyMiddleware(&memoryCache{entries: map[string][]byte{}}, time.Hour, zap.NewNop()))
+	router.POST("/orders", func(c *gin.Context) {
+		handled++
+		body, _ := io.ReadAll(c.Request.Body)
+		read = append(read, string(body))
+		c.JSON(http.StatusCreated, gin.H{"order": handled})
+	})
+	router.POST("/peek", func(c *gin.Context) {
+		// Reads only the start of the body, leaving the middleware the rest
+		handled++
+		_, _ = io.ReadFull(c.Request.Body, make([]byte, 2))
+		c.JSON(http.StatusCreated, gin.H{"order": handled})
+	})
+
+	send := func(path, key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set("Idempotency-Key", key)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := send("/orders", "a", `{"sku":"A"}`)
+	if first.Code != http.StatusCreated {
+		t.Fatalf("first request = %d, want 201", first.Code)
+	}
+	if len(read) != 1 || read[0] != `{"sku":"A"}` {
+		t.Errorf("handler read %q, want the whole body", read)
+	}
+
+	tests := []struct {
+		name         string
+		path         string
+		key          string
+		body         string
+		want         int
+		wantReplayed bool
+	}{
+		{name: "retry", path: "/orders", key: "a", body: `{"sku":"A"}`, want: http.StatusCreated, wantReplayed: true},
+		{name: "reused key, another body", path: "/orders", key: "a", body: `{"sku":"B"}`, want: http.StatusUnprocessableEntity},
+		{name: "reused key, body over the limit", path: "/orders", key: "a", body: strings.Repeat("x", 65), want: http.StatusRequestEntityTooLarge},
+		{name: "handler reading part of the body", path: "/peek", key: "b", body: `{"sku":"A"}`, want: http.StatusCreated},
+		{name: "retry of a partly read body", path: "/peek", key: "b", body: `{"sku":"A"}`, want: http.StatusCreated, wantReplayed: true},
+		{name: "partly read key, another body", path: "/peek", key: "b", body: `{"sku":"C"}`, want: http.StatusUnprocessableEntity},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := handled
+			rec := send(tt.path, tt.key, tt.body)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d; body %s", rec.Code, tt.want, rec.Body)
+			}
+			replayed := rec.Header().Get("Idempotent-Replayed") == "true"
+			if replayed != tt.wantReplayed {
+				t.Errorf("replayed = %v, want %v", replayed, tt.wantReplayed)
+			}
+			if tt.wantReplayed && handled != before {
+				t.Error("handler ran for a replayed request")
+			}
+		})
+	}
+}
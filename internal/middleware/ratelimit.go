@@ -0,0 +1,162 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/supabase-redis-middleware/internal/auth"
+	"github.com/yourusername/supabase-redis-middleware/internal/logger"
+	"github.com/yourusername/supabase-redis-middleware/internal/tenant"
+	"go.uber.org/zap"
+)
+
+// RateLimit lets a caller send Burst requests at once, refilled at RequestsPerSecond;
+// zero RequestsPerSecond doesn't limit it
+type RateLimit struct {
+	RequestsPerSecond float64
+	Burst             int
+}
+
+// RateLimits are the limits a RateLimiter holds callers to: Default, or their tenant's
+// entry in Tenants
+type RateLimits struct {
+	Default RateLimit
+	Tenants map[string]RateLimit
+}
+
+// For returns the limit for callers in tenantID
+func (l RateLimits) For(tenantID string) RateLimit {
+	if limit, ok := l.Tenants[tenantID]; ok {
+		return limit
+	}
+	return l.Default
+}
+
+// rateLimitSweepInterval is how often buckets that have refilled are dropped; a full
+// bucket allows as much as a missing one
+const rateLimitSweepInterval = time.Minute
+
+// RateLimiter keeps a token bucket for each caller in each tenant, in memory, so every
+// instance of the server limits the requests it serves itself. Its limits can be changed
+// while the server runs, as they are when the configuration is reloaded
+type RateLimiter struct {
+	limits  atomic.Pointer[RateLimits]
+	now     func() time.Time
+	mu      sync.Mutex
+	buckets map[rateLimitKey]*tokenBucket
+	swept   time.Time
+}
+
+// rateLimitKey names a caller's bucket: callers are limited separately in each tenant
+type rateLimitKey struct {
+	tenant string
+	caller string
+}
+
+type tokenBucket struct {
+	tokens float64
+	filled time.Time // When tokens was last brought up to date
+}
+
+// NewRateLimiter returns a RateLimiter holding callers to limits
+func NewRateLimiter(limits RateLimits) *RateLimiter {
+	l := &RateLimiter{
+		now:     time.Now,
+		buckets: map[rateLimitKey]*tokenBucket{},
+	}
+	l.Set(limits)
+	return l
+}
+
+// Set replaces the limits. Callers keep the requests left in their buckets, up to their
+// new burst, and earn more at their new rate from then on
+func (l *RateLimiter) Set(limits RateLimits) {
+	l.limits.Store(&limits)
+}
+
+// Allow takes a request from caller's bucket in tenantID, reporting whether one was left
+// and, if not, how long until there is
+func (l *RateLimiter) Allow(tenantID, caller string) (bool, time.Duration) {
+	limits := l.limits.Load()
+	limit := limits.For(tenantID)
+	if limit.RequestsPerSecond <= 0 {
+		return true, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	l.sweep(now, limits)
+
+	key := rateLimitKey{tenant: tenantID, caller: caller}
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: float64(limit.Burst), filled: now}
+		l.buckets[key] = b
+	}
+	b.refill(now, limit)
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / limit.RequestsPerSecond * float64(time.Second))
+		return false, wait
+	}
+	b.tokens--
+	return true, 0
+}
+
+// sweep drops the buckets that have refilled, once every rateLimitSweepInterval; l.mu
+// must be held
+func (l *RateLimiter) sweep(now time.Time, limits *RateLimits) {
+	if now.Sub(l.swept) < rateLimitSweepInterval {
+		return
+	}
+	l.swept = now
+	for key, b := range l.buckets {
+		limit := limits.For(key.tenant)
+		b.refill(now, limit)
+		if limit.RequestsPerSecond <= 0 || b.tokens >= float64(limit.Burst) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// refill adds the tokens earned since the bucket was last filled, up to limit.Burst
+func (b *tokenBucket) refill(now time.Time, limit RateLimit) {
+	if elapsed := now.Sub(b.filled); elapsed > 0 {
+		b.tokens += elapsed.Seconds() * limit.RequestsPerSecond
+		b.filled = now
+	}
+	b.tokens = min(b.tokens, float64(limit.Burst))
+}
+
+// RateLimitMiddleware refuses requests beyond the caller's rate limit with 429 and a
+// Retry-After header. Authenticated callers are limited by credential, anonymous ones by
+// address, and each separately in every tenant, so one tenant's traffic never uses up
+// another's; it must run after authentication and tenant resolution
+func RateLimitMiddleware(limiter *RateLimiter, base *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		caller := "ip:" + c.ClientIP()
+		if principal := auth.PrincipalFrom(ctx); principal != nil {
+			caller = principal.ID
+		}
+		tenantID := tenant.FromContext(ctx)
+
+		allowed, wait := limiter.Allow(tenantID, caller)
+		if !allowed {
+			logger.FromContext(ctx, base).Warn("rate limit exceeded",
+				zap.String("path", c.Request.URL.Path),
+				zap.String("caller", caller),
+				zap.String("tenant", tenantID))
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			abortWithError(c, http.StatusTooManyRequests, "RATE_LIMITED", "Too many requests; retry later", nil)
+			return
+		}
+
+		c.Next()
+	}
+}
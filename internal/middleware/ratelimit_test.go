@@ -0,0 +1,184 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/supabase-redis-middleware/internal/auth"
+	"github.com/yourusername/supabase-redis-middleware/internal/tenant"
+	"go.uber.org/zap"
+)
+
+// testClock is a clock tests move by hand
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	return c.now
+}
+
+// newTestRateLimiter returns a RateLimiter on a clock tests move by hand
+func newTestRateLimiter(limits RateLimits) (*RateLimiter, *testClock) {
+	clock := &testClock{now: time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)}
+	limiter := NewRateLimiter(limits)
+	limiter.now = clock.Now
+	return limiter, clock
+}
+
+// allowed counts the requests caller may send in tenantID right away, up to max
+func allowed(limiter *RateLimiter, tenantID, caller string, max int) int {
+	n := 0
+	for ; n < max; n++ {
+		if ok, _ := limiter.Allow(tenantID, caller); !ok {
+			break
+		}
+	}
+	return n
+}
+
+func TestRateLimiter(t *testing.T) {
+	limiter, clock := newTestRateLimiter(RateLimits{
+		Default: RateLimit{RequestsPerSecond: 2, Burst: 3},
+		Tenants: map[string]RateLimit{
+			"acme":   {RequestsPerSecond: 10, Burst: 5},
+			"globex": {},
+		},
+	})
+
+	if got := allowed(limiter, "", "key:1", 10); got != 3 {
+		t.Errorf("requests allowed at once = %d, want the burst of 3", got)
+	}
+	ok, wait := limiter.Allow("", "key:1")
+	if ok || wait != 500*time.Millisecond {
+		t.Errorf("Allow() beyond the burst = %v, %v, want refused for 500ms", ok, wait)
+	}
+
+	clock.now = clock.now.Add(time.Second)
+	if got := allowed(limiter, "", "key:1", 10); got != 2 {
+		t.Errorf("requests allowed a second later = %d, want 2", got)
+	}
+
+	// Callers, and each caller in every tenant, have buckets of their own
+	if got := allowed(limiter, "", "key:2", 10); got != 3 {
+		t.Errorf("requests allowed to another caller = %d, want 3", got)
+	}
+	if got := allowed(limiter, "initech", "key:1", 10); got != 3 {
+		t.Errorf("requests allowed to the caller in another tenant = %d, want 3", got)
+	}
+
+	if got := allowed(limiter, "acme", "key:1", 10); got != 5 {
+		t.Errorf("requests allowed in a tenant with its own limit = %d, want its burst of 5", got)
+	}
+	if got := allowed(limiter, "globex", "key:1", 100); got != 100 {
+		t.Errorf("requests allowed in an unlimited tenant = %d, want all 100", got)
+	}
+}
+
+func TestRateLimiter_Set(t *testing.T) {
+	limiter, clock := newTestRateLimiter(RateLimits{Default: RateLimit{RequestsPerSecond: 1, Burst: 2}})
+
+	if got := allowed(limiter, "acme", "key:1", 10); got != 2 {
+		t.Fatalf("requests allowed at once = %d, want 2", got)
+	}
+
+	// As a configuration reload does, without a new limiter
+	limiter.Set(RateLimits{
+		Default: RateLimit{RequestsPerSecond: 1, Burst: 2},
+		Tenants: map[string]RateLimit{"acme": {RequestsPerSecond: 5, Burst: 10}},
+	})
+	clock.now = clock.now.Add(time.Second)
+	if got := allowed(limiter, "acme", "key:1", 20); got != 5 {
+		t.Errorf("requests allowed a second after raising the limit = %d, want 5", got)
+	}
+	clock.now = clock.now.Add(time.Minute)
+	if got := allowed(limiter, "acme", "key:1", 20); got != 10 {
+		t.Errorf("requests allowed at once after raising the limit = %d, want the new burst of 10", got)
+	}
+
+	limiter.Set(RateLimits{Default: RateLimit{RequestsPerSecond: 1, Burst: 2}})
+	clock.now = clock.now.Add(time.Minute)
+	if got := allowed(limiter, "acme", "key:1", 20); got != 2 {
+		t.Errorf("requests allowed at once after the tenant's limit is dropped = %d, want the default burst of 2", got)
+	}
+
+	limiter.Set(RateLimits{})
+	if got := allowed(limiter, "acme", "key:1", 100); got != 100 {
+		t.Errorf("requests allowed once limits are removed = %d, want all 100", got)
+	}
+}
+
+func TestRateLimiter_SweepsRefilledBuckets(t *testing.T) {
+	limiter, clock := newTestRateLimiter(RateLimits{Default: RateLimit{RequestsPerSecond: 1, Burst: 2}})
+
+	allowed(limiter, "", "key:1", 1)
+	allowed(limiter, "", "key:2", 1)
+	clock.now = clock.now.Add(rateLimitSweepInterval)
+	limiter.Allow("", "key:3")
+
+	if len(limiter.buckets) != 1 {
+		t.Errorf("buckets after a sweep = %d, want only key:3's", len(limiter.buckets))
+	}
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	limiter, _ := newTestRateLimiter(RateLimits{Default: RateLimit{RequestsPerSecond: 0.5, Burst: 1}})
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		ctx := tenant.WithID(c.Request.Context(), c.GetHeader("X-Tenant-ID"))
+		if key := c.GetHeader("Authorization"); key != "" {
+			ctx = auth.WithPrincipal(ctx, &auth.Principal{ID: key})
+		}
+		c.Request = c.Request.WithContext(ctx)
+	})
+	router.Use(RateLimitMiddleware(limiter, zap.NewNop()))
+	router.GET("/stores", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	send := func(key, tenantID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/stores", nil)
+		req.Header.Set("Authorization", key)
+		req.Header.Set("X-Tenant-ID", tenantID)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := send("key:1", "acme"); rec.Code != http.StatusOK {
+		t.Fatalf("first request = %d, want 200", rec.Code)
+	}
+	rec := send("key:1", "acme")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("second request = %d, want 429", rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "2" {
+		t.Errorf("Retry-After = %q, want 2", got)
+	}
+	if !strings.Contains(rec.Body.String(), `"code":"RATE_LIMITED"`) {
+		t.Errorf("body = %s, want a RATE_LIMITED error", rec.Body)
+	}
+
+	tests := []struct {
+		name     string
+		key      string
+		tenantID string
+	}{
+		{name: "same key in another tenant", key: "key:1", tenantID: "globex"},
+		{name: "another key in the tenant", key: "key:2", tenantID: "acme"},
+		{name: "anonymous caller", tenantID: "acme"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := send(tt.key, tt.tenantID); rec.Code != http.StatusOK {
+				t.Errorf("request = %d, want 200 from a bucket of its own", rec.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,236 @@
+// Package openapi describes the API as an OpenAPI 3 document, built from the router's
+// routes and the Go types of their request bodies and responses
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Route is a registered method and gin path, such as GET /api/v1/stores/:id
+type Route struct {
+	Method string
+	Path   string
+}
+
+// Operation documents a route beyond what its method and path tell
+type Operation struct {
+	Summary     string
+	Description string
+	Tag         string
+	// Scope is the API key scope the route needs; routes with one are documented as
+	// requiring a bearer token
+	Scope   string
+	Params  []Param
+	Request any // A value of the body's type, bound from JSON
+	// Response is a value of the type of the success payload's data; Status defaults to 200
+	Response any
+	Status   int
+}
+
+// Param is a query or header parameter
+type Param struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// Query returns an optional query parameter of the given OpenAPI type
+func Query(name, typ, description string) Param {
+	return Param{Name: name, In: "query", Description: description, Schema: &Schema{Type: typ}}
+}
+
+// Header returns an optional string header parameter
+func Header(name, description string) Param {
+	return Param{Name: name, In: "header", Description: description, Schema: &Schema{Type: "string"}}
+}
+
+// Info is the document's title, version and description
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// Document is an OpenAPI 3.0 document
+type Document struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       Info                             `json:"info"`
+	Paths      map[string]map[string]*operation `json:"paths"`
+	Components components                       `json:"components"`
+}
+
+type components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]securityScheme `json:"securitySchemes"`
+}
+
+type securityScheme struct {
+	Type        string `json:"type"`
+	Scheme      string `json:"scheme"`
+	Description string `json:"description,omitempty"`
+}
+
+type operation struct {
+	OperationID string                `json:"operationId"`
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []Param               `json:"parameters,omitempty"`
+	RequestBody *requestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]response   `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+type requestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]mediaType `json:"content"`
+}
+
+type response struct {
+	Description string               `json:"description"`
+	Content     map[string]mediaType `json:"content,omitempty"`
+}
+
+type mediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// bearerAuth names the security scheme of routes needing an API key or JWT
+const bearerAuth = "bearerAuth"
+
+// Build returns the document for routes, described further by operations, which are keyed
+// by "METHOD path" as in Route. Routes without an entry are still listed, with their path
+// parameters and the response envelope
+func Build(info Info, routes []Route, operations map[string]Operation) *Document {
+	s := newSchemas()
+	s.components["Error"] = s.object(reflect.TypeOf(errorResponse{}))
+	errorRef := &Schema{Ref: "#/components/schemas/Error"}
+
+	doc := &Document{
+		OpenAPI: "3.0.3",
+		Info:    info,
+		Paths:   map[string]map[string]*operation{},
+		Components: components{
+			Schemas: s.components,
+			SecuritySchemes: map[string]securityScheme{
+				bearerAuth: {Type: "http", Scheme: "bearer", Description: "An API key (gol_...) or a Supabase access token"},
+			},
+		},
+	}
+
+	sorted := append([]Route(nil), routes...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Path != sorted[j].Path {
+			return sorted[i].Path < sorted[j].Path
+		}
+		return sorted[i].Method < sorted[j].Method
+	})
+
+	for _, route := range sorted {
+		spec := operations[route.Method+" "+route.Path]
+		path, pathParams := openAPIPath(route.Path)
+
+		op := &operation{
+			OperationID: operationID(route),
+			Summary:     spec.Summary,
+			Description: spec.Description,
+			Parameters:  append(pathParams, spec.Params...),
+			Responses:   map[string]response{},
+		}
+		if spec.Tag != "" {
+			op.Tags = []string{spec.Tag}
+		}
+		if spec.Scope != "" {
+			op.Security = []map[string][]string{{bearerAuth: {}}}
+			op.Description = strings.TrimSpace(op.Description + "\n\nRequires the `" + spec.Scope + "` scope.")
+		}
+		if spec.Request != nil {
+			op.RequestBody = &requestBody{
+				Required: true,
+				Content:  map[string]mediaType{"application/json": {Schema: s.of(reflect.TypeOf(spec.Request))}},
+			}
+		}
+
+		data := &Schema{}
+		if spec.Response != nil {
+			data = s.of(reflect.TypeOf(spec.Response))
+		}
+		status := spec.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		op.Responses[strconv.Itoa(status)] = response{
+			Description: http.StatusText(status),
+			Content:     map[string]mediaType{"application/json": {Schema: successEnvelope(data)}},
+		}
+		op.Responses["default"] = response{
+			Description: "Error",
+			Content:     map[string]mediaType{"application/json": {Schema: errorRef}},
+		}
+
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = map[string]*operation{}
+		}
+		doc.Paths[path][strings.ToLower(route.Method)] = op
+	}
+	return doc
+}
+
+// errorResponse is the payload of every error
+type errorResponse struct {
+	Status string `json:"status" binding:"required,oneof=error"`
+	Error  struct {
+		Code      string `json:"code" binding:"required"`
+		Message   string `json:"message" binding:"required"`
+		RequestID string `json:"request_id"`
+	} `json:"error" binding:"required"`
+}
+
+// successEnvelope returns the schema of a success payload carrying data
+func successEnvelope(data *Schema) *Schema {
+	return &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"status":   {Type: "string"},
+			"data":     data,
+			"message":  {Type: "string"},
+			"metadata": {Type: "object", Description: "Cache and pagination details of catalog reads"},
+		},
+		Required: []string{"status"},
+	}
+}
+
+// openAPIPath converts a gin path to OpenAPI's, returning its path parameters
+// /stores/:id becomes /stores/{id}
+func openAPIPath(path string) (string, []Param) {
+	segments := strings.Split(path, "/")
+	var params []Param
+	for i, segment := range segments {
+		if len(segment) < 2 || (segment[0] != ':' && segment[0] != '*') {
+			continue
+		}
+		name := segment[1:]
+		segments[i] = "{" + name + "}"
+		params = append(params, Param{Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"}})
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// operationID derives an operation ID from a route, e.g. get_api_v1_stores_id
+func operationID(route Route) string {
+	id := strings.ToLower(route.Method)
+	for _, segment := range strings.Split(route.Path, "/") {
+		segment = strings.TrimLeft(segment, ":*")
+		segment = strings.NewReplacer("-", "_", ".", "_").Replace(segment)
+		if segment != "" {
+			id += "_" + segment
+		}
+	}
+	return id
+}
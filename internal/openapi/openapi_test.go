@@ -0,0 +1,139 @@
+package openapi
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+type testAddress struct {
+	City string `json:"city" binding:"required"`
+}
+
+type testBase struct {
+	ID        string    `json:"id" binding:"required,uuid"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type testRequest struct {
+	testBase
+	Name     string        `json:"name" binding:"required,min=1,max=200"`
+	Role     string        `json:"role" binding:"omitempty,oneof=erp store_admin"`
+	Price    *float64      `json:"price" binding:"omitempty,gt=0"`
+	Tags     []string      `json:"tags" binding:"max=10,dive,min=2"`
+	Address  testAddress   `json:"address" binding:"required"`
+	Previous []testAddress `json:"previous"`
+	Internal string        `json:"-"`
+	hidden   string
+}
+
+func TestSchemaOf(t *testing.T) {
+	s := newSchemas()
+	ref := s.of(reflect.TypeOf(testRequest{}))
+	if ref.Ref != "#/components/schemas/testRequest" {
+		t.Fatalf("ref = %q, want #/components/schemas/testRequest", ref.Ref)
+	}
+	schema := s.components["testRequest"]
+
+	required := append([]string(nil), schema.Required...)
+	sort.Strings(required)
+	if want := []string{"address", "id", "name"}; !reflect.DeepEqual(required, want) {
+		t.Errorf("required = %v, want %v", required, want)
+	}
+
+	var names []string
+	for name := range schema.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if want := []string{"address", "created_at", "id", "name", "previous", "price", "role", "tags"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("properties = %v, want %v", names, want)
+	}
+
+	props := schema.Properties
+	if props["id"].Format != "uuid" {
+		t.Errorf("id format = %q, want uuid", props["id"].Format)
+	}
+	if props["created_at"].Format != "date-time" {
+		t.Errorf("created_at format = %q, want date-time", props["created_at"].Format)
+	}
+	if name := props["name"]; *name.MinLength != 1 || *name.MaxLength != 200 {
+		t.Errorf("name length = %d..%d, want 1..200", *name.MinLength, *name.MaxLength)
+	}
+	if want := []string{"erp", "store_admin"}; !reflect.DeepEqual(props["role"].Enum, want) {
+		t.Errorf("role enum = %v, want %v", props["role"].Enum, want)
+	}
+	if price := props["price"]; price.Type != "number" || !price.Nullable || *price.Minimum != 0 || !price.ExclusiveMinimum {
+		t.Errorf("price = %+v, want a nullable number above 0", price)
+	}
+	if tags := props["tags"]; *tags.MaxItems != 10 || *tags.Items.MinLength != 2 {
+		t.Errorf("tags = %+v, want at most 10 items of at least 2 characters", tags)
+	}
+	if props["address"].Ref != "#/components/schemas/testAddress" {
+		t.Errorf("address ref = %q, want #/components/schemas/testAddress", props["address"].Ref)
+	}
+	if props["previous"].Items.Ref != "#/components/schemas/testAddress" {
+		t.Errorf("previous items ref = %q, want #/components/schemas/testAddress", props["previous"].Items.Ref)
+	}
+	if len(s.components) != 2 {
+		t.Errorf("components = %d, want testRequest and testAddress", len(s.components))
+	}
+}
+
+func TestOpenAPIPath(t *testing.T) {
+	path, params := openAPIPath("/api/v1/stores/:id/taxes/:taxId")
+	if path != "/api/v1/stores/{id}/taxes/{taxId}" {
+		t.Errorf("path = %q", path)
+	}
+	if len(params) != 2 || params[0].Name != "id" || params[1].Name != "taxId" || !params[1].Required {
+		t.Errorf("params = %+v, want required id and taxId", params)
+	}
+}
+
+func TestBuild(t *testing.T) {
+	routes := []Route{
+		{Method: "GET", Path: "/api/v1/stores/:id"},
+		{Method: "POST", Path: "/api/v1/stores/:id/delivery-zones"},
+		{Method: "GET", Path: "/health"},
+	}
+	doc := Build(Info{Title: "Test", Version: "1"}, routes, map[string]Operation{
+		"POST /api/v1/stores/:id/delivery-zones": {
+			Summary:  "Add a delivery zone",
+			Scope:    "write:stores",
+			Params:   []Param{Header("Idempotency-Key", "")},
+			Request:  testAddress{},
+			Response: testAddress{},
+			Status:   201,
+		},
+	})
+
+	if len(doc.Paths) != 3 {
+		t.Fatalf("paths = %d, want 3", len(doc.Paths))
+	}
+	get := doc.Paths["/api/v1/stores/{id}"]["get"]
+	if get == nil || get.OperationID != "get_api_v1_stores_id" || len(get.Parameters) != 1 {
+		t.Errorf("undocumented route = %+v, want its path parameter", get)
+	}
+	if _, ok := get.Responses["200"]; !ok {
+		t.Errorf("undocumented route responses = %v, want 200", get.Responses)
+	}
+
+	post := doc.Paths["/api/v1/stores/{id}/delivery-zones"]["post"]
+	if post.Summary != "Add a delivery zone" || len(post.Security) != 1 || len(post.Parameters) != 2 {
+		t.Errorf("documented route = %+v", post)
+	}
+	if post.RequestBody == nil || post.RequestBody.Content["application/json"].Schema.Ref != "#/components/schemas/testAddress" {
+		t.Errorf("request body = %+v, want testAddress", post.RequestBody)
+	}
+	created, ok := post.Responses["201"]
+	if !ok || created.Content["application/json"].Schema.Properties["data"].Ref != "#/components/schemas/testAddress" {
+		t.Errorf("responses = %+v, want 201 wrapping testAddress", post.Responses)
+	}
+	if post.Responses["default"].Content["application/json"].Schema.Ref != "#/components/schemas/Error" {
+		t.Errorf("default response = %+v, want Error", post.Responses["default"])
+	}
+	if _, ok := doc.Components.Schemas["Error"]; !ok {
+		t.Error("Error schema missing from components")
+	}
+}
@@ -0,0 +1,234 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schema is an OpenAPI 3.0 schema object, as far as Go types and binding tags describe one
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	ExclusiveMinimum     bool               `json:"exclusiveMinimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	ExclusiveMaximum     bool               `json:"exclusiveMaximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// schemas builds schemas for Go types, keeping each named struct once under
+// components/schemas and referring to it from everywhere else
+type schemas struct {
+	components map[string]*Schema
+	names      map[reflect.Type]string
+}
+
+func newSchemas() *schemas {
+	return &schemas{components: map[string]*Schema{}, names: map[reflect.Type]string{}}
+}
+
+// of returns the schema of t, as encoding/json encodes it
+func (s *schemas) of(t reflect.Type) *Schema {
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case rawMessageType:
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		schema := s.of(t.Elem())
+		if schema.Ref != "" {
+			return schema
+		}
+		schema.Nullable = true
+		return schema
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: s.of(t.Elem())}
+	case reflect.Array:
+		return &Schema{Type: "array", Items: s.of(t.Elem()), MinItems: intPtr(t.Len()), MaxItems: intPtr(t.Len())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: s.of(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.object(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + s.component(t)}
+	default:
+		// Interfaces can hold anything
+		return &Schema{}
+	}
+}
+
+// component registers the named struct t under components/schemas, returning its name
+// Types of the same name from different packages are told apart by their package name
+func (s *schemas) component(t reflect.Type) string {
+	if name, ok := s.names[t]; ok {
+		return name
+	}
+
+	name := t.Name()
+	if _, taken := s.components[name]; taken {
+		pkg := t.PkgPath()
+		name = pkg[strings.LastIndex(pkg, "/")+1:] + "." + name
+	}
+	s.names[t] = name
+	// Registered before its fields, so a type referring to itself refers to the entry
+	s.components[name] = &Schema{}
+	*s.components[name] = *s.object(t)
+	return name
+}
+
+// object returns the schema of struct t: one property per field encoding/json encodes,
+// with the constraints of its binding tag. Embedded structs' fields are promoted
+func (s *schemas) object(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				promoted := s.object(embedded)
+				for property, propertySchema := range promoted.Properties {
+					schema.Properties[property] = propertySchema
+				}
+				schema.Required = append(schema.Required, promoted.Required...)
+				continue
+			}
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		property := s.of(field.Type)
+		if applyBinding(property, field.Tag.Get("binding")) {
+			schema.Required = append(schema.Required, name)
+		}
+		schema.Properties[name] = property
+	}
+	return schema
+}
+
+// applyBinding adds the constraints of a binding tag to schema, reporting whether the
+// field is required. Rules after dive constrain a list's items
+func applyBinding(schema *Schema, tag string) bool {
+	if tag == "" {
+		return false
+	}
+	rules, itemRules, dives := strings.Cut(tag, ",dive")
+	if dives && schema.Items != nil && schema.Items.Ref == "" {
+		applyBinding(schema.Items, strings.TrimPrefix(itemRules, ","))
+	}
+
+	// A reference can't carry constraints of its own
+	if schema.Ref != "" {
+		return strings.Contains(","+rules+",", ",required,")
+	}
+
+	required := false
+	for _, rule := range strings.Split(rules, ",") {
+		name, value, _ := strings.Cut(rule, "=")
+		switch name {
+		case "required":
+			required = true
+		case "oneof":
+			schema.Enum = strings.Fields(value)
+		case "uuid":
+			schema.Format = "uuid"
+		case "url":
+			schema.Format = "uri"
+		case "email":
+			schema.Format = "email"
+		case "min", "max", "len":
+			applyLength(schema, name, value)
+		case "gt", "gte":
+			if bound, err := strconv.ParseFloat(value, 64); err == nil {
+				schema.Minimum, schema.ExclusiveMinimum = &bound, name == "gt"
+			}
+		case "lt", "lte":
+			if bound, err := strconv.ParseFloat(value, 64); err == nil {
+				schema.Maximum, schema.ExclusiveMaximum = &bound, name == "lt"
+			}
+		}
+	}
+	return required
+}
+
+// applyLength applies a min, max or len rule, which bounds a number's value, a string's
+// length or a list's size
+func applyLength(schema *Schema, rule, value string) {
+	bound, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return
+	}
+	n := int(bound)
+
+	switch schema.Type {
+	case "number", "integer":
+		if rule != "max" {
+			schema.Minimum = &bound
+		}
+		if rule != "min" {
+			schema.Maximum = &bound
+		}
+	case "string":
+		if rule != "max" {
+			schema.MinLength = intPtr(n)
+		}
+		if rule != "min" {
+			schema.MaxLength = intPtr(n)
+		}
+	case "array":
+		if rule != "max" {
+			schema.MinItems = intPtr(n)
+		}
+		if rule != "min" {
+			schema.MaxItems = intPtr(n)
+		}
+	}
+}
+
+func intPtr(n int) *int {
+	return &n
+}
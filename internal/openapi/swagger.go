@@ -0,0 +1,37 @@
+package openapi
+
+import (
+	"html/template"
+	"strings"
+)
+
+// swaggerUIVersion is the swagger-ui-dist release the docs page loads from unpkg
+const swaggerUIVersion = "5.17.14"
+
+var swaggerUI = template.Must(template.New("docs").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <meta name="viewport" content="width=device-width, initial-scale=1">
+  <title>{{.Title}}</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@{{.Version}}/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@{{.Version}}/swagger-ui-bundle.js" crossorigin></script>
+  <script>
+    window.ui = SwaggerUIBundle({ url: {{.SpecURL}}, dom_id: "#swagger-ui" });
+  </script>
+</body>
+</html>
+`))
+
+// SwaggerUI returns an HTML page rendering the document served at specURL with Swagger UI
+func SwaggerUI(title, specURL string) []byte {
+	var page strings.Builder
+	// Executing a parsed template into a builder can't fail
+	_ = swaggerUI.Execute(&page, struct {
+		Title, Version, SpecURL string
+	}{title, swaggerUIVersion, specURL})
+	return []byte(page.String())
+}
@@ -0,0 +1,195 @@
+package realtime
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yourusername/supabase-redis-middleware/internal/cache"
+	"go.uber.org/zap"
+)
+
+const (
+	// clientBuffer is how many events a client may fall behind by before it is dropped
+	clientBuffer = 64
+
+	// hubRetryDelay is how long the hub waits after a Redis error before receiving again
+	hubRetryDelay = time.Second
+)
+
+// ErrTooManyClients is returned by Subscribe when the hub has its maximum of clients
+var ErrTooManyClients = errors.New("too many clients")
+
+// Hub relays the store events published by every instance to this instance's clients
+// Clients subscribe to a topic: a store's ID for its listing events, or its StatusTopic
+type Hub struct {
+	broadcaster cache.Broadcaster
+	maxClients  int
+	logger      *zap.Logger
+
+	mu      sync.Mutex
+	clients map[string]map[*Client]struct{} // By topic
+	count   int
+	stopped bool
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Client is one connection's subscription to a topic
+type Client struct {
+	topic  string
+	events chan []byte
+	closed chan struct{}
+	once   sync.Once
+}
+
+// NewHub creates a hub of at most maxClients clients; zero is unlimited
+func NewHub(broadcaster cache.Broadcaster, maxClients int, logger *zap.Logger) *Hub {
+	return &Hub{
+		broadcaster: broadcaster,
+		maxClients:  maxClients,
+		logger:      logger,
+		clients:     make(map[string]map[*Client]struct{}),
+		done:        make(chan struct{}),
+	}
+}
+
+// Start receives events in the background until Stop is called
+func (h *Hub) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	h.cancel = cancel
+
+	go func() {
+		defer close(h.done)
+
+		sub := h.broadcaster.PSubscribe(ctx, StoreChannel("*"))
+		defer sub.Close()
+
+		for {
+			msg, err := sub.Receive(ctx)
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				// The subscription reconnects on the next receive; events sent meanwhile are lost
+				h.logger.Warn("Failed to receive store events", zap.Error(err), zap.Duration("retry_in", hubRetryDelay))
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(hubRetryDelay):
+				}
+				continue
+			}
+			h.deliver(strings.TrimPrefix(msg.Channel, storeChannelPrefix), msg.Payload)
+		}
+	}()
+}
+
+// Stop stops receiving events and closes every client
+func (h *Hub) Stop() {
+	h.cancel()
+	<-h.done
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.stopped = true
+	for _, clients := range h.clients {
+		for c := range clients {
+			c.close()
+		}
+	}
+	h.clients = make(map[string]map[*Client]struct{})
+	h.count = 0
+}
+
+// Subscribe returns a client receiving the events of topic; a stopped hub returns one
+// already closed. Callers must Unsubscribe it when done
+func (h *Hub) Subscribe(topic string) (*Client, error) {
+	c := &Client{topic: topic, events: make(chan []byte, clientBuffer), closed: make(chan struct{})}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.stopped {
+		c.close()
+		return c, nil
+	}
+	if h.maxClients > 0 && h.count >= h.maxClients {
+		return nil, ErrTooManyClients
+	}
+	if h.clients[topic] == nil {
+		h.clients[topic] = make(map[*Client]struct{})
+	}
+	h.clients[topic][c] = struct{}{}
+	h.count++
+	return c, nil
+}
+
+// Unsubscribe stops delivering events to c
+func (h *Hub) Unsubscribe(c *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.remove(c)
+}
+
+// deliver queues an event for the topic's clients; clients too far behind to take it are
+// closed, so a slow connection can't hold up the rest
+func (h *Hub) deliver(topic string, payload []byte) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for c := range h.clients[topic] {
+		select {
+		case c.events <- payload:
+		default:
+			h.logger.Warn("Dropping store event client that fell behind", zap.String("topic", topic))
+			h.remove(c)
+		}
+	}
+}
+
+// remove closes c and forgets it; h.mu must be held
+func (h *Hub) remove(c *Client) {
+	c.close()
+	if _, ok := h.clients[c.topic][c]; !ok {
+		return
+	}
+	h.count--
+	delete(h.clients[c.topic], c)
+	if len(h.clients[c.topic]) == 0 {
+		delete(h.clients, c.topic)
+	}
+}
+
+// Events returns the client's events, each a JSON-encoded Event, or StatusEvent for a
+// status topic
+func (c *Client) Events() <-chan []byte {
+	return c.events
+}
+
+// Closed is closed once the client gets no more events: it fell behind, or the hub stopped
+func (c *Client) Closed() <-chan struct{} {
+	return c.closed
+}
+
+func (c *Client) close() {
+	c.once.Do(func() { close(c.closed) })
+}
+
+// IsUpgrade reports whether r asks to be upgraded to a WebSocket
+func IsUpgrade(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get("Upgrade"), "websocket")
+}
+
+// IsEventStream reports whether r accepts Server-Sent Events
+func IsEventStream(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+}
+
+// IsLongLived reports whether r asks for a connection held open for events, which
+// outlives request timeouts and mustn't be buffered
+func IsLongLived(r *http.Request) bool {
+	return IsUpgrade(r) || IsEventStream(r)
+}
@@ -0,0 +1,77 @@
+package realtime
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestHub_Deliver(t *testing.T) {
+	h := NewHub(nil, 0, zap.NewNop())
+	a, _ := h.Subscribe("store-a")
+	b, _ := h.Subscribe("store-b")
+
+	h.deliver("store-a", []byte(`{"type":"stock_updated"}`))
+
+	select {
+	case event := <-a.Events():
+		if string(event) != `{"type":"stock_updated"}` {
+			t.Errorf("Unexpected event %s", event)
+		}
+	default:
+		t.Fatal("Expected an event for the store's client")
+	}
+	select {
+	case event := <-b.Events():
+		t.Errorf("Expected no event for another store's client, got %s", event)
+	default:
+	}
+}
+
+func TestHub_DropsSlowClients(t *testing.T) {
+	h := NewHub(nil, 0, zap.NewNop())
+	c, _ := h.Subscribe("store-a")
+
+	for i := 0; i <= clientBuffer; i++ {
+		h.deliver("store-a", []byte("{}"))
+	}
+
+	select {
+	case <-c.Closed():
+	default:
+		t.Fatal("Expected a client that fell behind to be closed")
+	}
+	if h.count != 0 {
+		t.Errorf("Expected the client forgotten, hub has %d", h.count)
+	}
+}
+
+func TestHub_MaxClients(t *testing.T) {
+	h := NewHub(nil, 1, zap.NewNop())
+	c, err := h.Subscribe("store-a")
+	if err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+	if _, err := h.Subscribe("store-b"); !errors.Is(err, ErrTooManyClients) {
+		t.Fatalf("Expected ErrTooManyClients, got %v", err)
+	}
+
+	h.Unsubscribe(c)
+	h.Unsubscribe(c)
+	if _, err := h.Subscribe("store-b"); err != nil {
+		t.Errorf("Expected a slot freed by Unsubscribe, got %v", err)
+	}
+}
+
+func TestIsUpgrade(t *testing.T) {
+	r, _ := http.NewRequest(http.MethodGet, "/ws/stores/1", nil)
+	if IsUpgrade(r) {
+		t.Error("Expected a plain request not to be an upgrade")
+	}
+	r.Header.Set("Upgrade", "WebSocket")
+	if !IsUpgrade(r) {
+		t.Error("Expected an upgrade")
+	}
+}
@@ -0,0 +1,103 @@
+// Package realtime delivers changes to stores' listings and status to WebSocket and
+// Server-Sent Events clients as they happen. Writes publish events over Redis pub/sub, so
+// every instance's clients see the writes served by any instance
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/yourusername/supabase-redis-middleware/internal/cache"
+	"github.com/yourusername/supabase-redis-middleware/internal/logger"
+	"github.com/yourusername/supabase-redis-middleware/internal/repository"
+	"go.uber.org/zap"
+)
+
+// Event types
+const (
+	EventStockUpdated   = "stock_updated"   // A stock update changed stock, prices or availability
+	EventProductsPushed = "products_pushed" // A product push saved listings
+)
+
+// maxListingsPerEvent bounds the listings in one event; larger writes are split into
+// several, so clients never have to take in a whole catalog at once
+const maxListingsPerEvent = 200
+
+// storeChannelPrefix prefixes the pub/sub channel of each store's events
+const storeChannelPrefix = "store:"
+
+// Event is a change to some of a store's listings, carrying their state after it
+type Event struct {
+	Type     string                    `json:"type"`
+	StoreID  string                    `json:"store_id"`
+	Listings []repository.ListingState `json:"listings"`
+	At       time.Time                 `json:"at"`
+}
+
+// StateReader reads the listing and store states events carry
+type StateReader interface {
+	ListListingStates(ctx context.Context, storeID string, externalIDs []string) ([]repository.ListingState, error)
+	GetStoreStatus(ctx context.Context, storeID string) (*repository.StoreStatus, error)
+	GetStoreByID(ctx context.Context, storeID string) (*repository.StoreDetail, error)
+}
+
+// Publisher publishes the events of writes, keeping a history of store status events for
+// clients to resume from. A nil Publisher publishes nothing
+type Publisher struct {
+	broadcaster cache.Broadcaster
+	history     cache.EventLog
+	repo        StateReader
+	logger      *zap.Logger
+}
+
+func NewPublisher(broadcaster cache.Broadcaster, history cache.EventLog, repo StateReader, logger *zap.Logger) *Publisher {
+	return &Publisher{
+		broadcaster: broadcaster,
+		history:     history,
+		repo:        repo,
+		logger:      logger,
+	}
+}
+
+// ListingsChanged publishes an event of type eventType with the current state of the
+// listings of store storeID (its UUID) with the given ERP external IDs
+// Failures are logged rather than returned: the write being reported has already
+// succeeded, and clients missing an event see the change on their next read
+func (p *Publisher) ListingsChanged(ctx context.Context, eventType, storeID string, externalIDs []string) {
+	if p == nil || len(externalIDs) == 0 {
+		return
+	}
+	log := logger.FromContext(ctx, p.logger)
+
+	states, err := p.repo.ListListingStates(ctx, storeID, externalIDs)
+	if err != nil {
+		log.Warn("Failed to read listings for store event",
+			zap.String("store_id", storeID),
+			zap.String("type", eventType),
+			zap.Error(err))
+		return
+	}
+
+	at := time.Now().UTC()
+	for start := 0; start < len(states); start += maxListingsPerEvent {
+		end := min(start+maxListingsPerEvent, len(states))
+		payload, err := json.Marshal(Event{Type: eventType, StoreID: storeID, Listings: states[start:end], At: at})
+		if err != nil {
+			log.Error("Failed to encode store event", zap.String("store_id", storeID), zap.Error(err))
+			return
+		}
+		if err := p.broadcaster.Broadcast(ctx, StoreChannel(storeID), payload); err != nil {
+			log.Warn("Failed to publish store event",
+				zap.String("store_id", storeID),
+				zap.String("type", eventType),
+				zap.Error(err))
+			return
+		}
+	}
+}
+
+// StoreChannel returns the pub/sub channel of a store's events
+func StoreChannel(storeID string) string {
+	return storeChannelPrefix + storeID
+}
@@ -0,0 +1,209 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/yourusername/supabase-redis-middleware/internal/cache"
+	"github.com/yourusername/supabase-redis-middleware/internal/repository"
+	"go.uber.org/zap"
+)
+
+// recordingBroadcaster records broadcasts; subscribing is left unimplemented
+type recordingBroadcaster struct {
+	cache.Broadcaster
+	channels []string
+	messages [][]byte
+	err      error
+}
+
+func (b *recordingBroadcaster) Broadcast(ctx context.Context, channel string, message []byte) error {
+	if b.err != nil {
+		return b.err
+	}
+	b.channels = append(b.channels, channel)
+	b.messages = append(b.messages, message)
+	return nil
+}
+
+// stateStore returns a listing state for each external ID
+type stateStore struct {
+	err error
+}
+
+func (s stateStore) ListListingStates(ctx context.Context, storeID string, externalIDs []string) ([]repository.ListingState, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	states := make([]repository.ListingState, len(externalIDs))
+	for i, id := range externalIDs {
+		states[i] = repository.ListingState{StoreProductID: "sp-" + id, ExternalID: &id}
+	}
+	return states, nil
+}
+
+func (s stateStore) GetStoreStatus(ctx context.Context, storeID string) (*repository.StoreStatus, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &repository.StoreStatus{ID: storeID, IsActive: true, IsOpen: true, IsOpenNow: true}, nil
+}
+
+func (s stateStore) GetStoreByID(ctx context.Context, storeID string) (*repository.StoreDetail, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &repository.StoreDetail{Store: repository.Store{ID: storeID, DeliveryFee: 25}}, nil
+}
+
+// memoryLog is an event log kept in memory, numbering events 1-0, 2-0, ...
+type memoryLog struct {
+	messages []cache.StreamMessage
+}
+
+func (l *memoryLog) Append(ctx context.Context, stream string, maxLen int64, values map[string]interface{}) (string, error) {
+	// Redis returns values as strings
+	stored := make(map[string]interface{}, len(values))
+	for k, v := range values {
+		stored[k] = string(v.([]byte))
+	}
+	id := fmt.Sprintf("%d-0", len(l.messages)+1)
+	l.messages = append(l.messages, cache.StreamMessage{ID: id, Values: stored})
+	return id, nil
+}
+
+func (l *memoryLog) ReadAfter(ctx context.Context, stream, afterID string, count int64) ([]cache.StreamMessage, error) {
+	var after []cache.StreamMessage
+	for _, m := range l.messages {
+		if EventIDAfter(m.ID, afterID) {
+			after = append(after, m)
+		}
+	}
+	return after, nil
+}
+
+func TestPublisher_ListingsChanged(t *testing.T) {
+	b := &recordingBroadcaster{}
+	p := NewPublisher(b, &memoryLog{}, stateStore{}, zap.NewNop())
+
+	ids := make([]string, maxListingsPerEvent+1)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("ERP-%03d", i)
+	}
+	p.ListingsChanged(context.Background(), EventStockUpdated, "store-1", ids)
+
+	if len(b.messages) != 2 {
+		t.Fatalf("Expected the write split into 2 events, got %d", len(b.messages))
+	}
+	var listings int
+	for i, message := range b.messages {
+		if b.channels[i] != StoreChannel("store-1") {
+			t.Errorf("Expected channel %q, got %q", StoreChannel("store-1"), b.channels[i])
+		}
+		var event Event
+		if err := json.Unmarshal(message, &event); err != nil {
+			t.Fatalf("Failed to decode event: %v", err)
+		}
+		if event.Type != EventStockUpdated || event.StoreID != "store-1" {
+			t.Errorf("Unexpected event %s for store %s", event.Type, event.StoreID)
+		}
+		listings += len(event.Listings)
+	}
+	if listings != len(ids) {
+		t.Errorf("Expected %d listings across events, got %d", len(ids), listings)
+	}
+}
+
+func TestPublisher_ListingsChangedPublishesNothing(t *testing.T) {
+	tests := []struct {
+		name      string
+		publisher func(b *recordingBroadcaster) *Publisher
+		ids       []string
+	}{
+		{
+			name:      "nil publisher",
+			publisher: func(b *recordingBroadcaster) *Publisher { return nil },
+			ids:       []string{"ERP-1"},
+		},
+		{
+			name: "no listings",
+			publisher: func(b *recordingBroadcaster) *Publisher {
+				return NewPublisher(b, &memoryLog{}, stateStore{}, zap.NewNop())
+			},
+		},
+		{
+			name: "failed read",
+			publisher: func(b *recordingBroadcaster) *Publisher {
+				return NewPublisher(b, &memoryLog{}, stateStore{err: errors.New("connection refused")}, zap.NewNop())
+			},
+			ids: []string{"ERP-1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &recordingBroadcaster{}
+			tt.publisher(b).ListingsChanged(context.Background(), EventProductsPushed, "store-1", tt.ids)
+			if len(b.messages) != 0 {
+				t.Errorf("Expected no events, got %d", len(b.messages))
+			}
+		})
+	}
+}
+
+func TestPublisher_StatusEvents(t *testing.T) {
+	b := &recordingBroadcaster{}
+	p := NewPublisher(b, &memoryLog{}, stateStore{}, zap.NewNop())
+	ctx := context.Background()
+
+	p.StoreStatusChanged(ctx, "store-1")
+	p.DeliveryChanged(ctx, "store-1")
+	p.AvailabilityChanged(ctx, "store-1", AvailabilityState{StoreProductID: "sp-1", ExternalID: "ERP-1"})
+
+	if len(b.messages) != 3 {
+		t.Fatalf("Expected 3 events broadcast, got %d", len(b.messages))
+	}
+	var live StatusEvent
+	if err := json.Unmarshal(b.messages[1], &live); err != nil {
+		t.Fatalf("Failed to decode event: %v", err)
+	}
+	if b.channels[1] != StoreChannel(StatusTopic("store-1")) || live.ID != "2-0" || live.Type != EventDelivery {
+		t.Errorf("Unexpected event %+v on %s", live, b.channels[1])
+	}
+	var delivery DeliveryState
+	if err := json.Unmarshal(live.Data, &delivery); err != nil || delivery.DeliveryFee != 25 {
+		t.Errorf("Unexpected delivery data %s", live.Data)
+	}
+
+	missed, err := p.StatusEventsAfter(ctx, "store-1", "1-0")
+	if err != nil {
+		t.Fatalf("StatusEventsAfter failed: %v", err)
+	}
+	if len(missed) != 2 || missed[0].ID != "2-0" || missed[1].Type != EventAvailability {
+		t.Errorf("Expected the delivery and availability events, got %+v", missed)
+	}
+}
+
+func TestEventIDAfter(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"1700000000001-0", "1700000000000-5", true},
+		{"1700000000000-10", "1700000000000-9", true},
+		{"1700000000000-0", "1700000000000-0", false},
+		{"999-0", "1000-0", false},
+	}
+
+	for _, tt := range tests {
+		if got := EventIDAfter(tt.a, tt.b); got != tt.want {
+			t.Errorf("EventIDAfter(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+	if ValidEventID("1700000000000") || !ValidEventID("1700000000000-0") {
+		t.Error("Expected only stream entry IDs to be valid")
+	}
+}
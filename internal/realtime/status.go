@@ -0,0 +1,188 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yourusername/supabase-redis-middleware/internal/logger"
+	"go.uber.org/zap"
+)
+
+// Store status event types
+const (
+	EventStoreStatus  = "status"       // The store's active or open flags, hours or holidays changed
+	EventDelivery     = "delivery"     // The store's delivery fee, minimum order or delivery time changed
+	EventAvailability = "availability" // A listing was taken off the store's listings or put back
+)
+
+// statusHistoryLen is about how many of a store's latest status events are kept for
+// clients resuming after a disconnection
+const statusHistoryLen = 100
+
+// statusTopicSuffix follows a store's ID in the topic of its status events
+const statusTopicSuffix = ":status"
+
+// eventIDPattern matches the IDs of status events, as Redis stream entry IDs
+var eventIDPattern = regexp.MustCompile(`^\d+-\d+$`)
+
+// StatusEvent is a change to a store's status, delivery terms or listings' availability
+// Data is the store's status, a DeliveryState or an AvailabilityState, by Type
+type StatusEvent struct {
+	ID      string          `json:"id"`
+	Type    string          `json:"type"`
+	StoreID string          `json:"store_id"`
+	Data    json.RawMessage `json:"data"`
+	At      time.Time       `json:"at"`
+}
+
+// DeliveryState is a store's delivery terms, as delivery events report them
+type DeliveryState struct {
+	MinOrderAmount        float64 `json:"min_order_amount"`
+	DeliveryFee           float64 `json:"delivery_fee"`
+	EstimatedDeliveryTime *int    `json:"estimated_delivery_time"` // Minutes
+}
+
+// AvailabilityState is whether a listing is available, as availability events report it
+type AvailabilityState struct {
+	StoreProductID string `json:"store_product_id"`
+	ExternalID     string `json:"external_id"`
+	IsAvailable    bool   `json:"is_available"`
+}
+
+// StoreStatusChanged publishes the current status of store storeID (its UUID)
+func (p *Publisher) StoreStatusChanged(ctx context.Context, storeID string) {
+	if p == nil {
+		return
+	}
+	status, err := p.repo.GetStoreStatus(ctx, storeID)
+	if err != nil {
+		logger.FromContext(ctx, p.logger).Warn("Failed to read store status for event",
+			zap.String("store_id", storeID), zap.Error(err))
+		return
+	}
+	p.statusChanged(ctx, EventStoreStatus, storeID, status)
+}
+
+// DeliveryChanged publishes the current delivery terms of store storeID (its UUID)
+func (p *Publisher) DeliveryChanged(ctx context.Context, storeID string) {
+	if p == nil {
+		return
+	}
+	store, err := p.repo.GetStoreByID(ctx, storeID)
+	if err != nil {
+		logger.FromContext(ctx, p.logger).Warn("Failed to read store for delivery event",
+			zap.String("store_id", storeID), zap.Error(err))
+		return
+	}
+	p.statusChanged(ctx, EventDelivery, storeID, DeliveryState{
+		MinOrderAmount:        store.MinOrderAmount,
+		DeliveryFee:           store.DeliveryFee,
+		EstimatedDeliveryTime: store.EstimatedDeliveryTime,
+	})
+}
+
+// AvailabilityChanged publishes that a listing of store storeID (its UUID) was taken off
+// its listings or put back
+func (p *Publisher) AvailabilityChanged(ctx context.Context, storeID string, state AvailabilityState) {
+	if p == nil {
+		return
+	}
+	p.statusChanged(ctx, EventAvailability, storeID, state)
+}
+
+// statusChanged adds an event to the store's history, then broadcasts it with the ID the
+// history gave it. Failures are logged, as for listing events
+func (p *Publisher) statusChanged(ctx context.Context, eventType, storeID string, data any) {
+	log := logger.FromContext(ctx, p.logger)
+
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		log.Error("Failed to encode store status event", zap.String("store_id", storeID), zap.Error(err))
+		return
+	}
+	event := StatusEvent{Type: eventType, StoreID: storeID, Data: encoded, At: time.Now().UTC()}
+	stored, err := json.Marshal(event)
+	if err != nil {
+		log.Error("Failed to encode store status event", zap.String("store_id", storeID), zap.Error(err))
+		return
+	}
+
+	event.ID, err = p.history.Append(ctx, statusStream(storeID), statusHistoryLen, map[string]interface{}{"event": stored})
+	if err != nil {
+		log.Warn("Failed to record store status event",
+			zap.String("store_id", storeID),
+			zap.String("type", eventType),
+			zap.Error(err))
+		return
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		log.Error("Failed to encode store status event", zap.String("store_id", storeID), zap.Error(err))
+		return
+	}
+	if err := p.broadcaster.Broadcast(ctx, StoreChannel(StatusTopic(storeID)), payload); err != nil {
+		log.Warn("Failed to publish store status event",
+			zap.String("store_id", storeID),
+			zap.String("type", eventType),
+			zap.Error(err))
+	}
+}
+
+// StatusEventsAfter returns the store's status events following the one with ID afterID,
+// oldest first, as far back as its history goes
+func (p *Publisher) StatusEventsAfter(ctx context.Context, storeID, afterID string) ([]StatusEvent, error) {
+	messages, err := p.history.ReadAfter(ctx, statusStream(storeID), afterID, statusHistoryLen)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]StatusEvent, 0, len(messages))
+	for _, m := range messages {
+		raw, _ := m.Values["event"].(string)
+		var event StatusEvent
+		if err := json.Unmarshal([]byte(raw), &event); err != nil {
+			return nil, fmt.Errorf("failed to decode store status event %s: %w", m.ID, err)
+		}
+		event.ID = m.ID
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// StatusTopic returns the hub topic of a store's status events
+func StatusTopic(storeID string) string {
+	return storeID + statusTopicSuffix
+}
+
+// statusStream returns the stream holding a store's status event history
+func statusStream(storeID string) string {
+	return "store-status:" + storeID
+}
+
+// ValidEventID reports whether id can be the ID of a status event
+func ValidEventID(id string) bool {
+	return eventIDPattern.MatchString(id)
+}
+
+// EventIDAfter reports whether status event ID a comes after b; both must be valid
+func EventIDAfter(a, b string) bool {
+	aMillis, aSeq := splitEventID(a)
+	bMillis, bSeq := splitEventID(b)
+	if aMillis != bMillis {
+		return aMillis > bMillis
+	}
+	return aSeq > bSeq
+}
+
+func splitEventID(id string) (uint64, uint64) {
+	millis, seq, _ := strings.Cut(id, "-")
+	m, _ := strconv.ParseUint(millis, 10, 64)
+	s, _ := strconv.ParseUint(seq, 10, 64)
+	return m, s
+}
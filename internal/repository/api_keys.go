@@ -0,0 +1,222 @@
+package repository
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.uber.org/zap"
+)
+
+// API key scopes
+const (
+	ScopeReadCatalog  = "read:catalog"  // Pick the cache TTL of catalog reads
+	ScopePushProducts = "push:products" // Push and update products and their images
+	ScopeWriteStock   = "write:stock"   // Update stock and listing availability
+	ScopeWriteStores  = "write:stores"  // Update store details, status, hours, zones and taxes
+	ScopeAdmin        = "admin"         // Admin endpoints, including API key management
+)
+
+// APIKeyScopes lists every scope a key may be issued with
+var APIKeyScopes = []string{ScopeReadCatalog, ScopePushProducts, ScopeWriteStock, ScopeWriteStores, ScopeAdmin}
+
+// Caller roles, carried by API keys and by the app_metadata of Supabase JWTs
+// Each route group admits some roles; within it, each route requires a scope
+const (
+	RoleERP           = "erp"            // ERP integrations pushing products and stock
+	RoleStoreAdmin    = "store_admin"    // A store's staff, managing that one store
+	RoleConsumer      = "consumer"       // Shoppers; public reads only
+	RolePlatformAdmin = "platform_admin" // Operators; everything, including admin endpoints
+)
+
+// RoleScopes lists the scopes each role may hold. A JWT carries all of its role's scopes;
+// an API key is issued with some of them
+var RoleScopes = map[string][]string{
+	RoleERP:           {ScopeReadCatalog, ScopePushProducts, ScopeWriteStock, ScopeWriteStores},
+	RoleStoreAdmin:    {ScopeReadCatalog, ScopeWriteStock, ScopeWriteStores},
+	RoleConsumer:      {},
+	RolePlatformAdmin: APIKeyScopes,
+}
+
+// APIKeyPrefix starts every generated key, so leaked keys are easy to scan for
+const APIKeyPrefix = "gol_"
+
+// APIKey is a row from the api_keys table; the key itself is never stored, only its hash
+// A key with a StoreID may only write that store's data. StoreExternalID is the store's
+// ERP ID, which product pushes and stock updates name it by. A key with AllowedCIDRs may
+// only push products and stock from those addresses. A key with a TenantID acts for that
+// tenant alone; one without is a platform key, issued with no tenant, and acts for any
+type APIKey struct {
+	ID              string     `db:"id" json:"id"`
+	Name            string     `db:"name" json:"name"`
+	KeyHash         string     `db:"key_hash" json:"-"`
+	KeyPrefix       string     `db:"key_prefix" json:"key_prefix"`
+	Role            string     `db:"role" json:"role"`
+	Scopes          []string   `db:"scopes" json:"scopes"`
+	StoreID         *string    `db:"store_id" json:"store_id"`
+	StoreExternalID *string    `db:"store_external_id" json:"store_external_id"`
+	AllowedCIDRs    []string   `db:"allowed_cidrs" json:"allowed_cidrs"`
+	TenantID        *string    `db:"tenant_id" json:"tenant_id"`
+	ExpiresAt       *time.Time `db:"expires_at" json:"expires_at"`
+	LastUsedAt      *time.Time `db:"last_used_at" json:"last_used_at"`
+	RevokedAt       *time.Time `db:"revoked_at" json:"revoked_at"`
+	CreatedBy       string     `db:"created_by" json:"created_by"`
+	CreatedAt       time.Time  `db:"created_at" json:"created_at"`
+}
+
+const apiKeyColumns = `k.id, k.name, k.key_hash, k.key_prefix, k.role, k.scopes, k.store_id::text AS store_id,
+	s.external_id AS store_external_id, k.allowed_cidrs::text[] AS allowed_cidrs, k.tenant_id, k.expires_at, k.last_used_at, k.revoked_at, k.created_by, k.created_at`
+
+// Active reports whether the key can be used at now: it is neither revoked nor expired
+func (k *APIKey) Active(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
+
+// APIKeyInput describes a key to issue
+type APIKeyInput struct {
+	Name         string
+	Role         string
+	Scopes       []string
+	StoreID      *string
+	AllowedCIDRs []string // CIDR blocks the key may push from; empty for anywhere
+	ExpiresAt    *time.Time
+}
+
+// HashAPIKey returns the hex SHA-256 of key, as stored in api_keys.key_hash
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateAPIKey issues a key with input's scopes, returning it with the key itself,
+// which is shown this once. The key belongs to ctx's tenant, or is a platform key in a
+// system context. Returns a not-found error if the store to bind it to doesn't exist
+func (r *PostgresRepository) CreateAPIKey(ctx context.Context, input APIKeyInput) (*APIKey, string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", fmt.Errorf("failed to generate API key: %w", err)
+	}
+	key := APIKeyPrefix + hex.EncodeToString(secret)
+
+	var apiKey *APIKey
+	err := r.retry(ctx, "api_key_create", func() (err error) {
+		rows, _ := r.conn().Query(ctx, `
+			WITH k AS (
+				INSERT INTO api_keys (name, key_hash, key_prefix, role, scopes, store_id, expires_at, created_by, allowed_cidrs)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, COALESCE($9::cidr[], '{}'))
+				RETURNING *
+			)
+			SELECT `+apiKeyColumns+`
+			FROM k
+			LEFT JOIN stores s ON s.id = k.store_id
+		`, input.Name, HashAPIKey(key), key[:len(APIKeyPrefix)+8], input.Role, input.Scopes, input.StoreID, input.ExpiresAt,
+			auditActorFrom(ctx).Actor, input.AllowedCIDRs)
+		apiKey, err = pgx.CollectExactlyOneRow(rows, pgx.RowToAddrOfStructByName[APIKey])
+		return err
+	})
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23503" { // foreign_key_violation
+		return nil, "", NewNotFoundError("stores", *input.StoreID)
+	}
+	if err != nil {
+		r.log(ctx).Error("Failed to create API key", zap.String("name", input.Name), zap.Error(err))
+		return nil, "", NewQueryError(err)
+	}
+
+	r.log(ctx).Info("Issued API key",
+		zap.String("key_id", apiKey.ID),
+		zap.String("name", apiKey.Name),
+		zap.String("role", apiKey.Role),
+		zap.Strings("scopes", apiKey.Scopes))
+
+	return apiKey, key, nil
+}
+
+// FindAPIKeyByHash retrieves the key whose hash is keyHash, revoked and expired keys
+// included. It reads from the primary, so a key is usable as soon as it is issued
+func (r *PostgresRepository) FindAPIKeyByHash(ctx context.Context, keyHash string) (*APIKey, error) {
+	var apiKey *APIKey
+	err := r.retry(ctx, "query", func() (err error) {
+		rows, _ := r.conn().Query(ctx, `
+			SELECT `+apiKeyColumns+`
+			FROM api_keys k
+			LEFT JOIN stores s ON s.id = k.store_id
+			WHERE k.key_hash = $1
+		`, keyHash)
+		apiKey, err = pgx.CollectExactlyOneRow(rows, pgx.RowToAddrOfStructByName[APIKey])
+		return err
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, NewNotFoundError("api_keys", keyHash[:min(len(keyHash), 12)])
+	}
+	if err != nil {
+		r.log(ctx).Error("Failed to look up API key", zap.Error(err))
+		return nil, NewQueryError(err)
+	}
+	return apiKey, nil
+}
+
+// ListAPIKeys retrieves issued keys, newest first; revoked keys only if includeRevoked
+func (r *PostgresRepository) ListAPIKeys(ctx context.Context, includeRevoked bool, pagination Pagination) ([]APIKey, error) {
+	query := `
+		SELECT ` + apiKeyColumns + `
+		FROM api_keys k
+		LEFT JOIN stores s ON s.id = k.store_id
+		WHERE 1=1
+	`
+	if !includeRevoked {
+		query += ` AND k.revoked_at IS NULL`
+	}
+	query += ` ORDER BY k.created_at DESC, k.id DESC LIMIT $1 OFFSET $2`
+
+	keys, err := queryRows(ctx, r, pgx.RowToStructByName[APIKey], query, pagination.Limit, pagination.Offset)
+	if err != nil {
+		r.log(ctx).Error("Failed to list API keys", zap.Error(err))
+		return nil, NewQueryError(err)
+	}
+	return keys, nil
+}
+
+// RevokeAPIKey revokes a key, which can't be used from then on
+// Returns a not-found error if there is no such key or it was already revoked
+func (r *PostgresRepository) RevokeAPIKey(ctx context.Context, id string) (*APIKey, error) {
+	var apiKey *APIKey
+	err := r.retry(ctx, "api_key_revoke", func() (err error) {
+		rows, _ := r.conn().Query(ctx, `
+			WITH k AS (
+				UPDATE api_keys SET revoked_at = CURRENT_TIMESTAMP
+				WHERE id = $1 AND revoked_at IS NULL
+				RETURNING *
+			)
+			SELECT `+apiKeyColumns+`
+			FROM k
+			LEFT JOIN stores s ON s.id = k.store_id
+		`, id)
+		apiKey, err = pgx.CollectExactlyOneRow(rows, pgx.RowToAddrOfStructByName[APIKey])
+		return err
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, NewNotFoundError("api_keys", id)
+	}
+	if err != nil {
+		r.log(ctx).Error("Failed to revoke API key", zap.String("key_id", id), zap.Error(err))
+		return nil, NewQueryError(err)
+	}
+
+	r.log(ctx).Info("Revoked API key", zap.String("key_id", id), zap.String("name", apiKey.Name))
+	return apiKey, nil
+}
+
+// TouchAPIKey records that a key was just used
+func (r *PostgresRepository) TouchAPIKey(ctx context.Context, id string) error {
+	if _, err := r.exec(ctx, `UPDATE api_keys SET last_used_at = CURRENT_TIMESTAMP WHERE id = $1`, id); err != nil {
+		return NewQueryError(err)
+	}
+	return nil
+}
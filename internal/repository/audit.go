@@ -0,0 +1,263 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+// Audited actions
+const (
+	AuditProductPush   = "product_push"
+	AuditProductUpdate = "product_update"
+	AuditStockUpdate   = "stock_update"
+	AuditStoreUpdate   = "store_update"
+	AuditStoreStatus   = "store_status"
+	AuditStorePurge    = "store_purge"
+	AuditStoreHours    = "store_hours"
+	AuditDeliveryZone  = "delivery_zone"
+	AuditStoreTax      = "store_tax"
+	AuditBrandRename   = "brand_rename"
+	AuditBrandMerge    = "brand_merge"
+)
+
+// AuditEntry is one mutating operation recorded in audit_log
+// EntityIDs lists the store first, then any external product IDs or delivery zone involved; brand
+// changes list the brands, canonical first.
+// Before and After hold only the fields that changed; pushes and stock updates record the
+// listings and variations they changed under "listings" and "variations", keyed by external
+// ID, and their outcome in After
+type AuditEntry struct {
+	ID        int64           `db:"id" json:"id"`
+	Actor     string          `db:"actor" json:"actor"`
+	Endpoint  string          `db:"endpoint" json:"endpoint"`
+	Action    string          `db:"action" json:"action"`
+	EntityIDs []string        `db:"entity_ids" json:"entity_ids"`
+	Before    json.RawMessage `db:"before" json:"before,omitempty"`
+	After     json.RawMessage `db:"after" json:"after,omitempty"`
+	CreatedAt time.Time       `db:"created_at" json:"created_at"`
+}
+
+// AuditFilter narrows ListAuditEntries; zero fields match everything
+type AuditFilter struct {
+	Action   string
+	Actor    string
+	EntityID string
+	Since    *time.Time
+	Until    *time.Time
+}
+
+// AuditActor identifies who made a request and through which endpoint
+type AuditActor struct {
+	Actor    string
+	Endpoint string
+}
+
+type auditActorKey struct{}
+
+// WithAuditActor attaches the caller of a request to ctx for the audit log
+func WithAuditActor(ctx context.Context, actor AuditActor) context.Context {
+	return context.WithValue(ctx, auditActorKey{}, actor)
+}
+
+// auditActorFrom returns the caller attached to ctx, or an anonymous internal caller
+func auditActorFrom(ctx context.Context) AuditActor {
+	if actor, ok := ctx.Value(auditActorKey{}).(AuditActor); ok {
+		return actor
+	}
+	return AuditActor{Actor: "anonymous", Endpoint: "internal"}
+}
+
+// auditTimeout bounds an audit write made after its operation committed
+const auditTimeout = 5 * time.Second
+
+// recordAudit is the repository's audit hook, called once a mutating operation has committed
+// Failures are logged and never fail the operation, which has already been applied. Outside
+// WithTx the write outlives a cancelled request, as the operation did, within auditTimeout
+func (r *PostgresRepository) recordAudit(ctx context.Context, action string, entityIDs []string, before, after any) {
+	actor := auditActorFrom(ctx)
+	if r.tx == nil {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(context.WithoutCancel(ctx), auditTimeout)
+		defer cancel()
+	}
+	if err := r.insertAudit(ctx, actor, action, entityIDs, before, after); err != nil {
+		r.log(ctx).Error("Failed to write audit log",
+			zap.String("action", action),
+			zap.String("actor", actor.Actor),
+			zap.Strings("entity_ids", entityIDs),
+			zap.Error(err))
+	}
+}
+
+// insertAudit writes one audit_log row; a nil before or after is stored as NULL
+func (r *PostgresRepository) insertAudit(ctx context.Context, actor AuditActor, action string, entityIDs []string, before, after any) error {
+	var beforeJSON, afterJSON []byte
+	var err error
+	if before != nil {
+		if beforeJSON, err = json.Marshal(before); err != nil {
+			return err
+		}
+	}
+	if after != nil {
+		if afterJSON, err = json.Marshal(after); err != nil {
+			return err
+		}
+	}
+
+	const insert = `
+		INSERT INTO audit_log (actor, endpoint, action, entity_ids, before, after)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	args := []any{actor.Actor, actor.Endpoint, action, entityIDs, beforeJSON, afterJSON}
+
+	// Inside WithTx a failed insert would abort the caller's transaction, so it gets a savepoint
+	if r.tx != nil {
+		return pgx.BeginFunc(ctx, r.tx, func(tx pgx.Tx) error {
+			_, err := tx.Exec(ctx, insert, args...)
+			return err
+		})
+	}
+	_, err = r.exec(ctx, insert, args...)
+	return err
+}
+
+// auditChanges returns the fields whose values differ between two row snapshots,
+// as before and after maps. updated_at is ignored since every write changes it
+func auditChanges(before, after map[string]any) (map[string]any, map[string]any) {
+	changedBefore := map[string]any{}
+	changedAfter := map[string]any{}
+	for field, value := range after {
+		if field == "updated_at" || reflect.DeepEqual(before[field], value) {
+			continue
+		}
+		changedBefore[field] = before[field]
+		changedAfter[field] = value
+	}
+	return changedBefore, changedAfter
+}
+
+// auditedRows collects the fields a bulk write changed in each row, keyed by the row's
+// external ID, for its audit entry. Rows the write created have no before
+type auditedRows struct {
+	before map[string]map[string]any
+	after  map[string]map[string]any
+}
+
+func newAuditedRows() *auditedRows {
+	return &auditedRows{before: map[string]map[string]any{}, after: map[string]map[string]any{}}
+}
+
+// add records a row's state before and after the write; before is nil for a created row.
+// A row the write left unchanged is not recorded
+func (a *auditedRows) add(externalID string, before, after map[string]any) {
+	if before == nil {
+		a.after[externalID] = after
+		return
+	}
+	changedBefore, changedAfter := auditChanges(before, after)
+	if len(changedAfter) == 0 {
+		return
+	}
+	a.before[externalID] = changedBefore
+	a.after[externalID] = changedAfter
+}
+
+// merge adds the rows recorded in other
+func (a *auditedRows) merge(other *auditedRows) {
+	if other == nil {
+		return
+	}
+	maps.Copy(a.before, other.before)
+	maps.Copy(a.after, other.after)
+}
+
+// Columns of listings and variations whose changes pushes and stock updates audit
+var (
+	listingAuditColumns   = []string{"price", "stock_quantity", "is_in_stock", "is_available"}
+	variationAuditColumns = []string{"price", "stock_quantity", "is_in_stock", "is_active"}
+)
+
+// auditStateSQL returns an expression building a JSON object of columns of the row alias,
+// for a statement to return a row's state to collectAuditedRows
+func auditStateSQL(alias string, columns []string) string {
+	args := make([]string, len(columns))
+	for i, column := range columns {
+		args[i] = "'" + column + "', " + alias + "." + column
+	}
+	return "jsonb_build_object(" + strings.Join(args, ", ") + ")"
+}
+
+// collectAuditedRows reads rows of external ID, state before and state after as JSON
+// objects, the before NULL for a created row, into audited, and returns the external IDs
+func collectAuditedRows(rows pgx.Rows, audited *auditedRows) (map[string]bool, error) {
+	ids := map[string]bool{}
+	var externalID string
+	var before, after map[string]any
+	_, err := pgx.ForEachRow(rows, []any{&externalID, &before, &after}, func() error {
+		ids[externalID] = true
+		audited.add(externalID, before, after)
+		before, after = nil, nil
+		return nil
+	})
+	return ids, err
+}
+
+// ListAuditEntries returns audit entries matching filter, newest first
+func (r *PostgresRepository) ListAuditEntries(ctx context.Context, filter AuditFilter, pagination Pagination) ([]AuditEntry, error) {
+	query := `
+		SELECT id, actor, endpoint, action, entity_ids, before, after, created_at
+		FROM audit_log
+		WHERE 1=1
+	`
+	args := []interface{}{}
+	argCount := 1
+
+	if filter.Action != "" {
+		query += fmt.Sprintf(" AND action = $%d", argCount)
+		args = append(args, filter.Action)
+		argCount++
+	}
+
+	if filter.Actor != "" {
+		query += fmt.Sprintf(" AND actor = $%d", argCount)
+		args = append(args, filter.Actor)
+		argCount++
+	}
+
+	if filter.EntityID != "" {
+		query += fmt.Sprintf(" AND entity_ids @> ARRAY[$%d::text]", argCount)
+		args = append(args, filter.EntityID)
+		argCount++
+	}
+
+	if filter.Since != nil {
+		query += fmt.Sprintf(" AND created_at >= $%d", argCount)
+		args = append(args, *filter.Since)
+		argCount++
+	}
+
+	if filter.Until != nil {
+		query += fmt.Sprintf(" AND created_at < $%d", argCount)
+		args = append(args, *filter.Until)
+		argCount++
+	}
+
+	query += fmt.Sprintf(" ORDER BY created_at DESC, id DESC LIMIT $%d OFFSET $%d", argCount, argCount+1)
+	args = append(args, pagination.Limit, pagination.Offset)
+
+	entries, err := queryRows(ctx, r, pgx.RowToStructByName[AuditEntry], query, args...)
+	if err != nil {
+		r.log(ctx).Error("Failed to list audit entries", zap.Error(err))
+		return nil, NewQueryError(err)
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestAuditChanges(t *testing.T) {
+	before := map[string]any{
+		"id":         "b3c1",
+		"name":       "Corner Shop",
+		"is_open":    true,
+		"phone":      nil,
+		"updated_at": "2026-01-01T00:00:00Z",
+	}
+	after := map[string]any{
+		"id":         "b3c1",
+		"name":       "Corner Shop",
+		"is_open":    false,
+		"phone":      "+15550100",
+		"updated_at": "2026-01-02T00:00:00Z",
+	}
+
+	gotBefore, gotAfter := auditChanges(before, after)
+
+	wantBefore := map[string]any{"is_open": true, "phone": nil}
+	wantAfter := map[string]any{"is_open": false, "phone": "+15550100"}
+	if !reflect.DeepEqual(gotBefore, wantBefore) {
+		t.Errorf("auditChanges() before = %v, want %v", gotBefore, wantBefore)
+	}
+	if !reflect.DeepEqual(gotAfter, wantAfter) {
+		t.Errorf("auditChanges() after = %v, want %v", gotAfter, wantAfter)
+	}
+}
+
+func TestAuditActorFrom(t *testing.T) {
+	if got := auditActorFrom(context.Background()); got.Actor != "anonymous" || got.Endpoint != "internal" {
+		t.Errorf("auditActorFrom() without actor = %+v, want anonymous internal caller", got)
+	}
+
+	actor := AuditActor{Actor: "token:ab12cd34ef56", Endpoint: "PUT /api/v1/stores/:id/status"}
+	ctx := WithAuditActor(context.Background(), actor)
+	if got := auditActorFrom(ctx); got != actor {
+		t.Errorf("auditActorFrom() = %+v, want %+v", got, actor)
+	}
+}
+
+func TestAuditedRows(t *testing.T) {
+	rows := newAuditedRows()
+	rows.add("P1", map[string]any{"price": 10.0, "is_available": true}, map[string]any{"price": 12.0, "is_available": true})
+	rows.add("P2", map[string]any{"price": 5.0}, map[string]any{"price": 5.0})
+	rows.add("P3", nil, map[string]any{"price": 7.0})
+
+	other := newAuditedRows()
+	other.add("P4", map[string]any{"is_available": true}, map[string]any{"is_available": false})
+	rows.merge(other)
+	rows.merge(nil)
+
+	wantBefore := map[string]map[string]any{
+		"P1": {"price": 10.0},
+		"P4": {"is_available": true},
+	}
+	wantAfter := map[string]map[string]any{
+		"P1": {"price": 12.0},
+		"P3": {"price": 7.0},
+		"P4": {"is_available": false},
+	}
+	if !reflect.DeepEqual(rows.before, wantBefore) {
+		t.Errorf("before = %v, want %v", rows.before, wantBefore)
+	}
+	if !reflect.DeepEqual(rows.after, wantAfter) {
+		t.Errorf("after = %v, want %v", rows.after, wantAfter)
+	}
+}
+
+func TestAuditStateSQL(t *testing.T) {
+	got := auditStateSQL("old", []string{"price", "is_available"})
+	want := "jsonb_build_object('price', old.price, 'is_available', old.is_available)"
+	if got != want {
+		t.Errorf("auditStateSQL() = %q, want %q", got, want)
+	}
+}
+
+func TestAudit_PushAndStockUpdateChanges(t *testing.T) {
+	r := testPostgres(t)
+	ctx := context.Background()
+	seedStore(t, r, "TEST-AUDIT")
+	seedProducts(t, r, "TEST-AUDIT", PushOptions{}, "AUDIT-1", "AUDIT-2")
+
+	_, err := r.BulkUpdateStock(ctx, "TEST-AUDIT", []StockProductUpdate{
+		{ID: "AUDIT-1", StockQuantity: 0, IsAvailable: true, Price: 12},
+		{ID: "AUDIT-2", StockQuantity: 5, IsAvailable: true},
+	}, StockUpdateOptions{})
+	if err != nil {
+		t.Fatalf("BulkUpdateStock() error = %v", err)
+	}
+
+	changes := func(action string) (before, after map[string]map[string]map[string]any) {
+		t.Helper()
+		entries, err := r.ListAuditEntries(ctx, AuditFilter{Action: action, EntityID: "TEST-AUDIT"}, Pagination{Limit: 1})
+		if err != nil || len(entries) != 1 {
+			t.Fatalf("ListAuditEntries(%s) = %v, %v, want one entry", action, entries, err)
+		}
+		if err := json.Unmarshal(entries[0].Before, &before); err != nil {
+			t.Fatalf("%s before = %s: %v", action, entries[0].Before, err)
+		}
+		if err := json.Unmarshal(entries[0].After, &after); err != nil {
+			t.Fatalf("%s after = %s: %v", action, entries[0].After, err)
+		}
+		return before, after
+	}
+
+	before, after := changes(AuditStockUpdate)
+	wantBefore := map[string]map[string]any{"AUDIT-1": {"price": 10.0, "stock_quantity": 5.0, "is_in_stock": true}}
+	wantAfter := map[string]map[string]any{"AUDIT-1": {"price": 12.0, "stock_quantity": 0.0, "is_in_stock": false}}
+	if !reflect.DeepEqual(before["listings"], wantBefore) || !reflect.DeepEqual(after["listings"], wantAfter) {
+		t.Errorf("stock update listings = %v -> %v, want %v -> %v", before["listings"], after["listings"], wantBefore, wantAfter)
+	}
+
+	seedProducts(t, r, "TEST-AUDIT", PushOptions{FullSync: true}, "AUDIT-1", "AUDIT-3")
+	before, after = changes(AuditProductPush)
+	wantBefore = map[string]map[string]any{
+		"AUDIT-1": {"price": 12.0, "stock_quantity": 0.0, "is_in_stock": false},
+		"AUDIT-2": {"is_available": true},
+	}
+	if !reflect.DeepEqual(before["listings"], wantBefore) {
+		t.Errorf("push listings before = %v, want %v", before["listings"], wantBefore)
+	}
+	if after["listings"]["AUDIT-3"]["external_id"] != "AUDIT-3" {
+		t.Errorf("push listings after = %v, want the created AUDIT-3 in full", after["listings"])
+	}
+	if after["listings"]["AUDIT-2"]["is_available"] != false {
+		t.Errorf("push listings after = %v, want AUDIT-2 deactivated", after["listings"])
+	}
+}
@@ -0,0 +1,203 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.uber.org/zap"
+)
+
+// Brand is a row from the brands table with the number of products using it
+// MergedInto is set on a duplicate merged into a canonical brand
+type Brand struct {
+	ID             string    `db:"id" json:"id"`
+	Name           string    `db:"name" json:"name"`
+	Slug           string    `db:"slug" json:"slug"`
+	NormalizedName *string   `db:"normalized_name" json:"normalized_name"`
+	Description    *string   `db:"description" json:"description"`
+	LogoURL        *string   `db:"logo_url" json:"logo_url"`
+	WebsiteURL     *string   `db:"website_url" json:"website_url"`
+	IsActive       bool      `db:"is_active" json:"is_active"`
+	MergedInto     *string   `db:"merged_into" json:"merged_into"`
+	ProductCount   int64     `db:"product_count" json:"product_count"`
+	CreatedAt      time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time `db:"updated_at" json:"updated_at"`
+}
+
+const brandColumns = `b.id, b.name, b.slug, b.normalized_name, b.description, b.logo_url, b.website_url,
+	b.is_active, b.merged_into,
+	(SELECT COUNT(*) FROM products p WHERE p.brand_id = b.id) AS product_count,
+	b.created_at, b.updated_at`
+
+// BrandFilter narrows ListBrands; zero fields match every brand that hasn't been merged
+type BrandFilter struct {
+	Search        string // Matches the name, or the normalized name exactly
+	IncludeMerged bool
+}
+
+// BrandMergeResult describes a committed brand merge
+type BrandMergeResult struct {
+	CanonicalID   string   `json:"canonical_id"`
+	MergedIDs     []string `json:"merged_ids"`
+	ProductsMoved int64    `json:"products_moved"`
+}
+
+// ListBrands retrieves brands matching filter, ordered by name
+func (r *PostgresRepository) ListBrands(ctx context.Context, filter BrandFilter, pagination Pagination) ([]Brand, error) {
+	query := `SELECT ` + brandColumns + ` FROM brands b WHERE 1=1`
+	args := []interface{}{}
+	argCount := 1
+
+	if !filter.IncludeMerged {
+		query += ` AND b.merged_into IS NULL`
+	}
+
+	if filter.Search != "" {
+		query += fmt.Sprintf(" AND (b.name ILIKE '%%' || $%d || '%%' OR b.normalized_name = normalize_product_name($%d))", argCount, argCount)
+		args = append(args, filter.Search)
+		argCount++
+	}
+
+	query += fmt.Sprintf(" ORDER BY b.name, b.id LIMIT $%d OFFSET $%d", argCount, argCount+1)
+	args = append(args, pagination.Limit, pagination.Offset)
+
+	brands, err := queryRows(ctx, r, pgx.RowToStructByName[Brand], query, args...)
+	if err != nil {
+		r.log(ctx).Error("Failed to list brands", zap.Error(err))
+		return nil, NewQueryError(err)
+	}
+
+	return brands, nil
+}
+
+// RenameBrand changes a brand's name and slug; its products are re-indexed for search
+// by the brands rename trigger. Returns a conflict error if another brand has the name
+func (r *PostgresRepository) RenameBrand(ctx context.Context, brandID, name string) (*Brand, error) {
+	var oldName string
+	err := r.retry(ctx, AuditBrandRename, func() error {
+		return r.conn().QueryRow(ctx, `
+			UPDATE brands
+			SET name = $2, slug = $3, updated_at = CURRENT_TIMESTAMP
+			FROM (SELECT id, name FROM brands WHERE id = $1 FOR UPDATE) old
+			WHERE brands.id = old.id
+			RETURNING old.name
+		`, brandID, name, generateSlug(name)).Scan(&oldName)
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, NewNotFoundError("brands", brandID)
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return nil, NewConflictError(fmt.Sprintf("a brand named %q already exists", name), err)
+	}
+	if err != nil {
+		r.log(ctx).Error("Failed to rename brand", zap.String("brand_id", brandID), zap.Error(err))
+		return nil, NewQueryError(err)
+	}
+
+	r.recordAudit(ctx, AuditBrandRename, []string{brandID}, map[string]any{"name": oldName}, map[string]any{"name": name})
+
+	r.log(ctx).Info("Renamed brand",
+		zap.String("brand_id", brandID),
+		zap.String("from", oldName),
+		zap.String("to", name))
+
+	brand, err := queryRow(ctx, r, pgx.RowToAddrOfStructByName[Brand], `SELECT `+brandColumns+` FROM brands b WHERE b.id = $1`, brandID)
+	if err != nil {
+		return nil, NewQueryError(err)
+	}
+	return brand, nil
+}
+
+// MergeBrands moves every product of the duplicate brands to the canonical brand and
+// deactivates the duplicates in one transaction. Duplicates keep their rows, marked as
+// merged, so pushes that still use their names resolve to the canonical brand
+func (r *PostgresRepository) MergeBrands(ctx context.Context, canonicalID string, duplicateIDs []string) (*BrandMergeResult, error) {
+	result := &BrandMergeResult{CanonicalID: canonicalID, MergedIDs: duplicateIDs}
+
+	err := r.retry(ctx, AuditBrandMerge, func() error {
+		tx, err := r.conn().Begin(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to begin transaction: %w", err)
+		}
+		defer tx.Rollback(ctx)
+
+		// Lock every brand involved so a concurrent merge or rename can't interleave
+		rows, _ := tx.Query(ctx, `
+			SELECT id::text, merged_into::text
+			FROM brands
+			WHERE id = ANY($1::uuid[])
+			ORDER BY id
+			FOR UPDATE
+		`, append([]string{canonicalID}, duplicateIDs...))
+		mergedInto := map[string]*string{}
+		var id string
+		var target *string
+		_, err = pgx.ForEachRow(rows, []any{&id, &target}, func() error {
+			mergedInto[id] = target
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, id := range append([]string{canonicalID}, duplicateIDs...) {
+			if _, ok := mergedInto[id]; !ok {
+				return NewNotFoundError("brands", id)
+			}
+		}
+		if mergedInto[canonicalID] != nil {
+			return NewConflictError(fmt.Sprintf("brand %s was merged into %s; merge into that brand instead", canonicalID, *mergedInto[canonicalID]), nil)
+		}
+
+		tag, err := tx.Exec(ctx, `
+			UPDATE products SET brand_id = $1, updated_at = CURRENT_TIMESTAMP
+			WHERE brand_id = ANY($2::uuid[])
+		`, canonicalID, duplicateIDs)
+		if err != nil {
+			return fmt.Errorf("failed to move products: %w", err)
+		}
+		result.ProductsMoved = tag.RowsAffected()
+
+		// Brands merged into a duplicate earlier follow it to the canonical brand
+		_, err = tx.Exec(ctx, `
+			UPDATE brands
+			SET merged_into = $1, is_active = false, updated_at = CURRENT_TIMESTAMP
+			WHERE id = ANY($2::uuid[]) OR merged_into = ANY($2::uuid[])
+		`, canonicalID, duplicateIDs)
+		if err != nil {
+			return fmt.Errorf("failed to mark brands merged: %w", err)
+		}
+
+		if err := tx.Commit(ctx); err != nil {
+			return fmt.Errorf("failed to commit transaction: %w", err)
+		}
+		return nil
+	})
+	if IsRepositoryError(err) {
+		return nil, err
+	}
+	if err != nil {
+		r.log(ctx).Error("Failed to merge brands",
+			zap.String("canonical_id", canonicalID),
+			zap.Strings("duplicate_ids", duplicateIDs),
+			zap.Error(err))
+		return nil, NewQueryError(err)
+	}
+
+	r.recordAudit(ctx, AuditBrandMerge, append([]string{canonicalID}, duplicateIDs...), nil, map[string]any{
+		"merged_into":    canonicalID,
+		"products_moved": result.ProductsMoved,
+	})
+
+	r.log(ctx).Info("Merged brands",
+		zap.String("canonical_id", canonicalID),
+		zap.Strings("duplicate_ids", duplicateIDs),
+		zap.Int64("products_moved", result.ProductsMoved))
+
+	return result, nil
+}
@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+// seedBrand finds or creates the brand named name and returns its ID
+func seedBrand(t *testing.T, r *PostgresRepository, name string) string {
+	t.Helper()
+	var id string
+	if err := r.conn().QueryRow(context.Background(), `SELECT find_or_create_brand($1)::text`, name).Scan(&id); err != nil {
+		t.Fatalf("find_or_create_brand(%q) error = %v", name, err)
+	}
+	return id
+}
+
+// productBrand returns the brand ID of the product listed by the store under externalID
+func productBrand(t *testing.T, r *PostgresRepository, storeUUID, externalID string) string {
+	t.Helper()
+	var brandID string
+	err := r.conn().QueryRow(context.Background(), `
+		SELECT p.brand_id::text
+		FROM store_products sp JOIN products p ON p.id = sp.product_id
+		WHERE sp.store_id = $1 AND sp.external_id = $2
+	`, storeUUID, externalID).Scan(&brandID)
+	if err != nil {
+		t.Fatalf("failed to read the brand of %s: %v", externalID, err)
+	}
+	return brandID
+}
+
+func TestMergeBrands(t *testing.T) {
+	r := testPostgres(t)
+	ctx := context.Background()
+	storeUUID := seedStore(t, r, "TEST-BRANDS")
+
+	canonical := seedBrand(t, r, "Brandtest Canonical")
+	duplicate := seedBrand(t, r, "Brandtest Duplicate")
+	earlier := seedBrand(t, r, "Brandtest Earlier")
+
+	var products []ProductInput
+	var listings []StoreProductInput
+	for id, brand := range map[string]string{"BRAND-1": "Brandtest Canonical", "BRAND-2": "Brandtest Duplicate", "BRAND-3": "Brandtest Earlier"} {
+		products = append(products, ProductInput{ExternalProductID: id, Name: testProductName(id), Brand: brand, BasePrice: 10, IsActive: true})
+		listings = append(listings, StoreProductInput{ExternalProductID: id, Price: 10, StockQuantity: 5, IsInStock: true})
+	}
+	if _, err := r.UpsertProductsWithMatching(ctx, "TEST-BRANDS", products, nil, listings, PushOptions{}); err != nil {
+		t.Fatalf("UpsertProductsWithMatching() error = %v", err)
+	}
+
+	if _, err := r.MergeBrands(ctx, duplicate, []string{earlier}); err != nil {
+		t.Fatalf("MergeBrands() into the duplicate error = %v", err)
+	}
+	result, err := r.MergeBrands(ctx, canonical, []string{duplicate})
+	if err != nil {
+		t.Fatalf("MergeBrands() error = %v", err)
+	}
+	if result.ProductsMoved != 2 {
+		t.Errorf("ProductsMoved = %d, want 2 (BRAND-2 and BRAND-3, moved to the duplicate before)", result.ProductsMoved)
+	}
+	for _, id := range []string{"BRAND-1", "BRAND-2", "BRAND-3"} {
+		if got := productBrand(t, r, storeUUID, id); got != canonical {
+			t.Errorf("%s brand = %s, want the canonical %s", id, got, canonical)
+		}
+	}
+
+	// Both duplicates now point at the canonical brand, which their names resolve to
+	merged, err := r.ListBrands(ctx, BrandFilter{Search: "Brandtest", IncludeMerged: true}, Pagination{Limit: 10})
+	if err != nil {
+		t.Fatalf("ListBrands() error = %v", err)
+	}
+	for _, b := range merged {
+		if b.ID == canonical {
+			if b.MergedInto != nil || b.ProductCount != 3 {
+				t.Errorf("canonical brand = %+v, want unmerged with 3 products", b)
+			}
+			continue
+		}
+		if b.MergedInto == nil || *b.MergedInto != canonical || b.IsActive {
+			t.Errorf("brand %s merged_into = %v, active = %v, want inactive and merged into %s", b.Name, b.MergedInto, b.IsActive, canonical)
+		}
+	}
+	if got := seedBrand(t, r, "Brandtest Earlier"); got != canonical {
+		t.Errorf("find_or_create_brand() of a merged brand = %s, want the canonical %s", got, canonical)
+	}
+	if unmerged, _ := r.ListBrands(ctx, BrandFilter{Search: "Brandtest"}, Pagination{Limit: 10}); len(unmerged) != 1 {
+		t.Errorf("ListBrands() without merged = %d brands, want only the canonical one", len(unmerged))
+	}
+}
+
+func TestMergeBrands_Validation(t *testing.T) {
+	r := testPostgres(t)
+	ctx := context.Background()
+
+	canonical := seedBrand(t, r, "Brandtest Target")
+	duplicate := seedBrand(t, r, "Brandtest Source")
+	if _, err := r.MergeBrands(ctx, canonical, []string{duplicate}); err != nil {
+		t.Fatalf("MergeBrands() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		canonical  string
+		duplicates []string
+		wantStatus int
+	}{
+		{name: "unknown canonical", canonical: "00000000-0000-0000-0000-000000000001", duplicates: []string{duplicate}, wantStatus: http.StatusNotFound},
+		{name: "unknown duplicate", canonical: canonical, duplicates: []string{"00000000-0000-0000-0000-000000000001"}, wantStatus: http.StatusNotFound},
+		{name: "canonical already merged", canonical: duplicate, duplicates: []string{canonical}, wantStatus: http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := r.MergeBrands(ctx, tt.canonical, tt.duplicates)
+			if got := GetStatusCode(err); got != tt.wantStatus {
+				t.Errorf("MergeBrands() error = %v (status %d), want status %d", err, got, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestRenameBrand(t *testing.T) {
+	r := testPostgres(t)
+	ctx := context.Background()
+
+	id := seedBrand(t, r, "Brandtest Nestl")
+	seedBrand(t, r, "Brandtest Taken")
+
+	brand, err := r.RenameBrand(ctx, id, "Brandtest Nestle")
+	if err != nil {
+		t.Fatalf("RenameBrand() error = %v", err)
+	}
+	if brand.Name != "Brandtest Nestle" || brand.Slug != generateSlug("Brandtest Nestle") {
+		t.Errorf("RenameBrand() = %s (%s), want Brandtest Nestle with its slug", brand.Name, brand.Slug)
+	}
+
+	if _, err := r.RenameBrand(ctx, "00000000-0000-0000-0000-000000000001", "Brandtest Other"); GetStatusCode(err) != http.StatusNotFound {
+		t.Errorf("RenameBrand() of an unknown brand error = %v, want not found", err)
+	}
+	// Last: the unique violation aborts the test's transaction
+	if _, err := r.RenameBrand(ctx, id, "Brandtest Taken"); GetStatusCode(err) != http.StatusConflict {
+		t.Errorf("RenameBrand() to a taken name error = %v, want a conflict", err)
+	}
+}
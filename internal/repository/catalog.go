@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// GetProductByID retrieves a catalog product by its UUID
+func (r *PostgresRepository) GetProductByID(ctx context.Context, productID string) (*Product, error) {
+	product, err := queryRow(ctx, r, pgx.RowToAddrOfStructByName[Product], `SELECT `+productColumns+` FROM products WHERE id = $1`, productID)
+	if err != nil {
+		return nil, fmt.Errorf("product not found: %w", err)
+	}
+
+	return product, nil
+}
+
+// GetStoreProduct retrieves a product's listing in a store
+func (r *PostgresRepository) GetStoreProduct(ctx context.Context, storeID, productID string) (*StoreProduct, error) {
+	storeProduct, err := queryRow(ctx, r, pgx.RowToAddrOfStructByName[StoreProduct], `
+		SELECT `+storeProductColumns+`
+		FROM store_products
+		WHERE store_id = $1 AND product_id = $2
+	`, storeID, productID)
+	if err != nil {
+		return nil, fmt.Errorf("store product not found: %w", err)
+	}
+
+	return storeProduct, nil
+}
+
+// ListVariations retrieves the active variations of a store product in display order
+func (r *PostgresRepository) ListVariations(ctx context.Context, storeProductID string) ([]Variation, error) {
+	variations, err := queryRows(ctx, r, pgx.RowToStructByName[Variation], `
+		SELECT `+variationColumns+`
+		FROM product_variations
+		WHERE store_product_id = $1 AND is_active = true
+		ORDER BY display_order, name
+	`, storeProductID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query variations: %w", err)
+	}
+
+	return variations, nil
+}
+
+// ListStoreTaxes retrieves the taxes configured for a store
+func (r *PostgresRepository) ListStoreTaxes(ctx context.Context, storeID string) ([]Tax, error) {
+	taxes, err := queryRows(ctx, r, pgx.RowToStructByName[Tax], `
+		SELECT `+taxColumns+`
+		FROM taxes
+		WHERE store_id = $1
+		ORDER BY name
+	`, storeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query taxes: %w", err)
+	}
+
+	return taxes, nil
+}
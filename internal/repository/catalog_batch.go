@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+// Batch reads for the GraphQL dataloaders: each resolves many IDs in one query, so a
+// nested query costs a query per level rather than one per object. Only active rows
+// and visible listings are returned; IDs without one are simply missing from the result
+
+// GetStoresByIDs retrieves the active stores among ids
+func (r *PostgresRepository) GetStoresByIDs(ctx context.Context, ids []string) ([]Store, error) {
+	stores, err := queryRows(ctx, r, pgx.RowToStructByName[Store], `
+		SELECT `+storeColumns+`
+		FROM stores
+		WHERE id = ANY($1::uuid[]) AND is_active = true
+	`, ids)
+	if err != nil {
+		r.log(ctx).Error("Failed to query stores by ID", zap.Int("ids", len(ids)), zap.Error(err))
+		return nil, NewQueryError(err)
+	}
+
+	return stores, nil
+}
+
+// GetProductsByIDs retrieves the active catalog products among ids
+func (r *PostgresRepository) GetProductsByIDs(ctx context.Context, ids []string) ([]Product, error) {
+	products, err := queryRows(ctx, r, pgx.RowToStructByName[Product], `
+		SELECT `+productColumns+`
+		FROM products
+		WHERE id = ANY($1::uuid[]) AND is_active = true
+	`, ids)
+	if err != nil {
+		r.log(ctx).Error("Failed to query products by ID", zap.Int("ids", len(ids)), zap.Error(err))
+		return nil, NewQueryError(err)
+	}
+
+	return products, nil
+}
+
+// ListListingsByStores retrieves a page of each store's visible listings matching filter,
+// in ListStoreProducts' order, grouped by store in the order of storeIDs
+// filter.StoreID is ignored and pagination applies to every store on its own
+func (r *PostgresRepository) ListListingsByStores(ctx context.Context, storeIDs []string, filter ListingFilter, pagination Pagination) ([]StoreListing, error) {
+	filter.StoreID = ""
+	page := `SELECT ` + storeListingColumns + storeListingJoins + `
+	WHERE sp.store_id = q.id AND` + storeListingVisible
+	page, args := appendListingFilters(page, []interface{}{storeIDs}, filter, pagination)
+
+	order := "l.name, l.store_product_id"
+	if pagination.KeysetOrder() {
+		order = "l.created_at DESC, l.store_product_id DESC"
+	}
+
+	// The lateral join pages each store with its own LIMIT, using the store_id index
+	query := `
+		SELECT l.*
+		FROM unnest($1::uuid[]) WITH ORDINALITY AS q(id, n)
+		CROSS JOIN LATERAL (` + page + `) l
+		ORDER BY q.n, ` + order
+
+	listings, err := queryRows(ctx, r, pgx.RowToStructByName[StoreListing], query, args...)
+	if err != nil {
+		r.log(ctx).Error("Failed to query listings by store", zap.Int("stores", len(storeIDs)), zap.Error(err))
+		return nil, NewQueryError(err)
+	}
+
+	return listings, nil
+}
+
+// ListListingsByProducts retrieves every visible listing of the products in productIDs,
+// grouped by product and cheapest first
+func (r *PostgresRepository) ListListingsByProducts(ctx context.Context, productIDs []string) ([]StoreListing, error) {
+	query := `SELECT ` + storeListingColumns + storeListingJoins + `
+	WHERE sp.product_id = ANY($1::uuid[]) AND` + storeListingVisible + `
+	ORDER BY sp.product_id, COALESCE(sp.sale_price, sp.price), s.name, sp.id`
+
+	listings, err := queryRows(ctx, r, pgx.RowToStructByName[StoreListing], query, productIDs)
+	if err != nil {
+		r.log(ctx).Error("Failed to query listings by product", zap.Int("products", len(productIDs)), zap.Error(err))
+		return nil, NewQueryError(err)
+	}
+
+	return listings, nil
+}
+
+// ListVariationsByStoreProducts retrieves the active variations of the store products in
+// storeProductIDs, in ListVariations' order within each
+func (r *PostgresRepository) ListVariationsByStoreProducts(ctx context.Context, storeProductIDs []string) ([]Variation, error) {
+	variations, err := queryRows(ctx, r, pgx.RowToStructByName[Variation], `
+		SELECT `+variationColumns+`
+		FROM product_variations
+		WHERE store_product_id = ANY($1::uuid[]) AND is_active = true
+		ORDER BY store_product_id, display_order, name
+	`, storeProductIDs)
+	if err != nil {
+		r.log(ctx).Error("Failed to query variations by store product", zap.Int("store_products", len(storeProductIDs)), zap.Error(err))
+		return nil, NewQueryError(err)
+	}
+
+	return variations, nil
+}
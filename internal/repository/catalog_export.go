@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+// CatalogExportRow is one of a store's listings as an export writes it
+// Fields named as a push names them (id, category_id, taxes) hold the ERP's external IDs,
+// so an export can be imported again
+type CatalogExportRow struct {
+	ExternalID      *string   `db:"external_id" json:"id"`
+	SKU             string    `db:"sku" json:"sku"`
+	Name            string    `db:"name" json:"name"`
+	Slug            string    `db:"slug" json:"slug"`
+	Description     *string   `db:"description" json:"description"`
+	CategoryID      *string   `db:"category_id" json:"category_id"`
+	Price           float64   `db:"price" json:"price"`
+	Currency        *string   `db:"currency" json:"currency"`
+	Unit            *string   `db:"unit" json:"unit"`
+	UnitQuantity    *float64  `db:"unit_quantity" json:"unit_quantity"`
+	PrimaryImageURL *string   `db:"primary_image_url" json:"primary_image_url"`
+	Images          []string  `db:"images" json:"images"`
+	Brand           *string   `db:"brand" json:"brand"`
+	Manufacturer    *string   `db:"manufacturer" json:"manufacturer"`
+	Barcode         *string   `db:"barcode" json:"barcode"`
+	EAN             *string   `db:"ean" json:"ean"`
+	Taxes           []string  `db:"taxes" json:"taxes"`
+	IsActive        bool      `db:"is_active" json:"is_active"`
+	IsFeatured      bool      `db:"is_featured" json:"is_featured"`
+	IsCustomizable  bool      `db:"is_customizable" json:"is_customizable"`
+	IsAddon         bool      `db:"is_addon" json:"is_addon"`
+	StorePrice      float64   `db:"store_price" json:"store_price"`
+	SalePrice       *float64  `db:"sale_price" json:"sale_price"`
+	StockQuantity   float64   `db:"stock_quantity" json:"stock_quantity"`
+	IsInStock       bool      `db:"is_in_stock" json:"is_in_stock"`
+	TaxRate         float64   `db:"tax_rate" json:"tax_rate"`
+	CategoryName    *string   `db:"category_name" json:"category_name"`
+	ProductID       string    `db:"product_id" json:"product_id"`
+	StoreProductID  string    `db:"store_product_id" json:"store_product_id"`
+	UpdatedAt       time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// ExportStoreCatalog calls fn with each of a store's available listings, ordered by
+// name, as they are read, so a catalog of any size is never held in memory. It stops at
+// the first error fn returns, and returns it.
+// TaxRate is the sum of the listing's active tax rates, with its overrides applied.
+// Rows are read once and not retried: fn may already have written those before a failure
+func (r *PostgresRepository) ExportStoreCatalog(ctx context.Context, storeID string, fn func(CatalogExportRow) error) error {
+	var exists bool
+	err := r.retry(ctx, "store exists", func() error {
+		return r.reader().QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM stores WHERE id = $1)`, storeID).Scan(&exists)
+	})
+	if err != nil {
+		return NewQueryError(err)
+	}
+	if !exists {
+		return NewNotFoundError("stores", storeID)
+	}
+
+	rows, err := r.reader().Query(ctx, `
+		SELECT sp.external_id, p.sku, p.name, p.slug, p.description,
+		       c.external_id AS category_id, p.base_price AS price, p.currency,
+		       p.unit, p.unit_quantity, p.primary_image_url,
+		       COALESCE(img.images, '{}') AS images,
+		       COALESCE(b.name, p.brand) AS brand, p.manufacturer, p.barcode, p.ean,
+		       COALESCE(tx.taxes, '{}') AS taxes,
+		       COALESCE(p.is_active, true) AS is_active,
+		       COALESCE(p.is_featured, false) AS is_featured,
+		       COALESCE(p.is_customizable, false) AS is_customizable,
+		       COALESCE(p.is_addon, false) AS is_addon,
+		       sp.price AS store_price, sp.sale_price,
+		       COALESCE(sp.stock_quantity, 0) AS stock_quantity,
+		       COALESCE(sp.is_in_stock, true) AS is_in_stock,
+		       COALESCE(tx.tax_rate, 0) AS tax_rate,
+		       c.name AS category_name, p.id AS product_id, sp.id AS store_product_id,
+		       sp.updated_at
+		FROM store_products sp
+		JOIN products p ON p.id = sp.product_id
+		LEFT JOIN categories c ON c.id = p.category_id
+		LEFT JOIN brands b ON b.id = p.brand_id
+		LEFT JOIN LATERAL (
+			SELECT array_agg(pi.image_url ORDER BY pi.display_order, pi.image_url) AS images
+			FROM product_images pi
+			WHERE pi.product_id = p.id
+		) img ON true
+		LEFT JOIN LATERAL (
+			SELECT array_agg(t.external_id ORDER BY t.external_id) AS taxes,
+			       SUM(COALESCE(spt.override_rate, t.rate)) AS tax_rate
+			FROM store_product_taxes spt
+			JOIN taxes t ON t.id = spt.tax_id
+			WHERE spt.store_product_id = sp.id AND spt.is_active = true AND t.is_active = true
+		) tx ON true
+		WHERE sp.store_id = $1 AND sp.is_available = true
+		ORDER BY p.name, sp.id
+	`, storeID)
+	if err != nil {
+		r.log(ctx).Error("Failed to query catalog export", zap.String("store_id", storeID), zap.Error(err))
+		return NewQueryError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		row, err := pgx.RowToStructByName[CatalogExportRow](rows)
+		if err != nil {
+			return NewQueryError(err)
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		r.log(ctx).Error("Failed to read catalog export", zap.String("store_id", storeID), zap.Error(err))
+		return NewQueryError(err)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+// CategoryNode is an active category in the category tree
+// ProductCount counts available listings in the category itself; TotalProductCount
+// adds those of every subcategory
+type CategoryNode struct {
+	CategorySummary
+	Depth             int             `db:"depth" json:"depth"`
+	TotalProductCount int64           `db:"-" json:"total_product_count"`
+	Children          []*CategoryNode `db:"-" json:"children"`
+}
+
+// ListCategoryTree retrieves the active category hierarchy with available listing counts
+// With storeID set, only that store's listings are counted. Categories under an inactive
+// parent are left out along with it
+func (r *PostgresRepository) ListCategoryTree(ctx context.Context, storeID string) ([]*CategoryNode, error) {
+	query := `
+		WITH RECURSIVE tree AS (
+			SELECT id, 0 AS depth
+			FROM categories
+			WHERE parent_id IS NULL AND is_active = true
+			UNION ALL
+			SELECT c.id, t.depth + 1
+			FROM categories c
+			JOIN tree t ON c.parent_id = t.id
+			WHERE c.is_active = true
+		),
+		counts AS (
+			SELECT p.category_id, COUNT(sp.id) AS product_count
+			FROM store_products sp
+			JOIN products p ON p.id = sp.product_id AND p.is_active = true
+			JOIN stores s ON s.id = sp.store_id AND s.is_active = true
+			WHERE sp.is_available = true AND ($1::uuid IS NULL OR sp.store_id = $1::uuid)
+			GROUP BY p.category_id
+		)
+		SELECT c.id, c.parent_id, c.name, c.slug, c.description, c.icon_url, c.image_url,
+		       c.display_order, COALESCE(n.product_count, 0) AS product_count, t.depth
+		FROM tree t
+		JOIN categories c ON c.id = t.id
+		LEFT JOIN counts n ON n.category_id = c.id
+		ORDER BY t.depth, c.display_order, c.name
+	`
+
+	var store *string
+	if storeID != "" {
+		store = &storeID
+	}
+
+	rows, err := queryRows(ctx, r, pgx.RowToAddrOfStructByName[CategoryNode], query, store)
+	if err != nil {
+		r.log(ctx).Error("Failed to query category tree", zap.String("store_id", storeID), zap.Error(err))
+		return nil, NewQueryError(err)
+	}
+
+	return buildCategoryTree(rows), nil
+}
+
+// buildCategoryTree links categories, ordered parents first, under their parents
+// and rolls listing counts up into TotalProductCount. Returns the roots
+func buildCategoryTree(categories []*CategoryNode) []*CategoryNode {
+	byID := make(map[string]*CategoryNode, len(categories))
+	roots := []*CategoryNode{}
+	for _, c := range categories {
+		c.Children = []*CategoryNode{}
+		c.TotalProductCount = c.ProductCount
+		byID[c.ID] = c
+
+		if c.ParentID == nil {
+			roots = append(roots, c)
+		} else if parent, ok := byID[*c.ParentID]; ok {
+			parent.Children = append(parent.Children, c)
+		}
+	}
+
+	// Children follow their parents, so walking backwards totals each subtree before its parent
+	for i := len(categories) - 1; i >= 0; i-- {
+		c := categories[i]
+		if c.ParentID == nil {
+			continue
+		}
+		if parent, ok := byID[*c.ParentID]; ok {
+			parent.TotalProductCount += c.TotalProductCount
+		}
+	}
+
+	return roots
+}
@@ -0,0 +1,41 @@
+package repository
+
+import "testing"
+
+func TestBuildCategoryTree(t *testing.T) {
+	category := func(id string, parent string, depth int, count int64) *CategoryNode {
+		node := &CategoryNode{Depth: depth}
+		node.ID = id
+		node.ProductCount = count
+		if parent != "" {
+			node.ParentID = &parent
+		}
+		return node
+	}
+
+	roots := buildCategoryTree([]*CategoryNode{
+		category("food", "", 0, 1),
+		category("home", "", 0, 0),
+		category("dairy", "food", 1, 2),
+		category("snacks", "food", 1, 0),
+		category("cheese", "dairy", 2, 4),
+	})
+
+	if len(roots) != 2 || roots[0].ID != "food" || roots[1].ID != "home" {
+		t.Fatalf("buildCategoryTree() roots = %v, want food and home", roots)
+	}
+
+	food := roots[0]
+	if len(food.Children) != 2 || food.Children[0].ID != "dairy" || food.Children[1].ID != "snacks" {
+		t.Fatalf("food children = %v, want dairy and snacks", food.Children)
+	}
+	if food.TotalProductCount != 7 || food.ProductCount != 1 {
+		t.Errorf("food counts = %d total, %d own, want 7 and 1", food.TotalProductCount, food.ProductCount)
+	}
+	if dairy := food.Children[0]; dairy.TotalProductCount != 6 || len(dairy.Children) != 1 {
+		t.Errorf("dairy = %d total with %d children, want 6 with 1", dairy.TotalProductCount, len(dairy.Children))
+	}
+	if home := roots[1]; home.Children == nil || home.TotalProductCount != 0 {
+		t.Errorf("home = %+v, want empty non-nil children and no products", home)
+	}
+}
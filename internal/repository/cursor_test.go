@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCursor_RoundTrip(t *testing.T) {
+	want := Cursor{
+		CreatedAt: time.Date(2024, 1, 15, 10, 30, 0, 123456000, time.UTC),
+		ID:        "8c1d2f0e-0000-4000-8000-000000000001",
+	}
+
+	got, err := DecodeCursor(want.Encode())
+	if err != nil {
+		t.Fatalf("DecodeCursor() error = %v", err)
+	}
+	if !got.CreatedAt.Equal(want.CreatedAt) || got.ID != want.ID {
+		t.Errorf("DecodeCursor() = %+v, want %+v", got, want)
+	}
+}
+
+func TestDecodeCursor_Invalid(t *testing.T) {
+	for _, token := range []string{"", "not base64!", "bm90IGpzb24", Cursor{ID: "x"}.Encode()} {
+		if _, err := DecodeCursor(token); err != ErrInvalidCursor {
+			t.Errorf("DecodeCursor(%q) error = %v, want ErrInvalidCursor", token, err)
+		}
+	}
+}
@@ -0,0 +1,258 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+// Delivery zone shapes
+const (
+	DeliveryZoneRadius  = "radius"
+	DeliveryZonePolygon = "polygon"
+)
+
+// DeliveryZone is an area a store delivers to, from the delivery_zones table
+// Area is the zone as GeoJSON; a radius zone is stored as the polygon its circle covers
+type DeliveryZone struct {
+	ID        string          `db:"id" json:"id"`
+	StoreID   string          `db:"store_id" json:"store_id"`
+	Name      string          `db:"name" json:"name"`
+	ZoneType  string          `db:"zone_type" json:"zone_type"`
+	RadiusKm  *float64        `db:"radius_km" json:"radius_km"`
+	CenterLat *float64        `db:"center_lat" json:"center_lat"`
+	CenterLng *float64        `db:"center_lng" json:"center_lng"`
+	Area      json.RawMessage `db:"area" json:"area"`
+	IsActive  bool            `db:"is_active" json:"is_active"`
+	CreatedAt time.Time       `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time       `db:"updated_at" json:"updated_at"`
+}
+
+const deliveryZoneColumns = `id, store_id, name, zone_type, radius_km,
+	ST_Y(center::geometry) AS center_lat, ST_X(center::geometry) AS center_lng,
+	ST_AsGeoJSON(area)::json AS area, is_active, created_at, updated_at`
+
+// DeliveryZoneInput describes a zone to create
+// Radius zones use RadiusKm around the center, or the store's location when no center is
+// given. Polygon zones use Polygon, a ring of [lng, lat] points as in GeoJSON
+type DeliveryZoneInput struct {
+	Name      string
+	ZoneType  string
+	RadiusKm  float64
+	CenterLat *float64
+	CenterLng *float64
+	Polygon   [][2]float64
+}
+
+// DeliveryCoverage says whether a store delivers to a point, and through which zone
+type DeliveryCoverage struct {
+	StoreID    string  `json:"store_id"`
+	DeliversTo bool    `json:"delivers_to"`
+	ZoneID     *string `json:"zone_id"`
+	ZoneName   *string `json:"zone_name"`
+}
+
+// ServingStore is a store delivering to the search point, with the zone covering it
+type ServingStore struct {
+	Store
+	DistanceKm *float64 `db:"distance_km" json:"distance_km"`
+	ZoneID     string   `db:"zone_id" json:"zone_id"`
+	ZoneName   string   `db:"zone_name" json:"zone_name"`
+}
+
+// ServingStoresQuery describes a search for stores delivering to a point
+type ServingStoresQuery struct {
+	Lat       float64
+	Lng       float64
+	StoreType string // Optional
+	Limit     int
+}
+
+// ListDeliveryZones retrieves a store's delivery zones, oldest first
+func (r *PostgresRepository) ListDeliveryZones(ctx context.Context, storeID string) ([]DeliveryZone, error) {
+	zones, err := queryRows(ctx, r, pgx.RowToStructByName[DeliveryZone], `
+		SELECT `+deliveryZoneColumns+`
+		FROM delivery_zones
+		WHERE store_id = $1
+		ORDER BY created_at, id
+	`, storeID)
+	if err != nil {
+		r.log(ctx).Error("Failed to list delivery zones", zap.String("store_id", storeID), zap.Error(err))
+		return nil, NewQueryError(err)
+	}
+	return zones, nil
+}
+
+// CreateDeliveryZone adds a delivery zone to a store
+// Returns a validation error for a self-intersecting polygon, or for a radius zone
+// without a center when the store has no location
+func (r *PostgresRepository) CreateDeliveryZone(ctx context.Context, storeID string, input DeliveryZoneInput) (*DeliveryZone, error) {
+	var hasLocation bool
+	err := r.retry(ctx, "query", func() error {
+		return r.conn().QueryRow(ctx, `SELECT location IS NOT NULL FROM stores WHERE id = $1`, storeID).Scan(&hasLocation)
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, NewNotFoundError("stores", storeID)
+	}
+	if err != nil {
+		r.log(ctx).Error("Failed to get store location", zap.String("store_id", storeID), zap.Error(err))
+		return nil, NewQueryError(err)
+	}
+
+	var query string
+	var args []interface{}
+	switch input.ZoneType {
+	case DeliveryZoneRadius:
+		if input.CenterLat == nil && !hasLocation {
+			return nil, NewValidationError("store has no location; give the zone a center")
+		}
+		query = `
+			INSERT INTO delivery_zones (store_id, name, zone_type, radius_km, center, area)
+			SELECT s.id, $2, $3, $4, c.point, ST_Buffer(c.point, $4 * 1000)
+			FROM stores s
+			CROSS JOIN LATERAL (
+				SELECT COALESCE(ST_SetSRID(ST_MakePoint($6, $5), 4326)::geography, s.location) AS point
+			) c
+			WHERE s.id = $1
+			RETURNING ` + deliveryZoneColumns
+		args = []interface{}{storeID, input.Name, input.ZoneType, input.RadiusKm, input.CenterLat, input.CenterLng}
+	case DeliveryZonePolygon:
+		ring := input.Polygon
+		if ring[0] != ring[len(ring)-1] {
+			ring = append(ring, ring[0])
+		}
+		geoJSON, err := json.Marshal(map[string]any{"type": "Polygon", "coordinates": [][][2]float64{ring}})
+		if err != nil {
+			return nil, err
+		}
+
+		var valid bool
+		if err := r.reader().QueryRow(ctx, `SELECT ST_IsValid(ST_GeomFromGeoJSON($1))`, string(geoJSON)).Scan(&valid); err != nil {
+			return nil, NewQueryError(err)
+		}
+		if !valid {
+			return nil, NewValidationError("polygon must not intersect itself")
+		}
+
+		query = `
+			INSERT INTO delivery_zones (store_id, name, zone_type, area)
+			VALUES ($1, $2, $3, ST_SetSRID(ST_GeomFromGeoJSON($4), 4326)::geography)
+			RETURNING ` + deliveryZoneColumns
+		args = []interface{}{storeID, input.Name, input.ZoneType, string(geoJSON)}
+	default:
+		return nil, fmt.Errorf("unsupported delivery zone type %q", input.ZoneType)
+	}
+
+	var zone *DeliveryZone
+	err = r.retry(ctx, AuditDeliveryZone, func() error {
+		rows, _ := r.conn().Query(ctx, query, args...)
+		zone, err = pgx.CollectExactlyOneRow(rows, pgx.RowToAddrOfStructByName[DeliveryZone])
+		return err
+	})
+	if err != nil {
+		r.log(ctx).Error("Failed to create delivery zone", zap.String("store_id", storeID), zap.Error(err))
+		return nil, NewQueryError(err)
+	}
+
+	r.recordAudit(ctx, AuditDeliveryZone, []string{storeID, zone.ID}, nil, zone)
+
+	r.log(ctx).Info("Created delivery zone",
+		zap.String("store_id", storeID),
+		zap.String("zone_id", zone.ID),
+		zap.String("zone_type", zone.ZoneType))
+
+	return zone, nil
+}
+
+// DeleteDeliveryZone removes one of a store's delivery zones
+func (r *PostgresRepository) DeleteDeliveryZone(ctx context.Context, storeID, zoneID string) error {
+	tag, err := r.exec(ctx, `DELETE FROM delivery_zones WHERE id = $1 AND store_id = $2`, zoneID, storeID)
+	if err != nil {
+		r.log(ctx).Error("Failed to delete delivery zone", zap.String("zone_id", zoneID), zap.Error(err))
+		return NewQueryError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return NewNotFoundError("delivery_zones", zoneID)
+	}
+
+	r.recordAudit(ctx, AuditDeliveryZone, []string{storeID, zoneID}, map[string]any{"zone_id": zoneID}, nil)
+	return nil
+}
+
+// CheckDelivery reports whether an active store has an active zone covering a point
+func (r *PostgresRepository) CheckDelivery(ctx context.Context, storeID string, lat, lng float64) (*DeliveryCoverage, error) {
+	coverage := &DeliveryCoverage{StoreID: storeID}
+	var isActive bool
+	err := r.retry(ctx, "query", func() error {
+		return r.reader().QueryRow(ctx, `
+			SELECT s.is_active, z.id::text, z.name
+			FROM stores s
+			LEFT JOIN LATERAL (
+				SELECT id, name
+				FROM delivery_zones
+				WHERE store_id = s.id AND is_active = true
+				  AND ST_Covers(area, ST_SetSRID(ST_MakePoint($2, $3), 4326)::geography)
+				ORDER BY created_at, id
+				LIMIT 1
+			) z ON true
+			WHERE s.id = $1
+		`, storeID, lng, lat).Scan(&isActive, &coverage.ZoneID, &coverage.ZoneName)
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, NewNotFoundError("stores", storeID)
+	}
+	if err != nil {
+		r.log(ctx).Error("Failed to check delivery coverage", zap.String("store_id", storeID), zap.Error(err))
+		return nil, NewQueryError(err)
+	}
+
+	coverage.DeliversTo = isActive && coverage.ZoneID != nil
+	return coverage, nil
+}
+
+// FindServingStores retrieves active stores with an active zone covering a point, nearest first
+func (r *PostgresRepository) FindServingStores(ctx context.Context, q ServingStoresQuery) ([]ServingStore, error) {
+	query := `
+		WITH origin AS (
+			SELECT ST_SetSRID(ST_MakePoint($1, $2), 4326)::geography AS point
+		)
+		SELECT ` + storeColumns + `,
+		       ST_Distance(stores.location, origin.point) / 1000 AS distance_km,
+		       zone.zone_id, zone.zone_name
+		FROM stores
+		CROSS JOIN origin
+		CROSS JOIN LATERAL (
+			SELECT z.id::text AS zone_id, z.name AS zone_name
+			FROM delivery_zones z
+			WHERE z.store_id = stores.id AND z.is_active = true
+			  AND ST_Covers(z.area, origin.point)
+			ORDER BY z.created_at, z.id
+			LIMIT 1
+		) zone
+		WHERE stores.is_active = true
+	`
+	args := []interface{}{q.Lng, q.Lat}
+	argCount := 3
+
+	if q.StoreType != "" {
+		query += fmt.Sprintf(" AND stores.store_type = $%d", argCount)
+		args = append(args, q.StoreType)
+		argCount++
+	}
+
+	query += fmt.Sprintf(" ORDER BY distance_km NULLS LAST, stores.id LIMIT $%d", argCount)
+	args = append(args, q.Limit)
+
+	stores, err := queryRows(ctx, r, pgx.RowToStructByName[ServingStore], query, args...)
+	if err != nil {
+		r.log(ctx).Error("Failed to query serving stores", zap.Error(err))
+		return nil, NewQueryError(err)
+	}
+
+	return stores, nil
+}
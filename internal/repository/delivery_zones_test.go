@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestDeliveryZones(t *testing.T) {
+	r := testPostgres(t)
+	ctx := context.Background()
+	storeUUID := seedStore(t, r, "TEST-ZONES")
+	store := testStore("TEST-ZONES")
+	lat, lng := store.Location.Lat, store.Location.Lng
+
+	// A radius zone around the store's location, and a polygon far from it
+	radius, err := r.CreateDeliveryZone(ctx, storeUUID, DeliveryZoneInput{Name: "Nearby", ZoneType: DeliveryZoneRadius, RadiusKm: 2})
+	if err != nil {
+		t.Fatalf("CreateDeliveryZone() radius error = %v", err)
+	}
+	if radius.CenterLat == nil || *radius.CenterLat != lat || radius.CenterLng == nil || *radius.CenterLng != lng {
+		t.Errorf("CreateDeliveryZone() center = %v, %v, want the store's location", radius.CenterLat, radius.CenterLng)
+	}
+	polygon, err := r.CreateDeliveryZone(ctx, storeUUID, DeliveryZoneInput{
+		Name:     "Airport",
+		ZoneType: DeliveryZonePolygon,
+		Polygon:  [][2]float64{{77.70, 13.19}, {77.72, 13.19}, {77.72, 13.21}, {77.70, 13.21}},
+	})
+	if err != nil {
+		t.Fatalf("CreateDeliveryZone() polygon error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		lat, lng float64
+		wantZone string
+	}{
+		{name: "at the store", lat: lat, lng: lng, wantZone: radius.ID},
+		{name: "1km north", lat: lat + 0.009, lng: lng, wantZone: radius.ID},
+		{name: "inside the polygon", lat: 13.20, lng: 77.71, wantZone: polygon.ID},
+		{name: "outside both", lat: lat + 0.1, lng: lng},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			coverage, err := r.CheckDelivery(ctx, storeUUID, tt.lat, tt.lng)
+			if err != nil {
+				t.Fatalf("CheckDelivery() error = %v", err)
+			}
+			if coverage.DeliversTo != (tt.wantZone != "") || (tt.wantZone != "" && *coverage.ZoneID != tt.wantZone) {
+				t.Errorf("CheckDelivery() = %+v, want zone %q", coverage, tt.wantZone)
+			}
+
+			serving, err := r.FindServingStores(ctx, ServingStoresQuery{Lat: tt.lat, Lng: tt.lng, Limit: 100})
+			if err != nil {
+				t.Fatalf("FindServingStores() error = %v", err)
+			}
+			var found *ServingStore
+			for i := range serving {
+				if serving[i].ID == storeUUID {
+					found = &serving[i]
+				}
+			}
+			if (found != nil) != (tt.wantZone != "") || (found != nil && found.ZoneID != tt.wantZone) {
+				t.Errorf("FindServingStores() found the store = %+v, want zone %q", found, tt.wantZone)
+			}
+		})
+	}
+
+	if zones, err := r.ListDeliveryZones(ctx, storeUUID); err != nil || len(zones) != 2 || zones[0].ID != radius.ID {
+		t.Errorf("ListDeliveryZones() = %+v, %v, want both zones, oldest first", zones, err)
+	}
+
+	if err := r.DeleteDeliveryZone(ctx, storeUUID, radius.ID); err != nil {
+		t.Fatalf("DeleteDeliveryZone() error = %v", err)
+	}
+	if coverage, err := r.CheckDelivery(ctx, storeUUID, lat, lng); err != nil || coverage.DeliversTo {
+		t.Errorf("CheckDelivery() after deleting the zone = %+v, %v, want no delivery", coverage, err)
+	}
+	if err := r.DeleteDeliveryZone(ctx, storeUUID, radius.ID); GetStatusCode(err) != http.StatusNotFound {
+		t.Errorf("DeleteDeliveryZone() of a deleted zone error = %v, want not found", err)
+	}
+}
+
+func TestCreateDeliveryZone_Validation(t *testing.T) {
+	r := testPostgres(t)
+	ctx := context.Background()
+	storeUUID := seedStore(t, r, "TEST-ZONES")
+
+	tests := []struct {
+		name       string
+		storeID    string
+		input      DeliveryZoneInput
+		wantStatus int
+	}{
+		{
+			name:       "self-intersecting polygon",
+			storeID:    storeUUID,
+			input:      DeliveryZoneInput{Name: "Bowtie", ZoneType: DeliveryZonePolygon, Polygon: [][2]float64{{77.5, 12.9}, {77.6, 13.0}, {77.6, 12.9}, {77.5, 13.0}}},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "unknown store",
+			storeID:    "00000000-0000-0000-0000-000000000001",
+			input:      DeliveryZoneInput{Name: "Nearby", ZoneType: DeliveryZoneRadius, RadiusKm: 2},
+			wantStatus: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := r.CreateDeliveryZone(ctx, tt.storeID, tt.input)
+			if got := GetStatusCode(err); got != tt.wantStatus {
+				t.Errorf("CreateDeliveryZone() error = %v (status %d), want status %d", err, got, tt.wantStatus)
+			}
+		})
+	}
+	if _, err := r.CheckDelivery(ctx, "00000000-0000-0000-0000-000000000001", 12.97, 77.59); GetStatusCode(err) != http.StatusNotFound {
+		t.Errorf("CheckDelivery() of an unknown store error = %v, want not found", err)
+	}
+}
@@ -1,9 +1,12 @@
 package repository
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
+
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
 // RepositoryError represents a repository-level error with HTTP status code
@@ -57,6 +60,34 @@ func NewNotFoundError(table, id string) *RepositoryError {
 	}
 }
 
+func NewConflictError(message string, err error) *RepositoryError {
+	return &RepositoryError{
+		StatusCode: http.StatusConflict,
+		Message:    message,
+		Err:        err,
+	}
+}
+
+func NewValidationError(message string) *RepositoryError {
+	return &RepositoryError{
+		StatusCode: http.StatusBadRequest,
+		Message:    message,
+		Err:        nil,
+	}
+}
+
+// FieldError is a problem with one field of a request: Field is its JSON path, such as
+// products[3].price, and Rule the validation rule it broke (required, min, oneof...)
+// Field is empty for problems with the request as a whole
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+	// MessageKey and MessageParams, if set, translate Message into other languages
+	MessageKey    string         `json:"-"`
+	MessageParams map[string]any `json:"-"`
+}
+
 // IsRepositoryError checks if an error is a RepositoryError
 func IsRepositoryError(err error) bool {
 	var repoErr *RepositoryError
@@ -71,3 +102,24 @@ func GetStatusCode(err error) int {
 	}
 	return http.StatusInternalServerError
 }
+
+// IsUnavailable reports whether err means Supabase or Postgres couldn't be reached or
+// didn't answer in time, rather than rejecting the request: a connection or timeout error,
+// the open Supabase circuit breaker, or a Postgres connection failure
+func IsUnavailable(err error) bool {
+	if err == nil {
+		return false
+	}
+	switch GetStatusCode(err) {
+	case http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		// cannot_connect_now and connection_exception
+		return pgErr.Code == "57P03" || (len(pgErr.Code) == 5 && pgErr.Code[:2] == "08")
+	}
+	var connectErr *pgconn.ConnectError
+	return errors.As(err, &connectErr) || pgconn.Timeout(err) || errors.Is(err, context.DeadlineExceeded)
+}
@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.uber.org/zap"
+)
+
+// CatalogChangesChannel is the NOTIFY channel raised by the catalog change triggers
+const CatalogChangesChannel = "catalog_changes"
+
+// Reconnect delays for the change listener after its connection fails
+const (
+	listenerMinBackoff = time.Second
+	listenerMaxBackoff = 30 * time.Second
+)
+
+// CatalogChange is a write to a catalog table reported through NOTIFY
+// StoreID is the internal store UUID and is only set for store_products changes.
+// A CatalogChange with no table means notifications may have been missed while the
+// listener was reconnecting, so everything derived from the catalog should be treated as stale
+type CatalogChange struct {
+	Table   string `json:"table"`
+	StoreID string `json:"store_id"`
+	Origin  string `json:"origin"` // application_name of the session that made the change
+
+	// External is set when the change may have been made by another application: its
+	// origin isn't this service's, or isn't known
+	External bool `json:"-"`
+}
+
+// ChangeListener holds a dedicated connection LISTENing on CatalogChangesChannel and
+// passes each notification to its handler, reconnecting with backoff if the connection drops
+type ChangeListener struct {
+	repo   *PostgresRepository
+	handle func(ctx context.Context, change CatalogChange)
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewChangeListener creates a listener that calls handle for every catalog change
+// handle runs on the listener's goroutine; call Start to run it
+func (r *PostgresRepository) NewChangeListener(handle func(ctx context.Context, change CatalogChange)) *ChangeListener {
+	return &ChangeListener{
+		repo:   r,
+		handle: handle,
+		done:   make(chan struct{}),
+	}
+}
+
+// Start listens in the background until Stop is called
+func (l *ChangeListener) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	l.cancel = cancel
+
+	go func() {
+		defer close(l.done)
+
+		logger := l.repo.logger
+		backoff := listenerMinBackoff
+		reconnecting := false
+		for {
+			connected, err := l.listen(ctx, reconnecting)
+			if ctx.Err() != nil {
+				return
+			}
+			if connected {
+				backoff = listenerMinBackoff
+				reconnecting = true
+			}
+
+			logger.Warn("Catalog change listener disconnected",
+				zap.Error(err),
+				zap.Duration("retry_in", backoff))
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			backoff = min(2*backoff, listenerMaxBackoff)
+		}
+	}()
+}
+
+// Stop closes the listener's connection and waits for its goroutine to exit
+func (l *ChangeListener) Stop() {
+	l.cancel()
+	<-l.done
+}
+
+// listen runs one LISTEN session and returns when its connection fails
+// connected reports whether LISTEN succeeded. On a reconnect an external CatalogChange
+// with no table is handled once listening, since anything written while disconnected was not seen
+func (l *ChangeListener) listen(ctx context.Context, reconnecting bool) (connected bool, err error) {
+	conn, err := l.repo.pool.Acquire(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	// The connection is taken out of the pool for good so a LISTENing session is never reused
+	listenConn := conn.Hijack()
+	defer listenConn.Close(context.Background())
+
+	if _, err := listenConn.Exec(ctx, "LISTEN "+CatalogChangesChannel); err != nil {
+		return false, fmt.Errorf("failed to listen on %s: %w", CatalogChangesChannel, err)
+	}
+
+	l.repo.logger.Info("Listening for catalog changes", zap.String("channel", CatalogChangesChannel))
+	if reconnecting {
+		l.handle(ctx, CatalogChange{External: true})
+	}
+
+	for {
+		notification, err := listenConn.WaitForNotification(ctx)
+		if err != nil {
+			return true, err
+		}
+		l.dispatch(ctx, notification)
+	}
+}
+
+// dispatch decodes a notification and passes it to the handler
+func (l *ChangeListener) dispatch(ctx context.Context, notification *pgconn.Notification) {
+	var change CatalogChange
+	if err := json.Unmarshal([]byte(notification.Payload), &change); err != nil || change.Table == "" {
+		l.repo.logger.Warn("Ignoring malformed catalog change notification",
+			zap.String("payload", notification.Payload),
+			zap.Error(err))
+		return
+	}
+	change.External = change.Origin == "" || change.Origin != l.repo.applicationName
+
+	// The change committed on the primary, perhaps not yet on the replica the handler's
+	// cache refills would read
+	if l.repo.replica != nil {
+		l.repo.replica.noteWrite()
+	}
+	l.handle(ctx, change)
+}
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.uber.org/zap"
+)
+
+func TestChangeListenerDispatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		want    *CatalogChange
+	}{
+		{
+			name:    "own write",
+			payload: `{"table":"store_products","store_id":"s1","origin":"` + applicationName + `"}`,
+			want:    &CatalogChange{Table: "store_products", StoreID: "s1", Origin: applicationName},
+		},
+		{
+			name:    "other application",
+			payload: `{"table":"products","origin":"psql"}`,
+			want:    &CatalogChange{Table: "products", Origin: "psql", External: true},
+		},
+		{
+			name:    "no origin",
+			payload: `{"table":"store_products","store_id":"s1"}`,
+			want:    &CatalogChange{Table: "store_products", StoreID: "s1", External: true},
+		},
+		{name: "no table", payload: `{"origin":"psql"}`},
+		{name: "malformed", payload: `not json`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *CatalogChange
+			repo := &PostgresRepository{logger: zap.NewNop(), applicationName: applicationName}
+			listener := repo.NewChangeListener(func(ctx context.Context, change CatalogChange) {
+				got = &change
+			})
+
+			listener.dispatch(context.Background(), &pgconn.Notification{Payload: tt.payload})
+
+			switch {
+			case tt.want == nil && got != nil:
+				t.Errorf("dispatch() handled %+v, want it ignored", *got)
+			case tt.want != nil && got == nil:
+				t.Errorf("dispatch() ignored the notification, want %+v", *tt.want)
+			case tt.want != nil && *got != *tt.want:
+				t.Errorf("dispatch() handled %+v, want %+v", *got, *tt.want)
+			}
+		})
+	}
+}
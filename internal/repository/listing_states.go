@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+// ListingState is the stock, price and availability of a store listing and its
+// variations, as real-time store events report them after a write
+type ListingState struct {
+	StoreProductID string      `db:"store_product_id" json:"store_product_id"`
+	ProductID      string      `db:"product_id" json:"product_id"`
+	ExternalID     *string     `db:"external_id" json:"external_id"`
+	Price          float64     `db:"price" json:"price"`
+	SalePrice      *float64    `db:"sale_price" json:"sale_price"`
+	StockQuantity  float64     `db:"stock_quantity" json:"stock_quantity"`
+	IsInStock      bool        `db:"is_in_stock" json:"is_in_stock"`
+	IsAvailable    bool        `db:"is_available" json:"is_available"`
+	UpdatedAt      time.Time   `db:"updated_at" json:"updated_at"`
+	Variations     []Variation `db:"-" json:"variations"`
+}
+
+// ListListingStates retrieves the current state of the listings of store storeID (its
+// UUID) with the given ERP external IDs, whether or not they are available, with all of
+// their variations, active or not. IDs without a listing are skipped
+func (r *PostgresRepository) ListListingStates(ctx context.Context, storeID string, externalIDs []string) ([]ListingState, error) {
+	if len(externalIDs) == 0 {
+		return []ListingState{}, nil
+	}
+
+	states, err := queryRows(ctx, r, pgx.RowToStructByName[ListingState], `
+		SELECT id AS store_product_id, product_id, external_id, price, sale_price,
+		       stock_quantity, is_in_stock, is_available, updated_at
+		FROM store_products
+		WHERE store_id = $1::uuid AND external_id = ANY($2)
+		ORDER BY external_id
+	`, storeID, externalIDs)
+	if err != nil {
+		r.log(ctx).Error("Failed to query listing states", zap.String("store_id", storeID), zap.Int("ids", len(externalIDs)), zap.Error(err))
+		return nil, NewQueryError(err)
+	}
+	if len(states) == 0 {
+		return states, nil
+	}
+
+	ids := make([]string, len(states))
+	byID := make(map[string]*ListingState, len(states))
+	for i := range states {
+		states[i].Variations = []Variation{}
+		ids[i] = states[i].StoreProductID
+		byID[ids[i]] = &states[i]
+	}
+
+	variations, err := queryRows(ctx, r, pgx.RowToStructByName[Variation], `
+		SELECT `+variationColumns+`
+		FROM product_variations
+		WHERE store_product_id = ANY($1::uuid[])
+		ORDER BY store_product_id, display_order, name
+	`, ids)
+	if err != nil {
+		r.log(ctx).Error("Failed to query listing state variations", zap.String("store_id", storeID), zap.Error(err))
+		return nil, NewQueryError(err)
+	}
+	for _, v := range variations {
+		state := byID[v.StoreProductID]
+		state.Variations = append(state.Variations, v)
+	}
+
+	return states, nil
+}
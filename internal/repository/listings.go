@@ -0,0 +1,345 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+// StoreListing is a product as listed in a store:
+// store-specific price and stock joined with the catalog product, category and brand
+type StoreListing struct {
+	StoreProductID  string    `db:"store_product_id" json:"id"`
+	StoreID         string    `db:"store_id" json:"store_id"`
+	StoreName       string    `db:"store_name" json:"store_name"`
+	ProductID       string    `db:"product_id" json:"product_id"`
+	SKU             string    `db:"sku" json:"sku"`
+	Name            string    `db:"name" json:"name"`
+	Slug            string    `db:"slug" json:"slug"`
+	Description     *string   `db:"description" json:"description"`
+	Unit            *string   `db:"unit" json:"unit"`
+	UnitQuantity    *float64  `db:"unit_quantity" json:"unit_quantity"`
+	PrimaryImageURL *string   `db:"primary_image_url" json:"primary_image_url"`
+	Price           float64   `db:"price" json:"price"`
+	SalePrice       *float64  `db:"sale_price" json:"sale_price"`
+	Currency        *string   `db:"currency" json:"currency"`
+	StockQuantity   float64   `db:"stock_quantity" json:"stock_quantity"`
+	IsInStock       bool      `db:"is_in_stock" json:"is_in_stock"`
+	CategoryID      *string   `db:"category_id" json:"category_id"`
+	CategoryName    *string   `db:"category_name" json:"category_name"`
+	BrandID         *string   `db:"brand_id" json:"brand_id"`
+	BrandName       *string   `db:"brand_name" json:"brand_name"`
+	CreatedAt       time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt       time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// Cursor returns the keyset position just after this listing
+func (l StoreListing) Cursor() Cursor {
+	return Cursor{CreatedAt: l.CreatedAt, ID: l.StoreProductID}
+}
+
+// StoreListingDetail is a store listing with its variations
+type StoreListingDetail struct {
+	StoreListing
+	Variations []Variation `json:"variations"`
+}
+
+// ListingFilter narrows a store listing query; zero values are ignored
+type ListingFilter struct {
+	StoreID              string
+	CategoryID           string
+	BrandID              string
+	Search               string
+	InStockOnly          bool
+	FeaturedOnly         bool
+	MinPrice             *float64
+	MaxPrice             *float64
+	RequiresPrescription *bool // Pharmacy listings only
+}
+
+const storeListingColumns = `
+	sp.id AS store_product_id, sp.store_id, s.name AS store_name,
+	p.id AS product_id, p.sku, p.name, p.slug, p.description,
+	p.unit, p.unit_quantity, p.primary_image_url,
+	sp.price, sp.sale_price, p.currency, sp.stock_quantity, sp.is_in_stock,
+	c.id AS category_id, c.name AS category_name,
+	b.id AS brand_id, b.name AS brand_name,
+	sp.created_at, sp.updated_at`
+
+// storeListingJoins joins listings to their product, store, category and brand
+const storeListingJoins = `
+	FROM store_products sp
+	JOIN products p ON p.id = sp.product_id
+	JOIN stores s ON s.id = sp.store_id
+	LEFT JOIN categories c ON c.id = p.category_id
+	LEFT JOIN brands b ON b.id = p.brand_id
+`
+
+// storeListingVisible keeps only available, active listings in active stores
+const storeListingVisible = `
+	  s.is_active = true
+	  AND sp.is_available = true
+	  AND p.is_active = true
+`
+
+// storeListingFrom restricts visible listings to stores of the type bound to $1
+const storeListingFrom = storeListingJoins + `
+	WHERE s.store_type = $1 AND` + storeListingVisible
+
+// ListSupermarketProducts retrieves products listed in active supermarket stores
+func (r *PostgresRepository) ListSupermarketProducts(ctx context.Context, filter ListingFilter, pagination Pagination) ([]StoreListing, error) {
+	query := `SELECT ` + storeListingColumns + storeListingFrom
+	query, args := appendListingFilters(query, []interface{}{"supermarket"}, filter, pagination)
+
+	products, err := queryRows(ctx, r, pgx.RowToStructByName[StoreListing], query, args...)
+	if err != nil {
+		r.log(ctx).Error("Failed to query supermarket products", zap.Error(err))
+		return nil, NewQueryError(err)
+	}
+
+	return products, nil
+}
+
+// GetSupermarketProduct retrieves a single supermarket listing (by store product ID) with its variations
+func (r *PostgresRepository) GetSupermarketProduct(ctx context.Context, storeProductID string) (*StoreListingDetail, error) {
+	query := `SELECT ` + storeListingColumns + storeListingFrom + ` AND sp.id = $2`
+
+	product, err := queryRow(ctx, r, pgx.RowToStructByName[StoreListing], query, "supermarket", storeProductID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, NewNotFoundError("store_products", storeProductID)
+	}
+	if err != nil {
+		r.log(ctx).Error("Failed to get supermarket product", zap.String("id", storeProductID), zap.Error(err))
+		return nil, NewQueryError(err)
+	}
+
+	variations, err := r.ListVariations(ctx, storeProductID)
+	if err != nil {
+		return nil, NewQueryError(err)
+	}
+
+	return &StoreListingDetail{
+		StoreListing: product,
+		Variations:   variations,
+	}, nil
+}
+
+// CountSupermarketProducts counts supermarket listings matching filter, ignoring pagination
+func (r *PostgresRepository) CountSupermarketProducts(ctx context.Context, filter ListingFilter) (int64, error) {
+	return r.countListings(ctx, "supermarket", filter)
+}
+
+// countListings counts visible listings in stores of storeType that match filter
+func (r *PostgresRepository) countListings(ctx context.Context, storeType string, filter ListingFilter) (int64, error) {
+	query, args := appendListingConditions(`SELECT count(*)`+storeListingFrom, []interface{}{storeType}, filter)
+
+	var total int64
+	err := r.retry(ctx, "count listings", func() error {
+		return r.reader().QueryRow(ctx, query, args...).Scan(&total)
+	})
+	if err != nil {
+		r.log(ctx).Error("Failed to count listings", zap.String("store_type", storeType), zap.Error(err))
+		return 0, NewQueryError(err)
+	}
+
+	return total, nil
+}
+
+// appendListingFilters adds filter conditions, ordering and pagination to a listing query
+func appendListingFilters(query string, args []interface{}, filter ListingFilter, pagination Pagination) (string, []interface{}) {
+	query, args = appendListingConditions(query, args, filter)
+
+	// Order by name with the id as tie-breaker so pages are stable
+	if !pagination.KeysetOrder() {
+		return appendOrderAndPage(query, args, "p.name, sp.id", pagination)
+	}
+
+	// Cursor pages run newest first with the id as tie-breaker, the order the cursor follows
+	if pagination.After != nil {
+		query += fmt.Sprintf(" AND (sp.created_at, sp.id) < ($%d::timestamptz, $%d::uuid)", len(args)+1, len(args)+2)
+		args = append(args, pagination.After.CreatedAt, pagination.After.ID)
+	}
+	pagination.Offset = 0
+	return appendOrderAndPage(query, args, "sp.created_at DESC, sp.id DESC", pagination)
+}
+
+// appendListingConditions adds an AND condition for each set filter field
+func appendListingConditions(query string, args []interface{}, filter ListingFilter) (string, []interface{}) {
+	argCount := len(args) + 1
+
+	if filter.StoreID != "" {
+		query += fmt.Sprintf(" AND sp.store_id = $%d", argCount)
+		args = append(args, filter.StoreID)
+		argCount++
+	}
+
+	if filter.CategoryID != "" {
+		query += fmt.Sprintf(" AND p.category_id = $%d", argCount)
+		args = append(args, filter.CategoryID)
+		argCount++
+	}
+
+	if filter.BrandID != "" {
+		query += fmt.Sprintf(" AND p.brand_id = $%d", argCount)
+		args = append(args, filter.BrandID)
+		argCount++
+	}
+
+	if filter.Search != "" {
+		query += fmt.Sprintf(" AND p.name ILIKE $%d", argCount)
+		args = append(args, "%"+filter.Search+"%")
+		argCount++
+	}
+
+	if filter.InStockOnly {
+		query += " AND sp.is_in_stock = true"
+	}
+
+	if filter.FeaturedOnly {
+		query += " AND sp.is_featured = true"
+	}
+
+	if filter.MinPrice != nil {
+		query += fmt.Sprintf(" AND sp.price >= $%d", argCount)
+		args = append(args, *filter.MinPrice)
+		argCount++
+	}
+
+	if filter.MaxPrice != nil {
+		query += fmt.Sprintf(" AND sp.price <= $%d", argCount)
+		args = append(args, *filter.MaxPrice)
+		argCount++
+	}
+
+	if filter.RequiresPrescription != nil {
+		query += fmt.Sprintf(" AND p.requires_prescription = $%d", argCount)
+		args = append(args, *filter.RequiresPrescription)
+	}
+
+	return query, args
+}
+
+// appendOrderAndPage adds ORDER BY and LIMIT/OFFSET to a query
+func appendOrderAndPage(query string, args []interface{}, orderBy string, pagination Pagination) (string, []interface{}) {
+	argCount := len(args) + 1
+	query += " ORDER BY " + orderBy
+	query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", argCount, argCount+1)
+	args = append(args, pagination.Limit, pagination.Offset)
+
+	return query, args
+}
+
+// AppliedTax is a tax applied to a store product, with any store-specific rate override resolved
+type AppliedTax struct {
+	StoreProductID string  `db:"store_product_id" json:"-"`
+	ID             string  `db:"id" json:"id"`
+	TaxID          string  `db:"tax_id" json:"tax_id"`
+	Name           string  `db:"name" json:"name"`
+	Rate           float64 `db:"rate" json:"rate"`
+	TaxType        string  `db:"tax_type" json:"tax_type"`
+	IsInclusive    bool    `db:"is_inclusive" json:"is_inclusive"`
+}
+
+// StoreProductListing is a product in one store's catalog with its variations and applied taxes
+type StoreProductListing struct {
+	StoreListing
+	Variations []Variation  `db:"-" json:"variations"`
+	Taxes      []AppliedTax `db:"-" json:"taxes"`
+}
+
+// storeCatalogFrom restricts visible listings to the store bound to $1
+const storeCatalogFrom = storeListingJoins + `
+	WHERE sp.store_id = $1 AND` + storeListingVisible
+
+// ListStoreProducts retrieves a store's visible listings with their variations and applied taxes
+// filter.StoreID is ignored. An empty page for a store that doesn't exist or isn't active is a not found error
+func (r *PostgresRepository) ListStoreProducts(ctx context.Context, storeID string, filter ListingFilter, pagination Pagination) ([]StoreProductListing, error) {
+	filter.StoreID = ""
+	query := `SELECT ` + storeListingColumns + storeCatalogFrom
+	query, args := appendListingFilters(query, []interface{}{storeID}, filter, pagination)
+
+	listings, err := queryRows(ctx, r, pgx.RowToStructByName[StoreListing], query, args...)
+	if err != nil {
+		r.log(ctx).Error("Failed to query store products", zap.String("store_id", storeID), zap.Error(err))
+		return nil, NewQueryError(err)
+	}
+
+	if len(listings) == 0 {
+		var active bool
+		err := r.retry(ctx, "store exists", func() error {
+			return r.reader().QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM stores WHERE id = $1 AND is_active = true)`, storeID).Scan(&active)
+		})
+		if err != nil {
+			return nil, NewQueryError(err)
+		}
+		if !active {
+			return nil, NewNotFoundError("stores", storeID)
+		}
+		return []StoreProductListing{}, nil
+	}
+
+	ids := make([]string, len(listings))
+	for i, l := range listings {
+		ids[i] = l.StoreProductID
+	}
+
+	variations, err := queryRows(ctx, r, pgx.RowToStructByName[Variation], `
+		SELECT `+variationColumns+`
+		FROM product_variations
+		WHERE store_product_id = ANY($1::uuid[]) AND is_active = true
+		ORDER BY display_order, name
+	`, ids)
+	if err != nil {
+		r.log(ctx).Error("Failed to query store product variations", zap.String("store_id", storeID), zap.Error(err))
+		return nil, NewQueryError(err)
+	}
+
+	taxes, err := queryRows(ctx, r, pgx.RowToStructByName[AppliedTax], `
+		SELECT spt.store_product_id, t.id, t.tax_id, t.name,
+		       COALESCE(spt.override_rate, t.rate) AS rate, t.tax_type, t.is_inclusive
+		FROM store_product_taxes spt
+		JOIN taxes t ON t.id = spt.tax_id
+		WHERE spt.store_product_id = ANY($1::uuid[]) AND spt.is_active = true AND t.is_active = true
+		ORDER BY t.name
+	`, ids)
+	if err != nil {
+		r.log(ctx).Error("Failed to query store product taxes", zap.String("store_id", storeID), zap.Error(err))
+		return nil, NewQueryError(err)
+	}
+
+	products := make([]StoreProductListing, len(listings))
+	index := make(map[string]*StoreProductListing, len(listings))
+	for i, l := range listings {
+		products[i] = StoreProductListing{StoreListing: l, Variations: []Variation{}, Taxes: []AppliedTax{}}
+		index[l.StoreProductID] = &products[i]
+	}
+	for _, v := range variations {
+		index[v.StoreProductID].Variations = append(index[v.StoreProductID].Variations, v)
+	}
+	for _, t := range taxes {
+		index[t.StoreProductID].Taxes = append(index[t.StoreProductID].Taxes, t)
+	}
+
+	return products, nil
+}
+
+// CountStoreProducts counts a store's visible listings matching filter, ignoring pagination
+func (r *PostgresRepository) CountStoreProducts(ctx context.Context, storeID string, filter ListingFilter) (int64, error) {
+	filter.StoreID = ""
+	query, args := appendListingConditions(`SELECT count(*)`+storeCatalogFrom, []interface{}{storeID}, filter)
+
+	var total int64
+	err := r.retry(ctx, "count store products", func() error {
+		return r.reader().QueryRow(ctx, query, args...).Scan(&total)
+	})
+	if err != nil {
+		r.log(ctx).Error("Failed to count store products", zap.String("store_id", storeID), zap.Error(err))
+		return 0, NewQueryError(err)
+	}
+
+	return total, nil
+}
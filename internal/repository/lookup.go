@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+// Product identifiers accepted by LookupProducts
+const (
+	LookupBarcode = "barcode"
+	LookupSKU     = "sku"
+	LookupEAN     = "ean"
+)
+
+// ProductOffer is a product's price and stock in one store
+type ProductOffer struct {
+	StoreProductID string    `db:"store_product_id" json:"store_product_id"`
+	StoreID        string    `db:"store_id" json:"store_id"`
+	StoreName      string    `db:"store_name" json:"store_name"`
+	StoreType      string    `db:"store_type" json:"store_type"`
+	ProductID      string    `db:"product_id" json:"-"`
+	Price          float64   `db:"price" json:"price"`
+	SalePrice      *float64  `db:"sale_price" json:"sale_price"`
+	StockQuantity  float64   `db:"stock_quantity" json:"stock_quantity"`
+	IsInStock      bool      `db:"is_in_stock" json:"is_in_stock"`
+	UpdatedAt      time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// ProductLookup is a catalog product with its offers in every active store listing it
+type ProductLookup struct {
+	Product
+	BrandName *string        `db:"brand_name" json:"brand_name"`
+	Offers    []ProductOffer `db:"-" json:"offers"`
+}
+
+// LookupProducts finds active products by barcode, SKU or EAN (field is one of the
+// Lookup* constants) along with their offers, cheapest first. A barcode or EAN can be
+// shared by several catalog products, so every match is returned
+func (r *PostgresRepository) LookupProducts(ctx context.Context, field, value string) ([]ProductLookup, error) {
+	var column string
+	switch field {
+	case LookupBarcode, LookupSKU, LookupEAN:
+		column = field
+	default:
+		return nil, fmt.Errorf("unsupported lookup field %q", field)
+	}
+
+	products, err := queryRows(ctx, r, pgx.RowToStructByName[ProductLookup], `
+		SELECT `+productColumns+`,
+		       (SELECT name FROM brands WHERE brands.id = products.brand_id) AS brand_name
+		FROM products
+		WHERE `+column+` = $1 AND is_active = true
+		ORDER BY name, id
+	`, value)
+	if err != nil {
+		r.log(ctx).Error("Failed to look up products", zap.String(field, value), zap.Error(err))
+		return nil, NewQueryError(err)
+	}
+	if len(products) == 0 {
+		return nil, NewNotFoundError("products", value)
+	}
+
+	productIDs := make([]string, len(products))
+	for i, p := range products {
+		productIDs[i] = p.ID
+	}
+
+	offers, err := queryRows(ctx, r, pgx.RowToStructByName[ProductOffer], `
+		SELECT sp.id AS store_product_id, sp.store_id, s.name AS store_name, s.store_type,
+		       sp.product_id, sp.price, sp.sale_price, sp.stock_quantity, sp.is_in_stock, sp.updated_at
+		FROM store_products sp
+		JOIN stores s ON s.id = sp.store_id AND s.is_active = true
+		WHERE sp.product_id = ANY($1::uuid[]) AND sp.is_available = true
+		ORDER BY COALESCE(sp.sale_price, sp.price), s.name
+	`, productIDs)
+	if err != nil {
+		r.log(ctx).Error("Failed to query product offers", zap.String(field, value), zap.Error(err))
+		return nil, NewQueryError(err)
+	}
+
+	byProduct := make(map[string]*ProductLookup, len(products))
+	for i := range products {
+		products[i].Offers = []ProductOffer{}
+		byProduct[products[i].ID] = &products[i]
+	}
+	for _, offer := range offers {
+		if p, ok := byProduct[offer.ProductID]; ok {
+			p.Offers = append(p.Offers, offer)
+		}
+	}
+
+	return products, nil
+}
@@ -0,0 +1,190 @@
+package repository
+
+import "time"
+
+// Typed rows for the catalog tables, scanned with pgx.RowToStructByName
+// Each *Columns constant lists exactly the columns its struct maps, so queries
+// select them verbatim and new fields only need adding in one place. Columns the schema
+// leaves nullable are either read into pointers or, where NULL can only mean the column's
+// zero value, coalesced to it: NULL flags read as false, as the queries filtering on them
+// treat them
+
+// Store is a row from the stores table
+type Store struct {
+	ID                    string     `db:"id" json:"id"`
+	Name                  string     `db:"name" json:"name"`
+	Slug                  string     `db:"slug" json:"slug"`
+	Description           *string    `db:"description" json:"description"`
+	StoreType             string     `db:"store_type" json:"store_type"`
+	Phone                 *string    `db:"phone" json:"phone"`
+	Email                 *string    `db:"email" json:"email"`
+	AddressLine1          string     `db:"address_line1" json:"address_line1"`
+	City                  string     `db:"city" json:"city"`
+	State                 *string    `db:"state" json:"state"`
+	PostalCode            *string    `db:"postal_code" json:"postal_code"`
+	Country               string     `db:"country" json:"country"`
+	Latitude              float64    `db:"latitude" json:"latitude"`
+	Longitude             float64    `db:"longitude" json:"longitude"`
+	Rating                float64    `db:"rating" json:"rating"`
+	TotalRatings          *int       `db:"total_ratings" json:"total_ratings"`
+	MinOrderAmount        float64    `db:"min_order_amount" json:"min_order_amount"`
+	DeliveryFee           float64    `db:"delivery_fee" json:"delivery_fee"`
+	EstimatedDeliveryTime *int       `db:"estimated_delivery_time" json:"estimated_delivery_time"`
+	IsActive              bool       `db:"is_active" json:"is_active"`
+	IsOpen                bool       `db:"is_open" json:"is_open"`
+	CreatedAt             *time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt             *time.Time `db:"updated_at" json:"updated_at"`
+}
+
+const storeColumns = `id, name, slug, description, store_type, phone, email,
+	address_line1, city, state, postal_code, country,
+	latitude, longitude, COALESCE(rating, 0) AS rating, total_ratings,
+	COALESCE(min_order_amount, 0) AS min_order_amount, COALESCE(delivery_fee, 0) AS delivery_fee,
+	estimated_delivery_time, COALESCE(is_active, false) AS is_active,
+	COALESCE(is_open, false) AS is_open, created_at, updated_at`
+
+// StoreDetail is a store with whether its opening hours have it open right now
+type StoreDetail struct {
+	Store
+	Timezone  string `json:"timezone"`
+	IsOpenNow bool   `json:"is_open_now"`
+}
+
+// StoreStatus is the status subset of a stores row
+// IsOpenNow is computed from the store's opening hours, not read from the row
+type StoreStatus struct {
+	ID         string     `db:"id" json:"id"`
+	Name       string     `db:"name" json:"name"`
+	IsActive   bool       `db:"is_active" json:"is_active"`
+	IsOpen     bool       `db:"is_open" json:"is_open"`
+	IsVerified bool       `db:"is_verified" json:"is_verified"`
+	OpenedAt   *string    `db:"opened_at" json:"opened_at"` // TIME, formatted HH:MM:SS
+	ClosedAt   *string    `db:"closed_at" json:"closed_at"`
+	UpdatedAt  *time.Time `db:"updated_at" json:"updated_at"`
+	IsOpenNow  bool       `db:"-" json:"is_open_now"`
+}
+
+const storeStatusColumns = `id, name, COALESCE(is_active, false) AS is_active,
+	COALESCE(is_open, false) AS is_open, COALESCE(is_verified, false) AS is_verified,
+	opened_at::text AS opened_at, closed_at::text AS closed_at, updated_at`
+
+// CategorySummary is an active category with the number of listings available under it
+type CategorySummary struct {
+	ID           string  `db:"id" json:"id"`
+	ParentID     *string `db:"parent_id" json:"parent_id"`
+	Name         string  `db:"name" json:"name"`
+	Slug         string  `db:"slug" json:"slug"`
+	Description  *string `db:"description" json:"description"`
+	IconURL      *string `db:"icon_url" json:"icon_url"`
+	ImageURL     *string `db:"image_url" json:"image_url"`
+	DisplayOrder int     `db:"display_order" json:"display_order"`
+	ProductCount int64   `db:"product_count" json:"product_count"`
+}
+
+// Product is a row from the products table (the store-independent catalog entry)
+type Product struct {
+	ID                   string    `db:"id" json:"id"`
+	SKU                  string    `db:"sku" json:"sku"`
+	Name                 string    `db:"name" json:"name"`
+	Slug                 string    `db:"slug" json:"slug"`
+	Description          *string   `db:"description" json:"description"`
+	CategoryID           *string   `db:"category_id" json:"category_id"`
+	BrandID              *string   `db:"brand_id" json:"brand_id"`
+	BasePrice            float64   `db:"base_price" json:"base_price"`
+	SalePrice            *float64  `db:"sale_price" json:"sale_price"`
+	Currency             *string   `db:"currency" json:"currency"`
+	Unit                 *string   `db:"unit" json:"unit"`
+	UnitQuantity         *float64  `db:"unit_quantity" json:"unit_quantity"`
+	PrimaryImageURL      *string   `db:"primary_image_url" json:"primary_image_url"`
+	Manufacturer         *string   `db:"manufacturer" json:"manufacturer"`
+	Barcode              *string   `db:"barcode" json:"barcode"`
+	EAN                  *string   `db:"ean" json:"ean"`
+	IsActive             bool      `db:"is_active" json:"is_active"`
+	IsFeatured           bool      `db:"is_featured" json:"is_featured"`
+	IsCustomizable       bool      `db:"is_customizable" json:"is_customizable"`
+	IsAddon              bool      `db:"is_addon" json:"is_addon"`
+	RequiresPrescription bool      `db:"requires_prescription" json:"requires_prescription"`
+	CreatedAt            time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt            time.Time `db:"updated_at" json:"updated_at"`
+}
+
+const productColumns = `id, sku, name, slug, description, category_id, brand_id,
+	base_price, sale_price, currency, unit, unit_quantity, primary_image_url,
+	manufacturer, barcode, ean, is_active, is_featured, is_customizable, is_addon,
+	requires_prescription, created_at, updated_at`
+
+// StoreProduct is a row from the store_products table (a product's listing in one store)
+type StoreProduct struct {
+	ID            string     `db:"id" json:"id"`
+	ExternalID    *string    `db:"external_id" json:"external_id"`
+	StoreID       string     `db:"store_id" json:"store_id"`
+	ProductID     string     `db:"product_id" json:"product_id"`
+	Price         float64    `db:"price" json:"price"`
+	SalePrice     *float64   `db:"sale_price" json:"sale_price"`
+	StockQuantity float64    `db:"stock_quantity" json:"stock_quantity"`
+	IsInStock     bool       `db:"is_in_stock" json:"is_in_stock"`
+	IsAvailable   bool       `db:"is_available" json:"is_available"`
+	IsFeatured    bool       `db:"is_featured" json:"is_featured"`
+	CreatedAt     *time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt     *time.Time `db:"updated_at" json:"updated_at"`
+}
+
+const storeProductColumns = `id, external_id, store_id, product_id, price, sale_price,
+	COALESCE(stock_quantity, 0) AS stock_quantity, COALESCE(is_in_stock, false) AS is_in_stock,
+	COALESCE(is_available, false) AS is_available, COALESCE(is_featured, false) AS is_featured,
+	created_at, updated_at`
+
+// Variation is a row from the product_variations table
+type Variation struct {
+	ID             string    `db:"id" json:"id"`
+	ExternalID     *string   `db:"external_id" json:"external_id"`
+	StoreProductID string    `db:"store_product_id" json:"store_product_id"`
+	Name           string    `db:"name" json:"name"`
+	DisplayName    string    `db:"display_name" json:"display_name"`
+	Price          float64   `db:"price" json:"price"`
+	SalePrice      *float64  `db:"sale_price" json:"sale_price"`
+	StockQuantity  *float64  `db:"stock_quantity" json:"stock_quantity"`
+	IsInStock      bool      `db:"is_in_stock" json:"is_in_stock"`
+	DisplayOrder   int       `db:"display_order" json:"display_order"`
+	IsDefault      bool      `db:"is_default" json:"is_default"`
+	IsActive       bool      `db:"is_active" json:"is_active"`
+	CreatedAt      time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time `db:"updated_at" json:"updated_at"`
+}
+
+const variationColumns = `id, external_id, store_product_id, name, display_name, price, sale_price,
+	stock_quantity, is_in_stock, display_order, is_default, is_active, created_at, updated_at`
+
+// Tax is a row from the taxes table
+type Tax struct {
+	ID          string    `db:"id" json:"id"`
+	ExternalID  *string   `db:"external_id" json:"external_id"`
+	StoreID     *string   `db:"store_id" json:"store_id"`
+	Name        string    `db:"name" json:"name"`
+	TaxID       string    `db:"tax_id" json:"tax_id"`
+	Description *string   `db:"description" json:"description"`
+	Rate        float64   `db:"rate" json:"rate"`
+	TaxType     string    `db:"tax_type" json:"tax_type"`
+	IsInclusive bool      `db:"is_inclusive" json:"is_inclusive"`
+	IsActive    bool      `db:"is_active" json:"is_active"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at" json:"updated_at"`
+}
+
+const taxColumns = `id, external_id, store_id, name, tax_id, description, rate,
+	tax_type, is_inclusive, is_active, created_at, updated_at`
+
+// Movie is a row from the movies table
+type Movie struct {
+	ID          int       `db:"id" json:"id"`
+	Title       string    `db:"title" json:"title"`
+	Genre       string    `db:"genre" json:"genre"`
+	Duration    int       `db:"duration" json:"duration"`
+	Rating      float64   `db:"rating" json:"rating"`
+	ReleaseDate time.Time `db:"release_date" json:"release_date"`
+	Description string    `db:"description" json:"description"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at" json:"updated_at"`
+}
+
+const movieColumns = `id, title, genre, duration, rating, release_date, description, created_at, updated_at`
@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// columnNames extracts the result column names from a select list, honouring AS aliases
+func columnNames(columns string) []string {
+	alias := regexp.MustCompile(`(?i)\s+as\s+`)
+	var names []string
+	for _, col := range splitTopLevel(columns) {
+		col = strings.TrimSpace(col)
+		if parts := alias.Split(col, 2); len(parts) == 2 {
+			col = parts[1]
+		} else if i := strings.LastIndex(col, "."); i >= 0 {
+			col = col[i+1:] // table-qualified column
+		}
+		names = append(names, col)
+	}
+	return names
+}
+
+// splitTopLevel splits a select list at the commas outside parentheses, such as those
+// between a COALESCE's arguments
+func splitTopLevel(columns string) []string {
+	var cols []string
+	depth, start := 0, 0
+	for i, r := range columns {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				cols = append(cols, columns[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(cols, columns[start:])
+}
+
+// dbTags lists a struct's db tags, skipping fields tagged db:"-" as RowToStructByName does
+func dbTags(model interface{}) []string {
+	t := reflect.TypeOf(model)
+	var tags []string
+	for i := 0; i < t.NumField(); i++ {
+		if tag := t.Field(i).Tag.Get("db"); tag != "-" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// RowToStructByName fails at runtime on any column/field mismatch, so keep them in lockstep
+func TestModelColumnsMatchStructs(t *testing.T) {
+	tests := []struct {
+		name    string
+		model   interface{}
+		columns string
+	}{
+		{"Store", Store{}, storeColumns},
+		{"StoreStatus", StoreStatus{}, storeStatusColumns},
+		{"Product", Product{}, productColumns},
+		{"StoreProduct", StoreProduct{}, storeProductColumns},
+		{"Variation", Variation{}, variationColumns},
+		{"Tax", Tax{}, taxColumns},
+		{"StoreListing", StoreListing{}, storeListingColumns},
+		{"StoreHours", StoreHours{}, storeHoursColumns},
+		{"StoreHoliday", StoreHoliday{}, storeHolidayColumns},
+		{"DeliveryZone", DeliveryZone{}, deliveryZoneColumns},
+		{"Movie", Movie{}, movieColumns},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := columnNames(tt.columns)
+			want := dbTags(tt.model)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("columns = %v, struct db tags = %v", got, want)
+			}
+		})
+	}
+}
+
+// The schema leaves most of the stores' and listings' columns nullable; rows with NULLs in
+// them must still be read
+func TestModelColumnsReadNulls(t *testing.T) {
+	r := testPostgres(t)
+	ctx := context.Background()
+	storeUUID := seedStore(t, r, "TEST-NULLS")
+	seedProducts(t, r, "TEST-NULLS", PushOptions{}, "NULLS-1")
+	productID := listingProduct(t, r, storeUUID, "NULLS-1")
+
+	_, err := r.conn().Exec(ctx, `
+		UPDATE stores SET rating = NULL, min_order_amount = NULL, delivery_fee = NULL,
+			is_active = NULL, is_open = NULL, is_verified = NULL, created_at = NULL, updated_at = NULL
+		WHERE id = $1
+	`, storeUUID)
+	if err != nil {
+		t.Fatalf("failed to null the store's columns: %v", err)
+	}
+	_, err = r.conn().Exec(ctx, `
+		UPDATE store_products SET stock_quantity = NULL, is_in_stock = NULL, is_available = NULL,
+			is_featured = NULL, created_at = NULL, updated_at = NULL
+		WHERE store_id = $1
+	`, storeUUID)
+	if err != nil {
+		t.Fatalf("failed to null the listing's columns: %v", err)
+	}
+
+	store, err := r.GetStoreByID(ctx, storeUUID)
+	if err != nil {
+		t.Fatalf("GetStoreByID() error = %v", err)
+	}
+	if store.Rating != 0 || store.IsActive || store.IsOpen || store.CreatedAt != nil {
+		t.Errorf("GetStoreByID() = %+v, want NULLs read as zero values", store.Store)
+	}
+	if _, err := r.GetStoreStatus(ctx, storeUUID); err != nil {
+		t.Errorf("GetStoreStatus() error = %v", err)
+	}
+
+	listing, err := r.GetStoreProduct(ctx, storeUUID, productID)
+	if err != nil {
+		t.Fatalf("GetStoreProduct() error = %v", err)
+	}
+	if listing.StockQuantity != 0 || listing.IsInStock || listing.IsAvailable || listing.CreatedAt != nil {
+		t.Errorf("GetStoreProduct() = %+v, want NULLs read as zero values", listing)
+	}
+}
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+// Medicine is a listing in a pharmacy store, surfacing whether it needs a prescription
+type Medicine struct {
+	StoreListing
+	RequiresPrescription bool `db:"requires_prescription" json:"requires_prescription"`
+}
+
+// MedicineDetail is a medicine listing with its variations
+type MedicineDetail struct {
+	Medicine
+	Variations []Variation `json:"variations"`
+}
+
+const medicineColumns = storeListingColumns + `, p.requires_prescription`
+
+// ListMedicines retrieves products listed in active pharmacy stores
+func (r *PostgresRepository) ListMedicines(ctx context.Context, filter ListingFilter, pagination Pagination) ([]Medicine, error) {
+	query := `SELECT ` + medicineColumns + storeListingFrom
+	query, args := appendListingFilters(query, []interface{}{"pharmacy"}, filter, pagination)
+
+	medicines, err := queryRows(ctx, r, pgx.RowToStructByName[Medicine], query, args...)
+	if err != nil {
+		r.log(ctx).Error("Failed to query medicines", zap.Error(err))
+		return nil, NewQueryError(err)
+	}
+
+	return medicines, nil
+}
+
+// CountMedicines counts pharmacy listings matching filter, ignoring pagination
+func (r *PostgresRepository) CountMedicines(ctx context.Context, filter ListingFilter) (int64, error) {
+	return r.countListings(ctx, "pharmacy", filter)
+}
+
+// GetMedicine retrieves a single pharmacy listing (by store product ID) with its variations
+func (r *PostgresRepository) GetMedicine(ctx context.Context, storeProductID string) (*MedicineDetail, error) {
+	query := `SELECT ` + medicineColumns + storeListingFrom + ` AND sp.id = $2`
+
+	medicine, err := queryRow(ctx, r, pgx.RowToStructByName[Medicine], query, "pharmacy", storeProductID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, NewNotFoundError("store_products", storeProductID)
+	}
+	if err != nil {
+		r.log(ctx).Error("Failed to get medicine", zap.String("id", storeProductID), zap.Error(err))
+		return nil, NewQueryError(err)
+	}
+
+	variations, err := r.ListVariations(ctx, storeProductID)
+	if err != nil {
+		return nil, NewQueryError(err)
+	}
+
+	return &MedicineDetail{
+		Medicine:   medicine,
+		Variations: variations,
+	}, nil
+}
+
+// ListPharmacyCategories retrieves active categories that have medicines available in pharmacy stores
+func (r *PostgresRepository) ListPharmacyCategories(ctx context.Context) ([]CategorySummary, error) {
+	return r.listCategoriesForStoreType(ctx, "pharmacy")
+}
+
+// listCategoriesForStoreType retrieves active categories with their available listing counts for a store type
+func (r *PostgresRepository) listCategoriesForStoreType(ctx context.Context, storeType string) ([]CategorySummary, error) {
+	query := `
+		SELECT c.id, c.parent_id, c.name, c.slug, c.description, c.icon_url, c.image_url,
+		       c.display_order, COUNT(sp.id) AS product_count
+		FROM categories c
+		JOIN products p ON p.category_id = c.id AND p.is_active = true
+		JOIN store_products sp ON sp.product_id = p.id AND sp.is_available = true
+		JOIN stores s ON s.id = sp.store_id AND s.is_active = true
+		WHERE c.is_active = true AND s.store_type = $1
+		GROUP BY c.id
+		ORDER BY c.display_order, c.name
+	`
+
+	categories, err := queryRows(ctx, r, pgx.RowToStructByName[CategorySummary], query, storeType)
+	if err != nil {
+		r.log(ctx).Error("Failed to query categories", zap.String("store_type", storeType), zap.Error(err))
+		return nil, NewQueryError(err)
+	}
+
+	return categories, nil
+}
@@ -2,25 +2,91 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strconv"
+	"time"
 
+	"github.com/exaring/otelpgx"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/yourusername/supabase-redis-middleware/internal/logger"
+	"github.com/yourusername/supabase-redis-middleware/internal/tenant"
 	"go.uber.org/zap"
 )
 
 // PostgresRepository handles PostgreSQL database operations
 type PostgresRepository struct {
-	pool   *pgxpool.Pool
-	logger *zap.Logger
+	pool             *pgxpool.Pool
+	poolConfig       PoolConfig
+	replica          *readReplica // Optional; see SetReadReplica
+	applicationName  string       // Of the pool's sessions, as catalog changes report it
+	logger           *zap.Logger
+	fuzzyThreshold   float64
+	pushChunkSize    int
+	retryMaxAttempts int
+	tx               pgx.Tx // Set on the copy WithTx passes to its fn
+}
+
+// PoolConfig sizes a connection pool and bounds how long its statements may run
+// Zero fields keep the pgx default, and a zero StatementTimeout leaves the server's in place
+type PoolConfig struct {
+	MaxConns          int32
+	MinConns          int32
+	HealthCheckPeriod time.Duration
+	StatementTimeout  time.Duration
+	Tracing           bool // Record a span for every query, batch and copy
+	Tenancy           bool // Scope each connection to the tenant of the context acquiring it
+}
+
+// applicationName names the service's sessions to Postgres unless the database URL names
+// them otherwise. Catalog change notifications carry it, telling the service's own writes
+// apart from those of other applications
+const applicationName = "supabase-redis-middleware"
+
+// apply sets the non-zero settings on a parsed pool config, and the application name
+func (pc PoolConfig) apply(config *pgxpool.Config) {
+	if config.ConnConfig.RuntimeParams["application_name"] == "" {
+		config.ConnConfig.RuntimeParams["application_name"] = applicationName
+	}
+	if pc.MaxConns > 0 {
+		config.MaxConns = pc.MaxConns
+	}
+	if pc.MinConns > 0 {
+		config.MinConns = pc.MinConns
+	}
+	if pc.HealthCheckPeriod > 0 {
+		config.HealthCheckPeriod = pc.HealthCheckPeriod
+	}
+	if pc.StatementTimeout > 0 {
+		config.ConnConfig.RuntimeParams["statement_timeout"] = strconv.FormatInt(pc.StatementTimeout.Milliseconds(), 10)
+	}
+	if pc.Tracing {
+		config.ConnConfig.Tracer = otelpgx.NewTracer()
+	}
+	if pc.Tenancy {
+		config.BeforeAcquire = scopeToTenant
+	}
+}
+
+// scopeToTenant sets app.tenant_id, which the tenant_isolation policies read, to the tenant
+// of the context acquiring conn. A system context clears it, seeing every tenant
+// A connection the setting can't be written to is destroyed, and the pool acquires another
+func scopeToTenant(ctx context.Context, conn *pgx.Conn) bool {
+	_, err := conn.Exec(ctx, "SELECT set_config('app.tenant_id', $1, false)", tenant.FromContext(ctx))
+	return err == nil
 }
 
 // NewPostgresRepository creates a new PostgreSQL repository
-func NewPostgresRepository(databaseURL string, logger *zap.Logger) (*PostgresRepository, error) {
+// poolConfig also applies to the read replica's pool, if one is set
+func NewPostgresRepository(databaseURL string, poolConfig PoolConfig, logger *zap.Logger) (*PostgresRepository, error) {
 	// Parse and validate the connection string
 	config, err := pgxpool.ParseConfig(databaseURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse database URL: %w", err)
 	}
+	poolConfig.apply(config)
 
 	// Create connection pool
 	pool, err := pgxpool.NewWithConfig(context.Background(), config)
@@ -38,138 +104,100 @@ func NewPostgresRepository(databaseURL string, logger *zap.Logger) (*PostgresRep
 		zap.String("database", config.ConnConfig.Database),
 		zap.String("host", config.ConnConfig.Host),
 		zap.Uint16("port", config.ConnConfig.Port),
+		zap.Int32("max_conns", config.MaxConns),
+		zap.Int32("min_conns", config.MinConns),
+		zap.Duration("statement_timeout", poolConfig.StatementTimeout),
 	)
 
 	return &PostgresRepository{
-		pool:   pool,
-		logger: logger,
+		pool:             pool,
+		poolConfig:       poolConfig,
+		applicationName:  config.ConnConfig.RuntimeParams["application_name"],
+		logger:           logger,
+		fuzzyThreshold:   defaultFuzzySearchThreshold,
+		pushChunkSize:    defaultPushChunkSize,
+		retryMaxAttempts: defaultRetryMaxAttempts,
 	}, nil
 }
 
-// Close closes the database connection pool
+// defaultPushChunkSize is how many pushed products are committed per transaction
+const defaultPushChunkSize = 1000
+
+// SetPushChunkSize sets how many products a push commits per transaction
+// Must be called before the repository is used
+func (r *PostgresRepository) SetPushChunkSize(size int) {
+	r.pushChunkSize = size
+}
+
+// Close closes the database connection pool and the read replica's, if any
 func (r *PostgresRepository) Close() {
+	if r.replica != nil {
+		r.replica.close()
+	}
 	if r.pool != nil {
 		r.pool.Close()
 		r.logger.Info("PostgreSQL connection pool closed")
 	}
 }
 
+// log returns the logger for a call made with ctx, carrying its request's fields
+func (r *PostgresRepository) log(ctx context.Context) *zap.Logger {
+	return logger.FromContext(ctx, r.logger)
+}
+
 // Ping checks if the database connection is alive
 func (r *PostgresRepository) Ping(ctx context.Context) error {
 	return r.pool.Ping(ctx)
 }
 
-// GetPool returns the underlying connection pool for direct access
-func (r *PostgresRepository) GetPool() *pgxpool.Pool {
-	return r.pool
-}
-
-// QuerySupermarketProducts retrieves supermarket products with optional filters
-func (r *PostgresRepository) QuerySupermarketProducts(ctx context.Context, filters map[string]interface{}, limit, offset int) ([]map[string]interface{}, error) {
-	query := `
-		SELECT id, name, category, price, stock, description, created_at, updated_at
-		FROM supermarket_products
-		WHERE 1=1
-	`
-	args := []interface{}{}
-	argCount := 1
-
-	// Add category filter if provided
-	if category, ok := filters["category"].(string); ok && category != "" {
-		query += fmt.Sprintf(" AND category = $%d", argCount)
-		args = append(args, category)
-		argCount++
+// CheckSchema verifies that the migrations the repository's queries rely on whether or
+// not tenancy is enabled are applied: add_multi_tenancy.sql, whose per-tenant unique keys
+// store and category upserts conflict on and whose tenant_id API key lookups read
+func (r *PostgresRepository) CheckSchema(ctx context.Context) error {
+	var migrated bool
+	err := r.pool.QueryRow(ctx, `SELECT to_regclass('tenants') IS NOT NULL`).Scan(&migrated)
+	if err != nil {
+		return fmt.Errorf("failed to check the database schema: %w", err)
 	}
-
-	// Add search filter if provided
-	if search, ok := filters["search"].(string); ok && search != "" {
-		query += fmt.Sprintf(" AND name ILIKE $%d", argCount)
-		args = append(args, "%"+search+"%")
-		argCount++
+	if !migrated {
+		return errors.New("the tenants table is missing; apply migrations/add_multi_tenancy.sql")
 	}
+	return nil
+}
 
-	// Add ordering and pagination
-	query += " ORDER BY created_at DESC"
-	query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", argCount, argCount+1)
-	args = append(args, limit, offset)
-
-	rows, err := r.pool.Query(ctx, query, args...)
+// CheckTenancy verifies that the database can keep tenants apart: the role connected as
+// is subject to row-level security. A superuser or a role with BYPASSRLS ignores the
+// tenant_isolation policies and sees every tenant. CheckSchema checks the migration
+func (r *PostgresRepository) CheckTenancy(ctx context.Context) error {
+	var bypasses bool
+	var role string
+	err := r.pool.QueryRow(ctx, `
+		SELECT rolname, rolsuper OR rolbypassrls
+		FROM pg_roles
+		WHERE rolname = current_user
+	`).Scan(&role, &bypasses)
 	if err != nil {
-		r.logger.Error("Failed to query supermarket products", zap.Error(err))
-		return nil, fmt.Errorf("failed to query products: %w", err)
-	}
-	defer rows.Close()
-
-	var results []map[string]interface{}
-	for rows.Next() {
-		var id int
-		var name, category, description string
-		var price float64
-		var stock int
-		var createdAt, updatedAt interface{}
-
-		if err := rows.Scan(&id, &name, &category, &price, &stock, &description, &createdAt, &updatedAt); err != nil {
-			r.logger.Error("Failed to scan product row", zap.Error(err))
-			continue
-		}
-
-		results = append(results, map[string]interface{}{
-			"id":          id,
-			"name":        name,
-			"category":    category,
-			"price":       price,
-			"stock":       stock,
-			"description": description,
-			"created_at":  createdAt,
-			"updated_at":  updatedAt,
-		})
+		return fmt.Errorf("failed to check tenancy support: %w", err)
 	}
-
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating rows: %w", err)
+	if bypasses {
+		return fmt.Errorf("role %s bypasses row-level security, so tenants would not be isolated; connect as a role without SUPERUSER or BYPASSRLS", role)
 	}
-
-	return results, nil
+	return nil
 }
 
-// GetSupermarketProductByID retrieves a single supermarket product by ID
-func (r *PostgresRepository) GetSupermarketProductByID(ctx context.Context, id int) (map[string]interface{}, error) {
-	query := `
-		SELECT id, name, category, price, stock, description, created_at, updated_at
-		FROM supermarket_products
-		WHERE id = $1
-	`
-
-	var productID int
-	var name, category, description string
-	var price float64
-	var stock int
-	var createdAt, updatedAt interface{}
+// GetPool returns the underlying connection pool for direct access
+func (r *PostgresRepository) GetPool() *pgxpool.Pool {
+	return r.pool
+}
 
-	err := r.pool.QueryRow(ctx, query, id).Scan(
-		&productID, &name, &category, &price, &stock, &description, &createdAt, &updatedAt,
-	)
-	if err != nil {
-		r.logger.Error("Failed to get product by ID", zap.Int("id", id), zap.Error(err))
-		return nil, fmt.Errorf("product not found: %w", err)
-	}
-
-	return map[string]interface{}{
-		"id":          productID,
-		"name":        name,
-		"category":    category,
-		"price":       price,
-		"stock":       stock,
-		"description": description,
-		"created_at":  createdAt,
-		"updated_at":  updatedAt,
-	}, nil
+// MovieFilter narrows QueryMovies; zero fields don't filter
+type MovieFilter struct {
+	Genre string
 }
 
 // QueryMovies retrieves movies with optional filters
-func (r *PostgresRepository) QueryMovies(ctx context.Context, filters map[string]interface{}, limit, offset int) ([]map[string]interface{}, error) {
-	query := `
-		SELECT id, title, genre, duration, rating, release_date, description, created_at, updated_at
+func (r *PostgresRepository) QueryMovies(ctx context.Context, filter MovieFilter, limit, offset int) ([]Movie, error) {
+	query := `SELECT ` + movieColumns + `
 		FROM movies
 		WHERE 1=1
 	`
@@ -177,9 +205,9 @@ func (r *PostgresRepository) QueryMovies(ctx context.Context, filters map[string
 	argCount := 1
 
 	// Add genre filter if provided
-	if genre, ok := filters["genre"].(string); ok && genre != "" {
+	if filter.Genre != "" {
 		query += fmt.Sprintf(" AND genre = $%d", argCount)
-		args = append(args, genre)
+		args = append(args, filter.Genre)
 		argCount++
 	}
 
@@ -188,145 +216,29 @@ func (r *PostgresRepository) QueryMovies(ctx context.Context, filters map[string
 	query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", argCount, argCount+1)
 	args = append(args, limit, offset)
 
-	rows, err := r.pool.Query(ctx, query, args...)
+	movies, err := queryRows(ctx, r, pgx.RowToStructByName[Movie], query, args...)
 	if err != nil {
-		r.logger.Error("Failed to query movies", zap.Error(err))
+		r.log(ctx).Error("Failed to query movies", zap.Error(err))
 		return nil, fmt.Errorf("failed to query movies: %w", err)
 	}
-	defer rows.Close()
-
-	var results []map[string]interface{}
-	for rows.Next() {
-		var id, duration int
-		var title, genre, description string
-		var rating float64
-		var releaseDate, createdAt, updatedAt interface{}
-
-		if err := rows.Scan(&id, &title, &genre, &duration, &rating, &releaseDate, &description, &createdAt, &updatedAt); err != nil {
-			r.logger.Error("Failed to scan movie row", zap.Error(err))
-			continue
-		}
-
-		results = append(results, map[string]interface{}{
-			"id":           id,
-			"title":        title,
-			"genre":        genre,
-			"duration":     duration,
-			"rating":       rating,
-			"release_date": releaseDate,
-			"description":  description,
-			"created_at":   createdAt,
-			"updated_at":   updatedAt,
-		})
-	}
-
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating rows: %w", err)
-	}
-
-	return results, nil
+	return movies, nil
 }
 
-// QueryMedicines retrieves medicines with optional filters
-func (r *PostgresRepository) QueryMedicines(ctx context.Context, filters map[string]interface{}, limit, offset int) ([]map[string]interface{}, error) {
-	query := `
-		SELECT id, name, category, price, prescription_required, stock, description, created_at, updated_at
-		FROM medicines
-		WHERE 1=1
-	`
-	args := []interface{}{}
-	argCount := 1
-
-	// Add category filter if provided
-	if category, ok := filters["category"].(string); ok && category != "" {
-		query += fmt.Sprintf(" AND category = $%d", argCount)
-		args = append(args, category)
-		argCount++
-	}
-
-	// Add search filter if provided
-	if search, ok := filters["search"].(string); ok && search != "" {
-		query += fmt.Sprintf(" AND name ILIKE $%d", argCount)
-		args = append(args, "%"+search+"%")
-		argCount++
-	}
-
-	// Add ordering and pagination
-	query += " ORDER BY created_at DESC"
-	query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", argCount, argCount+1)
-	args = append(args, limit, offset)
-
-	rows, err := r.pool.Query(ctx, query, args...)
+// ExecuteQuery executes a raw SQL query (for advanced use cases) on the primary and
+// scans each row into T by column name, as pgx.RowToStructByName does; every column
+// must map to a field of T
+func ExecuteQuery[T any](ctx context.Context, r *PostgresRepository, query string, args ...interface{}) ([]T, error) {
+	rows, err := r.conn().Query(ctx, query, args...)
 	if err != nil {
-		r.logger.Error("Failed to query medicines", zap.Error(err))
-		return nil, fmt.Errorf("failed to query medicines: %w", err)
-	}
-	defer rows.Close()
-
-	var results []map[string]interface{}
-	for rows.Next() {
-		var id, stock int
-		var name, category, description string
-		var price float64
-		var prescriptionRequired bool
-		var createdAt, updatedAt interface{}
-
-		if err := rows.Scan(&id, &name, &category, &price, &prescriptionRequired, &stock, &description, &createdAt, &updatedAt); err != nil {
-			r.logger.Error("Failed to scan medicine row", zap.Error(err))
-			continue
-		}
-
-		results = append(results, map[string]interface{}{
-			"id":                    id,
-			"name":                  name,
-			"category":              category,
-			"price":                 price,
-			"prescription_required": prescriptionRequired,
-			"stock":                 stock,
-			"description":           description,
-			"created_at":            createdAt,
-			"updated_at":            updatedAt,
-		})
-	}
-
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating rows: %w", err)
+		r.log(ctx).Error("Failed to execute query", zap.String("query", query), zap.Error(err))
+		return nil, fmt.Errorf("failed to execute query: %w", err)
 	}
 
-	return results, nil
-}
-
-// ExecuteQuery executes a raw SQL query (for advanced use cases)
-func (r *PostgresRepository) ExecuteQuery(ctx context.Context, query string, args ...interface{}) ([]map[string]interface{}, error) {
-	rows, err := r.pool.Query(ctx, query, args...)
+	results, err := pgx.CollectRows(rows, pgx.RowToStructByName[T])
 	if err != nil {
-		r.logger.Error("Failed to execute query", zap.String("query", query), zap.Error(err))
+		r.log(ctx).Error("Failed to scan query rows", zap.String("query", query), zap.Error(err))
 		return nil, fmt.Errorf("failed to execute query: %w", err)
 	}
-	defer rows.Close()
-
-	// Get column descriptions
-	fieldDescriptions := rows.FieldDescriptions()
-	var results []map[string]interface{}
-
-	for rows.Next() {
-		values, err := rows.Values()
-		if err != nil {
-			r.logger.Error("Failed to get row values", zap.Error(err))
-			continue
-		}
-
-		row := make(map[string]interface{})
-		for i, col := range fieldDescriptions {
-			row[string(col.Name)] = values[i]
-		}
-		results = append(results, row)
-	}
-
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating rows: %w", err)
-	}
-
 	return results, nil
 }
 
@@ -346,8 +258,8 @@ type ProductCreate struct {
 }
 
 // BulkCreateProducts creates multiple products in a single transaction
-func (r *PostgresRepository) BulkCreateProducts(ctx context.Context, products []ProductCreate) ([]map[string]interface{}, error) {
-	tx, err := r.pool.Begin(ctx)
+func (r *PostgresRepository) BulkCreateProducts(ctx context.Context, products []ProductCreate) ([]Product, error) {
+	tx, err := r.conn().Begin(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
@@ -357,20 +269,14 @@ func (r *PostgresRepository) BulkCreateProducts(ctx context.Context, products []
 		INSERT INTO products (sku, name, description, category_id, base_price, sale_price, 
 			unit, unit_quantity, brand, is_active, requires_prescription, slug)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
-		RETURNING id, sku, name, base_price, is_active, created_at
-	`
+		RETURNING ` + productColumns
 
-	var createdProducts []map[string]interface{}
+	createdProducts := make([]Product, 0, len(products))
 
 	for _, product := range products {
 		slug := generateSlug(product.Name)
 
-		var id, sku, name string
-		var basePrice float64
-		var isActive bool
-		var createdAt interface{}
-
-		err := tx.QueryRow(ctx, query,
+		rows, _ := tx.Query(ctx, query,
 			product.SKU,
 			product.Name,
 			product.Description,
@@ -383,30 +289,23 @@ func (r *PostgresRepository) BulkCreateProducts(ctx context.Context, products []
 			product.IsActive,
 			product.RequiresPrescription,
 			slug,
-		).Scan(&id, &sku, &name, &basePrice, &isActive, &createdAt)
-
+		)
+		created, err := pgx.CollectExactlyOneRow(rows, pgx.RowToStructByName[Product])
 		if err != nil {
-			r.logger.Error("Failed to insert product",
+			r.log(ctx).Error("Failed to insert product",
 				zap.String("sku", product.SKU),
 				zap.Error(err))
 			return nil, fmt.Errorf("failed to insert product %s: %w", product.SKU, err)
 		}
 
-		createdProducts = append(createdProducts, map[string]interface{}{
-			"id":         id,
-			"sku":        sku,
-			"name":       name,
-			"base_price": basePrice,
-			"is_active":  isActive,
-			"created_at": createdAt,
-		})
+		createdProducts = append(createdProducts, created)
 	}
 
 	if err := tx.Commit(ctx); err != nil {
 		return nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	r.logger.Info("Bulk created products", zap.Int("count", len(createdProducts)))
+	r.log(ctx).Info("Bulk created products", zap.Int("count", len(createdProducts)))
 	return createdProducts, nil
 }
 
@@ -420,7 +319,7 @@ func (r *PostgresRepository) UpdateProductStock(ctx context.Context, productID s
 		WHERE product_id = $2
 	`
 
-	result, err := r.pool.Exec(ctx, query, stockQuantity, productID)
+	result, err := r.exec(ctx, query, stockQuantity, productID)
 	if err != nil {
 		return fmt.Errorf("failed to update product stock: %w", err)
 	}
@@ -429,7 +328,7 @@ func (r *PostgresRepository) UpdateProductStock(ctx context.Context, productID s
 		return fmt.Errorf("product not found in any store")
 	}
 
-	r.logger.Info("Updated product stock",
+	r.log(ctx).Info("Updated product stock",
 		zap.String("product_id", productID),
 		zap.Float64("stock", stockQuantity),
 		zap.Int64("rows_affected", result.RowsAffected()))
@@ -446,7 +345,7 @@ func (r *PostgresRepository) UpdateProductStatus(ctx context.Context, productID
 		WHERE id = $2
 	`
 
-	result, err := r.pool.Exec(ctx, query, isActive, productID)
+	result, err := r.exec(ctx, query, isActive, productID)
 	if err != nil {
 		return fmt.Errorf("failed to update product status: %w", err)
 	}
@@ -455,7 +354,7 @@ func (r *PostgresRepository) UpdateProductStatus(ctx context.Context, productID
 		return fmt.Errorf("product not found")
 	}
 
-	r.logger.Info("Updated product status",
+	r.log(ctx).Info("Updated product status",
 		zap.String("product_id", productID),
 		zap.Bool("is_active", isActive))
 
@@ -467,7 +366,7 @@ func (r *PostgresRepository) BulkUpdateProductStock(ctx context.Context, updates
 	ProductID     string  `json:"product_id"`
 	StockQuantity float64 `json:"stock_quantity"`
 }) error {
-	tx, err := r.pool.Begin(ctx)
+	tx, err := r.conn().Begin(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
@@ -484,7 +383,7 @@ func (r *PostgresRepository) BulkUpdateProductStock(ctx context.Context, updates
 	for _, update := range updates {
 		_, err := tx.Exec(ctx, query, update.StockQuantity, update.ProductID)
 		if err != nil {
-			r.logger.Error("Failed to update product stock in bulk",
+			r.log(ctx).Error("Failed to update product stock in bulk",
 				zap.String("product_id", update.ProductID),
 				zap.Error(err))
 			return fmt.Errorf("failed to update product %s: %w", update.ProductID, err)
@@ -495,66 +394,28 @@ func (r *PostgresRepository) BulkUpdateProductStock(ctx context.Context, updates
 		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	r.logger.Info("Bulk updated product stock", zap.Int("count", len(updates)))
+	r.log(ctx).Info("Bulk updated product stock", zap.Int("count", len(updates)))
 	return nil
 }
 
-// GetStoreByID retrieves basic store information
-func (r *PostgresRepository) GetStoreByID(ctx context.Context, storeID string) (map[string]interface{}, error) {
-	query := `
-		SELECT id, name, slug, description, store_type, phone, email,
-		       address_line1, city, state, postal_code, country,
-		       latitude, longitude, rating, total_ratings,
-		       min_order_amount, delivery_fee, estimated_delivery_time,
-		       is_active, is_open, created_at, updated_at
-		FROM stores
-		WHERE id = $1
-	`
-
-	var id, name, slug, storeType, addressLine1, city, country string
-	var description, phone, email, state, postalCode *string
-	var latitude, longitude, rating, minOrderAmount, deliveryFee float64
-	var totalRatings, estimatedDeliveryTime *int
-	var isActive, isOpen bool
-	var createdAt, updatedAt interface{}
-
-	err := r.pool.QueryRow(ctx, query, storeID).Scan(
-		&id, &name, &slug, &description, &storeType, &phone, &email,
-		&addressLine1, &city, &state, &postalCode, &country,
-		&latitude, &longitude, &rating, &totalRatings,
-		&minOrderAmount, &deliveryFee, &estimatedDeliveryTime,
-		&isActive, &isOpen, &createdAt, &updatedAt,
-	)
+// GetStoreByID retrieves basic store information and whether its hours have it open now
+func (r *PostgresRepository) GetStoreByID(ctx context.Context, storeID string) (*StoreDetail, error) {
+	query := `SELECT ` + storeColumns + ` FROM stores WHERE id = $1`
 
+	store, err := queryRow(ctx, r, pgx.RowToStructByName[Store], query, storeID)
 	if err != nil {
 		return nil, fmt.Errorf("store not found: %w", err)
 	}
 
-	return map[string]interface{}{
-		"id":                      id,
-		"name":                    name,
-		"slug":                    slug,
-		"description":             description,
-		"store_type":              storeType,
-		"phone":                   phone,
-		"email":                   email,
-		"address_line1":           addressLine1,
-		"city":                    city,
-		"state":                   state,
-		"postal_code":             postalCode,
-		"country":                 country,
-		"latitude":                latitude,
-		"longitude":               longitude,
-		"rating":                  rating,
-		"total_ratings":           totalRatings,
-		"min_order_amount":        minOrderAmount,
-		"delivery_fee":            deliveryFee,
-		"estimated_delivery_time": estimatedDeliveryTime,
-		"is_active":               isActive,
-		"is_open":                 isOpen,
-		"created_at":              createdAt,
-		"updated_at":              updatedAt,
-	}, nil
+	detail := &StoreDetail{Store: store, IsOpenNow: store.IsActive && store.IsOpen}
+	if schedule, err := r.GetStoreSchedule(ctx, storeID); err != nil {
+		r.log(ctx).Warn("Failed to load store hours, using is_open", zap.String("store_id", storeID), zap.Error(err))
+	} else {
+		detail.Timezone = schedule.Timezone
+		detail.IsOpenNow = schedule.IsOpenNow
+	}
+
+	return detail, nil
 }
 
 // UpdateStoreStatus updates store active and open status
@@ -575,19 +436,11 @@ func (r *PostgresRepository) UpdateStoreStatus(ctx context.Context, storeID stri
 		argCount++
 	}
 
-	query += fmt.Sprintf(" WHERE id = $%d", argCount)
-	args = append(args, storeID)
-
-	result, err := r.pool.Exec(ctx, query, args...)
-	if err != nil {
+	if err := r.updateStore(ctx, AuditStoreStatus, storeID, query, args); err != nil {
 		return fmt.Errorf("failed to update store status: %w", err)
 	}
 
-	if result.RowsAffected() == 0 {
-		return fmt.Errorf("store not found")
-	}
-
-	r.logger.Info("Updated store status",
+	r.log(ctx).Info("Updated store status",
 		zap.String("store_id", storeID),
 		zap.Any("is_active", isActive),
 		zap.Any("is_open", isOpen))
@@ -596,37 +449,22 @@ func (r *PostgresRepository) UpdateStoreStatus(ctx context.Context, storeID stri
 }
 
 // GetStoreStatus retrieves store status information
-func (r *PostgresRepository) GetStoreStatus(ctx context.Context, storeID string) (map[string]interface{}, error) {
-	query := `
-		SELECT id, name, is_active, is_open, is_verified,
-		       opened_at, closed_at, updated_at
-		FROM stores
-		WHERE id = $1
-	`
-
-	var id, name string
-	var isActive, isOpen, isVerified bool
-	var openedAt, closedAt, updatedAt interface{}
-
-	err := r.pool.QueryRow(ctx, query, storeID).Scan(
-		&id, &name, &isActive, &isOpen, &isVerified,
-		&openedAt, &closedAt, &updatedAt,
-	)
+func (r *PostgresRepository) GetStoreStatus(ctx context.Context, storeID string) (*StoreStatus, error) {
+	query := `SELECT ` + storeStatusColumns + ` FROM stores WHERE id = $1`
 
+	status, err := queryRow(ctx, r, pgx.RowToAddrOfStructByName[StoreStatus], query, storeID)
 	if err != nil {
 		return nil, fmt.Errorf("store not found: %w", err)
 	}
 
-	return map[string]interface{}{
-		"id":          id,
-		"name":        name,
-		"is_active":   isActive,
-		"is_open":     isOpen,
-		"is_verified": isVerified,
-		"opened_at":   openedAt,
-		"closed_at":   closedAt,
-		"updated_at":  updatedAt,
-	}, nil
+	status.IsOpenNow = status.IsActive && status.IsOpen
+	if schedule, err := r.GetStoreSchedule(ctx, storeID); err != nil {
+		r.log(ctx).Warn("Failed to load store hours, using is_open", zap.String("store_id", storeID), zap.Error(err))
+	} else {
+		status.IsOpenNow = schedule.IsOpenNow
+	}
+
+	return status, nil
 }
 
 // UpdateStoreDetailsInput represents data for updating store details
@@ -734,22 +572,40 @@ func (r *PostgresRepository) UpdateStoreDetails(ctx context.Context, storeID str
 		return fmt.Errorf("no fields to update")
 	}
 
-	query += fmt.Sprintf(" WHERE id = $%d", argCount)
-	args = append(args, storeID)
-
-	result, err := r.pool.Exec(ctx, query, args...)
-	if err != nil {
+	if err := r.updateStore(ctx, AuditStoreUpdate, storeID, query, args); err != nil {
 		return fmt.Errorf("failed to update store details: %w", err)
 	}
 
-	if result.RowsAffected() == 0 {
+	r.log(ctx).Info("Updated store details",
+		zap.String("store_id", storeID),
+		zap.Int("fields_updated", len(args)))
+
+	return nil
+}
+
+// updateStore finishes an "UPDATE stores SET ..." statement for storeID, runs it and
+// audits the columns it changed. The old row is locked and read in the same statement
+// so the before snapshot matches what was overwritten
+func (r *PostgresRepository) updateStore(ctx context.Context, action, storeID, update string, args []interface{}) error {
+	update += fmt.Sprintf(`
+		FROM (SELECT * FROM stores WHERE id = $%d FOR UPDATE) old
+		WHERE stores.id = old.id
+		RETURNING to_jsonb(old), to_jsonb(stores)`, len(args)+1)
+	args = append(args, storeID)
+
+	var before, after map[string]any
+	err := r.retry(ctx, action, func() error {
+		return r.conn().QueryRow(ctx, update, args...).Scan(&before, &after)
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
 		return fmt.Errorf("store not found")
 	}
+	if err != nil {
+		return err
+	}
 
-	r.logger.Info("Updated store details",
-		zap.String("store_id", storeID),
-		zap.Int("fields_updated", len(args)-1))
-
+	changedBefore, changedAfter := auditChanges(before, after)
+	r.recordAudit(ctx, action, []string{storeID}, changedBefore, changedAfter)
 	return nil
 }
 
@@ -773,11 +629,46 @@ func generateSlug(s string) string {
 
 // UpsertResult contains statistics about an upsert operation
 type UpsertResult struct {
+	StoreID                string // Internal store UUID
 	Created                int
 	Updated                int
 	VariationsProcessed    int
 	StoreProductsProcessed int
 	TaxesProcessed         int
+	TotalChunks            int
+	Chunks                 []ChunkProgress // Committed chunks, in order
+	Failures               []PushFailure   // Skipped products, with PushOptions.PartialSuccess
+	Matches                []ProductMatch  // Matching decision for each saved product
+	Deactivated            int             // Listings missing from a full sync, marked unavailable
+
+	listings *auditedRows // What the push changed in the store's listings, for the audit log
+}
+
+// ChunkProgress describes one committed chunk of a product push
+type ChunkProgress struct {
+	Index    int
+	Products int
+	Created  int
+	Updated  int
+	Failed   int
+	Duration time.Duration
+}
+
+// add accumulates the counts of a chunk's result
+func (u *UpsertResult) add(chunk *UpsertResult) {
+	u.Created += chunk.Created
+	u.Updated += chunk.Updated
+	u.VariationsProcessed += chunk.VariationsProcessed
+	u.StoreProductsProcessed += chunk.StoreProductsProcessed
+	u.TaxesProcessed += chunk.TaxesProcessed
+	u.Failures = append(u.Failures, chunk.Failures...)
+	u.Matches = append(u.Matches, chunk.Matches...)
+	if chunk.listings != nil {
+		if u.listings == nil {
+			u.listings = newAuditedRows()
+		}
+		u.listings.merge(chunk.listings)
+	}
 }
 
 // StoreDetailsInput represents store details for upsert
@@ -800,8 +691,20 @@ type LocationInput struct {
 	Lng float64
 }
 
+// execer runs a statement on either the pool or a transaction
+type execer interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+}
+
 // UpsertStore creates or updates a store using external_id as the unique key
 func (r *PostgresRepository) UpsertStore(ctx context.Context, storeDetails StoreDetailsInput) error {
+	return r.retry(ctx, "upsert store", func() error {
+		return r.upsertStore(ctx, r.conn(), storeDetails)
+	})
+}
+
+// upsertStore upserts a store through db
+func (r *PostgresRepository) upsertStore(ctx context.Context, db execer, storeDetails StoreDetailsInput) error {
 	store := storeDetails
 	slug := generateSlug(store.Name)
 
@@ -814,7 +717,7 @@ func (r *PostgresRepository) UpsertStore(ctx context.Context, storeDetails Store
 			$8, $9, ST_SetSRID(ST_MakePoint($10, $11), 4326)::geography, 
 			true, true
 		)
-		ON CONFLICT (external_id) DO UPDATE SET
+		ON CONFLICT (tenant_id, external_id) DO UPDATE SET
 			name = EXCLUDED.name,
 			slug = EXCLUDED.slug,
 			address_line1 = EXCLUDED.address_line1,
@@ -827,7 +730,7 @@ func (r *PostgresRepository) UpsertStore(ctx context.Context, storeDetails Store
 			updated_at = CURRENT_TIMESTAMP
 	`
 
-	_, err := r.pool.Exec(ctx, query,
+	_, err := db.Exec(ctx, query,
 		store.StoreID, // This is the external_id
 		store.Name,
 		slug,
@@ -842,11 +745,11 @@ func (r *PostgresRepository) UpsertStore(ctx context.Context, storeDetails Store
 	)
 
 	if err != nil {
-		r.logger.Error("Failed to upsert store", zap.Error(err))
+		r.log(ctx).Error("Failed to upsert store", zap.Error(err))
 		return fmt.Errorf("failed to upsert store: %w", err)
 	}
 
-	r.logger.Info("Upserted store", zap.String("external_id", store.StoreID))
+	r.log(ctx).Info("Upserted store", zap.String("external_id", store.StoreID))
 	return nil
 }
 
@@ -864,13 +767,33 @@ type CategoryInput struct {
 // UpsertCategories creates or updates categories using external_id
 // Processes parent categories first to ensure proper hierarchy
 func (r *PostgresRepository) UpsertCategories(ctx context.Context, categories []CategoryInput) error {
-	cats := categories
+	err := r.retry(ctx, "upsert categories", func() error {
+		tx, err := r.conn().Begin(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to begin transaction: %w", err)
+		}
+		defer tx.Rollback(ctx)
+
+		if err := r.upsertCategories(ctx, tx, categories); err != nil {
+			return err
+		}
 
-	tx, err := r.pool.Begin(ctx)
+		if err := tx.Commit(ctx); err != nil {
+			return fmt.Errorf("failed to commit transaction: %w", err)
+		}
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return err
 	}
-	defer tx.Rollback(ctx)
+
+	r.log(ctx).Info("Upserted categories", zap.Int("count", len(categories)))
+	return nil
+}
+
+// upsertCategories upserts categories within tx, parents first
+func (r *PostgresRepository) upsertCategories(ctx context.Context, tx pgx.Tx, categories []CategoryInput) error {
+	cats := categories
 
 	// Separate root categories (no parent) from child categories
 	var rootCats, childCats []CategoryInput
@@ -888,7 +811,7 @@ func (r *PostgresRepository) UpsertCategories(ctx context.Context, categories []
 			INSERT INTO categories (
 				external_id, parent_id, name, slug, description, display_order, is_active
 			) VALUES ($1, NULL, $2, $3, $4, $5, $6)
-			ON CONFLICT (external_id) DO UPDATE SET
+			ON CONFLICT (tenant_id, external_id) DO UPDATE SET
 				parent_id = NULL,
 				name = EXCLUDED.name,
 				slug = EXCLUDED.slug,
@@ -906,7 +829,7 @@ func (r *PostgresRepository) UpsertCategories(ctx context.Context, categories []
 			cat.IsActive,
 		)
 		if err != nil {
-			r.logger.Error("Failed to upsert root category", zap.String("external_id", cat.ID), zap.Error(err))
+			r.log(ctx).Error("Failed to upsert root category", zap.String("external_id", cat.ID), zap.Error(err))
 			return fmt.Errorf("failed to upsert root category %s: %w", cat.ID, err)
 		}
 	}
@@ -921,7 +844,7 @@ func (r *PostgresRepository) UpsertCategories(ctx context.Context, categories []
 				(SELECT id FROM categories WHERE external_id = $2), 
 				$3, $4, $5, $6, $7
 			)
-			ON CONFLICT (external_id) DO UPDATE SET
+			ON CONFLICT (tenant_id, external_id) DO UPDATE SET
 				parent_id = (SELECT id FROM categories WHERE external_id = $2),
 				name = EXCLUDED.name,
 				slug = EXCLUDED.slug,
@@ -940,16 +863,11 @@ func (r *PostgresRepository) UpsertCategories(ctx context.Context, categories []
 			cat.IsActive,
 		)
 		if err != nil {
-			r.logger.Error("Failed to upsert child category", zap.String("external_id", cat.ID), zap.Error(err))
+			r.log(ctx).Error("Failed to upsert child category", zap.String("external_id", cat.ID), zap.Error(err))
 			return fmt.Errorf("failed to upsert child category %s: %w", cat.ID, err)
 		}
 	}
 
-	if err := tx.Commit(ctx); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
-	}
-
-	r.logger.Info("Upserted categories", zap.Int("count", len(cats)))
 	return nil
 }
 
@@ -968,17 +886,37 @@ type TaxInput struct {
 
 // UpsertTaxes creates or updates taxes using (store_id, tax_id) as unique key
 func (r *PostgresRepository) UpsertTaxes(ctx context.Context, taxes []TaxInput, storeExternalID string) error {
-	txs := taxes
+	err := r.retry(ctx, "upsert taxes", func() error {
+		tx, err := r.conn().Begin(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to begin transaction: %w", err)
+		}
+		defer tx.Rollback(ctx)
 
-	tx, err := r.pool.Begin(ctx)
+		if err := r.upsertTaxes(ctx, tx, taxes, storeExternalID); err != nil {
+			return err
+		}
+
+		if err := tx.Commit(ctx); err != nil {
+			return fmt.Errorf("failed to commit transaction: %w", err)
+		}
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return err
 	}
-	defer tx.Rollback(ctx)
+
+	r.log(ctx).Info("Upserted taxes", zap.Int("count", len(taxes)))
+	return nil
+}
+
+// upsertTaxes upserts a store's taxes within tx
+func (r *PostgresRepository) upsertTaxes(ctx context.Context, tx pgx.Tx, taxes []TaxInput, storeExternalID string) error {
+	txs := taxes
 
 	// First, get the store's internal UUID from external_id
 	var storeUUID string
-	err = tx.QueryRow(ctx, `SELECT id FROM stores WHERE external_id = $1`, storeExternalID).Scan(&storeUUID)
+	err := tx.QueryRow(ctx, `SELECT id FROM stores WHERE external_id = $1`, storeExternalID).Scan(&storeUUID)
 	if err != nil {
 		return fmt.Errorf("failed to find store with external_id %s: %w", storeExternalID, err)
 	}
@@ -1011,16 +949,11 @@ func (r *PostgresRepository) UpsertTaxes(ctx context.Context, taxes []TaxInput,
 			t.IsActive,
 		)
 		if err != nil {
-			r.logger.Error("Failed to upsert tax", zap.String("tax_id", t.TaxID), zap.Error(err))
+			r.log(ctx).Error("Failed to upsert tax", zap.String("tax_id", t.TaxID), zap.Error(err))
 			return fmt.Errorf("failed to upsert tax %s: %w", t.ID, err)
 		}
 	}
 
-	if err := tx.Commit(ctx); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
-	}
-
-	r.logger.Info("Upserted taxes", zap.Int("count", len(txs)))
 	return nil
 }
 
@@ -1079,10 +1012,21 @@ func (r *PostgresRepository) UpsertProducts(ctx context.Context, products []Prod
 
 // StockUpdateResult contains statistics about stock update operation
 type StockUpdateResult struct {
-	Updated          int
-	NotFound         int
-	VariantsUpdated  int
-	VariantsNotFound int
+	StoreID             string // Internal store UUID
+	Updated             int
+	NotFound            int
+	VariantsUpdated     int
+	VariantsNotFound    int
+	NotFoundIDs         []string // External IDs of products not found in the store
+	VariantsNotFoundIDs []string // External IDs of variations not found
+
+	listings, variations *auditedRows // What the update changed, for the audit log
+}
+
+// StockUpdateOptions controls how BulkUpdateStock applies updates
+type StockUpdateOptions struct {
+	// DryRun applies the updates in a transaction that is rolled back
+	DryRun bool
 }
 
 // StockProductUpdate represents a product stock update
@@ -1103,8 +1047,47 @@ type StockVariantUpdate struct {
 }
 
 // BulkUpdateStock updates stock for multiple products in a store
-func (r *PostgresRepository) BulkUpdateStock(ctx context.Context, storeExternalID string, products []StockProductUpdate) (*StockUpdateResult, error) {
-	tx, err := r.pool.Begin(ctx)
+// Product and variant updates are each staged with CopyFrom and applied in one UPDATE.
+// The transaction is retried as a whole on transient errors
+func (r *PostgresRepository) BulkUpdateStock(ctx context.Context, storeExternalID string, products []StockProductUpdate, opts StockUpdateOptions) (*StockUpdateResult, error) {
+	var result *StockUpdateResult
+	err := r.retry(ctx, "bulk update stock", func() (err error) {
+		result, err = r.bulkUpdateStock(ctx, storeExternalID, products, opts)
+		return err
+	})
+	if err != nil || opts.DryRun {
+		return result, err
+	}
+
+	notFound := make(map[string]bool, len(result.NotFoundIDs))
+	for _, id := range result.NotFoundIDs {
+		notFound[id] = true
+	}
+	entityIDs := []string{storeExternalID, result.StoreID}
+	for _, prod := range products {
+		if !notFound[prod.ID] {
+			entityIDs = append(entityIDs, prod.ID)
+		}
+	}
+	r.recordAudit(ctx, AuditStockUpdate, entityIDs, map[string]any{
+		"listings":   result.listings.before,
+		"variations": result.variations.before,
+	}, map[string]any{
+		"products_updated":      result.Updated,
+		"products_not_found":    result.NotFound,
+		"variants_updated":      result.VariantsUpdated,
+		"variants_not_found":    result.VariantsNotFound,
+		"not_found_product_ids": result.NotFoundIDs,
+		"listings":              result.listings.after,
+		"variations":            result.variations.after,
+	})
+
+	return result, nil
+}
+
+// bulkUpdateStock applies one attempt of BulkUpdateStock in its own transaction
+func (r *PostgresRepository) bulkUpdateStock(ctx context.Context, storeExternalID string, products []StockProductUpdate, opts StockUpdateOptions) (*StockUpdateResult, error) {
+	tx, err := r.conn().Begin(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
@@ -1117,129 +1100,86 @@ func (r *PostgresRepository) BulkUpdateStock(ctx context.Context, storeExternalI
 		return nil, fmt.Errorf("failed to find store with external_id %s: %w", storeExternalID, err)
 	}
 
-	result := &StockUpdateResult{}
+	result := &StockUpdateResult{StoreID: storeUUID, NotFoundIDs: []string{}, VariantsNotFoundIDs: []string{}, listings: newAuditedRows(), variations: newAuditedRows()}
 
-	for _, prod := range products {
-		// Update store_product by external_id
-		query := `
-			UPDATE store_products
-			SET stock_quantity = $1::numeric,
-			    is_in_stock = CASE WHEN $1::numeric > 0 THEN true ELSE false END,
-			    is_available = $2,
-			    updated_at = CURRENT_TIMESTAMP
-			WHERE store_id = $3 AND external_id = $4
-		`
+	var productRows, variantRows [][]any
+	for i, prod := range products {
+		productRows = append(productRows, []any{i, prod.ID, prod.StockQuantity, prod.IsAvailable, prod.Price})
+		for _, variant := range prod.Variants {
+			variantRows = append(variantRows, []any{len(variantRows), variant.ID, variant.StockQuantity, variant.IsAvailable, variant.Price})
+		}
+	}
 
-		// If price is provided and > 0, include it in the update
-		if prod.Price > 0 {
-			query = `
-				UPDATE store_products
-				SET stock_quantity = $1::numeric,
-				    is_in_stock = CASE WHEN $1::numeric > 0 THEN true ELSE false END,
-				    is_available = $2,
-				    price = $5::numeric,
-				    updated_at = CURRENT_TIMESTAMP
-				WHERE store_id = $3 AND external_id = $4
-			`
-			cmdTag, err := tx.Exec(ctx, query, prod.StockQuantity, prod.IsAvailable, storeUUID, prod.ID, prod.Price)
-			if err != nil {
-				r.logger.Error("Failed to update stock with price",
-					zap.String("external_id", prod.ID),
-					zap.Error(err))
-				return nil, fmt.Errorf("failed to update stock for product %s: %w", prod.ID, err)
-			}
+	// A price of 0 leaves the current price unchanged; the last update for an ID wins. old is
+	// the row as the statement found it, for the audit log
+	updated, err := r.applyStockUpdates(ctx, tx, "push_stock", `
+		UPDATE store_products sp
+		SET stock_quantity = s.stock_quantity,
+		    is_in_stock = s.stock_quantity > 0,
+		    is_available = s.is_available,
+		    price = CASE WHEN s.price > 0 THEN s.price ELSE sp.price END,
+		    updated_at = CURRENT_TIMESTAMP
+		FROM (SELECT DISTINCT ON (external_id) * FROM push_stock ORDER BY external_id, ord DESC) s, store_products old
+		WHERE sp.store_id = $1::uuid AND sp.external_id = s.external_id AND old.id = sp.id
+		RETURNING sp.external_id, `+auditStateSQL("old", listingAuditColumns)+`, `+auditStateSQL("sp", listingAuditColumns)+`
+	`, productRows, result.listings, storeUUID)
+	if err != nil {
+		r.log(ctx).Error("Failed to update stock", zap.Error(err))
+		return nil, fmt.Errorf("failed to update stock: %w", err)
+	}
 
-			if cmdTag.RowsAffected() == 0 {
-				result.NotFound++
-				r.logger.Warn("Product not found in store",
-					zap.String("store_id", storeExternalID),
-					zap.String("external_id", prod.ID))
-			} else {
-				result.Updated++
-			}
+	variantsUpdated, err := r.applyStockUpdates(ctx, tx, "push_variant_stock", `
+		UPDATE product_variations v
+		SET stock_quantity = s.stock_quantity,
+		    is_in_stock = s.stock_quantity > 0,
+		    is_active = s.is_available,
+		    price = CASE WHEN s.price > 0 THEN s.price ELSE v.price END,
+		    updated_at = CURRENT_TIMESTAMP
+		FROM (SELECT DISTINCT ON (external_id) * FROM push_variant_stock ORDER BY external_id, ord DESC) s, product_variations old
+		WHERE v.external_id = s.external_id AND old.id = v.id
+		RETURNING v.external_id, `+auditStateSQL("old", variationAuditColumns)+`, `+auditStateSQL("v", variationAuditColumns)+`
+	`, variantRows, result.variations)
+	if err != nil {
+		r.log(ctx).Error("Failed to update variation stock", zap.Error(err))
+		return nil, fmt.Errorf("failed to update variation stock: %w", err)
+	}
+
+	for _, prod := range products {
+		if updated[prod.ID] {
+			result.Updated++
 		} else {
-			cmdTag, err := tx.Exec(ctx, query, prod.StockQuantity, prod.IsAvailable, storeUUID, prod.ID)
-			if err != nil {
-				r.logger.Error("Failed to update stock",
-					zap.String("external_id", prod.ID),
-					zap.Error(err))
-				return nil, fmt.Errorf("failed to update stock for product %s: %w", prod.ID, err)
-			}
+			result.NotFound++
+			result.NotFoundIDs = append(result.NotFoundIDs, prod.ID)
+			r.log(ctx).Warn("Product not found in store",
+				zap.String("store_id", storeExternalID),
+				zap.String("external_id", prod.ID))
+		}
 
-			if cmdTag.RowsAffected() == 0 {
-				result.NotFound++
-				r.logger.Warn("Product not found in store",
-					zap.String("store_id", storeExternalID),
-					zap.String("external_id", prod.ID))
+		for _, variant := range prod.Variants {
+			if variantsUpdated[variant.ID] {
+				result.VariantsUpdated++
 			} else {
-				result.Updated++
+				result.VariantsNotFound++
+				result.VariantsNotFoundIDs = append(result.VariantsNotFoundIDs, variant.ID)
+				r.log(ctx).Warn("Variation not found",
+					zap.String("external_id", variant.ID))
 			}
 		}
+	}
 
-		// Update variations if provided
-		if len(prod.Variants) > 0 {
-			for _, variant := range prod.Variants {
-				varQuery := `
-					UPDATE product_variations
-					SET stock_quantity = $1::numeric,
-					    is_in_stock = CASE WHEN $1::numeric > 0 THEN true ELSE false END,
-					    is_active = $2,
-					    updated_at = CURRENT_TIMESTAMP
-					WHERE external_id = $3
-				`
-
-				// If price is provided and > 0, include it in the update
-				if variant.Price > 0 {
-					varQuery = `
-						UPDATE product_variations
-						SET stock_quantity = $1::numeric,
-						    is_in_stock = CASE WHEN $1::numeric > 0 THEN true ELSE false END,
-						    is_active = $2,
-						    price = $4::numeric,
-						    updated_at = CURRENT_TIMESTAMP
-						WHERE external_id = $3
-					`
-					cmdTag, err := tx.Exec(ctx, varQuery, variant.StockQuantity, variant.IsAvailable, variant.ID, variant.Price)
-					if err != nil {
-						r.logger.Error("Failed to update variation stock with price",
-							zap.String("external_id", variant.ID),
-							zap.Error(err))
-						return nil, fmt.Errorf("failed to update variation stock for %s: %w", variant.ID, err)
-					}
-
-					if cmdTag.RowsAffected() == 0 {
-						result.VariantsNotFound++
-						r.logger.Warn("Variation not found",
-							zap.String("external_id", variant.ID))
-					} else {
-						result.VariantsUpdated++
-					}
-				} else {
-					cmdTag, err := tx.Exec(ctx, varQuery, variant.StockQuantity, variant.IsAvailable, variant.ID)
-					if err != nil {
-						r.logger.Error("Failed to update variation stock",
-							zap.String("external_id", variant.ID),
-							zap.Error(err))
-						return nil, fmt.Errorf("failed to update variation stock for %s: %w", variant.ID, err)
-					}
-
-					if cmdTag.RowsAffected() == 0 {
-						result.VariantsNotFound++
-						r.logger.Warn("Variation not found",
-							zap.String("external_id", variant.ID))
-					} else {
-						result.VariantsUpdated++
-					}
-				}
-			}
-		}
+	if opts.DryRun {
+		r.log(ctx).Info("Dry run stock update",
+			zap.String("store_id", storeExternalID),
+			zap.Int("updated", result.Updated),
+			zap.Int("not_found", result.NotFound))
+		return result, nil
 	}
 
 	if err := tx.Commit(ctx); err != nil {
 		return nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	r.logger.Info("Bulk updated stock",
+	r.log(ctx).Info("Bulk updated stock",
 		zap.String("store_id", storeExternalID),
 		zap.Int("updated", result.Updated),
 		zap.Int("not_found", result.NotFound),
@@ -1248,3 +1188,20 @@ func (r *PostgresRepository) BulkUpdateStock(ctx context.Context, storeExternalI
 
 	return result, nil
 }
+
+// applyStockUpdates stages stock rows into table and runs update, which must return the
+// external_id of each row it changed with its state before and after, as collectAuditedRows
+// reads them into audited. Returns the set of updated external IDs
+func (r *PostgresRepository) applyStockUpdates(ctx context.Context, tx pgx.Tx, table, update string, rows [][]any, audited *auditedRows, args ...any) (map[string]bool, error) {
+	if len(rows) == 0 {
+		return map[string]bool{}, nil
+	}
+
+	err := stageRows(ctx, tx, table, `ord int, external_id text, stock_quantity float8, is_available bool, price float8`, rows)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, _ := tx.Query(ctx, update, args...)
+	return collectAuditedRows(updated, audited)
+}
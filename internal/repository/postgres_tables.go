@@ -0,0 +1,257 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+// TableReader reads table rows the way SupabaseRepository does, with the same filters,
+// pagination and query options
+type TableReader interface {
+	Query(ctx context.Context, table string, filters map[string]interface{}, pagination Pagination, opts ...QueryOption) ([]map[string]interface{}, error)
+	QueryWithCount(ctx context.Context, table string, filters map[string]interface{}, pagination Pagination, opts ...QueryOption) ([]map[string]interface{}, int64, error)
+	GetByID(ctx context.Context, table string, id string, opts ...QueryOption) (map[string]interface{}, error)
+}
+
+// postgresTableReader reads the tables PostgREST serves straight from Postgres
+type postgresTableReader struct {
+	pg     *PostgresRepository
+	schema string
+}
+
+// NewPostgresTableReader returns a TableReader querying the tables of schema (public when
+// empty) on pg's pools. Rows are returned as PostgREST returns them, as JSON objects
+// Only plain column names are supported, so resource embedding and renamed columns are
+// rejected with a validation error. Queries run as pg's role, bypassing RLS policies
+func NewPostgresTableReader(pg *PostgresRepository, schema string) TableReader {
+	if schema == "" {
+		schema = "public"
+	}
+	return &postgresTableReader{pg: pg, schema: schema}
+}
+
+// Query retrieves the rows of table matching filters
+func (r *postgresTableReader) Query(ctx context.Context, table string, filters map[string]interface{}, pagination Pagination, opts ...QueryOption) ([]map[string]interface{}, error) {
+	rows, _, err := r.query(ctx, table, filters, pagination, false, opts)
+	return rows, err
+}
+
+// QueryWithCount is Query that also returns the number of matching rows, ignoring
+// pagination. Every count mode counts exactly
+func (r *postgresTableReader) QueryWithCount(ctx context.Context, table string, filters map[string]interface{}, pagination Pagination, opts ...QueryOption) ([]map[string]interface{}, int64, error) {
+	return r.query(ctx, table, filters, pagination, true, opts)
+}
+
+func (r *postgresTableReader) query(ctx context.Context, table string, filters map[string]interface{}, pagination Pagination, withCount bool, opts []QueryOption) ([]map[string]interface{}, int64, error) {
+	parsed, err := ParseFilters(filters)
+	if err != nil {
+		return nil, 0, err
+	}
+	groups, err := ParseFilterGroups(filters)
+	if err != nil {
+		return nil, 0, err
+	}
+	options, err := NewQueryOptions(opts...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	from, err := r.fromClause(table)
+	if err != nil {
+		return nil, 0, err
+	}
+	selectList, err := tableSelectList(options)
+	if err != nil {
+		return nil, 0, err
+	}
+	where := &tableWhere{}
+	condition, err := where.clause(parsed, groups)
+	if err != nil {
+		return nil, 0, err
+	}
+	orderBy, err := tableOrderBy(options)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	sql := "SELECT " + selectList + " FROM " + from + condition + orderBy
+	args := where.args
+	if pagination.Limit > 0 {
+		args = append(args, pagination.Limit)
+		sql += " LIMIT $" + strconv.Itoa(len(args))
+	}
+	if pagination.Offset > 0 {
+		args = append(args, pagination.Offset)
+		sql += " OFFSET $" + strconv.Itoa(len(args))
+	}
+
+	rows, err := queryRows(ctx, r.pg, pgx.RowTo[map[string]interface{}], sql, args...)
+	if err != nil {
+		return nil, 0, r.queryError(err, table)
+	}
+	if !withCount {
+		return rows, 0, nil
+	}
+
+	total, err := queryRow(ctx, r.pg, pgx.RowTo[int64], "SELECT count(*) FROM "+from+condition, where.args...)
+	if err != nil {
+		return nil, 0, r.queryError(err, table)
+	}
+	return rows, total, nil
+}
+
+// GetByID retrieves the row of table with id
+func (r *postgresTableReader) GetByID(ctx context.Context, table string, id string, opts ...QueryOption) (map[string]interface{}, error) {
+	options, err := NewQueryOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+	from, err := r.fromClause(table)
+	if err != nil {
+		return nil, err
+	}
+	selectList, err := tableSelectList(options)
+	if err != nil {
+		return nil, err
+	}
+
+	row, err := queryRow(ctx, r.pg, pgx.RowTo[map[string]interface{}],
+		"SELECT "+selectList+" FROM "+from+" WHERE t.id = $1", id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, NewNotFoundError(table, id)
+	}
+	if err != nil {
+		return nil, r.queryError(err, table)
+	}
+	return row, nil
+}
+
+// fromClause returns the quoted table, aliased t
+func (r *postgresTableReader) fromClause(table string) (string, error) {
+	if !identifier.MatchString(table) {
+		return "", NewValidationError(fmt.Sprintf("invalid table name %q", table))
+	}
+	return pgx.Identifier{r.schema, table}.Sanitize() + " AS t", nil
+}
+
+// queryError converts a failed query on table, logging it
+func (r *postgresTableReader) queryError(err error, table string) error {
+	r.pg.logger.Error("Failed to query table from Postgres",
+		zap.String("table", table),
+		zap.String("schema", r.schema),
+		zap.Error(err))
+	return NewQueryError(err)
+}
+
+// tableSelectList returns the expression building each row's JSON object
+func tableSelectList(options QueryOptions) (string, error) {
+	if len(options.Columns) == 0 {
+		return "to_jsonb(t)", nil
+	}
+	pairs := make([]string, len(options.Columns))
+	for i, column := range options.Columns {
+		if !identifier.MatchString(column) {
+			return "", NewValidationError(fmt.Sprintf("column %q isn't a plain column name", column))
+		}
+		pairs[i] = "'" + column + "', " + tableColumn(column)
+	}
+	return "jsonb_build_object(" + strings.Join(pairs, ", ") + ")", nil
+}
+
+// tableOrderBy returns the ORDER BY clause for the options' sort keys, with nulls last
+func tableOrderBy(options QueryOptions) (string, error) {
+	if len(options.OrderBy) == 0 {
+		return "", nil
+	}
+	keys := make([]string, len(options.OrderBy))
+	for i, key := range options.OrderBy {
+		if !identifier.MatchString(key.Column) {
+			return "", NewValidationError(fmt.Sprintf("order column %q isn't a plain column name", key.Column))
+		}
+		keys[i] = tableColumn(key.Column) + " " + strings.ToUpper(key.Direction) + " NULLS LAST"
+	}
+	return " ORDER BY " + strings.Join(keys, ", "), nil
+}
+
+// tableColumn returns column of the table aliased t, quoted
+func tableColumn(column string) string {
+	return "t." + pgx.Identifier{column}.Sanitize()
+}
+
+// tableWhere builds a WHERE clause from filters, collecting their values as arguments
+// Values are sent as text, so Postgres parses them as the column's type like PostgREST does
+type tableWhere struct {
+	args []interface{}
+}
+
+// clause returns the WHERE clause matching every filter and group, or "" for none
+func (w *tableWhere) clause(filters []Filter, groups []FilterGroup) (string, error) {
+	condition, err := w.all(FilterAnd, filters, groups)
+	if err != nil || condition == "" {
+		return "", err
+	}
+	return " WHERE " + condition, nil
+}
+
+// all joins the conditions of filters and groups with op
+func (w *tableWhere) all(op string, filters []Filter, groups []FilterGroup) (string, error) {
+	conditions := make([]string, 0, len(filters)+len(groups))
+	for _, f := range filters {
+		condition, err := w.condition(f)
+		if err != nil {
+			return "", err
+		}
+		conditions = append(conditions, condition)
+	}
+	for _, g := range groups {
+		condition, err := w.all(g.Operator, g.Conditions, g.Groups)
+		if err != nil {
+			return "", err
+		}
+		conditions = append(conditions, "("+condition+")")
+	}
+	return strings.Join(conditions, " "+strings.ToUpper(op)+" "), nil
+}
+
+// condition returns the SQL condition for one filter
+func (w *tableWhere) condition(f Filter) (string, error) {
+	if !identifier.MatchString(f.Column) {
+		return "", NewValidationError(fmt.Sprintf("filter column %q isn't a plain column name", f.Column))
+	}
+	column := tableColumn(f.Column)
+
+	switch f.Operator {
+	case FilterIn:
+		placeholders := make([]string, len(f.Values))
+		for i, v := range f.Values {
+			placeholders[i] = w.arg(v)
+		}
+		return column + " IN (" + strings.Join(placeholders, ", ") + ")", nil
+	case FilterIs:
+		// Validated as null, true or false by ParseFilters
+		return column + " IS " + strings.ToUpper(f.Value), nil
+	case FilterIlike:
+		return column + " ILIKE " + w.arg(strings.ReplaceAll(f.Value, "*", "%")), nil
+	}
+
+	operators := map[string]string{
+		FilterEq: "=", FilterNeq: "<>", FilterGt: ">", FilterGte: ">=", FilterLt: "<", FilterLte: "<=",
+	}
+	operator, ok := operators[f.Operator]
+	if !ok {
+		return "", NewValidationError(fmt.Sprintf("unknown filter operator %q on %s", f.Operator, f.Column))
+	}
+	return column + " " + operator + " " + w.arg(f.Value), nil
+}
+
+// arg adds value as an argument and returns its placeholder
+func (w *tableWhere) arg(value string) string {
+	w.args = append(w.args, value)
+	return "$" + strconv.Itoa(len(w.args))
+}
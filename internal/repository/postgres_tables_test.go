@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTableWhere(t *testing.T) {
+	filters, err := ParseFilters(map[string]interface{}{
+		"category": "dairy",
+		"price":    map[string]interface{}{FilterGte: 1, FilterLt: 5},
+		"name":     map[string]interface{}{FilterIlike: "*milk*"},
+		"brand_id": map[string]interface{}{FilterIn: []string{"a", "b"}},
+		"sale":     map[string]interface{}{FilterIs: nil},
+	})
+	if err != nil {
+		t.Fatalf("ParseFilters() error = %v", err)
+	}
+	groups, err := ParseFilterGroups(map[string]interface{}{
+		FilterOr: []interface{}{
+			map[string]interface{}{"stock": map[string]interface{}{FilterGt: 0}},
+			map[string]interface{}{"backorder": true},
+		},
+	})
+	if err != nil {
+		t.Fatalf("ParseFilterGroups() error = %v", err)
+	}
+
+	where := &tableWhere{}
+	got, err := where.clause(filters, groups)
+	if err != nil {
+		t.Fatalf("clause() error = %v", err)
+	}
+	want := ` WHERE t."brand_id" IN ($1, $2) AND t."category" = $3 AND t."name" ILIKE $4 AND t."price" >= $5 AND t."price" < $6 AND t."sale" IS NULL AND (t."stock" > $7 OR t."backorder" = $8)`
+	if got != want {
+		t.Errorf("clause() =\n%s\nwant\n%s", got, want)
+	}
+	wantArgs := []interface{}{"a", "b", "dairy", "%milk%", "1", "5", "0", "true"}
+	if !reflect.DeepEqual(where.args, wantArgs) {
+		t.Errorf("args = %v, want %v", where.args, wantArgs)
+	}
+}
+
+func TestTableWhereRejectsNonColumns(t *testing.T) {
+	where := &tableWhere{}
+	if _, err := where.clause([]Filter{{Column: "data->>name", Operator: FilterEq, Value: "x"}}, nil); GetStatusCode(err) != 400 {
+		t.Errorf("clause() error = %v, want a validation error", err)
+	}
+}
+
+func TestTableSelectAndOrder(t *testing.T) {
+	options, err := NewQueryOptions(WithColumns("id", "name"), WithOrderBy(OrderBy{Column: "price", Direction: SortDesc}, OrderBy{Column: "id"}))
+	if err != nil {
+		t.Fatalf("NewQueryOptions() error = %v", err)
+	}
+
+	if got, _ := tableSelectList(options); got != `jsonb_build_object('id', t."id", 'name', t."name")` {
+		t.Errorf("tableSelectList() = %s", got)
+	}
+	if got, _ := tableSelectList(QueryOptions{}); got != "to_jsonb(t)" {
+		t.Errorf("tableSelectList() without columns = %s, want every column", got)
+	}
+	if got, _ := tableOrderBy(options); got != ` ORDER BY t."price" DESC NULLS LAST, t."id" ASC NULLS LAST` {
+		t.Errorf("tableOrderBy() = %s", got)
+	}
+
+	embedded, _ := NewQueryOptions(WithColumns("id", "brands(name)"))
+	if _, err := tableSelectList(embedded); GetStatusCode(err) != 400 {
+		t.Errorf("tableSelectList() with an embedded resource error = %v, want a validation error", err)
+	}
+}
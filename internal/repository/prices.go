@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+// ProductPrice is one store's offer in a price comparison
+// EffectiveCost is the sale price when set, else the price, plus the store's delivery fee.
+// DistanceKm and DeliversTo are only set when the comparison is for a location
+type ProductPrice struct {
+	StoreProductID        string    `db:"store_product_id" json:"store_product_id"`
+	StoreID               string    `db:"store_id" json:"store_id"`
+	StoreName             string    `db:"store_name" json:"store_name"`
+	StoreType             string    `db:"store_type" json:"store_type"`
+	Price                 float64   `db:"price" json:"price"`
+	SalePrice             *float64  `db:"sale_price" json:"sale_price"`
+	DeliveryFee           float64   `db:"delivery_fee" json:"delivery_fee"`
+	EffectiveCost         float64   `db:"effective_cost" json:"effective_cost"`
+	EstimatedDeliveryTime *int      `db:"estimated_delivery_time" json:"estimated_delivery_time"`
+	StockQuantity         float64   `db:"stock_quantity" json:"stock_quantity"`
+	IsInStock             bool      `db:"is_in_stock" json:"is_in_stock"`
+	DistanceKm            *float64  `db:"distance_km" json:"distance_km"`
+	DeliversTo            *bool     `db:"delivers_to" json:"delivers_to"`
+	UpdatedAt             time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// PriceComparison is a catalog product with every active store's offer for it
+type PriceComparison struct {
+	Product Product        `json:"product"`
+	Prices  []ProductPrice `json:"prices"`
+}
+
+// CompareProductPrices retrieves a product and its offers in every active store listing it,
+// cheapest effective cost first. With a location (lat and lng both set), offers include
+// the distance to each store and whether its delivery zones cover the location, and ties
+// go to the nearer store
+func (r *PostgresRepository) CompareProductPrices(ctx context.Context, productID string, lat, lng *float64) (*PriceComparison, error) {
+	product, err := queryRow(ctx, r, pgx.RowToStructByName[Product], `
+		SELECT `+productColumns+` FROM products WHERE id = $1 AND is_active = true
+	`, productID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, NewNotFoundError("products", productID)
+	}
+	if err != nil {
+		r.log(ctx).Error("Failed to get product for price comparison", zap.String("product_id", productID), zap.Error(err))
+		return nil, NewQueryError(err)
+	}
+
+	// $2 and $3 are NULL without a location, making distance_km and delivers_to NULL
+	prices, err := queryRows(ctx, r, pgx.RowToStructByName[ProductPrice], `
+		WITH origin AS (
+			SELECT ST_SetSRID(ST_MakePoint($3::float8, $2::float8), 4326)::geography AS point
+		)
+		SELECT sp.id AS store_product_id, sp.store_id, s.name AS store_name, s.store_type,
+		       sp.price, sp.sale_price, COALESCE(s.delivery_fee, 0) AS delivery_fee,
+		       COALESCE(sp.sale_price, sp.price) + COALESCE(s.delivery_fee, 0) AS effective_cost,
+		       s.estimated_delivery_time, sp.stock_quantity, sp.is_in_stock,
+		       ST_Distance(s.location, origin.point) / 1000 AS distance_km,
+		       CASE WHEN origin.point IS NULL THEN NULL ELSE EXISTS (
+		           SELECT 1 FROM delivery_zones z
+		           WHERE z.store_id = s.id AND z.is_active = true AND ST_Covers(z.area, origin.point)
+		       ) END AS delivers_to,
+		       sp.updated_at
+		FROM store_products sp
+		JOIN stores s ON s.id = sp.store_id AND s.is_active = true
+		CROSS JOIN origin
+		WHERE sp.product_id = $1 AND sp.is_available = true
+		ORDER BY effective_cost, distance_km NULLS LAST, s.name
+	`, productID, lat, lng)
+	if err != nil {
+		r.log(ctx).Error("Failed to query product prices", zap.String("product_id", productID), zap.Error(err))
+		return nil, NewQueryError(err)
+	}
+
+	return &PriceComparison{Product: product, Prices: prices}, nil
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.uber.org/zap"
+)
+
+// ProductImage is one of a product's images
+type ProductImage struct {
+	ID           string    `db:"id" json:"id"`
+	ProductID    string    `db:"product_id" json:"product_id"`
+	ImageURL     string    `db:"image_url" json:"image_url"`
+	DisplayOrder int       `db:"display_order" json:"display_order"`
+	IsPrimary    bool      `db:"is_primary" json:"is_primary"`
+	CreatedAt    time.Time `db:"created_at" json:"created_at"`
+}
+
+// AddProductImage appends an image to a product's images, after the existing ones
+// The image becomes primary, replacing products.primary_image_url, when primary is set or
+// the product has no primary image yet
+func (r *PostgresRepository) AddProductImage(ctx context.Context, productID, imageURL string, primary bool) (*ProductImage, error) {
+	var image *ProductImage
+	err := r.WithTx(ctx, func(tx *PostgresRepository) error {
+		var err error
+		image, err = queryRow(ctx, tx, pgx.RowToAddrOfStructByName[ProductImage], `
+			INSERT INTO product_images (product_id, image_url, display_order, is_primary)
+			SELECT p.id, $2,
+				COALESCE((SELECT MAX(display_order) + 1 FROM product_images WHERE product_id = p.id), 0),
+				$3 OR NOT EXISTS (SELECT 1 FROM product_images WHERE product_id = p.id AND is_primary)
+			FROM products p WHERE p.id = $1
+			RETURNING id, product_id, image_url, display_order, is_primary, created_at
+		`, productID, imageURL, primary)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return NewNotFoundError("products", productID)
+		}
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return NewConflictError(fmt.Sprintf("product %s already has image %s", productID, imageURL), err)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to insert product image: %w", err)
+		}
+
+		before, after := map[string]any{}, map[string]any{"image_added": imageURL}
+		if image.IsPrimary {
+			var oldPrimary *string
+			err = tx.conn().QueryRow(ctx, `
+				WITH old AS (SELECT primary_image_url FROM products WHERE id = $1 FOR UPDATE),
+				unset AS (
+					UPDATE product_images SET is_primary = FALSE
+					WHERE product_id = $1 AND id <> $2 AND is_primary
+				),
+				updated AS (
+					UPDATE products SET primary_image_url = $3, updated_at = CURRENT_TIMESTAMP
+					WHERE id = $1
+				)
+				SELECT primary_image_url FROM old
+			`, productID, image.ID, imageURL).Scan(&oldPrimary)
+			if err != nil {
+				return fmt.Errorf("failed to set primary image: %w", err)
+			}
+			before["primary_image_url"], after["primary_image_url"] = oldPrimary, imageURL
+		}
+
+		tx.recordAudit(ctx, AuditProductUpdate, []string{productID, image.ID}, before, after)
+		return nil
+	})
+	if IsRepositoryError(err) {
+		return nil, err
+	}
+	if err != nil {
+		r.log(ctx).Error("Failed to add product image", zap.String("product_id", productID), zap.Error(err))
+		return nil, NewQueryError(err)
+	}
+
+	r.log(ctx).Info("Added product image",
+		zap.String("product_id", productID),
+		zap.String("image_id", image.ID),
+		zap.Bool("primary", image.IsPrimary))
+	return image, nil
+}
+
+// GetProductImage retrieves one of a product's images
+func (r *PostgresRepository) GetProductImage(ctx context.Context, productID, imageID string) (*ProductImage, error) {
+	image, err := queryRow(ctx, r, pgx.RowToAddrOfStructByName[ProductImage], `
+		SELECT id, product_id, image_url, COALESCE(display_order, 0) AS display_order,
+			COALESCE(is_primary, FALSE) AS is_primary, created_at
+		FROM product_images WHERE product_id = $1 AND id = $2
+	`, productID, imageID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, NewNotFoundError("product_images", imageID)
+	}
+	if err != nil {
+		r.log(ctx).Error("Failed to get product image",
+			zap.String("product_id", productID),
+			zap.String("image_id", imageID),
+			zap.Error(err))
+		return nil, NewQueryError(err)
+	}
+	return image, nil
+}
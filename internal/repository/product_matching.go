@@ -2,265 +2,1134 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
+// pushTracer records the matching and writing phases of a push, so its time can be told
+// apart from that of the statements they run
+var pushTracer = otel.Tracer("github.com/yourusername/supabase-redis-middleware/internal/repository")
+
+// productMatch is the matching engine's verdict for one pushed product
+type productMatch struct {
+	productID  string
+	matchType  string
+	confidence float64
+	found      bool
+}
+
+// PushOptions controls how UpsertProductsWithMatching handles failures and reconciliation
+type PushOptions struct {
+	// PartialSuccess skips products that fail to save and reports them in
+	// UpsertResult.Failures instead of stopping the push
+	PartialSuccess bool
+
+	// FullSync treats the push as the store's whole catalog: once every chunk has
+	// committed, the store's listings missing from the pushed store products are marked
+	// unavailable and counted in UpsertResult.Deactivated
+	FullSync bool
+
+	// Retain lists further external product IDs a full sync must not deactivate,
+	// such as products set aside before the push
+	Retain []string
+}
+
+// ProductPush is a complete push, written by DryRunProductPush in one transaction
+type ProductPush struct {
+	Store         StoreDetailsInput
+	Categories    []CategoryInput
+	Taxes         []TaxInput
+	Products      []ProductInput
+	Variations    []VariationInput
+	StoreProducts []StoreProductInput
+}
+
+// ProductMatch is the matching engine's decision for one pushed product
+// Action is "create" for a new product or "update" for a matched one
+type ProductMatch struct {
+	Index             int // Position in the pushed products
+	ExternalProductID string
+	Action            string
+	ProductID         string // Matched product, empty for creates
+	MatchType         string
+	Confidence        float64
+}
+
+// PushFailure is a pushed product that could not be saved
+// Errors lists the product's invalid fields when it failed validation
+type PushFailure struct {
+	Index             int // Position in the pushed products
+	ExternalProductID string
+	Reason            string
+	Errors            []FieldError
+}
+
 // UpsertProductsWithMatching creates or updates products using the product matching engine
+// Products are processed in chunks of the configured push chunk size, each in its own
+// transaction together with its store products and variations, so a very large push
+// commits progressively. If a chunk fails, the returned result still describes the chunks
+// committed before it alongside the error. Inside WithTx the chunks are savepoints, which
+// only commit with its transaction
 func (r *PostgresRepository) UpsertProductsWithMatching(
 	ctx context.Context,
 	storeExternalID string,
 	products []ProductInput,
 	variations []VariationInput,
 	storeProducts []StoreProductInput,
+	opts PushOptions,
 ) (*UpsertResult, error) {
-	tx, err := r.pool.Begin(ctx)
+	stream, err := r.StartProductPush(ctx, storeExternalID, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	chunks := chunkProductPush(products, variations, storeProducts, r.pushChunkSize)
+	stream.result.TotalChunks = len(chunks)
+	if err := stream.writeChunks(ctx, chunks); err != nil {
+		return stream.result, err
+	}
+	return stream.Finish(ctx)
+}
+
+// ProductPushStream is a push whose products are written a batch at a time as they are
+// read, for pushes too large to hold at once. Each batch is committed in chunks as
+// UpsertProductsWithMatching commits a whole push; only the external IDs of its listings
+// are kept, for a full sync, until Finish. Start one with StartProductPush
+type ProductPushStream struct {
+	r               *PostgresRepository
+	storeExternalID string
+	storeUUID       string
+	opts            PushOptions
+	result          *UpsertResult
+	written         int      // Products written so far; the next batch's first index
+	listings        []string // External IDs of the listings written, for a full sync
+}
+
+// StartProductPush starts a push to the store with external ID storeExternalID, which
+// must already exist
+func (r *PostgresRepository) StartProductPush(ctx context.Context, storeExternalID string, opts PushOptions) (*ProductPushStream, error) {
+	// Get store UUID from external_id
+	var storeUUID string
+	err := r.conn().QueryRow(ctx, `SELECT id FROM stores WHERE external_id = $1`, storeExternalID).Scan(&storeUUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find store: %w", err)
+	}
+
+	return &ProductPushStream{
+		r:               r,
+		storeExternalID: storeExternalID,
+		storeUUID:       storeUUID,
+		opts:            opts,
+		result:          &UpsertResult{StoreID: storeUUID},
+	}, nil
+}
+
+// Write commits a batch of products with their variations and store products. The
+// indexes of its matches and failures follow on from the previous batch's. If it fails,
+// the push stops: Result describes what was committed
+func (s *ProductPushStream) Write(ctx context.Context, products []ProductInput, variations []VariationInput, storeProducts []StoreProductInput) error {
+	if len(products) == 0 {
+		return nil
+	}
+	chunks := chunkProductPush(products, variations, storeProducts, s.r.pushChunkSize)
+	for i := range chunks {
+		chunks[i].offset += s.written
+	}
+	s.result.TotalChunks += len(chunks)
+	return s.writeChunks(ctx, chunks)
+}
+
+// Retain keeps the listings of products set aside instead of written from a full sync's
+// deactivation
+func (s *ProductPushStream) Retain(externalIDs ...string) {
+	s.opts.Retain = append(s.opts.Retain, externalIDs...)
+}
+
+// Result describes what the push has committed so far
+func (s *ProductPushStream) Result() *UpsertResult {
+	return s.result
+}
+
+// Finish completes a push every batch of which was written, deactivating the listings a
+// full sync left out, and records it in the audit log
+func (s *ProductPushStream) Finish(ctx context.Context) (*UpsertResult, error) {
+	r, result := s.r, s.result
+	if s.opts.FullSync {
+		err := r.retry(ctx, "deactivate missing store products", func() (err error) {
+			deactivated := newAuditedRows()
+			result.Deactivated, err = r.deactivateMissingStoreProducts(ctx, r.conn(), s.storeUUID, s.listings, s.opts.Retain, deactivated)
+			if err == nil {
+				result.add(&UpsertResult{listings: deactivated})
+			}
+			return err
+		})
+		if err != nil {
+			r.log(ctx).Error("Failed to deactivate store products missing from full push",
+				zap.String("store_id", s.storeExternalID),
+				zap.Error(err))
+			r.auditPush(ctx, s.storeExternalID, result)
+			return result, err
+		}
+	}
+
+	r.log(ctx).Info("Successfully upserted products with matching",
+		zap.Int("created", result.Created),
+		zap.Int("updated", result.Updated),
+		zap.Int("variations", result.VariationsProcessed),
+		zap.Int("store_products", result.StoreProductsProcessed),
+		zap.Int("taxes", result.TaxesProcessed),
+		zap.Int("failed", len(result.Failures)),
+		zap.Int("deactivated", result.Deactivated),
+		zap.Int("chunks", result.TotalChunks))
+
+	r.auditPush(ctx, s.storeExternalID, result)
+	return result, nil
+}
+
+// writeChunks commits chunks in order, stopping at the first that fails
+func (s *ProductPushStream) writeChunks(ctx context.Context, chunks []productPushChunk) error {
+	r, result := s.r, s.result
+	for _, chunk := range chunks {
+		i := len(result.Chunks)
+		started := time.Now()
+		chunk.fullSync = s.opts.FullSync
+		chunkResult, err := r.upsertProductChunk(ctx, s.storeUUID, chunk)
+		if err != nil && s.opts.PartialSuccess && len(chunk.products) > 0 {
+			r.log(ctx).Warn("Product push chunk failed, retrying product by product",
+				zap.Int("chunk", i+1),
+				zap.Error(err))
+			chunkResult, err = r.upsertChunkPerProduct(ctx, s.storeUUID, chunk)
+		}
+		if err != nil {
+			r.log(ctx).Error("Product push chunk failed",
+				zap.Int("chunk", i+1),
+				zap.Int("chunks", result.TotalChunks),
+				zap.Int("chunks_committed", len(result.Chunks)),
+				zap.Error(err))
+			r.auditPush(ctx, s.storeExternalID, result)
+			return fmt.Errorf("chunk %d of %d: %w", i+1, result.TotalChunks, err)
+		}
+
+		result.add(chunkResult)
+		result.Chunks = append(result.Chunks, ChunkProgress{
+			Index:    i,
+			Products: len(chunk.products),
+			Created:  chunkResult.Created,
+			Updated:  chunkResult.Updated,
+			Failed:   len(chunkResult.Failures),
+			Duration: time.Since(started),
+		})
+		s.written += len(chunk.products)
+		s.listings = append(s.listings, listingIDs(chunk.storeProducts)...)
+
+		if result.TotalChunks > 1 {
+			r.log(ctx).Info("Committed product push chunk",
+				zap.Int("chunk", i+1),
+				zap.Int("chunks", result.TotalChunks),
+				zap.Int("products", len(chunk.products)))
+		}
+	}
+	return nil
+}
+
+// auditPush records the committed part of a push: the store, every saved product and the
+// changes to the store's listings
+func (r *PostgresRepository) auditPush(ctx context.Context, storeExternalID string, result *UpsertResult) {
+	if len(result.Chunks) == 0 {
+		return
+	}
+
+	entityIDs := []string{storeExternalID, result.StoreID}
+	for _, m := range result.Matches {
+		entityIDs = append(entityIDs, m.ExternalProductID)
+	}
+
+	listings := result.listings
+	if listings == nil {
+		listings = newAuditedRows()
+	}
+	r.recordAudit(ctx, AuditProductPush, entityIDs, map[string]any{
+		"listings": listings.before,
+	}, map[string]any{
+		"products_created":           result.Created,
+		"products_updated":           result.Updated,
+		"variations_processed":       result.VariationsProcessed,
+		"store_products_processed":   result.StoreProductsProcessed,
+		"taxes_processed":            result.TaxesProcessed,
+		"products_failed":            len(result.Failures),
+		"store_products_deactivated": result.Deactivated,
+		"chunks_committed":           len(result.Chunks),
+		"chunks_total":               result.TotalChunks,
+		"listings":                   listings.after,
+	})
+}
+
+// DryRunProductPush runs a whole push - store, categories, taxes, matching, all product
+// writes and any full sync - in one transaction and rolls it back, returning what the push
+// would have done. Nothing is chunked, and the first write error is returned as it would
+// stop a real push, so opts.PartialSuccess is ignored
+func (r *PostgresRepository) DryRunProductPush(ctx context.Context, push ProductPush, opts PushOptions) (*UpsertResult, error) {
+	tx, err := r.conn().Begin(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	// Always rolled back: nothing a dry run writes is kept
 	defer tx.Rollback(ctx)
 
-	result := &UpsertResult{}
+	if err := r.upsertStore(ctx, tx, push.Store); err != nil {
+		return nil, fmt.Errorf("failed to upsert store: %w", err)
+	}
+	if len(push.Categories) > 0 {
+		if err := r.upsertCategories(ctx, tx, push.Categories); err != nil {
+			return nil, err
+		}
+	}
+	if len(push.Taxes) > 0 {
+		if err := r.upsertTaxes(ctx, tx, push.Taxes, push.Store.StoreID); err != nil {
+			return nil, err
+		}
+	}
 
-	// Get store UUID from external_id
 	var storeUUID string
-	err = tx.QueryRow(ctx, `SELECT id FROM stores WHERE external_id = $1`, storeExternalID).Scan(&storeUUID)
+	err = tx.QueryRow(ctx, `SELECT id FROM stores WHERE external_id = $1`, push.Store.StoreID).Scan(&storeUUID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to find store: %w", err)
 	}
 
-	// Map external product IDs to internal UUIDs
-	productIDMap := make(map[string]string)      // external_product_id -> product_uuid
-	storeProductIDMap := make(map[string]string) // external_product_id -> store_product_uuid
+	chunk := chunkProductPush(push.Products, push.Variations, push.StoreProducts, 0)[0]
+	chunk.fullSync = opts.FullSync
+	result, err := r.writeProductChunk(ctx, tx, storeUUID, chunk)
+	if err != nil {
+		return nil, err
+	}
 
-	// Process each product
-	for _, p := range products {
-		var productUUID string
-		var matchType string
-		var confidence float64
+	if opts.FullSync {
+		result.Deactivated, err = r.deactivateMissingStoreProducts(ctx, tx, storeUUID, listingIDs(push.StoreProducts), opts.Retain, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	r.log(ctx).Info("Dry run product push",
+		zap.String("store_id", push.Store.StoreID),
+		zap.Int("created", result.Created),
+		zap.Int("updated", result.Updated),
+		zap.Int("variations", result.VariationsProcessed),
+		zap.Int("store_products", result.StoreProductsProcessed),
+		zap.Int("deactivated", result.Deactivated))
 
-		// Try to find matching product using the matching engine
-		err := tx.QueryRow(ctx, `
+	return result, nil
+}
+
+// deactivateMissingStoreProducts marks the store's available listings unavailable unless
+// their external ID is among the pushed listings or retain, recording them in audited if
+// it isn't nil. Returns how many changed
+func (r *PostgresRepository) deactivateMissingStoreProducts(ctx context.Context, db conn, storeUUID string, pushed, retain []string, audited *auditedRows) (int, error) {
+	keep := append(slices.Clip(pushed), retain...)
+
+	rows, _ := db.Query(ctx, `
+		UPDATE store_products
+		SET is_available = false, updated_at = CURRENT_TIMESTAMP
+		WHERE store_id = $1
+		  AND is_available = true
+		  AND (external_id IS NULL OR NOT (external_id = ANY($2)))
+		RETURNING external_id
+	`, storeUUID, keep)
+	deactivated, err := pgx.CollectRows(rows, pgx.RowTo[*string])
+	if err != nil {
+		return 0, fmt.Errorf("failed to deactivate store products missing from push: %w", err)
+	}
+
+	if audited != nil {
+		for _, externalID := range deactivated {
+			if externalID != nil {
+				audited.add(*externalID, map[string]any{"is_available": true}, map[string]any{"is_available": false})
+			}
+		}
+	}
+	return len(deactivated), nil
+}
+
+// listingIDs returns the external product IDs of store products
+func listingIDs(storeProducts []StoreProductInput) []string {
+	ids := make([]string, len(storeProducts))
+	for i, sp := range storeProducts {
+		ids[i] = sp.ExternalProductID
+	}
+	return ids
+}
+
+// productPushChunk is the slice of a push committed in one transaction
+type productPushChunk struct {
+	offset        int  // Index of the chunk's first product in the push
+	fullSync      bool // Part of a full sync, which makes pushed listings available again
+	products      []ProductInput
+	variations    []VariationInput
+	storeProducts []StoreProductInput
+}
+
+// chunkProductPush splits products into chunks of at most size, sending each store product
+// and variation with the chunk holding its product. Entries whose product isn't in the push
+// go with the first chunk, where they are reported as not found
+func chunkProductPush(products []ProductInput, variations []VariationInput, storeProducts []StoreProductInput, size int) []productPushChunk {
+	if size <= 0 {
+		size = len(products)
+	}
+
+	var chunks []productPushChunk
+	chunkOf := make(map[string]int, len(products))
+	for start := 0; start < len(products); start += size {
+		end := min(start+size, len(products))
+		for _, p := range products[start:end] {
+			chunkOf[p.ExternalProductID] = len(chunks)
+		}
+		chunks = append(chunks, productPushChunk{offset: start, products: products[start:end]})
+	}
+	if len(chunks) == 0 {
+		chunks = append(chunks, productPushChunk{})
+	}
+
+	for _, sp := range storeProducts {
+		i := chunkOf[sp.ExternalProductID]
+		chunks[i].storeProducts = append(chunks[i].storeProducts, sp)
+	}
+	for _, v := range variations {
+		i := chunkOf[v.ExternalProductID]
+		chunks[i].variations = append(chunks[i].variations, v)
+	}
+
+	return chunks
+}
+
+// upsertChunkPerProduct retries a failed chunk with one transaction per product, recording
+// the products that still fail instead of aborting the push
+func (r *PostgresRepository) upsertChunkPerProduct(ctx context.Context, storeUUID string, chunk productPushChunk) (*UpsertResult, error) {
+	result := &UpsertResult{StoreID: storeUUID}
+
+	for _, single := range chunkProductPush(chunk.products, chunk.variations, chunk.storeProducts, 1) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		single.offset += chunk.offset
+		single.fullSync = chunk.fullSync
+		singleResult, err := r.upsertProductChunk(ctx, storeUUID, single)
+		if err != nil {
+			p := single.products[0]
+			r.log(ctx).Warn("Skipping product that failed to save",
+				zap.String("external_product_id", p.ExternalProductID),
+				zap.Error(err))
+			result.Failures = append(result.Failures, PushFailure{
+				Index:             single.offset,
+				ExternalProductID: p.ExternalProductID,
+				Reason:            err.Error(),
+			})
+			continue
+		}
+
+		result.add(singleResult)
+	}
+
+	return result, nil
+}
+
+// upsertProductChunk writes one chunk of a push in a single transaction, retrying the
+// whole transaction on transient errors
+func (r *PostgresRepository) upsertProductChunk(ctx context.Context, storeUUID string, chunk productPushChunk) (*UpsertResult, error) {
+	var result *UpsertResult
+	err := r.retry(ctx, "product push chunk", func() (err error) {
+		result, err = r.commitProductChunk(ctx, storeUUID, chunk)
+		return err
+	})
+	return result, err
+}
+
+// commitProductChunk makes one attempt at writing and committing a chunk
+func (r *PostgresRepository) commitProductChunk(ctx context.Context, storeUUID string, chunk productPushChunk) (*UpsertResult, error) {
+	ctx, span := pushTracer.Start(ctx, "push.commit_chunk", trace.WithAttributes(
+		attribute.Int("push.products", len(chunk.products)),
+		attribute.Int("push.store_products", len(chunk.storeProducts)),
+	))
+	defer span.End()
+
+	tx, err := r.conn().Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	result, err := r.writeProductChunk(ctx, tx, storeUUID, chunk)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return result, nil
+}
+
+// writeProductChunk writes a chunk's products, store products and variations within tx
+// Matching runs as one pgx.Batch; products, images, store products, taxes and variations
+// are then each written with one CopyFrom into a staging table plus one set-based statement.
+// As when products were written one at a time, a brand or image that fails to save is
+// logged and skipped rather than failing the chunk
+func (r *PostgresRepository) writeProductChunk(ctx context.Context, tx pgx.Tx, storeUUID string, chunk productPushChunk) (*UpsertResult, error) {
+	products, variations, storeProducts := chunk.products, chunk.variations, chunk.storeProducts
+	result := &UpsertResult{StoreID: storeUUID}
+
+	// external_product_id -> product_uuid
+	productIDMap, err := r.upsertMatchedProducts(ctx, tx, storeUUID, chunk.offset, products, result)
+	if err != nil {
+		return nil, err
+	}
+
+	// Upsert store products FIRST (before variations, so we have store_product_id)
+	storeProductIDMap, err := r.upsertStoreProducts(ctx, tx, storeUUID, productIDMap, storeProducts, chunk.fullSync, result)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.upsertVariations(ctx, tx, storeProductIDMap, variations, result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// matchProducts runs the matching engine for every product in one batch
+func (r *PostgresRepository) matchProducts(ctx context.Context, tx pgx.Tx, storeUUID string, products []ProductInput) ([]productMatch, error) {
+	ctx, span := pushTracer.Start(ctx, "push.match_products", trace.WithAttributes(attribute.Int("push.products", len(products))))
+	defer span.End()
+
+	batch := &pgx.Batch{}
+	for _, p := range products {
+		batch.Queue(`
 			SELECT product_id, match_type, confidence
 			FROM find_matching_product($1, $2, $3, $4, $5, $6)
-		`, p.Name, p.Barcode, p.SKU, p.EAN, storeUUID, p.ExternalProductID).Scan(&productUUID, &matchType, &confidence)
+		`, p.Name, p.Barcode, p.SKU, p.EAN, storeUUID, p.ExternalProductID)
+	}
 
+	results := tx.SendBatch(ctx, batch)
+	defer results.Close()
+
+	matches := make([]productMatch, len(products))
+	for i := range products {
+		m := &matches[i]
+		err := results.QueryRow().Scan(&m.productID, &m.matchType, &m.confidence)
+		if errors.Is(err, pgx.ErrNoRows) {
+			continue
+		}
 		if err != nil {
-			// No match found - create new product
-			r.logger.Info("No matching product found, creating new",
-				zap.String("external_product_id", p.ExternalProductID),
-				zap.String("name", p.Name))
+			return nil, fmt.Errorf("failed to match product %s: %w", products[i].ExternalProductID, err)
+		}
+		m.found = true
+	}
 
-			// Find or create brand
-			var brandUUID *string
-			if p.Brand != "" {
-				var brandID string
-				err := tx.QueryRow(ctx, `SELECT find_or_create_brand($1)`, p.Brand).Scan(&brandID)
-				if err == nil && brandID != "" {
-					brandUUID = &brandID
-				}
-			}
+	return matches, results.Close()
+}
+
+// upsertMatchedProducts matches each product, inserts the new ones, updates the matched ones
+// and upserts their images, recording each decision in result.Matches at offset+i.
+// Returns external_product_id -> product_uuid
+func (r *PostgresRepository) upsertMatchedProducts(ctx context.Context, tx pgx.Tx, storeUUID string, offset int, products []ProductInput, result *UpsertResult) (map[string]string, error) {
+	productIDMap := make(map[string]string)
+	if len(products) == 0 {
+		return productIDMap, nil
+	}
+
+	matches, err := r.matchProducts(ctx, tx, storeUUID, products)
+	if err != nil {
+		return nil, err
+	}
 
-			// Find category UUID from external_id
-			var categoryUUID *string
-			if p.CategoryID != "" {
-				var catID string
-				err := tx.QueryRow(ctx, `SELECT id FROM categories WHERE external_id = $1`, p.CategoryID).Scan(&catID)
-				if err == nil {
-					categoryUUID = &catID
+	// The batch matched against the catalog as it was before the chunk; products created
+	// earlier in the chunk are candidates too, as they were when each product was matched
+	// once the ones before it were written
+	var unmatched []int
+	for i := range products {
+		if !matches[i].found {
+			unmatched = append(unmatched, i)
+		}
+	}
+	withinPush, err := r.matchWithinPush(ctx, tx, products, unmatched)
+	if err != nil {
+		return nil, err
+	}
+
+	// A product repeated within one push (same SKU, barcode or EAN) matches the copy
+	// created earlier in the push, and otherwise the earlier new product it best matches
+	// by name
+	createdBy := make(map[string]string)
+	createdAt := make(map[int]string)
+	var created []int
+	for i, p := range products {
+		if !matches[i].found {
+			for _, key := range productIdentityKeys(p) {
+				if id, ok := createdBy[key]; ok {
+					matches[i] = productMatch{productID: id, matchType: "exact", confidence: 1, found: true}
+					break
 				}
 			}
-
-			// Create new product
-			productUUID = uuid.New().String()
-			_, err = tx.Exec(ctx, `
-				INSERT INTO products (
-					id, sku, name, slug, description, category_id, brand_id,
-					base_price, currency, unit, unit_quantity, primary_image_url,
-					manufacturer, barcode, ean, is_active, is_featured,
-					is_customizable, is_addon
-				) VALUES (
-					$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19
-				)
-			`, productUUID, p.SKU, p.Name, p.Slug, p.Description, categoryUUID, brandUUID,
-				p.BasePrice, p.Currency, p.Unit, p.UnitQuantity, p.PrimaryImageURL,
-				p.Manufacturer, p.Barcode, p.EAN, p.IsActive, p.IsFeatured,
-				p.IsCustomizable, p.IsAddon)
-
-			if err != nil {
-				return nil, fmt.Errorf("failed to create product: %w", err)
+		}
+		if !matches[i].found {
+			for _, m := range withinPush[i] {
+				if id, ok := createdAt[m.earlier]; ok {
+					matches[i] = productMatch{productID: id, matchType: m.matchType, confidence: m.confidence, found: true}
+					break
+				}
 			}
+		}
 
-			result.Created++
+		decision := ProductMatch{Index: offset + i, ExternalProductID: p.ExternalProductID, Action: "create"}
+		if matches[i].found {
+			r.log(ctx).Info("Found matching product",
+				zap.String("external_product_id", p.ExternalProductID),
+				zap.String("product_uuid", matches[i].productID),
+				zap.String("match_type", matches[i].matchType),
+				zap.Float64("confidence", matches[i].confidence))
+			decision.Action = "update"
+			decision.ProductID = matches[i].productID
+			decision.MatchType = matches[i].matchType
+			decision.Confidence = matches[i].confidence
+			result.Updated++
 		} else {
-			// Match found
-			r.logger.Info("Found matching product",
+			r.log(ctx).Info("No matching product found, creating new",
 				zap.String("external_product_id", p.ExternalProductID),
-				zap.String("product_uuid", productUUID),
-				zap.String("match_type", matchType),
-				zap.Float64("confidence", confidence))
-
-			// Update existing product
-			_, err = tx.Exec(ctx, `
-				UPDATE products SET
-					name = $2,
-					description = $3,
-					base_price = $4,
-					primary_image_url = $5,
-					manufacturer = $6,
-					is_active = $7,
-					is_featured = $8,
-					updated_at = CURRENT_TIMESTAMP
-				WHERE id = $1
-			`, productUUID, p.Name, p.Description, p.BasePrice, p.PrimaryImageURL,
-				p.Manufacturer, p.IsActive, p.IsFeatured)
-
-			if err != nil {
-				return nil, fmt.Errorf("failed to update product: %w", err)
+				zap.String("name", p.Name))
+			matches[i].productID = uuid.New().String()
+			for _, key := range productIdentityKeys(p) {
+				createdBy[key] = matches[i].productID
 			}
+			createdAt[i] = matches[i].productID
+			created = append(created, i)
+			result.Created++
+		}
+		result.Matches = append(result.Matches, decision)
 
-			result.Updated++
+		productIDMap[p.ExternalProductID] = matches[i].productID
+	}
+
+	if err := r.insertNewProducts(ctx, tx, products, matches, created); err != nil {
+		return nil, err
+	}
+
+	var updates, images [][]any
+	for i, p := range products {
+		id := matches[i].productID
+		if matches[i].found {
+			updates = append(updates, []any{i, id, p.Name, p.Description, p.BasePrice, p.PrimaryImageURL,
+				p.Manufacturer, p.IsActive, p.IsFeatured})
 		}
+		for idx, imgURL := range p.Images {
+			images = append(images, []any{i, id, imgURL, idx, idx == 0})
+		}
+	}
 
-		// Store mapping
-		productIDMap[p.ExternalProductID] = productUUID
-
-		// Create/update store_product_mapping
-		// Store the external_product_id mapping for later use
-		// (will be stored in store_products.external_id)
-
-		// Insert product images
-		if len(p.Images) > 0 {
-			for idx, imgURL := range p.Images {
-				_, err := tx.Exec(ctx, `
-					INSERT INTO product_images (product_id, image_url, display_order, is_primary)
-					VALUES ($1, $2, $3, $4)
-					ON CONFLICT (product_id, image_url) DO UPDATE SET
-						display_order = EXCLUDED.display_order
-				`, productUUID, imgURL, idx, idx == 0)
-				if err != nil {
-					r.logger.Warn("Failed to insert product image", zap.Error(err))
-				}
-			}
+	if len(updates) > 0 {
+		err := stageRows(ctx, tx, "push_product_updates", `ord int, id text, name text, description text,
+			base_price float8, primary_image_url text, manufacturer text, is_active bool, is_featured bool`, updates)
+		if err != nil {
+			return nil, err
+		}
+
+		// The last occurrence of a product in the push wins
+		_, err = tx.Exec(ctx, `
+			UPDATE products p SET
+				name = u.name,
+				description = u.description,
+				base_price = u.base_price,
+				primary_image_url = u.primary_image_url,
+				manufacturer = u.manufacturer,
+				is_active = u.is_active,
+				is_featured = u.is_featured,
+				updated_at = CURRENT_TIMESTAMP
+			FROM (SELECT DISTINCT ON (id) * FROM push_product_updates ORDER BY id, ord DESC) u
+			WHERE p.id = u.id::uuid
+		`)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update products: %w", err)
 		}
 	}
 
-	// Upsert store products FIRST (before variations, so we have store_product_id)
-	if len(storeProducts) > 0 {
-		for _, sp := range storeProducts {
-			productUUID, ok := productIDMap[sp.ExternalProductID]
-			if !ok {
-				r.logger.Warn("Product not found for store product", zap.String("external_product_id", sp.ExternalProductID))
-				continue
-			}
+	if len(images) > 0 {
+		r.upsertProductImages(ctx, tx, images)
+	}
 
-			// Upsert store_product
-			var storeProductUUID string
-			err := tx.QueryRow(ctx, `
-				INSERT INTO store_products (
-					external_id, store_id, product_id, price, stock_quantity, is_in_stock, is_available
-				) VALUES ($1, $2, $3, $4, $5, $6, true)
-				ON CONFLICT (store_id, product_id) DO UPDATE SET
-					external_id = EXCLUDED.external_id,
-					price = EXCLUDED.price,
-					stock_quantity = EXCLUDED.stock_quantity,
-					is_in_stock = EXCLUDED.is_in_stock,
-					updated_at = CURRENT_TIMESTAMP
-				RETURNING id
-			`, sp.ExternalProductID, storeUUID, productUUID, sp.Price, sp.StockQuantity, sp.IsInStock).Scan(&storeProductUUID)
-
-			if err != nil {
-				r.logger.Error("Failed to upsert store product", zap.String("external_product_id", sp.ExternalProductID), zap.Error(err))
-				return nil, fmt.Errorf("failed to upsert store product: %w", err)
-			}
+	return productIDMap, nil
+}
 
-			// Store the mapping for variations
-			storeProductIDMap[sp.ExternalProductID] = storeProductUUID
-
-			// Upsert store product taxes
-			if len(sp.Taxes) > 0 {
-				for _, taxExternalID := range sp.Taxes {
-					// Find tax UUID by external_id (ERP's tax ID)
-					var taxUUID string
-					err := tx.QueryRow(ctx, `
-						SELECT id FROM taxes 
-						WHERE store_id = $1 AND external_id = $2
-					`, storeUUID, taxExternalID).Scan(&taxUUID)
-
-					if err != nil {
-						r.logger.Warn("Tax not found by external_id",
-							zap.String("external_id", taxExternalID),
-							zap.String("store_id", storeUUID))
-						continue
-					}
-
-					// Insert store_product_tax using internal UUID
-					_, err = tx.Exec(ctx, `
-						INSERT INTO store_product_taxes (store_id, store_product_id, tax_id, is_active)
-						VALUES ($1, $2, $3, true)
-						ON CONFLICT (store_id, store_product_id, tax_id) DO UPDATE SET
-							is_active = true,
-							updated_at = CURRENT_TIMESTAMP
-					`, storeUUID, storeProductUUID, taxUUID)
-
-					if err != nil {
-						r.logger.Warn("Failed to insert store product tax", zap.Error(err))
-					} else {
-						result.TaxesProcessed++
-					}
-				}
-			}
+// pushMatch is an earlier product of the same chunk that a pushed product matches by name
+type pushMatch struct {
+	earlier    int // Index in the chunk of the earlier product
+	matchType  string
+	confidence float64
+}
 
-			result.StoreProductsProcessed++
-		}
+// matchWithinPush matches the products at the given indexes, which matched nothing in the
+// catalog, against the active ones before them with find_matching_product's name layers:
+// normalized name with the same volume, then with the same weight, then trigram similarity.
+// Returns each product's candidates, best first; only those that end up created can match
+func (r *PostgresRepository) matchWithinPush(ctx context.Context, tx pgx.Tx, products []ProductInput, indexes []int) (map[int][]pushMatch, error) {
+	if len(indexes) < 2 {
+		return nil, nil
 	}
 
-	// Upsert variations AFTER store_products (so we have store_product_id mapping)
-	if len(variations) > 0 {
-		for _, v := range variations {
-			storeProductUUID, ok := storeProductIDMap[v.ExternalProductID]
-			if !ok {
-				r.logger.Warn("Store product not found for variation",
-					zap.String("external_product_id", v.ExternalProductID),
-					zap.String("variation_id", v.ExternalID))
-				continue
-			}
+	rows := make([][]any, len(indexes))
+	for n, i := range indexes {
+		rows[n] = []any{i, products[i].Name, products[i].IsActive}
+	}
+	if err := stageRows(ctx, tx, "push_unmatched", `ord int, name text, is_active bool`, rows); err != nil {
+		return nil, err
+	}
+
+	candidates, _ := tx.Query(ctx, `
+		WITH pushed AS (
+			SELECT ord, name, is_active, normalize_product_name(name) AS normalized,
+				extract_volume_ml(name) AS volume_ml, extract_weight_g(name) AS weight_g
+			FROM push_unmatched
+		), pairs AS (
+			SELECT l.ord, e.ord AS earlier,
+				CASE
+					WHEN e.normalized = l.normalized AND ABS(e.volume_ml - l.volume_ml) < 10 THEN 1
+					WHEN e.normalized = l.normalized AND ABS(e.weight_g - l.weight_g) < 10 THEN 2
+					WHEN similarity(e.name, l.name) > 0.45 THEN 3
+				END AS layer,
+				similarity(e.name, l.name) AS similarity
+			FROM pushed l
+			JOIN pushed e ON e.ord < l.ord AND e.is_active
+		)
+		SELECT ord, earlier,
+			CASE layer WHEN 1 THEN 'normalized_name_volume' WHEN 2 THEN 'normalized_name_weight' ELSE 'fuzzy' END,
+			CASE WHEN layer < 3 THEN 95 ELSE similarity * 100 END::float8
+		FROM pairs
+		WHERE layer IS NOT NULL
+		ORDER BY ord, layer, similarity DESC, earlier
+	`)
 
+	withinPush := make(map[int][]pushMatch)
+	var i int
+	var m pushMatch
+	_, err := pgx.ForEachRow(candidates, []any{&i, &m.earlier, &m.matchType, &m.confidence}, func() error {
+		withinPush[i] = append(withinPush[i], m)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to match products within push: %w", err)
+	}
+	return withinPush, nil
+}
+
+// upsertProductImages upserts staged image rows (ord, product_id, image_url, display_order,
+// is_primary) in a savepoint. If that fails, each image is retried in its own, and those
+// that still fail are logged and skipped
+func (r *PostgresRepository) upsertProductImages(ctx context.Context, tx pgx.Tx, images [][]any) {
+	err := pgx.BeginFunc(ctx, tx, func(tx pgx.Tx) error {
+		err := stageRows(ctx, tx, "push_product_images",
+			`ord int, product_id text, image_url text, display_order int, is_primary bool`, images)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx, `
+			INSERT INTO product_images (product_id, image_url, display_order, is_primary)
+			SELECT DISTINCT ON (product_id, image_url) product_id::uuid, image_url, display_order, is_primary
+			FROM push_product_images
+			ORDER BY product_id, image_url, ord DESC
+			ON CONFLICT (product_id, image_url) DO UPDATE SET
+				display_order = EXCLUDED.display_order
+		`)
+		return err
+	})
+	if err == nil || ctx.Err() != nil {
+		return
+	}
+	r.log(ctx).Warn("Failed to upsert product images, retrying one at a time", zap.Error(err))
+
+	for _, image := range images {
+		err := pgx.BeginFunc(ctx, tx, func(tx pgx.Tx) error {
 			_, err := tx.Exec(ctx, `
-				INSERT INTO product_variations (
-					external_id, store_product_id, name, display_name, price, is_default, is_active
-				) VALUES ($1, $2, $3, $4, $5, $6, true)
-				ON CONFLICT (store_product_id, name) DO UPDATE SET
-					external_id = EXCLUDED.external_id,
-					display_name = EXCLUDED.display_name,
-					price = EXCLUDED.price,
-					is_default = EXCLUDED.is_default,
-					updated_at = CURRENT_TIMESTAMP
-			`, v.ExternalID, storeProductUUID, v.Name, v.DisplayName, v.Price, v.IsDefault)
-
-			if err != nil {
-				r.logger.Error("Failed to upsert variation",
-					zap.String("external_product_id", v.ExternalProductID),
-					zap.String("variation_id", v.ExternalID),
-					zap.Error(err))
-				return nil, fmt.Errorf("failed to upsert variation: %w", err)
-			}
-			result.VariationsProcessed++
+				INSERT INTO product_images (product_id, image_url, display_order, is_primary)
+				VALUES ($1, $2, $3, $4)
+				ON CONFLICT (product_id, image_url) DO UPDATE SET
+					display_order = EXCLUDED.display_order
+			`, image[1:]...)
+			return err
+		})
+		if err != nil {
+			r.log(ctx).Warn("Failed to insert product image",
+				zap.Any("product_id", image[1]),
+				zap.Any("image_url", image[2]),
+				zap.Error(err))
 		}
 	}
+}
 
-	if err := tx.Commit(ctx); err != nil {
-		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+// productIdentityKeys returns the exact-match identifiers of a product
+func productIdentityKeys(p ProductInput) []string {
+	var keys []string
+	if p.SKU != "" {
+		keys = append(keys, "sku:"+p.SKU)
+	}
+	if p.Barcode != "" {
+		keys = append(keys, "barcode:"+p.Barcode)
+	}
+	if p.EAN != "" {
+		keys = append(keys, "ean:"+p.EAN)
 	}
+	return keys
+}
 
-	r.logger.Info("Successfully upserted products with matching",
-		zap.Int("created", result.Created),
-		zap.Int("updated", result.Updated),
-		zap.Int("variations", result.VariationsProcessed),
-		zap.Int("store_products", result.StoreProductsProcessed),
-		zap.Int("taxes", result.TaxesProcessed))
+// insertNewProducts creates the products at the given indexes, resolving their brands and categories
+func (r *PostgresRepository) insertNewProducts(ctx context.Context, tx pgx.Tx, products []ProductInput, matches []productMatch, indexes []int) error {
+	if len(indexes) == 0 {
+		return nil
+	}
 
-	return result, nil
+	brandIDs, err := r.findOrCreateBrands(ctx, tx, products, indexes)
+	if err != nil {
+		return err
+	}
+
+	categoryIDs, err := r.findCategoriesByExternalID(ctx, tx, products, indexes)
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]any, 0, len(indexes))
+	for _, i := range indexes {
+		p := products[i]
+		var brandUUID, categoryUUID *string
+		if id, ok := brandIDs[p.Brand]; ok {
+			brandUUID = &id
+		}
+		if id, ok := categoryIDs[p.CategoryID]; ok {
+			categoryUUID = &id
+		}
+
+		rows = append(rows, []any{matches[i].productID, p.SKU, p.Name, p.Slug, p.Description, categoryUUID, brandUUID,
+			p.BasePrice, p.Currency, p.Unit, p.UnitQuantity, p.PrimaryImageURL,
+			p.Manufacturer, p.Barcode, p.EAN, p.IsActive, p.IsFeatured,
+			p.IsCustomizable, p.IsAddon})
+	}
+
+	err = stageRows(ctx, tx, "push_new_products", `id text, sku text, name text, slug text, description text,
+		category_id text, brand_id text, base_price float8, currency text, unit text, unit_quantity float8,
+		primary_image_url text, manufacturer text, barcode text, ean text, is_active bool, is_featured bool,
+		is_customizable bool, is_addon bool`, rows)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO products (
+			id, sku, name, slug, description, category_id, brand_id,
+			base_price, currency, unit, unit_quantity, primary_image_url,
+			manufacturer, barcode, ean, is_active, is_featured,
+			is_customizable, is_addon
+		)
+		SELECT
+			id::uuid, sku, name, slug, description, category_id::uuid, brand_id::uuid,
+			base_price, currency, unit, unit_quantity, primary_image_url,
+			manufacturer, barcode, ean, is_active, is_featured,
+			is_customizable, is_addon
+		FROM push_new_products
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create products: %w", err)
+	}
+
+	return nil
+}
+
+// findOrCreateBrands resolves each distinct brand name of the given products in one batch,
+// run in a savepoint. If that fails, each brand is retried in its own; products whose brand
+// still fails are created without one, and the failure logged
+func (r *PostgresRepository) findOrCreateBrands(ctx context.Context, tx pgx.Tx, products []ProductInput, indexes []int) (map[string]string, error) {
+	var names []string
+	seen := make(map[string]bool)
+	for _, i := range indexes {
+		if name := products[i].Brand; name != "" && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	brandIDs := make(map[string]string, len(names))
+	if len(names) == 0 {
+		return brandIDs, nil
+	}
+
+	err := pgx.BeginFunc(ctx, tx, func(tx pgx.Tx) error {
+		return resolveBrands(ctx, tx, names, brandIDs)
+	})
+	if err == nil {
+		return brandIDs, nil
+	}
+	if ctx.Err() != nil {
+		return nil, err
+	}
+	r.log(ctx).Warn("Failed to resolve brands, retrying one at a time", zap.Error(err))
+
+	clear(brandIDs)
+	for _, name := range names {
+		err := pgx.BeginFunc(ctx, tx, func(tx pgx.Tx) error {
+			return resolveBrands(ctx, tx, []string{name}, brandIDs)
+		})
+		if err != nil {
+			r.log(ctx).Warn("Failed to resolve brand, creating its products without one",
+				zap.String("brand", name),
+				zap.Error(err))
+		}
+	}
+	return brandIDs, nil
+}
+
+// resolveBrands finds or creates each named brand in one batch, adding their IDs to brandIDs
+func resolveBrands(ctx context.Context, tx pgx.Tx, names []string, brandIDs map[string]string) error {
+	batch := &pgx.Batch{}
+	for _, name := range names {
+		batch.Queue(`SELECT find_or_create_brand($1)`, name)
+	}
+
+	results := tx.SendBatch(ctx, batch)
+	defer results.Close()
+
+	for _, name := range names {
+		var brandID *string
+		if err := results.QueryRow().Scan(&brandID); err != nil {
+			return fmt.Errorf("failed to resolve brand %s: %w", name, err)
+		}
+		if brandID != nil && *brandID != "" {
+			brandIDs[name] = *brandID
+		}
+	}
+
+	return results.Close()
+}
+
+// findCategoriesByExternalID maps the given products' external category IDs to category UUIDs
+func (r *PostgresRepository) findCategoriesByExternalID(ctx context.Context, tx pgx.Tx, products []ProductInput, indexes []int) (map[string]string, error) {
+	var externalIDs []string
+	for _, i := range indexes {
+		if id := products[i].CategoryID; id != "" {
+			externalIDs = append(externalIDs, id)
+		}
+	}
+
+	categoryIDs := make(map[string]string)
+	if len(externalIDs) == 0 {
+		return categoryIDs, nil
+	}
+
+	rows, err := tx.Query(ctx, `SELECT external_id, id FROM categories WHERE external_id = ANY($1)`, externalIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up categories: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var externalID, id string
+		if err := rows.Scan(&externalID, &id); err != nil {
+			return nil, fmt.Errorf("failed to scan category: %w", err)
+		}
+		categoryIDs[externalID] = id
+	}
+
+	return categoryIDs, rows.Err()
+}
+
+// upsertStoreProducts upserts the store's listings and their taxes. New listings are
+// available; existing ones keep their availability, which the store may have toggled off,
+// unless fullSync restates the catalog and so makes every pushed listing available again
+// Returns external_product_id -> store_product_uuid for the variations that follow
+func (r *PostgresRepository) upsertStoreProducts(ctx context.Context, tx pgx.Tx, storeUUID string, productIDMap map[string]string, storeProducts []StoreProductInput, fullSync bool, result *UpsertResult) (map[string]string, error) {
+	storeProductIDMap := make(map[string]string)
+
+	var rows [][]any
+	for i, sp := range storeProducts {
+		productUUID, ok := productIDMap[sp.ExternalProductID]
+		if !ok {
+			r.log(ctx).Warn("Product not found for store product", zap.String("external_product_id", sp.ExternalProductID))
+			continue
+		}
+		rows = append(rows, []any{i, sp.ExternalProductID, productUUID, sp.Price, sp.StockQuantity, sp.IsInStock})
+	}
+	if len(rows) == 0 {
+		return storeProductIDMap, nil
+	}
+
+	err := stageRows(ctx, tx, "push_store_products",
+		`ord int, external_id text, product_id text, price float8, stock_quantity float8, is_in_stock bool`, rows)
+	if err != nil {
+		return nil, err
+	}
+
+	// Two ERP products matched to one catalog product share a listing; the last one pushed
+	// wins. previous holds the listings as they were, for the audit log
+	columns := append([]string{"external_id"}, listingAuditColumns...)
+	upserted, _ := tx.Query(ctx, `
+		WITH previous AS (
+			SELECT product_id, `+auditStateSQL("sp", columns)+` AS state
+			FROM store_products sp
+			WHERE store_id = $1::uuid AND product_id IN (SELECT product_id::uuid FROM push_store_products)
+		), upserted AS (
+			INSERT INTO store_products (
+				external_id, store_id, product_id, price, stock_quantity, is_in_stock, is_available
+			)
+			SELECT DISTINCT ON (product_id) external_id, $1::uuid, product_id::uuid, price, stock_quantity, is_in_stock, true
+			FROM push_store_products
+			ORDER BY product_id, ord DESC
+			ON CONFLICT (store_id, product_id) DO UPDATE SET
+				external_id = EXCLUDED.external_id,
+				price = EXCLUDED.price,
+				stock_quantity = EXCLUDED.stock_quantity,
+				is_in_stock = EXCLUDED.is_in_stock,
+				is_available = store_products.is_available OR $2,
+				updated_at = CURRENT_TIMESTAMP
+			RETURNING id, product_id, `+auditStateSQL("store_products", columns)+` AS state
+		)
+		SELECT u.id::text, u.product_id::text, u.state->>'external_id', p.state, u.state
+		FROM upserted u
+		LEFT JOIN previous p ON p.product_id = u.product_id
+	`, storeUUID, fullSync)
+
+	storeProductByProduct := make(map[string]string, len(rows))
+	result.listings = newAuditedRows()
+	var storeProductID, productID, externalID string
+	var before, after map[string]any
+	_, err = pgx.ForEachRow(upserted, []any{&storeProductID, &productID, &externalID, &before, &after}, func() error {
+		storeProductByProduct[productID] = storeProductID
+		result.listings.add(externalID, before, after)
+		before, after = nil, nil
+		return nil
+	})
+	if err != nil {
+		r.log(ctx).Error("Failed to upsert store products", zap.Error(err))
+		return nil, fmt.Errorf("failed to upsert store products: %w", err)
+	}
+
+	var taxes [][]any
+	for _, sp := range storeProducts {
+		productUUID, ok := productIDMap[sp.ExternalProductID]
+		if !ok {
+			continue
+		}
+		storeProductUUID := storeProductByProduct[productUUID]
+		storeProductIDMap[sp.ExternalProductID] = storeProductUUID
+		result.StoreProductsProcessed++
+
+		for _, taxExternalID := range sp.Taxes {
+			taxes = append(taxes, []any{storeProductUUID, taxExternalID})
+		}
+	}
+
+	if err := r.upsertStoreProductTaxes(ctx, tx, storeUUID, taxes, result); err != nil {
+		return nil, err
+	}
+
+	return storeProductIDMap, nil
+}
+
+// upsertStoreProductTaxes links store products to the store's taxes by the ERP's tax IDs
+// Unknown tax IDs are logged and skipped
+func (r *PostgresRepository) upsertStoreProductTaxes(ctx context.Context, tx pgx.Tx, storeUUID string, taxes [][]any, result *UpsertResult) error {
+	if len(taxes) == 0 {
+		return nil
+	}
+
+	err := stageRows(ctx, tx, "push_store_product_taxes", `store_product_id text, tax_external_id text`, taxes)
+	if err != nil {
+		return err
+	}
+
+	missing, _ := tx.Query(ctx, `
+		SELECT DISTINCT s.tax_external_id
+		FROM push_store_product_taxes s
+		WHERE NOT EXISTS (
+			SELECT 1 FROM taxes t WHERE t.store_id = $1::uuid AND t.external_id = s.tax_external_id
+		)
+	`, storeUUID)
+	unknown, err := pgx.CollectRows(missing, pgx.RowTo[string])
+	if err != nil {
+		return fmt.Errorf("failed to check store product taxes: %w", err)
+	}
+	if len(unknown) > 0 {
+		r.log(ctx).Warn("Tax not found by external_id",
+			zap.Strings("external_ids", unknown),
+			zap.String("store_id", storeUUID))
+	}
+
+	tag, err := tx.Exec(ctx, `
+		INSERT INTO store_product_taxes (store_id, store_product_id, tax_id, is_active)
+		SELECT DISTINCT $1::uuid, s.store_product_id::uuid, t.id, true
+		FROM push_store_product_taxes s
+		JOIN taxes t ON t.store_id = $1::uuid AND t.external_id = s.tax_external_id
+		ON CONFLICT (store_id, store_product_id, tax_id) DO UPDATE SET
+			is_active = true,
+			updated_at = CURRENT_TIMESTAMP
+	`, storeUUID)
+	if err != nil {
+		return fmt.Errorf("failed to upsert store product taxes: %w", err)
+	}
+
+	result.TaxesProcessed += int(tag.RowsAffected())
+	return nil
+}
+
+// upsertVariations upserts variations of the pushed store products
+func (r *PostgresRepository) upsertVariations(ctx context.Context, tx pgx.Tx, storeProductIDMap map[string]string, variations []VariationInput, result *UpsertResult) error {
+	var rows [][]any
+	for i, v := range variations {
+		storeProductUUID, ok := storeProductIDMap[v.ExternalProductID]
+		if !ok {
+			r.log(ctx).Warn("Store product not found for variation",
+				zap.String("external_product_id", v.ExternalProductID),
+				zap.String("variation_id", v.ExternalID))
+			continue
+		}
+		rows = append(rows, []any{i, v.ExternalID, storeProductUUID, v.Name, v.DisplayName, v.Price, v.IsDefault})
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	err := stageRows(ctx, tx, "push_variations",
+		`ord int, external_id text, store_product_id text, name text, display_name text, price float8, is_default bool`, rows)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO product_variations (
+			external_id, store_product_id, name, display_name, price, is_default, is_active
+		)
+		SELECT DISTINCT ON (store_product_id, name)
+			external_id, store_product_id::uuid, name, display_name, price, is_default, true
+		FROM push_variations
+		ORDER BY store_product_id, name, ord DESC
+		ON CONFLICT (store_product_id, name) DO UPDATE SET
+			external_id = EXCLUDED.external_id,
+			display_name = EXCLUDED.display_name,
+			price = EXCLUDED.price,
+			is_default = EXCLUDED.is_default,
+			updated_at = CURRENT_TIMESTAMP
+	`)
+	if err != nil {
+		r.log(ctx).Error("Failed to upsert variations", zap.Error(err))
+		return fmt.Errorf("failed to upsert variations: %w", err)
+	}
+
+	result.VariationsProcessed += len(rows)
+	return nil
 }
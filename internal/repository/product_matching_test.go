@@ -0,0 +1,259 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestChunkProductPush(t *testing.T) {
+	products := []ProductInput{
+		{ExternalProductID: "p1"}, {ExternalProductID: "p2"}, {ExternalProductID: "p3"},
+		{ExternalProductID: "p4"}, {ExternalProductID: "p5"},
+	}
+	storeProducts := []StoreProductInput{
+		{ExternalProductID: "p5"}, {ExternalProductID: "p1"}, {ExternalProductID: "unknown"},
+	}
+	variations := []VariationInput{
+		{ExternalProductID: "p3", Name: "Small"}, {ExternalProductID: "p4", Name: "Large"},
+	}
+
+	chunks := chunkProductPush(products, variations, storeProducts, 2)
+
+	if len(chunks) != 3 {
+		t.Fatalf("chunkProductPush() = %d chunks, want 3", len(chunks))
+	}
+	for i, want := range []int{2, 2, 1} {
+		if got := len(chunks[i].products); got != want {
+			t.Errorf("chunk %d has %d products, want %d", i, got, want)
+		}
+		if chunks[i].offset != i*2 {
+			t.Errorf("chunk %d offset = %d, want %d", i, chunks[i].offset, i*2)
+		}
+	}
+
+	// Store products and variations travel with their product; unknown ones go first
+	if got := len(chunks[0].storeProducts); got != 2 {
+		t.Errorf("chunk 0 has %d store products, want 2 (p1 and unknown)", got)
+	}
+	if got := len(chunks[2].storeProducts); got != 1 || chunks[2].storeProducts[0].ExternalProductID != "p5" {
+		t.Errorf("chunk 2 store products = %+v, want p5", chunks[2].storeProducts)
+	}
+	if got := len(chunks[1].variations); got != 2 {
+		t.Errorf("chunk 1 has %d variations, want 2", got)
+	}
+}
+
+func TestChunkProductPush_Empty(t *testing.T) {
+	chunks := chunkProductPush(nil, nil, []StoreProductInput{{ExternalProductID: "p1"}}, 1000)
+	if len(chunks) != 1 || len(chunks[0].storeProducts) != 1 {
+		t.Errorf("chunkProductPush() with no products = %+v, want one chunk holding the store product", chunks)
+	}
+}
+
+func TestUpsertStoreProducts_KeepsAvailability(t *testing.T) {
+	r := testPostgres(t)
+	ctx := context.Background()
+	storeUUID := seedStore(t, r, "TEST-AVAIL")
+	seedProducts(t, r, "TEST-AVAIL", PushOptions{}, "AVAIL-1")
+
+	if _, err := r.SetStoreProductAvailability(ctx, storeUUID, "AVAIL-1", false); err != nil {
+		t.Fatalf("SetStoreProductAvailability() error = %v", err)
+	}
+
+	seedProducts(t, r, "TEST-AVAIL", PushOptions{}, "AVAIL-1")
+	if listingAvailable(t, r, storeUUID, "AVAIL-1") {
+		t.Error("an incremental push made a listing the store turned off available again")
+	}
+
+	seedProducts(t, r, "TEST-AVAIL", PushOptions{FullSync: true}, "AVAIL-1")
+	if !listingAvailable(t, r, storeUUID, "AVAIL-1") {
+		t.Error("a full sync left a pushed listing unavailable")
+	}
+}
+
+func TestUpsertProductsWithMatching_FullSync(t *testing.T) {
+	r := testPostgres(t)
+	ctx := context.Background()
+	storeUUID := seedStore(t, r, "TEST-SYNC")
+	seedProducts(t, r, "TEST-SYNC", PushOptions{}, "SYNC-1", "SYNC-2", "SYNC-3", "SYNC-4")
+
+	result := seedProducts(t, r, "TEST-SYNC", PushOptions{FullSync: true, Retain: []string{"SYNC-4"}}, "SYNC-1")
+	if result.Deactivated != 2 {
+		t.Errorf("Deactivated = %d, want 2", result.Deactivated)
+	}
+	for id, want := range map[string]bool{"SYNC-1": true, "SYNC-2": false, "SYNC-3": false, "SYNC-4": true} {
+		if got := listingAvailable(t, r, storeUUID, id); got != want {
+			t.Errorf("%s available = %v, want %v", id, got, want)
+		}
+	}
+
+	// Listings are deactivated once every chunk has committed, so none pushed in a later
+	// chunk is, and those deactivated before come back
+	r.SetPushChunkSize(1)
+	result = seedProducts(t, r, "TEST-SYNC", PushOptions{FullSync: true}, "SYNC-1", "SYNC-2", "SYNC-3")
+	if result.TotalChunks != 3 {
+		t.Errorf("TotalChunks = %d, want 3", result.TotalChunks)
+	}
+	if result.Deactivated != 1 {
+		t.Errorf("chunked Deactivated = %d, want 1", result.Deactivated)
+	}
+	for id, want := range map[string]bool{"SYNC-1": true, "SYNC-2": true, "SYNC-3": true, "SYNC-4": false} {
+		if got := listingAvailable(t, r, storeUUID, id); got != want {
+			t.Errorf("after chunked sync, %s available = %v, want %v", id, got, want)
+		}
+	}
+
+	dryRun, err := r.DryRunProductPush(ctx, ProductPush{
+		Store:         testStore("TEST-SYNC"),
+		Products:      []ProductInput{{ExternalProductID: "SYNC-1", Name: testProductName("SYNC-1"), BasePrice: 10, IsActive: true}},
+		StoreProducts: []StoreProductInput{{ExternalProductID: "SYNC-1", Price: 10, StockQuantity: 5, IsInStock: true}},
+	}, PushOptions{FullSync: true})
+	if err != nil {
+		t.Fatalf("DryRunProductPush() error = %v", err)
+	}
+	if dryRun.Deactivated != 2 {
+		t.Errorf("dry run Deactivated = %d, want 2", dryRun.Deactivated)
+	}
+	if !listingAvailable(t, r, storeUUID, "SYNC-2") {
+		t.Error("a dry run deactivated a listing")
+	}
+}
+
+func TestUpsertProductsWithMatching_Batch(t *testing.T) {
+	r := testPostgres(t)
+	ctx := context.Background()
+	storeUUID := seedStore(t, r, "TEST-BATCH")
+	err := r.UpsertTaxes(ctx, []TaxInput{{ID: "BATCH-GST5", Name: "GST", TaxID: "GST_5", Rate: 5, TaxType: "percentage", IsActive: true}}, "TEST-BATCH")
+	if err != nil {
+		t.Fatalf("UpsertTaxes() error = %v", err)
+	}
+
+	products := []ProductInput{
+		{ExternalProductID: "BATCH-1", Name: testProductName("BATCH-1"), SKU: "BATCH-SKU-1", Brand: "Batch Test Brand",
+			Images: []string{"https://img.test/batch-1.jpg", "https://img.test/batch-1b.jpg"}, BasePrice: 10, IsActive: true},
+		{ExternalProductID: "BATCH-2", Name: testProductName("BATCH-2"), BasePrice: 20, IsActive: true},
+		// Same SKU as BATCH-1: matches the product created for it earlier in the push
+		{ExternalProductID: "BATCH-3", Name: testProductName("BATCH-3"), SKU: "BATCH-SKU-1", BasePrice: 30, IsActive: true},
+	}
+	storeProducts := []StoreProductInput{
+		{ExternalProductID: "BATCH-1", Price: 10, StockQuantity: 5, IsInStock: true, Taxes: []string{"BATCH-GST5", "UNKNOWN-TAX"}},
+		{ExternalProductID: "BATCH-2", Price: 20, StockQuantity: 5, IsInStock: true},
+		{ExternalProductID: "MISSING", Price: 1},
+	}
+	variations := []VariationInput{
+		{ExternalID: "BATCH-2-S", ExternalProductID: "BATCH-2", Name: "Small", Price: 18},
+		{ExternalID: "BATCH-2-L", ExternalProductID: "BATCH-2", Name: "Large", Price: 25, IsDefault: true},
+		{ExternalID: "MISSING-S", ExternalProductID: "MISSING", Name: "Small"},
+	}
+
+	result, err := r.UpsertProductsWithMatching(ctx, "TEST-BATCH", products, variations, storeProducts, PushOptions{})
+	if err != nil {
+		t.Fatalf("UpsertProductsWithMatching() error = %v", err)
+	}
+	if result.Created != 2 || result.Updated != 1 {
+		t.Errorf("Created/Updated = %d/%d, want 2/1", result.Created, result.Updated)
+	}
+	if m := result.Matches[2]; m.Action != "update" || m.MatchType != "exact" {
+		t.Errorf("BATCH-3 match = %+v, want an exact update of BATCH-1's product", m)
+	}
+	if result.StoreProductsProcessed != 2 || result.VariationsProcessed != 2 || result.TaxesProcessed != 1 {
+		t.Errorf("store products/variations/taxes = %d/%d/%d, want 2/2/1",
+			result.StoreProductsProcessed, result.VariationsProcessed, result.TaxesProcessed)
+	}
+
+	var images int
+	var brand *string
+	err = r.conn().QueryRow(ctx, `
+		SELECT (SELECT count(*) FROM product_images i WHERE i.product_id = p.id), b.name
+		FROM store_products sp
+		JOIN products p ON p.id = sp.product_id
+		LEFT JOIN brands b ON b.id = p.brand_id
+		WHERE sp.store_id = $1 AND sp.external_id = 'BATCH-1'
+	`, storeUUID).Scan(&images, &brand)
+	if err != nil {
+		t.Fatalf("failed to read BATCH-1: %v", err)
+	}
+	if images != 2 || brand == nil || *brand != "Batch Test Brand" {
+		t.Errorf("BATCH-1 images/brand = %d/%v, want 2/Batch Test Brand", images, brand)
+	}
+
+	// Pushed again, every product matches its listing
+	again, err := r.UpsertProductsWithMatching(ctx, "TEST-BATCH", products[:2], nil, storeProducts[:2], PushOptions{})
+	if err != nil {
+		t.Fatalf("second UpsertProductsWithMatching() error = %v", err)
+	}
+	for _, m := range again.Matches {
+		if m.Action != "update" || m.MatchType != "existing_external_id" {
+			t.Errorf("second push match = %+v, want an update by existing_external_id", m)
+		}
+	}
+}
+
+func TestUpsertProductsWithMatching_MatchesWithinPush(t *testing.T) {
+	r := testPostgres(t)
+	ctx := context.Background()
+	seedStore(t, r, "TEST-WITHIN")
+
+	name := testProductName("WITHIN")
+	products := []ProductInput{
+		{ExternalProductID: "WITHIN-1", Name: name + " Milk 1 Litre", BasePrice: 10, IsActive: true},
+		{ExternalProductID: "WITHIN-2", Name: name + " Milk 1 Ltr", BasePrice: 10, IsActive: true},
+		{ExternalProductID: "WITHIN-3", Name: name + " Milk Powder", BasePrice: 10, IsActive: true},
+	}
+
+	result, err := r.UpsertProductsWithMatching(ctx, "TEST-WITHIN", products, nil, nil, PushOptions{})
+	if err != nil {
+		t.Fatalf("UpsertProductsWithMatching() error = %v", err)
+	}
+	if result.Created != 1 || result.Updated != 2 {
+		t.Errorf("Created/Updated = %d/%d, want 1/2", result.Created, result.Updated)
+	}
+	if m := result.Matches[1]; m.MatchType != "normalized_name_volume" {
+		t.Errorf("WITHIN-2 match type = %q, want normalized_name_volume", m.MatchType)
+	}
+	if m := result.Matches[2]; m.MatchType != "fuzzy" || m.ProductID != result.Matches[1].ProductID {
+		t.Errorf("WITHIN-3 match = %+v, want a fuzzy match of the product WITHIN-1 created", m)
+	}
+}
+
+func TestUpsertProductsWithMatching_SkipsFailedBrandsAndImages(t *testing.T) {
+	r := testPostgres(t)
+	ctx := context.Background()
+	storeUUID := seedStore(t, r, "TEST-SKIP")
+
+	// The brand is longer than brands.name allows, and text can't hold the NUL in the
+	// second image
+	products := []ProductInput{{
+		ExternalProductID: "SKIP-1",
+		Name:              testProductName("SKIP-1"),
+		Brand:             strings.Repeat("b", 300),
+		Images:            []string{"https://img.test/skip-1.jpg", "https://img.test/skip\x00.jpg"},
+		BasePrice:         10,
+		IsActive:          true,
+	}}
+	storeProducts := []StoreProductInput{{ExternalProductID: "SKIP-1", Price: 10, StockQuantity: 5, IsInStock: true}}
+
+	result, err := r.UpsertProductsWithMatching(ctx, "TEST-SKIP", products, nil, storeProducts, PushOptions{})
+	if err != nil {
+		t.Fatalf("UpsertProductsWithMatching() error = %v, want the brand and image failures skipped", err)
+	}
+	if result.Created != 1 || result.StoreProductsProcessed != 1 {
+		t.Errorf("Created/StoreProductsProcessed = %d/%d, want 1/1", result.Created, result.StoreProductsProcessed)
+	}
+
+	var images int
+	var brandID *string
+	err = r.conn().QueryRow(ctx, `
+		SELECT (SELECT count(*) FROM product_images i WHERE i.product_id = p.id), p.brand_id::text
+		FROM store_products sp
+		JOIN products p ON p.id = sp.product_id
+		WHERE sp.store_id = $1 AND sp.external_id = 'SKIP-1'
+	`, storeUUID).Scan(&images, &brandID)
+	if err != nil {
+		t.Fatalf("failed to read SKIP-1: %v", err)
+	}
+	if images != 1 || brandID != nil {
+		t.Errorf("SKIP-1 images/brand = %d/%v, want the valid image and no brand", images, brandID)
+	}
+}
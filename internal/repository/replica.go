@@ -0,0 +1,185 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+// replicaHealthInterval is how often the read replica is pinged
+const replicaHealthInterval = 5 * time.Second
+
+// replicaLagQuery returns how far the replica's replay is behind, in seconds: none once it
+// has replayed everything it received, or when it isn't replaying at all
+const replicaLagQuery = `
+	SELECT CASE
+		WHEN NOT pg_is_in_recovery() OR pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+		ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+	END::float8
+`
+
+// readReplica is a read-only pool whose reachability and lag are checked in the background
+// Reads go to the primary while the replica is unhealthy, and for maxLag after a write
+type readReplica struct {
+	pool    *pgxpool.Pool
+	logger  *zap.Logger
+	maxLag  time.Duration
+	healthy atomic.Bool
+	written atomic.Int64 // When the primary was last written, in Unix nanoseconds
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// SetReadReplica routes reads to a read-only database at databaseURL
+// The replica doesn't need to be reachable yet: reads use the primary until a health
+// check succeeds, and again whenever one fails or finds the replica more than maxLag behind.
+// Reads within maxLag of a write through the repository, or of a catalog change notification,
+// go to the primary too, so a cache refilled after a write doesn't miss it. Must be called
+// before the repository is used
+func (r *PostgresRepository) SetReadReplica(databaseURL string, maxLag time.Duration) error {
+	config, err := pgxpool.ParseConfig(databaseURL)
+	if err != nil {
+		return fmt.Errorf("failed to parse read replica URL: %w", err)
+	}
+	r.poolConfig.apply(config)
+
+	pool, err := pgxpool.NewWithConfig(context.Background(), config)
+	if err != nil {
+		return fmt.Errorf("failed to create read replica pool: %w", err)
+	}
+
+	replica := &readReplica{
+		pool:   pool,
+		logger: r.logger,
+		maxLag: maxLag,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	replica.check()
+	go replica.monitor()
+
+	r.replica = replica
+	r.logger.Info("Routing reads to PostgreSQL read replica",
+		zap.String("host", config.ConnConfig.Host),
+		zap.Bool("healthy", replica.healthy.Load()),
+	)
+	return nil
+}
+
+// reader returns what read-only queries run on: the transaction inside WithTx, so they
+// see its writes, else the replica when it is usable, otherwise the primary
+func (r *PostgresRepository) reader() conn {
+	if r.tx != nil {
+		return r.tx
+	}
+	if r.replica != nil && r.replica.usable() {
+		return r.replica.pool
+	}
+	return r.pool
+}
+
+// noteWrite records that the primary has just been written
+func (rr *readReplica) noteWrite() {
+	rr.written.Store(time.Now().UnixNano())
+}
+
+// usable reports whether reads may go to the replica: it is reachable and within maxLag of
+// the primary, and nothing written in the last maxLag may be missing from it
+func (rr *readReplica) usable() bool {
+	return rr.healthy.Load() && time.Since(time.Unix(0, rr.written.Load())) >= rr.maxLag
+}
+
+// primaryWrites is the primary pool with a replica, noting what runs on it as a write once
+// it has run, or for transactions once they commit
+type primaryWrites struct {
+	conn
+	replica *readReplica
+}
+
+func (p *primaryWrites) Begin(ctx context.Context) (pgx.Tx, error) {
+	tx, err := p.conn.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &noteWriteTx{Tx: tx, replica: p.replica}, nil
+}
+
+func (p *primaryWrites) Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+	defer p.replica.noteWrite()
+	return p.conn.Exec(ctx, sql, arguments...)
+}
+
+// Query notes the write when it is sent; its rows are read, and it commits, after it returns
+func (p *primaryWrites) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	p.replica.noteWrite()
+	return p.conn.Query(ctx, sql, args...)
+}
+
+func (p *primaryWrites) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	p.replica.noteWrite()
+	return p.conn.QueryRow(ctx, sql, args...)
+}
+
+// noteWriteTx is a transaction on the primary that notes a write when it commits
+type noteWriteTx struct {
+	pgx.Tx
+	replica *readReplica
+}
+
+func (t *noteWriteTx) Commit(ctx context.Context) error {
+	defer t.replica.noteWrite()
+	return t.Tx.Commit(ctx)
+}
+
+// monitor pings the replica until close is called
+func (rr *readReplica) monitor() {
+	defer close(rr.done)
+
+	ticker := time.NewTicker(replicaHealthInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-rr.stop:
+			return
+		case <-ticker.C:
+			rr.check()
+		}
+	}
+}
+
+// check measures the replica's lag and logs when its health changes
+func (rr *readReplica) check() {
+	ctx, cancel := context.WithTimeout(context.Background(), replicaHealthInterval)
+	defer cancel()
+
+	var seconds float64
+	err := rr.pool.QueryRow(ctx, replicaLagQuery).Scan(&seconds)
+	if lag := time.Duration(seconds * float64(time.Second)); err == nil && lag > rr.maxLag {
+		err = fmt.Errorf("replica is %s behind the primary, more than %s", lag.Round(time.Millisecond), rr.maxLag)
+	}
+	healthy := err == nil
+	if rr.healthy.Swap(healthy) == healthy {
+		return
+	}
+
+	if healthy {
+		rr.logger.Info("PostgreSQL read replica is reachable and caught up; routing reads to it")
+	} else {
+		rr.logger.Warn("PostgreSQL read replica is unreachable or lagging; reading from primary", zap.Error(err))
+	}
+}
+
+// close stops health checks and closes the replica pool
+func (rr *readReplica) close() {
+	close(rr.stop)
+	<-rr.done
+	rr.pool.Close()
+}
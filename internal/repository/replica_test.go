@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+func TestReaderWithoutReplica(t *testing.T) {
+	primary := &pgxpool.Pool{}
+	r := &PostgresRepository{pool: primary, logger: zap.NewNop()}
+
+	if r.reader() != primary {
+		t.Error("reader() should use the primary when no replica is configured")
+	}
+}
+
+func TestReaderFallsBackWhenReplicaUnreachable(t *testing.T) {
+	primary := &pgxpool.Pool{}
+	r := &PostgresRepository{pool: primary, logger: zap.NewNop()}
+
+	// Nothing listens on port 1, so the initial health check fails
+	if err := r.SetReadReplica("postgres://postgres@127.0.0.1:1/replica?connect_timeout=1", time.Second); err != nil {
+		t.Fatalf("SetReadReplica() error = %v", err)
+	}
+	defer r.replica.close()
+
+	if r.reader() != primary {
+		t.Error("reader() should use the primary while the replica is unreachable")
+	}
+
+	r.replica.healthy.Store(true)
+	if r.reader() != r.replica.pool {
+		t.Error("reader() should use the replica once it is healthy")
+	}
+}
+
+func TestReaderAvoidsReplicaAfterWrite(t *testing.T) {
+	primary := &pgxpool.Pool{}
+	r := &PostgresRepository{pool: primary, logger: zap.NewNop()}
+	r.replica = &readReplica{pool: &pgxpool.Pool{}, maxLag: time.Minute}
+	r.replica.healthy.Store(true)
+
+	if r.reader() != r.replica.pool {
+		t.Fatal("reader() should use the healthy replica before any write")
+	}
+
+	if _, ok := r.conn().(*primaryWrites); !ok {
+		t.Fatalf("conn() = %T, want *primaryWrites with a replica", r.conn())
+	}
+
+	// A committed transaction opened through conn() notes the write
+	tx := &noteWriteTx{Tx: committedTx{}, replica: r.replica}
+	if err := tx.Commit(context.Background()); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+	if r.reader() != primary {
+		t.Error("reader() should use the primary within maxLag of a write")
+	}
+
+	r.replica.written.Store(time.Now().Add(-time.Minute).UnixNano())
+	if r.reader() != r.replica.pool {
+		t.Error("reader() should return to the replica once maxLag has passed since the write")
+	}
+}
+
+// committedTx is a transaction whose Commit succeeds without a database
+type committedTx struct {
+	pgx.Tx
+}
+
+func (committedTx) Commit(context.Context) error { return nil }
+
+func TestSetReadReplicaInvalidURL(t *testing.T) {
+	r := &PostgresRepository{logger: zap.NewNop()}
+
+	if err := r.SetReadReplica("postgres://%zz", time.Second); err == nil {
+		t.Error("SetReadReplica() should reject an unparseable URL")
+	}
+}
+
+func TestPoolConfigApply(t *testing.T) {
+	config, err := pgxpool.ParseConfig("postgres://postgres@localhost:5432/db?pool_max_conns=4")
+	if err != nil {
+		t.Fatalf("ParseConfig() error = %v", err)
+	}
+	defaultHealthCheck := config.HealthCheckPeriod
+
+	PoolConfig{MaxConns: 20, MinConns: 5, StatementTimeout: 1500 * time.Millisecond}.apply(config)
+
+	if config.MaxConns != 20 || config.MinConns != 5 {
+		t.Errorf("apply() conns = %d/%d, want 20/5", config.MaxConns, config.MinConns)
+	}
+	if config.HealthCheckPeriod != defaultHealthCheck {
+		t.Errorf("apply() HealthCheckPeriod = %v, want pgx default %v", config.HealthCheckPeriod, defaultHealthCheck)
+	}
+	if got := config.ConnConfig.RuntimeParams["statement_timeout"]; got != "1500" {
+		t.Errorf("apply() statement_timeout = %q, want %q", got, "1500")
+	}
+	if got := config.ConnConfig.RuntimeParams["application_name"]; got != applicationName {
+		t.Errorf("apply() application_name = %q, want %q", got, applicationName)
+	}
+}
+
+func TestPoolConfigApplyKeepsURLApplicationName(t *testing.T) {
+	config, err := pgxpool.ParseConfig("postgres://postgres@localhost:5432/db?application_name=catalog-api")
+	if err != nil {
+		t.Fatalf("ParseConfig() error = %v", err)
+	}
+
+	PoolConfig{}.apply(config)
+
+	if got := config.ConnConfig.RuntimeParams["application_name"]; got != "catalog-api" {
+		t.Errorf("apply() application_name = %q, want the URL's catalog-api", got)
+	}
+}
+
+func TestPoolConfigApplyKeepsURLPoolSize(t *testing.T) {
+	config, err := pgxpool.ParseConfig("postgres://postgres@localhost:5432/db?pool_max_conns=4&pool_min_conns=1")
+	if err != nil {
+		t.Fatalf("ParseConfig() error = %v", err)
+	}
+
+	PoolConfig{}.apply(config)
+
+	if config.MaxConns != 4 || config.MinConns != 1 {
+		t.Errorf("apply() conns = %d/%d, want the URL's 4/1", config.MaxConns, config.MinConns)
+	}
+}
+
+func TestPoolConfigApplyTracing(t *testing.T) {
+	for _, tracing := range []bool{false, true} {
+		config, err := pgxpool.ParseConfig("postgres://postgres@localhost:5432/db")
+		if err != nil {
+			t.Fatalf("ParseConfig() error = %v", err)
+		}
+
+		PoolConfig{Tracing: tracing}.apply(config)
+
+		if traced := config.ConnConfig.Tracer != nil; traced != tracing {
+			t.Errorf("apply() with Tracing %v set a query tracer: %v", tracing, traced)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.uber.org/zap"
+)
+
+// Backoff bounds between retries of a transient failure
+const (
+	defaultRetryMaxAttempts = 3
+	retryBaseDelay          = 50 * time.Millisecond
+	retryMaxDelay           = 2 * time.Second
+)
+
+// SetRetryMaxAttempts sets how many times an operation is tried before a transient error
+// is returned; 1 disables retries. Must be called before the repository is used
+func (r *PostgresRepository) SetRetryMaxAttempts(attempts int) {
+	r.retryMaxAttempts = attempts
+}
+
+// retry runs fn until it succeeds, fails with an error that isn't transient, ctx ends or
+// the attempts run out, sleeping with exponential backoff and full jitter in between.
+// fn must be safe to run again: a single read, or a whole transaction that is begun inside it
+func (r *PostgresRepository) retry(ctx context.Context, op string, fn func() error) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = fn()
+		if err == nil || attempt >= r.retryMaxAttempts || !isTransient(err) {
+			return err
+		}
+
+		delay := retryDelay(attempt)
+		r.log(ctx).Warn("Retrying transient database error",
+			zap.String("operation", op),
+			zap.Int("attempt", attempt),
+			zap.Duration("delay", delay),
+			zap.Error(err))
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+	}
+}
+
+// retryDelay returns a random delay up to the exponential backoff for attempt (from 1)
+func retryDelay(attempt int) time.Duration {
+	backoff := retryMaxDelay
+	if shift := attempt - 1; shift < 16 {
+		backoff = min(retryBaseDelay<<shift, retryMaxDelay)
+	}
+	return rand.N(backoff) + time.Millisecond
+}
+
+// isTransient reports whether an operation that failed with err can simply be run again:
+// serialization failures, deadlocks, connection exceptions reported by the server, and
+// connection failures that happened before the request reached it. Errors while waiting
+// for a COMMIT are not retried since the transaction may have been applied
+func isTransient(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch {
+		case pgErr.Code == "40001", // serialization_failure
+			pgErr.Code == "40P01",                          // deadlock_detected
+			pgErr.Code == "57P03",                          // cannot_connect_now
+			len(pgErr.Code) == 5 && pgErr.Code[:2] == "08": // connection_exception
+			return true
+		}
+		return false
+	}
+
+	var connectErr *pgconn.ConnectError
+	return errors.As(err, &connectErr) || pgconn.SafeToRetry(err)
+}
+
+// queryRows runs a read-only query on the reader pool and collects every row with fn,
+// retrying transient failures
+func queryRows[T any](ctx context.Context, r *PostgresRepository, fn pgx.RowToFunc[T], sql string, args ...any) ([]T, error) {
+	var results []T
+	err := r.retry(ctx, "query", func() (err error) {
+		rows, _ := r.reader().Query(ctx, sql, args...)
+		results, err = pgx.CollectRows(rows, fn)
+		return err
+	})
+	return results, err
+}
+
+// queryRow is queryRows for a query that must return exactly one row
+func queryRow[T any](ctx context.Context, r *PostgresRepository, fn pgx.RowToFunc[T], sql string, args ...any) (T, error) {
+	var result T
+	err := r.retry(ctx, "query", func() (err error) {
+		rows, _ := r.reader().Query(ctx, sql, args...)
+		result, err = pgx.CollectExactlyOneRow(rows, fn)
+		return err
+	})
+	return result, err
+}
+
+// exec runs a single statement on the primary, retrying transient failures
+func (r *PostgresRepository) exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	var tag pgconn.CommandTag
+	err := r.retry(ctx, "exec", func() (err error) {
+		tag, err = r.conn().Exec(ctx, sql, args...)
+		return err
+	})
+	return tag, err
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+// defaultFuzzySearchThreshold is the minimum word similarity for a fuzzy match
+const defaultFuzzySearchThreshold = 0.5
+
+// SearchResult is a store listing matched by a search, with its relevance
+// Match is "fulltext" or "fuzzy"
+type SearchResult struct {
+	StoreListing
+	Rank  float64 `db:"rank" json:"rank"`
+	Match string  `db:"match" json:"match"`
+}
+
+// SetFuzzySearchThreshold sets the minimum word similarity (0-1) for FuzzySearchProducts
+// Must be called before the repository is used
+func (r *PostgresRepository) SetFuzzySearchThreshold(threshold float64) {
+	r.fuzzyThreshold = threshold
+}
+
+// SearchProducts runs a full-text search over listed products, best matches first
+// query accepts web search syntax ("quoted phrases", OR, -exclusions) and is matched
+// against products.search_vector, which weights name over brand, manufacturer and description.
+// storeID is optional; filter.Search is ignored since query replaces it
+func (r *PostgresRepository) SearchProducts(ctx context.Context, query, storeID string, filter ListingFilter, pagination Pagination) ([]SearchResult, error) {
+	filter.StoreID = storeID
+	filter.Search = ""
+
+	sql := `SELECT ` + storeListingColumns + `, ts_rank_cd(p.search_vector, q.query) AS rank, 'fulltext' AS match` +
+		storeListingJoins + `
+		CROSS JOIN websearch_to_tsquery('english', $1) AS q(query)
+		WHERE p.search_vector @@ q.query AND` + storeListingVisible
+	sql, args := appendListingConditions(sql, []interface{}{query}, filter)
+	sql, args = appendOrderAndPage(sql, args, "rank DESC, sp.id", pagination)
+
+	results, err := queryRows(ctx, r, pgx.RowToStructByName[SearchResult], sql, args...)
+	if err != nil {
+		r.log(ctx).Error("Failed to search products", zap.String("query", query), zap.Error(err))
+		return nil, NewQueryError(err)
+	}
+
+	return results, nil
+}
+
+// FuzzySearchProducts matches product names by trigram word similarity, most similar first
+// It tolerates typos and partial words ("colgat") that full-text search misses.
+// The similarity threshold is applied through pg_trgm.word_similarity_threshold so the
+// <% operator can use the trigram index on products.name
+func (r *PostgresRepository) FuzzySearchProducts(ctx context.Context, query, storeID string, filter ListingFilter, pagination Pagination) ([]SearchResult, error) {
+	filter.StoreID = storeID
+	filter.Search = ""
+
+	sql := `SELECT ` + storeListingColumns + `, word_similarity($1, p.name) AS rank, 'fuzzy' AS match` +
+		storeListingJoins + `
+		WHERE $1 <% p.name AND` + storeListingVisible
+	sql, args := appendListingConditions(sql, []interface{}{query}, filter)
+	sql, args = appendOrderAndPage(sql, args, "rank DESC, sp.id", pagination)
+
+	// is_local: the threshold only applies to this transaction
+	threshold := strconv.FormatFloat(r.fuzzyThreshold, 'f', -1, 64)
+
+	var results []SearchResult
+	err := r.retry(ctx, "fuzzy search", func() error {
+		tx, err := r.reader().Begin(ctx)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback(ctx)
+
+		if _, err := tx.Exec(ctx, `SELECT set_config('pg_trgm.word_similarity_threshold', $1, true)`, threshold); err != nil {
+			return fmt.Errorf("failed to set fuzzy search threshold: %w", err)
+		}
+
+		rows, _ := tx.Query(ctx, sql, args...)
+		results, err = pgx.CollectRows(rows, pgx.RowToStructByName[SearchResult])
+		return err
+	})
+	if err != nil {
+		r.log(ctx).Error("Failed to fuzzy search products", zap.String("query", query), zap.Error(err))
+		return nil, NewQueryError(err)
+	}
+
+	return results, nil
+}
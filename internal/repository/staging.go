@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Bulk writes copy their input into a temporary staging table with CopyFrom and then
+// apply it with a single set-based statement, so a push costs a handful of round trips
+// instead of several per row. Staging tables are dropped when the transaction ends.
+
+// stageRows creates the temp table described by columnDefs ("name type, ...") and copies rows into it
+// A table left by an earlier savepoint of the same transaction, such as a previous push
+// inside WithTx, is replaced
+func stageRows(ctx context.Context, tx pgx.Tx, table, columnDefs string, rows [][]any) error {
+	columns := stagingColumns(columnDefs)
+
+	// Without arguments the two statements go in one round trip
+	_, err := tx.Exec(ctx, `DROP TABLE IF EXISTS pg_temp.`+table+`; CREATE TEMP TABLE `+table+` (`+columnDefs+`) ON COMMIT DROP`)
+	if err != nil {
+		return fmt.Errorf("failed to create staging table %s: %w", table, err)
+	}
+
+	if _, err := tx.CopyFrom(ctx, pgx.Identifier{table}, columns, pgx.CopyFromRows(rows)); err != nil {
+		return fmt.Errorf("failed to copy into staging table %s: %w", table, err)
+	}
+
+	return nil
+}
+
+// stagingColumns returns the column names of a "name type, ..." definition list
+func stagingColumns(columnDefs string) []string {
+	var columns []string
+	for _, def := range strings.Split(columnDefs, ",") {
+		columns = append(columns, strings.Fields(def)[0])
+	}
+	return columns
+}
@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStagingColumns(t *testing.T) {
+	got := stagingColumns(`ord int, external_id text,
+		stock_quantity float8, is_available bool`)
+	want := []string{"ord", "external_id", "stock_quantity", "is_available"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("stagingColumns() = %v, want %v", got, want)
+	}
+}
+
+func TestProductIdentityKeys(t *testing.T) {
+	got := productIdentityKeys(ProductInput{SKU: "COKE-1L", EAN: "8901234567890"})
+	want := []string{"sku:COKE-1L", "ean:8901234567890"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("productIdentityKeys() = %v, want %v", got, want)
+	}
+}
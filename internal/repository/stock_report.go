@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+// Stock report statuses
+const (
+	StockOutOfStock = "out_of_stock"
+	StockLow        = "low_stock"
+)
+
+// StockReportItem is a store listing that is out of stock or below its reorder threshold
+type StockReportItem struct {
+	StoreProductID string    `db:"store_product_id" json:"store_product_id"`
+	ExternalID     *string   `db:"external_id" json:"external_id"`
+	ProductID      string    `db:"product_id" json:"product_id"`
+	SKU            string    `db:"sku" json:"sku"`
+	Name           string    `db:"name" json:"name"`
+	BrandName      *string   `db:"brand_name" json:"brand_name"`
+	CategoryName   *string   `db:"category_name" json:"category_name"`
+	StockQuantity  float64   `db:"stock_quantity" json:"stock_quantity"`
+	Threshold      float64   `db:"threshold" json:"threshold"`
+	Status         string    `db:"status" json:"status"`
+	UpdatedAt      time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// StockReport lists a store's out-of-stock and low-stock listings, out of stock first
+// Threshold is the threshold the report was run with; nil means each listing's own
+// low_stock_threshold was used
+type StockReport struct {
+	StoreID         string            `json:"store_id"`
+	Threshold       *float64          `json:"threshold"`
+	OutOfStockCount int               `json:"out_of_stock_count"`
+	LowStockCount   int               `json:"low_stock_count"`
+	Items           []StockReportItem `json:"items"`
+}
+
+// GetStockReport retrieves a store's available listings that are out of stock, or whose
+// stock is below threshold (or their own low_stock_threshold when threshold is nil)
+func (r *PostgresRepository) GetStockReport(ctx context.Context, storeID string, threshold *float64) (*StockReport, error) {
+	var exists bool
+	err := r.retry(ctx, "store exists", func() error {
+		return r.reader().QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM stores WHERE id = $1)`, storeID).Scan(&exists)
+	})
+	if err != nil {
+		return nil, NewQueryError(err)
+	}
+	if !exists {
+		return nil, NewNotFoundError("stores", storeID)
+	}
+
+	items, err := r.stockReportItems(ctx, storeID, threshold, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &StockReport{StoreID: storeID, Threshold: threshold, Items: items}
+	for _, item := range items {
+		if item.Status == StockOutOfStock {
+			report.OutOfStockCount++
+		} else {
+			report.LowStockCount++
+		}
+	}
+	return report, nil
+}
+
+// ListLowStockListings retrieves those of the store's available listings with the given
+// ERP external IDs that are out of stock or below their own low_stock_threshold
+func (r *PostgresRepository) ListLowStockListings(ctx context.Context, storeID string, externalIDs []string) ([]StockReportItem, error) {
+	if len(externalIDs) == 0 {
+		return []StockReportItem{}, nil
+	}
+	return r.stockReportItems(ctx, storeID, nil, externalIDs)
+}
+
+// stockReportItems queries the store's low and out-of-stock listings, only those with the
+// given external IDs unless externalIDs is nil
+func (r *PostgresRepository) stockReportItems(ctx context.Context, storeID string, threshold *float64, externalIDs []string) ([]StockReportItem, error) {
+	items, err := queryRows(ctx, r, pgx.RowToStructByName[StockReportItem], `
+		SELECT sp.id AS store_product_id, sp.external_id, p.id AS product_id, p.sku, p.name,
+		       b.name AS brand_name, c.name AS category_name,
+		       COALESCE(sp.stock_quantity, 0) AS stock_quantity, t.threshold,
+		       CASE WHEN sp.is_in_stock = false OR COALESCE(sp.stock_quantity, 0) <= 0
+		            THEN '`+StockOutOfStock+`' ELSE '`+StockLow+`' END AS status,
+		       sp.updated_at
+		FROM store_products sp
+		JOIN products p ON p.id = sp.product_id
+		LEFT JOIN categories c ON c.id = p.category_id
+		LEFT JOIN brands b ON b.id = p.brand_id
+		CROSS JOIN LATERAL (
+			SELECT COALESCE($2::numeric, sp.low_stock_threshold, 0) AS threshold
+		) t
+		WHERE sp.store_id = $1 AND sp.is_available = true AND p.is_active = true
+		  AND ($3::text[] IS NULL OR sp.external_id = ANY($3))
+		  AND (sp.is_in_stock = false OR COALESCE(sp.stock_quantity, 0) <= 0
+		       OR COALESCE(sp.stock_quantity, 0) < t.threshold)
+		ORDER BY status DESC, stock_quantity, p.name, sp.id
+	`, storeID, threshold, externalIDs)
+	if err != nil {
+		r.log(ctx).Error("Failed to query stock report", zap.String("store_id", storeID), zap.Error(err))
+		return nil, NewQueryError(err)
+	}
+	return items, nil
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestGetStockReport(t *testing.T) {
+	r := testPostgres(t)
+	ctx := context.Background()
+	storeUUID := seedStore(t, r, "TEST-STOCK")
+	seedProducts(t, r, "TEST-STOCK", PushOptions{}, "STOCK-OUT", "STOCK-LOW", "STOCK-OK", "STOCK-OFF")
+
+	// Listings have the default low_stock_threshold of 10
+	_, err := r.conn().Exec(ctx, `
+		UPDATE store_products SET
+			stock_quantity = CASE external_id WHEN 'STOCK-LOW' THEN 2 WHEN 'STOCK-OK' THEN 50 ELSE 0 END,
+			is_available = external_id <> 'STOCK-OFF'
+		WHERE store_id = $1
+	`, storeUUID)
+	if err != nil {
+		t.Fatalf("failed to set stock: %v", err)
+	}
+
+	hundred, one := 100.0, 1.0
+	tests := []struct {
+		name      string
+		threshold *float64
+		want      []string // External IDs, in order
+		wantOut   int
+	}{
+		{name: "own thresholds", want: []string{"STOCK-OUT", "STOCK-LOW"}, wantOut: 1},
+		{name: "threshold of 1", threshold: &one, want: []string{"STOCK-OUT"}, wantOut: 1},
+		{name: "threshold of 100", threshold: &hundred, want: []string{"STOCK-OUT", "STOCK-LOW", "STOCK-OK"}, wantOut: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := r.GetStockReport(ctx, storeUUID, tt.threshold)
+			if err != nil {
+				t.Fatalf("GetStockReport() error = %v", err)
+			}
+			var got []string
+			for _, item := range report.Items {
+				got = append(got, *item.ExternalID)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("GetStockReport() items = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("GetStockReport() items = %v, want %v", got, tt.want)
+				}
+			}
+			if report.OutOfStockCount != tt.wantOut || report.LowStockCount != len(tt.want)-tt.wantOut {
+				t.Errorf("GetStockReport() counts = %d out, %d low, want %d out of %d", report.OutOfStockCount, report.LowStockCount, tt.wantOut, len(tt.want))
+			}
+			if report.Items[0].Status != StockOutOfStock || (len(report.Items) > 1 && report.Items[1].Status != StockLow) {
+				t.Errorf("GetStockReport() statuses = %s, ..., want out of stock first", report.Items[0].Status)
+			}
+		})
+	}
+
+	low, err := r.ListLowStockListings(ctx, storeUUID, []string{"STOCK-LOW", "STOCK-OK"})
+	if err != nil || len(low) != 1 || *low[0].ExternalID != "STOCK-LOW" {
+		t.Errorf("ListLowStockListings() = %+v, %v, want only STOCK-LOW", low, err)
+	}
+	if _, err := r.GetStockReport(ctx, "00000000-0000-0000-0000-000000000001", nil); GetStatusCode(err) != http.StatusNotFound {
+		t.Errorf("GetStockReport() of an unknown store error = %v, want not found", err)
+	}
+}
@@ -0,0 +1,313 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	// Embedded zone database, so store timezones resolve on images without tzdata
+	_ "time/tzdata"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+// StoreHours is one opening window from the store_hours table
+// Times are HH:MM:SS in the store's timezone. A window whose close_time is not after its
+// open_time runs past midnight into the next day, so 00:00-00:00 is open all day
+type StoreHours struct {
+	ID        string `db:"id" json:"id"`
+	DayOfWeek int    `db:"day_of_week" json:"day_of_week"` // 0=Sunday, 6=Saturday
+	OpenTime  string `db:"open_time" json:"open_time"`
+	CloseTime string `db:"close_time" json:"close_time"`
+	IsClosed  bool   `db:"is_closed" json:"is_closed"`
+}
+
+const storeHoursColumns = `id, day_of_week, open_time::text AS open_time, close_time::text AS close_time, is_closed`
+
+// StoreHoliday is a dated exception to a store's weekly hours
+// A closed holiday shuts the store all day; otherwise its window replaces the weekday's
+type StoreHoliday struct {
+	ID        string  `db:"id" json:"id"`
+	Date      string  `db:"date" json:"date"` // YYYY-MM-DD
+	IsClosed  bool    `db:"is_closed" json:"is_closed"`
+	OpenTime  *string `db:"open_time" json:"open_time"`
+	CloseTime *string `db:"close_time" json:"close_time"`
+	Note      *string `db:"note" json:"note"`
+}
+
+const storeHolidayColumns = `id, date::text AS date, is_closed,
+	open_time::text AS open_time, close_time::text AS close_time, note`
+
+// StoreSchedule is a store's weekly hours and upcoming holidays, with whether it is open now
+type StoreSchedule struct {
+	StoreID   string         `json:"store_id"`
+	Timezone  string         `json:"timezone"`
+	Hours     []StoreHours   `json:"hours"`
+	Holidays  []StoreHoliday `json:"holidays"`
+	IsOpenNow bool           `json:"is_open_now"`
+}
+
+// StoreHoursInput is one weekly opening window to save; times are HH:MM or HH:MM:SS
+type StoreHoursInput struct {
+	DayOfWeek int
+	OpenTime  string
+	CloseTime string
+	IsClosed  bool
+}
+
+// StoreHolidayInput is a holiday to save for a date (YYYY-MM-DD)
+// OpenTime and CloseTime are required unless IsClosed
+type StoreHolidayInput struct {
+	Date      string
+	IsClosed  bool
+	OpenTime  *string
+	CloseTime *string
+	Note      *string
+}
+
+// GetStoreSchedule retrieves a store's hours, its holidays from yesterday on, and
+// whether it is open now
+func (r *PostgresRepository) GetStoreSchedule(ctx context.Context, storeID string) (*StoreSchedule, error) {
+	var isActive, isOpen bool
+	schedule := &StoreSchedule{StoreID: storeID}
+	err := r.retry(ctx, "query", func() error {
+		return r.reader().QueryRow(ctx, `SELECT timezone, is_active, is_open FROM stores WHERE id = $1`, storeID).
+			Scan(&schedule.Timezone, &isActive, &isOpen)
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, NewNotFoundError("stores", storeID)
+	}
+	if err != nil {
+		r.log(ctx).Error("Failed to get store timezone", zap.String("store_id", storeID), zap.Error(err))
+		return nil, NewQueryError(err)
+	}
+
+	schedule.Hours, err = queryRows(ctx, r, pgx.RowToStructByName[StoreHours], `
+		SELECT `+storeHoursColumns+`
+		FROM store_hours
+		WHERE store_id = $1
+		ORDER BY day_of_week, open_time
+	`, storeID)
+	if err != nil {
+		r.log(ctx).Error("Failed to query store hours", zap.String("store_id", storeID), zap.Error(err))
+		return nil, NewQueryError(err)
+	}
+
+	// Yesterday's holiday can still decide whether an overnight window is open now
+	schedule.Holidays, err = queryRows(ctx, r, pgx.RowToStructByName[StoreHoliday], `
+		SELECT `+storeHolidayColumns+`
+		FROM store_holidays
+		WHERE store_id = $1 AND date >= (CURRENT_TIMESTAMP AT TIME ZONE $2)::date - 1
+		ORDER BY date
+	`, storeID, schedule.Timezone)
+	if err != nil {
+		r.log(ctx).Error("Failed to query store holidays", zap.String("store_id", storeID), zap.Error(err))
+		return nil, NewQueryError(err)
+	}
+
+	schedule.IsOpenNow = isActive && isOpen && r.scheduleOpenAt(schedule, time.Now())
+	return schedule, nil
+}
+
+// scheduleOpenAt reports whether schedule is open at t, in the store's timezone
+// A store without weekly hours has no schedule to keep, so it counts as open
+func (r *PostgresRepository) scheduleOpenAt(schedule *StoreSchedule, t time.Time) bool {
+	if len(schedule.Hours) == 0 {
+		return true
+	}
+
+	loc, err := time.LoadLocation(schedule.Timezone)
+	if err != nil {
+		r.logger.Warn("Unknown store timezone, using UTC",
+			zap.String("store_id", schedule.StoreID),
+			zap.String("timezone", schedule.Timezone))
+		loc = time.UTC
+	}
+	return openAt(schedule.Hours, schedule.Holidays, t.In(loc))
+}
+
+// openingWindow is an opening and closing time in seconds after midnight
+type openingWindow struct {
+	opens, closes int
+}
+
+// openAt reports whether a store with these hours and holidays is open at local time t
+func openAt(hours []StoreHours, holidays []StoreHoliday, t time.Time) bool {
+	now := t.Hour()*3600 + t.Minute()*60 + t.Second()
+
+	for _, w := range windowsOn(hours, holidays, t) {
+		if now >= w.opens && (w.closes > w.opens && now < w.closes || w.closes <= w.opens) {
+			return true
+		}
+	}
+
+	// Yesterday's overnight windows run until their closing time today
+	for _, w := range windowsOn(hours, holidays, t.AddDate(0, 0, -1)) {
+		if w.closes <= w.opens && now < w.closes {
+			return true
+		}
+	}
+
+	return false
+}
+
+// windowsOn returns the opening windows that start on day's local date
+func windowsOn(hours []StoreHours, holidays []StoreHoliday, day time.Time) []openingWindow {
+	date := day.Format(time.DateOnly)
+	for _, h := range holidays {
+		if h.Date != date {
+			continue
+		}
+		if h.IsClosed {
+			return nil
+		}
+		if h.OpenTime != nil && h.CloseTime != nil {
+			opens, err1 := ParseClock(*h.OpenTime)
+			closes, err2 := ParseClock(*h.CloseTime)
+			if err1 != nil || err2 != nil {
+				return nil
+			}
+			return []openingWindow{{opens, closes}}
+		}
+	}
+
+	var windows []openingWindow
+	for _, h := range hours {
+		if h.DayOfWeek != int(day.Weekday()) || h.IsClosed {
+			continue
+		}
+		opens, err1 := ParseClock(h.OpenTime)
+		closes, err2 := ParseClock(h.CloseTime)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		windows = append(windows, openingWindow{opens, closes})
+	}
+	return windows
+}
+
+// ParseClock converts HH:MM or HH:MM:SS to seconds after midnight
+func ParseClock(clock string) (int, error) {
+	for _, layout := range []string{time.TimeOnly, "15:04"} {
+		if t, err := time.Parse(layout, clock); err == nil {
+			return t.Hour()*3600 + t.Minute()*60 + t.Second(), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid time %q, want HH:MM or HH:MM:SS", clock)
+}
+
+// ReplaceStoreHours replaces a store's weekly hours, and its timezone if one is given,
+// in one transaction
+func (r *PostgresRepository) ReplaceStoreHours(ctx context.Context, storeID string, timezone *string, hours []StoreHoursInput) error {
+	var before []StoreHours
+	err := r.retry(ctx, AuditStoreHours, func() error {
+		tx, err := r.conn().Begin(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to begin transaction: %w", err)
+		}
+		defer tx.Rollback(ctx)
+
+		var locked string
+		err = tx.QueryRow(ctx, `SELECT id FROM stores WHERE id = $1 FOR UPDATE`, storeID).Scan(&locked)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return NewNotFoundError("stores", storeID)
+		}
+		if err != nil {
+			return err
+		}
+
+		rows, _ := tx.Query(ctx, `SELECT `+storeHoursColumns+` FROM store_hours WHERE store_id = $1 ORDER BY day_of_week, open_time`, storeID)
+		if before, err = pgx.CollectRows(rows, pgx.RowToStructByName[StoreHours]); err != nil {
+			return err
+		}
+
+		if timezone != nil {
+			if _, err := tx.Exec(ctx, `UPDATE stores SET timezone = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $1`, storeID, *timezone); err != nil {
+				return fmt.Errorf("failed to update timezone: %w", err)
+			}
+		}
+
+		if _, err := tx.Exec(ctx, `DELETE FROM store_hours WHERE store_id = $1`, storeID); err != nil {
+			return fmt.Errorf("failed to clear store hours: %w", err)
+		}
+
+		batch := &pgx.Batch{}
+		for _, h := range hours {
+			batch.Queue(`
+				INSERT INTO store_hours (store_id, day_of_week, open_time, close_time, is_closed)
+				VALUES ($1, $2, $3::time, $4::time, $5)
+			`, storeID, h.DayOfWeek, h.OpenTime, h.CloseTime, h.IsClosed)
+		}
+		if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+			return fmt.Errorf("failed to insert store hours: %w", err)
+		}
+
+		if err := tx.Commit(ctx); err != nil {
+			return fmt.Errorf("failed to commit transaction: %w", err)
+		}
+		return nil
+	})
+	if IsRepositoryError(err) {
+		return err
+	}
+	if err != nil {
+		r.log(ctx).Error("Failed to replace store hours", zap.String("store_id", storeID), zap.Error(err))
+		return NewQueryError(err)
+	}
+
+	after := map[string]any{"hours": hours}
+	if timezone != nil {
+		after["timezone"] = *timezone
+	}
+	r.recordAudit(ctx, AuditStoreHours, []string{storeID}, map[string]any{"hours": before}, after)
+
+	r.log(ctx).Info("Replaced store hours", zap.String("store_id", storeID), zap.Int("windows", len(hours)))
+	return nil
+}
+
+// UpsertStoreHoliday creates or replaces a store's holiday for a date
+func (r *PostgresRepository) UpsertStoreHoliday(ctx context.Context, storeID string, input StoreHolidayInput) (*StoreHoliday, error) {
+	var holiday *StoreHoliday
+	err := r.retry(ctx, AuditStoreHours, func() (err error) {
+		rows, _ := r.conn().Query(ctx, `
+			INSERT INTO store_holidays (store_id, date, is_closed, open_time, close_time, note)
+			SELECT id, $2::date, $3, $4::time, $5::time, $6 FROM stores WHERE id = $1
+			ON CONFLICT (store_id, date) DO UPDATE SET
+				is_closed = EXCLUDED.is_closed,
+				open_time = EXCLUDED.open_time,
+				close_time = EXCLUDED.close_time,
+				note = EXCLUDED.note,
+				updated_at = CURRENT_TIMESTAMP
+			RETURNING `+storeHolidayColumns,
+			storeID, input.Date, input.IsClosed, input.OpenTime, input.CloseTime, input.Note)
+		holiday, err = pgx.CollectExactlyOneRow(rows, pgx.RowToAddrOfStructByName[StoreHoliday])
+		return err
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, NewNotFoundError("stores", storeID)
+	}
+	if err != nil {
+		r.log(ctx).Error("Failed to save store holiday", zap.String("store_id", storeID), zap.Error(err))
+		return nil, NewQueryError(err)
+	}
+
+	r.recordAudit(ctx, AuditStoreHours, []string{storeID}, nil, map[string]any{"holiday": holiday})
+	return holiday, nil
+}
+
+// DeleteStoreHoliday removes a store's holiday for a date (YYYY-MM-DD)
+func (r *PostgresRepository) DeleteStoreHoliday(ctx context.Context, storeID, date string) error {
+	tag, err := r.exec(ctx, `DELETE FROM store_holidays WHERE store_id = $1 AND date = $2::date`, storeID, date)
+	if err != nil {
+		r.log(ctx).Error("Failed to delete store holiday", zap.String("store_id", storeID), zap.Error(err))
+		return NewQueryError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return NewNotFoundError("store_holidays", date)
+	}
+
+	r.recordAudit(ctx, AuditStoreHours, []string{storeID}, map[string]any{"holiday_date": date}, nil)
+	return nil
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOpenAt(t *testing.T) {
+	// 2024-06-12 is a Wednesday (day 3)
+	at := func(clock string) time.Time {
+		ts, err := time.Parse(time.DateTime, "2024-06-12 "+clock)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ts
+	}
+	clock := func(s string) *string { return &s }
+
+	weekly := []StoreHours{
+		{DayOfWeek: 3, OpenTime: "09:00:00", CloseTime: "13:00:00"},
+		{DayOfWeek: 3, OpenTime: "16:00:00", CloseTime: "21:00:00"},
+		{DayOfWeek: 2, OpenTime: "20:00:00", CloseTime: "02:00:00"}, // Tuesday, past midnight
+		{DayOfWeek: 4, OpenTime: "00:00:00", CloseTime: "00:00:00"}, // Thursday, all day
+	}
+
+	tests := []struct {
+		name     string
+		hours    []StoreHours
+		holidays []StoreHoliday
+		at       time.Time
+		want     bool
+	}{
+		{"inside morning window", weekly, nil, at("10:30:00"), true},
+		{"at closing time", weekly, nil, at("13:00:00"), false},
+		{"between windows", weekly, nil, at("14:00:00"), false},
+		{"inside evening window", weekly, nil, at("20:59:59"), true},
+		{"yesterday's overnight window", weekly, nil, at("01:30:00"), true},
+		{"after overnight window closes", weekly, nil, at("02:00:00"), false},
+		{"open all day", weekly, nil, at("23:59:00").AddDate(0, 0, 1), true},
+		{"no window that day", weekly, nil, at("12:00:00").AddDate(0, 0, 2), false},
+		{
+			"closed holiday",
+			weekly,
+			[]StoreHoliday{{Date: "2024-06-12", IsClosed: true}},
+			at("10:30:00"),
+			false,
+		},
+		{
+			"holiday with special hours",
+			weekly,
+			[]StoreHoliday{{Date: "2024-06-12", OpenTime: clock("14:00"), CloseTime: clock("15:00")}},
+			at("14:30:00"),
+			true,
+		},
+		{
+			"special hours replace the weekday",
+			weekly,
+			[]StoreHoliday{{Date: "2024-06-12", OpenTime: clock("14:00"), CloseTime: clock("15:00")}},
+			at("10:30:00"),
+			false,
+		},
+		{
+			"closed holiday yesterday ends overnight window",
+			weekly,
+			[]StoreHoliday{{Date: "2024-06-11", IsClosed: true}},
+			at("01:30:00"),
+			false,
+		},
+		{
+			"closed weekday",
+			[]StoreHours{{DayOfWeek: 3, OpenTime: "00:00:00", CloseTime: "00:00:00", IsClosed: true}},
+			nil,
+			at("10:30:00"),
+			false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := openAt(tt.hours, tt.holidays, tt.at); got != tt.want {
+				t.Errorf("openAt(%s) = %v, want %v", tt.at.Format(time.DateTime), got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseClock(t *testing.T) {
+	tests := map[string]int{"00:00": 0, "09:30": 34200, "23:59:59": 86399}
+	for clock, want := range tests {
+		if got, err := ParseClock(clock); err != nil || got != want {
+			t.Errorf("ParseClock(%q) = %d, %v, want %d", clock, got, err, want)
+		}
+	}
+
+	for _, clock := range []string{"", "9am", "24:00", "12:60"} {
+		if _, err := ParseClock(clock); err == nil {
+			t.Errorf("ParseClock(%q) succeeded, want error", clock)
+		}
+	}
+}
@@ -0,0 +1,317 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+// ProductUpdate changes a product as listed in one store; nil fields are left unchanged.
+// Price and the flags belong to the store's listing. Name, Description and Images change
+// the catalog product, which every store listing it shares, as a push would
+type ProductUpdate struct {
+	Price       *float64
+	Name        *string
+	Description *string
+	Images      []string // Replaces the product's images, the first becoming primary; empty clears them
+	IsAvailable *bool
+	IsInStock   *bool
+	IsFeatured  *bool
+}
+
+// findStoreProductForUpdate locks a store's listing by the product's ERP ID, with the store
+// matched on storeColumn ("id" or "external_id"), and returns its store product and product UUIDs
+func (r *PostgresRepository) findStoreProductForUpdate(ctx context.Context, storeColumn, storeID, externalID string) (string, string, error) {
+	var storeProductID, productID string
+	err := r.conn().QueryRow(ctx, `
+		SELECT sp.id, sp.product_id
+		FROM store_products sp
+		JOIN stores s ON s.id = sp.store_id
+		WHERE s.`+storeColumn+` = $1 AND sp.external_id = $2
+		FOR UPDATE OF sp
+	`, storeID, externalID).Scan(&storeProductID, &productID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", "", NewNotFoundError("store_products", externalID)
+	}
+	return storeProductID, productID, err
+}
+
+// UpdateStoreProduct applies a sparse update to a store's listing, found by the store's
+// and product's ERP IDs, and its catalog product in one transaction, auditing the fields
+// that changed. A later push that includes the product overwrites these changes, except
+// availability, which only a full sync sets again
+func (r *PostgresRepository) UpdateStoreProduct(ctx context.Context, storeExternalID, externalID string, update ProductUpdate) (*StoreListing, error) {
+	listingQuery := `UPDATE store_products SET updated_at = CURRENT_TIMESTAMP`
+	var listingArgs []interface{}
+	setListing := func(column string, value interface{}) {
+		listingArgs = append(listingArgs, value)
+		listingQuery += fmt.Sprintf(", %s = $%d", column, len(listingArgs))
+	}
+	if update.Price != nil {
+		setListing("price", *update.Price)
+	}
+	if update.IsAvailable != nil {
+		setListing("is_available", *update.IsAvailable)
+	}
+	if update.IsInStock != nil {
+		setListing("is_in_stock", *update.IsInStock)
+	}
+	if update.IsFeatured != nil {
+		setListing("is_featured", *update.IsFeatured)
+	}
+
+	productQuery := `UPDATE products SET updated_at = CURRENT_TIMESTAMP`
+	var productArgs []interface{}
+	setProduct := func(column string, value interface{}) {
+		productArgs = append(productArgs, value)
+		productQuery += fmt.Sprintf(", %s = $%d", column, len(productArgs))
+	}
+	if update.Name != nil {
+		setProduct("name", *update.Name)
+	}
+	if update.Description != nil {
+		setProduct("description", *update.Description)
+	}
+	if update.Images != nil {
+		var primary *string
+		if len(update.Images) > 0 {
+			primary = &update.Images[0]
+		}
+		setProduct("primary_image_url", primary)
+	}
+
+	if len(listingArgs) == 0 && len(productArgs) == 0 {
+		return nil, NewValidationError("no fields to update")
+	}
+
+	var listing StoreListing
+	var before, after map[string]any
+	err := r.WithTx(ctx, func(tx *PostgresRepository) error {
+		storeProductID, productID, err := tx.findStoreProductForUpdate(ctx, "external_id", storeExternalID, externalID)
+		if err != nil {
+			return err
+		}
+		before, after = map[string]any{}, map[string]any{}
+
+		if len(listingArgs) > 0 {
+			if err := tx.updateAuditedRow(ctx, "store_products", listingQuery, listingArgs, storeProductID, before, after); err != nil {
+				return err
+			}
+		}
+		if len(productArgs) > 0 {
+			if err := tx.updateAuditedRow(ctx, "products", productQuery, productArgs, productID, before, after); err != nil {
+				return err
+			}
+		}
+		if update.Images != nil {
+			if err := tx.replaceProductImages(ctx, productID, update.Images, before, after); err != nil {
+				return err
+			}
+		}
+
+		listing, err = queryRow(ctx, tx, pgx.RowToStructByName[StoreListing],
+			`SELECT `+storeListingColumns+storeListingJoins+` WHERE sp.id = $1`, storeProductID)
+		if err != nil {
+			return err
+		}
+
+		tx.recordAudit(ctx, AuditProductUpdate, []string{storeExternalID, externalID, storeProductID, productID}, before, after)
+		return nil
+	})
+	if IsRepositoryError(err) {
+		return nil, err
+	}
+	if err != nil {
+		r.log(ctx).Error("Failed to update store product",
+			zap.String("store_id", storeExternalID),
+			zap.String("external_id", externalID),
+			zap.Error(err))
+		return nil, NewQueryError(err)
+	}
+
+	r.log(ctx).Info("Updated store product",
+		zap.String("store_id", storeExternalID),
+		zap.String("external_id", externalID),
+		zap.Int("fields_changed", len(after)))
+
+	return &listing, nil
+}
+
+// StoreProductRemoval is the outcome of DeactivateStoreProduct
+type StoreProductRemoval struct {
+	StoreProductID     string `json:"store_product_id"`
+	ProductID          string `json:"product_id"`
+	ProductDeactivated bool   `json:"product_deactivated"`
+}
+
+// DeactivateStoreProduct marks a store's listing, found by the product's ERP ID, unavailable,
+// as a full sync does for listings missing from it. With deactivateOrphan the catalog product
+// is deactivated too when no other store has it available. The rows are kept, so a later push
+// that includes the product lists it again
+func (r *PostgresRepository) DeactivateStoreProduct(ctx context.Context, storeID, externalID string, deactivateOrphan bool) (*StoreProductRemoval, error) {
+	var removal StoreProductRemoval
+	err := r.WithTx(ctx, func(tx *PostgresRepository) error {
+		storeProductID, productID, err := tx.findStoreProductForUpdate(ctx, "id", storeID, externalID)
+		if err != nil {
+			return err
+		}
+		removal = StoreProductRemoval{StoreProductID: storeProductID, ProductID: productID}
+		before, after := map[string]any{}, map[string]any{}
+
+		err = tx.updateAuditedRow(ctx, "store_products",
+			`UPDATE store_products SET updated_at = CURRENT_TIMESTAMP, is_available = $1`, []interface{}{false},
+			storeProductID, before, after)
+		if err != nil {
+			return err
+		}
+
+		if deactivateOrphan {
+			var orphaned bool
+			err := tx.conn().QueryRow(ctx, `
+				SELECT COALESCE(p.is_active, false) AND NOT EXISTS (
+					SELECT 1 FROM store_products sp
+					WHERE sp.product_id = p.id AND sp.id <> $2 AND sp.is_available = true
+				)
+				FROM products p WHERE p.id = $1
+				FOR UPDATE
+			`, productID, storeProductID).Scan(&orphaned)
+			if err != nil {
+				return fmt.Errorf("failed to check other listings: %w", err)
+			}
+			if orphaned {
+				err = tx.updateAuditedRow(ctx, "products",
+					`UPDATE products SET updated_at = CURRENT_TIMESTAMP, is_active = $1`, []interface{}{false},
+					productID, before, after)
+				if err != nil {
+					return err
+				}
+				removal.ProductDeactivated = true
+			}
+		}
+
+		tx.recordAudit(ctx, AuditProductUpdate, []string{storeID, externalID, storeProductID, productID}, before, after)
+		return nil
+	})
+	if IsRepositoryError(err) {
+		return nil, err
+	}
+	if err != nil {
+		r.log(ctx).Error("Failed to deactivate store product",
+			zap.String("store_id", storeID),
+			zap.String("external_id", externalID),
+			zap.Error(err))
+		return nil, NewQueryError(err)
+	}
+
+	r.log(ctx).Info("Deactivated store product",
+		zap.String("store_id", storeID),
+		zap.String("external_id", externalID),
+		zap.Bool("product_deactivated", removal.ProductDeactivated))
+
+	return &removal, nil
+}
+
+// SetStoreProductAvailability marks a store's listing, found by the product's ERP ID, available
+// or not, leaving its stock as it is, and returns its store product UUID. The next full sync
+// or stock update that includes the product sets availability again
+func (r *PostgresRepository) SetStoreProductAvailability(ctx context.Context, storeID, externalID string, available bool) (string, error) {
+	var storeProductID string
+	err := r.WithTx(ctx, func(tx *PostgresRepository) error {
+		var productID string
+		var err error
+		storeProductID, productID, err = tx.findStoreProductForUpdate(ctx, "id", storeID, externalID)
+		if err != nil {
+			return err
+		}
+
+		before, after := map[string]any{}, map[string]any{}
+		err = tx.updateAuditedRow(ctx, "store_products",
+			`UPDATE store_products SET updated_at = CURRENT_TIMESTAMP, is_available = $1`, []interface{}{available},
+			storeProductID, before, after)
+		if err != nil {
+			return err
+		}
+
+		tx.recordAudit(ctx, AuditProductUpdate, []string{storeID, externalID, storeProductID, productID}, before, after)
+		return nil
+	})
+	if IsRepositoryError(err) {
+		return "", err
+	}
+	if err != nil {
+		r.log(ctx).Error("Failed to set store product availability",
+			zap.String("store_id", storeID),
+			zap.String("external_id", externalID),
+			zap.Error(err))
+		return "", NewQueryError(err)
+	}
+
+	r.log(ctx).Info("Set store product availability",
+		zap.String("store_id", storeID),
+		zap.String("external_id", externalID),
+		zap.Bool("is_available", available))
+
+	return storeProductID, nil
+}
+
+// updateAuditedRow finishes an "UPDATE <table> SET ..." statement for the row with id,
+// runs it and adds the columns it changed to before and after, as updateStore does
+func (r *PostgresRepository) updateAuditedRow(ctx context.Context, table, update string, args []interface{}, id string, before, after map[string]any) error {
+	update += fmt.Sprintf(`
+		FROM (SELECT * FROM %[1]s WHERE id = $%[2]d FOR UPDATE) old
+		WHERE %[1]s.id = old.id
+		RETURNING to_jsonb(old), to_jsonb(%[1]s)`, table, len(args)+1)
+	args = append(args, id)
+
+	var oldRow, newRow map[string]any
+	if err := r.conn().QueryRow(ctx, update, args...).Scan(&oldRow, &newRow); err != nil {
+		return fmt.Errorf("failed to update %s: %w", table, err)
+	}
+
+	changedBefore, changedAfter := auditChanges(oldRow, newRow)
+	for field, value := range changedBefore {
+		before[field] = value
+	}
+	for field, value := range changedAfter {
+		after[field] = value
+	}
+	return nil
+}
+
+// replaceProductImages replaces a product's images with urls, in order, adding the old and
+// new lists to before and after when they differ
+func (r *PostgresRepository) replaceProductImages(ctx context.Context, productID string, urls []string, before, after map[string]any) error {
+	var old []string
+	err := r.conn().QueryRow(ctx, `
+		WITH deleted AS (
+			DELETE FROM product_images WHERE product_id = $1
+			RETURNING image_url, display_order
+		)
+		SELECT COALESCE(array_agg(image_url ORDER BY display_order), '{}') FROM deleted
+	`, productID).Scan(&old)
+	if err != nil {
+		return fmt.Errorf("failed to delete product images: %w", err)
+	}
+
+	if len(urls) > 0 {
+		// A repeated URL keeps its first position
+		_, err = r.conn().Exec(ctx, `
+			INSERT INTO product_images (product_id, image_url, display_order, is_primary)
+			SELECT $1, url, ord - 1, ord = 1
+			FROM unnest($2::text[]) WITH ORDINALITY AS u(url, ord)
+			ON CONFLICT (product_id, image_url) DO NOTHING
+		`, productID, urls)
+		if err != nil {
+			return fmt.Errorf("failed to insert product images: %w", err)
+		}
+	}
+
+	if !slices.Equal(old, urls) {
+		before["images"], after["images"] = old, urls
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+// productImages returns the image URLs of a product in display order
+func productImages(t *testing.T, r *PostgresRepository, productID string) []string {
+	t.Helper()
+	var urls []string
+	err := r.conn().QueryRow(context.Background(), `
+		SELECT COALESCE(array_agg(image_url ORDER BY display_order), '{}') FROM product_images WHERE product_id = $1
+	`, productID).Scan(&urls)
+	if err != nil {
+		t.Fatalf("failed to read images of %s: %v", productID, err)
+	}
+	return urls
+}
+
+func TestUpdateStoreProduct(t *testing.T) {
+	r := testPostgres(t)
+	ctx := context.Background()
+	storeUUID := seedStore(t, r, "TEST-PATCH")
+	seedProducts(t, r, "TEST-PATCH", PushOptions{}, "PATCH-1")
+
+	price, name, unavailable := 12.5, "Patched Test Product", false
+	images := []string{"https://example.com/patch-1.jpg", "https://example.com/patch-2.jpg"}
+	listing, err := r.UpdateStoreProduct(ctx, "TEST-PATCH", "PATCH-1", ProductUpdate{
+		Price:       &price,
+		Name:        &name,
+		Images:      images,
+		IsAvailable: &unavailable,
+	})
+	if err != nil {
+		t.Fatalf("UpdateStoreProduct() error = %v", err)
+	}
+	if listing.StoreID != storeUUID || listing.Price != price || listing.Name != name {
+		t.Errorf("UpdateStoreProduct() = %+v, want store %s at price %v named %q", listing, storeUUID, price, name)
+	}
+	if listing.PrimaryImageURL == nil || *listing.PrimaryImageURL != images[0] {
+		t.Errorf("UpdateStoreProduct() primary image = %v, want %s", listing.PrimaryImageURL, images[0])
+	}
+	if got := productImages(t, r, listing.ProductID); len(got) != 2 || got[0] != images[0] || got[1] != images[1] {
+		t.Errorf("product images = %v, want %v", got, images)
+	}
+	if listingAvailable(t, r, storeUUID, "PATCH-1") {
+		t.Error("listing available after UpdateStoreProduct(), want unavailable")
+	}
+
+	// Omitted fields are unchanged; an empty image list clears them
+	description := "Now with a description"
+	if listing, err = r.UpdateStoreProduct(ctx, "TEST-PATCH", "PATCH-1", ProductUpdate{Description: &description, Images: []string{}}); err != nil {
+		t.Fatalf("UpdateStoreProduct() of the description error = %v", err)
+	}
+	if listing.Price != price || listing.Name != name || listing.Description == nil || *listing.Description != description {
+		t.Errorf("UpdateStoreProduct() = %+v, want the description added and the rest kept", listing)
+	}
+	if listing.PrimaryImageURL != nil || len(productImages(t, r, listing.ProductID)) != 0 {
+		t.Errorf("UpdateStoreProduct() primary image = %v, want the images cleared", listing.PrimaryImageURL)
+	}
+
+	tests := []struct {
+		name       string
+		store      string
+		externalID string
+		update     ProductUpdate
+		wantStatus int
+	}{
+		{name: "no fields", store: "TEST-PATCH", externalID: "PATCH-1", wantStatus: http.StatusBadRequest},
+		{name: "unknown product", store: "TEST-PATCH", externalID: "PATCH-404", update: ProductUpdate{Price: &price}, wantStatus: http.StatusNotFound},
+		{name: "unknown store", store: "TEST-PATCH-404", externalID: "PATCH-1", update: ProductUpdate{Price: &price}, wantStatus: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := r.UpdateStoreProduct(ctx, tt.store, tt.externalID, tt.update)
+			if got := GetStatusCode(err); got != tt.wantStatus {
+				t.Errorf("UpdateStoreProduct() error = %v (status %d), want status %d", err, got, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestDeactivateStoreProduct(t *testing.T) {
+	r := testPostgres(t)
+	ctx := context.Background()
+	storeUUID := seedStore(t, r, "TEST-REMOVE")
+	otherUUID := seedStore(t, r, "TEST-REMOVE-OTHER")
+	seedProducts(t, r, "TEST-REMOVE", PushOptions{}, "REMOVE-1")
+	productID := listingProduct(t, r, storeUUID, "REMOVE-1")
+
+	// The other store has the product available too
+	_, err := r.conn().Exec(ctx, `
+		INSERT INTO store_products (store_id, product_id, external_id, price) VALUES ($1, $2, 'OTHER-1', 12)
+	`, otherUUID, productID)
+	if err != nil {
+		t.Fatalf("failed to list the product in the other store: %v", err)
+	}
+	productActive := func() bool {
+		t.Helper()
+		return countRows(t, r, `SELECT count(*) FROM products WHERE id = $1 AND is_active`, productID) == 1
+	}
+
+	removal, err := r.DeactivateStoreProduct(ctx, storeUUID, "REMOVE-1", true)
+	if err != nil {
+		t.Fatalf("DeactivateStoreProduct() error = %v", err)
+	}
+	if removal.ProductID != productID || removal.ProductDeactivated {
+		t.Errorf("DeactivateStoreProduct() = %+v, want product %s kept active for the other store", removal, productID)
+	}
+	if listingAvailable(t, r, storeUUID, "REMOVE-1") || !productActive() {
+		t.Error("after DeactivateStoreProduct() want the listing unavailable and the product active")
+	}
+
+	// Without the flag the last available listing goes, and the product stays
+	if removal, err = r.DeactivateStoreProduct(ctx, otherUUID, "OTHER-1", false); err != nil || removal.ProductDeactivated || !productActive() {
+		t.Errorf("DeactivateStoreProduct() without deactivate_product = %+v, %v, want the product kept active", removal, err)
+	}
+	if removal, err = r.DeactivateStoreProduct(ctx, otherUUID, "OTHER-1", true); err != nil || !removal.ProductDeactivated || productActive() {
+		t.Errorf("DeactivateStoreProduct() of the last listing = %+v, %v, want the product deactivated", removal, err)
+	}
+
+	if _, err := r.DeactivateStoreProduct(ctx, otherUUID, "REMOVE-1", false); GetStatusCode(err) != http.StatusNotFound {
+		t.Errorf("DeactivateStoreProduct() of another store's listing error = %v, want not found", err)
+	}
+}
+
+func TestSetStoreProductAvailability(t *testing.T) {
+	r := testPostgres(t)
+	ctx := context.Background()
+	storeUUID := seedStore(t, r, "TEST-AVAIL")
+	seedProducts(t, r, "TEST-AVAIL", PushOptions{}, "AVAIL-1")
+	want := storeProductID(t, r, storeUUID, "AVAIL-1")
+	stock := func() float64 {
+		t.Helper()
+		var quantity float64
+		err := r.conn().QueryRow(ctx, `SELECT stock_quantity FROM store_products WHERE id = $1`, want).Scan(&quantity)
+		if err != nil {
+			t.Fatalf("failed to read stock: %v", err)
+		}
+		return quantity
+	}
+
+	for _, available := range []bool{false, true} {
+		got, err := r.SetStoreProductAvailability(ctx, storeUUID, "AVAIL-1", available)
+		if err != nil {
+			t.Fatalf("SetStoreProductAvailability(%v) error = %v", available, err)
+		}
+		if got != want {
+			t.Errorf("SetStoreProductAvailability(%v) = %s, want store product %s", available, got, want)
+		}
+		if listingAvailable(t, r, storeUUID, "AVAIL-1") != available || stock() != 5 {
+			t.Errorf("after SetStoreProductAvailability(%v) want that availability with the stock of 5 kept", available)
+		}
+	}
+
+	if _, err := r.SetStoreProductAvailability(ctx, storeUUID, "AVAIL-404", false); GetStatusCode(err) != http.StatusNotFound {
+		t.Errorf("SetStoreProductAvailability() of an unknown product error = %v, want not found", err)
+	}
+}
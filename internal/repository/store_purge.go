@@ -0,0 +1,174 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.uber.org/zap"
+)
+
+// StorePurge counts what purging a store removes, or removed
+// Products and Images are catalog products no other store lists, and their images
+type StorePurge struct {
+	StoreID       string `json:"store_id"`
+	ExternalID    string `json:"external_id"`
+	DryRun        bool   `json:"dry_run"`
+	StoreProducts int64  `json:"store_products"`
+	Variations    int64  `json:"variations"`
+	Taxes         int64  `json:"taxes"`
+	ProductTaxes  int64  `json:"product_taxes"`
+	Mappings      int64  `json:"mappings"`
+	Products      int64  `json:"products"`
+	Images        int64  `json:"images"`
+	DeliveryZones int64  `json:"delivery_zones"`
+	HoursEntries  int64  `json:"hours_entries"`
+}
+
+// orphanedProducts selects the products listed by store $1 and by no other store
+const orphanedProducts = `
+	SELECT sp.product_id FROM store_products sp
+	WHERE sp.store_id = $1
+	  AND NOT EXISTS (
+		SELECT 1 FROM store_products other
+		WHERE other.product_id = sp.product_id AND other.store_id <> $1
+	  )`
+
+// PurgeStore deletes a store with everything that belongs to it, in one transaction:
+// its listings with their variations and taxes, its taxes, ERP mappings, delivery zones
+// and hours, and the catalog products no other store lists, with their images.
+// Stores with orders can't be purged. With dryRun nothing is deleted and the counts say
+// what would be
+func (r *PostgresRepository) PurgeStore(ctx context.Context, storeID string, dryRun bool) (*StorePurge, error) {
+	var purge *StorePurge
+	err := r.WithTx(ctx, func(tx *PostgresRepository) error {
+		var err error
+		if purge, err = tx.countStorePurge(ctx, storeID); err != nil {
+			return err
+		}
+		purge.DryRun = dryRun
+		if dryRun {
+			return nil
+		}
+
+		// Products are found before the store's listings cascade away
+		var orphans []string
+		err = tx.conn().QueryRow(ctx, `SELECT COALESCE(array_agg(product_id::text), '{}') FROM (`+orphanedProducts+`) o`,
+			storeID).Scan(&orphans)
+		if err != nil {
+			return fmt.Errorf("failed to find orphaned products: %w", err)
+		}
+
+		if _, err := tx.conn().Exec(ctx, `DELETE FROM stores WHERE id = $1`, storeID); err != nil {
+			return fmt.Errorf("failed to delete store: %w", err)
+		}
+		if _, err := tx.conn().Exec(ctx, `DELETE FROM products WHERE id = ANY($1::uuid[])`, orphans); err != nil {
+			return fmt.Errorf("failed to delete orphaned products: %w", err)
+		}
+
+		tx.recordAudit(ctx, AuditStorePurge, []string{storeID, purge.ExternalID}, map[string]any{
+			"store_products": purge.StoreProducts,
+			"variations":     purge.Variations,
+			"taxes":          purge.Taxes,
+			"product_taxes":  purge.ProductTaxes,
+			"mappings":       purge.Mappings,
+			"products":       purge.Products,
+			"images":         purge.Images,
+			"delivery_zones": purge.DeliveryZones,
+			"hours_entries":  purge.HoursEntries,
+		}, nil)
+		return nil
+	})
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23503" { // foreign_key_violation
+		return nil, NewConflictError("store data is still referenced, e.g. by orders of other stores", err)
+	}
+	if IsRepositoryError(err) {
+		return nil, err
+	}
+	if err != nil {
+		r.log(ctx).Error("Failed to purge store", zap.String("store_id", storeID), zap.Error(err))
+		return nil, NewQueryError(err)
+	}
+
+	if !dryRun {
+		r.log(ctx).Warn("Purged store",
+			zap.String("store_id", storeID),
+			zap.String("external_id", purge.ExternalID),
+			zap.Int64("store_products", purge.StoreProducts),
+			zap.Int64("products", purge.Products))
+	}
+	return purge, nil
+}
+
+// countStorePurge locks a store and its products and counts what purging it removes
+// Returns a conflict error if the store has orders
+func (r *PostgresRepository) countStorePurge(ctx context.Context, storeID string) (*StorePurge, error) {
+	purge := &StorePurge{StoreID: storeID}
+	var externalID *string
+	var hasOrders, hasMappings bool
+	err := r.conn().QueryRow(ctx, `
+		SELECT external_id,
+		       EXISTS (SELECT 1 FROM orders WHERE store_id = $1),
+		       to_regclass('store_product_mappings') IS NOT NULL
+		FROM stores WHERE id = $1
+		FOR UPDATE
+	`, storeID).Scan(&externalID, &hasOrders, &hasMappings)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, NewNotFoundError("stores", storeID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if externalID != nil {
+		purge.ExternalID = *externalID
+	}
+	if hasOrders {
+		return nil, NewConflictError("store has orders and can't be purged", nil)
+	}
+
+	// Listing a product takes a key share lock on it, so locking the store's products waits
+	// for listings of them other stores are creating and blocks new ones until the purge
+	// ends. Deleting a product cascades to its listings, so an orphan counted here must
+	// still be one when it is deleted
+	_, err = r.conn().Exec(ctx, `
+		SELECT 1 FROM products
+		WHERE id IN (SELECT product_id FROM store_products WHERE store_id = $1)
+		ORDER BY id
+		FOR UPDATE
+	`, storeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock store products: %w", err)
+	}
+
+	err = r.conn().QueryRow(ctx, `
+		SELECT
+			(SELECT count(*) FROM store_products WHERE store_id = $1),
+			(SELECT count(*) FROM product_variations v
+			 JOIN store_products sp ON sp.id = v.store_product_id WHERE sp.store_id = $1),
+			(SELECT count(*) FROM taxes WHERE store_id = $1),
+			(SELECT count(*) FROM store_product_taxes WHERE store_id = $1),
+			(SELECT count(*) FROM (`+orphanedProducts+`) o),
+			(SELECT count(*) FROM product_images WHERE product_id IN (`+orphanedProducts+`)),
+			(SELECT count(*) FROM delivery_zones WHERE store_id = $1),
+			(SELECT count(*) FROM store_hours WHERE store_id = $1)
+			  + (SELECT count(*) FROM store_holidays WHERE store_id = $1)
+	`, storeID).Scan(&purge.StoreProducts, &purge.Variations, &purge.Taxes, &purge.ProductTaxes,
+		&purge.Products, &purge.Images, &purge.DeliveryZones, &purge.HoursEntries)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count store data: %w", err)
+	}
+
+	// The mappings table comes from an optional migration
+	if hasMappings {
+		err = r.conn().QueryRow(ctx, `SELECT count(*) FROM store_product_mappings WHERE store_id = $1`,
+			storeID).Scan(&purge.Mappings)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count store product mappings: %w", err)
+		}
+	}
+	return purge, nil
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+// listingProduct returns the product ID of the store's listing with externalID
+func listingProduct(t *testing.T, r *PostgresRepository, storeUUID, externalID string) string {
+	t.Helper()
+	var productID string
+	err := r.conn().QueryRow(context.Background(), `
+		SELECT product_id::text FROM store_products WHERE store_id = $1 AND external_id = $2
+	`, storeUUID, externalID).Scan(&productID)
+	if err != nil {
+		t.Fatalf("failed to read listing %s: %v", externalID, err)
+	}
+	return productID
+}
+
+// countRows returns the result of a count query
+func countRows(t *testing.T, r *PostgresRepository, query string, args ...any) int64 {
+	t.Helper()
+	var n int64
+	if err := r.conn().QueryRow(context.Background(), query, args...).Scan(&n); err != nil {
+		t.Fatalf("failed to count rows: %v", err)
+	}
+	return n
+}
+
+func TestPurgeStore(t *testing.T) {
+	r := testPostgres(t)
+	ctx := context.Background()
+	storeUUID := seedStore(t, r, "TEST-PURGE")
+	otherUUID := seedStore(t, r, "TEST-PURGE-OTHER")
+
+	products := []ProductInput{
+		{ExternalProductID: "PURGE-1", Name: testProductName("PURGE-1"), BasePrice: 10, IsActive: true,
+			Images: []string{"https://example.com/purge-1.jpg"}},
+		{ExternalProductID: "PURGE-2", Name: testProductName("PURGE-2"), BasePrice: 10, IsActive: true,
+			Images: []string{"https://example.com/purge-2a.jpg", "https://example.com/purge-2b.jpg"}},
+	}
+	listings := []StoreProductInput{
+		{ExternalProductID: "PURGE-1", Price: 10, StockQuantity: 5, IsInStock: true},
+		{ExternalProductID: "PURGE-2", Price: 10, StockQuantity: 5, IsInStock: true},
+	}
+	if _, err := r.UpsertProductsWithMatching(ctx, "TEST-PURGE", products, nil, listings, PushOptions{}); err != nil {
+		t.Fatalf("UpsertProductsWithMatching() error = %v", err)
+	}
+	shared := listingProduct(t, r, storeUUID, "PURGE-1")
+	orphan := listingProduct(t, r, storeUUID, "PURGE-2")
+
+	// The other store lists PURGE-1 too, so only PURGE-2 is the purged store's alone
+	_, err := r.conn().Exec(ctx, `
+		INSERT INTO store_products (store_id, product_id, external_id, price) VALUES ($1, $2, 'OTHER-1', 12)
+	`, otherUUID, shared)
+	if err != nil {
+		t.Fatalf("failed to list the shared product: %v", err)
+	}
+
+	dryRun, err := r.PurgeStore(ctx, storeUUID, true)
+	if err != nil {
+		t.Fatalf("PurgeStore() dry run error = %v", err)
+	}
+	if !dryRun.DryRun || dryRun.ExternalID != "TEST-PURGE" || dryRun.StoreProducts != 2 || dryRun.Products != 1 || dryRun.Images != 2 {
+		t.Errorf("PurgeStore() dry run = %+v, want 2 listings, 1 product and its 2 images", dryRun)
+	}
+	if n := countRows(t, r, `SELECT count(*) FROM store_products WHERE store_id = $1`, storeUUID); n != 2 {
+		t.Errorf("listings after a dry run = %d, want 2", n)
+	}
+
+	purge, err := r.PurgeStore(ctx, storeUUID, false)
+	if err != nil {
+		t.Fatalf("PurgeStore() error = %v", err)
+	}
+	if purge.DryRun || purge.StoreProducts != dryRun.StoreProducts || purge.Products != dryRun.Products || purge.Images != dryRun.Images {
+		t.Errorf("PurgeStore() = %+v, want the dry run's counts %+v", purge, dryRun)
+	}
+
+	if n := countRows(t, r, `SELECT count(*) FROM stores WHERE id = $1`, storeUUID); n != 0 {
+		t.Errorf("purged store rows = %d, want 0", n)
+	}
+	if n := countRows(t, r, `SELECT count(*) FROM products WHERE id = $1`, orphan); n != 0 {
+		t.Errorf("orphaned product rows = %d, want 0", n)
+	}
+	if n := countRows(t, r, `SELECT count(*) FROM product_images WHERE product_id = $1`, orphan); n != 0 {
+		t.Errorf("orphaned product images = %d, want 0", n)
+	}
+	if n := countRows(t, r, `SELECT count(*) FROM store_products WHERE store_id = $1 AND product_id = $2`, otherUUID, shared); n != 1 {
+		t.Errorf("other store's listings of the shared product = %d, want 1", n)
+	}
+	if n := countRows(t, r, `SELECT count(*) FROM product_images WHERE product_id = $1`, shared); n != 1 {
+		t.Errorf("shared product images = %d, want 1", n)
+	}
+
+	if _, err := r.PurgeStore(ctx, storeUUID, false); GetStatusCode(err) != http.StatusNotFound {
+		t.Errorf("PurgeStore() of a purged store error = %v, want not found", err)
+	}
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+// NearbyStore is a store with its distance from the search point
+type NearbyStore struct {
+	Store
+	DistanceKm float64 `db:"distance_km" json:"distance_km"`
+}
+
+// NearbyStoresQuery describes a geospatial store search
+type NearbyStoresQuery struct {
+	Lat       float64
+	Lng       float64
+	RadiusKm  float64
+	StoreType string // Optional
+	Limit     int
+}
+
+// FindNearbyStores retrieves active stores within RadiusKm of a point, nearest first
+// Uses the GIST-indexed location column; stores without a location are never returned
+func (r *PostgresRepository) FindNearbyStores(ctx context.Context, q NearbyStoresQuery) ([]NearbyStore, error) {
+	query := `
+		WITH origin AS (
+			SELECT ST_SetSRID(ST_MakePoint($1, $2), 4326)::geography AS point
+		)
+		SELECT ` + storeColumns + `,
+		       ST_Distance(stores.location, origin.point) / 1000 AS distance_km
+		FROM stores, origin
+		WHERE stores.is_active = true
+		  AND ST_DWithin(stores.location, origin.point, $3)
+	`
+	args := []interface{}{q.Lng, q.Lat, q.RadiusKm * 1000}
+	argCount := 4
+
+	if q.StoreType != "" {
+		query += fmt.Sprintf(" AND stores.store_type = $%d", argCount)
+		args = append(args, q.StoreType)
+		argCount++
+	}
+
+	query += fmt.Sprintf(" ORDER BY distance_km LIMIT $%d", argCount)
+	args = append(args, q.Limit)
+
+	stores, err := queryRows(ctx, r, pgx.RowToStructByName[NearbyStore], query, args...)
+	if err != nil {
+		r.log(ctx).Error("Failed to query nearby stores", zap.Error(err))
+		return nil, fmt.Errorf("failed to query nearby stores: %w", err)
+	}
+
+	return stores, nil
+}
+
+// StoreIDByExternalID returns the UUID of the store with an ERP store ID
+func (r *PostgresRepository) StoreIDByExternalID(ctx context.Context, externalID string) (string, error) {
+	id, err := queryRow(ctx, r, pgx.RowTo[string], `SELECT id::text FROM stores WHERE external_id = $1`, externalID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", NewNotFoundError("stores", externalID)
+	}
+	if err != nil {
+		r.log(ctx).Error("Failed to look up store", zap.String("external_id", externalID), zap.Error(err))
+		return "", NewQueryError(err)
+	}
+	return id, nil
+}
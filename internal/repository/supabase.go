@@ -2,86 +2,165 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
-	"fmt"
+	"net/http"
 	"strings"
+	"time"
 
+	"github.com/supabase-community/postgrest-go"
 	"github.com/supabase-community/supabase-go"
+	"go.uber.org/zap"
 )
 
 // Pagination holds pagination parameters
+// After switches Postgres listings from offset to keyset pagination and takes precedence over Offset.
+// IncludeTotal asks for the total number of matching rows, which costs an extra count query
 type Pagination struct {
-	Limit  int
-	Offset int
+	Limit        int
+	Offset       int
+	After        *Cursor `json:"-"`
+	Keyset       bool    `json:"-"` // Pages listings newest first, as cursors do, from the first page
+	IncludeTotal bool    `json:"-"`
+}
+
+// KeysetOrder reports whether listings are paged newest first by cursor rather than by
+// name and offset
+func (p Pagination) KeysetOrder() bool {
+	return p.Keyset || p.After != nil
 }
 
 // SupabaseRepository defines the interface for Supabase data access
 type SupabaseRepository interface {
-	Query(ctx context.Context, table string, filters map[string]interface{}, pagination Pagination) ([]map[string]interface{}, error)
-	GetByID(ctx context.Context, table string, id string) (map[string]interface{}, error)
+	Query(ctx context.Context, table string, filters map[string]interface{}, pagination Pagination, opts ...QueryOption) ([]map[string]interface{}, error)
+	QueryWithCount(ctx context.Context, table string, filters map[string]interface{}, pagination Pagination, opts ...QueryOption) ([]map[string]interface{}, int64, error)
+	GetByID(ctx context.Context, table string, id string, opts ...QueryOption) (map[string]interface{}, error)
+	RPC(ctx context.Context, fn string, params map[string]interface{}) (json.RawMessage, error)
+	Insert(ctx context.Context, table string, record map[string]interface{}) (map[string]interface{}, error)
+	Update(ctx context.Context, table string, id string, changes map[string]interface{}) (map[string]interface{}, error)
+	Delete(ctx context.Context, table string, id string) error
 }
 
 // supabaseRepository implements SupabaseRepository
 type supabaseRepository struct {
-	client *supabase.Client
+	restURL        string            // PostgREST base URL
+	headers        map[string]string // API key and client headers sent with every call
+	httpClient     *http.Client
+	requestTimeout time.Duration // Bound on each HTTP call, see WithRequestTimeout
+	userAgent      string
+	userTokens     bool   // Run queries with the caller's JWT from the context, see WithUserTokens
+	schema         string // Postgres schema PostgREST serves the queries from, see WithSchema
+	// Transient failures are retried up to retryMaxAttempts times, and trip breaker if set
+	retryMaxAttempts int
+	breaker          *supabaseBreaker
+	logger           *zap.Logger
 }
 
 // NewSupabaseRepository creates a new Supabase repository instance
-func NewSupabaseRepository(url, apiKey string) (SupabaseRepository, error) {
+func NewSupabaseRepository(url, apiKey string, opts ...SupabaseOption) (SupabaseRepository, error) {
 	if url == "" || apiKey == "" {
 		return nil, NewConnectionError(errors.New("Supabase URL and API key are required"))
 	}
 
-	client, err := supabase.NewClient(url, apiKey, nil)
-	if err != nil {
-		return nil, NewConnectionError(err)
+	r := &supabaseRepository{
+		restURL: strings.TrimSuffix(url, "/") + supabase.REST_URL,
+		headers: map[string]string{
+			"apikey":        apiKey,
+			"Authorization": "Bearer " + apiKey,
+		},
+		httpClient:       http.DefaultClient,
+		retryMaxAttempts: 1,
+		logger:           zap.NewNop(),
 	}
-
-	return &supabaseRepository{
-		client: client,
-	}, nil
+	for _, opt := range opts {
+		opt(r)
+	}
+	if r.breaker != nil {
+		r.breaker.logger = r.logger
+	}
+	if r.schema != "" {
+		// Sent with RPC calls too, which are made without postgrest-go
+		r.headers["Accept-Profile"] = r.schema
+		r.headers["Content-Profile"] = r.schema
+	}
+	if r.userAgent != "" {
+		r.headers["User-Agent"] = r.userAgent
+	}
+	return r, nil
 }
 
 // Query retrieves records from a Supabase table with filtering and pagination
-func (r *supabaseRepository) Query(ctx context.Context, table string, filters map[string]interface{}, pagination Pagination) ([]map[string]interface{}, error) {
+// Filters are parsed by ParseFilters and ParseFilterGroups; invalid ones return a validation error
+func (r *supabaseRepository) Query(ctx context.Context, table string, filters map[string]interface{}, pagination Pagination, opts ...QueryOption) ([]map[string]interface{}, error) {
+	results, _, err := r.query(ctx, table, filters, pagination, false, opts)
+	return results, err
+}
+
+// QueryWithCount is Query that also returns the number of records matching the filters,
+// regardless of pagination. PostgREST returns the count with the page, so it costs no
+// second request; WithCount chooses how it is counted
+func (r *supabaseRepository) QueryWithCount(ctx context.Context, table string, filters map[string]interface{}, pagination Pagination, opts ...QueryOption) ([]map[string]interface{}, int64, error) {
+	return r.query(ctx, table, filters, pagination, true, opts)
+}
+
+// query runs Query, asking PostgREST for the count when withCount is set
+func (r *supabaseRepository) query(ctx context.Context, table string, filters map[string]interface{}, pagination Pagination, withCount bool, opts []QueryOption) ([]map[string]interface{}, int64, error) {
 	// Check for context cancellation or timeout
 	if err := ctx.Err(); err != nil {
 		if errors.Is(err, context.DeadlineExceeded) {
-			return nil, NewTimeoutError(err)
+			return nil, 0, NewTimeoutError(err)
 		}
-		return nil, NewQueryError(err)
+		return nil, 0, NewQueryError(err)
 	}
 
-	// Execute query with timeout handling
-	resultChan := make(chan queryResult, 1)
-	go func() {
-		results, err := r.executeQuery(table, filters, pagination)
-		resultChan <- queryResult{data: results, err: err}
-	}()
+	parsed, err := ParseFilters(filters)
+	if err != nil {
+		return nil, 0, err
+	}
+	groups, err := ParseFilterGroups(filters)
+	if err != nil {
+		return nil, 0, err
+	}
+	options, err := NewQueryOptions(opts...)
+	if err != nil {
+		return nil, 0, err
+	}
+	count := ""
+	if withCount {
+		count = options.Count
+	}
 
-	select {
-	case <-ctx.Done():
-		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
-			return nil, NewTimeoutError(ctx.Err())
-		}
-		return nil, NewQueryError(ctx.Err())
-	case result := <-resultChan:
-		if result.err != nil {
-			return nil, r.handleError(result.err, table)
+	var results []map[string]interface{}
+	var total int64
+	err = r.call(ctx, "query "+table, func() error {
+		// The HTTP call ends with attemptCtx, so it can't outlive the request
+		attemptCtx, cancel := r.attemptContext(ctx)
+		defer cancel()
+
+		data, n, err := r.executeQuery(r.from(attemptCtx, table), parsed, groups, pagination, options, count)
+		if err != nil {
+			return r.requestError(attemptCtx, err, table)
 		}
-		return result.data, nil
+		results, total = data, n
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
 	}
+	return results, total, nil
 }
 
 // executeQuery performs the actual query execution
-func (r *supabaseRepository) executeQuery(table string, filters map[string]interface{}, pagination Pagination) ([]map[string]interface{}, error) {
+// count is the PostgREST count mode; empty skips counting
+func (r *supabaseRepository) executeQuery(from *postgrest.QueryBuilder, filters []Filter, groups []FilterGroup, pagination Pagination, options QueryOptions, count string) ([]map[string]interface{}, int64, error) {
 	// Start building the query
-	query := r.client.From(table).Select("*", "exact", false)
+	query := from.Select(options.selectClause(), count, false)
 
 	// Apply filters
-	for key, value := range filters {
-		query = query.Eq(key, fmt.Sprintf("%v", value))
-	}
+	query = applyFilters(query, filters, groups)
+
+	// Apply ordering
+	query = options.applyOrder(query)
 
 	// Apply pagination
 	if pagination.Limit > 0 {
@@ -93,21 +172,16 @@ func (r *supabaseRepository) executeQuery(table string, filters map[string]inter
 
 	// Execute query
 	var results []map[string]interface{}
-	_, err := query.ExecuteTo(&results)
+	total, err := query.ExecuteTo(&results)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
-	return results, nil
-}
-
-type queryResult struct {
-	data []map[string]interface{}
-	err  error
+	return results, total, nil
 }
 
 // GetByID retrieves a single record by ID from a Supabase table
-func (r *supabaseRepository) GetByID(ctx context.Context, table string, id string) (map[string]interface{}, error) {
+func (r *supabaseRepository) GetByID(ctx context.Context, table string, id string, opts ...QueryOption) (map[string]interface{}, error) {
 	// Check for context cancellation or timeout
 	if err := ctx.Err(); err != nil {
 		if errors.Is(err, context.DeadlineExceeded) {
@@ -116,34 +190,36 @@ func (r *supabaseRepository) GetByID(ctx context.Context, table string, id strin
 		return nil, NewQueryError(err)
 	}
 
-	// Execute query with timeout handling
-	resultChan := make(chan getByIDResult, 1)
-	go func() {
-		result, err := r.executeGetByID(table, id)
-		resultChan <- getByIDResult{data: result, err: err}
-	}()
+	options, err := NewQueryOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
 
-	select {
-	case <-ctx.Done():
-		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
-			return nil, NewTimeoutError(ctx.Err())
-		}
-		return nil, NewQueryError(ctx.Err())
-	case result := <-resultChan:
-		if result.err != nil {
+	var item map[string]interface{}
+	err = r.call(ctx, "get "+table, func() error {
+		attemptCtx, cancel := r.attemptContext(ctx)
+		defer cancel()
+
+		data, err := r.executeGetByID(r.from(attemptCtx, table), id, options)
+		if err != nil {
 			// Check if it's a not found error
-			if r.isNotFoundError(result.err) {
-				return nil, NewNotFoundError(table, id)
+			if attemptCtx.Err() == nil && r.isNotFoundError(err) {
+				return NewNotFoundError(table, id)
 			}
-			return nil, r.handleError(result.err, table)
+			return r.requestError(attemptCtx, err, table)
 		}
-		return result.data, nil
+		item = data
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
+	return item, nil
 }
 
 // executeGetByID performs the actual get by ID execution
-func (r *supabaseRepository) executeGetByID(table string, id string) (map[string]interface{}, error) {
-	query := r.client.From(table).Select("*", "exact", false).Eq("id", id).Single()
+func (r *supabaseRepository) executeGetByID(from *postgrest.QueryBuilder, id string, options QueryOptions) (map[string]interface{}, error) {
+	query := from.Select(options.selectClause(), "exact", false).Eq("id", id).Single()
 
 	var result map[string]interface{}
 	_, err := query.ExecuteTo(&result)
@@ -154,9 +230,16 @@ func (r *supabaseRepository) executeGetByID(table string, id string) (map[string
 	return result, nil
 }
 
-type getByIDResult struct {
-	data map[string]interface{}
-	err  error
+// requestError converts the error of an HTTP call made under ctx, which a call cut short
+// by its deadline or cancellation returns too
+func (r *supabaseRepository) requestError(ctx context.Context, err error, table string) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		if errors.Is(ctxErr, context.DeadlineExceeded) {
+			return NewTimeoutError(err)
+		}
+		return NewQueryError(ctxErr)
+	}
+	return r.handleError(err, table)
 }
 
 // handleError converts Supabase errors to appropriate RepositoryErrors
@@ -164,6 +247,18 @@ func (r *supabaseRepository) handleError(err error, table string) error {
 	errMsg := err.Error()
 	errMsgLower := strings.ToLower(errMsg)
 
+	// PostgREST reports losing the database as PGRST000-002 and pool timeouts as PGRST003.
+	// An error without a PostgREST code, or a body that isn't JSON, comes from the gateway
+	// in front of it
+	switch {
+	case strings.HasPrefix(errMsg, "(PGRST000)"), strings.HasPrefix(errMsg, "(PGRST001)"),
+		strings.HasPrefix(errMsg, "(PGRST002)"), strings.HasPrefix(errMsg, "() "),
+		strings.HasPrefix(errMsg, "error parsing error response"):
+		return NewConnectionError(err)
+	case strings.HasPrefix(errMsg, "(PGRST003)"):
+		return NewTimeoutError(err)
+	}
+
 	// Check for connection errors
 	if strings.Contains(errMsgLower, "connection") || 
 	   strings.Contains(errMsgLower, "network") ||
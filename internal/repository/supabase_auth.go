@@ -0,0 +1,52 @@
+package repository
+
+import "context"
+
+type accessTokenKey struct{}
+
+// WithAccessToken attaches the caller's Supabase JWT to ctx
+// A repository created WithUserTokens runs the caller's queries with it, so RLS policies apply
+func WithAccessToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, accessTokenKey{}, token)
+}
+
+// AccessTokenFrom returns the caller's Supabase JWT attached to ctx, or "" if there is none
+func AccessTokenFrom(ctx context.Context) string {
+	token, _ := ctx.Value(accessTokenKey{}).(string)
+	return token
+}
+
+// SupabaseOption configures optional Supabase repository behaviour
+type SupabaseOption func(*supabaseRepository)
+
+// WithUserTokens runs queries as the caller whose JWT is attached to the context, instead
+// of with the API key. Calls without a token still use the API key
+func WithUserTokens() SupabaseOption {
+	return func(r *supabaseRepository) {
+		r.userTokens = true
+	}
+}
+
+// userToken returns the caller's token to send for ctx, if user tokens are enabled
+func (r *supabaseRepository) userToken(ctx context.Context) string {
+	if !r.userTokens {
+		return ""
+	}
+	return AccessTokenFrom(ctx)
+}
+
+// authHeaders returns the headers a call for ctx is sent with: the API key, authorized
+// as the caller when their token is forwarded
+func (r *supabaseRepository) authHeaders(ctx context.Context) map[string]string {
+	token := r.userToken(ctx)
+	if token == "" {
+		return r.headers
+	}
+
+	headers := make(map[string]string, len(r.headers))
+	for key, value := range r.headers {
+		headers[key] = value
+	}
+	headers["Authorization"] = "Bearer " + token
+	return headers
+}
@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestAuthHeaders(t *testing.T) {
+	repo := &supabaseRepository{headers: map[string]string{"apikey": "api-key", "Authorization": "Bearer api-key"}}
+	ctx := WithAccessToken(context.Background(), "user-jwt")
+
+	if got := repo.authHeaders(ctx)["Authorization"]; got != "Bearer api-key" {
+		t.Errorf("authHeaders() without user tokens = %q, want the API key", got)
+	}
+
+	repo.userTokens = true
+	headers := repo.authHeaders(ctx)
+	if headers["Authorization"] != "Bearer user-jwt" {
+		t.Errorf("authHeaders() Authorization = %q, want the caller's token", headers["Authorization"])
+	}
+	if headers["apikey"] != "api-key" {
+		t.Errorf("authHeaders() apikey = %q, want the API key", headers["apikey"])
+	}
+	if repo.headers["Authorization"] != "Bearer api-key" {
+		t.Error("authHeaders() must not change the repository's own headers")
+	}
+
+	if got := repo.authHeaders(context.Background())["Authorization"]; got != "Bearer api-key" {
+		t.Errorf("authHeaders() without a token = %q, want the API key", got)
+	}
+}
+
+func TestRPCForwardsUserToken(t *testing.T) {
+	repo := newRPCTestRepository(t, func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer user-jwt" {
+			t.Errorf("Authorization = %q, want the caller's token", got)
+		}
+		w.Write([]byte(`1`))
+	})
+	repo.userTokens = true
+
+	if _, err := repo.RPC(WithAccessToken(context.Background(), "user-jwt"), "count_orders", nil); err != nil {
+		t.Fatalf("RPC() error = %v", err)
+	}
+}
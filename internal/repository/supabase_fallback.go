@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/yourusername/supabase-redis-middleware/internal/logger"
+	"go.uber.org/zap"
+)
+
+// fallbackRepository is a SupabaseRepository reading from Postgres while Supabase is
+// unavailable. Writes and RPC calls are only made through Supabase
+type fallbackRepository struct {
+	SupabaseRepository
+	fallback TableReader
+	logger   *zap.Logger
+}
+
+// NewPostgresFallback returns primary, with its reads made from fallback, which reads the
+// same database directly, whenever primary fails because Supabase or PostgREST is
+// unavailable. Reads made as a caller whose JWT is attached to the context are never made
+// from fallback, which would bypass the caller's RLS policies
+// If fallback fails as well, primary's error is returned unless fallback found no row
+func NewPostgresFallback(primary SupabaseRepository, fallback TableReader, logger *zap.Logger) SupabaseRepository {
+	return &fallbackRepository{SupabaseRepository: primary, fallback: fallback, logger: logger}
+}
+
+// Query runs Query on Supabase, falling back to Postgres
+func (r *fallbackRepository) Query(ctx context.Context, table string, filters map[string]interface{}, pagination Pagination, opts ...QueryOption) ([]map[string]interface{}, error) {
+	rows, err := r.SupabaseRepository.Query(ctx, table, filters, pagination, opts...)
+	if !r.shouldFallBack(ctx, err) {
+		return rows, err
+	}
+
+	r.logFallback(ctx, table, err)
+	rows, fallbackErr := r.fallback.Query(ctx, table, filters, pagination, opts...)
+	return rows, r.result(ctx, table, err, fallbackErr)
+}
+
+// QueryWithCount runs QueryWithCount on Supabase, falling back to Postgres
+func (r *fallbackRepository) QueryWithCount(ctx context.Context, table string, filters map[string]interface{}, pagination Pagination, opts ...QueryOption) ([]map[string]interface{}, int64, error) {
+	rows, total, err := r.SupabaseRepository.QueryWithCount(ctx, table, filters, pagination, opts...)
+	if !r.shouldFallBack(ctx, err) {
+		return rows, total, err
+	}
+
+	r.logFallback(ctx, table, err)
+	rows, total, fallbackErr := r.fallback.QueryWithCount(ctx, table, filters, pagination, opts...)
+	return rows, total, r.result(ctx, table, err, fallbackErr)
+}
+
+// GetByID runs GetByID on Supabase, falling back to Postgres
+func (r *fallbackRepository) GetByID(ctx context.Context, table string, id string, opts ...QueryOption) (map[string]interface{}, error) {
+	item, err := r.SupabaseRepository.GetByID(ctx, table, id, opts...)
+	if !r.shouldFallBack(ctx, err) {
+		return item, err
+	}
+
+	r.logFallback(ctx, table, err)
+	item, fallbackErr := r.fallback.GetByID(ctx, table, id, opts...)
+	return item, r.result(ctx, table, err, fallbackErr)
+}
+
+// RPC runs RPC on Supabase only, since a function may write
+func (r *fallbackRepository) RPC(ctx context.Context, fn string, params map[string]interface{}) (json.RawMessage, error) {
+	return r.SupabaseRepository.RPC(ctx, fn, params)
+}
+
+// shouldFallBack reports whether a read that failed with err is made again from Postgres
+func (r *fallbackRepository) shouldFallBack(ctx context.Context, err error) bool {
+	return IsUnavailable(err) && ctx.Err() == nil && AccessTokenFrom(ctx) == ""
+}
+
+// result returns the error of a read Supabase failed with err and Postgres with fallbackErr
+func (r *fallbackRepository) result(ctx context.Context, table string, err, fallbackErr error) error {
+	if fallbackErr == nil || GetStatusCode(fallbackErr) == http.StatusNotFound {
+		return fallbackErr
+	}
+
+	r.log(ctx).Warn("Postgres fallback failed",
+		zap.String("table", table),
+		zap.Error(fallbackErr))
+	return err
+}
+
+// log returns the logger for a call made with ctx, carrying its request's fields
+func (r *fallbackRepository) log(ctx context.Context) *zap.Logger {
+	return logger.FromContext(ctx, r.logger)
+}
+
+func (r *fallbackRepository) logFallback(ctx context.Context, table string, err error) {
+	r.log(ctx).Warn("Supabase unavailable, reading from Postgres",
+		zap.String("table", table),
+		zap.Error(err))
+}
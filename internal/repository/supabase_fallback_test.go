@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// failingRepository is a SupabaseRepository whose reads fail with err
+type failingRepository struct {
+	namedRepository
+	err error
+}
+
+func (r failingRepository) Query(ctx context.Context, table string, filters map[string]interface{}, pagination Pagination, opts ...QueryOption) ([]map[string]interface{}, error) {
+	return nil, r.err
+}
+
+func (r failingRepository) GetByID(ctx context.Context, table string, id string, opts ...QueryOption) (map[string]interface{}, error) {
+	return nil, r.err
+}
+
+func TestPostgresFallbackReadsWhenSupabaseUnavailable(t *testing.T) {
+	unavailable := NewConnectionError(ErrCircuitOpen)
+	repo := NewPostgresFallback(failingRepository{namedRepository{"supabase"}, unavailable}, namedRepository{"postgres"}, zap.NewNop())
+
+	rows, err := repo.Query(context.Background(), "products", nil, Pagination{Limit: 10})
+	if err != nil || len(rows) != 1 || rows[0]["project"] != "postgres" {
+		t.Errorf("Query() = %v, %v, want the Postgres rows", rows, err)
+	}
+
+	// Callers whose token is forwarded keep Supabase's error rather than bypass RLS
+	ctx := WithAccessToken(context.Background(), "jwt")
+	if _, err := repo.GetByID(ctx, "products", "1"); err != unavailable {
+		t.Errorf("GetByID() with a forwarded token error = %v, want Supabase's", err)
+	}
+
+	// Writes aren't made from Postgres
+	if item, _ := repo.Insert(context.Background(), "products", map[string]interface{}{"name": "Milk"}); item["project"] != "supabase" {
+		t.Errorf("Insert() = %v, want it made through Supabase", item)
+	}
+}
+
+func TestPostgresFallbackOnlyWhenUnavailable(t *testing.T) {
+	notFound := NewNotFoundError("products", "1")
+	repo := NewPostgresFallback(failingRepository{namedRepository{"supabase"}, notFound}, namedRepository{"postgres"}, zap.NewNop())
+
+	if _, err := repo.GetByID(context.Background(), "products", "1"); err != notFound {
+		t.Errorf("GetByID() error = %v, want Supabase's not-found error", err)
+	}
+}
+
+func TestPostgresFallbackFailing(t *testing.T) {
+	unavailable := NewConnectionError(errors.New("connection refused"))
+	tests := []struct {
+		name        string
+		fallbackErr error
+		want        int
+	}{
+		{"fallback finds no row", NewNotFoundError("products", "1"), 404},
+		{"fallback fails too", NewQueryError(errors.New("relation does not exist")), 503},
+		{"fallback can't express the query", NewValidationError("column \"brand(name)\" isn't a plain column name"), 503},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := NewPostgresFallback(failingRepository{err: unavailable}, failingRepository{err: tt.fallbackErr}, zap.NewNop())
+			if _, err := repo.GetByID(context.Background(), "products", "1"); GetStatusCode(err) != tt.want {
+				t.Errorf("GetByID() error = %v, want status %d", err, tt.want)
+			}
+		})
+	}
+}
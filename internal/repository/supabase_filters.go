@@ -0,0 +1,325 @@
+package repository
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/supabase-community/postgrest-go"
+)
+
+// Filter operators accepted by Query. A filter value is either a plain value, matched
+// for equality, or a map of operators to values, e.g. {"price": {"gte": 10, "lt": 100}}
+const (
+	FilterEq    = "eq"
+	FilterNeq   = "neq"
+	FilterGt    = "gt"
+	FilterGte   = "gte"
+	FilterLt    = "lt"
+	FilterLte   = "lte"
+	FilterIn    = "in"    // Takes a list of values
+	FilterIlike = "ilike" // Case-insensitive pattern; * or % match any characters
+	FilterIs    = "is"    // Takes null, true or false
+)
+
+// Filter group keys. Under one of them, a filters map takes a list of filter maps, which
+// are combined with or / and. Each listed map must match as a whole, so
+// {"or": [{"category": "dairy"}, {"category": "bakery", "price": {"lt": 5}}]} matches dairy,
+// or bakery under 5. A map holds one group of each kind; nest groups under and for more
+const (
+	FilterOr  = "or"
+	FilterAnd = "and"
+)
+
+// filterOperators is the set of operators ParseFilters accepts
+var filterOperators = map[string]bool{
+	FilterEq: true, FilterNeq: true, FilterGt: true, FilterGte: true, FilterLt: true,
+	FilterLte: true, FilterIn: true, FilterIlike: true, FilterIs: true,
+}
+
+// Filter is one condition on a column, with its value formatted for PostgREST
+// Values holds the list of an in filter, whose Value is the formatted list
+type Filter struct {
+	Column   string
+	Operator string
+	Value    string
+	Values   []string
+}
+
+// reservedChars are the characters PostgREST needs quoted inside lists and logic trees
+var reservedChars = regexp.MustCompile(`[,()"]`)
+
+// FilterGroup is a compound condition matching any (FilterOr) or all (FilterAnd) of its
+// conditions and nested groups
+type FilterGroup struct {
+	Operator   string
+	Conditions []Filter
+	Groups     []FilterGroup
+}
+
+// ParseFilters validates Query filters and returns them sorted by column and operator
+// Filter groups are parsed by ParseFilterGroups; like PostgREST, or and and aren't column names here
+func ParseFilters(filters map[string]interface{}) ([]Filter, error) {
+	parsed := make([]Filter, 0, len(filters))
+	for column, value := range filters {
+		if column == "" {
+			return nil, NewValidationError("filter column must not be empty")
+		}
+		if column == FilterOr || column == FilterAnd {
+			continue
+		}
+
+		ops, ok := value.(map[string]interface{})
+		if !ok {
+			ops = map[string]interface{}{FilterEq: value}
+		}
+		if len(ops) == 0 {
+			return nil, NewValidationError(fmt.Sprintf("filter on %s has no operators", column))
+		}
+
+		for op, operand := range ops {
+			filter, err := parseFilter(column, op, operand)
+			if err != nil {
+				return nil, err
+			}
+			parsed = append(parsed, filter)
+		}
+	}
+
+	sort.Slice(parsed, func(i, j int) bool {
+		if parsed[i].Column != parsed[j].Column {
+			return parsed[i].Column < parsed[j].Column
+		}
+		return parsed[i].Operator < parsed[j].Operator
+	})
+	return parsed, nil
+}
+
+// ParseFilterGroups validates the or and and groups of Query filters, nested ones included,
+// and returns them sorted by operator
+func ParseFilterGroups(filters map[string]interface{}) ([]FilterGroup, error) {
+	var groups []FilterGroup
+	for _, op := range []string{FilterAnd, FilterOr} {
+		value, ok := filters[op]
+		if !ok {
+			continue
+		}
+
+		var members []interface{}
+		switch list := value.(type) {
+		case []interface{}:
+			members = list
+		case []map[string]interface{}:
+			for _, m := range list {
+				members = append(members, m)
+			}
+		default:
+			return nil, NewValidationError(fmt.Sprintf("%s filter must be a list of filters", op))
+		}
+		if len(members) < 2 {
+			return nil, NewValidationError(fmt.Sprintf("%s filter must list at least two filters", op))
+		}
+
+		group := FilterGroup{Operator: op}
+		for _, member := range members {
+			m, ok := member.(map[string]interface{})
+			if !ok || len(m) == 0 {
+				return nil, NewValidationError(fmt.Sprintf("%s filter must list non-empty filter maps", op))
+			}
+			conditions, err := ParseFilters(m)
+			if err != nil {
+				return nil, err
+			}
+			nested, err := ParseFilterGroups(m)
+			if err != nil {
+				return nil, err
+			}
+
+			// A map with several conditions must match as a whole
+			switch {
+			case len(conditions) == 1 && len(nested) == 0:
+				group.Conditions = append(group.Conditions, conditions[0])
+			case len(conditions) == 0 && len(nested) == 1:
+				group.Groups = append(group.Groups, nested[0])
+			default:
+				group.Groups = append(group.Groups, FilterGroup{Operator: FilterAnd, Conditions: conditions, Groups: nested})
+			}
+		}
+		groups = append(groups, group)
+	}
+	return groups, nil
+}
+
+// parseFilter validates one operator and its operand
+func parseFilter(column, op string, operand interface{}) (Filter, error) {
+	filter := Filter{Column: column, Operator: op}
+
+	switch op {
+	case FilterIn:
+		var values []interface{}
+		switch list := operand.(type) {
+		case []interface{}:
+			values = list
+		case []string:
+			for _, v := range list {
+				values = append(values, v)
+			}
+		default:
+			return filter, NewValidationError(fmt.Sprintf("in filter on %s must be a list", column))
+		}
+		if len(values) == 0 {
+			return filter, NewValidationError(fmt.Sprintf("in filter on %s must not be empty", column))
+		}
+		for _, v := range values {
+			s, ok := scalarString(v)
+			if !ok {
+				return filter, NewValidationError(fmt.Sprintf("in filter on %s must list plain values", column))
+			}
+			filter.Values = append(filter.Values, s)
+		}
+		filter.Value = formatFilterList(filter.Values)
+
+	case FilterIs:
+		switch v := operand.(type) {
+		case nil:
+			filter.Value = "null"
+		case bool:
+			filter.Value = fmt.Sprintf("%t", v)
+		case string:
+			filter.Value = strings.ToLower(v)
+		}
+		if filter.Value != "null" && filter.Value != "true" && filter.Value != "false" {
+			return filter, NewValidationError(fmt.Sprintf("is filter on %s must be null, true or false", column))
+		}
+
+	default:
+		if !filterOperators[op] {
+			return filter, NewValidationError(fmt.Sprintf("unknown filter operator %q on %s", op, column))
+		}
+		if operand == nil {
+			return filter, NewValidationError(fmt.Sprintf("%s filter on %s needs a value; use is to match null", op, column))
+		}
+		s, ok := scalarString(operand)
+		if !ok {
+			return filter, NewValidationError(fmt.Sprintf("%s filter on %s must be a plain value", op, column))
+		}
+		filter.Value = s
+	}
+	return filter, nil
+}
+
+// scalarString formats a string, number or bool operand
+func scalarString(v interface{}) (string, bool) {
+	switch v.(type) {
+	case nil, map[string]interface{}, []interface{}, []string:
+		return "", false
+	}
+	return fmt.Sprintf("%v", v), true
+}
+
+// formatFilterList formats in values as a PostgREST list, quoting values with reserved characters
+func formatFilterList(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = quoteFilterValue(v)
+	}
+	return "(" + strings.Join(quoted, ",") + ")"
+}
+
+// quoteFilterValue double-quotes a value containing characters PostgREST reserves
+func quoteFilterValue(v string) string {
+	if !reservedChars.MatchString(v) {
+		return v
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(v) + `"`
+}
+
+// CacheParam returns the cache key parameter for the filter. Equality filters keep the bare
+// column as their key, so their keys are the same as before operators were supported
+func (f Filter) CacheParam() (string, string) {
+	if f.Operator == FilterEq {
+		return f.Column, f.Value
+	}
+	return f.Column + "." + f.Operator, f.Value
+}
+
+// String formats the filter as a PostgREST logic tree condition, e.g. price.lt.100
+func (f Filter) String() string {
+	if f.Operator == FilterIn {
+		return f.Column + "." + f.Operator + "." + f.Value
+	}
+	return f.Column + "." + f.Operator + "." + quoteFilterValue(f.Value)
+}
+
+// members returns the group's conditions and nested groups as logic tree conditions
+func (g FilterGroup) members() []string {
+	members := make([]string, 0, len(g.Conditions)+len(g.Groups))
+	for _, f := range g.Conditions {
+		members = append(members, f.String())
+	}
+	for _, nested := range g.Groups {
+		members = append(members, nested.String())
+	}
+	return members
+}
+
+// String formats the group as a PostgREST logic tree, e.g. or(category.eq.dairy,category.eq.bakery)
+func (g FilterGroup) String() string {
+	return g.Operator + "(" + strings.Join(g.members(), ",") + ")"
+}
+
+// CacheParam returns the cache key parameter for the group
+func (g FilterGroup) CacheParam() (string, string) {
+	return g.Operator, g.String()
+}
+
+// applyFilters adds filters and groups to a query. postgrest-go keeps one condition per
+// column and one and group, so the conditions on a column with several, and the members
+// of an and group, all go into that single and group; an or group maps onto Or
+func applyFilters(query *postgrest.FilterBuilder, filters []Filter, groups []FilterGroup) *postgrest.FilterBuilder {
+	perColumn := make(map[string]int, len(filters))
+	for _, f := range filters {
+		perColumn[f.Column]++
+	}
+
+	var grouped []string
+	for _, f := range filters {
+		if perColumn[f.Column] > 1 {
+			grouped = append(grouped, f.String())
+			continue
+		}
+
+		switch f.Operator {
+		case FilterEq:
+			query = query.Eq(f.Column, f.Value)
+		case FilterNeq:
+			query = query.Neq(f.Column, f.Value)
+		case FilterGt:
+			query = query.Gt(f.Column, f.Value)
+		case FilterGte:
+			query = query.Gte(f.Column, f.Value)
+		case FilterLt:
+			query = query.Lt(f.Column, f.Value)
+		case FilterLte:
+			query = query.Lte(f.Column, f.Value)
+		case FilterIn:
+			query = query.In(f.Column, f.Values)
+		case FilterIlike:
+			query = query.Ilike(f.Column, f.Value)
+		case FilterIs:
+			query = query.Is(f.Column, f.Value)
+		}
+	}
+	for _, g := range groups {
+		if g.Operator == FilterOr {
+			query = query.Or(strings.Join(g.members(), ","), "")
+			continue
+		}
+		grouped = append(grouped, g.members()...)
+	}
+	if len(grouped) > 0 {
+		query = query.And(strings.Join(grouped, ","), "")
+	}
+	return query
+}
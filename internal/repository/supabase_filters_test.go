@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/supabase-community/postgrest-go"
+)
+
+func TestParseFilters(t *testing.T) {
+	filters, err := ParseFilters(map[string]interface{}{
+		"category": "electronics",
+		"price":    map[string]interface{}{"lt": 100.0, "gte": 10},
+		"brand":    map[string]interface{}{"in": []interface{}{"Apple", "A,B"}},
+		"name":     map[string]interface{}{"ilike": "*phone*"},
+		"deleted":  map[string]interface{}{"is": nil},
+		"featured": map[string]interface{}{"is": true},
+		"status":   map[string]interface{}{"neq": "archived"},
+	})
+	if err != nil {
+		t.Fatalf("ParseFilters() error = %v", err)
+	}
+
+	want := []Filter{
+		{Column: "brand", Operator: FilterIn, Value: `(Apple,"A,B")`, Values: []string{"Apple", "A,B"}},
+		{Column: "category", Operator: FilterEq, Value: "electronics"},
+		{Column: "deleted", Operator: FilterIs, Value: "null"},
+		{Column: "featured", Operator: FilterIs, Value: "true"},
+		{Column: "name", Operator: FilterIlike, Value: "*phone*"},
+		{Column: "price", Operator: FilterGte, Value: "10"},
+		{Column: "price", Operator: FilterLt, Value: "100"},
+		{Column: "status", Operator: FilterNeq, Value: "archived"},
+	}
+	if !reflect.DeepEqual(filters, want) {
+		t.Errorf("ParseFilters() = %+v, want %+v", filters, want)
+	}
+}
+
+func TestParseFiltersInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		filters map[string]interface{}
+	}{
+		{"unknown operator", map[string]interface{}{"price": map[string]interface{}{"between": 1}}},
+		{"no operators", map[string]interface{}{"price": map[string]interface{}{}}},
+		{"in without a list", map[string]interface{}{"brand": map[string]interface{}{"in": "Apple"}}},
+		{"empty in", map[string]interface{}{"brand": map[string]interface{}{"in": []interface{}{}}}},
+		{"nested in value", map[string]interface{}{"brand": map[string]interface{}{"in": []interface{}{[]interface{}{"a"}}}}},
+		{"is with a value", map[string]interface{}{"deleted": map[string]interface{}{"is": "yes"}}},
+		{"null comparison", map[string]interface{}{"price": map[string]interface{}{"lt": nil}}},
+		{"list comparison", map[string]interface{}{"price": map[string]interface{}{"gt": []interface{}{1}}}},
+		{"empty column", map[string]interface{}{"": "x"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseFilters(tt.filters)
+			if GetStatusCode(err) != 400 {
+				t.Errorf("ParseFilters() error = %v, want a validation error", err)
+			}
+		})
+	}
+}
+
+func TestFilterString(t *testing.T) {
+	tests := []struct {
+		filter Filter
+		want   string
+	}{
+		{Filter{Column: "price", Operator: FilterLt, Value: "100"}, "price.lt.100"},
+		{Filter{Column: "name", Operator: FilterEq, Value: `a,"b"`}, `name.eq."a,\"b\""`},
+		{Filter{Column: "brand", Operator: FilterIn, Value: `(Apple,"A,B")`}, `brand.in.(Apple,"A,B")`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := tt.filter.String(); got != tt.want {
+				t.Errorf("String() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFilterGroups(t *testing.T) {
+	filters := map[string]interface{}{
+		"status": "active",
+		"or": []interface{}{
+			map[string]interface{}{"category": "dairy"},
+			map[string]interface{}{"category": "bakery", "price": map[string]interface{}{"lt": 5}},
+		},
+		"and": []interface{}{
+			map[string]interface{}{"or": []interface{}{
+				map[string]interface{}{"brand": "Amul"},
+				map[string]interface{}{"brand": map[string]interface{}{"in": []interface{}{"A,B", "C"}}},
+			}},
+			map[string]interface{}{"stock": map[string]interface{}{"gt": 0}},
+		},
+	}
+
+	groups, err := ParseFilterGroups(filters)
+	if err != nil {
+		t.Fatalf("ParseFilterGroups() error = %v", err)
+	}
+
+	want := []string{
+		`and(stock.gt.0,or(brand.eq.Amul,brand.in.("A,B",C)))`,
+		`or(category.eq.dairy,and(category.eq.bakery,price.lt.5))`,
+	}
+	if len(groups) != len(want) {
+		t.Fatalf("ParseFilterGroups() = %d groups, want %d", len(groups), len(want))
+	}
+	for i, group := range groups {
+		if got := group.String(); got != want[i] {
+			t.Errorf("group %d = %s, want %s", i, got, want[i])
+		}
+	}
+
+	// Group keys aren't columns
+	conditions, err := ParseFilters(filters)
+	if err != nil || len(conditions) != 1 || conditions[0].Column != "status" {
+		t.Errorf("ParseFilters() = %+v, %v, want only the status condition", conditions, err)
+	}
+}
+
+func TestParseFilterGroupsInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		filters map[string]interface{}
+	}{
+		{"not a list", map[string]interface{}{"or": "category.eq.dairy"}},
+		{"single member", map[string]interface{}{"or": []interface{}{map[string]interface{}{"a": 1}}}},
+		{"empty member", map[string]interface{}{"or": []interface{}{map[string]interface{}{"a": 1}, map[string]interface{}{}}}},
+		{"member not a map", map[string]interface{}{"and": []interface{}{map[string]interface{}{"a": 1}, "b.eq.2"}}},
+		{"invalid condition", map[string]interface{}{"or": []interface{}{
+			map[string]interface{}{"a": 1},
+			map[string]interface{}{"b": map[string]interface{}{"between": 2}},
+		}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseFilterGroups(tt.filters)
+			if GetStatusCode(err) != 400 {
+				t.Errorf("ParseFilterGroups() error = %v, want a validation error", err)
+			}
+		})
+	}
+}
+
+func TestApplyFiltersGroups(t *testing.T) {
+	var query url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	filters := map[string]interface{}{
+		"price": map[string]interface{}{"gte": 1, "lt": 10},
+		"or": []interface{}{
+			map[string]interface{}{"category": "dairy"},
+			map[string]interface{}{"category": "bakery"},
+		},
+		"and": []interface{}{
+			map[string]interface{}{"brand": "Amul"},
+			map[string]interface{}{"stock": map[string]interface{}{"gt": 0}},
+		},
+	}
+	conditions, _ := ParseFilters(filters)
+	groups, _ := ParseFilterGroups(filters)
+
+	client := postgrest.NewClient(server.URL, "", nil)
+	var results []map[string]interface{}
+	if _, err := applyFilters(client.From("products").Select("*", "", false), conditions, groups).ExecuteTo(&results); err != nil {
+		t.Fatalf("ExecuteTo() error = %v", err)
+	}
+
+	if got := query.Get("or"); got != "(category.eq.dairy,category.eq.bakery)" {
+		t.Errorf("or = %s, want the or group", got)
+	}
+	// The and group shares the single and parameter with the conditions on price
+	if got := query.Get("and"); got != "(price.gte.1,price.lt.10,brand.eq.Amul,stock.gt.0)" {
+		t.Errorf("and = %s, want the price conditions and the and group", got)
+	}
+}
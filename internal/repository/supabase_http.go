@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/supabase-community/postgrest-go"
+	"github.com/yourusername/supabase-redis-middleware/internal/logger"
+	"github.com/yourusername/supabase-redis-middleware/internal/tenant"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.uber.org/zap"
+)
+
+type requestIDKey struct{}
+
+// WithRequestID attaches the ID of the request being served to ctx
+// Supabase calls made for it send the ID as X-Request-ID, so they can be traced in its logs
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFrom returns the request ID attached to ctx, or "" if there is none
+func RequestIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// WithRequestTimeout bounds each HTTP call to Supabase, every retry included, to timeout
+// A call also ends when its context does
+func WithRequestTimeout(timeout time.Duration) SupabaseOption {
+	return func(r *supabaseRepository) {
+		r.requestTimeout = timeout
+	}
+}
+
+// WithUserAgent sends userAgent as the User-Agent of every call to Supabase
+func WithUserAgent(userAgent string) SupabaseOption {
+	return func(r *supabaseRepository) {
+		r.userAgent = userAgent
+	}
+}
+
+// WithTracing records a client span for every HTTP call to Supabase, sending the trace
+// context with it
+func WithTracing() SupabaseOption {
+	return func(r *supabaseRepository) {
+		r.httpClient = tracedHTTPClient()
+	}
+}
+
+// tracedHTTPClient returns an HTTP client recording a span for each call it makes
+func tracedHTTPClient() *http.Client {
+	return &http.Client{Transport: otelhttp.NewTransport(http.DefaultTransport)}
+}
+
+// log returns the logger for a call made with ctx, carrying its request's fields
+func (r *supabaseRepository) log(ctx context.Context) *zap.Logger {
+	return logger.FromContext(ctx, r.logger)
+}
+
+// attemptContext returns the context for a single HTTP call made for ctx
+func (r *supabaseRepository) attemptContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if r.requestTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, r.requestTimeout)
+}
+
+// from starts a query on table for ctx
+// postgrest-go builds its requests without a context, so each query gets a client whose
+// transport binds them to ctx and authorizes them as the caller when their token is forwarded
+func (r *supabaseRepository) from(ctx context.Context, table string) *postgrest.QueryBuilder {
+	client := postgrest.NewClient(r.restURL, r.schema, r.authHeaders(ctx))
+	transport := &contextTransport{ctx: ctx}
+	if r.httpClient != nil {
+		transport.base = r.httpClient.Transport
+	}
+	client.Transport.Parent = transport
+	return client.From(table)
+}
+
+// setContextHeaders sends the request ID and tenant attached to ctx with req. Functions
+// called through RPC can read the tenant from PostgREST's request.headers setting
+func setContextHeaders(ctx context.Context, req *http.Request) {
+	if id := RequestIDFrom(ctx); id != "" {
+		req.Header.Set("X-Request-ID", id)
+	}
+	if id := tenant.FromContext(ctx); id != "" {
+		req.Header.Set("X-Tenant-ID", id)
+	}
+}
+
+// contextTransport sends requests under ctx, so they end with it
+type contextTransport struct {
+	ctx  context.Context
+	base http.RoundTripper
+}
+
+func (t *contextTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.WithContext(t.ctx)
+	setContextHeaders(t.ctx, req)
+
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(req)
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newHTTPTestRepository returns a repository whose calls go to handler
+func newHTTPTestRepository(t *testing.T, handler http.HandlerFunc, opts ...SupabaseOption) SupabaseRepository {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	repo, err := NewSupabaseRepository(server.URL, "test-key", opts...)
+	if err != nil {
+		t.Fatalf("NewSupabaseRepository() error = %v", err)
+	}
+	return repo
+}
+
+func TestQueryRequestTimeout(t *testing.T) {
+	released := make(chan struct{})
+	repo := newHTTPTestRepository(t, func(w http.ResponseWriter, r *http.Request) {
+		// The call must be abandoned, not left waiting on the response
+		<-r.Context().Done()
+		close(released)
+	}, WithRequestTimeout(50*time.Millisecond))
+
+	start := time.Now()
+	_, err := repo.Query(context.Background(), "products", nil, Pagination{Limit: 10})
+	if GetStatusCode(err) != 504 {
+		t.Errorf("Query() error = %v, want a timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Query() took %s, want it bounded by the request timeout", elapsed)
+	}
+
+	select {
+	case <-released:
+	case <-time.After(time.Second):
+		t.Error("the HTTP call outlived the request timeout")
+	}
+}
+
+func TestGetByIDEndsWithContext(t *testing.T) {
+	repo := newHTTPTestRepository(t, func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := repo.GetByID(ctx, "products", "1"); GetStatusCode(err) != 504 {
+		t.Errorf("GetByID() error = %v, want a timeout once the context expires", err)
+	}
+}
+
+func TestSupabaseClientHeaders(t *testing.T) {
+	var headers []http.Header
+	repo := newHTTPTestRepository(t, func(w http.ResponseWriter, r *http.Request) {
+		headers = append(headers, r.Header.Clone())
+		w.Write([]byte(`[]`))
+	}, WithUserAgent("catalog-api/1.0"))
+
+	ctx := WithRequestID(context.Background(), "req-123")
+	if _, err := repo.Query(ctx, "products", nil, Pagination{Limit: 10}); err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if _, err := repo.RPC(ctx, "search_products", nil); err != nil {
+		t.Fatalf("RPC() error = %v", err)
+	}
+	if _, err := repo.Query(context.Background(), "products", nil, Pagination{Limit: 10}); err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+
+	for i, h := range headers[:2] {
+		if h.Get("User-Agent") != "catalog-api/1.0" || h.Get("X-Request-ID") != "req-123" {
+			t.Errorf("call %d headers = %v, want the user agent and request ID", i, h)
+		}
+		if h.Get("apikey") != "test-key" {
+			t.Errorf("call %d apikey = %q, want the API key", i, h.Get("apikey"))
+		}
+	}
+	if got := headers[2].Get("X-Request-ID"); got != "" {
+		t.Errorf("X-Request-ID = %q for a call without a request ID, want none", got)
+	}
+}
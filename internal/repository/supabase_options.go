@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/supabase-community/postgrest-go"
+)
+
+// Sort directions for OrderBy
+const (
+	SortAsc  = "asc"
+	SortDesc = "desc"
+)
+
+// Count modes for QueryWithCount
+const (
+	CountExact     = "exact"     // Counts every matching record
+	CountPlanned   = "planned"   // Uses the Postgres planner's estimate, cheap on large tables
+	CountEstimated = "estimated" // Exact up to PostgREST's max rows, planned beyond
+)
+
+// OrderBy sorts Query results by a column, with nulls last
+// Direction is SortAsc or SortDesc; empty means ascending
+type OrderBy struct {
+	Column    string
+	Direction string
+}
+
+// QueryOptions controls what Query and GetByID return
+// Columns limits the fields of each record; empty selects all of them.
+// OrderBy sorts Query results by each key in turn; without it their order is arbitrary.
+// Count is how QueryWithCount counts, CountExact by default
+type QueryOptions struct {
+	Columns []string
+	OrderBy []OrderBy
+	Count   string
+}
+
+// QueryOption configures optional Query and GetByID behaviour
+type QueryOption func(*QueryOptions)
+
+// WithColumns selects only columns, cutting egress and cache size for wide tables
+func WithColumns(columns ...string) QueryOption {
+	return func(o *QueryOptions) {
+		o.Columns = append(o.Columns, columns...)
+	}
+}
+
+// WithOrderBy sorts Query results by keys, the first taking precedence
+func WithOrderBy(keys ...OrderBy) QueryOption {
+	return func(o *QueryOptions) {
+		o.OrderBy = append(o.OrderBy, keys...)
+	}
+}
+
+// WithCount sets how QueryWithCount counts matching records: CountExact, CountPlanned or CountEstimated
+func WithCount(mode string) QueryOption {
+	return func(o *QueryOptions) {
+		o.Count = mode
+	}
+}
+
+// NewQueryOptions applies opts and validates the result
+func NewQueryOptions(opts ...QueryOption) (QueryOptions, error) {
+	var options QueryOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	for i, column := range options.Columns {
+		column = strings.TrimSpace(column)
+		if column == "" || strings.Contains(column, ",") {
+			return options, NewValidationError("columns must be non-empty names without commas")
+		}
+		options.Columns[i] = column
+	}
+
+	for i, key := range options.OrderBy {
+		key.Column = strings.TrimSpace(key.Column)
+		if key.Column == "" || strings.Contains(key.Column, ",") {
+			return options, NewValidationError("order columns must be non-empty names without commas")
+		}
+		key.Direction = strings.ToLower(key.Direction)
+		if key.Direction == "" {
+			key.Direction = SortAsc
+		}
+		if key.Direction != SortAsc && key.Direction != SortDesc {
+			return options, NewValidationError(fmt.Sprintf("order direction for %s must be asc or desc", key.Column))
+		}
+		options.OrderBy[i] = key
+	}
+
+	switch options.Count {
+	case "":
+		options.Count = CountExact
+	case CountExact, CountPlanned, CountEstimated:
+	default:
+		return options, NewValidationError("count must be exact, planned or estimated")
+	}
+	return options, nil
+}
+
+// selectClause returns the PostgREST select list for the options
+func (o QueryOptions) selectClause() string {
+	if len(o.Columns) == 0 {
+		return "*"
+	}
+	return strings.Join(o.Columns, ",")
+}
+
+// applyOrder adds the options' sort keys to a query
+func (o QueryOptions) applyOrder(query *postgrest.FilterBuilder) *postgrest.FilterBuilder {
+	for _, key := range o.OrderBy {
+		query = query.Order(key.Column, &postgrest.OrderOpts{Ascending: key.Direction == SortAsc})
+	}
+	return query
+}
+
+// CacheParams adds the options to a cache key's parameters
+// Columns are sorted, since their order doesn't change the records returned; sort keys
+// keep theirs, since it does
+func (o QueryOptions) CacheParams(params map[string]string) {
+	if len(o.Columns) > 0 {
+		columns := append([]string(nil), o.Columns...)
+		sort.Strings(columns)
+		params["select"] = strings.Join(columns, ",")
+	}
+	if len(o.OrderBy) > 0 {
+		keys := make([]string, len(o.OrderBy))
+		for i, key := range o.OrderBy {
+			keys[i] = key.Column + "." + key.Direction
+		}
+		params["order"] = strings.Join(keys, ",")
+	}
+}
@@ -0,0 +1,89 @@
+package repository
+
+import "testing"
+
+func TestNewQueryOptions(t *testing.T) {
+	options, err := NewQueryOptions(WithColumns("name", " id "), WithColumns("price"))
+	if err != nil {
+		t.Fatalf("NewQueryOptions() error = %v", err)
+	}
+	if got := options.selectClause(); got != "name,id,price" {
+		t.Errorf("selectClause() = %s, want name,id,price", got)
+	}
+
+	params := map[string]string{"id": "123"}
+	options.CacheParams(params)
+	if params["select"] != "id,name,price" {
+		t.Errorf("CacheParams() select = %q, want id,name,price", params["select"])
+	}
+	if params["id"] != "123" {
+		t.Errorf("CacheParams() should keep existing params, got %v", params)
+	}
+}
+
+func TestNewQueryOptionsDefaults(t *testing.T) {
+	options, err := NewQueryOptions()
+	if err != nil {
+		t.Fatalf("NewQueryOptions() error = %v", err)
+	}
+	if got := options.selectClause(); got != "*" {
+		t.Errorf("selectClause() = %s, want *", got)
+	}
+
+	params := map[string]string{}
+	options.CacheParams(params)
+	if len(params) != 0 {
+		t.Errorf("CacheParams() without columns should add nothing, got %v", params)
+	}
+}
+
+func TestNewQueryOptionsInvalidColumns(t *testing.T) {
+	for _, columns := range [][]string{{""}, {" "}, {"id,name"}} {
+		if _, err := NewQueryOptions(WithColumns(columns...)); GetStatusCode(err) != 400 {
+			t.Errorf("NewQueryOptions(%q) error = %v, want a validation error", columns, err)
+		}
+	}
+}
+
+func TestNewQueryOptionsOrderBy(t *testing.T) {
+	options, err := NewQueryOptions(WithOrderBy(
+		OrderBy{Column: "price", Direction: "DESC"},
+		OrderBy{Column: "name"},
+	))
+	if err != nil {
+		t.Fatalf("NewQueryOptions() error = %v", err)
+	}
+
+	want := []OrderBy{{Column: "price", Direction: SortDesc}, {Column: "name", Direction: SortAsc}}
+	if len(options.OrderBy) != len(want) || options.OrderBy[0] != want[0] || options.OrderBy[1] != want[1] {
+		t.Errorf("OrderBy = %+v, want %+v", options.OrderBy, want)
+	}
+
+	// Sort keys keep their order in the cache key
+	params := map[string]string{}
+	options.CacheParams(params)
+	if params["order"] != "price.desc,name.asc" {
+		t.Errorf("CacheParams() order = %q, want price.desc,name.asc", params["order"])
+	}
+}
+
+func TestNewQueryOptionsInvalidOrderBy(t *testing.T) {
+	for _, key := range []OrderBy{{Column: ""}, {Column: "a,b"}, {Column: "price", Direction: "up"}} {
+		if _, err := NewQueryOptions(WithOrderBy(key)); GetStatusCode(err) != 400 {
+			t.Errorf("NewQueryOptions(%+v) error = %v, want a validation error", key, err)
+		}
+	}
+}
+
+func TestNewQueryOptionsCount(t *testing.T) {
+	options, err := NewQueryOptions()
+	if err != nil || options.Count != CountExact {
+		t.Errorf("NewQueryOptions() count = %q, %v, want exact", options.Count, err)
+	}
+	if options, _ := NewQueryOptions(WithCount(CountPlanned)); options.Count != CountPlanned {
+		t.Errorf("NewQueryOptions(WithCount(planned)) count = %q, want planned", options.Count)
+	}
+	if _, err := NewQueryOptions(WithCount("approximate")); GetStatusCode(err) != 400 {
+		t.Errorf("NewQueryOptions(WithCount(approximate)) error = %v, want a validation error", err)
+	}
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+)
+
+type domainKey struct{}
+
+// WithDomain attaches the domain a request belongs to (movies, pharmacy) to ctx
+// A SupabaseRegistry sends the request's queries to that domain's project
+func WithDomain(ctx context.Context, domain string) context.Context {
+	return context.WithValue(ctx, domainKey{}, domain)
+}
+
+// DomainFrom returns the domain attached to ctx, or "" if there is none
+func DomainFrom(ctx context.Context) string {
+	domain, _ := ctx.Value(domainKey{}).(string)
+	return domain
+}
+
+// WithSchema queries tables and functions in schema instead of public
+// The schema must be exposed by the project's PostgREST configuration
+func WithSchema(schema string) SupabaseOption {
+	return func(r *supabaseRepository) {
+		r.schema = schema
+	}
+}
+
+// SupabaseRegistry is a SupabaseRepository that sends each call to the repository
+// registered for the domain attached to its context, resolved per call, falling back to
+// the default one for requests without a domain or whose domain has none of its own
+type SupabaseRegistry struct {
+	fallback SupabaseRepository
+	domains  map[string]SupabaseRepository
+}
+
+// NewSupabaseRegistry creates a registry of the repositories for domains, with fallback
+// serving every other domain
+func NewSupabaseRegistry(fallback SupabaseRepository, domains map[string]SupabaseRepository) *SupabaseRegistry {
+	if domains == nil {
+		domains = map[string]SupabaseRepository{}
+	}
+	return &SupabaseRegistry{fallback: fallback, domains: domains}
+}
+
+// For returns the repository for domain
+func (r *SupabaseRegistry) For(domain string) SupabaseRepository {
+	if repo, ok := r.domains[domain]; ok {
+		return repo
+	}
+	return r.fallback
+}
+
+// Query runs Query on the repository for ctx's domain
+func (r *SupabaseRegistry) Query(ctx context.Context, table string, filters map[string]interface{}, pagination Pagination, opts ...QueryOption) ([]map[string]interface{}, error) {
+	return r.For(DomainFrom(ctx)).Query(ctx, table, filters, pagination, opts...)
+}
+
+// QueryWithCount runs QueryWithCount on the repository for ctx's domain
+func (r *SupabaseRegistry) QueryWithCount(ctx context.Context, table string, filters map[string]interface{}, pagination Pagination, opts ...QueryOption) ([]map[string]interface{}, int64, error) {
+	return r.For(DomainFrom(ctx)).QueryWithCount(ctx, table, filters, pagination, opts...)
+}
+
+// GetByID runs GetByID on the repository for ctx's domain
+func (r *SupabaseRegistry) GetByID(ctx context.Context, table string, id string, opts ...QueryOption) (map[string]interface{}, error) {
+	return r.For(DomainFrom(ctx)).GetByID(ctx, table, id, opts...)
+}
+
+// RPC runs RPC on the repository for ctx's domain
+func (r *SupabaseRegistry) RPC(ctx context.Context, fn string, params map[string]interface{}) (json.RawMessage, error) {
+	return r.For(DomainFrom(ctx)).RPC(ctx, fn, params)
+}
+
+// Insert runs Insert on the repository for ctx's domain
+func (r *SupabaseRegistry) Insert(ctx context.Context, table string, record map[string]interface{}) (map[string]interface{}, error) {
+	return r.For(DomainFrom(ctx)).Insert(ctx, table, record)
+}
+
+// Update runs Update on the repository for ctx's domain
+func (r *SupabaseRegistry) Update(ctx context.Context, table string, id string, changes map[string]interface{}) (map[string]interface{}, error) {
+	return r.For(DomainFrom(ctx)).Update(ctx, table, id, changes)
+}
+
+// Delete runs Delete on the repository for ctx's domain
+func (r *SupabaseRegistry) Delete(ctx context.Context, table string, id string) error {
+	return r.For(DomainFrom(ctx)).Delete(ctx, table, id)
+}
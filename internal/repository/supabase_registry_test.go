@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+// namedRepository is a SupabaseRepository whose results name the project it stands for
+type namedRepository struct {
+	name string
+}
+
+func (r namedRepository) Query(ctx context.Context, table string, filters map[string]interface{}, pagination Pagination, opts ...QueryOption) ([]map[string]interface{}, error) {
+	return []map[string]interface{}{{"project": r.name}}, nil
+}
+
+func (r namedRepository) QueryWithCount(ctx context.Context, table string, filters map[string]interface{}, pagination Pagination, opts ...QueryOption) ([]map[string]interface{}, int64, error) {
+	return []map[string]interface{}{{"project": r.name}}, 1, nil
+}
+
+func (r namedRepository) GetByID(ctx context.Context, table string, id string, opts ...QueryOption) (map[string]interface{}, error) {
+	return map[string]interface{}{"project": r.name}, nil
+}
+
+func (r namedRepository) RPC(ctx context.Context, fn string, params map[string]interface{}) (json.RawMessage, error) {
+	return json.RawMessage(`"` + r.name + `"`), nil
+}
+
+func (r namedRepository) Insert(ctx context.Context, table string, record map[string]interface{}) (map[string]interface{}, error) {
+	return map[string]interface{}{"project": r.name}, nil
+}
+
+func (r namedRepository) Update(ctx context.Context, table string, id string, changes map[string]interface{}) (map[string]interface{}, error) {
+	return map[string]interface{}{"project": r.name}, nil
+}
+
+func (r namedRepository) Delete(ctx context.Context, table string, id string) error {
+	return nil
+}
+
+func TestSupabaseRegistryRoutesByDomain(t *testing.T) {
+	registry := NewSupabaseRegistry(namedRepository{"default"}, map[string]SupabaseRepository{
+		"movies": namedRepository{"movies"},
+	})
+
+	tests := []struct {
+		domain string
+		want   string
+	}{
+		{"movies", "movies"},
+		{"pharmacy", "default"},
+		{"", "default"},
+	}
+
+	for _, tt := range tests {
+		ctx := context.Background()
+		if tt.domain != "" {
+			ctx = WithDomain(ctx, tt.domain)
+		}
+
+		rows, err := registry.Query(ctx, "items", nil, Pagination{Limit: 10})
+		if err != nil || rows[0]["project"] != tt.want {
+			t.Errorf("Query() in %q = %v, %v, want the %s project", tt.domain, rows, err, tt.want)
+		}
+		row, err := registry.GetByID(ctx, "items", "1")
+		if err != nil || row["project"] != tt.want {
+			t.Errorf("GetByID() in %q = %v, %v, want the %s project", tt.domain, row, err, tt.want)
+		}
+		raw, err := registry.RPC(ctx, "fn", nil)
+		if err != nil || string(raw) != `"`+tt.want+`"` {
+			t.Errorf("RPC() in %q = %s, %v, want the %s project", tt.domain, raw, err, tt.want)
+		}
+		row, err = registry.Insert(ctx, "items", map[string]interface{}{"name": "x"})
+		if err != nil || row["project"] != tt.want {
+			t.Errorf("Insert() in %q = %v, %v, want the %s project", tt.domain, row, err, tt.want)
+		}
+	}
+}
+
+func TestWithSchemaSetsProfileHeaders(t *testing.T) {
+	repo, err := NewSupabaseRepository("http://localhost:54321", "test-key", WithSchema("movies"))
+	if err != nil {
+		t.Fatalf("NewSupabaseRepository() error = %v", err)
+	}
+
+	r := repo.(*supabaseRepository)
+	if r.headers["Accept-Profile"] != "movies" || r.headers["Content-Profile"] != "movies" {
+		t.Errorf("headers = %v, want the movies profile", r.headers)
+	}
+}
@@ -0,0 +1,198 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ErrCircuitOpen is wrapped in the connection error returned while the Supabase circuit
+// breaker is open, so callers can tell a short-circuited call from a failed one
+var ErrCircuitOpen = errors.New("supabase circuit breaker is open")
+
+// WithLogger logs retries and circuit breaker changes to logger
+func WithLogger(logger *zap.Logger) SupabaseOption {
+	return func(r *supabaseRepository) {
+		r.logger = logger
+	}
+}
+
+// WithRetry tries Query, QueryWithCount and GetByID up to maxAttempts times when
+// Supabase is unreachable or reports a transient failure, with exponential backoff and
+// jitter in between. RPC isn't retried, since a function may not be safe to run twice
+func WithRetry(maxAttempts int) SupabaseOption {
+	return func(r *supabaseRepository) {
+		r.retryMaxAttempts = maxAttempts
+	}
+}
+
+// WithCircuitBreaker short-circuits calls for cooldown after threshold consecutive
+// transient failures, returning a connection error wrapping ErrCircuitOpen
+// After the cool-down a single probe is let through
+func WithCircuitBreaker(threshold int, cooldown time.Duration) SupabaseOption {
+	return func(r *supabaseRepository) {
+		r.breaker = &supabaseBreaker{state: breakerClosed, threshold: threshold, cooldown: cooldown}
+	}
+}
+
+// call runs fn, which returns a repository error, under the retry policy and circuit breaker
+func (r *supabaseRepository) call(ctx context.Context, op string, fn func() error) error {
+	if !r.breaker.allow() {
+		return NewConnectionError(ErrCircuitOpen)
+	}
+
+	err := fn()
+	for attempt := 1; attempt < r.retryMaxAttempts && isTransientSupabase(ctx, err); attempt++ {
+		delay := retryDelay(attempt)
+		r.log(ctx).Warn("Retrying transient Supabase error",
+			zap.String("operation", op),
+			zap.Int("attempt", attempt),
+			zap.Duration("delay", delay),
+			zap.Error(err))
+
+		// A cancelled wait ends the loop, since the error is no longer transient for ctx
+		select {
+		case <-ctx.Done():
+		case <-time.After(delay):
+			err = fn()
+		}
+	}
+
+	switch {
+	case isTransientSupabase(ctx, err):
+		r.breaker.failure()
+	case ctx.Err() != nil:
+		// The caller gave up, which says nothing about Supabase
+		r.breaker.abandon()
+	default:
+		r.breaker.success()
+	}
+	return err
+}
+
+// isTransientSupabase reports whether a call that failed with err may succeed if made
+// again: Supabase was unreachable or unavailable, or timed out while the caller still waits
+func isTransientSupabase(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil {
+		return false
+	}
+	switch GetStatusCode(err) {
+	case http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// supabaseBreaker stops calling Supabase after consecutive transient failures so requests
+// don't each wait out the retries while it is down
+type supabaseBreaker struct {
+	mu        sync.Mutex
+	state     string
+	failures  int
+	openedAt  time.Time
+	probing   bool
+	threshold int
+	cooldown  time.Duration
+	logger    *zap.Logger
+}
+
+// Supabase circuit breaker states
+const (
+	breakerClosed   = "closed"
+	breakerOpen     = "open"
+	breakerHalfOpen = "half-open"
+)
+
+// allow reports whether a call may proceed
+func (b *supabaseBreaker) allow() bool {
+	if b == nil {
+		return true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.transition(breakerHalfOpen)
+		b.probing = true
+		return true
+	case breakerHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// success records a call Supabase answered
+func (b *supabaseBreaker) success() {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.probing = false
+	if b.state != breakerClosed {
+		b.transition(breakerClosed)
+	}
+}
+
+// failure records a transient failure, tripping the breaker at the threshold
+func (b *supabaseBreaker) failure() {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.probing = false
+	if b.state == breakerHalfOpen || (b.state == breakerClosed && b.failures >= b.threshold) {
+		b.openedAt = time.Now()
+		b.transition(breakerOpen)
+	}
+}
+
+// abandon records a call the caller cancelled, letting another probe through
+func (b *supabaseBreaker) abandon() {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// transition changes state and logs it; callers must hold the mutex
+func (b *supabaseBreaker) transition(to string) {
+	from := b.state
+	b.state = to
+
+	fields := []zap.Field{
+		zap.String("from", from),
+		zap.String("to", to),
+		zap.Int("consecutive_failures", b.failures),
+	}
+	if to == breakerOpen {
+		b.logger.Warn("Supabase circuit breaker opened, short-circuiting queries",
+			append(fields, zap.Duration("cooldown", b.cooldown))...)
+		return
+	}
+	b.logger.Info("Supabase circuit breaker state changed", fields...)
+}
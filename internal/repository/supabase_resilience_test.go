@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// newResilientTestRepository returns a repository retrying maxAttempts times, with a
+// breaker tripping after threshold failures
+func newResilientTestRepository(maxAttempts, threshold int, cooldown time.Duration) *supabaseRepository {
+	r := &supabaseRepository{retryMaxAttempts: maxAttempts, logger: zap.NewNop()}
+	WithCircuitBreaker(threshold, cooldown)(r)
+	r.breaker.logger = r.logger
+	return r
+}
+
+func TestCallRetriesTransientErrors(t *testing.T) {
+	r := newResilientTestRepository(3, 5, time.Minute)
+
+	calls := 0
+	err := r.call(context.Background(), "query", func() error {
+		calls++
+		if calls < 3 {
+			return NewConnectionError(errors.New("connection refused"))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("call() error = %v, want success on the third attempt", err)
+	}
+	if calls != 3 {
+		t.Errorf("calls = %d, want 3", calls)
+	}
+}
+
+func TestCallDoesNotRetryOtherErrors(t *testing.T) {
+	r := newResilientTestRepository(3, 5, time.Minute)
+
+	for _, want := range []error{NewNotFoundError("products", "1"), NewValidationError("bad filter"), NewQueryError(errors.New("syntax"))} {
+		calls := 0
+		err := r.call(context.Background(), "query", func() error {
+			calls++
+			return want
+		})
+		if err != want || calls != 1 {
+			t.Errorf("call() = %v after %d calls, want %v after 1", err, calls, want)
+		}
+	}
+}
+
+func TestCallStopsRetryingWhenContextEnds(t *testing.T) {
+	r := newResilientTestRepository(5, 5, time.Minute)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	calls := 0
+	err := r.call(ctx, "query", func() error {
+		calls++
+		cancel()
+		return NewTimeoutError(errors.New("timeout"))
+	})
+	if GetStatusCode(err) != 504 || calls != 1 {
+		t.Errorf("call() = %v after %d calls, want the timeout after 1", err, calls)
+	}
+	if r.breaker.failures != 0 {
+		t.Errorf("failures = %d, a cancelled call shouldn't count against Supabase", r.breaker.failures)
+	}
+}
+
+func TestCallCircuitBreaker(t *testing.T) {
+	r := newResilientTestRepository(1, 2, 20*time.Millisecond)
+	unavailable := func() error { return NewConnectionError(errors.New("connection refused")) }
+
+	for i := 0; i < 2; i++ {
+		_ = r.call(context.Background(), "query", unavailable)
+	}
+
+	calls := 0
+	err := r.call(context.Background(), "query", func() error {
+		calls++
+		return nil
+	})
+	if !errors.Is(err, ErrCircuitOpen) || GetStatusCode(err) != 503 {
+		t.Errorf("call() with the breaker open = %v, want a connection error wrapping ErrCircuitOpen", err)
+	}
+	if calls != 0 {
+		t.Errorf("calls = %d, the open breaker should short-circuit", calls)
+	}
+
+	// After the cool-down a probe that succeeds closes the breaker
+	time.Sleep(30 * time.Millisecond)
+	if err := r.call(context.Background(), "query", func() error { return nil }); err != nil {
+		t.Fatalf("probe call() error = %v", err)
+	}
+	if r.breaker.state != breakerClosed {
+		t.Errorf("state = %s, want closed after a successful probe", r.breaker.state)
+	}
+}
+
+func TestCallWithoutBreaker(t *testing.T) {
+	r := &supabaseRepository{retryMaxAttempts: 1, logger: zap.NewNop()}
+	for i := 0; i < 10; i++ {
+		calls := 0
+		_ = r.call(context.Background(), "query", func() error {
+			calls++
+			return NewConnectionError(errors.New("connection refused"))
+		})
+		if calls != 1 {
+			t.Fatalf("calls = %d, want every call to reach Supabase without a breaker", calls)
+		}
+	}
+}
+
+func TestHandleErrorPostgRESTCodes(t *testing.T) {
+	r := &supabaseRepository{}
+	tests := []struct {
+		err  string
+		want int
+	}{
+		{"(PGRST000) Could not connect with the database", 503},
+		{"(PGRST002) Could not query the database for the schema cache", 503},
+		{"(PGRST003) Timed out acquiring connection from connection pool.", 504},
+		{"() An invalid response was received from the upstream server", 503},
+		{"error parsing error response: invalid character '<'", 503},
+		{"(42P01) relation \"missing\" does not exist", 500},
+	}
+
+	for _, tt := range tests {
+		if got := GetStatusCode(r.handleError(errors.New(tt.err), "products")); got != tt.want {
+			t.Errorf("handleError(%q) status = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
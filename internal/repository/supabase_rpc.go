@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+)
+
+// identifier matches the Postgres function and table names RPC and writes accept, keeping
+// the request path plain
+var identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// postgrestError is the body PostgREST returns for a failed call
+type postgrestError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// RPC calls a Postgres function exposed by PostgREST with params as its named arguments
+// and returns its result as JSON: an array for set-returning functions, otherwise a scalar
+// or object. Unknown functions return a not-found error and invalid arguments a validation error
+func (r *supabaseRepository) RPC(ctx context.Context, fn string, params map[string]interface{}) (json.RawMessage, error) {
+	if !identifier.MatchString(fn) {
+		return nil, NewValidationError(fmt.Sprintf("invalid function name %q", fn))
+	}
+	if params == nil {
+		params = map[string]interface{}{}
+	}
+	body, err := json.Marshal(params)
+	if err != nil {
+		return nil, NewValidationError(fmt.Sprintf("invalid arguments for %s: %v", fn, err))
+	}
+
+	ctx, cancel := r.attemptContext(ctx)
+	defer cancel()
+
+	// postgrest-go's Rpc drops the status code and leaves errors on its client, failing
+	// every later call, so the call is made directly
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.restURL+"/rpc/"+fn, bytes.NewReader(body))
+	if err != nil {
+		return nil, NewQueryError(err)
+	}
+	for key, value := range r.authHeaders(ctx) {
+		req.Header.Set(key, value)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	setContextHeaders(ctx, req)
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return nil, r.requestError(ctx, err, fn)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, r.requestError(ctx, err, fn)
+	}
+
+	if resp.StatusCode >= 400 {
+		var rpcErr postgrestError
+		_ = json.Unmarshal(data, &rpcErr)
+		switch resp.StatusCode {
+		case http.StatusNotFound:
+			return nil, NewNotFoundError("functions", fn)
+		case http.StatusBadRequest:
+			return nil, NewValidationError(fmt.Sprintf("%s: %s", fn, rpcErr.Message))
+		case http.StatusServiceUnavailable:
+			return nil, NewConnectionError(fmt.Errorf("(%s) %s", rpcErr.Code, rpcErr.Message))
+		case http.StatusGatewayTimeout:
+			return nil, NewTimeoutError(fmt.Errorf("(%s) %s", rpcErr.Code, rpcErr.Message))
+		default:
+			return nil, r.handleError(fmt.Errorf("(%s) %s", rpcErr.Code, rpcErr.Message), fn)
+		}
+	}
+
+	// A void function returns no body
+	if len(bytes.TrimSpace(data)) == 0 {
+		return json.RawMessage("null"), nil
+	}
+	if !json.Valid(data) {
+		return nil, NewQueryError(fmt.Errorf("%s returned invalid JSON", fn))
+	}
+	return json.RawMessage(data), nil
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newRPCTestRepository returns a repository whose RPC calls go to handler
+func newRPCTestRepository(t *testing.T, handler http.HandlerFunc) *supabaseRepository {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return &supabaseRepository{
+		restURL:    server.URL,
+		headers:    map[string]string{"apikey": "test-key", "Authorization": "Bearer test-key"},
+		httpClient: server.Client(),
+	}
+}
+
+func TestRPC(t *testing.T) {
+	repo := newRPCTestRepository(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/rpc/search_products" {
+			t.Errorf("request = %s %s, want POST /rpc/search_products", r.Method, r.URL.Path)
+		}
+		if r.Header.Get("apikey") != "test-key" {
+			t.Errorf("apikey header = %q, want test-key", r.Header.Get("apikey"))
+		}
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != `{"query":"milk"}` {
+			t.Errorf("body = %s, want the function arguments", body)
+		}
+		w.Write([]byte(`[{"id":"1"}]`))
+	})
+
+	result, err := repo.RPC(context.Background(), "search_products", map[string]interface{}{"query": "milk"})
+	if err != nil {
+		t.Fatalf("RPC() error = %v", err)
+	}
+	if string(result) != `[{"id":"1"}]` {
+		t.Errorf("RPC() = %s, want [{\"id\":\"1\"}]", result)
+	}
+}
+
+func TestRPCVoidFunction(t *testing.T) {
+	repo := newRPCTestRepository(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	result, err := repo.RPC(context.Background(), "refresh_stats", nil)
+	if err != nil {
+		t.Fatalf("RPC() error = %v", err)
+	}
+	if string(result) != "null" {
+		t.Errorf("RPC() = %s, want null", result)
+	}
+}
+
+func TestRPCErrors(t *testing.T) {
+	tests := []struct {
+		name           string
+		fn             string
+		status         int
+		wantStatusCode int
+	}{
+		{"invalid name", "search/../products", 0, 400},
+		{"unknown function", "missing", http.StatusNotFound, 404},
+		{"invalid arguments", "search_products", http.StatusBadRequest, 400},
+		{"unavailable", "search_products", http.StatusServiceUnavailable, 503},
+		{"server error", "search_products", http.StatusInternalServerError, 500},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newRPCTestRepository(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				json.NewEncoder(w).Encode(postgrestError{Code: "P0001", Message: "failed"})
+			})
+
+			_, err := repo.RPC(context.Background(), tt.fn, nil)
+			if got := GetStatusCode(err); got != tt.wantStatusCode {
+				t.Errorf("RPC() error = %v, status %d, want %d", err, got, tt.wantStatusCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,217 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// StorageClient stores files in one Supabase Storage bucket
+// Public buckets serve objects at a permanent public URL; private ones only through
+// signed URLs that expire
+type StorageClient struct {
+	storageURL string
+	bucket     string
+	public     bool
+	headers    map[string]string
+	httpClient *http.Client
+}
+
+// storageError is the body Supabase Storage returns for a failed call
+type storageError struct {
+	StatusCode string `json:"statusCode"`
+	Error      string `json:"error"`
+	Message    string `json:"message"`
+}
+
+// NewStorageClient creates a client for bucket in the Supabase project at url
+func NewStorageClient(url, apiKey, bucket string, public bool) (*StorageClient, error) {
+	if url == "" || apiKey == "" {
+		return nil, NewConnectionError(fmt.Errorf("supabase URL and API key are required"))
+	}
+	if bucket == "" {
+		return nil, NewValidationError("storage bucket is required")
+	}
+
+	return &StorageClient{
+		storageURL: strings.TrimRight(url, "/") + "/storage/v1",
+		bucket:     bucket,
+		public:     public,
+		headers: map[string]string{
+			"apikey":        apiKey,
+			"Authorization": "Bearer " + apiKey,
+		},
+		httpClient: http.DefaultClient,
+	}, nil
+}
+
+// EnableTracing records a client span for every call to Supabase Storage
+// Must be called before the client is used
+func (s *StorageClient) EnableTracing() {
+	s.httpClient = tracedHTTPClient()
+}
+
+// Bucket returns the name of the client's bucket
+func (s *StorageClient) Bucket() string {
+	return s.bucket
+}
+
+// Public reports whether the bucket serves objects at public URLs
+func (s *StorageClient) Public() bool {
+	return s.public
+}
+
+// Upload streams body to path in the bucket without buffering it
+// An existing object at path is a conflict, not overwritten
+func (s *StorageClient) Upload(ctx context.Context, path, contentType string, body io.Reader) error {
+	// storage-go sets the content type on headers shared by every request and takes no
+	// context, so the call is made directly
+	req, err := s.newRequest(ctx, http.MethodPost, "/object/"+s.objectPath(path), body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Cache-Control", "max-age=3600")
+	req.Header.Set("x-upsert", "false")
+
+	_, err = s.do(req, path)
+	return err
+}
+
+// Remove deletes the object at path
+func (s *StorageClient) Remove(ctx context.Context, path string) error {
+	req, err := s.newRequest(ctx, http.MethodDelete, "/object/"+s.objectPath(path), nil)
+	if err != nil {
+		return err
+	}
+	_, err = s.do(req, path)
+	return err
+}
+
+// ObjectURL returns the URL recorded for the object at path: its public URL in a public
+// bucket, otherwise its authenticated URL, which needs a signed URL to be fetched
+func (s *StorageClient) ObjectURL(path string) string {
+	if s.public {
+		return s.storageURL + "/object/public/" + s.objectPath(path)
+	}
+	return s.storageURL + "/object/authenticated/" + s.objectPath(path)
+}
+
+// PathFromURL returns the path of the object in the bucket that ObjectURL returned url for
+// It reports false for URLs of other buckets or sites
+func (s *StorageClient) PathFromURL(objectURL string) (string, bool) {
+	prefix := s.ObjectURL("")
+	if !strings.HasPrefix(objectURL, prefix) || len(objectURL) == len(prefix) {
+		return "", false
+	}
+	path, err := url.PathUnescape(objectURL[len(prefix):])
+	if err != nil {
+		return "", false
+	}
+	return path, true
+}
+
+// SignedURL returns a URL that serves the object at path to anyone for expiresIn
+func (s *StorageClient) SignedURL(ctx context.Context, path string, expiresIn time.Duration) (string, error) {
+	seconds := int(expiresIn / time.Second)
+	if seconds < 1 {
+		return "", NewValidationError("signed URL expiry must be at least one second")
+	}
+	body, err := json.Marshal(map[string]int{"expiresIn": seconds})
+	if err != nil {
+		return "", NewQueryError(err)
+	}
+
+	req, err := s.newRequest(ctx, http.MethodPost, "/object/sign/"+s.objectPath(path), bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	data, err := s.do(req, path)
+	if err != nil {
+		return "", err
+	}
+
+	var signed struct {
+		SignedURL string `json:"signedURL"`
+	}
+	if err := json.Unmarshal(data, &signed); err != nil || signed.SignedURL == "" {
+		return "", NewQueryError(fmt.Errorf("storage returned no signed URL for %s", path))
+	}
+	// The signed URL is relative to the storage API
+	return s.storageURL + signed.SignedURL, nil
+}
+
+// objectPath returns the escaped bucket and object path for a request
+func (s *StorageClient) objectPath(path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return url.PathEscape(s.bucket) + "/" + strings.Join(segments, "/")
+}
+
+// newRequest creates a request to the storage API authorized with the API key
+func (s *StorageClient) newRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, s.storageURL+path, body)
+	if err != nil {
+		return nil, NewQueryError(err)
+	}
+	for key, value := range s.headers {
+		req.Header.Set(key, value)
+	}
+	return req, nil
+}
+
+// do sends req and returns the response body, mapping failures to repository errors
+func (s *StorageClient) do(req *http.Request, path string) ([]byte, error) {
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, NewTimeoutError(err)
+		}
+		return nil, NewConnectionError(err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, NewConnectionError(err)
+	}
+	if resp.StatusCode < 400 {
+		return data, nil
+	}
+
+	var storageErr storageError
+	_ = json.Unmarshal(data, &storageErr)
+	// Storage reports some failures as 400 with the real status in the body
+	status := resp.StatusCode
+	if storageErr.StatusCode != "" {
+		fmt.Sscanf(storageErr.StatusCode, "%d", &status)
+	}
+
+	switch status {
+	case http.StatusNotFound:
+		return nil, NewNotFoundError("storage.objects", s.bucket+"/"+path)
+	case http.StatusConflict:
+		return nil, NewConflictError(fmt.Sprintf("%s already exists in %s", path, s.bucket), errors.New(storageErr.Message))
+	case http.StatusRequestEntityTooLarge:
+		return nil, NewValidationError(fmt.Sprintf("%s is larger than %s allows", path, s.bucket))
+	case http.StatusBadRequest:
+		return nil, NewValidationError(fmt.Sprintf("%s: %s", path, storageErr.Message))
+	case http.StatusServiceUnavailable:
+		return nil, NewConnectionError(fmt.Errorf("(%s) %s", storageErr.Error, storageErr.Message))
+	case http.StatusGatewayTimeout:
+		return nil, NewTimeoutError(fmt.Errorf("(%s) %s", storageErr.Error, storageErr.Message))
+	default:
+		return nil, NewQueryError(fmt.Errorf("storage returned %d: (%s) %s", status, storageErr.Error, storageErr.Message))
+	}
+}
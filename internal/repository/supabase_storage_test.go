@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newStorageTestClient returns a client for the images bucket whose calls go to handler
+func newStorageTestClient(t *testing.T, public bool, handler http.HandlerFunc) *StorageClient {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	client, err := NewStorageClient(server.URL, "test-key", "images", public)
+	if err != nil {
+		t.Fatalf("NewStorageClient() error = %v", err)
+	}
+	client.httpClient = server.Client()
+	return client
+}
+
+func TestStorageUpload(t *testing.T) {
+	client := newStorageTestClient(t, true, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/storage/v1/object/images/p1/photo.png" {
+			t.Errorf("request = %s %s, want POST /storage/v1/object/images/p1/photo.png", r.Method, r.URL.Path)
+		}
+		if r.Header.Get("Content-Type") != "image/png" || r.Header.Get("x-upsert") != "false" {
+			t.Errorf("headers = %v, want image/png without upsert", r.Header)
+		}
+		if r.Header.Get("Authorization") != "Bearer test-key" {
+			t.Errorf("Authorization = %q, want the API key", r.Header.Get("Authorization"))
+		}
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != "png-bytes" {
+			t.Errorf("body = %q, want the file", body)
+		}
+		w.Write([]byte(`{"Key":"images/p1/photo.png"}`))
+	})
+
+	if err := client.Upload(context.Background(), "p1/photo.png", "image/png", strings.NewReader("png-bytes")); err != nil {
+		t.Fatalf("Upload() error = %v", err)
+	}
+}
+
+func TestStorageUploadErrors(t *testing.T) {
+	tests := []struct {
+		name           string
+		status         int
+		body           string
+		wantStatusCode int
+	}{
+		{"duplicate reported in body", http.StatusBadRequest, `{"statusCode":"409","error":"Duplicate","message":"The resource already exists"}`, 409},
+		{"bucket missing", http.StatusBadRequest, `{"statusCode":"404","error":"Bucket not found","message":"Bucket not found"}`, 404},
+		{"too large", http.StatusRequestEntityTooLarge, `{"statusCode":"413","error":"Payload too large","message":"too large"}`, 400},
+		{"unavailable", http.StatusServiceUnavailable, ``, 503},
+		{"server error", http.StatusInternalServerError, `{"error":"internal","message":"failed"}`, 500},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newStorageTestClient(t, true, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			})
+
+			err := client.Upload(context.Background(), "p1/photo.png", "image/png", strings.NewReader("png-bytes"))
+			if got := GetStatusCode(err); got != tt.wantStatusCode {
+				t.Errorf("Upload() error = %v, status %d, want %d", err, got, tt.wantStatusCode)
+			}
+		})
+	}
+}
+
+func TestStorageObjectURL(t *testing.T) {
+	public := newStorageTestClient(t, true, func(w http.ResponseWriter, r *http.Request) {})
+	url := public.ObjectURL("p1/my photo.png")
+	if !strings.HasSuffix(url, "/storage/v1/object/public/images/p1/my%20photo.png") {
+		t.Errorf("ObjectURL() = %s, want the escaped public URL", url)
+	}
+	if path, ok := public.PathFromURL(url); !ok || path != "p1/my photo.png" {
+		t.Errorf("PathFromURL(%s) = %q, %v, want p1/my photo.png", url, path, ok)
+	}
+	if _, ok := public.PathFromURL("https://cdn.example.com/p1/photo.png"); ok {
+		t.Error("PathFromURL() should reject URLs outside the bucket")
+	}
+
+	private := newStorageTestClient(t, false, func(w http.ResponseWriter, r *http.Request) {})
+	if url := private.ObjectURL("p1/photo.png"); !strings.Contains(url, "/object/authenticated/images/") {
+		t.Errorf("ObjectURL() = %s, want the authenticated URL for a private bucket", url)
+	}
+}
+
+func TestStorageSignedURL(t *testing.T) {
+	client := newStorageTestClient(t, false, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/storage/v1/object/sign/images/p1/photo.png" {
+			t.Errorf("request = %s %s, want POST /storage/v1/object/sign/images/p1/photo.png", r.Method, r.URL.Path)
+		}
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != `{"expiresIn":600}` {
+			t.Errorf("body = %s, want the expiry in seconds", body)
+		}
+		w.Write([]byte(`{"signedURL":"/object/sign/images/p1/photo.png?token=abc"}`))
+	})
+
+	url, err := client.SignedURL(context.Background(), "p1/photo.png", 10*time.Minute)
+	if err != nil {
+		t.Fatalf("SignedURL() error = %v", err)
+	}
+	if !strings.HasSuffix(url, "/storage/v1/object/sign/images/p1/photo.png?token=abc") {
+		t.Errorf("SignedURL() = %s, want the signed URL under the storage API", url)
+	}
+
+	if _, err := client.SignedURL(context.Background(), "p1/photo.png", 0); GetStatusCode(err) != 400 {
+		t.Errorf("SignedURL() with no expiry error = %v, want a validation error", err)
+	}
+}
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"maps"
+
+	"github.com/yourusername/supabase-redis-middleware/internal/tenant"
+)
+
+// TenantColumn is the column naming the tenant of each row, in Postgres and, with
+// NewTenantScoped, in the tables read through Supabase
+const TenantColumn = "tenant_id"
+
+// tenantRepository is a SupabaseRepository keeping the calls made for a tenant to that
+// tenant's rows
+type tenantRepository struct {
+	SupabaseRepository
+}
+
+// NewTenantScoped returns repo with each call made for a tenant limited to the rows whose
+// TenantColumn holds its ID: reads filter on it, inserts set it, and updates and deletes
+// find no row of another tenant. Calls in a system context, with no tenant, see every
+// row. PostgREST runs as the project's API key, which bypasses RLS, so the tables read
+// must have the column. RPC calls can't be filtered; they send the tenant in X-Tenant-ID,
+// for functions to scope themselves
+func NewTenantScoped(repo SupabaseRepository) SupabaseRepository {
+	return &tenantRepository{SupabaseRepository: repo}
+}
+
+// scoped returns filters limited to the tenant of ctx, if any
+func (r *tenantRepository) scoped(ctx context.Context, filters map[string]interface{}) map[string]interface{} {
+	id := tenant.FromContext(ctx)
+	if id == "" {
+		return filters
+	}
+	scoped := maps.Clone(filters)
+	if scoped == nil {
+		scoped = map[string]interface{}{}
+	}
+	scoped[TenantColumn] = id
+	return scoped
+}
+
+// Query runs Query on the tenant's rows
+func (r *tenantRepository) Query(ctx context.Context, table string, filters map[string]interface{}, pagination Pagination, opts ...QueryOption) ([]map[string]interface{}, error) {
+	return r.SupabaseRepository.Query(ctx, table, r.scoped(ctx, filters), pagination, opts...)
+}
+
+// QueryWithCount runs QueryWithCount on the tenant's rows
+func (r *tenantRepository) QueryWithCount(ctx context.Context, table string, filters map[string]interface{}, pagination Pagination, opts ...QueryOption) ([]map[string]interface{}, int64, error) {
+	return r.SupabaseRepository.QueryWithCount(ctx, table, r.scoped(ctx, filters), pagination, opts...)
+}
+
+// GetByID returns the row with id if it is the tenant's; another tenant's is not found
+func (r *tenantRepository) GetByID(ctx context.Context, table string, id string, opts ...QueryOption) (map[string]interface{}, error) {
+	if tenant.FromContext(ctx) == "" {
+		return r.SupabaseRepository.GetByID(ctx, table, id, opts...)
+	}
+	rows, err := r.SupabaseRepository.Query(ctx, table, r.scoped(ctx, map[string]interface{}{"id": id}), Pagination{Limit: 1}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, NewNotFoundError(table, id)
+	}
+	return rows[0], nil
+}
+
+// Insert adds record to table as a row of the tenant
+func (r *tenantRepository) Insert(ctx context.Context, table string, record map[string]interface{}) (map[string]interface{}, error) {
+	return r.SupabaseRepository.Insert(ctx, table, r.scoped(ctx, record))
+}
+
+// Update updates the row with id if it is the tenant's, keeping it the tenant's
+func (r *tenantRepository) Update(ctx context.Context, table string, id string, changes map[string]interface{}) (map[string]interface{}, error) {
+	if err := r.checkOwned(ctx, table, id); err != nil {
+		return nil, err
+	}
+	return r.SupabaseRepository.Update(ctx, table, id, r.scoped(ctx, changes))
+}
+
+// Delete removes the row with id if it is the tenant's
+func (r *tenantRepository) Delete(ctx context.Context, table string, id string) error {
+	if err := r.checkOwned(ctx, table, id); err != nil {
+		return err
+	}
+	return r.SupabaseRepository.Delete(ctx, table, id)
+}
+
+// checkOwned returns a not-found error unless the row of table with id is the tenant's
+// A row's tenant never changes through this repository, so it stays the tenant's
+func (r *tenantRepository) checkOwned(ctx context.Context, table string, id string) error {
+	if tenant.FromContext(ctx) == "" || id == "" {
+		return nil
+	}
+	_, err := r.GetByID(ctx, table, id, WithColumns("id"))
+	return err
+}
@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/yourusername/supabase-redis-middleware/internal/tenant"
+)
+
+// tenantRows is a SupabaseRepository over rows of one table, filtering them on equality
+// filters, which records the calls that reach it
+type tenantRows struct {
+	rows    []map[string]interface{}
+	filters map[string]interface{}
+	written map[string]interface{}
+	updated bool
+	deleted bool
+}
+
+func (r *tenantRows) Query(ctx context.Context, table string, filters map[string]interface{}, pagination Pagination, opts ...QueryOption) ([]map[string]interface{}, error) {
+	r.filters = filters
+	var matches []map[string]interface{}
+	for _, row := range r.rows {
+		match := true
+		for column, value := range filters {
+			if row[column] != value {
+				match = false
+			}
+		}
+		if match {
+			matches = append(matches, row)
+		}
+	}
+	return matches, nil
+}
+
+func (r *tenantRows) QueryWithCount(ctx context.Context, table string, filters map[string]interface{}, pagination Pagination, opts ...QueryOption) ([]map[string]interface{}, int64, error) {
+	rows, err := r.Query(ctx, table, filters, pagination, opts...)
+	return rows, int64(len(rows)), err
+}
+
+func (r *tenantRows) GetByID(ctx context.Context, table string, id string, opts ...QueryOption) (map[string]interface{}, error) {
+	rows, _ := r.Query(ctx, table, map[string]interface{}{"id": id}, Pagination{})
+	if len(rows) == 0 {
+		return nil, NewNotFoundError(table, id)
+	}
+	return rows[0], nil
+}
+
+func (r *tenantRows) RPC(ctx context.Context, fn string, params map[string]interface{}) (json.RawMessage, error) {
+	return nil, nil
+}
+
+func (r *tenantRows) Insert(ctx context.Context, table string, record map[string]interface{}) (map[string]interface{}, error) {
+	r.written = record
+	return record, nil
+}
+
+func (r *tenantRows) Update(ctx context.Context, table string, id string, changes map[string]interface{}) (map[string]interface{}, error) {
+	r.written, r.updated = changes, true
+	return changes, nil
+}
+
+func (r *tenantRows) Delete(ctx context.Context, table string, id string) error {
+	r.deleted = true
+	return nil
+}
+
+func TestTenantScoped(t *testing.T) {
+	newRepo := func() (*tenantRows, SupabaseRepository) {
+		rows := &tenantRows{rows: []map[string]interface{}{
+			{"id": "1", "title": "Alpha", TenantColumn: "acme"},
+			{"id": "2", "title": "Beta", TenantColumn: "globex"},
+		}}
+		return rows, NewTenantScoped(rows)
+	}
+	acme := tenant.WithID(context.Background(), "acme")
+
+	t.Run("query", func(t *testing.T) {
+		_, repo := newRepo()
+		rows, _ := repo.Query(acme, "movies", nil, Pagination{})
+		if len(rows) != 1 || rows[0]["id"] != "1" {
+			t.Errorf("Query() for acme = %v, want acme's row alone", rows)
+		}
+		rows, _ = repo.Query(acme, "movies", map[string]interface{}{TenantColumn: "globex"}, Pagination{})
+		if len(rows) != 1 || rows[0]["id"] != "1" {
+			t.Errorf("Query() for acme filtering on globex = %v, want acme's row alone", rows)
+		}
+		rows, _ = repo.Query(context.Background(), "movies", nil, Pagination{})
+		if len(rows) != 2 {
+			t.Errorf("Query() in a system context = %v, want every row", rows)
+		}
+	})
+
+	t.Run("get another tenant's row", func(t *testing.T) {
+		_, repo := newRepo()
+		var notFound *RepositoryError
+		if _, err := repo.GetByID(acme, "movies", "2"); !errors.As(err, &notFound) || notFound.StatusCode != http.StatusNotFound {
+			t.Errorf("GetByID() of globex's row for acme error = %v, want not found", err)
+		}
+		if row, err := repo.GetByID(acme, "movies", "1"); err != nil || row["id"] != "1" {
+			t.Errorf("GetByID() of acme's row = %v, %v, want the row", row, err)
+		}
+	})
+
+	t.Run("insert", func(t *testing.T) {
+		rows, repo := newRepo()
+		record := map[string]interface{}{"title": "Gamma", TenantColumn: "globex"}
+		if _, err := repo.Insert(acme, "movies", record); err != nil {
+			t.Fatalf("Insert() error = %v", err)
+		}
+		if rows.written[TenantColumn] != "acme" {
+			t.Errorf("inserted %v, want it acme's", rows.written)
+		}
+		if record[TenantColumn] != "globex" {
+			t.Error("Insert() changed the caller's record")
+		}
+	})
+
+	t.Run("write another tenant's row", func(t *testing.T) {
+		rows, repo := newRepo()
+		if _, err := repo.Update(acme, "movies", "2", map[string]interface{}{"title": "Stolen"}); err == nil {
+			t.Error("Update() of globex's row for acme succeeded, want not found")
+		}
+		if err := repo.Delete(acme, "movies", "2"); err == nil {
+			t.Error("Delete() of globex's row for acme succeeded, want not found")
+		}
+		if rows.updated || rows.deleted {
+			t.Error("a write to globex's row reached the repository")
+		}
+
+		if _, err := repo.Update(acme, "movies", "1", map[string]interface{}{TenantColumn: "globex"}); err != nil {
+			t.Fatalf("Update() of acme's row error = %v", err)
+		}
+		if rows.written[TenantColumn] != "acme" {
+			t.Errorf("updated with %v, want the row kept acme's", rows.written)
+		}
+	})
+}
+
+func TestSetContextHeaders(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/rest/v1/rpc/top_movies", nil)
+	ctx := tenant.WithID(WithRequestID(context.Background(), "req-1"), "acme")
+	setContextHeaders(ctx, req)
+
+	if got := req.Header.Get("X-Request-ID"); got != "req-1" {
+		t.Errorf("X-Request-ID = %q, want req-1", got)
+	}
+	if got := req.Header.Get("X-Tenant-ID"); got != "acme" {
+		t.Errorf("X-Tenant-ID = %q, want acme", got)
+	}
+}
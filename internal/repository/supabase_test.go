@@ -2,9 +2,13 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"testing"
 	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
 // MockSupabaseClient is a mock implementation for testing
@@ -22,7 +26,7 @@ func newMockRepository(mock *MockSupabaseClient) SupabaseRepository {
 	return &mockSupabaseRepository{mock: mock}
 }
 
-func (m *mockSupabaseRepository) Query(ctx context.Context, table string, filters map[string]interface{}, pagination Pagination) ([]map[string]interface{}, error) {
+func (m *mockSupabaseRepository) Query(ctx context.Context, table string, filters map[string]interface{}, pagination Pagination, opts ...QueryOption) ([]map[string]interface{}, error) {
 	if err := ctx.Err(); err != nil {
 		if errors.Is(err, context.DeadlineExceeded) {
 			return nil, NewTimeoutError(err)
@@ -36,7 +40,28 @@ func (m *mockSupabaseRepository) Query(ctx context.Context, table string, filter
 	return nil, errors.New("queryFunc not implemented")
 }
 
-func (m *mockSupabaseRepository) GetByID(ctx context.Context, table string, id string) (map[string]interface{}, error) {
+func (m *mockSupabaseRepository) QueryWithCount(ctx context.Context, table string, filters map[string]interface{}, pagination Pagination, opts ...QueryOption) ([]map[string]interface{}, int64, error) {
+	results, err := m.Query(ctx, table, filters, pagination, opts...)
+	return results, int64(len(results)), err
+}
+
+func (m *mockSupabaseRepository) RPC(ctx context.Context, fn string, params map[string]interface{}) (json.RawMessage, error) {
+	return nil, errors.New("RPC not implemented")
+}
+
+func (m *mockSupabaseRepository) Insert(ctx context.Context, table string, record map[string]interface{}) (map[string]interface{}, error) {
+	return nil, errors.New("Insert not implemented")
+}
+
+func (m *mockSupabaseRepository) Update(ctx context.Context, table string, id string, changes map[string]interface{}) (map[string]interface{}, error) {
+	return nil, errors.New("Update not implemented")
+}
+
+func (m *mockSupabaseRepository) Delete(ctx context.Context, table string, id string) error {
+	return errors.New("Delete not implemented")
+}
+
+func (m *mockSupabaseRepository) GetByID(ctx context.Context, table string, id string, opts ...QueryOption) (map[string]interface{}, error) {
 	if err := ctx.Err(); err != nil {
 		if errors.Is(err, context.DeadlineExceeded) {
 			return nil, NewTimeoutError(err)
@@ -344,3 +369,31 @@ func TestGetStatusCode(t *testing.T) {
 		})
 	}
 }
+
+func TestIsUnavailable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"connection error", NewConnectionError(errors.New("connection refused")), true},
+		{"timeout error", NewTimeoutError(errors.New("deadline exceeded")), true},
+		{"open circuit breaker", NewConnectionError(ErrCircuitOpen), true},
+		{"postgres starting up", fmt.Errorf("query: %w", &pgconn.PgError{Code: "57P03"}), true},
+		{"postgres connection failure", &pgconn.PgError{Code: "08006"}, true},
+		{"deadline exceeded", fmt.Errorf("query: %w", context.DeadlineExceeded), true},
+		{"not found", NewNotFoundError("products", "1"), false},
+		{"validation error", NewValidationError("bad filter"), false},
+		{"postgres constraint violation", &pgconn.PgError{Code: "23505"}, false},
+		{"cancelled", context.Canceled, false},
+		{"nil", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsUnavailable(tt.err); got != tt.want {
+				t.Errorf("IsUnavailable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
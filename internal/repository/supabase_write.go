@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// Insert adds record to table and returns the row as stored, with its generated columns
+// Writes aren't retried, since an insert made twice adds two rows
+func (r *supabaseRepository) Insert(ctx context.Context, table string, record map[string]interface{}) (map[string]interface{}, error) {
+	if len(record) == 0 {
+		return nil, NewValidationError("record must have at least one column")
+	}
+
+	rows, err := r.write(ctx, http.MethodPost, table, nil, record)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, NewQueryError(fmt.Errorf("insert into %s returned no row", table))
+	}
+	return rows[0], nil
+}
+
+// Update sets the columns in changes on the row of table with id and returns the row as
+// stored. A missing row returns a not-found error
+func (r *supabaseRepository) Update(ctx context.Context, table string, id string, changes map[string]interface{}) (map[string]interface{}, error) {
+	if id == "" {
+		return nil, NewValidationError("id is required")
+	}
+	if len(changes) == 0 {
+		return nil, NewValidationError("changes must have at least one column")
+	}
+
+	rows, err := r.write(ctx, http.MethodPatch, table, url.Values{"id": {"eq." + id}}, changes)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, NewNotFoundError(table, id)
+	}
+	return rows[0], nil
+}
+
+// Delete removes the row of table with id. A missing row returns a not-found error
+func (r *supabaseRepository) Delete(ctx context.Context, table string, id string) error {
+	if id == "" {
+		return NewValidationError("id is required")
+	}
+
+	rows, err := r.write(ctx, http.MethodDelete, table, url.Values{"id": {"eq." + id}}, nil)
+	if err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return NewNotFoundError(table, id)
+	}
+	return nil
+}
+
+// write sends a write to table and returns the rows it affected
+// Like RPC it is made directly, since postgrest-go drops the status code that tells a
+// conflict or a missing table from any other failure
+func (r *supabaseRepository) write(ctx context.Context, method, table string, query url.Values, record map[string]interface{}) ([]map[string]interface{}, error) {
+	if !identifier.MatchString(table) {
+		return nil, NewValidationError(fmt.Sprintf("invalid table name %q", table))
+	}
+	var body io.Reader
+	if record != nil {
+		data, err := json.Marshal(record)
+		if err != nil {
+			return nil, NewValidationError(fmt.Sprintf("invalid record for %s: %v", table, err))
+		}
+		body = bytes.NewReader(data)
+	}
+
+	ctx, cancel := r.attemptContext(ctx)
+	defer cancel()
+
+	target := r.restURL + "/" + table
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return nil, NewQueryError(err)
+	}
+	for key, value := range r.authHeaders(ctx) {
+		req.Header.Set(key, value)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Prefer", "return=representation")
+	setContextHeaders(ctx, req)
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return nil, r.requestError(ctx, err, table)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, r.requestError(ctx, err, table)
+	}
+
+	if resp.StatusCode >= 400 {
+		var writeErr postgrestError
+		_ = json.Unmarshal(data, &writeErr)
+		err := fmt.Errorf("(%s) %s", writeErr.Code, writeErr.Message)
+		switch resp.StatusCode {
+		case http.StatusNotFound:
+			return nil, NewNotFoundError("tables", table)
+		case http.StatusConflict:
+			return nil, NewConflictError(fmt.Sprintf("%s: %s", table, writeErr.Message), err)
+		case http.StatusBadRequest:
+			return nil, NewValidationError(fmt.Sprintf("%s: %s", table, writeErr.Message))
+		case http.StatusServiceUnavailable:
+			return nil, NewConnectionError(err)
+		case http.StatusGatewayTimeout:
+			return nil, NewTimeoutError(err)
+		default:
+			return nil, r.handleError(err, table)
+		}
+	}
+
+	var rows []map[string]interface{}
+	if err := json.Unmarshal(data, &rows); err != nil {
+		return nil, NewQueryError(fmt.Errorf("write to %s returned invalid JSON: %w", table, err))
+	}
+	return rows, nil
+}
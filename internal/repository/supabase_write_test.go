@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestInsert(t *testing.T) {
+	repo := newRPCTestRepository(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/products" {
+			t.Errorf("request = %s %s, want POST /products", r.Method, r.URL.Path)
+		}
+		if r.Header.Get("Prefer") != "return=representation" {
+			t.Errorf("Prefer = %q, want the row returned", r.Header.Get("Prefer"))
+		}
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != `{"name":"Milk"}` {
+			t.Errorf("body = %s, want the record", body)
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`[{"id":"p1","name":"Milk"}]`))
+	})
+
+	row, err := repo.Insert(context.Background(), "products", map[string]interface{}{"name": "Milk"})
+	if err != nil {
+		t.Fatalf("Insert() error = %v", err)
+	}
+	if row["id"] != "p1" {
+		t.Errorf("Insert() = %v, want the stored row", row)
+	}
+}
+
+func TestUpdateAndDeleteByID(t *testing.T) {
+	var requests []string
+	repo := newRPCTestRepository(t, func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.RawQuery)
+		// Only p1 exists
+		if r.URL.Query().Get("id") != "eq.p1" {
+			w.Write([]byte(`[]`))
+			return
+		}
+		w.Write([]byte(`[{"id":"p1","name":"Oat milk"}]`))
+	})
+
+	row, err := repo.Update(context.Background(), "products", "p1", map[string]interface{}{"name": "Oat milk"})
+	if err != nil || row["name"] != "Oat milk" {
+		t.Errorf("Update() = %v, %v, want the updated row", row, err)
+	}
+	if err := repo.Delete(context.Background(), "products", "p1"); err != nil {
+		t.Errorf("Delete() error = %v", err)
+	}
+	if requests[0] != "PATCH id=eq.p1" || requests[1] != "DELETE id=eq.p1" {
+		t.Errorf("requests = %v, want PATCH and DELETE filtered by id", requests)
+	}
+
+	if _, err := repo.Update(context.Background(), "products", "p2", map[string]interface{}{"name": "x"}); GetStatusCode(err) != 404 {
+		t.Errorf("Update() of a missing row error = %v, want not found", err)
+	}
+	if err := repo.Delete(context.Background(), "products", "p2"); GetStatusCode(err) != 404 {
+		t.Errorf("Delete() of a missing row error = %v, want not found", err)
+	}
+}
+
+func TestWriteErrors(t *testing.T) {
+	tests := []struct {
+		name           string
+		status         int
+		body           string
+		wantStatusCode int
+	}{
+		{"duplicate key", http.StatusConflict, `{"code":"23505","message":"duplicate key value violates unique constraint"}`, 409},
+		{"not null violation", http.StatusBadRequest, `{"code":"23502","message":"null value in column \"name\""}`, 400},
+		{"unknown table", http.StatusNotFound, `{"code":"42P01","message":"relation does not exist"}`, 404},
+		{"unavailable", http.StatusServiceUnavailable, `{"code":"PGRST000","message":"Could not connect"}`, 503},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newRPCTestRepository(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			})
+
+			_, err := repo.Insert(context.Background(), "products", map[string]interface{}{"name": "Milk"})
+			if got := GetStatusCode(err); got != tt.wantStatusCode {
+				t.Errorf("Insert() error = %v, status %d, want %d", err, got, tt.wantStatusCode)
+			}
+		})
+	}
+}
+
+func TestWriteValidation(t *testing.T) {
+	repo := newRPCTestRepository(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("invalid writes shouldn't reach Supabase, got %s %s", r.Method, r.URL.Path)
+	})
+
+	if _, err := repo.Insert(context.Background(), "products; drop", map[string]interface{}{"name": "x"}); GetStatusCode(err) != 400 {
+		t.Errorf("Insert() into an invalid table error = %v, want a validation error", err)
+	}
+	if _, err := repo.Insert(context.Background(), "products", nil); GetStatusCode(err) != 400 {
+		t.Errorf("Insert() of an empty record error = %v, want a validation error", err)
+	}
+	if err := repo.Delete(context.Background(), "products", ""); GetStatusCode(err) != 400 {
+		t.Errorf("Delete() without an id error = %v, want a validation error", err)
+	}
+}
@@ -0,0 +1,229 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+// Tax types
+const (
+	TaxTypePercentage = "percentage"
+	TaxTypeFixed      = "fixed"
+)
+
+// TaxUpdate changes a store tax; nil fields are left unchanged
+type TaxUpdate struct {
+	Name        *string
+	Description *string
+	Rate        *float64
+	TaxType     *string
+	IsInclusive *bool
+	IsActive    *bool
+}
+
+// GetStoreTax retrieves one of a store's taxes by its UUID
+func (r *PostgresRepository) GetStoreTax(ctx context.Context, storeID, taxID string) (*Tax, error) {
+	tax, err := queryRow(ctx, r, pgx.RowToAddrOfStructByName[Tax], `
+		SELECT `+taxColumns+` FROM taxes WHERE id = $1 AND store_id = $2
+	`, taxID, storeID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, NewNotFoundError("taxes", taxID)
+	}
+	if err != nil {
+		r.log(ctx).Error("Failed to get tax", zap.String("tax_id", taxID), zap.Error(err))
+		return nil, NewQueryError(err)
+	}
+	return tax, nil
+}
+
+// UpdateStoreTax changes one of a store's taxes and audits the fields that changed
+// A later product push that includes the tax overwrites these changes
+func (r *PostgresRepository) UpdateStoreTax(ctx context.Context, storeID, taxID string, update TaxUpdate) (*Tax, error) {
+	query := `UPDATE taxes SET updated_at = CURRENT_TIMESTAMP`
+	args := []interface{}{}
+	argCount := 1
+
+	set := func(column string, value interface{}) {
+		query += fmt.Sprintf(", %s = $%d", column, argCount)
+		args = append(args, value)
+		argCount++
+	}
+	if update.Name != nil {
+		set("name", *update.Name)
+	}
+	if update.Description != nil {
+		set("description", *update.Description)
+	}
+	if update.Rate != nil {
+		set("rate", *update.Rate)
+	}
+	if update.TaxType != nil {
+		set("tax_type", *update.TaxType)
+	}
+	if update.IsInclusive != nil {
+		set("is_inclusive", *update.IsInclusive)
+	}
+	if update.IsActive != nil {
+		set("is_active", *update.IsActive)
+	}
+
+	if len(args) == 0 {
+		return nil, NewValidationError("no fields to update")
+	}
+
+	// The old row is locked and read in the same statement, as in updateStore
+	query += fmt.Sprintf(`
+		FROM (SELECT * FROM taxes WHERE id = $%d AND store_id = $%d FOR UPDATE) old
+		WHERE taxes.id = old.id
+		RETURNING to_jsonb(old), to_jsonb(taxes)`, argCount, argCount+1)
+	args = append(args, taxID, storeID)
+
+	var before map[string]any
+	var afterJSON []byte
+	err := r.retry(ctx, AuditStoreTax, func() error {
+		return r.conn().QueryRow(ctx, query, args...).Scan(&before, &afterJSON)
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, NewNotFoundError("taxes", taxID)
+	}
+	if err != nil {
+		r.log(ctx).Error("Failed to update tax", zap.String("tax_id", taxID), zap.Error(err))
+		return nil, NewQueryError(err)
+	}
+
+	var after map[string]any
+	var tax Tax
+	if err := json.Unmarshal(afterJSON, &after); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(afterJSON, &tax); err != nil {
+		return nil, err
+	}
+
+	changedBefore, changedAfter := auditChanges(before, after)
+	r.recordAudit(ctx, AuditStoreTax, []string{storeID, taxID}, changedBefore, changedAfter)
+
+	r.log(ctx).Info("Updated tax",
+		zap.String("store_id", storeID),
+		zap.String("tax_id", taxID),
+		zap.Int("fields_updated", len(args)-2))
+
+	return &tax, nil
+}
+
+// ListStoreProductTaxes retrieves the active taxes applied to a store product
+func (r *PostgresRepository) ListStoreProductTaxes(ctx context.Context, storeID, storeProductID string) ([]AppliedTax, error) {
+	var exists bool
+	err := r.retry(ctx, "store product exists", func() error {
+		return r.reader().QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM store_products WHERE id = $1 AND store_id = $2)`,
+			storeProductID, storeID).Scan(&exists)
+	})
+	if err != nil {
+		return nil, NewQueryError(err)
+	}
+	if !exists {
+		return nil, NewNotFoundError("store_products", storeProductID)
+	}
+
+	taxes, err := queryRows(ctx, r, pgx.RowToStructByName[AppliedTax], `
+		SELECT spt.store_product_id, t.id, t.tax_id, t.name,
+		       COALESCE(spt.override_rate, t.rate) AS rate, t.tax_type, t.is_inclusive
+		FROM store_product_taxes spt
+		JOIN taxes t ON t.id = spt.tax_id
+		WHERE spt.store_product_id = $1 AND spt.is_active = true AND t.is_active = true
+		ORDER BY t.name
+	`, storeProductID)
+	if err != nil {
+		r.log(ctx).Error("Failed to query store product taxes", zap.String("store_product_id", storeProductID), zap.Error(err))
+		return nil, NewQueryError(err)
+	}
+	return taxes, nil
+}
+
+// AttachStoreProductTax applies one of a store's taxes to one of its products, optionally
+// at an override rate. Attaching an already attached tax replaces its override rate
+func (r *PostgresRepository) AttachStoreProductTax(ctx context.Context, storeID, storeProductID, taxID string, overrideRate *float64) (*AppliedTax, error) {
+	var applied *AppliedTax
+	err := r.retry(ctx, AuditStoreTax, func() (err error) {
+		rows, _ := r.conn().Query(ctx, `
+			WITH attached AS (
+				INSERT INTO store_product_taxes (store_id, store_product_id, tax_id, override_rate, is_active)
+				SELECT sp.store_id, sp.id, t.id, $4, true
+				FROM store_products sp
+				JOIN taxes t ON t.id = $3 AND t.store_id = sp.store_id
+				WHERE sp.id = $2 AND sp.store_id = $1
+				ON CONFLICT (store_id, store_product_id, tax_id) DO UPDATE SET
+					override_rate = EXCLUDED.override_rate,
+					is_active = true,
+					updated_at = CURRENT_TIMESTAMP
+				RETURNING store_product_id, tax_id, override_rate
+			)
+			SELECT a.store_product_id, t.id, t.tax_id, t.name,
+			       COALESCE(a.override_rate, t.rate) AS rate, t.tax_type, t.is_inclusive
+			FROM attached a
+			JOIN taxes t ON t.id = a.tax_id
+		`, storeID, storeProductID, taxID, overrideRate)
+		applied, err = pgx.CollectExactlyOneRow(rows, pgx.RowToAddrOfStructByName[AppliedTax])
+		return err
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, r.storeProductTaxNotFound(ctx, storeID, storeProductID, taxID)
+	}
+	if err != nil {
+		r.log(ctx).Error("Failed to attach tax",
+			zap.String("store_product_id", storeProductID),
+			zap.String("tax_id", taxID),
+			zap.Error(err))
+		return nil, NewQueryError(err)
+	}
+
+	r.recordAudit(ctx, AuditStoreTax, []string{storeID, storeProductID, taxID}, nil, map[string]any{
+		"attached":      taxID,
+		"override_rate": overrideRate,
+	})
+	return applied, nil
+}
+
+// DetachStoreProductTax stops applying a tax to a store product
+// A later product push that lists the tax for the product attaches it again
+func (r *PostgresRepository) DetachStoreProductTax(ctx context.Context, storeID, storeProductID, taxID string) error {
+	tag, err := r.exec(ctx, `
+		DELETE FROM store_product_taxes
+		WHERE store_id = $1 AND store_product_id = $2 AND tax_id = $3
+	`, storeID, storeProductID, taxID)
+	if err != nil {
+		r.log(ctx).Error("Failed to detach tax",
+			zap.String("store_product_id", storeProductID),
+			zap.String("tax_id", taxID),
+			zap.Error(err))
+		return NewQueryError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return r.storeProductTaxNotFound(ctx, storeID, storeProductID, taxID)
+	}
+
+	r.recordAudit(ctx, AuditStoreTax, []string{storeID, storeProductID, taxID}, map[string]any{"attached": taxID}, nil)
+	return nil
+}
+
+// storeProductTaxNotFound builds the not-found error for a store product and tax that
+// didn't match, naming the store product if it is the one missing
+func (r *PostgresRepository) storeProductTaxNotFound(ctx context.Context, storeID, storeProductID, taxID string) error {
+	var exists bool
+	err := r.retry(ctx, "store product exists", func() error {
+		return r.conn().QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM store_products WHERE id = $1 AND store_id = $2)`,
+			storeProductID, storeID).Scan(&exists)
+	})
+	if err != nil {
+		return NewQueryError(err)
+	}
+	if !exists {
+		return NewNotFoundError("store_products", storeProductID)
+	}
+	return NewNotFoundError("taxes", taxID)
+}
@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+// storeProductID returns the UUID of the store's listing with externalID
+func storeProductID(t *testing.T, r *PostgresRepository, storeUUID, externalID string) string {
+	t.Helper()
+	var id string
+	err := r.conn().QueryRow(context.Background(), `
+		SELECT id::text FROM store_products WHERE store_id = $1 AND external_id = $2
+	`, storeUUID, externalID).Scan(&id)
+	if err != nil {
+		t.Fatalf("failed to read listing %s: %v", externalID, err)
+	}
+	return id
+}
+
+// seedTax creates a tax for the store and returns its UUID
+func seedTax(t *testing.T, r *PostgresRepository, storeExternalID, storeUUID string, tax TaxInput) string {
+	t.Helper()
+	ctx := context.Background()
+	if err := r.UpsertTaxes(ctx, []TaxInput{tax}, storeExternalID); err != nil {
+		t.Fatalf("UpsertTaxes() error = %v", err)
+	}
+	taxes, err := r.ListStoreTaxes(ctx, storeUUID)
+	if err != nil {
+		t.Fatalf("ListStoreTaxes() error = %v", err)
+	}
+	for _, seeded := range taxes {
+		if seeded.TaxID == tax.TaxID {
+			return seeded.ID
+		}
+	}
+	t.Fatalf("ListStoreTaxes() = %+v, want tax %s", taxes, tax.TaxID)
+	return ""
+}
+
+func TestUpdateStoreTax(t *testing.T) {
+	r := testPostgres(t)
+	ctx := context.Background()
+	storeUUID := seedStore(t, r, "TEST-TAX")
+	otherUUID := seedStore(t, r, "TEST-TAX-OTHER")
+	taxID := seedTax(t, r, "TEST-TAX", storeUUID, TaxInput{ID: "GST5", Name: "GST 5%", TaxID: "GST5", Rate: 5, TaxType: TaxTypePercentage, IsActive: true})
+
+	name, rate := "GST 12%", 12.0
+	tax, err := r.UpdateStoreTax(ctx, storeUUID, taxID, TaxUpdate{Name: &name, Rate: &rate})
+	if err != nil {
+		t.Fatalf("UpdateStoreTax() error = %v", err)
+	}
+	if tax.Name != name || tax.Rate != rate || tax.TaxType != TaxTypePercentage || !tax.IsActive {
+		t.Errorf("UpdateStoreTax() = %+v, want the new name and rate with the rest unchanged", tax)
+	}
+
+	inactive := false
+	if tax, err = r.UpdateStoreTax(ctx, storeUUID, taxID, TaxUpdate{IsActive: &inactive}); err != nil || tax.IsActive {
+		t.Errorf("UpdateStoreTax() deactivating = %+v, %v, want inactive", tax, err)
+	}
+	if got, err := r.GetStoreTax(ctx, storeUUID, taxID); err != nil || got.Name != name || got.IsActive {
+		t.Errorf("GetStoreTax() = %+v, %v, want the updated, inactive tax", got, err)
+	}
+
+	tests := []struct {
+		name       string
+		storeID    string
+		update     TaxUpdate
+		wantStatus int
+	}{
+		{name: "no fields", storeID: storeUUID, wantStatus: http.StatusBadRequest},
+		{name: "other store", storeID: otherUUID, update: TaxUpdate{Name: &name}, wantStatus: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := r.UpdateStoreTax(ctx, tt.storeID, taxID, tt.update)
+			if got := GetStatusCode(err); got != tt.wantStatus {
+				t.Errorf("UpdateStoreTax() error = %v (status %d), want status %d", err, got, tt.wantStatus)
+			}
+		})
+	}
+	if _, err := r.GetStoreTax(ctx, otherUUID, taxID); GetStatusCode(err) != http.StatusNotFound {
+		t.Errorf("GetStoreTax() from another store error = %v, want not found", err)
+	}
+}
+
+func TestAttachAndDetachStoreProductTax(t *testing.T) {
+	r := testPostgres(t)
+	ctx := context.Background()
+	storeUUID := seedStore(t, r, "TEST-TAX")
+	otherUUID := seedStore(t, r, "TEST-TAX-OTHER")
+	seedProducts(t, r, "TEST-TAX", PushOptions{}, "TAX-1")
+	listing := storeProductID(t, r, storeUUID, "TAX-1")
+	taxID := seedTax(t, r, "TEST-TAX", storeUUID, TaxInput{ID: "GST5", Name: "GST 5%", TaxID: "GST5", Rate: 5, TaxType: TaxTypePercentage, IsActive: true})
+	otherTax := seedTax(t, r, "TEST-TAX-OTHER", otherUUID, TaxInput{ID: "VAT", Name: "VAT", TaxID: "VAT", Rate: 10, TaxType: TaxTypePercentage, IsActive: true})
+
+	applied, err := r.AttachStoreProductTax(ctx, storeUUID, listing, taxID, nil)
+	if err != nil {
+		t.Fatalf("AttachStoreProductTax() error = %v", err)
+	}
+	if applied.ID != taxID || applied.Rate != 5 {
+		t.Errorf("AttachStoreProductTax() = %+v, want tax %s at its own rate 5", applied, taxID)
+	}
+
+	// Attaching again replaces the override rate
+	override := 3.5
+	if applied, err = r.AttachStoreProductTax(ctx, storeUUID, listing, taxID, &override); err != nil || applied.Rate != override {
+		t.Errorf("AttachStoreProductTax() with an override = %+v, %v, want rate %v", applied, err, override)
+	}
+	taxes, err := r.ListStoreProductTaxes(ctx, storeUUID, listing)
+	if err != nil || len(taxes) != 1 || taxes[0].Rate != override {
+		t.Errorf("ListStoreProductTaxes() = %+v, %v, want the one tax at %v", taxes, err, override)
+	}
+
+	notFound := []struct {
+		name    string
+		storeID string
+		listing string
+		taxID   string
+	}{
+		{name: "other store's tax", storeID: storeUUID, listing: listing, taxID: otherTax},
+		{name: "other store's listing", storeID: otherUUID, listing: listing, taxID: otherTax},
+		{name: "unknown listing", storeID: storeUUID, listing: "00000000-0000-0000-0000-000000000001", taxID: taxID},
+	}
+	for _, tt := range notFound {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := r.AttachStoreProductTax(ctx, tt.storeID, tt.listing, tt.taxID, nil); GetStatusCode(err) != http.StatusNotFound {
+				t.Errorf("AttachStoreProductTax() error = %v, want not found", err)
+			}
+		})
+	}
+
+	if err := r.DetachStoreProductTax(ctx, storeUUID, listing, taxID); err != nil {
+		t.Fatalf("DetachStoreProductTax() error = %v", err)
+	}
+	if taxes, err := r.ListStoreProductTaxes(ctx, storeUUID, listing); err != nil || len(taxes) != 0 {
+		t.Errorf("ListStoreProductTaxes() after detaching = %+v, %v, want none", taxes, err)
+	}
+	if err := r.DetachStoreProductTax(ctx, storeUUID, listing, taxID); GetStatusCode(err) != http.StatusNotFound {
+		t.Errorf("DetachStoreProductTax() of a detached tax error = %v, want not found", err)
+	}
+}
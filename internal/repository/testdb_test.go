@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// testPostgres returns a repository bound to a transaction on the database named by
+// TEST_DATABASE_URL, rolled back when the test ends, so tests leave nothing behind. The
+// database must have grocery_superapp_schema.sql and the migrations applied. Tests
+// using it are skipped when TEST_DATABASE_URL is not set
+func testPostgres(t *testing.T) *PostgresRepository {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL not set, skipping Postgres test")
+	}
+
+	r, err := NewPostgresRepository(url, PoolConfig{}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewPostgresRepository() error = %v", err)
+	}
+	t.Cleanup(r.Close)
+
+	tx, err := r.conn().Begin(context.Background())
+	if err != nil {
+		t.Fatalf("failed to begin test transaction: %v", err)
+	}
+	t.Cleanup(func() { tx.Rollback(context.Background()) })
+
+	bound := *r
+	bound.tx = tx
+	bound.retryMaxAttempts = 1
+	return &bound
+}
+
+// testStore returns the details seedStore creates a store with
+func testStore(externalID string) StoreDetailsInput {
+	return StoreDetailsInput{
+		StoreID:  externalID,
+		Name:     "Test Store " + externalID,
+		Address:  AddressInput{Line1: "1 Test Road", City: "Bengaluru", State: "KA", PostalCode: "560001"},
+		Location: LocationInput{Lat: 12.97, Lng: 77.59},
+	}
+}
+
+// seedStore creates a store with externalID and returns its UUID
+func seedStore(t *testing.T, r *PostgresRepository, externalID string) string {
+	t.Helper()
+	ctx := context.Background()
+	if err := r.UpsertStore(ctx, testStore(externalID)); err != nil {
+		t.Fatalf("UpsertStore() error = %v", err)
+	}
+	id, err := r.StoreIDByExternalID(ctx, externalID)
+	if err != nil {
+		t.Fatalf("StoreIDByExternalID() error = %v", err)
+	}
+	return id
+}
+
+// testProductName returns a name for the product with externalID that the matching engine
+// won't match to another's
+func testProductName(externalID string) string {
+	return "Test " + uuid.NewSHA1(uuid.NameSpaceOID, []byte(externalID)).String()
+}
+
+// seedProducts pushes products named by testProductName to the store, each listed at
+// price 10 with stock 5, and returns the result
+func seedProducts(t *testing.T, r *PostgresRepository, storeExternalID string, opts PushOptions, externalIDs ...string) *UpsertResult {
+	t.Helper()
+	products := make([]ProductInput, len(externalIDs))
+	listings := make([]StoreProductInput, len(externalIDs))
+	for i, id := range externalIDs {
+		products[i] = ProductInput{ExternalProductID: id, Name: testProductName(id), BasePrice: 10, IsActive: true}
+		listings[i] = StoreProductInput{ExternalProductID: id, Price: 10, StockQuantity: 5, IsInStock: true}
+	}
+	result, err := r.UpsertProductsWithMatching(context.Background(), storeExternalID, products, nil, listings, opts)
+	if err != nil {
+		t.Fatalf("UpsertProductsWithMatching() error = %v", err)
+	}
+	return result
+}
+
+// listingAvailable reports whether the store's listing with externalID is available
+func listingAvailable(t *testing.T, r *PostgresRepository, storeUUID, externalID string) bool {
+	t.Helper()
+	var available bool
+	err := r.conn().QueryRow(context.Background(), `
+		SELECT is_available FROM store_products WHERE store_id = $1 AND external_id = $2
+	`, storeUUID, externalID).Scan(&available)
+	if err != nil {
+		t.Fatalf("failed to read listing %s: %v", externalID, err)
+	}
+	return available
+}
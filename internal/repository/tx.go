@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// conn is what statements run on: a pool, or the transaction of a repository bound by WithTx.
+// Begin on a transaction starts a savepoint, so methods that use transactions of their
+// own nest inside WithTx's
+type conn interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// conn returns what writes run on: the transaction inside WithTx, otherwise the primary pool,
+// which with a read replica notes each write so reads wait for the replica to replay it
+func (r *PostgresRepository) conn() conn {
+	if r.tx != nil {
+		return r.tx
+	}
+	if r.replica != nil {
+		return &primaryWrites{conn: r.pool, replica: r.replica}
+	}
+	return r.pool
+}
+
+// WithTx runs fn with a copy of the repository bound to a new transaction, committing it
+// when fn returns nil and rolling it back otherwise, so fn can compose several methods
+// atomically. Audit entries the methods record are committed or rolled back with them.
+// A transient failure reruns fn in a fresh transaction, so fn must not have effects outside
+// it; statements within it are not retried on their own. tx must not be used after fn returns
+func (r *PostgresRepository) WithTx(ctx context.Context, fn func(tx *PostgresRepository) error) error {
+	return r.retry(ctx, "transaction", func() error {
+		return pgx.BeginFunc(ctx, r.conn(), func(tx pgx.Tx) error {
+			bound := *r
+			bound.tx = tx
+			bound.retryMaxAttempts = 1
+			return fn(&bound)
+		})
+	})
+}
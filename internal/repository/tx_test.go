@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+// stubTx stands in for a transaction; only its identity is checked
+type stubTx struct {
+	pgx.Tx
+}
+
+func TestConnUsesTransactionWhenBound(t *testing.T) {
+	primary := &pgxpool.Pool{}
+	r := &PostgresRepository{pool: primary, logger: zap.NewNop()}
+
+	if r.conn() != primary {
+		t.Error("conn() should use the primary outside a transaction")
+	}
+
+	bound := *r
+	bound.tx = &stubTx{}
+	if bound.conn() != bound.tx {
+		t.Error("conn() should use the transaction inside WithTx")
+	}
+	if bound.reader() != bound.tx {
+		t.Error("reader() should use the transaction inside WithTx so reads see its writes")
+	}
+}
@@ -0,0 +1,316 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.uber.org/zap"
+)
+
+// Webhook delivery statuses
+const (
+	WebhookPending   = "pending"   // Not yet delivered; retried at next_attempt_at
+	WebhookSucceeded = "succeeded" // The subscriber answered with a 2xx
+	WebhookFailed    = "failed"    // Every attempt failed; not retried again
+)
+
+// WebhookSubscription is a row from the webhook_subscriptions table
+// A subscription with a StoreID is only sent that store's events
+type WebhookSubscription struct {
+	ID         string    `db:"id" json:"id"`
+	Name       string    `db:"name" json:"name"`
+	URL        string    `db:"url" json:"url"`
+	Secret     string    `db:"secret" json:"-"`
+	EventTypes []string  `db:"event_types" json:"event_types"`
+	StoreID    *string   `db:"store_id" json:"store_id"`
+	IsActive   bool      `db:"is_active" json:"is_active"`
+	CreatedBy  string    `db:"created_by" json:"created_by"`
+	CreatedAt  time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt  time.Time `db:"updated_at" json:"updated_at"`
+}
+
+const webhookSubscriptionColumns = `id, name, url, secret, event_types, store_id::text AS store_id,
+	is_active, created_by, created_at, updated_at`
+
+// WebhookSubscriptionInput describes a subscription to create
+type WebhookSubscriptionInput struct {
+	Name       string
+	URL        string
+	Secret     string
+	EventTypes []string
+	StoreID    *string
+}
+
+// WebhookSubscriptionUpdate changes some of a subscription's fields; nil fields are unchanged
+type WebhookSubscriptionUpdate struct {
+	Name       *string
+	URL        *string
+	Secret     *string
+	EventTypes []string
+	IsActive   *bool
+}
+
+// WebhookDelivery is a row from the webhook_deliveries table: one event sent, or to be
+// sent, to one subscription
+type WebhookDelivery struct {
+	ID             int64           `db:"id" json:"id"`
+	SubscriptionID string          `db:"subscription_id" json:"subscription_id"`
+	EventID        string          `db:"event_id" json:"event_id"`
+	EventType      string          `db:"event_type" json:"event_type"`
+	Payload        json.RawMessage `db:"payload" json:"payload"`
+	Status         string          `db:"status" json:"status"`
+	Attempts       int             `db:"attempts" json:"attempts"`
+	NextAttemptAt  *time.Time      `db:"next_attempt_at" json:"next_attempt_at"` // Pending deliveries only
+	LastAttemptAt  *time.Time      `db:"last_attempt_at" json:"last_attempt_at"`
+	ResponseStatus *int            `db:"response_status" json:"response_status"`
+	LastError      *string         `db:"last_error" json:"last_error"`
+	CreatedAt      time.Time       `db:"created_at" json:"created_at"`
+	DeliveredAt    *time.Time      `db:"delivered_at" json:"delivered_at"`
+}
+
+const webhookDeliveryColumns = `id, subscription_id::text AS subscription_id, event_id::text AS event_id,
+	event_type, payload,
+	status, attempts, CASE WHEN status = 'pending' THEN next_attempt_at END AS next_attempt_at,
+	last_attempt_at, response_status, last_error, created_at, delivered_at`
+
+// WebhookEvent is an event to queue for every subscription to its type
+// StoreID, if set, also queues it for subscriptions bound to that store
+type WebhookEvent struct {
+	ID      string
+	Type    string
+	StoreID string
+	Payload []byte
+}
+
+// WebhookDispatch is a claimed delivery with what sending it needs
+type WebhookDispatch struct {
+	DeliveryID int64  `db:"delivery_id"`
+	EventID    string `db:"event_id"`
+	EventType  string `db:"event_type"`
+	Payload    string `db:"payload"`
+	Attempts   int    `db:"attempts"` // Including this one
+	URL        string `db:"url"`
+	Secret     string `db:"secret"`
+}
+
+// WebhookAttempt is the outcome of sending a delivery
+// Status is WebhookPending to retry it at NextAttemptAt
+type WebhookAttempt struct {
+	Status         string
+	ResponseStatus *int
+	Error          *string
+	NextAttemptAt  *time.Time
+}
+
+// CreateWebhookSubscription creates an active subscription
+// Returns a not-found error if the store to bind it to doesn't exist
+func (r *PostgresRepository) CreateWebhookSubscription(ctx context.Context, input WebhookSubscriptionInput) (*WebhookSubscription, error) {
+	var subscription *WebhookSubscription
+	err := r.retry(ctx, "webhook_subscription_create", func() (err error) {
+		rows, _ := r.conn().Query(ctx, `
+			INSERT INTO webhook_subscriptions (name, url, secret, event_types, store_id, created_by)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			RETURNING `+webhookSubscriptionColumns,
+			input.Name, input.URL, input.Secret, input.EventTypes, input.StoreID, auditActorFrom(ctx).Actor)
+		subscription, err = pgx.CollectExactlyOneRow(rows, pgx.RowToAddrOfStructByName[WebhookSubscription])
+		return err
+	})
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23503" { // foreign_key_violation
+		return nil, NewNotFoundError("stores", *input.StoreID)
+	}
+	if err != nil {
+		r.log(ctx).Error("Failed to create webhook subscription", zap.String("name", input.Name), zap.Error(err))
+		return nil, NewQueryError(err)
+	}
+
+	r.log(ctx).Info("Created webhook subscription",
+		zap.String("subscription_id", subscription.ID),
+		zap.String("name", subscription.Name),
+		zap.Strings("event_types", subscription.EventTypes))
+	return subscription, nil
+}
+
+// ListWebhookSubscriptions retrieves subscriptions, newest first
+func (r *PostgresRepository) ListWebhookSubscriptions(ctx context.Context, pagination Pagination) ([]WebhookSubscription, error) {
+	subscriptions, err := queryRows(ctx, r, pgx.RowToStructByName[WebhookSubscription], `
+		SELECT `+webhookSubscriptionColumns+`
+		FROM webhook_subscriptions
+		ORDER BY created_at DESC, id DESC
+		LIMIT $1 OFFSET $2
+	`, pagination.Limit, pagination.Offset)
+	if err != nil {
+		r.log(ctx).Error("Failed to list webhook subscriptions", zap.Error(err))
+		return nil, NewQueryError(err)
+	}
+	return subscriptions, nil
+}
+
+// GetWebhookSubscription retrieves a subscription
+func (r *PostgresRepository) GetWebhookSubscription(ctx context.Context, id string) (*WebhookSubscription, error) {
+	subscription, err := queryRow(ctx, r, pgx.RowToAddrOfStructByName[WebhookSubscription], `
+		SELECT `+webhookSubscriptionColumns+` FROM webhook_subscriptions WHERE id = $1
+	`, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, NewNotFoundError("webhook_subscriptions", id)
+	}
+	if err != nil {
+		r.log(ctx).Error("Failed to get webhook subscription", zap.String("subscription_id", id), zap.Error(err))
+		return nil, NewQueryError(err)
+	}
+	return subscription, nil
+}
+
+// UpdateWebhookSubscription changes a subscription's fields set in update
+// Deliveries already queued are sent to the URL and signed with the secret current when
+// they are sent
+func (r *PostgresRepository) UpdateWebhookSubscription(ctx context.Context, id string, update WebhookSubscriptionUpdate) (*WebhookSubscription, error) {
+	var subscription *WebhookSubscription
+	err := r.retry(ctx, "webhook_subscription_update", func() (err error) {
+		rows, _ := r.conn().Query(ctx, `
+			UPDATE webhook_subscriptions SET
+				name = COALESCE($2, name),
+				url = COALESCE($3, url),
+				secret = COALESCE($4, secret),
+				event_types = COALESCE($5, event_types),
+				is_active = COALESCE($6, is_active),
+				updated_at = CURRENT_TIMESTAMP
+			WHERE id = $1
+			RETURNING `+webhookSubscriptionColumns,
+			id, update.Name, update.URL, update.Secret, update.EventTypes, update.IsActive)
+		subscription, err = pgx.CollectExactlyOneRow(rows, pgx.RowToAddrOfStructByName[WebhookSubscription])
+		return err
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, NewNotFoundError("webhook_subscriptions", id)
+	}
+	if err != nil {
+		r.log(ctx).Error("Failed to update webhook subscription", zap.String("subscription_id", id), zap.Error(err))
+		return nil, NewQueryError(err)
+	}
+
+	r.log(ctx).Info("Updated webhook subscription", zap.String("subscription_id", id), zap.Bool("is_active", subscription.IsActive))
+	return subscription, nil
+}
+
+// DeleteWebhookSubscription deletes a subscription along with its delivery log
+func (r *PostgresRepository) DeleteWebhookSubscription(ctx context.Context, id string) error {
+	tag, err := r.exec(ctx, `DELETE FROM webhook_subscriptions WHERE id = $1`, id)
+	if err != nil {
+		r.log(ctx).Error("Failed to delete webhook subscription", zap.String("subscription_id", id), zap.Error(err))
+		return NewQueryError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return NewNotFoundError("webhook_subscriptions", id)
+	}
+
+	r.log(ctx).Info("Deleted webhook subscription", zap.String("subscription_id", id))
+	return nil
+}
+
+// EnqueueWebhookEvent queues a delivery of event for each active subscription to its type,
+// returning how many were queued
+func (r *PostgresRepository) EnqueueWebhookEvent(ctx context.Context, event WebhookEvent) (int64, error) {
+	var storeID *string
+	if event.StoreID != "" {
+		storeID = &event.StoreID
+	}
+
+	tag, err := r.exec(ctx, `
+		INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, payload)
+		SELECT id, $1, $2, $3
+		FROM webhook_subscriptions
+		WHERE is_active AND $2 = ANY(event_types)
+		  AND (store_id IS NULL OR store_id = $4::uuid)
+		ON CONFLICT (subscription_id, event_id) DO NOTHING
+	`, event.ID, event.Type, event.Payload, storeID)
+	if err != nil {
+		return 0, NewQueryError(err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// ClaimWebhookDeliveries claims up to limit pending deliveries that are due, of active
+// subscriptions, oldest first, counting an attempt for each. A claimed delivery isn't due
+// again for lease, so other instances leave it alone while it is sent; one whose outcome
+// is never recorded is retried then
+func (r *PostgresRepository) ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]WebhookDispatch, error) {
+	var dispatches []WebhookDispatch
+	err := r.retry(ctx, "webhook_claim", func() (err error) {
+		rows, _ := r.conn().Query(ctx, `
+			WITH due AS (
+				SELECT d.id
+				FROM webhook_deliveries d
+				JOIN webhook_subscriptions s ON s.id = d.subscription_id AND s.is_active
+				WHERE d.status = 'pending' AND d.next_attempt_at <= CURRENT_TIMESTAMP
+				ORDER BY d.next_attempt_at
+				LIMIT $1
+				FOR UPDATE OF d SKIP LOCKED
+			)
+			UPDATE webhook_deliveries d SET
+				attempts = d.attempts + 1,
+				last_attempt_at = CURRENT_TIMESTAMP,
+				next_attempt_at = CURRENT_TIMESTAMP + make_interval(secs => $2)
+			FROM due, webhook_subscriptions s
+			WHERE d.id = due.id AND s.id = d.subscription_id
+			RETURNING d.id AS delivery_id, d.event_id::text AS event_id, d.event_type,
+			          d.payload::text AS payload, d.attempts, s.url, s.secret
+		`, limit, lease.Seconds())
+		dispatches, err = pgx.CollectRows(rows, pgx.RowToStructByName[WebhookDispatch])
+		return err
+	})
+	if err != nil {
+		return nil, NewQueryError(err)
+	}
+	return dispatches, nil
+}
+
+// RecordWebhookAttempt records the outcome of sending a claimed delivery
+func (r *PostgresRepository) RecordWebhookAttempt(ctx context.Context, deliveryID int64, attempt WebhookAttempt) error {
+	_, err := r.exec(ctx, `
+		UPDATE webhook_deliveries SET
+			status = $2,
+			response_status = $3,
+			last_error = $4,
+			next_attempt_at = COALESCE($5, next_attempt_at),
+			delivered_at = CASE WHEN $2 = 'succeeded' THEN CURRENT_TIMESTAMP END
+		WHERE id = $1
+	`, deliveryID, attempt.Status, attempt.ResponseStatus, attempt.Error, attempt.NextAttemptAt)
+	if err != nil {
+		return NewQueryError(err)
+	}
+	return nil
+}
+
+// ListWebhookDeliveries retrieves a subscription's deliveries, newest first; only those
+// with the given status unless it is empty
+func (r *PostgresRepository) ListWebhookDeliveries(ctx context.Context, subscriptionID, status string, pagination Pagination) ([]WebhookDelivery, error) {
+	args := []any{subscriptionID, pagination.Limit, pagination.Offset}
+	query := `SELECT ` + webhookDeliveryColumns + ` FROM webhook_deliveries WHERE subscription_id = $1`
+	if status != "" {
+		query += ` AND status = $4`
+		args = append(args, status)
+	}
+	query += ` ORDER BY created_at DESC, id DESC LIMIT $2 OFFSET $3`
+
+	deliveries, err := queryRows(ctx, r, pgx.RowToStructByName[WebhookDelivery], query, args...)
+	if err != nil {
+		r.log(ctx).Error("Failed to list webhook deliveries", zap.String("subscription_id", subscriptionID), zap.Error(err))
+		return nil, NewQueryError(err)
+	}
+	return deliveries, nil
+}
+
+// PruneWebhookDeliveries deletes succeeded and failed deliveries created before cutoff,
+// returning how many were deleted
+func (r *PostgresRepository) PruneWebhookDeliveries(ctx context.Context, cutoff time.Time) (int64, error) {
+	tag, err := r.exec(ctx, `DELETE FROM webhook_deliveries WHERE status <> 'pending' AND created_at < $1`, cutoff)
+	if err != nil {
+		return 0, NewQueryError(err)
+	}
+	return tag.RowsAffected(), nil
+}
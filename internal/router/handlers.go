@@ -2,119 +2,186 @@ package router
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/yourusername/supabase-redis-middleware/internal/cache"
+	"github.com/yourusername/supabase-redis-middleware/internal/i18n"
 	"github.com/yourusername/supabase-redis-middleware/internal/repository"
 	"go.uber.org/zap"
 )
 
-// HealthCheckHandler creates a handler for the /health endpoint
-// It checks connectivity to Redis and Supabase
-func HealthCheckHandler(cacheService cache.CacheService, repo repository.SupabaseRepository, logger *zap.Logger) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
-		defer cancel()
+// Dependency is a backend checked by the readiness probe. A required dependency failing
+// makes the service unready; an optional one only degrades it
+type Dependency struct {
+	Name     string
+	Required bool
+	Timeout  time.Duration
+	Check    func(ctx context.Context) error
+}
 
-		health := gin.H{
-			"status": "healthy",
-			"timestamp": time.Now().Format(time.RFC3339),
-			"dependencies": gin.H{},
-		}
+// defaultReadyTimeout bounds the check of a dependency without a timeout of its own
+const defaultReadyTimeout = 2 * time.Second
 
-		allHealthy := true
+// LivenessHandler creates a handler for the /healthz endpoint
+// It answers as long as the process serves requests and checks no dependency, so an
+// outage of one never gets the process restarted
+func LivenessHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"status":    "alive",
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+	}
+}
 
-		// Check Redis connectivity
-		redisStatus := checkRedis(ctx, cacheService, logger)
-		health["dependencies"].(gin.H)["redis"] = redisStatus
-		if redisStatus["status"] != "healthy" {
-			allHealthy = false
+// ReadinessHandler creates a handler for the /readyz endpoint
+// It checks the dependencies concurrently, each within its own timeout. A failing
+// required dependency answers 503 with status "unhealthy"; failing optional ones answer
+// 200 with status "degraded"
+func ReadinessHandler(dependencies []Dependency, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		results := make([]gin.H, len(dependencies))
+		var wg sync.WaitGroup
+		for i, dep := range dependencies {
+			wg.Add(1)
+			go func(i int, dep Dependency) {
+				defer wg.Done()
+				results[i] = checkDependency(c.Request.Context(), dep, logger)
+			}(i, dep)
 		}
-
-		// Check Supabase connectivity
-		supabaseStatus := checkSupabase(ctx, repo, logger)
-		health["dependencies"].(gin.H)["supabase"] = supabaseStatus
-		if supabaseStatus["status"] != "healthy" {
-			allHealthy = false
+		wg.Wait()
+
+		status, statusCode := "healthy", http.StatusOK
+		statuses := gin.H{}
+		for i, dep := range dependencies {
+			statuses[dep.Name] = results[i]
+			if results[i]["status"] == "healthy" {
+				continue
+			}
+			if dep.Required {
+				status, statusCode = "unhealthy", http.StatusServiceUnavailable
+			} else if status == "healthy" {
+				status = "degraded"
+			}
 		}
 
-		// Set overall status
-		if !allHealthy {
-			health["status"] = "degraded"
-		}
+		c.JSON(statusCode, gin.H{
+			"status":       status,
+			"timestamp":    time.Now().Format(time.RFC3339),
+			"dependencies": statuses,
+		})
+	}
+}
 
-		// Return appropriate status code
-		statusCode := http.StatusOK
-		if !allHealthy {
-			statusCode = http.StatusServiceUnavailable
+// checkDependency runs one dependency's check within its timeout
+func checkDependency(ctx context.Context, dep Dependency, logger *zap.Logger) gin.H {
+	timeout := dep.Timeout
+	if timeout <= 0 {
+		timeout = defaultReadyTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := dep.Check(ctx)
+	result := gin.H{
+		"status":     "healthy",
+		"required":   dep.Required,
+		"latency_ms": time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		// The cause is logged; probes are public, so the response only names the failure
+		result["status"] = "unhealthy"
+		result["error"] = "Check failed"
+		if ctx.Err() == context.DeadlineExceeded {
+			result["error"] = fmt.Sprintf("No response within %s", timeout)
 		}
+		logger.Warn("Readiness check failed",
+			zap.String("dependency", dep.Name),
+			zap.Bool("required", dep.Required),
+			zap.Error(err))
+	}
+	return result
+}
 
-		c.JSON(statusCode, health)
+// readinessDependencies lists the dependencies of deps the readiness probe checks; each
+// is required unless deps.OptionalDependencies names it
+func readinessDependencies(deps HandlerDependencies) []Dependency {
+	optional := map[string]bool{}
+	for _, name := range deps.OptionalDependencies {
+		optional[name] = true
 	}
+	dependency := func(name string, check func(ctx context.Context) error) Dependency {
+		return Dependency{Name: name, Required: !optional[name], Timeout: deps.ReadyTimeouts[name], Check: check}
+	}
+
+	var dependencies []Dependency
+	if deps.Cache != nil {
+		dependencies = append(dependencies, dependency("redis", func(ctx context.Context) error {
+			return checkRedis(ctx, deps.Cache)
+		}))
+	}
+	if deps.PgRepo != nil {
+		dependencies = append(dependencies, dependency("postgres", func(ctx context.Context) error {
+			return checkPostgres(ctx, deps.PgRepo)
+		}))
+	}
+	if deps.Repository != nil {
+		dependencies = append(dependencies, dependency("supabase", func(ctx context.Context) error {
+			return checkSupabase(ctx, deps.Repository)
+		}))
+	}
+	return dependencies
 }
 
 // checkRedis verifies Redis connectivity
-func checkRedis(ctx context.Context, cacheService cache.CacheService, logger *zap.Logger) gin.H {
+func checkRedis(ctx context.Context, cacheService cache.CacheService) error {
 	testKey := "health:check:redis"
 	testValue := []byte("ping")
 
 	// Try to set a value
-	err := cacheService.Set(ctx, testKey, testValue, 10*time.Second)
-	if err != nil {
-		logger.Warn("Redis health check failed on SET", zap.Error(err))
-		return gin.H{
-			"status": "unhealthy",
-			"error":  "Failed to write to Redis",
-		}
+	if err := cacheService.Set(ctx, testKey, testValue, 10*time.Second); err != nil {
+		return fmt.Errorf("failed to write to Redis: %w", err)
 	}
 
 	// Try to get the value
-	_, err = cacheService.Get(ctx, testKey)
-	if err != nil {
-		logger.Warn("Redis health check failed on GET", zap.Error(err))
-		return gin.H{
-			"status": "unhealthy",
-			"error":  "Failed to read from Redis",
-		}
+	if _, err := cacheService.Get(ctx, testKey); err != nil {
+		return fmt.Errorf("failed to read from Redis: %w", err)
 	}
 
 	// Clean up
 	_ = cacheService.Delete(ctx, testKey)
+	return nil
+}
 
-	return gin.H{
-		"status": "healthy",
+// checkPostgres verifies the Postgres pool can reach the database
+func checkPostgres(ctx context.Context, pgRepo *repository.PostgresRepository) error {
+	if err := pgRepo.Ping(ctx); err != nil {
+		return fmt.Errorf("failed to ping Postgres: %w", err)
 	}
+	return nil
 }
 
 // checkSupabase verifies Supabase connectivity
-func checkSupabase(ctx context.Context, repo repository.SupabaseRepository, logger *zap.Logger) gin.H {
+func checkSupabase(ctx context.Context, repo repository.SupabaseRepository) error {
 	// Try a simple query to verify connectivity
 	// We'll query with a limit of 1 to minimize load
 	_, err := repo.Query(ctx, "health_check", map[string]interface{}{}, repository.Pagination{Limit: 1})
-	
-	if err != nil {
-		// Check if it's a "table not found" error, which actually means connection is working
-		// but the health_check table doesn't exist (which is expected)
-		errMsg := err.Error()
-		if contains(errMsg, "relation") && contains(errMsg, "does not exist") {
-			// Connection is working, table just doesn't exist
-			return gin.H{
-				"status": "healthy",
-			}
-		}
-
-		logger.Warn("Supabase health check failed", zap.Error(err))
-		return gin.H{
-			"status": "unhealthy",
-			"error":  "Failed to connect to Supabase",
-		}
+	if err == nil {
+		return nil
 	}
 
-	return gin.H{
-		"status": "healthy",
+	// Check if it's a "table not found" error, which actually means connection is working
+	// but the health_check table doesn't exist (which is expected)
+	errMsg := err.Error()
+	if contains(errMsg, "relation") && contains(errMsg, "does not exist") {
+		return nil
 	}
+	return fmt.Errorf("failed to connect to Supabase: %w", err)
 }
 
 // NotFoundHandler returns a handler for 404 errors
@@ -123,8 +190,9 @@ func NotFoundHandler() gin.HandlerFunc {
 		c.JSON(http.StatusNotFound, gin.H{
 			"status": "error",
 			"error": gin.H{
-				"code":    "NOT_FOUND",
-				"message": "The requested endpoint does not exist",
+				"code":       "NOT_FOUND",
+				"message":    i18n.T(c.Request.Context(), "NOT_FOUND.endpoint", "The requested endpoint does not exist", nil),
+				"request_id": repository.RequestIDFrom(c.Request.Context()),
 			},
 		})
 	}
@@ -137,8 +205,9 @@ func PlaceholderHandler(domain, operation string) gin.HandlerFunc {
 		c.JSON(http.StatusNotImplemented, gin.H{
 			"status": "error",
 			"error": gin.H{
-				"code":    "NOT_IMPLEMENTED",
-				"message": "This endpoint is not yet implemented",
+				"code":       "NOT_IMPLEMENTED",
+				"message":    i18n.T(c.Request.Context(), "NOT_IMPLEMENTED", "This endpoint is not yet implemented", nil),
+				"request_id": repository.RequestIDFrom(c.Request.Context()),
 			},
 			"metadata": gin.H{
 				"domain":    domain,
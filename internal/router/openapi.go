@@ -0,0 +1,479 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/supabase-redis-middleware/internal/auth"
+	"github.com/yourusername/supabase-redis-middleware/internal/graphql"
+	"github.com/yourusername/supabase-redis-middleware/internal/handlers"
+	"github.com/yourusername/supabase-redis-middleware/internal/jobs"
+	"github.com/yourusername/supabase-redis-middleware/internal/openapi"
+	"github.com/yourusername/supabase-redis-middleware/internal/repository"
+)
+
+// apiInfo titles the generated OpenAPI document
+var apiInfo = openapi.Info{
+	Title:       "GOL Backend API",
+	Version:     "1.0.0",
+	Description: "Store, catalog and ERP integration API. Reads are public; writes need an API key or Supabase access token holding the documented scope.",
+}
+
+// Query parameters shared by several routes
+var (
+	paginationParams = []openapi.Param{
+		openapi.Query("limit", "integer", "Page size (default 20, max 100)"),
+		openapi.Query("offset", "integer", "Rows to skip"),
+	}
+	cursorParams = []openapi.Param{
+		openapi.Query("cursor", "string", "next_cursor of the previous page; replaces offset"),
+		openapi.Query("include_total", "boolean", "Report the total count in metadata.total_count"),
+	}
+	listingParams = []openapi.Param{
+		openapi.Query("category_id", "string", "Only listings in this category"),
+		openapi.Query("brand_id", "string", "Only listings of this brand"),
+		openapi.Query("search", "string", "Name substring"),
+		openapi.Query("in_stock", "boolean", "Only listings in stock"),
+		openapi.Query("min_price", "number", "Lowest price"),
+		openapi.Query("max_price", "number", "Highest price"),
+		openapi.Query("fields", "string", "Comma separated keys to keep in each item, e.g. id,name,price"),
+	}
+	pointParams = []openapi.Param{
+		requiredParam(openapi.Query("lat", "number", "Latitude")),
+		requiredParam(openapi.Query("lng", "number", "Longitude")),
+	}
+	storeIDParam     = openapi.Query("store_id", "string", "Store ID")
+	storeTypeParam   = openapi.Query("store_type", "string", "Only stores of this type, e.g. supermarket or pharmacy")
+	dryRunParam      = openapi.Query("dry_run", "boolean", "Report what would change without changing it")
+	idempotencyParam = openapi.Header("Idempotency-Key", "Replays the stored response when the same write is retried")
+	signatureParam   = openapi.Header(auth.SignatureHeader, "Hex HMAC-SHA256 of the body under the store's signing secret, when the store has one")
+)
+
+// routeDocs describes the API routes for the OpenAPI document, keyed by method and path
+var routeDocs = map[string]openapi.Operation{
+	// Stores
+	"GET /api/v1/stores/nearby": {
+		Summary: "Find stores near a point", Tag: "Stores",
+		Params:   params(pointParams, []openapi.Param{openapi.Query("radius_km", "number", "Search radius (default 5, max 50)"), storeTypeParam, paginationParams[0]}),
+		Response: []repository.NearbyStore{},
+	},
+	"GET /api/v1/stores/serving": {
+		Summary: "Find stores delivering to a point", Tag: "Stores",
+		Params:   params(pointParams, []openapi.Param{storeTypeParam, paginationParams[0]}),
+		Response: []repository.ServingStore{},
+	},
+	"GET /api/v1/stores/:id": {
+		Summary: "Get a store", Tag: "Stores",
+		Response: repository.StoreDetail{},
+	},
+	"PUT /api/v1/stores/:id": {
+		Summary: "Update a store's details", Tag: "Stores", Scope: repository.ScopeWriteStores,
+		Params:  []openapi.Param{idempotencyParam},
+		Request: repository.UpdateStoreDetailsInput{},
+	},
+	"GET /api/v1/stores/:id/products": {
+		Summary: "List a store's products", Tag: "Stores",
+		Params:   params(listingParams, paginationParams, cursorParams),
+		Response: []repository.StoreProductListing{},
+	},
+	"GET /api/v1/stores/:id/status": {
+		Summary: "Get a store's status", Tag: "Stores",
+		Response: repository.StoreStatus{},
+	},
+	"GET /api/v1/stores/:id/events": {
+		Summary: "Stream a store's status changes", Tag: "Stores",
+		Params: []openapi.Param{
+			openapi.Header("Last-Event-ID", "Resume after this event, sending those still in the store's history first"),
+			openapi.Query("last_event_id", "string", "Last-Event-ID, for clients that can't set headers"),
+		},
+		Description: "Server-Sent Events (Accept: text/event-stream) of the store's status, delivery and availability changes. Only served when realtime is enabled.",
+	},
+	"PUT /api/v1/stores/:id/status": {
+		Summary: "Open, close, activate or deactivate a store", Tag: "Stores", Scope: repository.ScopeWriteStores,
+		Params:  []openapi.Param{idempotencyParam},
+		Request: handlers.StoreStatusRequest{},
+	},
+	"GET /api/v1/stores/:id/hours": {
+		Summary: "Get a store's weekly hours and holidays", Tag: "Store hours",
+		Response: repository.StoreSchedule{},
+	},
+	"PUT /api/v1/stores/:id/hours": {
+		Summary: "Replace a store's weekly hours", Tag: "Store hours", Scope: repository.ScopeWriteStores,
+		Params:   []openapi.Param{idempotencyParam},
+		Request:  handlers.StoreHoursRequest{},
+		Response: repository.StoreSchedule{},
+	},
+	"PUT /api/v1/stores/:id/hours/holidays/:date": {
+		Summary: "Set a store's hours for a date", Tag: "Store hours", Scope: repository.ScopeWriteStores,
+		Params:   []openapi.Param{idempotencyParam},
+		Request:  handlers.StoreHolidayRequest{},
+		Response: repository.StoreHoliday{},
+	},
+	"DELETE /api/v1/stores/:id/hours/holidays/:date": {
+		Summary: "Remove a store's hours for a date", Tag: "Store hours", Scope: repository.ScopeWriteStores,
+	},
+	"GET /api/v1/stores/:id/delivery-zones": {
+		Summary: "List a store's delivery zones", Tag: "Delivery",
+		Response: []repository.DeliveryZone{},
+	},
+	"POST /api/v1/stores/:id/delivery-zones": {
+		Summary: "Add a delivery zone", Tag: "Delivery", Scope: repository.ScopeWriteStores,
+		Params:   []openapi.Param{idempotencyParam},
+		Request:  handlers.DeliveryZoneRequest{},
+		Response: repository.DeliveryZone{},
+		Status:   http.StatusCreated,
+	},
+	"DELETE /api/v1/stores/:id/delivery-zones/:zoneId": {
+		Summary: "Remove a delivery zone", Tag: "Delivery", Scope: repository.ScopeWriteStores,
+	},
+	"GET /api/v1/stores/:id/delivers-to": {
+		Summary: "Check whether a store delivers to a point", Tag: "Delivery",
+		Params:   pointParams,
+		Response: repository.DeliveryCoverage{},
+	},
+	"GET /api/v1/stores/:id/products/export": {
+		Summary: "Export a store's catalog", Tag: "Stores",
+		Description: "Streams every available listing with its prices, stock and taxes, one per row. " +
+			"A CSV export can be imported again with POST /api/v1/products/import/csv.",
+		Params: []openapi.Param{
+			openapi.Query("format", "string", "csv (default) or ndjson"),
+		},
+		Response: repository.CatalogExportRow{},
+	},
+	"GET /api/v1/stores/:id/stock-report": {
+		Summary: "Report a store's low and out of stock products", Tag: "Stock", Scope: repository.ScopeWriteStock,
+		Params: []openapi.Param{
+			openapi.Query("threshold", "integer", "Low stock threshold (default: each product's own)"),
+			openapi.Query("format", "string", "json (default) or csv"),
+		},
+		Response: repository.StockReport{},
+	},
+	"GET /api/v1/stores/:id/taxes": {
+		Summary: "List a store's taxes", Tag: "Taxes",
+		Response: []repository.Tax{},
+	},
+	"GET /api/v1/stores/:id/taxes/:taxId": {
+		Summary: "Get a store's tax", Tag: "Taxes",
+		Response: repository.Tax{},
+	},
+	"PUT /api/v1/stores/:id/taxes/:taxId": {
+		Summary: "Update a store's tax", Tag: "Taxes", Scope: repository.ScopeWriteStores,
+		Params:   []openapi.Param{idempotencyParam},
+		Request:  handlers.UpdateTaxRequest{},
+		Response: repository.Tax{},
+	},
+	"DELETE /api/v1/stores/:id/taxes/:taxId": {
+		Summary: "Deactivate a store's tax", Tag: "Taxes", Scope: repository.ScopeWriteStores,
+		Response: repository.Tax{},
+	},
+	"GET /api/v1/stores/:id/listings/:storeProductId/taxes": {
+		Summary: "List the taxes applied to a store product", Tag: "Taxes",
+		Response: []repository.AppliedTax{},
+	},
+	"PUT /api/v1/stores/:id/listings/:storeProductId/taxes/:taxId": {
+		Summary: "Apply a tax to a store product", Tag: "Taxes", Scope: repository.ScopeWriteStores,
+		Params:   []openapi.Param{idempotencyParam},
+		Request:  handlers.AttachTaxRequest{},
+		Response: repository.AppliedTax{},
+	},
+	"DELETE /api/v1/stores/:id/listings/:storeProductId/taxes/:taxId": {
+		Summary: "Remove a tax from a store product", Tag: "Taxes", Scope: repository.ScopeWriteStores,
+	},
+	"DELETE /api/v1/stores/:id/products/:productId": {
+		Summary: "Remove a product from a store", Tag: "Stock", Scope: repository.ScopeWriteStock,
+		Description: "productId is the product's ERP ID.",
+		Params:      []openapi.Param{openapi.Query("deactivate_product", "boolean", "Also deactivate the catalog product when no other store has it available")},
+		Response:    repository.StoreProductRemoval{},
+	},
+	"PUT /api/v1/stores/:id/products/:productId/availability": {
+		Summary: "Set a store product's availability", Tag: "Stock", Scope: repository.ScopeWriteStock,
+		Description: "productId is the product's ERP ID.",
+		Params:      []openapi.Param{idempotencyParam},
+		Request:     handlers.AvailabilityRequest{},
+	},
+
+	// Products
+	"GET /api/v1/products/lookup": {
+		Summary: "Look a product up by barcode, SKU or EAN", Tag: "Products",
+		Description: "Pass exactly one of barcode, sku and ean.",
+		Params: []openapi.Param{
+			openapi.Query("barcode", "string", "Barcode"),
+			openapi.Query("sku", "string", "SKU"),
+			openapi.Query("ean", "string", "EAN"),
+		},
+		Response: []repository.ProductLookup{},
+	},
+	"GET /api/v1/products/:id/prices": {
+		Summary: "Compare a product's prices across stores", Tag: "Products",
+		Description: "lat and lng, passed together, add each store's distance and delivery coverage.",
+		Params: []openapi.Param{
+			openapi.Query("lat", "number", "Latitude"),
+			openapi.Query("lng", "number", "Longitude"),
+		},
+		Response: repository.PriceComparison{},
+	},
+	"GET /api/v1/products/:id/images/:imageId/signed-url": {
+		Summary: "Get a signed URL for a product image", Tag: "Products",
+		Response: repository.ProductImage{},
+	},
+	"POST /api/v1/products/push": {
+		Summary: "Push an ERP catalog", Tag: "ERP", Scope: repository.ScopePushProducts,
+		Description: "Products are matched to the catalog and upserted with their variations, listings, categories and taxes. The body may be gzipped. Sent as application/x-ndjson, the body is a line of store details followed by a line per product, written in batches as it is read.",
+		Params: []openapi.Param{
+			openapi.Query("partial", "boolean", "Skip and report invalid products and products that fail to save, committing the rest"),
+			dryRunParam, idempotencyParam, signatureParam,
+		},
+		Request: handlers.PushProductsRequest{},
+	},
+	"POST /api/v1/products/import/csv": {
+		Summary: "Import a store's catalog from CSV", Tag: "ERP", Scope: repository.ScopePushProducts,
+		Description: "The file is a multipart/form-data part named file, one product per row, pushed as a push without categories, taxes or variations. A template part naming a configured column mapping, or a mapping part with one as JSON, may precede it. Invalid rows are refused with INVALID_CSV unless partial is set.",
+		Params: []openapi.Param{
+			requiredParam(openapi.Query("store_id", "string", "ERP ID of the store; it must already exist")),
+			openapi.Query("partial", "boolean", "Skip and report invalid rows and products that fail to save, committing the rest"),
+			openapi.Query("sync_mode", "string", "full deactivates the store's listings missing from the file; incremental, the default, leaves them"),
+			dryRunParam, idempotencyParam, signatureParam,
+		},
+	},
+	"POST /api/v1/products/stock": {
+		Summary: "Update stock levels", Tag: "ERP", Scope: repository.ScopeWriteStock,
+		Params:  []openapi.Param{dryRunParam, idempotencyParam, signatureParam},
+		Request: handlers.UpdateStockRequest{},
+	},
+	"POST /api/v1/products/:id/images/upload": {
+		Summary: "Upload a product image", Tag: "Products", Scope: repository.ScopePushProducts,
+		Description: "The image is a multipart/form-data part named file.",
+		Params:      []openapi.Param{openapi.Query("primary", "boolean", "Make the image the product's primary image")},
+		Response:    repository.ProductImage{},
+		Status:      http.StatusCreated,
+	},
+	"PATCH /api/v1/products/:external_id": {
+		Summary: "Update a pushed product", Tag: "ERP", Scope: repository.ScopePushProducts,
+		Params:   []openapi.Param{requiredParam(openapi.Query("store_id", "string", "ERP ID of the store the product was pushed to")), idempotencyParam},
+		Request:  handlers.UpdateProductRequest{},
+		Response: repository.StoreListing{},
+	},
+
+	// Catalog
+	"GET /api/v1/categories": {
+		Summary: "Get the category tree", Tag: "Catalog",
+		Params:   []openapi.Param{openapi.Query("store_id", "string", "Count only this store's listings")},
+		Response: []repository.CategoryNode{},
+	},
+	"GET /api/v1/home": {
+		Summary: "Compose the home screen for a point", Tag: "Catalog",
+		Params: pointParams,
+	},
+	"GET /api/v1/graphql": {
+		Summary: "Run a GraphQL query", Tag: "Catalog",
+		Description: "Answers a GraphQL response, not the usual payload; its schema is served at /api/v1/graphql/schema.",
+		Params: []openapi.Param{
+			requiredParam(openapi.Query("query", "string", "The query document")),
+			openapi.Query("operationName", "string", "The operation to run, if the document has several"),
+			openapi.Query("variables", "string", "The variables, as a JSON object"),
+		},
+	},
+	"POST /api/v1/graphql": {
+		Summary: "Run a GraphQL query", Tag: "Catalog",
+		Description: "Answers a GraphQL response, not the usual payload; its schema is served at /api/v1/graphql/schema.",
+		Request:     graphql.Request{},
+	},
+	"GET /ws/stores/:id": {
+		Summary: "Stream a store's listing changes", Tag: "Catalog",
+		Description: "Upgrades to a WebSocket sending a JSON event each time a stock update or push changes the store's listings. Only served when realtime is enabled.",
+	},
+	"GET /api/v1/graphql/schema": {
+		Summary: "Get the GraphQL schema", Tag: "Catalog",
+		Description: "The schema in the GraphQL schema definition language, as text/plain.",
+	},
+	"GET /api/v1/search/products": {
+		Summary: "Search products", Tag: "Catalog",
+		Params: params([]openapi.Param{
+			requiredParam(openapi.Query("q", "string", "Search terms")),
+			openapi.Query("fuzzy", "boolean", "Fall back to trigram matching for misspellings"),
+			storeIDParam,
+		}, listingParams, paginationParams),
+		Response: []repository.SearchResult{},
+	},
+	"GET /api/v1/supermarket/products": {
+		Summary: "List supermarket products", Tag: "Catalog",
+		Params:   params([]openapi.Param{storeIDParam}, listingParams, paginationParams, cursorParams),
+		Response: []repository.StoreListing{},
+	},
+	"GET /api/v1/supermarket/products/:id": {
+		Summary: "Get a supermarket product", Tag: "Catalog",
+		Response: repository.StoreListingDetail{},
+	},
+	"GET /api/v1/pharmacy/medicines": {
+		Summary: "List medicines", Tag: "Catalog",
+		Params: params([]openapi.Param{
+			storeIDParam,
+			openapi.Query("prescription_required", "boolean", "Only medicines that need, or don't need, a prescription"),
+		}, listingParams, paginationParams, cursorParams),
+		Response: []repository.Medicine{},
+	},
+	"GET /api/v1/pharmacy/medicines/:id": {
+		Summary: "Get a medicine", Tag: "Catalog",
+		Response: repository.MedicineDetail{},
+	},
+	"GET /api/v1/pharmacy/categories": {
+		Summary: "List pharmacy categories", Tag: "Catalog",
+		Response: []repository.CategorySummary{},
+	},
+
+	// Admin
+	"GET /api/v1/admin/cache/stats": {
+		Summary: "Get cache statistics", Tag: "Admin", Scope: repository.ScopeAdmin,
+	},
+	"GET /api/v1/admin/cache/keys": {
+		Summary: "List cache keys", Tag: "Admin", Scope: repository.ScopeAdmin,
+		Params: []openapi.Param{
+			requiredParam(openapi.Query("domain", "string", "Cache domain, e.g. supermarket")),
+			openapi.Query("limit", "integer", "Most keys to list (default 100, max 1000)"),
+		},
+	},
+	"DELETE /api/v1/admin/cache": {
+		Summary: "Invalidate cache keys", Tag: "Admin", Scope: repository.ScopeAdmin,
+		Params: []openapi.Param{requiredParam(openapi.Query("pattern", "string", "Key glob"))},
+	},
+	"GET /api/v1/admin/audit": {
+		Summary: "List audit entries", Tag: "Admin", Scope: repository.ScopeAdmin,
+		Params: params([]openapi.Param{
+			openapi.Query("action", "string", "Only this action"),
+			openapi.Query("actor", "string", "Only this actor"),
+			openapi.Query("entity_id", "string", "Only entries about this entity"),
+			openapi.Query("since", "string", "RFC 3339 lower bound"),
+			openapi.Query("until", "string", "RFC 3339 upper bound"),
+		}, paginationParams),
+		Response: []repository.AuditEntry{},
+	},
+	"GET /api/v1/admin/brands": {
+		Summary: "List brands", Tag: "Admin", Scope: repository.ScopeAdmin,
+		Params: params([]openapi.Param{
+			openapi.Query("search", "string", "Name substring"),
+			openapi.Query("include_merged", "boolean", "Include brands merged into others"),
+		}, paginationParams),
+		Response: []repository.Brand{},
+	},
+	"PUT /api/v1/admin/brands/:id": {
+		Summary: "Rename a brand", Tag: "Admin", Scope: repository.ScopeAdmin,
+		Params:   []openapi.Param{idempotencyParam},
+		Request:  handlers.RenameBrandRequest{},
+		Response: repository.Brand{},
+	},
+	"POST /api/v1/admin/brands/merge": {
+		Summary: "Merge brands", Tag: "Admin", Scope: repository.ScopeAdmin,
+		Params:   []openapi.Param{idempotencyParam},
+		Request:  handlers.MergeBrandsRequest{},
+		Response: repository.BrandMergeResult{},
+	},
+	"DELETE /api/v1/admin/stores/:id/purge": {
+		Summary: "Delete a store and everything it owns", Tag: "Admin", Scope: repository.ScopeAdmin,
+		Params:   []openapi.Param{dryRunParam},
+		Response: repository.StorePurge{},
+	},
+	"GET /api/v1/admin/api-keys": {
+		Summary: "List API keys", Tag: "Admin", Scope: repository.ScopeAdmin,
+		Params:   params([]openapi.Param{openapi.Query("include_revoked", "boolean", "Include revoked keys")}, paginationParams),
+		Response: []repository.APIKey{},
+	},
+	"POST /api/v1/admin/api-keys": {
+		Summary: "Issue an API key", Tag: "Admin", Scope: repository.ScopeAdmin,
+		Description: "The key is only returned here.",
+		Params:      []openapi.Param{idempotencyParam},
+		Request:     handlers.CreateAPIKeyRequest{},
+		Response:    handlers.IssuedAPIKey{},
+		Status:      http.StatusCreated,
+	},
+	"DELETE /api/v1/admin/api-keys/:id": {
+		Summary: "Revoke an API key", Tag: "Admin", Scope: repository.ScopeAdmin,
+		Response: repository.APIKey{},
+	},
+	"GET /api/v1/admin/webhooks": {
+		Summary: "List webhook subscriptions", Tag: "Admin", Scope: repository.ScopeAdmin,
+		Params:   paginationParams,
+		Response: []repository.WebhookSubscription{},
+	},
+	"POST /api/v1/admin/webhooks": {
+		Summary: "Subscribe a URL to webhook events", Tag: "Admin", Scope: repository.ScopeAdmin,
+		Description: "Event types are product.updated, stock.updated, stock.low, store.opened and store.closed. " +
+			"The signing secret, generated unless given, is only returned here.",
+		Params:   []openapi.Param{idempotencyParam},
+		Request:  handlers.CreateWebhookRequest{},
+		Response: handlers.WebhookWithSecret{},
+		Status:   http.StatusCreated,
+	},
+	"GET /api/v1/admin/webhooks/:id": {
+		Summary: "Get a webhook subscription", Tag: "Admin", Scope: repository.ScopeAdmin,
+		Response: repository.WebhookSubscription{},
+	},
+	"PUT /api/v1/admin/webhooks/:id": {
+		Summary: "Update a webhook subscription", Tag: "Admin", Scope: repository.ScopeAdmin,
+		Description: "Omitted fields are unchanged; queued deliveries are sent with the new URL and secret.",
+		Request:     handlers.UpdateWebhookRequest{},
+		Response:    repository.WebhookSubscription{},
+	},
+	"DELETE /api/v1/admin/webhooks/:id": {
+		Summary: "Delete a webhook subscription and its delivery log", Tag: "Admin", Scope: repository.ScopeAdmin,
+	},
+	"GET /api/v1/admin/jobs/dead": {
+		Summary: "List dead-lettered background jobs", Tag: "Admin", Scope: repository.ScopeAdmin,
+		Description: "Jobs that failed every attempt, oldest first. Pass the last entry_id as after for the next page.",
+		Params: []openapi.Param{
+			openapi.Query("after", "string", "entry_id of the last job already listed"),
+			openapi.Query("limit", "integer", "Jobs to return (default 20, max 100)"),
+		},
+		Response: []jobs.DeadJob{},
+	},
+	"GET /api/v1/admin/webhooks/:id/deliveries": {
+		Summary: "List a webhook's deliveries", Tag: "Admin", Scope: repository.ScopeAdmin,
+		Params: params([]openapi.Param{
+			openapi.Query("status", "string", "pending, succeeded or failed"),
+		}, paginationParams),
+		Response: []repository.WebhookDelivery{},
+	},
+
+	// Operations
+	"GET /healthz": {Summary: "Report the process alive", Tag: "Operations"},
+	"GET /readyz": {
+		Summary: "Check the service's dependencies", Tag: "Operations",
+		Description: "503 when a required dependency fails; 200 with status degraded when only optional ones do.",
+	},
+	"GET /health":  {Summary: "Check the service's dependencies, as /readyz does", Tag: "Operations"},
+	"GET /metrics": {Summary: "Prometheus metrics", Tag: "Operations"},
+}
+
+// requiredParam marks p as required
+func requiredParam(p openapi.Param) openapi.Param {
+	p.Required = true
+	return p
+}
+
+// params concatenates lists of parameters
+func params(lists ...[]openapi.Param) []openapi.Param {
+	var all []openapi.Param
+	for _, list := range lists {
+		all = append(all, list...)
+	}
+	return all
+}
+
+// registerDocs serves the OpenAPI document of the routes registered so far at
+// /openapi.json, and Swagger UI rendering it at /docs
+func registerDocs(router *gin.Engine) {
+	var routes []openapi.Route
+	for _, route := range router.Routes() {
+		routes = append(routes, openapi.Route{Method: route.Method, Path: route.Path})
+	}
+	// The document is built from Go types, so encoding it can't fail
+	spec, _ := json.Marshal(openapi.Build(apiInfo, routes, routeDocs))
+	page := openapi.SwaggerUI(apiInfo.Title, "/openapi.json")
+
+	router.GET("/openapi.json", func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json; charset=utf-8", spec)
+	})
+	router.GET("/docs", func(c *gin.Context) {
+		c.Data(http.StatusOK, "text/html; charset=utf-8", page)
+	})
+}
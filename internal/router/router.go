@@ -1,86 +1,388 @@
 package router
 
 import (
+	"net/netip"
 	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/yourusername/supabase-redis-middleware/internal/auth"
 	"github.com/yourusername/supabase-redis-middleware/internal/cache"
+	"github.com/yourusername/supabase-redis-middleware/internal/graphql/catalog"
 	"github.com/yourusername/supabase-redis-middleware/internal/handlers"
+	"github.com/yourusername/supabase-redis-middleware/internal/i18n"
+	"github.com/yourusername/supabase-redis-middleware/internal/jobs"
+	"github.com/yourusername/supabase-redis-middleware/internal/metrics"
+	"github.com/yourusername/supabase-redis-middleware/internal/middleware"
+	"github.com/yourusername/supabase-redis-middleware/internal/realtime"
 	"github.com/yourusername/supabase-redis-middleware/internal/repository"
+	"github.com/yourusername/supabase-redis-middleware/internal/service"
+	"github.com/yourusername/supabase-redis-middleware/internal/webhooks"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 	"go.uber.org/zap"
 )
 
 // HandlerDependencies contains all dependencies needed by handlers
 type HandlerDependencies struct {
-	Cache        cache.CacheService
-	Repository   repository.SupabaseRepository
-	PgRepo       *repository.PostgresRepository
-	Logger       *zap.Logger
-	BearerTokens []string // Valid bearer tokens for authentication
+	Cache      cache.CacheService
+	Repository repository.SupabaseRepository
+	PgRepo     *repository.PostgresRepository
+	Catalog    service.CatalogService
+	Logger     *zap.Logger
+	// GraphQL is the schema /api/v1/graphql queries the catalog with
+	GraphQL *catalog.Schema
+	// Events publishes the listing changes of stock updates and pushes, and the status
+	// changes of store writes; nil publishes none
+	Events *realtime.Publisher
+	// Realtime, if set, relays published events to /ws/stores/:id and
+	// /api/v1/stores/:id/events clients, which are pinged every RealtimePingInterval
+	Realtime             *realtime.Hub
+	RealtimePingInterval time.Duration
+	// Webhooks queues the webhook events of pushes, stock updates and store status
+	// updates; nil queues none
+	Webhooks *webhooks.Dispatcher
+	// Jobs, if set, is the background job pool whose dead-lettered jobs
+	// /api/v1/admin/jobs/dead lists
+	Jobs *jobs.Pool
+	// Auth identifies callers by their bearer API key or Supabase JWT
+	Auth *auth.Authenticator
+	// ForwardUserTokens passes callers' bearer tokens to the Supabase repository
+	ForwardUserTokens bool
+	// Storage holds uploaded product images; uploads are limited to StorageMaxUpload bytes
+	// and a private bucket's images are signed for StorageSignedURLTTL
+	Storage             *repository.StorageClient
+	StorageMaxUpload    int64
+	StorageSignedURLTTL time.Duration
+	// ServiceMetrics, if set, are exposed on /metrics along with the cache's
+	ServiceMetrics *metrics.ServiceMetrics
+	// TracingService, if set, records a server span named after it for every request
+	TracingService string
+	// Messages, if set, translates the messages of error responses into the language of
+	// each request's Accept-Language header
+	Messages *i18n.Catalog
+	// LoggingOptions choose which requests are logged, and whether with their headers
+	LoggingOptions []middleware.LoggingOption
+	// Callers with a read:catalog API key may override the cache TTL of their reads with
+	// cache_ttl, clamped to between MinTTLOverride and MaxTTLOverride
+	MinTTLOverride *cache.TTL
+	MaxTTLOverride *cache.TTL
+	// RateLimiter, if set, limits how often each caller may send API requests
+	RateLimiter *middleware.RateLimiter
+	// CORSOrigins are the origins browsers may call the API from; nil allows any
+	CORSOrigins *middleware.AllowedOrigins
+	// PushSigningSecrets maps ERP store IDs to the shared secrets their product pushes and
+	// stock updates must be signed with
+	PushSigningSecrets map[string]string
+	// PushAllowedCIDRs, if set, are the only addresses product pushes and stock updates
+	// are admitted from; an API key's own allowed CIDRs narrow them further
+	PushAllowedCIDRs []netip.Prefix
+	// TrustedProxies are the proxies whose X-Forwarded-For names the client's address; nil
+	// keeps gin's default of trusting every proxy, except for the push allow-lists, which
+	// then check the connection's address
+	TrustedProxies []string
+	// Tenancy scopes every API request to a tenant: the caller's own, or the one named by
+	// the TenantHeader header, falling back to DefaultTenant
+	Tenancy       bool
+	TenantHeader  string
+	DefaultTenant string
+	// CSVImportTemplates are the column mappings CSV imports may name, by template name
+	CSVImportTemplates map[string]map[string]string
+	// Responses to writes sent with an Idempotency-Key are replayed to retries for
+	// IdempotencyTTL; zero ignores the header
+	IdempotencyTTL time.Duration
+	// Request bodies are limited to MaxBodySize bytes, except product pushes, CSV imports
+	// and stock updates, which may be MaxPushBodySize; zero doesn't limit them
+	MaxBodySize     int64
+	MaxPushBodySize int64
+	// GzipResponses gzips responses of at least GzipMinSize bytes whose media type starts
+	// with one of GzipTypes, for clients accepting it
+	GzipResponses bool
+	GzipMinSize   int
+	GzipTypes     []string
+	// CacheControl lets HTTP caches reuse GET responses served from the cache for as long
+	// as their entries stay fresh, up to CacheControlMaxAge if it is positive
+	CacheControl       bool
+	CacheControlMaxAge time.Duration
+	// ReadyTimeouts bounds the readiness check of each dependency (redis, postgres,
+	// supabase); dependencies named in OptionalDependencies only degrade readiness
+	ReadyTimeouts        map[string]time.Duration
+	OptionalDependencies []string
 }
 
 // SetupRouter creates and configures the Gin engine with all routes and middleware
 func SetupRouter(deps HandlerDependencies, requestTimeout time.Duration) *gin.Engine {
 	// Create Gin engine
 	router := gin.New()
+	if deps.TrustedProxies != nil {
+		if err := router.SetTrustedProxies(deps.TrustedProxies); err != nil {
+			deps.Logger.Fatal("Invalid trusted proxies", zap.Error(err))
+		}
+	}
 
 	// Add recovery middleware (must be first to catch panics from other middleware)
 	router.Use(gin.Recovery())
 
+	// Add tracing middleware, continuing traces from callers' traceparent headers
+	if deps.TracingService != "" {
+		router.Use(otelgin.Middleware(deps.TracingService))
+	}
+
+	// Add request ID middleware, so log lines, error payloads and Supabase calls can be
+	// traced to the request (before the timeout, whose errors carry it too)
+	router.Use(middleware.RequestIDMiddleware(deps.Logger))
+
+	// Add locale middleware, so error messages, the timeout's included, are translated
+	if deps.Messages != nil {
+		router.Use(middleware.LocaleMiddleware(deps.Messages))
+	}
+
 	// Add timeout middleware
-	router.Use(TimeoutMiddleware(requestTimeout))
+	router.Use(middleware.TimeoutMiddleware(requestTimeout))
 
 	// Add CORS middleware
-	router.Use(cors.New(cors.Config{
+	allowHeaders := []string{"Origin", "Content-Type", "Content-Encoding", "Accept", "Authorization", "If-None-Match", "X-Request-ID", "traceparent", "tracestate"}
+	if deps.Tenancy {
+		allowHeaders = append(allowHeaders, deps.TenantHeader)
+	}
+	corsConfig := cors.Config{
 		AllowOrigins:     []string{"*"},
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowHeaders:     allowHeaders,
+		ExposeHeaders:    []string{"Content-Length", "ETag", "X-Request-ID"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
-	}))
+	}
+	if deps.CORSOrigins != nil {
+		// Checked per request, so reloaded origins apply at once
+		corsConfig.AllowOrigins, corsConfig.AllowOriginFunc = nil, deps.CORSOrigins.Allows
+	}
+	router.Use(cors.New(corsConfig))
 
 	// Add logging middleware (after recovery and timeout)
-	router.Use(LoggingMiddleware(deps.Logger))
+	router.Use(middleware.LoggingMiddleware(deps.Logger, deps.LoggingOptions...))
 
-	// Health check endpoint (outside API versioning)
-	router.GET("/health", HealthCheckHandler(deps.Cache, deps.Repository, deps.Logger))
+	// Decode gzipped request bodies before their limits and signatures are checked, and
+	// gzip large responses (inside logging, which then reports the bytes sent)
+	router.Use(middleware.DecompressMiddleware())
+	if deps.GzipResponses {
+		router.Use(middleware.CompressMiddleware(deps.GzipMinSize, deps.GzipTypes))
+	}
+
+	// Liveness and readiness probes (outside API versioning); /health predates the split
+	// and answers as /readyz does
+	readiness := ReadinessHandler(readinessDependencies(deps), deps.Logger)
+	router.GET("/healthz", LivenessHandler())
+	router.GET("/readyz", readiness)
+	router.GET("/health", readiness)
+
+	// Prometheus metrics endpoint (outside API versioning)
+	registry := metrics.NewRegistry(deps.Cache)
+	if deps.ServiceMetrics != nil {
+		registry.MustRegister(deps.ServiceMetrics)
+	}
+	router.GET("/metrics", gin.WrapH(metrics.Handler(registry)))
+
+	// tenantScope scopes requests to their tenant once they're authenticated, if tenancy
+	// is enabled, and requireTenant refuses catalog writes acting for none
+	tenantScope := func(c *gin.Context) { c.Next() }
+	requireTenant := func(c *gin.Context) { c.Next() }
+	if deps.Tenancy {
+		tenantScope = middleware.TenantMiddleware(deps.TenantHeader, deps.DefaultTenant, deps.Logger)
+		requireTenant = middleware.RequireTenantMiddleware(deps.TenantHeader, deps.Logger)
+	}
+
+	// Real-time store events over WebSockets (outside API versioning)
+	realtimeHandler := handlers.NewRealtimeHandler(deps.PgRepo, deps.Realtime, deps.Events, deps.RealtimePingInterval, deps.Logger)
+	if deps.Realtime != nil {
+		router.GET("/ws/stores/:id", tenantScope, realtimeHandler.StoreEvents)
+	}
 
 	// Initialize handlers
-	storeHandler := handlers.NewStoreHandler(deps.PgRepo, deps.Logger)
-	productHandler := handlers.NewProductHandler(deps.PgRepo, deps.Logger)
-	stockHandler := handlers.NewStockHandler(deps.PgRepo, deps.Logger)
+	storeHandler := handlers.NewStoreHandler(deps.PgRepo, deps.Catalog, deps.Cache, deps.Events, deps.Webhooks, deps.Logger)
+	productHandler := handlers.NewProductHandler(deps.PgRepo, deps.Cache, deps.Events, deps.Webhooks, deps.Logger)
+	csvImportHandler := handlers.NewCSVImportHandler(productHandler, deps.PgRepo, deps.CSVImportTemplates, deps.Logger)
+	productImageHandler := handlers.NewProductImageHandler(deps.PgRepo, deps.Storage, deps.Cache, deps.StorageMaxUpload, deps.StorageSignedURLTTL, deps.Logger)
+	stockHandler := handlers.NewStockHandler(deps.PgRepo, deps.Cache, deps.Events, deps.Webhooks, deps.Logger)
+	cacheHandler := handlers.NewCacheHandler(deps.Cache, deps.Logger)
+	supermarketHandler := handlers.NewSupermarketHandler(deps.Catalog, deps.Logger)
+	pharmacyHandler := handlers.NewPharmacyHandler(deps.Catalog, deps.Logger)
+	searchHandler := handlers.NewSearchHandler(deps.Catalog, deps.Logger)
+	categoryHandler := handlers.NewCategoryHandler(deps.Catalog, deps.Logger)
+	homeHandler := handlers.NewHomeHandler(deps.Catalog, deps.Logger)
+	graphQLHandler := handlers.NewGraphQLHandler(deps.GraphQL, deps.Logger)
+	auditHandler := handlers.NewAuditHandler(deps.PgRepo, deps.Logger)
+	brandHandler := handlers.NewBrandHandler(deps.PgRepo, deps.Cache, deps.Logger)
+	apiKeyHandler := handlers.NewAPIKeyHandler(deps.PgRepo, deps.Auth, deps.Logger)
+	webhookHandler := handlers.NewWebhookHandler(deps.PgRepo, deps.Logger)
+	jobsHandler := handlers.NewJobsHandler(deps.Jobs, deps.Logger)
+
+	// List routes parse limit, offset, cursor, sort and filters before their handlers, by
+	// each route's rules: catalog listings page by keyset and read the listing filters,
+	// the rest page by offset, 20 at a time up to 100
+	catalogList := handlers.ListQueryMiddleware(handlers.QueryRules{Cursor: true, Filters: true})
+	offsetList := handlers.ListQueryMiddleware(handlers.QueryRules{})
+
+	// requireScope admits callers holding scope; callers bound to a store must also be
+	// bound to the one named by the route's storeParam
+	requireScope := func(scope, storeParam string) gin.HandlerFunc {
+		return middleware.RequireScope(deps.Logger, scope, storeParam)
+	}
 
-	// API v1 route group - All routes are public (no authentication required)
+	// API v1 route group - reads are public; each group of writes admits some roles, and
+	// each write requires a scope
 	v1 := router.Group("/api/v1")
+	v1.Use(middleware.AuditActorMiddleware())
+	v1.Use(middleware.AuthenticateMiddleware(deps.Auth, deps.Logger))
+	v1.Use(tenantScope)
+	if deps.RateLimiter != nil {
+		v1.Use(middleware.RateLimitMiddleware(deps.RateLimiter, deps.Logger))
+	}
+	v1.Use(middleware.BodyLimitMiddleware(deps.MaxBodySize, map[string]int64{
+		"/api/v1/products/push":       deps.MaxPushBodySize,
+		"/api/v1/products/stock":      deps.MaxPushBodySize,
+		"/api/v1/products/import/csv": deps.MaxPushBodySize,
+		// The upload handler limits images to StorageMaxUpload itself
+		"/api/v1/products/:id/images/upload": 0,
+	}))
+	v1.Use(middleware.CacheTTLMiddleware(deps.MinTTLOverride, deps.MaxTTLOverride))
+	if deps.CacheControl {
+		// Anonymous readers' responses are shared by HTTP caches, so they must vary by the
+		// tenant they're for
+		var vary []string
+		if deps.Tenancy {
+			vary = append(vary, deps.TenantHeader)
+		}
+		v1.Use(middleware.CacheControlMiddleware(deps.CacheControlMaxAge, vary...))
+	}
+	v1.Use(middleware.IdempotencyMiddleware(deps.Cache, deps.IdempotencyTTL, deps.Logger))
+	if deps.ForwardUserTokens {
+		v1.Use(middleware.UserTokenMiddleware())
+	}
 	{
 		// Store management
 		stores := v1.Group("/stores")
 		{
+			stores.GET("/nearby", offsetList, storeHandler.FindNearbyStores)
+			stores.GET("/serving", offsetList, storeHandler.FindServingStores)
 			stores.GET("/:id", storeHandler.GetStoreBasicData)
-			stores.PUT("/:id", storeHandler.UpdateStoreDetails)
-			stores.PUT("/:id/status", storeHandler.UpdateStoreStatus)
+			stores.GET("/:id/products", catalogList, storeHandler.ListStoreProducts)
+			stores.GET("/:id/products/export", storeHandler.ExportStoreProducts)
 			stores.GET("/:id/status", storeHandler.GetStoreStatus)
+			stores.GET("/:id/hours", storeHandler.GetStoreHours)
+			stores.GET("/:id/delivery-zones", storeHandler.ListDeliveryZones)
+			stores.GET("/:id/delivers-to", storeHandler.CheckDelivery)
+			stores.GET("/:id/taxes", storeHandler.ListStoreTaxes)
+			stores.GET("/:id/taxes/:taxId", storeHandler.GetStoreTax)
+			stores.GET("/:id/listings/:storeProductId/taxes", storeHandler.ListStoreProductTaxes)
+			if deps.Realtime != nil {
+				stores.GET("/:id/events", realtimeHandler.StoreStatusEvents)
+			}
+		}
+
+		// Store writes, and the stock report - store admins, for their own store, ERP
+		// integrations and platform admins
+		storeWrites := stores.Group("", middleware.RequireRole(deps.Logger, repository.RoleStoreAdmin, repository.RoleERP, repository.RolePlatformAdmin), requireTenant)
+		{
+			// Stock levels are the store's own business, so its report is read like a write
+			storeWrites.GET("/:id/stock-report", requireScope(repository.ScopeWriteStock, "id"), storeHandler.GetStockReport)
+			storeWrites.PUT("/:id", requireScope(repository.ScopeWriteStores, "id"), storeHandler.UpdateStoreDetails)
+			storeWrites.PUT("/:id/status", requireScope(repository.ScopeWriteStores, "id"), storeHandler.UpdateStoreStatus)
+			storeWrites.PUT("/:id/hours", requireScope(repository.ScopeWriteStores, "id"), storeHandler.ReplaceStoreHours)
+			storeWrites.PUT("/:id/hours/holidays/:date", requireScope(repository.ScopeWriteStores, "id"), storeHandler.UpsertStoreHoliday)
+			storeWrites.DELETE("/:id/hours/holidays/:date", requireScope(repository.ScopeWriteStores, "id"), storeHandler.DeleteStoreHoliday)
+			storeWrites.POST("/:id/delivery-zones", requireScope(repository.ScopeWriteStores, "id"), storeHandler.CreateDeliveryZone)
+			storeWrites.DELETE("/:id/delivery-zones/:zoneId", requireScope(repository.ScopeWriteStores, "id"), storeHandler.DeleteDeliveryZone)
+			storeWrites.PUT("/:id/taxes/:taxId", requireScope(repository.ScopeWriteStores, "id"), storeHandler.UpdateStoreTax)
+			storeWrites.DELETE("/:id/taxes/:taxId", requireScope(repository.ScopeWriteStores, "id"), storeHandler.DeactivateStoreTax)
+			// Listings are named by the product's ERP ID under products, and by their store
+			// product UUID under listings
+			storeWrites.DELETE("/:id/products/:productId", requireScope(repository.ScopeWriteStock, "id"), storeHandler.DeactivateStoreProduct)
+			storeWrites.PUT("/:id/products/:productId/availability", requireScope(repository.ScopeWriteStock, "id"), storeHandler.SetStoreProductAvailability)
+			storeWrites.PUT("/:id/listings/:storeProductId/taxes/:taxId", requireScope(repository.ScopeWriteStores, "id"), storeHandler.AttachStoreProductTax)
+			storeWrites.DELETE("/:id/listings/:storeProductId/taxes/:taxId", requireScope(repository.ScopeWriteStores, "id"), storeHandler.DetachStoreProductTax)
 		}
 
 		// Product management
 		products := v1.Group("/products")
 		{
-			products.POST("/push", productHandler.PushProducts)
-			products.POST("/stock", stockHandler.UpdateStock)
+			products.GET("/lookup", searchHandler.LookupProduct)
+			products.GET("/:id/prices", searchHandler.CompareProductPrices)
+			products.GET("/:id/images/:imageId/signed-url", productImageHandler.GetProductImageSignedURL)
+		}
+
+		// Product writes - ERP integrations and platform admins. Callers bound to a store
+		// are checked against the store the body or query names; pushes and stock updates
+		// are admitted from the allowed addresses only
+		productWrites := products.Group("", middleware.RequireRole(deps.Logger, repository.RoleERP, repository.RolePlatformAdmin), requireTenant)
+		{
+			productWrites.POST("/push", requireScope(repository.ScopePushProducts, ""), middleware.IPAllowlistMiddleware(deps.PushAllowedCIDRs, deps.TrustedProxies != nil, deps.Logger),
+				middleware.SignatureMiddleware(deps.PushSigningSecrets, "store_details.store_id", deps.Logger), productHandler.PushProducts)
+			productWrites.POST("/import/csv", requireScope(repository.ScopePushProducts, ""),
+				middleware.QuerySignatureMiddleware(deps.PushSigningSecrets, "store_id", deps.Logger), csvImportHandler.ImportCSV)
+			productWrites.POST("/stock", requireScope(repository.ScopeWriteStock, ""), middleware.IPAllowlistMiddleware(deps.PushAllowedCIDRs, deps.TrustedProxies != nil, deps.Logger),
+				middleware.SignatureMiddleware(deps.PushSigningSecrets, "store_id", deps.Logger), stockHandler.UpdateStock)
+			productWrites.POST("/:id/images/upload", requireScope(repository.ScopePushProducts, ""), productImageHandler.UploadProductImage)
+			productWrites.PATCH("/:external_id", requireScope(repository.ScopePushProducts, ""), productHandler.UpdateProduct)
+		}
+
+		// Category hierarchy across all store types
+		v1.GET("/categories", categoryHandler.GetCategoryTree)
+
+		// Home screen composed from nearby stores, featured products and categories
+		v1.GET("/home", homeHandler.GetHome)
+
+		// GraphQL queries of stores, products, variations, categories and prices
+		v1.GET("/graphql", graphQLHandler.Query)
+		v1.POST("/graphql", graphQLHandler.Query)
+		v1.GET("/graphql/schema", graphQLHandler.Schema)
+
+		// Search across all store types
+		search := v1.Group("/search")
+		{
+			search.GET("/products", handlers.ListQueryMiddleware(handlers.QueryRules{Filters: true}), searchHandler.SearchProducts)
+		}
+
+		// Admin routes - platform admins holding the admin scope or, once admin tokens are
+		// configured, admin tokens only, so no credential an ERP or store holds reaches them
+		admin := v1.Group("/admin")
+		if deps.Auth.SeparatesAdmin() {
+			admin.Use(middleware.RequireAdminToken(deps.Logger))
+		} else {
+			admin.Use(middleware.RequireRole(deps.Logger, repository.RolePlatformAdmin), requireScope(repository.ScopeAdmin, ""))
+		}
+		{
+			admin.GET("/cache/stats", cacheHandler.GetStats)
+			admin.GET("/cache/keys", handlers.ListQueryMiddleware(handlers.QueryRules{DefaultLimit: 100, MaxLimit: 1000}), cacheHandler.ListKeys)
+			admin.DELETE("/cache", cacheHandler.InvalidatePattern)
+			admin.GET("/audit", offsetList, auditHandler.ListAuditEntries)
+			admin.GET("/brands", offsetList, brandHandler.ListBrands)
+			admin.PUT("/brands/:id", requireTenant, brandHandler.RenameBrand)
+			admin.POST("/brands/merge", requireTenant, brandHandler.MergeBrands)
+			admin.DELETE("/stores/:id/purge", requireTenant, storeHandler.PurgeStore)
+			admin.GET("/api-keys", offsetList, apiKeyHandler.ListAPIKeys)
+			admin.POST("/api-keys", apiKeyHandler.CreateAPIKey)
+			admin.DELETE("/api-keys/:id", apiKeyHandler.RevokeAPIKey)
+			admin.GET("/webhooks", offsetList, webhookHandler.ListWebhooks)
+			admin.POST("/webhooks", webhookHandler.CreateWebhook)
+			admin.GET("/webhooks/:id", webhookHandler.GetWebhook)
+			admin.PUT("/webhooks/:id", webhookHandler.UpdateWebhook)
+			admin.DELETE("/webhooks/:id", webhookHandler.DeleteWebhook)
+			admin.GET("/webhooks/:id/deliveries", offsetList, webhookHandler.ListWebhookDeliveries)
+			if deps.Jobs != nil {
+				admin.GET("/jobs/dead", offsetList, jobsHandler.ListDeadJobs)
+			}
 		}
 
 		// Supermarket domain routes
-		supermarket := v1.Group("/supermarket")
+		supermarket := v1.Group("/supermarket", middleware.DomainMiddleware("supermarket"))
 		{
-			supermarket.GET("/products", PlaceholderHandler("supermarket", "products"))
-			supermarket.GET("/products/:id", PlaceholderHandler("supermarket", "product"))
+			supermarket.GET("/products", catalogList, supermarketHandler.ListProducts)
+			supermarket.GET("/products/:id", supermarketHandler.GetProduct)
 			supermarket.GET("/categories", PlaceholderHandler("supermarket", "categories"))
 		}
 
 		// Movie domain routes
-		movies := v1.Group("/movies")
+		movies := v1.Group("/movies", middleware.DomainMiddleware("movies"))
 		{
 			movies.GET("", PlaceholderHandler("movies", "list"))
 			movies.GET("/:id", PlaceholderHandler("movies", "detail"))
@@ -88,14 +390,17 @@ func SetupRouter(deps HandlerDependencies, requestTimeout time.Duration) *gin.En
 		}
 
 		// Pharmacy domain routes
-		pharmacy := v1.Group("/pharmacy")
+		pharmacy := v1.Group("/pharmacy", middleware.DomainMiddleware("pharmacy"))
 		{
-			pharmacy.GET("/medicines", PlaceholderHandler("pharmacy", "medicines"))
-			pharmacy.GET("/medicines/:id", PlaceholderHandler("pharmacy", "medicine"))
-			pharmacy.GET("/categories", PlaceholderHandler("pharmacy", "categories"))
+			pharmacy.GET("/medicines", catalogList, pharmacyHandler.ListMedicines)
+			pharmacy.GET("/medicines/:id", pharmacyHandler.GetMedicine)
+			pharmacy.GET("/categories", pharmacyHandler.ListCategories)
 		}
 	}
 
+	// OpenAPI document of the routes above, and Swagger UI rendering it
+	registerDocs(router)
+
 	// 404 handler for unsupported endpoints
 	router.NoRoute(NotFoundHandler())
 
@@ -0,0 +1,473 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/yourusername/supabase-redis-middleware/internal/cache"
+	"github.com/yourusername/supabase-redis-middleware/internal/repository"
+	"go.uber.org/zap"
+)
+
+// CatalogRepository is the Postgres-backed catalog data access used by CatalogService
+type CatalogRepository interface {
+	ListSupermarketProducts(ctx context.Context, filter repository.ListingFilter, pagination repository.Pagination) ([]repository.StoreListing, error)
+	CountSupermarketProducts(ctx context.Context, filter repository.ListingFilter) (int64, error)
+	GetSupermarketProduct(ctx context.Context, storeProductID string) (*repository.StoreListingDetail, error)
+	ListStoreProducts(ctx context.Context, storeID string, filter repository.ListingFilter, pagination repository.Pagination) ([]repository.StoreProductListing, error)
+	CountStoreProducts(ctx context.Context, storeID string, filter repository.ListingFilter) (int64, error)
+	ListMedicines(ctx context.Context, filter repository.ListingFilter, pagination repository.Pagination) ([]repository.Medicine, error)
+	CountMedicines(ctx context.Context, filter repository.ListingFilter) (int64, error)
+	GetMedicine(ctx context.Context, storeProductID string) (*repository.MedicineDetail, error)
+	ListPharmacyCategories(ctx context.Context) ([]repository.CategorySummary, error)
+	ListCategoryTree(ctx context.Context, storeID string) ([]*repository.CategoryNode, error)
+	LookupProducts(ctx context.Context, field, value string) ([]repository.ProductLookup, error)
+	CompareProductPrices(ctx context.Context, productID string, lat, lng *float64) (*repository.PriceComparison, error)
+	SearchProducts(ctx context.Context, query, storeID string, filter repository.ListingFilter, pagination repository.Pagination) ([]repository.SearchResult, error)
+	FuzzySearchProducts(ctx context.Context, query, storeID string, filter repository.ListingFilter, pagination repository.Pagination) ([]repository.SearchResult, error)
+	FindNearbyStores(ctx context.Context, q repository.NearbyStoresQuery) ([]repository.NearbyStore, error)
+}
+
+// CatalogService serves catalog reads from Postgres with the same cache-first flow as DomainService
+type CatalogService interface {
+	ListSupermarketProducts(ctx context.Context, filter repository.ListingFilter, pagination repository.Pagination) (*Response, error)
+	GetSupermarketProduct(ctx context.Context, storeProductID string) (*Response, error)
+	ListStoreProducts(ctx context.Context, storeID string, filter repository.ListingFilter, pagination repository.Pagination) (*Response, error)
+	ListMedicines(ctx context.Context, filter repository.ListingFilter, pagination repository.Pagination) (*Response, error)
+	GetMedicine(ctx context.Context, storeProductID string) (*Response, error)
+	ListPharmacyCategories(ctx context.Context) (*Response, error)
+	ListCategoryTree(ctx context.Context, storeID string) (*Response, error)
+	LookupProducts(ctx context.Context, field, value string) (*Response, error)
+	CompareProductPrices(ctx context.Context, productID string, lat, lng *float64) (*Response, error)
+	SearchProducts(ctx context.Context, query, storeID string, filter repository.ListingFilter, pagination repository.Pagination, fuzzy bool) (*Response, error)
+	FindNearbyStores(ctx context.Context, q repository.NearbyStoresQuery) (*Response, error)
+	Home(ctx context.Context, lat, lng float64) (*Response, error)
+}
+
+// CatalogChangeDomains returns the cache domains made stale by a catalog change
+// Product data is shown in every store's listings, so product changes and the change
+// with no table sent after missed notifications clear all stores; store product changes
+// clear the one store plus the cross-store listings
+func CatalogChangeDomains(change repository.CatalogChange) []string {
+	listings := []string{cache.DomainSupermarket, cache.DomainPharmacy, cache.DomainSearch, cache.DomainCategories, cache.DomainLookup}
+	if change.Table == "store_products" && change.StoreID != "" {
+		return append(listings, cache.StoreDomain(change.StoreID))
+	}
+	return append(listings, cache.DomainProducts, cache.DomainStores)
+}
+
+// catalogService reuses domainService for caching, tracking and error mapping
+type catalogService struct {
+	*domainService
+	catalog CatalogRepository
+}
+
+// NewCatalogService creates a new catalog service instance
+func NewCatalogService(
+	cache cache.CacheService,
+	catalog CatalogRepository,
+	logger *zap.Logger,
+	cacheTTL time.Duration,
+	opts ...Option,
+) CatalogService {
+	base := &domainService{
+		cache:    cache,
+		logger:   logger,
+		cacheTTL: cacheTTL,
+	}
+	for _, opt := range opts {
+		opt(base)
+	}
+	return &catalogService{domainService: base, catalog: catalog}
+}
+
+// ListSupermarketProducts retrieves supermarket listings with cache-first logic
+func (s *catalogService) ListSupermarketProducts(ctx context.Context, filter repository.ListingFilter, pagination repository.Pagination) (*Response, error) {
+	cacheKey := s.cache.GenerateKey(cache.DomainSupermarket, listingCacheParams(filter, pagination))
+	resp := s.cachedFetch(ctx, cacheKey, func(ctx context.Context) (interface{}, error) {
+		return s.catalog.ListSupermarketProducts(ctx, filter, pagination)
+	})
+	setListingPage(resp, pagination)
+	if pagination.IncludeTotal {
+		s.setTotalCount(ctx, resp, cache.DomainSupermarket, filter, s.catalog.CountSupermarketProducts)
+	}
+	return resp, nil
+}
+
+// GetSupermarketProduct retrieves a single supermarket listing with cache-first logic
+func (s *catalogService) GetSupermarketProduct(ctx context.Context, storeProductID string) (*Response, error) {
+	cacheKey := s.cache.GenerateKey(cache.DomainSupermarket, map[string]string{"id": storeProductID})
+	return s.cachedFetch(ctx, cacheKey, func(ctx context.Context) (interface{}, error) {
+		return s.catalog.GetSupermarketProduct(ctx, storeProductID)
+	}), nil
+}
+
+// ListStoreProducts retrieves one store's catalog with cache-first logic
+// Pages are cached under the store's domain, so stock updates and pushes for the store clear them
+func (s *catalogService) ListStoreProducts(ctx context.Context, storeID string, filter repository.ListingFilter, pagination repository.Pagination) (*Response, error) {
+	filter.StoreID = storeID
+	domain := cache.StoreDomain(storeID)
+	cacheKey := s.cache.GenerateKey(domain, listingCacheParams(filter, pagination))
+	resp := s.cachedFetch(ctx, cacheKey, func(ctx context.Context) (interface{}, error) {
+		return s.catalog.ListStoreProducts(ctx, storeID, filter, pagination)
+	})
+	setListingPage(resp, pagination)
+	if pagination.IncludeTotal {
+		s.setTotalCount(ctx, resp, domain, filter, func(ctx context.Context, filter repository.ListingFilter) (int64, error) {
+			return s.catalog.CountStoreProducts(ctx, storeID, filter)
+		})
+	}
+	return resp, nil
+}
+
+// ListMedicines retrieves pharmacy listings with cache-first logic
+func (s *catalogService) ListMedicines(ctx context.Context, filter repository.ListingFilter, pagination repository.Pagination) (*Response, error) {
+	cacheKey := s.cache.GenerateKey(cache.DomainPharmacy, listingCacheParams(filter, pagination))
+	resp := s.cachedFetch(ctx, cacheKey, func(ctx context.Context) (interface{}, error) {
+		return s.catalog.ListMedicines(ctx, filter, pagination)
+	})
+	setListingPage(resp, pagination)
+	if pagination.IncludeTotal {
+		s.setTotalCount(ctx, resp, cache.DomainPharmacy, filter, s.catalog.CountMedicines)
+	}
+	return resp, nil
+}
+
+// GetMedicine retrieves a single pharmacy listing with cache-first logic
+func (s *catalogService) GetMedicine(ctx context.Context, storeProductID string) (*Response, error) {
+	cacheKey := s.cache.GenerateKey(cache.DomainPharmacy, map[string]string{"id": storeProductID})
+	return s.cachedFetch(ctx, cacheKey, func(ctx context.Context) (interface{}, error) {
+		return s.catalog.GetMedicine(ctx, storeProductID)
+	}), nil
+}
+
+// ListPharmacyCategories retrieves categories with medicines available, with cache-first logic
+func (s *catalogService) ListPharmacyCategories(ctx context.Context) (*Response, error) {
+	cacheKey := s.cache.GenerateKey(cache.DomainPharmacy, map[string]string{"view": "categories"})
+	return s.cachedFetch(ctx, cacheKey, func(ctx context.Context) (interface{}, error) {
+		return s.catalog.ListPharmacyCategories(ctx)
+	}), nil
+}
+
+// ListCategoryTree retrieves the category hierarchy with listing counts, with cache-first logic
+// A store's tree is cached under the store's domain, so its pushes and stock updates clear it
+func (s *catalogService) ListCategoryTree(ctx context.Context, storeID string) (*Response, error) {
+	domain := cache.DomainCategories
+	if storeID != "" {
+		domain = cache.StoreDomain(storeID)
+	}
+	cacheKey := s.cache.GenerateKey(domain, map[string]string{"view": "categories"})
+	return s.cachedFetch(ctx, cacheKey, func(ctx context.Context) (interface{}, error) {
+		return s.catalog.ListCategoryTree(ctx, storeID)
+	}), nil
+}
+
+// LookupProducts resolves a barcode, SKU or EAN to products and their offers, with cache-first logic
+// Offers carry store prices and stock, so the lookup domain is cleared with the listings
+func (s *catalogService) LookupProducts(ctx context.Context, field, value string) (*Response, error) {
+	cacheKey := s.cache.GenerateKey(cache.DomainLookup, map[string]string{field: value})
+	return s.cachedFetch(ctx, cacheKey, func(ctx context.Context) (interface{}, error) {
+		return s.catalog.LookupProducts(ctx, field, value)
+	}), nil
+}
+
+// CompareProductPrices compares a product's offers across stores with cache-first logic
+// Like lookups, comparisons show per-store offers, so they share the lookup domain
+func (s *catalogService) CompareProductPrices(ctx context.Context, productID string, lat, lng *float64) (*Response, error) {
+	params := map[string]string{"prices": productID}
+	if lat != nil && lng != nil {
+		params["lat"] = fmt.Sprintf("%.5f", *lat)
+		params["lng"] = fmt.Sprintf("%.5f", *lng)
+	}
+
+	cacheKey := s.cache.GenerateKey(cache.DomainLookup, params)
+	return s.cachedFetch(ctx, cacheKey, func(ctx context.Context) (interface{}, error) {
+		return s.catalog.CompareProductPrices(ctx, productID, lat, lng)
+	}), nil
+}
+
+// SearchProducts runs a full-text product search with cache-first logic
+// With fuzzy set, a query with no full-text matches falls back to trigram similarity
+func (s *catalogService) SearchProducts(ctx context.Context, query, storeID string, filter repository.ListingFilter, pagination repository.Pagination, fuzzy bool) (*Response, error) {
+	filter.StoreID = storeID
+	filter.Search = ""
+	params := listingCacheParams(filter, pagination)
+	params["q"] = query
+	params["fuzzy"] = fmt.Sprintf("%t", fuzzy)
+
+	cacheKey := s.cache.GenerateKey(cache.DomainSearch, params)
+	resp := s.cachedFetch(ctx, cacheKey, func(ctx context.Context) (interface{}, error) {
+		if !fuzzy {
+			return s.catalog.SearchProducts(ctx, query, storeID, filter, pagination)
+		}
+		return s.searchWithFuzzyFallback(ctx, query, storeID, filter, pagination)
+	})
+	if resp.Metadata != nil {
+		resp.Metadata.Pagination = &pagination
+	}
+	return resp, nil
+}
+
+// FindNearbyStores retrieves active stores near a point with cache-first logic
+// The point is rounded to about 100m, so nearby callers share cached results
+func (s *catalogService) FindNearbyStores(ctx context.Context, q repository.NearbyStoresQuery) (*Response, error) {
+	q.Lat = math.Round(q.Lat*1000) / 1000
+	q.Lng = math.Round(q.Lng*1000) / 1000
+	cacheKey := s.cache.GenerateKey(cache.DomainNearby, map[string]string{
+		"lat":        fmt.Sprintf("%.3f", q.Lat),
+		"lng":        fmt.Sprintf("%.3f", q.Lng),
+		"radius_km":  fmt.Sprintf("%g", q.RadiusKm),
+		"store_type": q.StoreType,
+		"limit":      fmt.Sprintf("%d", q.Limit),
+	})
+	return s.cachedFetch(ctx, cacheKey, func(ctx context.Context) (interface{}, error) {
+		return s.catalog.FindNearbyStores(ctx, q)
+	}), nil
+}
+
+// Sizes of the sections of the home screen
+const (
+	homeRadiusKm      = 5.0
+	homeStoreLimit    = 10
+	homeFeaturedLimit = 20
+)
+
+// HomeData is the home screen: stores near the caller, featured products and the category tree
+type HomeData struct {
+	Stores           interface{} `json:"stores"`
+	FeaturedProducts interface{} `json:"featured_products"`
+	Categories       interface{} `json:"categories"`
+}
+
+// Home composes the home screen for a point from nearby stores, featured in-stock products
+// and the category tree. The sections are fetched concurrently, each through its own cache
+// entry, so each is refreshed on its own domain's invalidation. If a section fails, its
+// error is returned. The response is from cache only if every section was, and stale if any was
+func (s *catalogService) Home(ctx context.Context, lat, lng float64) (*Response, error) {
+	sections := make([]*Response, 3)
+	var wg sync.WaitGroup
+	wg.Add(len(sections))
+	go func() {
+		defer wg.Done()
+		sections[0], _ = s.FindNearbyStores(ctx, repository.NearbyStoresQuery{
+			Lat: lat, Lng: lng, RadiusKm: homeRadiusKm, Limit: homeStoreLimit,
+		})
+	}()
+	go func() {
+		defer wg.Done()
+		filter := repository.ListingFilter{InStockOnly: true, FeaturedOnly: true}
+		pagination := repository.Pagination{Limit: homeFeaturedLimit}
+		cacheKey := s.cache.GenerateKey(cache.DomainSupermarket, listingCacheParams(filter, pagination))
+		sections[1] = s.cachedFetch(ctx, cacheKey, func(ctx context.Context) (interface{}, error) {
+			return s.catalog.ListSupermarketProducts(ctx, filter, pagination)
+		})
+	}()
+	go func() {
+		defer wg.Done()
+		sections[2], _ = s.ListCategoryTree(ctx, "")
+	}()
+	wg.Wait()
+
+	metadata := &ResponseMetadata{FromCache: true}
+	for _, section := range sections {
+		if section.Error != nil {
+			return section, nil
+		}
+		metadata.FromCache = metadata.FromCache && section.Metadata.FromCache
+		metadata.Stale = metadata.Stale || section.Metadata.Stale
+	}
+
+	home := HomeData{Stores: sections[0].Data, FeaturedProducts: sections[1].Data, Categories: sections[2].Data}
+	var etag string
+	if encoded, err := json.Marshal(home); err == nil {
+		etag = contentETag(encoded)
+	}
+	return &Response{Status: "success", Data: home, ETag: etag, Metadata: metadata}, nil
+}
+
+// searchWithFuzzyFallback runs the full-text search and, if the query has no full-text
+// matches at all, the fuzzy search instead. A later page that is merely past the end of
+// the full-text results stays empty so paging never switches match type midway
+func (s *catalogService) searchWithFuzzyFallback(ctx context.Context, query, storeID string, filter repository.ListingFilter, pagination repository.Pagination) ([]repository.SearchResult, error) {
+	results, err := s.catalog.SearchProducts(ctx, query, storeID, filter, pagination)
+	if err != nil || len(results) > 0 {
+		return results, err
+	}
+
+	if pagination.Offset > 0 {
+		first, err := s.catalog.SearchProducts(ctx, query, storeID, filter, repository.Pagination{Limit: 1})
+		if err != nil || len(first) > 0 {
+			return results, err
+		}
+	}
+
+	return s.catalog.FuzzySearchProducts(ctx, query, storeID, filter, pagination)
+}
+
+// listingCacheParams converts a listing filter and pagination to cache parameters
+func listingCacheParams(filter repository.ListingFilter, pagination repository.Pagination) map[string]string {
+	params := map[string]string{
+		"store_id":    filter.StoreID,
+		"category_id": filter.CategoryID,
+		"brand_id":    filter.BrandID,
+		"search":      filter.Search,
+		"in_stock":    fmt.Sprintf("%t", filter.InStockOnly),
+		"limit":       fmt.Sprintf("%d", pagination.Limit),
+		"offset":      fmt.Sprintf("%d", pagination.Offset),
+	}
+	if pagination.After != nil {
+		params["cursor"] = pagination.After.Encode()
+	} else if pagination.Keyset {
+		params["cursor"] = ""
+	}
+	if filter.MinPrice != nil {
+		params["min_price"] = fmt.Sprintf("%g", *filter.MinPrice)
+	}
+	if filter.MaxPrice != nil {
+		params["max_price"] = fmt.Sprintf("%g", *filter.MaxPrice)
+	}
+	if filter.FeaturedOnly {
+		params["featured"] = "true"
+	}
+	if filter.RequiresPrescription != nil {
+		params["requires_prescription"] = fmt.Sprintf("%t", *filter.RequiresPrescription)
+	}
+	return params
+}
+
+// setListingPage adds pagination and, when a cursor page is full, the cursor for the next page
+func setListingPage(resp *Response, pagination repository.Pagination) {
+	if resp.Metadata == nil {
+		return
+	}
+	resp.Metadata.Pagination = &pagination
+	if !pagination.KeysetOrder() {
+		return
+	}
+
+	var positions []repository.Cursor
+	switch data := resp.Data.(type) {
+	case []repository.StoreListing:
+		positions = listingCursors(data)
+	case []repository.StoreProductListing:
+		positions = listingCursors(data)
+	case []repository.Medicine:
+		positions = listingCursors(data)
+	case json.RawMessage:
+		// Cached pages only need the keyset fields of each listing decoded
+		var listings []struct {
+			ID        string    `json:"id"`
+			CreatedAt time.Time `json:"created_at"`
+		}
+		if err := json.Unmarshal(data, &listings); err != nil {
+			return
+		}
+		for _, l := range listings {
+			positions = append(positions, repository.Cursor{CreatedAt: l.CreatedAt, ID: l.ID})
+		}
+	}
+
+	if pagination.Limit > 0 && len(positions) == pagination.Limit {
+		resp.Metadata.NextCursor = positions[len(positions)-1].Encode()
+	}
+}
+
+// setTotalCount adds the number of listings matching filter to a successful response
+// Counts are cached separately from pages, so every page of a listing shares one count.
+// A failed count is logged and omitted rather than failing the page
+func (s *catalogService) setTotalCount(ctx context.Context, resp *Response, domain string, filter repository.ListingFilter,
+	count func(ctx context.Context, filter repository.ListingFilter) (int64, error)) {
+	if resp.Metadata == nil {
+		return
+	}
+
+	params := listingCacheParams(filter, repository.Pagination{})
+	params["view"] = "count"
+	countResp := s.cachedFetch(ctx, s.cache.GenerateKey(domain, params), func(ctx context.Context) (interface{}, error) {
+		return count(ctx, filter)
+	})
+
+	var total int64
+	switch data := countResp.Data.(type) {
+	case int64:
+		total = data
+	case json.RawMessage:
+		if err := json.Unmarshal(data, &total); err != nil {
+			return
+		}
+	default:
+		s.log(ctx).Warn("Failed to count listings", zap.String("domain", domain))
+		return
+	}
+	resp.Metadata.TotalCount = &total
+}
+
+// listingCursors returns the keyset position of each listing
+func listingCursors[T interface{ Cursor() repository.Cursor }](listings []T) []repository.Cursor {
+	positions := make([]repository.Cursor, len(listings))
+	for i, l := range listings {
+		positions[i] = l.Cursor()
+	}
+	return positions
+}
+
+// cachedFetch returns the cached payload for key or loads, caches and returns it
+// Cache hits are passed through as raw JSON, so typed results are never decoded and re-encoded.
+// Results cached for a TTL override are kept under key's ttl partition
+func (s *catalogService) cachedFetch(ctx context.Context, cacheKey string, fetch func(ctx context.Context) (interface{}, error)) *Response {
+	if partition := s.ttlPartition(ctx); partition != "" {
+		cacheKey += ":ttl:" + partition
+	}
+	s.track(ctx, cacheKey, func(ctx context.Context) ([]byte, error) {
+		data, err := fetch(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(data)
+	})
+
+	cachedData, err := s.cache.Get(ctx, cacheKey)
+	if err == nil && cachedData != nil && json.Valid(cachedData) {
+		s.log(ctx).Info("Cache hit", zap.String("key", cacheKey))
+
+		cachedAt := time.Now()
+		return &Response{
+			Status: "success",
+			Data:   json.RawMessage(cachedData),
+			ETag:   contentETag(cachedData),
+			Metadata: &ResponseMetadata{
+				FromCache: true,
+				CachedAt:  &cachedAt,
+			},
+		}
+	}
+
+	s.log(ctx).Info("Cache miss", zap.String("key", cacheKey))
+
+	data, err := fetch(ctx)
+	if err != nil {
+		if stale, ok := s.staleCopy(ctx, err, cacheKey); ok && json.Valid(stale) {
+			return staleResponse(stale)
+		}
+		return s.errorResponse(err)
+	}
+
+	var etag string
+	if encoded, err := json.Marshal(data); err == nil {
+		etag = contentETag(encoded)
+		s.cacheResult(ctx, cacheKey, encoded)
+	}
+
+	return &Response{
+		Status: "success",
+		Data:   data,
+		ETag:   etag,
+		Metadata: &ResponseMetadata{
+			FromCache: false,
+		},
+	}
+}
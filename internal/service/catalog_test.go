@@ -0,0 +1,581 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/yourusername/supabase-redis-middleware/internal/repository"
+	"go.uber.org/zap"
+)
+
+type mockCatalogRepository struct {
+	products    []repository.StoreListing
+	storeID     string
+	detail      *repository.StoreListingDetail
+	detailErr   error
+	searchQuery string
+	searchStore string
+	textMatches []repository.SearchResult
+	fuzzyCalls  int
+	total       int64
+	countCalls  int
+	calls       int
+	featured    bool
+	nearby      *repository.NearbyStoresQuery
+	nearbyErr   error
+	mu          sync.Mutex // Home calls the repository concurrently
+}
+
+func (m *mockCatalogRepository) ListSupermarketProducts(ctx context.Context, filter repository.ListingFilter, pagination repository.Pagination) ([]repository.StoreListing, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls++
+	m.featured = filter.FeaturedOnly
+	return m.products, nil
+}
+
+func (m *mockCatalogRepository) CountSupermarketProducts(ctx context.Context, filter repository.ListingFilter) (int64, error) {
+	m.countCalls++
+	return m.total, nil
+}
+
+func (m *mockCatalogRepository) ListStoreProducts(ctx context.Context, storeID string, filter repository.ListingFilter, pagination repository.Pagination) ([]repository.StoreProductListing, error) {
+	m.calls++
+	m.storeID = storeID
+	listings := make([]repository.StoreProductListing, len(m.products))
+	for i, p := range m.products {
+		listings[i] = repository.StoreProductListing{StoreListing: p}
+	}
+	return listings, nil
+}
+
+func (m *mockCatalogRepository) CountStoreProducts(ctx context.Context, storeID string, filter repository.ListingFilter) (int64, error) {
+	m.countCalls++
+	return m.total, nil
+}
+
+func (m *mockCatalogRepository) CountMedicines(ctx context.Context, filter repository.ListingFilter) (int64, error) {
+	m.countCalls++
+	return m.total, nil
+}
+
+func (m *mockCatalogRepository) GetSupermarketProduct(ctx context.Context, storeProductID string) (*repository.StoreListingDetail, error) {
+	m.calls++
+	return m.detail, m.detailErr
+}
+
+func (m *mockCatalogRepository) ListMedicines(ctx context.Context, filter repository.ListingFilter, pagination repository.Pagination) ([]repository.Medicine, error) {
+	m.calls++
+	return []repository.Medicine{}, nil
+}
+
+func (m *mockCatalogRepository) GetMedicine(ctx context.Context, storeProductID string) (*repository.MedicineDetail, error) {
+	m.calls++
+	return nil, repository.NewNotFoundError("store_products", storeProductID)
+}
+
+func (m *mockCatalogRepository) ListPharmacyCategories(ctx context.Context) ([]repository.CategorySummary, error) {
+	m.calls++
+	return []repository.CategorySummary{}, nil
+}
+
+func (m *mockCatalogRepository) ListCategoryTree(ctx context.Context, storeID string) ([]*repository.CategoryNode, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls++
+	m.storeID = storeID
+	return []*repository.CategoryNode{}, nil
+}
+
+func (m *mockCatalogRepository) LookupProducts(ctx context.Context, field, value string) ([]repository.ProductLookup, error) {
+	m.calls++
+	m.searchQuery = field + "=" + value
+	if value == "missing" {
+		return nil, repository.NewNotFoundError("products", value)
+	}
+	return []repository.ProductLookup{{Offers: []repository.ProductOffer{{StoreID: "store-1"}}}}, nil
+}
+
+func (m *mockCatalogRepository) CompareProductPrices(ctx context.Context, productID string, lat, lng *float64) (*repository.PriceComparison, error) {
+	m.calls++
+	m.searchQuery = productID
+	if lat != nil {
+		m.searchQuery += fmt.Sprintf("@%.4f,%.4f", *lat, *lng)
+	}
+	return &repository.PriceComparison{Prices: []repository.ProductPrice{{StoreID: "store-1"}}}, nil
+}
+
+func (m *mockCatalogRepository) SearchProducts(ctx context.Context, query, storeID string, filter repository.ListingFilter, pagination repository.Pagination) ([]repository.SearchResult, error) {
+	m.calls++
+	m.searchQuery = query
+	m.searchStore = storeID
+	if pagination.Offset >= len(m.textMatches) {
+		return []repository.SearchResult{}, nil
+	}
+	return m.textMatches[pagination.Offset:], nil
+}
+
+func (m *mockCatalogRepository) FuzzySearchProducts(ctx context.Context, query, storeID string, filter repository.ListingFilter, pagination repository.Pagination) ([]repository.SearchResult, error) {
+	m.calls++
+	m.fuzzyCalls++
+	return []repository.SearchResult{{Match: "fuzzy"}}, nil
+}
+
+func (m *mockCatalogRepository) FindNearbyStores(ctx context.Context, q repository.NearbyStoresQuery) ([]repository.NearbyStore, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls++
+	m.nearby = &q
+	if m.nearbyErr != nil {
+		return nil, m.nearbyErr
+	}
+	return []repository.NearbyStore{{DistanceKm: 1.2}}, nil
+}
+
+// syncCache serializes a mockCacheService for services reading it concurrently
+type syncCache struct {
+	*mockCacheService
+	mu sync.Mutex
+}
+
+func (c *syncCache) Get(ctx context.Context, key string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.mockCacheService.Get(ctx, key)
+}
+
+func (c *syncCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.mockCacheService.Set(ctx, key, value, ttl)
+}
+
+func setupTestCatalogService(cache *mockCacheService, repo *mockCatalogRepository) CatalogService {
+	logger, _ := zap.NewDevelopment()
+	return NewCatalogService(cache, repo, logger, 5*time.Minute)
+}
+
+func TestListSupermarketProducts_CacheFirst(t *testing.T) {
+	mockCache := &mockCacheService{getData: make(map[string][]byte)}
+	mockRepo := &mockCatalogRepository{
+		products: []repository.StoreListing{{StoreProductID: "sp-1", Name: "Milk", Price: 1.5}},
+	}
+	service := setupTestCatalogService(mockCache, mockRepo)
+
+	ctx := context.Background()
+	filter := repository.ListingFilter{Search: "milk"}
+	pagination := repository.Pagination{Limit: 20}
+
+	miss, _ := service.ListSupermarketProducts(ctx, filter, pagination)
+	if miss.Status != "success" || miss.Metadata.FromCache {
+		t.Fatalf("first ListSupermarketProducts() = %+v, want uncached success", miss)
+	}
+	if _, ok := miss.Data.([]repository.StoreListing); !ok {
+		t.Errorf("ListSupermarketProducts() data on miss = %T, want []StoreListing", miss.Data)
+	}
+
+	hit, _ := service.ListSupermarketProducts(ctx, filter, pagination)
+	if !hit.Metadata.FromCache {
+		t.Fatal("second ListSupermarketProducts() should be served from cache")
+	}
+	if mockRepo.calls != 1 {
+		t.Errorf("repository called %d times, want 1", mockRepo.calls)
+	}
+	if hit.ETag != miss.ETag {
+		t.Errorf("ETag on hit = %s, want %s", hit.ETag, miss.ETag)
+	}
+	if hit.Metadata.Pagination == nil || hit.Metadata.Pagination.Limit != 20 {
+		t.Errorf("ListSupermarketProducts() pagination = %+v, want limit 20", hit.Metadata.Pagination)
+	}
+
+	// Cached payload is passed through without decoding
+	raw, ok := hit.Data.(json.RawMessage)
+	if !ok {
+		t.Fatalf("ListSupermarketProducts() data on hit = %T, want json.RawMessage", hit.Data)
+	}
+	var products []repository.StoreListing
+	if err := json.Unmarshal(raw, &products); err != nil || len(products) != 1 || products[0].Name != "Milk" {
+		t.Errorf("cached data = %s, want the Milk listing", raw)
+	}
+}
+
+func TestGetSupermarketProduct_NotFound(t *testing.T) {
+	mockCache := &mockCacheService{getData: make(map[string][]byte)}
+	mockRepo := &mockCatalogRepository{
+		detailErr: repository.NewNotFoundError("store_products", "missing"),
+	}
+	service := setupTestCatalogService(mockCache, mockRepo)
+
+	resp, _ := service.GetSupermarketProduct(context.Background(), "missing")
+
+	if resp.Status != "error" || resp.Error == nil || resp.Error.Code != "NOT_FOUND" {
+		t.Errorf("GetSupermarketProduct() = %+v, want NOT_FOUND error", resp)
+	}
+	if len(mockCache.getData) != 0 {
+		t.Error("GetSupermarketProduct() should not cache errors")
+	}
+}
+
+func TestListMedicines_CachedUnderPharmacyDomain(t *testing.T) {
+	mockCache := &mockCacheService{getData: make(map[string][]byte)}
+	service := setupTestCatalogService(mockCache, &mockCatalogRepository{})
+
+	required := true
+	filter := repository.ListingFilter{RequiresPrescription: &required}
+	resp, _ := service.ListMedicines(context.Background(), filter, repository.Pagination{Limit: 20})
+
+	if resp.Status != "success" {
+		t.Fatalf("ListMedicines() status = %v, want success", resp.Status)
+	}
+	if _, ok := mockCache.getData["pharmacy:cached"]; !ok {
+		t.Error("ListMedicines() should cache under the pharmacy domain")
+	}
+}
+
+func TestListingCacheParams(t *testing.T) {
+	required := true
+	base := listingCacheParams(repository.ListingFilter{}, repository.Pagination{Limit: 20})
+	rx := listingCacheParams(repository.ListingFilter{RequiresPrescription: &required}, repository.Pagination{Limit: 20})
+
+	if _, ok := base["requires_prescription"]; ok {
+		t.Error("listingCacheParams() should omit unset prescription filter")
+	}
+	if rx["requires_prescription"] != "true" {
+		t.Errorf("listingCacheParams() requires_prescription = %q, want true", rx["requires_prescription"])
+	}
+}
+
+func TestCatalogChangeDomains(t *testing.T) {
+	tests := []struct {
+		name   string
+		change repository.CatalogChange
+		want   string
+		absent string
+	}{
+		{"store product", repository.CatalogChange{Table: "store_products", StoreID: "s1"}, "store:s1", "store"},
+		{"product", repository.CatalogChange{Table: "products"}, "store", "store:s1"},
+		{"missed notifications", repository.CatalogChange{}, "products", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			domains := map[string]bool{}
+			for _, d := range CatalogChangeDomains(tt.change) {
+				domains[d] = true
+			}
+			if !domains[tt.want] || !domains["supermarket"] {
+				t.Errorf("CatalogChangeDomains() = %v, want %q and supermarket", domains, tt.want)
+			}
+			if tt.absent != "" && domains[tt.absent] {
+				t.Errorf("CatalogChangeDomains() = %v, should not include %q", domains, tt.absent)
+			}
+		})
+	}
+}
+
+func TestSearchProducts_CachedUnderSearchDomain(t *testing.T) {
+	mockCache := &mockCacheService{getData: make(map[string][]byte)}
+	mockRepo := &mockCatalogRepository{}
+	service := setupTestCatalogService(mockCache, mockRepo)
+
+	resp, _ := service.SearchProducts(context.Background(), "toothpaste -gel", "store-1",
+		repository.ListingFilter{}, repository.Pagination{Limit: 20}, false)
+
+	if resp.Status != "success" {
+		t.Fatalf("SearchProducts() status = %v, want success", resp.Status)
+	}
+	if mockRepo.searchQuery != "toothpaste -gel" || mockRepo.searchStore != "store-1" {
+		t.Errorf("repository searched %q in %q, want the request query and store", mockRepo.searchQuery, mockRepo.searchStore)
+	}
+	if _, ok := mockCache.getData["search:cached"]; !ok {
+		t.Error("SearchProducts() should cache under the search domain")
+	}
+	if mockRepo.fuzzyCalls != 0 {
+		t.Error("SearchProducts() without fuzzy should not fall back to fuzzy search")
+	}
+}
+
+func TestSearchProducts_FuzzyFallback(t *testing.T) {
+	tests := []struct {
+		name        string
+		textMatches []repository.SearchResult
+		offset      int
+		wantFuzzy   bool
+	}{
+		{"no text matches", nil, 0, true},
+		{"no text matches on later page", nil, 20, true},
+		{"text matches", []repository.SearchResult{{Match: "fulltext"}}, 0, false},
+		{"past end of text matches", []repository.SearchResult{{Match: "fulltext"}}, 20, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCache := &mockCacheService{getData: make(map[string][]byte)}
+			mockRepo := &mockCatalogRepository{textMatches: tt.textMatches}
+			service := setupTestCatalogService(mockCache, mockRepo)
+
+			resp, _ := service.SearchProducts(context.Background(), "colgat", "",
+				repository.ListingFilter{}, repository.Pagination{Limit: 20, Offset: tt.offset}, true)
+
+			if resp.Status != "success" {
+				t.Fatalf("SearchProducts() status = %v, want success", resp.Status)
+			}
+			if got := mockRepo.fuzzyCalls > 0; got != tt.wantFuzzy {
+				t.Errorf("fuzzy search used = %v, want %v", got, tt.wantFuzzy)
+			}
+		})
+	}
+}
+
+func TestListSupermarketProducts_NextCursor(t *testing.T) {
+	created := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	mockCache := &mockCacheService{getData: make(map[string][]byte)}
+	mockRepo := &mockCatalogRepository{
+		products: []repository.StoreListing{
+			{StoreProductID: "sp-2", CreatedAt: created.Add(time.Minute)},
+			{StoreProductID: "sp-1", CreatedAt: created},
+		},
+	}
+	service := setupTestCatalogService(mockCache, mockRepo)
+	ctx := context.Background()
+
+	want := repository.Cursor{CreatedAt: created, ID: "sp-1"}.Encode()
+	for _, label := range []string{"miss", "hit"} {
+		resp, _ := service.ListSupermarketProducts(ctx, repository.ListingFilter{}, repository.Pagination{Limit: 2, Keyset: true})
+		if resp.Metadata.NextCursor != want {
+			t.Errorf("NextCursor on %s = %q, want %q", label, resp.Metadata.NextCursor, want)
+		}
+	}
+
+	partial, _ := service.ListSupermarketProducts(ctx, repository.ListingFilter{}, repository.Pagination{Limit: 3, Keyset: true})
+	if partial.Metadata.NextCursor != "" {
+		t.Errorf("NextCursor on last page = %q, want empty", partial.Metadata.NextCursor)
+	}
+
+	// Offset pages are ordered by name, which a cursor can't continue
+	offset, _ := service.ListSupermarketProducts(ctx, repository.ListingFilter{}, repository.Pagination{Limit: 2})
+	if offset.Metadata.NextCursor != "" {
+		t.Errorf("NextCursor on offset page = %q, want empty", offset.Metadata.NextCursor)
+	}
+}
+
+func TestListSupermarketProducts_TotalCount(t *testing.T) {
+	mockCache := &mockCacheService{getData: make(map[string][]byte)}
+	mockRepo := &mockCatalogRepository{
+		products: []repository.StoreListing{{StoreProductID: "sp-1"}},
+		total:    541,
+	}
+	service := setupTestCatalogService(mockCache, mockRepo)
+	ctx := context.Background()
+
+	without, _ := service.ListSupermarketProducts(ctx, repository.ListingFilter{}, repository.Pagination{Limit: 20})
+	if without.Metadata.TotalCount != nil || mockRepo.countCalls != 0 {
+		t.Errorf("TotalCount without include_total = %v (%d counts), want none", without.Metadata.TotalCount, mockRepo.countCalls)
+	}
+
+	for _, label := range []string{"miss", "hit"} {
+		resp, _ := service.ListSupermarketProducts(ctx, repository.ListingFilter{}, repository.Pagination{Limit: 20, IncludeTotal: true})
+		if resp.Metadata.TotalCount == nil || *resp.Metadata.TotalCount != 541 {
+			t.Errorf("TotalCount on %s = %v, want 541", label, resp.Metadata.TotalCount)
+		}
+	}
+	if mockRepo.countCalls != 1 {
+		t.Errorf("count queried %d times, want 1 (then cached)", mockRepo.countCalls)
+	}
+}
+
+func TestListStoreProducts_CachedUnderStoreDomain(t *testing.T) {
+	created := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	mockCache := &mockCacheService{getData: make(map[string][]byte)}
+	mockRepo := &mockCatalogRepository{
+		products: []repository.StoreListing{{StoreProductID: "sp-1", CreatedAt: created}},
+		total:    1,
+	}
+	service := setupTestCatalogService(mockCache, mockRepo)
+	ctx := context.Background()
+
+	want := repository.Cursor{CreatedAt: created, ID: "sp-1"}.Encode()
+	for _, label := range []string{"miss", "hit"} {
+		resp, _ := service.ListStoreProducts(ctx, "store-1", repository.ListingFilter{}, repository.Pagination{Limit: 1, Keyset: true, IncludeTotal: true})
+		if resp.Status != "success" {
+			t.Fatalf("ListStoreProducts() on %s status = %v, want success", label, resp.Status)
+		}
+		if resp.Metadata.NextCursor != want {
+			t.Errorf("NextCursor on %s = %q, want %q", label, resp.Metadata.NextCursor, want)
+		}
+		if resp.Metadata.TotalCount == nil || *resp.Metadata.TotalCount != 1 {
+			t.Errorf("TotalCount on %s = %v, want 1", label, resp.Metadata.TotalCount)
+		}
+	}
+
+	if mockRepo.calls != 1 || mockRepo.storeID != "store-1" {
+		t.Errorf("repository called %d times for store %q, want once for store-1", mockRepo.calls, mockRepo.storeID)
+	}
+	if _, ok := mockCache.getData["store:store-1:cached"]; !ok {
+		t.Error("ListStoreProducts() should cache under the store's domain")
+	}
+}
+
+func TestListCategoryTree_CacheDomain(t *testing.T) {
+	tests := []struct {
+		storeID string
+		wantKey string
+	}{
+		{"", "categories:categories"},
+		{"store-1", "store:store-1:categories"},
+	}
+
+	for _, tt := range tests {
+		mockCache := &mockCacheService{getData: make(map[string][]byte)}
+		mockRepo := &mockCatalogRepository{}
+		service := setupTestCatalogService(mockCache, mockRepo)
+
+		for _, label := range []string{"miss", "hit"} {
+			resp, _ := service.ListCategoryTree(context.Background(), tt.storeID)
+			if resp.Status != "success" {
+				t.Fatalf("ListCategoryTree(%q) on %s status = %v, want success", tt.storeID, label, resp.Status)
+			}
+		}
+
+		if mockRepo.calls != 1 || mockRepo.storeID != tt.storeID {
+			t.Errorf("repository called %d times for store %q, want once for %q", mockRepo.calls, mockRepo.storeID, tt.storeID)
+		}
+		if _, ok := mockCache.getData[tt.wantKey]; !ok {
+			t.Errorf("ListCategoryTree(%q) should cache under %q", tt.storeID, tt.wantKey)
+		}
+	}
+}
+
+func TestLookupProducts_CachedUnderLookupDomain(t *testing.T) {
+	mockCache := &mockCacheService{getData: make(map[string][]byte)}
+	mockRepo := &mockCatalogRepository{}
+	service := setupTestCatalogService(mockCache, mockRepo)
+
+	resp, _ := service.LookupProducts(context.Background(), repository.LookupEAN, "8901030865278")
+	if resp.Status != "success" {
+		t.Fatalf("LookupProducts() status = %v, want success", resp.Status)
+	}
+	if mockRepo.searchQuery != "ean=8901030865278" {
+		t.Errorf("repository looked up %q, want ean=8901030865278", mockRepo.searchQuery)
+	}
+	if _, ok := mockCache.getData["lookup:cached"]; !ok {
+		t.Error("LookupProducts() should cache under the lookup domain")
+	}
+
+	// The mock cache keys by domain only, so the miss needs its own cache
+	service = setupTestCatalogService(&mockCacheService{getData: make(map[string][]byte)}, mockRepo)
+	missing, _ := service.LookupProducts(context.Background(), repository.LookupSKU, "missing")
+	if missing.Status != "error" || missing.Error.Code != "NOT_FOUND" {
+		t.Errorf("LookupProducts() for an unknown SKU = %+v, want NOT_FOUND", missing.Error)
+	}
+}
+
+func TestCompareProductPrices_CachedUnderLookupDomain(t *testing.T) {
+	mockCache := &mockCacheService{getData: make(map[string][]byte)}
+	mockRepo := &mockCatalogRepository{}
+	service := setupTestCatalogService(mockCache, mockRepo)
+
+	lat, lng := 12.9716, 77.5946
+	resp, _ := service.CompareProductPrices(context.Background(), "product-1", &lat, &lng)
+	if resp.Status != "success" {
+		t.Fatalf("CompareProductPrices() status = %v, want success", resp.Status)
+	}
+	if mockRepo.searchQuery != "product-1@12.9716,77.5946" {
+		t.Errorf("repository compared %q, want product-1 at the given location", mockRepo.searchQuery)
+	}
+	if _, ok := mockCache.getData["lookup:cached"]; !ok {
+		t.Error("CompareProductPrices() should cache under the lookup domain")
+	}
+
+	service.CompareProductPrices(context.Background(), "product-1", &lat, &lng)
+	if mockRepo.calls != 1 {
+		t.Errorf("repository called %d times, want 1 (second comparison served from cache)", mockRepo.calls)
+	}
+}
+
+func TestGetSupermarketProduct_ServesStaleWhenPostgresUnavailable(t *testing.T) {
+	mockCache := &mockCacheService{getData: make(map[string][]byte)}
+	mockRepo := &mockCatalogRepository{
+		detail: &repository.StoreListingDetail{StoreListing: repository.StoreListing{StoreProductID: "sp-1", Name: "Milk"}},
+	}
+	logger, _ := zap.NewDevelopment()
+	service := NewCatalogService(mockCache, mockRepo, logger, 5*time.Minute, WithStaleCopies(time.Hour))
+
+	ctx := context.Background()
+	if resp, _ := service.GetSupermarketProduct(ctx, "sp-1"); resp.Status != "success" {
+		t.Fatalf("GetSupermarketProduct() = %+v, want success", resp)
+	}
+
+	// The cached product expires while Postgres refuses connections
+	for key := range mockCache.getData {
+		if !strings.HasSuffix(key, ":stale") {
+			delete(mockCache.getData, key)
+		}
+	}
+	mockRepo.detailErr = fmt.Errorf("get store product: %w", &pgconn.PgError{Code: "57P03"})
+
+	resp, _ := service.GetSupermarketProduct(ctx, "sp-1")
+	if resp.Status != "success" || !resp.Metadata.Stale || !resp.Metadata.FromCache {
+		t.Fatalf("GetSupermarketProduct() = %+v, want the stale copy", resp)
+	}
+	var detail repository.StoreListingDetail
+	if err := json.Unmarshal(resp.Data.(json.RawMessage), &detail); err != nil || detail.Name != "Milk" {
+		t.Errorf("GetSupermarketProduct() data = %s, want the stale Milk listing", resp.Data)
+	}
+}
+
+func TestHome_ComposesCachedSections(t *testing.T) {
+	mockCache := &mockCacheService{getData: make(map[string][]byte)}
+	mockRepo := &mockCatalogRepository{products: []repository.StoreListing{{StoreProductID: "sp-1", Name: "Milk"}}}
+	logger, _ := zap.NewDevelopment()
+	service := NewCatalogService(&syncCache{mockCacheService: mockCache}, mockRepo, logger, 5*time.Minute)
+
+	ctx := context.Background()
+	first, _ := service.Home(ctx, 12.97161, 77.59456)
+	if first.Status != "success" || first.Metadata.FromCache {
+		t.Fatalf("Home() on miss = %+v, want success from the repository", first)
+	}
+	if mockRepo.calls != 3 || !mockRepo.featured {
+		t.Errorf("repository called %d times (featured %v), want each section once with featured products", mockRepo.calls, mockRepo.featured)
+	}
+	if mockRepo.nearby == nil || mockRepo.nearby.Lat != 12.972 || mockRepo.nearby.Lng != 77.595 {
+		t.Errorf("nearby stores queried with %+v, want the point rounded to 3 decimals", mockRepo.nearby)
+	}
+	for _, key := range []string{"nearby:cached", "supermarket:cached", "categories:categories"} {
+		if _, ok := mockCache.getData[key]; !ok {
+			t.Errorf("Home() should cache its section under %q", key)
+		}
+	}
+
+	second, _ := service.Home(ctx, 12.97161, 77.59456)
+	if second.Status != "success" || !second.Metadata.FromCache || mockRepo.calls != 3 {
+		t.Errorf("Home() on hit = %+v after %d repository calls, want every section from cache", second, mockRepo.calls)
+	}
+	if second.ETag == "" || second.ETag != first.ETag {
+		t.Errorf("Home() ETag = %q on hit and %q on miss, want them equal", second.ETag, first.ETag)
+	}
+
+	data, _ := json.Marshal(second.Data)
+	var home map[string]json.RawMessage
+	if err := json.Unmarshal(data, &home); err != nil || len(home) != 3 || !strings.Contains(string(home["featured_products"]), "Milk") {
+		t.Errorf("Home() data = %s, want stores, featured_products and categories", data)
+	}
+}
+
+func TestHome_SectionFailureFailsResponse(t *testing.T) {
+	mockCache := &mockCacheService{getData: make(map[string][]byte)}
+	mockRepo := &mockCatalogRepository{nearbyErr: fmt.Errorf("find nearby stores: %w", &pgconn.PgError{Code: "57P03"})}
+	logger, _ := zap.NewDevelopment()
+	service := NewCatalogService(&syncCache{mockCacheService: mockCache}, mockRepo, logger, 5*time.Minute)
+
+	resp, _ := service.Home(context.Background(), 12.97, 77.59)
+	if resp.Status != "error" || resp.Error == nil || !strings.Contains(resp.Error.Message, "find nearby stores") {
+		t.Errorf("Home() = %+v, want the nearby stores error", resp)
+	}
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"encoding/json"
+)
+
+// SelectFields projects the response data down to fields: an object keeps only those of
+// its keys, and a list has each of its objects projected. Other data is left as is
+// Values are copied as serialized, so cached data isn't decoded beyond its top-level keys,
+// and the ETag is recomputed for the projected payload
+func (r *Response) SelectFields(fields []string) error {
+	if r.Error != nil || len(fields) == 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(r.Data)
+	if err != nil {
+		return err
+	}
+
+	var projected interface{}
+	var object map[string]json.RawMessage
+	var list []json.RawMessage
+	switch {
+	case json.Unmarshal(data, &object) == nil && object != nil:
+		projected = selectKeys(object, fields)
+	case json.Unmarshal(data, &list) == nil && list != nil:
+		items := make([]json.RawMessage, len(list))
+		for i, item := range list {
+			items[i] = item
+			var object map[string]json.RawMessage
+			if json.Unmarshal(item, &object) != nil || object == nil {
+				continue
+			}
+			if items[i], err = json.Marshal(selectKeys(object, fields)); err != nil {
+				return err
+			}
+		}
+		projected = items
+	default:
+		return nil
+	}
+
+	data, err = json.Marshal(projected)
+	if err != nil {
+		return err
+	}
+	r.Data = json.RawMessage(data)
+	r.ETag = contentETag(data)
+	return nil
+}
+
+// selectKeys returns the entries of object whose key is one of fields
+func selectKeys(object map[string]json.RawMessage, fields []string) map[string]json.RawMessage {
+	selected := make(map[string]json.RawMessage, len(fields))
+	for _, field := range fields {
+		if value, ok := object[field]; ok {
+			selected[field] = value
+		}
+	}
+	return selected
+}
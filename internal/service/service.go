@@ -2,11 +2,20 @@ package service
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/yourusername/supabase-redis-middleware/internal/cache"
+	"github.com/yourusername/supabase-redis-middleware/internal/logger"
 	"github.com/yourusername/supabase-redis-middleware/internal/repository"
 	"go.uber.org/zap"
 )
@@ -17,127 +26,462 @@ type Response struct {
 	Data     interface{}       `json:"data,omitempty"`
 	Metadata *ResponseMetadata `json:"metadata,omitempty"`
 	Error    *ErrorDetail      `json:"error,omitempty"`
+	ETag     string            `json:"-"` // Hash of the cached payload, sent as the ETag header
 }
 
 // ResponseMetadata contains metadata about the response
 type ResponseMetadata struct {
-	CachedAt   *time.Time              `json:"cached_at,omitempty"`
-	FromCache  bool                    `json:"from_cache"`
-	Pagination *repository.Pagination  `json:"pagination,omitempty"`
+	CachedAt   *time.Time             `json:"cached_at,omitempty"`
+	FromCache  bool                   `json:"from_cache"`
+	Pagination *repository.Pagination `json:"pagination,omitempty"`
+	NextCursor string                 `json:"next_cursor,omitempty"` // Pass as cursor to fetch the next page
+	TotalCount *int64                 `json:"total_count,omitempty"` // Only when requested with include_total
+	Stale      bool                   `json:"stale,omitempty"`       // Served from an expired copy while the source is unavailable
+	MissingIDs []string               `json:"missing_ids,omitempty"` // IDs asked of GetItemsByIDs that match no item
+	CacheTTL   int64                  `json:"cache_ttl,omitempty"`   // Seconds fresh results are cached for, when overridden
 }
 
 // ErrorDetail contains error information
 type ErrorDetail struct {
-	Code    string `json:"code"`
-	Message string `json:"message"`
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	RequestID string `json:"request_id,omitempty"` // Set by the handler writing the response
 }
 
-// DomainService defines the interface for domain-specific operations
-type DomainService interface {
-	GetItems(ctx context.Context, table string, filters map[string]interface{}, pagination repository.Pagination) (*Response, error)
-	GetItemByID(ctx context.Context, table string, id string) (*Response, error)
+// Record is an item as Supabase returns it, the model of services without a typed one
+type Record = map[string]interface{}
+
+// Result is a Response whose data has a compile-time type
+// It is written as the equivalent Response, so the data is left out of errors
+type Result[T any] struct {
+	Status   string
+	Data     T
+	Metadata *ResponseMetadata
+	Error    *ErrorDetail
+	ETag     string
+}
+
+// Response returns the equivalent untyped Response, for handlers writing it
+func (r *Result[T]) Response() *Response {
+	resp := &Response{
+		Status:   r.Status,
+		Metadata: r.Metadata,
+		Error:    r.Error,
+		ETag:     r.ETag,
+	}
+	if r.Error == nil {
+		resp.Data = r.Data
+	}
+	return resp
+}
+
+// MarshalJSON encodes r as its Response
+func (r Result[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.Response())
+}
+
+// failed returns the Result of an error response
+func failed[T any](resp *Response) *Result[T] {
+	return &Result[T]{Status: resp.Status, Error: resp.Error}
+}
+
+// DomainService defines the interface for domain-specific operations on items of type T
+// Items are decoded from Supabase and the cache straight into T; database function
+// results aren't items, so CallRPC passes them through as raw JSON
+type DomainService[T any] interface {
+	GetItems(ctx context.Context, table string, filters map[string]interface{}, pagination repository.Pagination, opts ...repository.QueryOption) (*Result[[]T], error)
+	GetItemByID(ctx context.Context, table string, id string, opts ...repository.QueryOption) (*Result[T], error)
+	GetItemsByIDs(ctx context.Context, table string, ids []string, opts ...repository.QueryOption) (*Result[[]T], error)
+	CallRPC(ctx context.Context, fn string, params map[string]interface{}) (*Response, error)
+	CreateItem(ctx context.Context, table string, item T) (*Result[T], error)
+	UpdateItem(ctx context.Context, table string, id string, changes map[string]interface{}) (*Result[T], error)
+	DeleteItem(ctx context.Context, table string, id string) (*Response, error)
+}
+
+// AccessTracker records cache key accesses so hot keys can be refreshed ahead of expiry
+// refresh reloads key and caches it as the lookup would, with its stale copy and count
+type AccessTracker interface {
+	Track(ctx context.Context, key string, refresh func(ctx context.Context) error)
+}
+
+// Metrics records how GetItems and GetItemByID reads are served, per domain (table)
+// and operation: whether the cache had them, how long Supabase took when it didn't, and
+// how long encoding and decoding their JSON took
+type Metrics interface {
+	ObserveCacheLookup(domain, operation string, hit bool)
+	ObserveRepository(domain, operation string, duration time.Duration, err error)
+	ObserveSerialization(domain, operation string, duration time.Duration)
+}
+
+// Operations reported to Metrics
+const (
+	OperationGetItems    = "get_items"
+	OperationGetItemByID = "get_item_by_id"
+)
+
+// Fetch is a read a Transformer may adjust before it's looked up and made
+// Filters is a copy of the caller's, set for GetItems; IDs is set for GetItemByID and
+// GetItemsByIDs and can't be changed
+type Fetch struct {
+	Table   string
+	IDs     []string
+	Filters map[string]interface{}
+	Options []repository.QueryOption
 }
 
-// domainService implements DomainService with caching logic
+// Transformer lets a vertical adapt a table's reads without changing the service:
+// BeforeFetch may add filters or options to a read, or reject it with an error, and
+// AfterFetch may mask, rename or derive fields of each row fetched or written, in place.
+// Rows are transformed before they're cached, so cached results are transformed ones
+// and masked fields never reach the cache. AfterFetch must keep the id column
+type Transformer interface {
+	BeforeFetch(ctx context.Context, fetch *Fetch) error
+	AfterFetch(ctx context.Context, table string, row map[string]interface{}) error
+}
+
+// Option configures optional domain service behaviour
+type Option func(*domainService)
+
+// WithAccessTracker enables refresh-ahead by reporting every lookup to tracker
+func WithAccessTracker(tracker AccessTracker) Option {
+	return func(s *domainService) {
+		s.tracker = tracker
+	}
+}
+
+// WithTransformer runs transformer on the reads of table, after any added before it
+func WithTransformer(table string, transformer Transformer) Option {
+	return func(s *domainService) {
+		if s.transformers == nil {
+			s.transformers = make(map[string][]Transformer)
+		}
+		s.transformers[table] = append(s.transformers[table], transformer)
+	}
+}
+
+// WithMetrics reports how reads are served to metrics
+func WithMetrics(metrics Metrics) Option {
+	return func(s *domainService) {
+		s.metrics = metrics
+	}
+}
+
+// WithReloadableTTL caches reads for ttl, which may change while the service runs, in
+// place of the cache TTL it was created with
+func WithReloadableTTL(ttl *cache.TTL) Option {
+	return func(s *domainService) {
+		s.reloadableTTL = ttl
+	}
+}
+
+// WithStaleCopies keeps a copy of each result for grace past its cache TTL, and serves it,
+// marked stale, once the cached result has expired while Supabase or Postgres is unavailable
+func WithStaleCopies(grace time.Duration) Option {
+	return func(s *domainService) {
+		s.staleGrace = grace
+	}
+}
+
+// domainService holds the caching logic shared by every DomainService and the catalog service
 type domainService struct {
 	cache      cache.CacheService
 	repository repository.SupabaseRepository
 	logger     *zap.Logger
 	cacheTTL   time.Duration
+	staleGrace time.Duration
+	tracker    AccessTracker
+	metrics    Metrics
+	// reloadableTTL, if set, replaces cacheTTL
+	reloadableTTL *cache.TTL
+	// Transformers per table, in the order they run
+	transformers map[string][]Transformer
 }
 
-// NewDomainService creates a new domain service instance
+// NewDomainService creates a new domain service instance serving Records
 func NewDomainService(
 	cache cache.CacheService,
 	repository repository.SupabaseRepository,
 	logger *zap.Logger,
 	cacheTTL time.Duration,
-) DomainService {
-	return &domainService{
+	opts ...Option,
+) DomainService[Record] {
+	return NewTypedDomainService[Record](cache, repository, logger, cacheTTL, opts...)
+}
+
+// NewTypedDomainService creates a new domain service instance serving items of type T,
+// which rows and cached results are decoded into as JSON
+func NewTypedDomainService[T any](
+	cache cache.CacheService,
+	repository repository.SupabaseRepository,
+	logger *zap.Logger,
+	cacheTTL time.Duration,
+	opts ...Option,
+) DomainService[T] {
+	s := &domainService{
 		cache:      cache,
 		repository: repository,
 		logger:     logger,
 		cacheTTL:   cacheTTL,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return &typedService[T]{domainService: s}
+}
+
+// typedService implements DomainService for items of type T
+type typedService[T any] struct {
+	*domainService
+}
+
+// decode returns v, a repository result, as an R, decoding data, its JSON encoding,
+// unless v already is one, as it is for services of Records
+func decode[R any](v interface{}, data []byte) (R, error) {
+	if r, ok := v.(R); ok {
+		return r, nil
+	}
+	var r R
+	err := json.Unmarshal(data, &r)
+	return r, err
 }
 
 // GetItems retrieves items with cache-first logic
-func (s *domainService) GetItems(ctx context.Context, table string, filters map[string]interface{}, pagination repository.Pagination) (*Response, error) {
+// Query options, such as the columns selected, are part of the cache key. With
+// IncludeTotal the count comes back with the page and is cached apart from it, as the
+// catalog service does, so every page of a query shares one count
+func (s *typedService[T]) GetItems(ctx context.Context, table string, filters map[string]interface{}, pagination repository.Pagination, opts ...repository.QueryOption) (*Result[[]T], error) {
+	fetch := &Fetch{Table: table, Filters: make(map[string]interface{}, len(filters)), Options: opts}
+	for column, value := range filters {
+		fetch.Filters[column] = value
+	}
+	if err := s.beforeFetch(ctx, fetch); err != nil {
+		return failed[[]T](s.writeErrorResponse(err)), nil
+	}
+	filters, opts = fetch.Filters, fetch.Options
+
 	// Generate cache key
-	cacheParams := s.buildCacheParams(filters, pagination)
-	cacheKey := s.cache.GenerateKey(table, cacheParams)
+	options, err := repository.NewQueryOptions(opts...)
+	if err != nil {
+		return failed[[]T](invalidInputResponse(err)), nil
+	}
+	cacheParams, err := s.buildCacheParams(filters, pagination)
+	if err != nil {
+		return failed[[]T](invalidInputResponse(err)), nil
+	}
+	options.CacheParams(cacheParams)
+	cacheKey := s.cacheKey(ctx, table, cacheParams)
+
+	var countKey string
+	if pagination.IncludeTotal {
+		countParams, _ := s.buildCacheParams(filters, repository.Pagination{})
+		countParams["view"] = "count"
+		countParams["count"] = options.Count
+		countKey = s.cacheKey(ctx, table, countParams)
+	}
+
+	s.trackRefresh(ctx, cacheKey, func(ctx context.Context) error {
+		var items []map[string]interface{}
+		var total int64
+		var err error
+		if countKey == "" {
+			items, err = s.repository.Query(ctx, table, filters, pagination, opts...)
+		} else {
+			items, total, err = s.repository.QueryWithCount(ctx, table, filters, pagination, opts...)
+		}
+		if err != nil {
+			return err
+		}
+		if err := s.afterFetch(ctx, table, items...); err != nil {
+			return err
+		}
+		data, err := json.Marshal(items)
+		if err != nil {
+			return err
+		}
+		s.cacheResult(ctx, cacheKey, data)
+		if countKey != "" {
+			if data, err := json.Marshal(total); err == nil {
+				s.cacheResult(ctx, countKey, data)
+			}
+		}
+		return nil
+	})
 
 	// Check cache first
 	cachedData, err := s.cache.Get(ctx, cacheKey)
 	if err == nil && cachedData != nil {
-		// Cache hit
-		var items []map[string]interface{}
-		if err := json.Unmarshal(cachedData, &items); err == nil {
-			s.logger.Info("Cache hit",
+		// Cache hit, unless the count asked for isn't cached
+		var items []T
+		total, counted := s.cachedCount(ctx, countKey)
+		decodeStart := time.Now()
+		if err := json.Unmarshal(cachedData, &items); err == nil && counted {
+			s.observeSerialization(table, OperationGetItems, decodeStart)
+			s.observeCacheLookup(table, OperationGetItems, true)
+			s.log(ctx).Info("Cache hit",
 				zap.String("key", cacheKey),
 				zap.String("domain", table),
 			)
 
 			cachedAt := time.Now()
-			return &Response{
+			return &Result[[]T]{
 				Status: "success",
 				Data:   items,
+				ETag:   contentETag(cachedData),
 				Metadata: &ResponseMetadata{
 					FromCache:  true,
 					CachedAt:   &cachedAt,
 					Pagination: &pagination,
+					TotalCount: total,
 				},
 			}, nil
 		}
 	}
 
 	// Cache miss - fetch from Supabase
-	s.logger.Info("Cache miss",
+	s.log(ctx).Info("Cache miss",
 		zap.String("key", cacheKey),
 		zap.String("domain", table),
 	)
 
-	items, err := s.repository.Query(ctx, table, filters, pagination)
+	s.observeCacheLookup(table, OperationGetItems, false)
+
+	var rows []map[string]interface{}
+	var total *int64
+	queryStart := time.Now()
+	if countKey == "" {
+		rows, err = s.repository.Query(ctx, table, filters, pagination, opts...)
+	} else {
+		var count int64
+		rows, count, err = s.repository.QueryWithCount(ctx, table, filters, pagination, opts...)
+		total = &count
+	}
+	s.observeRepository(table, OperationGetItems, queryStart, err)
+	if err == nil {
+		err = s.afterFetch(ctx, table, rows...)
+	}
 	if err != nil {
-		return s.errorResponse(err), nil
+		if data, ok := s.staleCopy(ctx, err, cacheKey); ok {
+			var staleItems []T
+			if json.Unmarshal(data, &staleItems) == nil {
+				// The count is left out unless a fresh or stale one is cached too
+				staleTotal, counted := s.cachedCount(ctx, countKey)
+				if !counted {
+					if countData, ok := s.staleCopy(ctx, err, countKey); ok {
+						var count int64
+						if json.Unmarshal(countData, &count) == nil {
+							staleTotal = &count
+						}
+					}
+				}
+				return &Result[[]T]{
+					Status: "success",
+					Data:   staleItems,
+					ETag:   contentETag(data),
+					Metadata: &ResponseMetadata{
+						FromCache:  true,
+						Stale:      true,
+						Pagination: &pagination,
+						TotalCount: staleTotal,
+					},
+				}, nil
+			}
+		}
+		return failed[[]T](s.errorResponse(err)), nil
 	}
 
 	// Update cache
-	if data, err := json.Marshal(items); err == nil {
-		_ = s.cache.Set(ctx, cacheKey, data, s.cacheTTL)
+	encodeStart := time.Now()
+	data, err := json.Marshal(rows)
+	if err != nil {
+		return failed[[]T](s.errorResponse(repository.NewQueryError(err))), nil
+	}
+	items, err := decode[[]T](rows, data)
+	if err != nil {
+		return failed[[]T](s.errorResponse(repository.NewQueryError(err))), nil
+	}
+	s.observeSerialization(table, OperationGetItems, encodeStart)
+	s.cacheResult(ctx, cacheKey, data)
+	if total != nil {
+		if data, err := json.Marshal(*total); err == nil {
+			s.cacheResult(ctx, countKey, data)
+		}
 	}
 
-	return &Response{
+	return &Result[[]T]{
 		Status: "success",
 		Data:   items,
+		ETag:   contentETag(data),
 		Metadata: &ResponseMetadata{
 			FromCache:  false,
 			Pagination: &pagination,
+			TotalCount: total,
 		},
 	}, nil
 }
 
+// cachedCount returns the count cached under key, reporting whether one was found
+// An empty key means no count was asked for, which is always found
+func (s *domainService) cachedCount(ctx context.Context, key string) (*int64, bool) {
+	if key == "" {
+		return nil, true
+	}
+	data, err := s.cache.Get(ctx, key)
+	if err != nil || data == nil {
+		return nil, false
+	}
+	var total int64
+	if err := json.Unmarshal(data, &total); err != nil {
+		return nil, false
+	}
+	return &total, true
+}
+
 // GetItemByID retrieves a single item by ID with cache-first logic
-func (s *domainService) GetItemByID(ctx context.Context, table string, id string) (*Response, error) {
+func (s *typedService[T]) GetItemByID(ctx context.Context, table string, id string, opts ...repository.QueryOption) (*Result[T], error) {
+	fetch := &Fetch{Table: table, IDs: []string{id}, Options: opts}
+	if err := s.beforeFetch(ctx, fetch); err != nil {
+		return failed[T](s.writeErrorResponse(err)), nil
+	}
+	opts = fetch.Options
+
 	// Generate cache key
+	options, err := repository.NewQueryOptions(opts...)
+	if err != nil {
+		return failed[T](invalidInputResponse(err)), nil
+	}
 	cacheParams := map[string]string{"id": id}
-	cacheKey := s.cache.GenerateKey(table, cacheParams)
+	options.CacheParams(cacheParams)
+	cacheKey := s.cacheKey(ctx, table, cacheParams)
+	s.track(ctx, cacheKey, func(ctx context.Context) ([]byte, error) {
+		item, err := s.repository.GetByID(ctx, table, id, opts...)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.afterFetch(ctx, table, item); err != nil {
+			return nil, err
+		}
+		return json.Marshal(item)
+	})
 
 	// Check cache first
 	cachedData, err := s.cache.Get(ctx, cacheKey)
 	if err == nil && cachedData != nil {
 		// Cache hit
-		var item map[string]interface{}
+		var item T
+		decodeStart := time.Now()
 		if err := json.Unmarshal(cachedData, &item); err == nil {
-			s.logger.Info("Cache hit",
+			s.observeSerialization(table, OperationGetItemByID, decodeStart)
+			s.observeCacheLookup(table, OperationGetItemByID, true)
+			s.log(ctx).Info("Cache hit",
 				zap.String("key", cacheKey),
 				zap.String("domain", table),
 			)
 
 			cachedAt := time.Now()
-			return &Response{
+			return &Result[T]{
 				Status: "success",
 				Data:   item,
+				ETag:   contentETag(cachedData),
 				Metadata: &ResponseMetadata{
 					FromCache: true,
 					CachedAt:  &cachedAt,
@@ -147,37 +491,602 @@ func (s *domainService) GetItemByID(ctx context.Context, table string, id string
 	}
 
 	// Cache miss - fetch from Supabase
-	s.logger.Info("Cache miss",
+	s.log(ctx).Info("Cache miss",
 		zap.String("key", cacheKey),
 		zap.String("domain", table),
 	)
 
-	item, err := s.repository.GetByID(ctx, table, id)
+	s.observeCacheLookup(table, OperationGetItemByID, false)
+
+	getStart := time.Now()
+	row, err := s.repository.GetByID(ctx, table, id, opts...)
+	s.observeRepository(table, OperationGetItemByID, getStart, err)
+	if err == nil {
+		err = s.afterFetch(ctx, table, row)
+	}
 	if err != nil {
-		return s.errorResponse(err), nil
+		if data, ok := s.staleCopy(ctx, err, cacheKey); ok {
+			var staleItem T
+			if json.Unmarshal(data, &staleItem) == nil {
+				return &Result[T]{
+					Status: "success",
+					Data:   staleItem,
+					ETag:   contentETag(data),
+					Metadata: &ResponseMetadata{
+						FromCache: true,
+						Stale:     true,
+					},
+				}, nil
+			}
+		}
+		return failed[T](s.errorResponse(err)), nil
 	}
 
 	// Update cache
-	if data, err := json.Marshal(item); err == nil {
-		_ = s.cache.Set(ctx, cacheKey, data, s.cacheTTL)
+	encodeStart := time.Now()
+	data, err := json.Marshal(row)
+	if err != nil {
+		return failed[T](s.errorResponse(repository.NewQueryError(err))), nil
 	}
+	item, err := decode[T](row, data)
+	if err != nil {
+		return failed[T](s.errorResponse(repository.NewQueryError(err))), nil
+	}
+	s.observeSerialization(table, OperationGetItemByID, encodeStart)
+	s.cacheResult(ctx, cacheKey, data)
 
-	return &Response{
+	return &Result[T]{
 		Status: "success",
 		Data:   item,
+		ETag:   contentETag(data),
+		Metadata: &ResponseMetadata{
+			FromCache: false,
+		},
+	}, nil
+}
+
+// MaxBatchIDs is the most IDs GetItemsByIDs takes at once, keeping the in filter for its
+// misses within URL length limits
+const MaxBatchIDs = 100
+
+// GetItemsByIDs retrieves the items with ids, in their order, with cache-first logic
+// Items share their cache entries with GetItemByID: the cached ones are read in one MGET,
+// the rest fetched in a single in query and cached. IDs matching no item are left out of
+// the data and listed in the metadata
+func (s *typedService[T]) GetItemsByIDs(ctx context.Context, table string, ids []string, opts ...repository.QueryOption) (*Result[[]T], error) {
+	if len(ids) == 0 {
+		return failed[[]T](invalidInputResponse(errors.New("ids must not be empty"))), nil
+	}
+	if len(ids) > MaxBatchIDs {
+		return failed[[]T](invalidInputResponse(fmt.Errorf("at most %d ids may be requested at once", MaxBatchIDs))), nil
+	}
+	fetch := &Fetch{Table: table, IDs: ids, Options: opts}
+	if err := s.beforeFetch(ctx, fetch); err != nil {
+		return failed[[]T](s.writeErrorResponse(err)), nil
+	}
+	opts = fetch.Options
+	options, err := repository.NewQueryOptions(opts...)
+	if err != nil {
+		return failed[[]T](invalidInputResponse(err)), nil
+	}
+	if len(options.Columns) > 0 && !slices.Contains(options.Columns, "id") {
+		// Fetched rows are matched to their IDs by the id column
+		return failed[[]T](invalidInputResponse(errors.New("selected columns must include id"))), nil
+	}
+
+	// Generate a cache key per distinct ID, the one GetItemByID uses
+	keys := make(map[string]string, len(ids))
+	var distinct, keyList []string
+	for _, id := range ids {
+		if _, seen := keys[id]; seen {
+			continue
+		}
+		cacheParams := map[string]string{"id": id}
+		options.CacheParams(cacheParams)
+		keys[id] = s.cacheKey(ctx, table, cacheParams)
+		distinct = append(distinct, id)
+		keyList = append(keyList, keys[id])
+
+		id := id
+		s.track(ctx, keys[id], func(ctx context.Context) ([]byte, error) {
+			item, err := s.repository.GetByID(ctx, table, id, opts...)
+			if err != nil {
+				return nil, err
+			}
+			if err := s.afterFetch(ctx, table, item); err != nil {
+				return nil, err
+			}
+			return json.Marshal(item)
+		})
+	}
+
+	// Check cache first, in a single round trip
+	cached, _ := s.cache.GetMany(ctx, keyList)
+	found := make(map[string]T, len(distinct))
+	var misses []string
+	stale := false
+	for _, id := range distinct {
+		var item T
+		if data, ok := cached[keys[id]]; ok && string(data) != "null" && json.Unmarshal(data, &item) == nil {
+			found[id] = item
+			continue
+		}
+		misses = append(misses, id)
+	}
+
+	s.log(ctx).Info("Batch cache lookup",
+		zap.String("domain", table),
+		zap.Int("ids", len(distinct)),
+		zap.Int("misses", len(misses)),
+	)
+
+	if len(misses) > 0 {
+		// Fetch every miss from Supabase at once
+		filters := map[string]interface{}{"id": map[string]interface{}{repository.FilterIn: misses}}
+		rows, err := s.repository.Query(ctx, table, filters, repository.Pagination{Limit: len(misses)}, opts...)
+		if err == nil {
+			err = s.afterFetch(ctx, table, rows...)
+		}
+		if err != nil {
+			// Stale copies stand in only when every miss has one, so no item goes missing
+			if !s.staleItems(ctx, err, misses, keys, found) {
+				return failed[[]T](s.errorResponse(err)), nil
+			}
+			stale = true
+			misses = nil
+		}
+
+		// Back-fill the cache
+		fresh := make(map[string][]byte, len(rows))
+		for _, row := range rows {
+			id := itemID(row)
+			key, ok := keys[id]
+			if !ok {
+				continue
+			}
+			data, err := json.Marshal(row)
+			if err != nil {
+				continue
+			}
+			if item, err := decode[T](row, data); err == nil {
+				found[id] = item
+				fresh[key] = data
+			}
+		}
+		s.cacheResults(ctx, fresh)
+	}
+
+	items := make([]T, 0, len(ids))
+	var missing []string
+	for _, id := range ids {
+		if item, ok := found[id]; ok {
+			items = append(items, item)
+		} else {
+			missing = append(missing, id)
+		}
+	}
+
+	var etag string
+	if data, err := json.Marshal(items); err == nil {
+		etag = contentETag(data)
+	}
+	metadata := &ResponseMetadata{
+		FromCache:  len(misses) == 0,
+		Stale:      stale,
+		MissingIDs: missing,
+	}
+	if metadata.FromCache && !stale {
+		cachedAt := time.Now()
+		metadata.CachedAt = &cachedAt
+	}
+
+	return &Result[[]T]{
+		Status:   "success",
+		Data:     items,
+		ETag:     etag,
+		Metadata: metadata,
+	}, nil
+}
+
+// staleItems adds the stale copies of misses to found when err means the source is
+// unavailable, reporting whether every miss had one; found is left as is otherwise
+func (s *typedService[T]) staleItems(ctx context.Context, err error, misses []string, keys map[string]string, found map[string]T) bool {
+	if s.staleGrace <= 0 || !repository.IsUnavailable(err) {
+		return false
+	}
+	staleKeys := make([]string, len(misses))
+	for i, id := range misses {
+		staleKeys[i] = staleKey(keys[id])
+	}
+	copies, getErr := s.cache.GetMany(ctx, staleKeys)
+	if getErr != nil {
+		return false
+	}
+
+	items := make(map[string]T, len(misses))
+	for i, id := range misses {
+		var item T
+		data, ok := copies[staleKeys[i]]
+		if !ok || string(data) == "null" || json.Unmarshal(data, &item) != nil {
+			return false
+		}
+		items[id] = item
+	}
+	for id, item := range items {
+		found[id] = item
+	}
+
+	s.log(ctx).Warn("Serving stale copies while the source is unavailable",
+		zap.Int("ids", len(misses)),
+		zap.Error(err))
+	cache.MarkStale(ctx)
+	return true
+}
+
+// CallRPC calls a database function with cache-first logic
+// Results are cached per function and arguments, under cache.RPCDomain(fn), and passed
+// through as raw JSON
+func (s *typedService[T]) CallRPC(ctx context.Context, fn string, params map[string]interface{}) (*Response, error) {
+	// Generate cache key; arguments marshal with sorted keys, so equal arguments share it
+	args, err := json.Marshal(params)
+	if err != nil {
+		return invalidInputResponse(err), nil
+	}
+	cacheKey := s.cacheKey(ctx, cache.RPCDomain(fn), map[string]string{"args": string(args)})
+	s.track(ctx, cacheKey, func(ctx context.Context) ([]byte, error) {
+		return s.repository.RPC(ctx, fn, params)
+	})
+
+	// Check cache first
+	cachedData, err := s.cache.Get(ctx, cacheKey)
+	if err == nil && cachedData != nil && json.Valid(cachedData) {
+		s.log(ctx).Info("Cache hit",
+			zap.String("key", cacheKey),
+			zap.String("function", fn),
+		)
+
+		cachedAt := time.Now()
+		return &Response{
+			Status: "success",
+			Data:   json.RawMessage(cachedData),
+			ETag:   contentETag(cachedData),
+			Metadata: &ResponseMetadata{
+				FromCache: true,
+				CachedAt:  &cachedAt,
+			},
+		}, nil
+	}
+
+	// Cache miss - call the function
+	s.log(ctx).Info("Cache miss",
+		zap.String("key", cacheKey),
+		zap.String("function", fn),
+	)
+
+	result, err := s.repository.RPC(ctx, fn, params)
+	if err != nil {
+		if data, ok := s.staleCopy(ctx, err, cacheKey); ok && json.Valid(data) {
+			return staleResponse(data), nil
+		}
+		return s.errorResponse(err), nil
+	}
+
+	// Update cache
+	s.cacheResult(ctx, cacheKey, result)
+
+	return &Response{
+		Status: "success",
+		Data:   result,
+		ETag:   contentETag(result),
 		Metadata: &ResponseMetadata{
 			FromCache: false,
 		},
 	}, nil
 }
 
+// CreateItem inserts item into table and returns the row as stored
+// The table's cached listings are cleared and the row is cached for GetItemByID, so
+// reads see the write at once
+func (s *typedService[T]) CreateItem(ctx context.Context, table string, item T) (*Result[T], error) {
+	data, err := json.Marshal(item)
+	if err != nil {
+		return failed[T](invalidInputResponse(err)), nil
+	}
+	record, err := decode[Record](item, data)
+	if err != nil {
+		return failed[T](invalidInputResponse(errors.New("item must encode as a JSON object"))), nil
+	}
+
+	row, err := s.repository.Insert(ctx, table, record)
+	if err != nil {
+		return failed[T](s.writeErrorResponse(err)), nil
+	}
+	return s.written(ctx, table, itemID(row), row), nil
+}
+
+// UpdateItem sets the columns in changes on the row of table with id and returns the row
+// as stored, refreshing the cache as CreateItem does
+func (s *typedService[T]) UpdateItem(ctx context.Context, table string, id string, changes map[string]interface{}) (*Result[T], error) {
+	row, err := s.repository.Update(ctx, table, id, changes)
+	if err != nil {
+		return failed[T](s.writeErrorResponse(err)), nil
+	}
+	return s.written(ctx, table, id, row), nil
+}
+
+// DeleteItem removes the row of table with id and clears the table's cached results
+func (s *typedService[T]) DeleteItem(ctx context.Context, table string, id string) (*Response, error) {
+	if err := s.repository.Delete(ctx, table, id); err != nil {
+		return s.writeErrorResponse(err), nil
+	}
+
+	s.writeThrough(ctx, table, id, nil)
+	return &Response{Status: "success"}, nil
+}
+
+// written refreshes the cache after row, with id, was written to table and returns it
+func (s *typedService[T]) written(ctx context.Context, table string, id string, row map[string]interface{}) *Result[T] {
+	if err := s.afterFetch(ctx, table, row); err != nil {
+		s.writeThrough(ctx, table, "", nil)
+		return failed[T](s.errorResponse(err))
+	}
+	data, err := json.Marshal(row)
+	if err != nil {
+		s.writeThrough(ctx, table, "", nil)
+		return failed[T](s.errorResponse(repository.NewQueryError(err)))
+	}
+	item, err := decode[T](row, data)
+	if err != nil {
+		s.writeThrough(ctx, table, "", nil)
+		return failed[T](s.errorResponse(repository.NewQueryError(err)))
+	}
+
+	s.writeThrough(ctx, table, id, data)
+	return &Result[T]{
+		Status: "success",
+		Data:   item,
+		Metadata: &ResponseMetadata{
+			FromCache: false,
+		},
+	}
+}
+
+// writeThrough clears every cached result for table after a write, stale copies
+// included, then caches data, the written row, under the detail key of id unless it
+// was deleted. Only the caller's own partition is refreshed; other callers' entries are
+// just cleared
+func (s *domainService) writeThrough(ctx context.Context, table string, id string, data []byte) {
+	cache.InvalidateDomains(ctx, s.cache, s.logger, table)
+	if data == nil || id == "" {
+		return
+	}
+	s.cacheResult(ctx, s.cacheKey(ctx, table, map[string]string{"id": id}), data)
+}
+
+// itemID returns the id column of a row as GetItemByID takes it, or "" if it has none
+func itemID(item map[string]interface{}) string {
+	switch id := item["id"].(type) {
+	case string:
+		return id
+	case float64:
+		return strconv.FormatFloat(id, 'f', -1, 64)
+	default:
+		return ""
+	}
+}
+
+// cacheResult caches a result under key, keeping a stale copy when enabled
+func (s *domainService) cacheResult(ctx context.Context, key string, data []byte) {
+	ttl := s.ttl(ctx)
+	_ = s.cache.Set(ctx, key, data, ttl)
+	if s.staleGrace > 0 {
+		_ = s.cache.Set(ctx, staleKey(key), data, ttl+s.staleGrace)
+	}
+}
+
+// cacheResults caches results by key in one round trip, as cacheResult does
+func (s *domainService) cacheResults(ctx context.Context, entries map[string][]byte) {
+	if len(entries) == 0 {
+		return
+	}
+	ttl := s.ttl(ctx)
+	_ = s.cache.SetMany(ctx, entries, ttl)
+	if s.staleGrace > 0 {
+		stale := make(map[string][]byte, len(entries))
+		for key, data := range entries {
+			stale[staleKey(key)] = data
+		}
+		_ = s.cache.SetMany(ctx, stale, ttl+s.staleGrace)
+	}
+}
+
+// staleCopy returns the stale copy of key when err means the source is unavailable,
+// reporting whether one was found. Errors rejecting the request never serve one
+func (s *domainService) staleCopy(ctx context.Context, err error, key string) ([]byte, bool) {
+	if s.staleGrace <= 0 || key == "" || !repository.IsUnavailable(err) {
+		return nil, false
+	}
+	data, getErr := s.cache.Get(ctx, staleKey(key))
+	if getErr != nil || data == nil {
+		return nil, false
+	}
+
+	s.log(ctx).Warn("Serving stale copy while the source is unavailable",
+		zap.String("key", key),
+		zap.Error(err))
+	cache.MarkStale(ctx)
+	return data, true
+}
+
+// staleResponse returns the response serving data, a stale copy
+func staleResponse(data []byte) *Response {
+	return &Response{
+		Status: "success",
+		Data:   json.RawMessage(data),
+		ETag:   contentETag(data),
+		Metadata: &ResponseMetadata{
+			FromCache: true,
+			Stale:     true,
+		},
+	}
+}
+
+// staleKey returns the key of the stale copy of key
+// It shares key's domain prefix, so invalidating the domain clears the copy too
+func staleKey(key string) string {
+	return key + ":stale"
+}
+
+// cacheKey generates the cache key for params in domain, partitioned by the caller when
+// their Supabase JWT is attached to ctx, so results fetched under RLS aren't served to others,
+// by the request's domain, whose tables may live in a project or schema of their own,
+// and by the TTL override, if any
+func (s *domainService) cacheKey(ctx context.Context, domain string, params map[string]string) string {
+	if partition := s.ttlPartition(ctx); partition != "" {
+		params["ttl"] = partition
+	}
+	if partition := authPartition(repository.AccessTokenFrom(ctx)); partition != "" {
+		params["auth"] = partition
+	}
+	if project := repository.DomainFrom(ctx); project != "" {
+		params["project"] = project
+	}
+	return s.cache.GenerateKey(domain, params)
+}
+
+// authPartition returns the cache partition for a caller's Supabase JWT, or "" for none
+// The token isn't verified here, PostgREST verifies it on a miss, so claims can't be trusted
+// on a hit: only the anon role, which a forged token gains nothing from, is shared by every
+// caller, and any other token gets a partition of its own
+func authPartition(token string) string {
+	if token == "" {
+		return ""
+	}
+
+	var claims struct {
+		Sub  string `json:"sub"`
+		Role string `json:"role"`
+	}
+	if parts := strings.Split(token, "."); len(parts) == 3 {
+		if payload, err := base64.RawURLEncoding.DecodeString(parts[1]); err == nil {
+			_ = json.Unmarshal(payload, &claims)
+		}
+	}
+	if claims.Role == "anon" && claims.Sub == "" {
+		return "role:anon"
+	}
+
+	sum := sha256.Sum256([]byte(token))
+	return "token:" + hex.EncodeToString(sum[:])[:16]
+}
+
+// log returns the logger for a call made with ctx, carrying its request's fields
+func (s *domainService) log(ctx context.Context) *zap.Logger {
+	return logger.FromContext(ctx, s.logger)
+}
+
+// track reports a lookup of key to the access tracker, if refresh-ahead is enabled, to be
+// refreshed by caching what loader returns under it
+func (s *domainService) track(ctx context.Context, key string, loader func(ctx context.Context) ([]byte, error)) {
+	s.trackRefresh(ctx, key, func(ctx context.Context) error {
+		data, err := loader(ctx)
+		if err != nil {
+			return err
+		}
+		s.cacheResult(ctx, key, data)
+		return nil
+	})
+}
+
+// trackRefresh reports a lookup to the access tracker, if refresh-ahead is enabled
+// Lookups made with a caller's token aren't tracked, since a refresh would run without it;
+// refreshes run in the lookup's domain and with its TTL, so they query the same project
+// and cache what the lookup would
+func (s *domainService) trackRefresh(ctx context.Context, key string, refresh func(ctx context.Context) error) {
+	if s.tracker == nil || repository.AccessTokenFrom(ctx) != "" {
+		return
+	}
+	domain, ttl := repository.DomainFrom(ctx), TTLOverrideFrom(ctx)
+	s.tracker.Track(ctx, key, func(ctx context.Context) error {
+		if domain != "" {
+			ctx = repository.WithDomain(ctx, domain)
+		}
+		if ttl > 0 {
+			ctx = WithTTLOverride(ctx, ttl)
+		}
+		return refresh(ctx)
+	})
+}
+
+// beforeFetch runs the transformers of fetch's table on it
+// The IDs are a copy, since the caller's are the ones read
+func (s *domainService) beforeFetch(ctx context.Context, fetch *Fetch) error {
+	fetch.IDs = slices.Clone(fetch.IDs)
+	for _, transformer := range s.transformers[fetch.Table] {
+		if err := transformer.BeforeFetch(ctx, fetch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// afterFetch runs the transformers of table on rows fetched or written, in place
+func (s *domainService) afterFetch(ctx context.Context, table string, rows ...map[string]interface{}) error {
+	for _, transformer := range s.transformers[table] {
+		for _, row := range rows {
+			if err := transformer.AfterFetch(ctx, table, row); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// observeCacheLookup reports a cache lookup for operation on domain, if metrics are enabled
+func (s *domainService) observeCacheLookup(domain, operation string, hit bool) {
+	if s.metrics != nil {
+		s.metrics.ObserveCacheLookup(domain, operation, hit)
+	}
+}
+
+// observeRepository reports a repository call for operation on domain made since start
+func (s *domainService) observeRepository(domain, operation string, start time.Time, err error) {
+	if s.metrics != nil {
+		s.metrics.ObserveRepository(domain, operation, time.Since(start), err)
+	}
+}
+
+// observeSerialization reports JSON encoding or decoding for operation on domain done since start
+func (s *domainService) observeSerialization(domain, operation string, start time.Time) {
+	if s.metrics != nil {
+		s.metrics.ObserveSerialization(domain, operation, time.Since(start))
+	}
+}
+
 // buildCacheParams converts filters and pagination to cache parameters
-func (s *domainService) buildCacheParams(filters map[string]interface{}, pagination repository.Pagination) map[string]string {
+// Operator filters are keyed by column and operator, e.g. price.lt, so each condition
+// gets its own parameter, and or / and groups by their operator; invalid filters return
+// the repository's validation error
+func (s *domainService) buildCacheParams(filters map[string]interface{}, pagination repository.Pagination) (map[string]string, error) {
+	parsed, err := repository.ParseFilters(filters)
+	if err != nil {
+		return nil, err
+	}
+	groups, err := repository.ParseFilterGroups(filters)
+	if err != nil {
+		return nil, err
+	}
+
 	params := make(map[string]string)
 
 	// Add filters
-	for key, value := range filters {
-		params[key] = fmt.Sprintf("%v", value)
+	for _, filter := range parsed {
+		key, value := filter.CacheParam()
+		params[key] = value
+	}
+	for _, group := range groups {
+		key, value := group.CacheParam()
+		params[key] = value
 	}
 
 	// Add pagination
@@ -188,7 +1097,14 @@ func (s *domainService) buildCacheParams(filters map[string]interface{}, paginat
 		params["offset"] = fmt.Sprintf("%d", pagination.Offset)
 	}
 
-	return params
+	return params, nil
+}
+
+// contentETag returns a strong ETag for a cached payload
+// Hit and miss paths hash the same serialized bytes, so the tag is stable across both
+func contentETag(data []byte) string {
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
 }
 
 // errorResponse converts repository errors to Response format
@@ -213,11 +1129,34 @@ func (s *domainService) errorResponse(err error) *Response {
 	}
 }
 
+// writeErrorResponse converts a failed write's error, or that of a transformer rejecting
+// a read, to Response format; unlike reads, whose input is checked before the repository
+// is called, these learn of invalid input from Supabase or the transformer
+func (s *domainService) writeErrorResponse(err error) *Response {
+	if repository.GetStatusCode(err) == http.StatusBadRequest {
+		return invalidInputResponse(err)
+	}
+	return s.errorResponse(err)
+}
+
+// invalidInputResponse builds the error response for invalid filters or query options
+func invalidInputResponse(err error) *Response {
+	return &Response{
+		Status: "error",
+		Error: &ErrorDetail{
+			Code:    "INVALID_INPUT",
+			Message: err.Error(),
+		},
+	}
+}
+
 // statusCodeToErrorCode converts HTTP status codes to error codes
 func (s *domainService) statusCodeToErrorCode(statusCode int) string {
 	switch statusCode {
 	case 404:
 		return "NOT_FOUND"
+	case 409:
+		return "CONFLICT"
 	case 503:
 		return "SERVICE_UNAVAILABLE"
 	case 504:
@@ -2,11 +2,16 @@ package service
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/yourusername/supabase-redis-middleware/internal/cache"
 	"github.com/yourusername/supabase-redis-middleware/internal/repository"
 	"go.uber.org/zap"
 )
@@ -47,39 +52,163 @@ func (m *mockCacheService) Delete(ctx context.Context, key string) error {
 	return nil
 }
 
+func (m *mockCacheService) DeleteByPattern(ctx context.Context, pattern string) (int64, error) {
+	var deleted int64
+	prefix := strings.TrimSuffix(pattern, "*")
+	for key := range m.getData {
+		if strings.HasPrefix(key, prefix) {
+			delete(m.getData, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func (m *mockCacheService) InspectKeys(ctx context.Context, pattern string, limit int) ([]cache.KeyInfo, error) {
+	return []cache.KeyInfo{}, nil
+}
+
+func (m *mockCacheService) GetMany(ctx context.Context, keys []string) (map[string][]byte, error) {
+	result := make(map[string][]byte)
+	if m.shouldFail {
+		return result, m.getError
+	}
+	for _, key := range keys {
+		if data, ok := m.getData[key]; ok {
+			result[key] = data
+		}
+	}
+	return result, nil
+}
+
+func (m *mockCacheService) SetMany(ctx context.Context, entries map[string][]byte, ttl time.Duration) error {
+	for key, value := range entries {
+		if err := m.Set(ctx, key, value, ttl); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *mockCacheService) GetOrSet(ctx context.Context, key string, ttl time.Duration, loader func() ([]byte, error)) ([]byte, error) {
+	if data, _ := m.Get(ctx, key); data != nil {
+		return data, nil
+	}
+	data, err := loader()
+	if err != nil {
+		return nil, err
+	}
+	_ = m.Set(ctx, key, data, ttl)
+	return data, nil
+}
+
+func (m *mockCacheService) AcquireLock(ctx context.Context, name string, ttl time.Duration) (*cache.Lock, error) {
+	return &cache.Lock{Name: name, Token: "test", ExpiresAt: time.Now().Add(ttl)}, nil
+}
+
+func (m *mockCacheService) ReleaseLock(ctx context.Context, lock *cache.Lock) error {
+	return nil
+}
+
 func (m *mockCacheService) GenerateKey(domain string, params map[string]string) string {
 	if len(params) == 0 {
 		return domain
 	}
+	// Alternate views of a domain (counts, categories) must not collide with its pages
+	if view := params["view"]; view != "" {
+		return domain + ":" + view
+	}
 	return domain + ":cached"
 }
 
+func (m *mockCacheService) Stats() map[string]cache.DomainStats {
+	return map[string]cache.DomainStats{}
+}
+
 func (m *mockCacheService) Close() error {
 	return nil
 }
 
 type mockSupabaseRepository struct {
 	queryResult   []map[string]interface{}
+	queryCount    int64
 	getByIDResult map[string]interface{}
+	rpcResult     json.RawMessage
 	queryError    error
 	getByIDError  error
+	rpcError      error
+	writeResult   map[string]interface{}
+	writeError    error
+	countQueries  int
+	rpcCalls      int
+	writes        []string
+	inserted      map[string]interface{}
 }
 
-func (m *mockSupabaseRepository) Query(ctx context.Context, table string, filters map[string]interface{}, pagination repository.Pagination) ([]map[string]interface{}, error) {
+func (m *mockSupabaseRepository) Query(ctx context.Context, table string, filters map[string]interface{}, pagination repository.Pagination, opts ...repository.QueryOption) ([]map[string]interface{}, error) {
 	if m.queryError != nil {
 		return nil, m.queryError
 	}
 	return m.queryResult, nil
 }
 
-func (m *mockSupabaseRepository) GetByID(ctx context.Context, table string, id string) (map[string]interface{}, error) {
+func (m *mockSupabaseRepository) QueryWithCount(ctx context.Context, table string, filters map[string]interface{}, pagination repository.Pagination, opts ...repository.QueryOption) ([]map[string]interface{}, int64, error) {
+	m.countQueries++
+	if m.queryError != nil {
+		return nil, 0, m.queryError
+	}
+	return m.queryResult, m.queryCount, nil
+}
+
+func (m *mockSupabaseRepository) RPC(ctx context.Context, fn string, params map[string]interface{}) (json.RawMessage, error) {
+	m.rpcCalls++
+	if m.rpcError != nil {
+		return nil, m.rpcError
+	}
+	return m.rpcResult, nil
+}
+
+func (m *mockSupabaseRepository) GetByID(ctx context.Context, table string, id string, opts ...repository.QueryOption) (map[string]interface{}, error) {
 	if m.getByIDError != nil {
 		return nil, m.getByIDError
 	}
 	return m.getByIDResult, nil
 }
 
-func setupTestService(cache *mockCacheService, repo *mockSupabaseRepository) DomainService {
+func (m *mockSupabaseRepository) Insert(ctx context.Context, table string, record map[string]interface{}) (map[string]interface{}, error) {
+	m.writes = append(m.writes, "insert "+table)
+	m.inserted = record
+	if m.writeError != nil {
+		return nil, m.writeError
+	}
+	return m.writeResult, nil
+}
+
+func (m *mockSupabaseRepository) Update(ctx context.Context, table string, id string, changes map[string]interface{}) (map[string]interface{}, error) {
+	m.writes = append(m.writes, "update "+table+" "+id)
+	if m.writeError != nil {
+		return nil, m.writeError
+	}
+	return m.writeResult, nil
+}
+
+func (m *mockSupabaseRepository) Delete(ctx context.Context, table string, id string) error {
+	m.writes = append(m.writes, "delete "+table+" "+id)
+	return m.writeError
+}
+
+type mockAccessTracker struct {
+	refreshes map[string]func(ctx context.Context) error
+}
+
+func (m *mockAccessTracker) Track(ctx context.Context, key string, refresh func(ctx context.Context) error) {
+	if m.refreshes == nil {
+		m.refreshes = make(map[string]func(ctx context.Context) error)
+	}
+	m.refreshes[key] = refresh
+}
+
+func setupTestService(cache *mockCacheService, repo *mockSupabaseRepository) DomainService[Record] {
 	logger, _ := zap.NewDevelopment()
 	return NewDomainService(cache, repo, logger, 5*time.Minute)
 }
@@ -123,10 +252,7 @@ func TestGetItems_CacheHit(t *testing.T) {
 		t.Error("GetItems() should include cached_at timestamp")
 	}
 
-	items, ok := response.Data.([]map[string]interface{})
-	if !ok {
-		t.Fatal("GetItems() data should be []map[string]interface{}")
-	}
+	items := response.Data
 
 	if len(items) != 2 {
 		t.Errorf("GetItems() returned %d items, want 2", len(items))
@@ -170,10 +296,7 @@ func TestGetItems_CacheMiss(t *testing.T) {
 		t.Error("GetItems() should not include cached_at for cache miss")
 	}
 
-	items, ok := response.Data.([]map[string]interface{})
-	if !ok {
-		t.Fatal("GetItems() data should be []map[string]interface{}")
-	}
+	items := response.Data
 
 	if len(items) != 2 {
 		t.Errorf("GetItems() returned %d items, want 2", len(items))
@@ -186,6 +309,114 @@ func TestGetItems_CacheMiss(t *testing.T) {
 	}
 }
 
+func TestGetItems_ETagStableAcrossCache(t *testing.T) {
+	mockCache := &mockCacheService{
+		getData: make(map[string][]byte),
+	}
+	mockRepo := &mockSupabaseRepository{
+		queryResult: []map[string]interface{}{{"id": "1", "name": "Product 1"}},
+	}
+
+	service := setupTestService(mockCache, mockRepo)
+
+	ctx := context.Background()
+	filters := map[string]interface{}{"category": "electronics"}
+	pagination := repository.Pagination{Limit: 10}
+
+	miss, _ := service.GetItems(ctx, "products", filters, pagination)
+	hit, _ := service.GetItems(ctx, "products", filters, pagination)
+
+	if miss.ETag == "" {
+		t.Fatal("GetItems() should set an ETag on cache miss")
+	}
+	if !hit.Metadata.FromCache {
+		t.Fatal("second GetItems() should be served from cache")
+	}
+	if hit.ETag != miss.ETag {
+		t.Errorf("GetItems() ETag on hit = %s, want %s", hit.ETag, miss.ETag)
+	}
+}
+
+func TestGetItems_TracksAccessForRefresh(t *testing.T) {
+	mockCache := &mockCacheService{
+		getData: make(map[string][]byte),
+	}
+	mockRepo := &mockSupabaseRepository{
+		queryResult: []map[string]interface{}{{"id": "1", "name": "Product 1"}},
+		queryCount:  42,
+	}
+	tracker := &mockAccessTracker{}
+
+	logger, _ := zap.NewDevelopment()
+	service := NewDomainService(mockCache, mockRepo, logger, 5*time.Minute, WithAccessTracker(tracker), WithStaleCopies(time.Hour))
+
+	ctx := context.Background()
+	pagination := repository.Pagination{Limit: 10, IncludeTotal: true}
+	if _, err := service.GetItems(ctx, "products", map[string]interface{}{"category": "electronics"}, pagination); err != nil {
+		t.Fatalf("GetItems() error = %v", err)
+	}
+
+	refresh, ok := tracker.refreshes["products:cached"]
+	if !ok {
+		t.Fatal("GetItems() should report the lookup to the access tracker")
+	}
+
+	// The refresh must cache everything the lookup did: the page, its count and their
+	// stale copies
+	cached := mockCache.getData
+	mockCache.getData = make(map[string][]byte)
+	if err := refresh(ctx); err != nil {
+		t.Fatalf("refresh() error = %v", err)
+	}
+	for _, key := range []string{"products:cached", "products:cached:stale", "products:count", "products:count:stale"} {
+		if string(mockCache.getData[key]) != string(cached[key]) || cached[key] == nil {
+			t.Errorf("refresh() cached %s = %s, want %s as the lookup did", key, mockCache.getData[key], cached[key])
+		}
+	}
+}
+
+func TestGetItems_IncludeTotal(t *testing.T) {
+	mockCache := &mockCacheService{}
+	mockRepo := &mockSupabaseRepository{
+		queryResult: []map[string]interface{}{{"id": "1", "name": "Product 1"}},
+		queryCount:  42,
+	}
+	service := setupTestService(mockCache, mockRepo)
+
+	ctx := context.Background()
+	filters := map[string]interface{}{"category": "electronics"}
+	pagination := repository.Pagination{Limit: 1, IncludeTotal: true}
+
+	miss, err := service.GetItems(ctx, "products", filters, pagination)
+	if err != nil {
+		t.Fatalf("GetItems() error = %v", err)
+	}
+	if miss.Metadata.TotalCount == nil || *miss.Metadata.TotalCount != 42 {
+		t.Fatalf("GetItems() total_count = %v, want 42", miss.Metadata.TotalCount)
+	}
+	if string(mockCache.getData["products:count"]) != "42" {
+		t.Errorf("GetItems() should cache the count apart from the page, got %v", mockCache.getData)
+	}
+
+	hit, _ := service.GetItems(ctx, "products", filters, pagination)
+	if !hit.Metadata.FromCache {
+		t.Error("second GetItems() should be served from cache")
+	}
+	if hit.Metadata.TotalCount == nil || *hit.Metadata.TotalCount != 42 {
+		t.Errorf("GetItems() total_count on hit = %v, want 42", hit.Metadata.TotalCount)
+	}
+	if mockRepo.countQueries != 1 {
+		t.Errorf("GetItems() made %d count queries, want 1", mockRepo.countQueries)
+	}
+
+	// A cached page without its count is fetched again with the count
+	delete(mockCache.getData, "products:count")
+	refetched, _ := service.GetItems(ctx, "products", filters, pagination)
+	if refetched.Metadata.FromCache || mockRepo.countQueries != 2 {
+		t.Errorf("GetItems() without a cached count should query again, from_cache = %v", refetched.Metadata.FromCache)
+	}
+}
+
 func TestGetItems_RepositoryError(t *testing.T) {
 	mockCache := &mockCacheService{
 		getData: make(map[string][]byte),
@@ -249,8 +480,8 @@ func TestGetItems_RedisFallback(t *testing.T) {
 	}
 
 	// Should still return data from repository
-	items, ok := response.Data.([]map[string]interface{})
-	if !ok || len(items) != 1 {
+	items := response.Data
+	if len(items) != 1 {
 		t.Error("GetItems() should return data from repository when cache fails")
 	}
 }
@@ -283,10 +514,7 @@ func TestGetItemByID_CacheHit(t *testing.T) {
 		t.Error("GetItemByID() should indicate cache hit")
 	}
 
-	item, ok := response.Data.(map[string]interface{})
-	if !ok {
-		t.Fatal("GetItemByID() data should be map[string]interface{}")
-	}
+	item := response.Data
 
 	if item["id"] != "123" {
 		t.Errorf("GetItemByID() returned item with id %v, want 123", item["id"])
@@ -320,10 +548,7 @@ func TestGetItemByID_CacheMiss(t *testing.T) {
 		t.Error("GetItemByID() should indicate cache miss")
 	}
 
-	item, ok := response.Data.(map[string]interface{})
-	if !ok {
-		t.Fatal("GetItemByID() data should be map[string]interface{}")
-	}
+	item := response.Data
 
 	if item["id"] != "123" {
 		t.Errorf("GetItemByID() returned item with id %v, want 123", item["id"])
@@ -385,12 +610,185 @@ func TestGetItemByID_RedisFallback(t *testing.T) {
 	}
 
 	// Should still return data from repository
-	item, ok := response.Data.(map[string]interface{})
-	if !ok || item["id"] != "123" {
+	item := response.Data
+	if item["id"] != "123" {
 		t.Error("GetItemByID() should return data from repository when cache fails")
 	}
 }
 
+func TestGetItems_ServesStaleWhenCircuitOpen(t *testing.T) {
+	mockCache := &mockCacheService{getData: make(map[string][]byte)}
+	mockRepo := &mockSupabaseRepository{
+		queryResult: []map[string]interface{}{{"id": "1", "name": "Product 1"}},
+	}
+	logger, _ := zap.NewDevelopment()
+	service := NewDomainService(mockCache, mockRepo, logger, 5*time.Minute, WithStaleCopies(time.Hour))
+
+	ctx := context.Background()
+	pagination := repository.Pagination{Limit: 10}
+	if _, err := service.GetItems(ctx, "products", nil, pagination); err != nil {
+		t.Fatalf("GetItems() error = %v", err)
+	}
+
+	// The cached page expires while the breaker is open
+	delete(mockCache.getData, "products:cached")
+	mockRepo.queryError = repository.NewConnectionError(repository.ErrCircuitOpen)
+
+	response, _ := service.GetItems(ctx, "products", nil, pagination)
+	if response.Status != "success" {
+		t.Fatalf("GetItems() status = %v, want the stale copy, got %+v", response.Status, response.Error)
+	}
+	if !response.Metadata.Stale || !response.Metadata.FromCache {
+		t.Errorf("GetItems() metadata = %+v, want a stale cached response", response.Metadata)
+	}
+	if items := response.Data; len(items) != 1 || items[0]["id"] != "1" {
+		t.Errorf("GetItems() data = %v, want the stale copy", response.Data)
+	}
+}
+
+func TestGetItemByID_StaleOnlyWhenUnavailable(t *testing.T) {
+	mockCache := &mockCacheService{getData: map[string][]byte{
+		"products:cached:stale": []byte(`{"id":"1","name":"Product 1"}`),
+	}}
+	mockRepo := &mockSupabaseRepository{}
+	logger, _ := zap.NewDevelopment()
+	service := NewDomainService(mockCache, mockRepo, logger, 5*time.Minute, WithStaleCopies(time.Hour))
+
+	// Errors rejecting the request are returned, since a stale copy would hide them
+	for _, err := range []error{repository.NewNotFoundError("products", "1"), repository.NewValidationError("bad id")} {
+		mockRepo.getByIDError = err
+		response, _ := service.GetItemByID(context.Background(), "products", "1")
+		if response.Status != "error" {
+			t.Errorf("GetItemByID() with %v = %+v, want the error", err, response)
+		}
+	}
+
+	for _, err := range []error{
+		repository.NewConnectionError(errors.New("connection refused")),
+		repository.NewTimeoutError(errors.New("deadline exceeded")),
+		repository.NewConnectionError(repository.ErrCircuitOpen),
+	} {
+		mockRepo.getByIDError = err
+		response, _ := service.GetItemByID(context.Background(), "products", "1")
+		if response.Status != "success" || !response.Metadata.Stale || response.Data["name"] != "Product 1" {
+			t.Errorf("GetItemByID() with %v = %+v, want the stale copy", err, response)
+		}
+	}
+}
+
+func TestCacheResult_KeepsStaleCopyForGrace(t *testing.T) {
+	ttls := map[string]time.Duration{}
+	mockCache := &ttlRecordingCache{mockCacheService: &mockCacheService{}, ttls: ttls}
+	mockRepo := &mockSupabaseRepository{getByIDResult: map[string]interface{}{"id": "1"}}
+	logger, _ := zap.NewDevelopment()
+	service := NewDomainService(mockCache, mockRepo, logger, 5*time.Minute, WithStaleCopies(time.Hour))
+
+	if _, err := service.GetItemByID(context.Background(), "products", "1"); err != nil {
+		t.Fatalf("GetItemByID() error = %v", err)
+	}
+	if ttls["products:cached"] != 5*time.Minute {
+		t.Errorf("cached result TTL = %v, want 5m", ttls["products:cached"])
+	}
+	if ttls["products:cached:stale"] != 65*time.Minute {
+		t.Errorf("stale copy TTL = %v, want the cache TTL plus the grace window", ttls["products:cached:stale"])
+	}
+}
+
+func TestTTLOverride_CachedApartForItsTTL(t *testing.T) {
+	ttls := map[string]time.Duration{}
+	mockCache := &ttlRecordingCache{mockCacheService: &mockCacheService{}, ttls: ttls}
+	mockRepo := &mockCatalogRepository{detail: &repository.StoreListingDetail{}}
+	logger, _ := zap.NewDevelopment()
+	service := NewCatalogService(mockCache, mockRepo, logger, 5*time.Minute)
+
+	ctx := context.Background()
+	for _, override := range []time.Duration{0, 5 * time.Minute, 30 * time.Second} {
+		if resp, _ := service.GetSupermarketProduct(WithTTLOverride(ctx, override), "sp-1"); resp.Status != "success" {
+			t.Fatalf("GetSupermarketProduct() with TTL override %v = %+v, want success", override, resp)
+		}
+	}
+
+	// Overriding with the default TTL shares the default copy
+	want := map[string]time.Duration{"supermarket:cached": 5 * time.Minute, "supermarket:cached:ttl:30": 30 * time.Second}
+	if len(ttls) != len(want) || mockRepo.calls != 2 {
+		t.Fatalf("cached %v after %d repository calls, want %v after 2", ttls, mockRepo.calls, want)
+	}
+	for key, ttl := range want {
+		if ttls[key] != ttl {
+			t.Errorf("%s cached for %v, want %v", key, ttls[key], ttl)
+		}
+	}
+}
+
+func TestReloadableTTL(t *testing.T) {
+	ttls := map[string]time.Duration{}
+	mockCache := &ttlRecordingCache{mockCacheService: &mockCacheService{}, ttls: ttls}
+	mockRepo := &mockCatalogRepository{detail: &repository.StoreListingDetail{}}
+	logger, _ := zap.NewDevelopment()
+	ttl := cache.NewTTL(5 * time.Minute)
+	service := NewCatalogService(mockCache, mockRepo, logger, time.Hour, WithReloadableTTL(ttl))
+
+	ctx := context.Background()
+	if resp, _ := service.GetSupermarketProduct(ctx, "sp-1"); resp.Status != "success" {
+		t.Fatalf("GetSupermarketProduct() = %+v, want success", resp)
+	}
+	ttl.Set(time.Minute)
+	for _, override := range []time.Duration{time.Minute, 5 * time.Minute} {
+		if resp, _ := service.GetSupermarketProduct(WithTTLOverride(ctx, override), "sp-1"); resp.Status != "success" {
+			t.Fatalf("GetSupermarketProduct() with TTL override %v = %+v, want success", override, resp)
+		}
+	}
+
+	// Once reloaded, overriding with the new TTL shares the default copy, and the old one
+	// is an override like any other
+	want := map[string]time.Duration{"supermarket:cached": 5 * time.Minute, "supermarket:cached:ttl:300": 5 * time.Minute}
+	if len(ttls) != len(want) {
+		t.Fatalf("cached %v, want %v", ttls, want)
+	}
+	for key, ttl := range want {
+		if ttls[key] != ttl {
+			t.Errorf("%s cached for %v, want %v", key, ttls[key], ttl)
+		}
+	}
+}
+
+// ttlRecordingCache records the TTL each key is cached with
+type ttlRecordingCache struct {
+	*mockCacheService
+	ttls map[string]time.Duration
+}
+
+func (c *ttlRecordingCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	c.ttls[key] = ttl
+	return c.mockCacheService.Set(ctx, key, value, ttl)
+}
+
+func TestGetItemsByIDs_ServesStaleWhenUnavailable(t *testing.T) {
+	mockCache := &idKeyCache{mockCacheService: &mockCacheService{getData: map[string][]byte{
+		"products:1:stale": []byte(`{"id":"1","name":"Product 1"}`),
+		"products:2:stale": []byte(`{"id":"2","name":"Product 2"}`),
+	}}}
+	mockRepo := &batchRepository{mockSupabaseRepository: mockSupabaseRepository{
+		queryError: repository.NewConnectionError(errors.New("connection refused")),
+	}}
+	logger, _ := zap.NewDevelopment()
+	service := NewDomainService(mockCache, mockRepo, logger, 5*time.Minute, WithStaleCopies(time.Hour))
+
+	response, _ := service.GetItemsByIDs(context.Background(), "products", []string{"2", "1"})
+	if response.Status != "success" || !response.Metadata.Stale {
+		t.Fatalf("GetItemsByIDs() = %+v, want the stale copies", response)
+	}
+	if len(response.Data) != 2 || response.Data[0]["id"] != "2" || response.Data[1]["id"] != "1" {
+		t.Errorf("GetItemsByIDs() data = %v, want items 2 and 1", response.Data)
+	}
+
+	// An item without a stale copy can't be told from a missing one, so the error stands
+	response, _ = service.GetItemsByIDs(context.Background(), "products", []string{"1", "3"})
+	if response.Status != "error" || response.Error.Code != "SERVICE_UNAVAILABLE" {
+		t.Errorf("GetItemsByIDs() with an uncopied miss = %+v, want SERVICE_UNAVAILABLE", response)
+	}
+}
+
 func TestBuildCacheParams(t *testing.T) {
 	logger, _ := zap.NewDevelopment()
 	service := &domainService{logger: logger}
@@ -401,7 +799,10 @@ func TestBuildCacheParams(t *testing.T) {
 	}
 	pagination := repository.Pagination{Limit: 20, Offset: 10}
 
-	params := service.buildCacheParams(filters, pagination)
+	params, err := service.buildCacheParams(filters, pagination)
+	if err != nil {
+		t.Fatalf("buildCacheParams() error = %v", err)
+	}
 
 	if params["category"] != "electronics" {
 		t.Errorf("buildCacheParams() category = %v, want electronics", params["category"])
@@ -420,6 +821,201 @@ func TestBuildCacheParams(t *testing.T) {
 	}
 }
 
+func TestBuildCacheParams_Operators(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	service := &domainService{logger: logger}
+
+	params, err := service.buildCacheParams(map[string]interface{}{
+		"category": "electronics",
+		"price":    map[string]interface{}{"gte": 10, "lt": 100},
+		"brand":    map[string]interface{}{"in": []interface{}{"Apple", "Samsung"}},
+	}, repository.Pagination{Limit: 20})
+	if err != nil {
+		t.Fatalf("buildCacheParams() error = %v", err)
+	}
+
+	want := map[string]string{
+		"category":  "electronics",
+		"price.gte": "10",
+		"price.lt":  "100",
+		"brand.in":  "(Apple,Samsung)",
+		"limit":     "20",
+	}
+	for key, value := range want {
+		if params[key] != value {
+			t.Errorf("buildCacheParams() %s = %q, want %q", key, params[key], value)
+		}
+	}
+	if len(params) != len(want) {
+		t.Errorf("buildCacheParams() = %v, want %v", params, want)
+	}
+
+	// An equality filter and an operator filter with the same value must not share a key
+	eq, _ := service.buildCacheParams(map[string]interface{}{"price": 100}, repository.Pagination{})
+	lt, _ := service.buildCacheParams(map[string]interface{}{"price": map[string]interface{}{"lt": 100}}, repository.Pagination{})
+	if fmt.Sprint(eq) == fmt.Sprint(lt) {
+		t.Errorf("buildCacheParams() gives eq and lt filters the same params %v", eq)
+	}
+}
+
+func TestBuildCacheParams_Groups(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	service := &domainService{logger: logger}
+
+	params, err := service.buildCacheParams(map[string]interface{}{
+		"status": "active",
+		"or": []interface{}{
+			map[string]interface{}{"category": "dairy"},
+			map[string]interface{}{"category": "bakery"},
+		},
+	}, repository.Pagination{})
+	if err != nil {
+		t.Fatalf("buildCacheParams() error = %v", err)
+	}
+	if params["or"] != "or(category.eq.dairy,category.eq.bakery)" || params["status"] != "active" || len(params) != 2 {
+		t.Errorf("buildCacheParams() = %v, want the status filter and the or group", params)
+	}
+
+	if _, err := service.buildCacheParams(map[string]interface{}{"or": "category.eq.dairy"}, repository.Pagination{}); err == nil {
+		t.Error("buildCacheParams() should reject an or group that isn't a list")
+	}
+}
+
+func TestGetItems_InvalidFilter(t *testing.T) {
+	service := setupTestService(&mockCacheService{}, &mockSupabaseRepository{})
+
+	response, err := service.GetItems(context.Background(), "products",
+		map[string]interface{}{"price": map[string]interface{}{"between": 1}}, repository.Pagination{Limit: 10})
+	if err != nil {
+		t.Fatalf("GetItems() should not return error, got %v", err)
+	}
+	if response.Status != "error" || response.Error == nil || response.Error.Code != "INVALID_INPUT" {
+		t.Errorf("GetItems() = %+v, want INVALID_INPUT error", response)
+	}
+}
+
+func TestGetItemByID_InvalidColumns(t *testing.T) {
+	service := setupTestService(&mockCacheService{}, &mockSupabaseRepository{})
+
+	response, err := service.GetItemByID(context.Background(), "products", "123", repository.WithColumns(""))
+	if err != nil {
+		t.Fatalf("GetItemByID() should not return error, got %v", err)
+	}
+	if response.Status != "error" || response.Error == nil || response.Error.Code != "INVALID_INPUT" {
+		t.Errorf("GetItemByID() = %+v, want INVALID_INPUT error", response)
+	}
+}
+
+func TestCallRPC_CachesResult(t *testing.T) {
+	mockCache := &mockCacheService{}
+	mockRepo := &mockSupabaseRepository{rpcResult: json.RawMessage(`[{"id":"1"}]`)}
+	service := setupTestService(mockCache, mockRepo)
+
+	ctx := context.Background()
+	params := map[string]interface{}{"query": "milk", "max_results": 10}
+
+	miss, err := service.CallRPC(ctx, "search_products", params)
+	if err != nil {
+		t.Fatalf("CallRPC() error = %v", err)
+	}
+	if miss.Status != "success" || miss.Metadata.FromCache {
+		t.Fatalf("CallRPC() = %+v, want an uncached success", miss)
+	}
+
+	hit, _ := service.CallRPC(ctx, "search_products", params)
+	if !hit.Metadata.FromCache {
+		t.Error("second CallRPC() should be served from cache")
+	}
+	if string(hit.Data.(json.RawMessage)) != `[{"id":"1"}]` {
+		t.Errorf("CallRPC() data = %s, want the function result", hit.Data)
+	}
+	if hit.ETag != miss.ETag {
+		t.Errorf("CallRPC() ETag on hit = %s, want %s", hit.ETag, miss.ETag)
+	}
+	if mockRepo.rpcCalls != 1 {
+		t.Errorf("CallRPC() called the function %d times, want 1", mockRepo.rpcCalls)
+	}
+}
+
+func TestCallRPC_ServesStaleWhenUnavailable(t *testing.T) {
+	mockCache := &mockCacheService{}
+	mockRepo := &mockSupabaseRepository{rpcResult: json.RawMessage(`[{"id":"1"}]`)}
+	logger, _ := zap.NewDevelopment()
+	service := NewDomainService(mockCache, mockRepo, logger, 5*time.Minute, WithStaleCopies(time.Hour))
+
+	ctx := context.Background()
+	params := map[string]interface{}{"query": "milk"}
+	if _, err := service.CallRPC(ctx, "search_products", params); err != nil {
+		t.Fatalf("CallRPC() error = %v", err)
+	}
+
+	// The cached result expires while Supabase is down
+	for key := range mockCache.getData {
+		if !strings.HasSuffix(key, ":stale") {
+			delete(mockCache.getData, key)
+		}
+	}
+	mockRepo.rpcError = repository.NewTimeoutError(errors.New("deadline exceeded"))
+
+	response, _ := service.CallRPC(ctx, "search_products", params)
+	if response.Status != "success" || !response.Metadata.Stale || !response.Metadata.FromCache {
+		t.Fatalf("CallRPC() = %+v, want the stale copy", response)
+	}
+	if string(response.Data.(json.RawMessage)) != `[{"id":"1"}]` {
+		t.Errorf("CallRPC() data = %s, want the stale result", response.Data)
+	}
+}
+
+func TestCallRPC_Error(t *testing.T) {
+	mockRepo := &mockSupabaseRepository{rpcError: repository.NewNotFoundError("functions", "missing")}
+	service := setupTestService(&mockCacheService{}, mockRepo)
+
+	response, err := service.CallRPC(context.Background(), "missing", nil)
+	if err != nil {
+		t.Fatalf("CallRPC() should not return error, got %v", err)
+	}
+	if response.Status != "error" || response.Error == nil || response.Error.Code != "NOT_FOUND" {
+		t.Errorf("CallRPC() = %+v, want NOT_FOUND error", response)
+	}
+}
+
+func TestAuthPartition(t *testing.T) {
+	jwt := func(payload string) string {
+		return "eyJhbGciOiJIUzI1NiJ9." + base64.RawURLEncoding.EncodeToString([]byte(payload)) + ".sig"
+	}
+	anon := jwt(`{"role":"anon"}`)
+	alice := jwt(`{"role":"authenticated","sub":"alice"}`)
+	forged := jwt(`{"role":"authenticated","sub":"alice","exp":1}`)
+
+	if got := authPartition(""); got != "" {
+		t.Errorf("authPartition(\"\") = %q, want none", got)
+	}
+	if got := authPartition(anon); got != "role:anon" {
+		t.Errorf("authPartition(anon) = %q, want role:anon", got)
+	}
+	if got := authPartition(alice); !strings.HasPrefix(got, "token:") {
+		t.Errorf("authPartition(alice) = %q, want a token partition", got)
+	}
+	// Unverified claims must not let another token share a user's partition
+	if authPartition(alice) == authPartition(forged) {
+		t.Error("authPartition() gives different tokens with the same subject one partition")
+	}
+}
+
+func TestGetItems_UserTokenNotTracked(t *testing.T) {
+	tracker := &mockAccessTracker{}
+	logger, _ := zap.NewDevelopment()
+	service := NewDomainService(&mockCacheService{}, &mockSupabaseRepository{}, logger, 5*time.Minute, WithAccessTracker(tracker))
+
+	ctx := repository.WithAccessToken(context.Background(), "user-jwt")
+	if _, err := service.GetItems(ctx, "products", nil, repository.Pagination{Limit: 10}); err != nil {
+		t.Fatalf("GetItems() error = %v", err)
+	}
+	if len(tracker.refreshes) != 0 {
+		t.Error("GetItems() with a caller's token should not be refreshed ahead without it")
+	}
+}
+
 func TestStatusCodeToErrorCode(t *testing.T) {
 	logger, _ := zap.NewDevelopment()
 	service := &domainService{logger: logger}
@@ -444,3 +1040,494 @@ func TestStatusCodeToErrorCode(t *testing.T) {
 		})
 	}
 }
+
+// domainRecordingRepository records the domain attached to each query's context
+type domainRecordingRepository struct {
+	mockSupabaseRepository
+	domains []string
+}
+
+func (m *domainRecordingRepository) Query(ctx context.Context, table string, filters map[string]interface{}, pagination repository.Pagination, opts ...repository.QueryOption) ([]map[string]interface{}, error) {
+	m.domains = append(m.domains, repository.DomainFrom(ctx))
+	return m.mockSupabaseRepository.Query(ctx, table, filters, pagination, opts...)
+}
+
+func TestGetItems_RefreshKeepsDomain(t *testing.T) {
+	tracker := &mockAccessTracker{}
+	repo := &domainRecordingRepository{}
+	logger, _ := zap.NewDevelopment()
+	service := NewDomainService(&mockCacheService{}, repo, logger, 5*time.Minute, WithAccessTracker(tracker))
+
+	ctx := repository.WithDomain(context.Background(), "movies")
+	if _, err := service.GetItems(ctx, "movies", nil, repository.Pagination{Limit: 10}); err != nil {
+		t.Fatalf("GetItems() error = %v", err)
+	}
+	if len(tracker.refreshes) != 1 {
+		t.Fatalf("tracked %d lookups, want 1", len(tracker.refreshes))
+	}
+
+	// Refreshes run on a background context, without the request's
+	for _, refresh := range tracker.refreshes {
+		if err := refresh(context.Background()); err != nil {
+			t.Fatalf("refresh() error = %v", err)
+		}
+	}
+	if len(repo.domains) != 2 || repo.domains[1] != "movies" {
+		t.Errorf("queried in domains %v, want the refresh to query the movies project too", repo.domains)
+	}
+}
+
+func TestCacheKey_PartitionsByDomain(t *testing.T) {
+	service := &domainService{cache: &mockCacheService{}}
+
+	params := map[string]string{}
+	service.cacheKey(repository.WithDomain(context.Background(), "movies"), "movies", params)
+	if params["project"] != "movies" {
+		t.Errorf("params = %v, want the request's domain in the key", params)
+	}
+
+	params = map[string]string{}
+	service.cacheKey(context.Background(), "movies", params)
+	if _, ok := params["project"]; ok {
+		t.Errorf("params = %v, want no project without a domain", params)
+	}
+}
+
+func TestCreateItem_WritesThrough(t *testing.T) {
+	mockCache := &mockCacheService{getData: map[string][]byte{
+		"products:count":  []byte(`3`),
+		"products:cached": []byte(`[{"id":"old"}]`),
+		"stores:cached":   []byte(`[]`),
+	}}
+	mockRepo := &mockSupabaseRepository{writeResult: map[string]interface{}{"id": "p1", "name": "Milk"}}
+	logger, _ := zap.NewDevelopment()
+	service := NewDomainService(mockCache, mockRepo, logger, 5*time.Minute)
+
+	resp, err := service.CreateItem(context.Background(), "products", map[string]interface{}{"name": "Milk"})
+	if err != nil || resp.Status != "success" {
+		t.Fatalf("CreateItem() = %+v, %v, want success", resp, err)
+	}
+
+	if _, ok := mockCache.getData["products:count"]; ok {
+		t.Error("CreateItem() should clear the table's cached listings")
+	}
+	if _, ok := mockCache.getData["stores:cached"]; !ok {
+		t.Error("CreateItem() should leave other tables cached")
+	}
+	// The mock keys every detail lookup as products:cached
+	if got := string(mockCache.getData["products:cached"]); got != `{"id":"p1","name":"Milk"}` {
+		t.Errorf("cached row = %s, want the inserted row under its detail key", got)
+	}
+}
+
+func TestUpdateItem_NotFound(t *testing.T) {
+	mockCache := &mockCacheService{getData: map[string][]byte{"products:count": []byte(`3`)}}
+	mockRepo := &mockSupabaseRepository{writeError: repository.NewNotFoundError("products", "p1")}
+	logger, _ := zap.NewDevelopment()
+	service := NewDomainService(mockCache, mockRepo, logger, 5*time.Minute)
+
+	resp, _ := service.UpdateItem(context.Background(), "products", "p1", map[string]interface{}{"name": "Milk"})
+	if resp.Status != "error" || resp.Error.Code != "NOT_FOUND" {
+		t.Errorf("UpdateItem() = %+v, want NOT_FOUND", resp)
+	}
+	if _, ok := mockCache.getData["products:count"]; !ok {
+		t.Error("a failed write shouldn't clear the cache")
+	}
+}
+
+func TestDeleteItem_ClearsTable(t *testing.T) {
+	mockCache := &mockCacheService{getData: map[string][]byte{"products:cached": []byte(`{"id":"p1"}`)}}
+	mockRepo := &mockSupabaseRepository{}
+	logger, _ := zap.NewDevelopment()
+	service := NewDomainService(mockCache, mockRepo, logger, 5*time.Minute)
+
+	resp, _ := service.DeleteItem(context.Background(), "products", "p1")
+	if resp.Status != "success" {
+		t.Fatalf("DeleteItem() = %+v, want success", resp)
+	}
+	if len(mockCache.getData) != 0 {
+		t.Errorf("cache = %v, want the deleted row and listings cleared", mockCache.getData)
+	}
+	if len(mockRepo.writes) != 1 || mockRepo.writes[0] != "delete products p1" {
+		t.Errorf("writes = %v, want the delete", mockRepo.writes)
+	}
+}
+
+func TestWriteErrorResponse(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{repository.NewValidationError("products: null value in column \"name\""), "INVALID_INPUT"},
+		{repository.NewConflictError("products: duplicate key", errors.New("(23505) duplicate key")), "CONFLICT"},
+		{repository.NewConnectionError(errors.New("connection refused")), "SERVICE_UNAVAILABLE"},
+	}
+
+	for _, tt := range tests {
+		service := NewDomainService(&mockCacheService{}, &mockSupabaseRepository{writeError: tt.err}, logger, 5*time.Minute)
+		resp, _ := service.CreateItem(context.Background(), "products", map[string]interface{}{"name": "Milk"})
+		if resp.Error == nil || resp.Error.Code != tt.want {
+			t.Errorf("CreateItem() with %v = %+v, want %s", tt.err, resp, tt.want)
+		}
+	}
+}
+
+func TestItemID(t *testing.T) {
+	tests := []struct {
+		item map[string]interface{}
+		want string
+	}{
+		{map[string]interface{}{"id": "p1"}, "p1"},
+		{map[string]interface{}{"id": float64(12345678)}, "12345678"},
+		{map[string]interface{}{"name": "Milk"}, ""},
+	}
+
+	for _, tt := range tests {
+		if got := itemID(tt.item); got != tt.want {
+			t.Errorf("itemID(%v) = %q, want %q", tt.item, got, tt.want)
+		}
+	}
+}
+
+// idKeyCache keys detail lookups by ID, so a batch's items get keys of their own
+type idKeyCache struct {
+	*mockCacheService
+	mgets int
+}
+
+func (c *idKeyCache) GenerateKey(domain string, params map[string]string) string {
+	return domain + ":" + params["id"]
+}
+
+func (c *idKeyCache) GetMany(ctx context.Context, keys []string) (map[string][]byte, error) {
+	c.mgets++
+	return c.mockCacheService.GetMany(ctx, keys)
+}
+
+// batchRepository serves rows by ID, recording the IDs each in query asks for
+type batchRepository struct {
+	mockSupabaseRepository
+	rows    map[string]map[string]interface{}
+	queried [][]string
+}
+
+func (m *batchRepository) Query(ctx context.Context, table string, filters map[string]interface{}, pagination repository.Pagination, opts ...repository.QueryOption) ([]map[string]interface{}, error) {
+	ids := filters["id"].(map[string]interface{})[repository.FilterIn].([]string)
+	m.queried = append(m.queried, ids)
+	if m.queryError != nil {
+		return nil, m.queryError
+	}
+
+	var rows []map[string]interface{}
+	for _, id := range ids {
+		if row, ok := m.rows[id]; ok {
+			rows = append(rows, row)
+		}
+	}
+	return rows, nil
+}
+
+func TestGetItemsByIDs(t *testing.T) {
+	mockCache := &idKeyCache{mockCacheService: &mockCacheService{getData: map[string][]byte{
+		"products:b": []byte(`{"id":"b","name":"Bread"}`),
+	}}}
+	repo := &batchRepository{rows: map[string]map[string]interface{}{
+		"a": {"id": "a", "name": "Apples"},
+		"c": {"id": "c", "name": "Cheese"},
+	}}
+	logger, _ := zap.NewDevelopment()
+	service := NewDomainService(mockCache, repo, logger, 5*time.Minute)
+
+	resp, err := service.GetItemsByIDs(context.Background(), "products", []string{"c", "b", "missing", "a"})
+	if err != nil || resp.Status != "success" {
+		t.Fatalf("GetItemsByIDs() = %+v, %v, want success", resp, err)
+	}
+
+	items := resp.Data
+	var got []string
+	for _, item := range items {
+		got = append(got, item["id"].(string))
+	}
+	if strings.Join(got, ",") != "c,b,a" {
+		t.Errorf("items = %v, want c,b,a in request order", got)
+	}
+	if len(resp.Metadata.MissingIDs) != 1 || resp.Metadata.MissingIDs[0] != "missing" {
+		t.Errorf("MissingIDs = %v, want [missing]", resp.Metadata.MissingIDs)
+	}
+	if resp.Metadata.FromCache {
+		t.Error("FromCache should be false when some items were fetched")
+	}
+
+	// Only the misses are fetched, in one query, and cached for next time
+	if len(repo.queried) != 1 || strings.Join(repo.queried[0], ",") != "c,missing,a" {
+		t.Errorf("queried = %v, want one in query for c,missing,a", repo.queried)
+	}
+	if mockCache.mgets != 1 {
+		t.Errorf("GetMany called %d times, want 1", mockCache.mgets)
+	}
+	for _, key := range []string{"products:a", "products:c"} {
+		if _, ok := mockCache.getData[key]; !ok {
+			t.Errorf("%s not cached after the fetch", key)
+		}
+	}
+
+	resp, _ = service.GetItemsByIDs(context.Background(), "products", []string{"a", "c"})
+	if !resp.Metadata.FromCache || len(repo.queried) != 1 {
+		t.Errorf("second lookup FromCache = %v after %d queries, want it served from cache", resp.Metadata.FromCache, len(repo.queried))
+	}
+}
+
+func TestGetItemsByIDs_InvalidInput(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	service := NewDomainService(&mockCacheService{}, &mockSupabaseRepository{}, logger, 5*time.Minute)
+
+	tooMany := make([]string, MaxBatchIDs+1)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf("id-%d", i)
+	}
+
+	tests := []struct {
+		name string
+		ids  []string
+		opts []repository.QueryOption
+	}{
+		{"no ids", nil, nil},
+		{"too many ids", tooMany, nil},
+		{"columns without id", []string{"a"}, []repository.QueryOption{repository.WithColumns("name")}},
+	}
+
+	for _, tt := range tests {
+		resp, _ := service.GetItemsByIDs(context.Background(), "products", tt.ids, tt.opts...)
+		if resp.Error == nil || resp.Error.Code != "INVALID_INPUT" {
+			t.Errorf("%s: GetItemsByIDs() = %+v, want INVALID_INPUT", tt.name, resp)
+		}
+	}
+}
+
+// testProduct is a typed model for the generic service
+type testProduct struct {
+	ID    string  `json:"id"`
+	Name  string  `json:"name"`
+	Price float64 `json:"price"`
+}
+
+func TestTypedDomainService_GetItems(t *testing.T) {
+	mockCache := &mockCacheService{}
+	mockRepo := &mockSupabaseRepository{
+		queryResult: []map[string]interface{}{{"id": "1", "name": "Milk", "price": 1.5}},
+	}
+	logger, _ := zap.NewDevelopment()
+	service := NewTypedDomainService[testProduct](mockCache, mockRepo, logger, 5*time.Minute)
+
+	miss, err := service.GetItems(context.Background(), "products", nil, repository.Pagination{Limit: 10})
+	if err != nil || miss.Status != "success" {
+		t.Fatalf("GetItems() = %+v, %v, want success", miss, err)
+	}
+	want := []testProduct{{ID: "1", Name: "Milk", Price: 1.5}}
+	if !reflect.DeepEqual(miss.Data, want) {
+		t.Errorf("GetItems() data on miss = %+v, want %+v", miss.Data, want)
+	}
+
+	hit, _ := service.GetItems(context.Background(), "products", nil, repository.Pagination{Limit: 10})
+	if !hit.Metadata.FromCache || !reflect.DeepEqual(hit.Data, want) {
+		t.Errorf("GetItems() on hit = %+v from cache %v, want %+v", hit.Data, hit.Metadata.FromCache, want)
+	}
+	if hit.ETag != miss.ETag {
+		t.Errorf("GetItems() ETag on hit = %s, want %s", hit.ETag, miss.ETag)
+	}
+}
+
+func TestTypedDomainService_CreateItem(t *testing.T) {
+	mockRepo := &mockSupabaseRepository{writeResult: map[string]interface{}{"id": "p1", "name": "Milk", "price": 1.5}}
+	logger, _ := zap.NewDevelopment()
+	service := NewTypedDomainService[testProduct](&mockCacheService{}, mockRepo, logger, 5*time.Minute)
+
+	resp, _ := service.CreateItem(context.Background(), "products", testProduct{Name: "Milk", Price: 1.5})
+	if resp.Status != "success" || resp.Data.ID != "p1" {
+		t.Errorf("CreateItem() = %+v, want the stored product", resp)
+	}
+	if mockRepo.inserted["name"] != "Milk" || mockRepo.inserted["price"] != 1.5 {
+		t.Errorf("inserted record = %v, want the product's columns", mockRepo.inserted)
+	}
+}
+
+func TestResultJSON(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	mockRepo := &mockSupabaseRepository{getByIDError: repository.NewNotFoundError("products", "1")}
+	service := NewTypedDomainService[testProduct](&mockCacheService{}, mockRepo, logger, 5*time.Minute)
+
+	resp, _ := service.GetItemByID(context.Background(), "products", "1")
+	data, err := json.Marshal(resp)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if got := string(data); strings.Contains(got, `"data"`) || !strings.Contains(got, `"NOT_FOUND"`) {
+		t.Errorf("error result JSON = %s, want the error without data", got)
+	}
+
+	ok := &Result[testProduct]{Status: "success", Data: testProduct{ID: "1", Name: "Milk"}}
+	data, _ = json.Marshal(ok)
+	if string(data) != `{"status":"success","data":{"id":"1","name":"Milk","price":0}}` {
+		t.Errorf("result JSON = %s, want the Response encoding", data)
+	}
+}
+
+// recordingMetrics records what the service reports
+type recordingMetrics struct {
+	lookups        []string
+	repository     []string
+	serializations int
+}
+
+func (m *recordingMetrics) ObserveCacheLookup(domain, operation string, hit bool) {
+	m.lookups = append(m.lookups, fmt.Sprintf("%s %s %t", domain, operation, hit))
+}
+
+func (m *recordingMetrics) ObserveRepository(domain, operation string, duration time.Duration, err error) {
+	m.repository = append(m.repository, fmt.Sprintf("%s %s %t", domain, operation, err == nil))
+}
+
+func (m *recordingMetrics) ObserveSerialization(domain, operation string, duration time.Duration) {
+	m.serializations++
+}
+
+func TestMetrics_RecordsLookupsAndRepositoryCalls(t *testing.T) {
+	mockCache := &mockCacheService{getData: make(map[string][]byte)}
+	mockRepo := &mockSupabaseRepository{
+		queryResult:   []map[string]interface{}{{"id": "1"}},
+		getByIDResult: map[string]interface{}{"id": "1"},
+	}
+	recorded := &recordingMetrics{}
+	logger, _ := zap.NewDevelopment()
+	service := NewDomainService(mockCache, mockRepo, logger, 5*time.Minute, WithMetrics(recorded))
+
+	ctx := context.Background()
+	pagination := repository.Pagination{Limit: 10}
+	_, _ = service.GetItems(ctx, "products", nil, pagination)
+	_, _ = service.GetItems(ctx, "products", nil, pagination)
+
+	mockCache.getData = make(map[string][]byte)
+	mockRepo.getByIDError = repository.NewConnectionError(errors.New("connection refused"))
+	_, _ = service.GetItemByID(ctx, "stores", "1")
+
+	wantLookups := []string{"products get_items false", "products get_items true", "stores get_item_by_id false"}
+	if !reflect.DeepEqual(recorded.lookups, wantLookups) {
+		t.Errorf("lookups = %v, want %v", recorded.lookups, wantLookups)
+	}
+	wantRepository := []string{"products get_items true", "stores get_item_by_id false"}
+	if !reflect.DeepEqual(recorded.repository, wantRepository) {
+		t.Errorf("repository calls = %v, want %v", recorded.repository, wantRepository)
+	}
+	// The fetched page is encoded and the cached one decoded; the failed get has nothing to
+	if recorded.serializations != 2 {
+		t.Errorf("serializations = %d, want 2", recorded.serializations)
+	}
+}
+
+func TestResponseSelectFields(t *testing.T) {
+	tests := []struct {
+		name string
+		data interface{}
+		want string
+	}{
+		{"cached object", json.RawMessage(`{"id":"1","name":"Milk","price":1.5,"variations":[{"id":"v"}]}`), `{"id":"1","price":1.5}`},
+		{"fetched list", []map[string]interface{}{{"id": "1", "name": "Milk"}, {"id": "2"}}, `[{"id":"1"},{"id":"2"}]`},
+		{"list of scalars", []string{"a", "b"}, `["a","b"]`},
+		{"scalar", 42, `42`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &Response{Status: "success", Data: tt.data, ETag: `"full"`}
+			if err := resp.SelectFields([]string{"id", "price", "missing"}); err != nil {
+				t.Fatalf("SelectFields() error = %v", err)
+			}
+			got, _ := json.Marshal(resp.Data)
+			if string(got) != tt.want {
+				t.Errorf("SelectFields() data = %s, want %s", got, tt.want)
+			}
+			if raw, ok := resp.Data.(json.RawMessage); ok && resp.ETag != contentETag(raw) {
+				t.Errorf("SelectFields() ETag = %s, want that of the projected data", resp.ETag)
+			}
+		})
+	}
+}
+
+// costMasker hides cost prices and derives discounts, and scopes reads to active rows
+type costMasker struct{}
+
+func (costMasker) BeforeFetch(ctx context.Context, fetch *Fetch) error {
+	if fetch.Filters != nil {
+		fetch.Filters["is_active"] = true
+	}
+	return nil
+}
+
+func (costMasker) AfterFetch(ctx context.Context, table string, row map[string]interface{}) error {
+	delete(row, "cost_price")
+	if price, ok := row["price"].(float64); ok {
+		if sale, ok := row["sale_price"].(float64); ok {
+			row["discount_pct"] = (price - sale) / price * 100
+		}
+	}
+	return nil
+}
+
+func TestTransformer_AppliedBeforeCaching(t *testing.T) {
+	mockCache := &mockCacheService{getData: make(map[string][]byte)}
+	mockRepo := &mockSupabaseRepository{
+		queryResult:   []map[string]interface{}{{"id": "1", "price": 10.0, "sale_price": 8.0, "cost_price": 5.0}},
+		getByIDResult: map[string]interface{}{"id": "1", "price": 10.0, "cost_price": 5.0},
+	}
+	logger, _ := zap.NewDevelopment()
+	service := NewDomainService(mockCache, mockRepo, logger, 5*time.Minute, WithTransformer("products", costMasker{}))
+
+	ctx := context.Background()
+	filters := map[string]interface{}{"category": "dairy"}
+	response, _ := service.GetItems(ctx, "products", filters, repository.Pagination{Limit: 10})
+	if response.Status != "success" {
+		t.Fatalf("GetItems() = %+v, want success", response)
+	}
+	item := response.Data[0]
+	if _, ok := item["cost_price"]; ok || item["discount_pct"] != 20.0 {
+		t.Errorf("GetItems() item = %v, want cost_price masked and discount_pct derived", item)
+	}
+	if _, ok := filters["is_active"]; ok {
+		t.Error("GetItems() changed the caller's filters")
+	}
+
+	for key, data := range mockCache.getData {
+		if strings.Contains(string(data), "cost_price") {
+			t.Errorf("cached %s = %s, masked fields shouldn't be cached", key, data)
+		}
+	}
+
+	// Other tables aren't transformed
+	detail, _ := service.GetItemByID(ctx, "stores", "1")
+	if _, ok := detail.Data["cost_price"]; !ok {
+		t.Errorf("GetItemByID() on stores = %v, want it untransformed", detail.Data)
+	}
+}
+
+// rejectingTransformer rejects every read
+type rejectingTransformer struct{}
+
+func (rejectingTransformer) BeforeFetch(ctx context.Context, fetch *Fetch) error {
+	return repository.NewValidationError("reads of " + fetch.Table + " are disabled")
+}
+
+func (rejectingTransformer) AfterFetch(ctx context.Context, table string, row map[string]interface{}) error {
+	return nil
+}
+
+func TestTransformer_BeforeFetchRejects(t *testing.T) {
+	mockRepo := &mockSupabaseRepository{getByIDResult: map[string]interface{}{"id": "1"}}
+	logger, _ := zap.NewDevelopment()
+	service := NewDomainService(&mockCacheService{}, mockRepo, logger, 5*time.Minute, WithTransformer("products", rejectingTransformer{}))
+
+	response, _ := service.GetItemByID(context.Background(), "products", "1")
+	if response.Status != "error" || response.Error.Code != "INVALID_INPUT" {
+		t.Errorf("GetItemByID() = %+v, want the transformer's INVALID_INPUT", response)
+	}
+}
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"time"
+)
+
+type ttlOverrideKey struct{}
+
+// WithTTLOverride caches the results of reads made with ctx for ttl instead of the
+// service's cache TTL. They are cached apart from results cached for any other TTL, so a
+// short TTL is never served a copy older than it asked for
+func WithTTLOverride(ctx context.Context, ttl time.Duration) context.Context {
+	return context.WithValue(ctx, ttlOverrideKey{}, ttl)
+}
+
+// TTLOverrideFrom returns the TTL override attached to ctx, or 0 if there is none
+func TTLOverrideFrom(ctx context.Context) time.Duration {
+	ttl, _ := ctx.Value(ttlOverrideKey{}).(time.Duration)
+	return ttl
+}
+
+// ttl returns the TTL to cache the results of reads made with ctx for
+func (s *domainService) ttl(ctx context.Context) time.Duration {
+	if ttl := TTLOverrideFrom(ctx); ttl > 0 {
+		return ttl
+	}
+	return s.defaultTTL()
+}
+
+// defaultTTL returns the service's cache TTL, as last reloaded if it is reloadable
+func (s *domainService) defaultTTL() time.Duration {
+	if s.reloadableTTL != nil {
+		return s.reloadableTTL.Get()
+	}
+	return s.cacheTTL
+}
+
+// ttlPartition returns the cache partition for reads made with ctx, or "" when they use
+// the service's cache TTL
+func (s *domainService) ttlPartition(ctx context.Context) string {
+	ttl := s.ttl(ctx)
+	if ttl == s.defaultTTL() {
+		return ""
+	}
+	return strconv.FormatInt(int64(ttl/time.Second), 10)
+}
@@ -0,0 +1,35 @@
+// Package tenant carries the marketplace operator a request acts for. Postgres row-level
+// security and cache keys are scoped to the tenant of a request's context
+package tenant
+
+import (
+	"context"
+	"regexp"
+)
+
+// Default is the tenant that owns rows written without one, and every row that predates
+// multi-tenancy
+const Default = "default"
+
+// idPattern is the form of a tenant ID: a lowercase slug, safe in cache keys and headers
+var idPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// Valid reports whether id can name a tenant: up to 64 lowercase letters, digits, hyphens
+// and underscores, starting with a letter or digit
+func Valid(id string) bool {
+	return idPattern.MatchString(id)
+}
+
+type tenantKey struct{}
+
+// WithID scopes ctx to the tenant id. An empty id returns a system context, which is
+// scoped to no tenant and sees every tenant's data
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, id)
+}
+
+// FromContext returns the tenant ctx is scoped to, or "" for a system context
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(tenantKey{}).(string)
+	return id
+}
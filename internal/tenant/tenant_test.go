@@ -0,0 +1,35 @@
+package tenant
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestValid(t *testing.T) {
+	for _, id := range []string{"default", "acme", "fresh-mart_2", "9to5", strings.Repeat("a", 64)} {
+		if !Valid(id) {
+			t.Errorf("Valid(%q) = false, want true", id)
+		}
+	}
+	for _, id := range []string{"", "Acme", "-acme", "acme:1", "acme mart", "acme*", strings.Repeat("a", 65)} {
+		if Valid(id) {
+			t.Errorf("Valid(%q) = true, want false", id)
+		}
+	}
+}
+
+func TestWithID(t *testing.T) {
+	ctx := context.Background()
+	if got := FromContext(ctx); got != "" {
+		t.Errorf("FromContext() without a tenant = %q, want empty", got)
+	}
+
+	scoped := WithID(ctx, "acme")
+	if got := FromContext(scoped); got != "acme" {
+		t.Errorf("FromContext() = %q, want acme", got)
+	}
+	if got := FromContext(WithID(scoped, "")); got != "" {
+		t.Errorf("FromContext() of a system context = %q, want empty", got)
+	}
+}
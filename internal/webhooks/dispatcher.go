@@ -0,0 +1,190 @@
+// Package webhooks sends downstream systems the events they subscribe to as signed POSTs.
+// Writes queue one delivery per event and subscription in Postgres, and a worker sends
+// them, retrying failures with exponential backoff; the deliveries are kept as a log
+package webhooks
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yourusername/supabase-redis-middleware/internal/logger"
+	"github.com/yourusername/supabase-redis-middleware/internal/repository"
+	"go.uber.org/zap"
+)
+
+// Event types
+const (
+	EventProductUpdated = "product.updated" // A push or product update saved a store's listings
+	EventStockUpdated   = "stock.updated"   // A stock update changed a store's stock
+	EventStockLow       = "stock.low"       // A stock update left listings below their reorder threshold
+	EventStoreOpened    = "store.opened"    // A status update left the store open
+	EventStoreClosed    = "store.closed"    // A status update left the store closed or inactive
+)
+
+// EventTypes lists every event type a subscription may ask for
+var EventTypes = []string{EventProductUpdated, EventStockUpdated, EventStockLow, EventStoreOpened, EventStoreClosed}
+
+// SecretPrefix starts every generated signing secret
+const SecretPrefix = "whsec_"
+
+// maxProductsPerEvent bounds the products in one event; larger writes are split into
+// several, so no delivery carries a whole catalog
+const maxProductsPerEvent = 500
+
+// Event is the body of a delivery
+// StoreID is the store's UUID; Data depends on Type
+type Event struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	StoreID   string    `json:"store_id"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
+
+// ProductsData is the data of product.updated events
+type ProductsData struct {
+	ExternalIDs []string `json:"external_ids"`
+}
+
+// StockLevel is a listing's stock as a stock update set it
+type StockLevel struct {
+	ExternalID    string  `json:"external_id"`
+	StockQuantity float64 `json:"stock_quantity"`
+	IsAvailable   bool    `json:"is_available"`
+}
+
+// StockData is the data of stock.updated events
+type StockData struct {
+	Products []StockLevel `json:"products"`
+}
+
+// LowStockData is the data of stock.low events: the listings out of stock or below their
+// own low_stock_threshold after the update
+type LowStockData struct {
+	Products []repository.StockReportItem `json:"products"`
+}
+
+// EventStore queues events and reads the state they carry
+type EventStore interface {
+	EnqueueWebhookEvent(ctx context.Context, event repository.WebhookEvent) (int64, error)
+	GetStoreStatus(ctx context.Context, storeID string) (*repository.StoreStatus, error)
+	ListLowStockListings(ctx context.Context, storeID string, externalIDs []string) ([]repository.StockReportItem, error)
+}
+
+// Dispatcher queues the events of writes for their subscribers. A nil Dispatcher queues nothing
+type Dispatcher struct {
+	store  EventStore
+	logger *zap.Logger
+}
+
+func NewDispatcher(store EventStore, logger *zap.Logger) *Dispatcher {
+	return &Dispatcher{
+		store:  store,
+		logger: logger,
+	}
+}
+
+// ProductsUpdated queues product.updated for the listings of store storeID (its UUID)
+// with the given ERP external IDs
+func (d *Dispatcher) ProductsUpdated(ctx context.Context, storeID string, externalIDs []string) {
+	if d == nil {
+		return
+	}
+	for start := 0; start < len(externalIDs); start += maxProductsPerEvent {
+		end := min(start+maxProductsPerEvent, len(externalIDs))
+		d.emit(ctx, EventProductUpdated, storeID, ProductsData{ExternalIDs: externalIDs[start:end]})
+	}
+}
+
+// StockUpdated queues stock.updated for the stock levels a stock update set on store
+// storeID (its UUID), and stock.low for those of its listings it left low
+func (d *Dispatcher) StockUpdated(ctx context.Context, storeID string, levels []StockLevel) {
+	if d == nil || len(levels) == 0 {
+		return
+	}
+	ids := make([]string, len(levels))
+	for i, level := range levels {
+		ids[i] = level.ExternalID
+	}
+	for start := 0; start < len(levels); start += maxProductsPerEvent {
+		end := min(start+maxProductsPerEvent, len(levels))
+		d.emit(ctx, EventStockUpdated, storeID, StockData{Products: levels[start:end]})
+	}
+
+	low, err := d.store.ListLowStockListings(ctx, storeID, ids)
+	if err != nil {
+		logger.FromContext(ctx, d.logger).Warn("Failed to read low stock listings for webhooks",
+			zap.String("store_id", storeID), zap.Error(err))
+		return
+	}
+	for start := 0; start < len(low); start += maxProductsPerEvent {
+		end := min(start+maxProductsPerEvent, len(low))
+		d.emit(ctx, EventStockLow, storeID, LowStockData{Products: low[start:end]})
+	}
+}
+
+// StoreStatusChanged queues store.opened or store.closed, by whether store storeID (its
+// UUID) is open now, with its status as data
+func (d *Dispatcher) StoreStatusChanged(ctx context.Context, storeID string) {
+	if d == nil {
+		return
+	}
+	status, err := d.store.GetStoreStatus(ctx, storeID)
+	if err != nil {
+		logger.FromContext(ctx, d.logger).Warn("Failed to read store status for webhooks",
+			zap.String("store_id", storeID), zap.Error(err))
+		return
+	}
+	eventType := EventStoreClosed
+	if status.IsOpenNow {
+		eventType = EventStoreOpened
+	}
+	d.emit(ctx, eventType, storeID, status)
+}
+
+// emit queues an event for its subscribers. Failures are logged rather than returned:
+// the write being reported has already succeeded
+func (d *Dispatcher) emit(ctx context.Context, eventType, storeID string, data any) {
+	log := logger.FromContext(ctx, d.logger)
+
+	event := Event{ID: uuid.NewString(), Type: eventType, StoreID: storeID, CreatedAt: time.Now().UTC(), Data: data}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		log.Error("Failed to encode webhook event", zap.String("type", eventType), zap.Error(err))
+		return
+	}
+
+	queued, err := d.store.EnqueueWebhookEvent(ctx, repository.WebhookEvent{
+		ID:      event.ID,
+		Type:    eventType,
+		StoreID: storeID,
+		Payload: payload,
+	})
+	if err != nil {
+		log.Warn("Failed to queue webhook event",
+			zap.String("type", eventType),
+			zap.String("store_id", storeID),
+			zap.Error(err))
+		return
+	}
+	if queued > 0 {
+		log.Debug("Queued webhook event",
+			zap.String("event_id", event.ID),
+			zap.String("type", eventType),
+			zap.Int64("deliveries", queued))
+	}
+}
+
+// NewSecret generates a signing secret for a subscription
+func NewSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return SecretPrefix + hex.EncodeToString(secret), nil
+}
@@ -0,0 +1,157 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/supabase-redis-middleware/internal/repository"
+	"go.uber.org/zap"
+)
+
+// eventStore records queued events; listings whose external ID starts with "low" are low
+type eventStore struct {
+	events []repository.WebhookEvent
+	open   bool
+	err    error
+}
+
+func (s *eventStore) EnqueueWebhookEvent(ctx context.Context, event repository.WebhookEvent) (int64, error) {
+	if s.err != nil {
+		return 0, s.err
+	}
+	s.events = append(s.events, event)
+	return 1, nil
+}
+
+func (s *eventStore) GetStoreStatus(ctx context.Context, storeID string) (*repository.StoreStatus, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &repository.StoreStatus{ID: storeID, IsActive: true, IsOpen: s.open, IsOpenNow: s.open}, nil
+}
+
+func (s *eventStore) ListLowStockListings(ctx context.Context, storeID string, externalIDs []string) ([]repository.StockReportItem, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	var low []repository.StockReportItem
+	for _, id := range externalIDs {
+		if strings.HasPrefix(id, "low") {
+			low = append(low, repository.StockReportItem{ExternalID: &id})
+		}
+	}
+	return low, nil
+}
+
+func eventTypes(events []repository.WebhookEvent) []string {
+	types := make([]string, len(events))
+	for i, event := range events {
+		types[i] = event.Type
+	}
+	return types
+}
+
+func TestDispatcher_StockUpdated(t *testing.T) {
+	store := &eventStore{}
+	d := NewDispatcher(store, zap.NewNop())
+
+	d.StockUpdated(context.Background(), "store-1", []StockLevel{
+		{ExternalID: "ok-1", StockQuantity: 40, IsAvailable: true},
+		{ExternalID: "low-1", StockQuantity: 1, IsAvailable: true},
+	})
+
+	if got := eventTypes(store.events); len(got) != 2 || got[0] != EventStockUpdated || got[1] != EventStockLow {
+		t.Fatalf("expected stock.updated then stock.low, got %v", got)
+	}
+	var event struct {
+		ID      string       `json:"id"`
+		Type    string       `json:"type"`
+		StoreID string       `json:"store_id"`
+		Data    LowStockData `json:"data"`
+	}
+	if err := json.Unmarshal(store.events[1].Payload, &event); err != nil {
+		t.Fatalf("failed to decode payload: %v", err)
+	}
+	if event.ID != store.events[1].ID || event.StoreID != "store-1" || event.Type != EventStockLow {
+		t.Errorf("payload envelope doesn't match the event: %+v", event)
+	}
+	if len(event.Data.Products) != 1 || *event.Data.Products[0].ExternalID != "low-1" {
+		t.Errorf("expected only low-1 to be low, got %+v", event.Data.Products)
+	}
+}
+
+func TestDispatcher_StockUpdatedNothingLow(t *testing.T) {
+	store := &eventStore{}
+	NewDispatcher(store, zap.NewNop()).StockUpdated(context.Background(), "store-1", []StockLevel{{ExternalID: "ok-1", StockQuantity: 40}})
+
+	if got := eventTypes(store.events); len(got) != 1 || got[0] != EventStockUpdated {
+		t.Errorf("expected only stock.updated, got %v", got)
+	}
+}
+
+func TestDispatcher_ProductsUpdatedSplitsLargeWrites(t *testing.T) {
+	store := &eventStore{}
+	ids := make([]string, maxProductsPerEvent+1)
+	for i := range ids {
+		ids[i] = "p"
+	}
+	NewDispatcher(store, zap.NewNop()).ProductsUpdated(context.Background(), "store-1", ids)
+
+	if len(store.events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(store.events))
+	}
+	if store.events[0].ID == store.events[1].ID {
+		t.Error("expected each event to have its own ID")
+	}
+}
+
+func TestDispatcher_StoreStatusChanged(t *testing.T) {
+	for _, tc := range []struct {
+		open bool
+		want string
+	}{
+		{open: true, want: EventStoreOpened},
+		{open: false, want: EventStoreClosed},
+	} {
+		store := &eventStore{open: tc.open}
+		NewDispatcher(store, zap.NewNop()).StoreStatusChanged(context.Background(), "store-1")
+
+		if got := eventTypes(store.events); len(got) != 1 || got[0] != tc.want {
+			t.Errorf("open=%v: expected %s, got %v", tc.open, tc.want, got)
+		}
+	}
+}
+
+func TestDispatcher_Failures(t *testing.T) {
+	store := &eventStore{err: errors.New("database down")}
+	d := NewDispatcher(store, zap.NewNop())
+
+	// Failures are logged, not returned or panicked on
+	d.ProductsUpdated(context.Background(), "store-1", []string{"p1"})
+	d.StockUpdated(context.Background(), "store-1", []StockLevel{{ExternalID: "low-1"}})
+	d.StoreStatusChanged(context.Background(), "store-1")
+}
+
+func TestDispatcher_Nil(t *testing.T) {
+	var d *Dispatcher
+	d.ProductsUpdated(context.Background(), "store-1", []string{"p1"})
+	d.StockUpdated(context.Background(), "store-1", []StockLevel{{ExternalID: "p1"}})
+	d.StoreStatusChanged(context.Background(), "store-1")
+}
+
+func TestNewSecret(t *testing.T) {
+	a, err := NewSecret()
+	if err != nil {
+		t.Fatalf("NewSecret failed: %v", err)
+	}
+	b, _ := NewSecret()
+	if !strings.HasPrefix(a, SecretPrefix) || len(a) != len(SecretPrefix)+64 {
+		t.Errorf("unexpected secret format %q", a)
+	}
+	if a == b {
+		t.Error("expected secrets to differ")
+	}
+}
@@ -0,0 +1,226 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/yourusername/supabase-redis-middleware/internal/auth"
+	"github.com/yourusername/supabase-redis-middleware/internal/repository"
+	"go.uber.org/zap"
+)
+
+// Delivery headers, besides auth.SignatureHeader
+const (
+	EventHeader    = "X-Webhook-Event"    // The event's type
+	EventIDHeader  = "X-Webhook-ID"       // The event's ID, the same in every attempt, to drop repeats by
+	DeliveryHeader = "X-Webhook-Delivery" // The delivery's ID in the delivery log
+	AttemptHeader  = "X-Webhook-Attempt"  // 1 for the first attempt
+)
+
+const (
+	// retryBaseDelay is the wait before the first retry; each later one waits twice as long
+	retryBaseDelay = 30 * time.Second
+	retryMaxDelay  = 6 * time.Hour
+
+	// maxErrorLength bounds the error recorded for a failed attempt
+	maxErrorLength = 500
+
+	// pruneInterval is how often deliveries past their retention are deleted
+	pruneInterval = time.Hour
+)
+
+// WorkerOptions controls how deliveries are sent
+type WorkerOptions struct {
+	PollInterval time.Duration // How often due deliveries are looked for while idle
+	Timeout      time.Duration // Per attempt, including reading the response
+	MaxAttempts  int           // Attempts before a delivery fails for good
+	Concurrency  int           // Deliveries sent at once
+	Retention    time.Duration // How long finished deliveries stay in the log; zero keeps them
+}
+
+// DeliveryStore claims due deliveries and records their outcomes
+type DeliveryStore interface {
+	ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]repository.WebhookDispatch, error)
+	RecordWebhookAttempt(ctx context.Context, deliveryID int64, attempt repository.WebhookAttempt) error
+	PruneWebhookDeliveries(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+// Worker sends due deliveries in the background. Instances claim deliveries with
+// SKIP LOCKED, so any number of them may run workers
+type Worker struct {
+	store  DeliveryStore
+	opts   WorkerOptions
+	client *http.Client
+	logger *zap.Logger
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewWorker creates a worker; call Start to run it
+func NewWorker(store DeliveryStore, opts WorkerOptions, logger *zap.Logger) *Worker {
+	return &Worker{
+		store:  store,
+		opts:   opts,
+		client: &http.Client{Timeout: opts.Timeout},
+		logger: logger,
+		done:   make(chan struct{}),
+	}
+}
+
+// Start sends deliveries in the background until Stop is called
+func (w *Worker) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+
+	go func() {
+		defer close(w.done)
+
+		var lastPrune time.Time
+		for {
+			if w.opts.Retention > 0 && time.Since(lastPrune) >= pruneInterval {
+				w.prune(ctx)
+				lastPrune = time.Now()
+			}
+
+			// A full batch suggests more are due, so the next is claimed straight away
+			if sent := w.dispatchDue(ctx); sent == w.opts.Concurrency {
+				continue
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(w.opts.PollInterval):
+			}
+		}
+	}()
+}
+
+// Stop stops claiming deliveries and waits for those being sent. Attempts cut short are
+// retried once their claim lapses
+func (w *Worker) Stop() {
+	w.cancel()
+	<-w.done
+}
+
+// dispatchDue claims a batch of due deliveries and sends them, returning how many it claimed
+func (w *Worker) dispatchDue(ctx context.Context) int {
+	// The claim outlasts an attempt, so no other instance sends a delivery still being sent
+	dispatches, err := w.store.ClaimWebhookDeliveries(ctx, w.opts.Concurrency, w.opts.Timeout+time.Minute)
+	if err != nil {
+		if ctx.Err() == nil {
+			w.logger.Warn("Failed to claim webhook deliveries", zap.Error(err))
+		}
+		return 0
+	}
+
+	var wg sync.WaitGroup
+	for _, d := range dispatches {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			attempt := w.send(ctx, d)
+			if ctx.Err() != nil {
+				return
+			}
+			if err := w.store.RecordWebhookAttempt(ctx, d.DeliveryID, attempt); err != nil {
+				w.logger.Warn("Failed to record webhook attempt", zap.Int64("delivery_id", d.DeliveryID), zap.Error(err))
+			}
+		}()
+	}
+	wg.Wait()
+	return len(dispatches)
+}
+
+// send POSTs a delivery and returns the outcome to record
+func (w *Worker) send(ctx context.Context, d repository.WebhookDispatch) repository.WebhookAttempt {
+	log := w.logger.With(
+		zap.Int64("delivery_id", d.DeliveryID),
+		zap.String("event_type", d.EventType),
+		zap.Int("attempt", d.Attempts))
+
+	body := []byte(d.Payload)
+	status, err := w.post(ctx, d, body)
+	if err == nil {
+		log.Debug("Delivered webhook", zap.Int("response_status", status))
+		return repository.WebhookAttempt{Status: repository.WebhookSucceeded, ResponseStatus: &status}
+	}
+
+	message := err.Error()
+	if len(message) > maxErrorLength {
+		message = message[:maxErrorLength]
+	}
+	attempt := repository.WebhookAttempt{Status: repository.WebhookFailed, Error: &message}
+	if status != 0 {
+		attempt.ResponseStatus = &status
+	}
+	if d.Attempts >= w.opts.MaxAttempts {
+		log.Warn("Webhook delivery failed for good", zap.Error(err))
+		return attempt
+	}
+
+	retryAt := time.Now().Add(RetryDelay(d.Attempts))
+	attempt.Status = repository.WebhookPending
+	attempt.NextAttemptAt = &retryAt
+	log.Info("Webhook delivery failed; will retry", zap.Time("retry_at", retryAt), zap.Error(err))
+	return attempt
+}
+
+// post sends body to the subscriber, returning its response status, and an error unless
+// the status is 2xx
+func (w *Worker) post(ctx context.Context, d repository.WebhookDispatch, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("invalid request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "gol-webhooks/1")
+	req.Header.Set(EventHeader, d.EventType)
+	req.Header.Set(EventIDHeader, d.EventID)
+	req.Header.Set(DeliveryHeader, strconv.FormatInt(d.DeliveryID, 10))
+	req.Header.Set(AttemptHeader, strconv.Itoa(d.Attempts))
+	req.Header.Set(auth.SignatureHeader, "sha256="+auth.Sign(body, d.Secret))
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// Reading some of the body lets the connection be reused
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("subscriber answered %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// prune deletes finished deliveries past their retention
+func (w *Worker) prune(ctx context.Context) {
+	deleted, err := w.store.PruneWebhookDeliveries(ctx, time.Now().Add(-w.opts.Retention))
+	if err != nil {
+		if ctx.Err() == nil {
+			w.logger.Warn("Failed to prune webhook deliveries", zap.Error(err))
+		}
+		return
+	}
+	if deleted > 0 {
+		w.logger.Info("Pruned webhook deliveries", zap.Int64("deleted", deleted))
+	}
+}
+
+// RetryDelay returns how long to wait before retrying a delivery that failed its attempt'th
+// attempt: 30s after the first, doubling each time, at most 6h
+func RetryDelay(attempt int) time.Duration {
+	delay := retryBaseDelay
+	for i := 1; i < attempt && delay < retryMaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, retryMaxDelay)
+}
@@ -0,0 +1,166 @@
+package webhooks
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yourusername/supabase-redis-middleware/internal/auth"
+	"github.com/yourusername/supabase-redis-middleware/internal/repository"
+	"go.uber.org/zap"
+)
+
+// deliveryStore hands out its dispatches once and records the attempts
+type deliveryStore struct {
+	mu         sync.Mutex
+	dispatches []repository.WebhookDispatch
+	attempts   map[int64]repository.WebhookAttempt
+}
+
+func (s *deliveryStore) ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]repository.WebhookDispatch, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	claimed := s.dispatches[:min(limit, len(s.dispatches))]
+	s.dispatches = s.dispatches[len(claimed):]
+	return claimed, nil
+}
+
+func (s *deliveryStore) RecordWebhookAttempt(ctx context.Context, deliveryID int64, attempt repository.WebhookAttempt) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.attempts == nil {
+		s.attempts = map[int64]repository.WebhookAttempt{}
+	}
+	s.attempts[deliveryID] = attempt
+	return nil
+}
+
+func (s *deliveryStore) PruneWebhookDeliveries(ctx context.Context, cutoff time.Time) (int64, error) {
+	return 0, nil
+}
+
+func testWorker(store DeliveryStore) *Worker {
+	return NewWorker(store, WorkerOptions{
+		PollInterval: time.Second,
+		Timeout:      5 * time.Second,
+		MaxAttempts:  3,
+		Concurrency:  10,
+	}, zap.NewNop())
+}
+
+func TestWorker_SendsSignedDeliveries(t *testing.T) {
+	var header http.Header
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	store := &deliveryStore{dispatches: []repository.WebhookDispatch{{
+		DeliveryID: 7,
+		EventID:    "evt-1",
+		EventType:  EventStockLow,
+		Payload:    `{"id":"evt-1"}`,
+		Attempts:   1,
+		URL:        server.URL,
+		Secret:     "whsec_test",
+	}}}
+	if n := testWorker(store).dispatchDue(context.Background()); n != 1 {
+		t.Fatalf("expected 1 delivery claimed, got %d", n)
+	}
+
+	if string(body) != `{"id":"evt-1"}` {
+		t.Errorf("unexpected body %s", body)
+	}
+	if !auth.VerifySignature(body, "whsec_test", header.Get(auth.SignatureHeader)) {
+		t.Errorf("signature %q doesn't verify", header.Get(auth.SignatureHeader))
+	}
+	if header.Get(EventHeader) != EventStockLow || header.Get(EventIDHeader) != "evt-1" ||
+		header.Get(DeliveryHeader) != "7" || header.Get(AttemptHeader) != "1" {
+		t.Errorf("unexpected headers %v", header)
+	}
+	attempt := store.attempts[7]
+	if attempt.Status != repository.WebhookSucceeded || attempt.ResponseStatus == nil || *attempt.ResponseStatus != http.StatusNoContent {
+		t.Errorf("expected a succeeded attempt with status 204, got %+v", attempt)
+	}
+}
+
+func TestWorker_RetriesThenFails(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	store := &deliveryStore{dispatches: []repository.WebhookDispatch{
+		{DeliveryID: 1, Payload: `{}`, Attempts: 1, URL: server.URL},
+		{DeliveryID: 2, Payload: `{}`, Attempts: 3, URL: server.URL},
+	}}
+	before := time.Now()
+	testWorker(store).dispatchDue(context.Background())
+
+	retry := store.attempts[1]
+	if retry.Status != repository.WebhookPending || retry.NextAttemptAt == nil || retry.NextAttemptAt.Before(before.Add(RetryDelay(1))) {
+		t.Errorf("expected the first attempt to be retried after %v, got %+v", RetryDelay(1), retry)
+	}
+	if retry.ResponseStatus == nil || *retry.ResponseStatus != http.StatusBadGateway || retry.Error == nil {
+		t.Errorf("expected the 502 and an error to be recorded, got %+v", retry)
+	}
+	if last := store.attempts[2]; last.Status != repository.WebhookFailed || last.NextAttemptAt != nil {
+		t.Errorf("expected the last attempt to fail the delivery, got %+v", last)
+	}
+}
+
+func TestWorker_UnreachableSubscriber(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	store := &deliveryStore{dispatches: []repository.WebhookDispatch{{DeliveryID: 1, Payload: `{}`, Attempts: 1, URL: url}}}
+	testWorker(store).dispatchDue(context.Background())
+
+	attempt := store.attempts[1]
+	if attempt.Status != repository.WebhookPending || attempt.ResponseStatus != nil || attempt.Error == nil {
+		t.Errorf("expected a retry with no response status, got %+v", attempt)
+	}
+}
+
+func TestWorker_StartStop(t *testing.T) {
+	delivered := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		delivered <- struct{}{}
+	}))
+	defer server.Close()
+
+	w := testWorker(&deliveryStore{dispatches: []repository.WebhookDispatch{{DeliveryID: 1, Payload: `{}`, Attempts: 1, URL: server.URL}}})
+	w.Start()
+	select {
+	case <-delivered:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the delivery to be sent")
+	}
+	w.Stop()
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{8, 64 * time.Minute},
+		{20, 6 * time.Hour},
+	}
+	for _, tc := range tests {
+		if got := RetryDelay(tc.attempt); got != tc.want {
+			t.Errorf("RetryDelay(%d) = %v, want %v", tc.attempt, got, tc.want)
+		}
+	}
+}
@@ -3,19 +3,34 @@ package main
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/yourusername/supabase-redis-middleware/config"
+	"github.com/yourusername/supabase-redis-middleware/internal/auth"
 	"github.com/yourusername/supabase-redis-middleware/internal/cache"
+	"github.com/yourusername/supabase-redis-middleware/internal/graphql/catalog"
+	"github.com/yourusername/supabase-redis-middleware/internal/grpcapi"
+	"github.com/yourusername/supabase-redis-middleware/internal/handlers"
+	"github.com/yourusername/supabase-redis-middleware/internal/https"
+	"github.com/yourusername/supabase-redis-middleware/internal/i18n"
+	"github.com/yourusername/supabase-redis-middleware/internal/jobs"
 	"github.com/yourusername/supabase-redis-middleware/internal/logger"
+	"github.com/yourusername/supabase-redis-middleware/internal/metrics"
+	"github.com/yourusername/supabase-redis-middleware/internal/middleware"
+	"github.com/yourusername/supabase-redis-middleware/internal/realtime"
 	"github.com/yourusername/supabase-redis-middleware/internal/repository"
 	"github.com/yourusername/supabase-redis-middleware/internal/router"
 	"github.com/yourusername/supabase-redis-middleware/internal/service"
+	"github.com/yourusername/supabase-redis-middleware/internal/tracing"
+	"github.com/yourusername/supabase-redis-middleware/internal/webhooks"
 	"go.uber.org/zap"
+	"google.golang.org/grpc"
 )
 
 func main() {
@@ -41,6 +56,33 @@ func main() {
 		zap.Duration("request_timeout", cfg.Server.RequestTimeout),
 	)
 
+	// Initialize tracing before the clients it instruments
+	var tracingService string
+	if cfg.Tracing.Enabled {
+		shutdownTracing, err := tracing.Setup(context.Background(), tracing.Options{
+			Endpoint:    cfg.Tracing.Endpoint,
+			Insecure:    cfg.Tracing.Insecure,
+			ServiceName: cfg.Tracing.ServiceName,
+			SampleRatio: cfg.Tracing.SampleRatio,
+		})
+		if err != nil {
+			log.Error("Failed to initialize tracing", zap.Error(err))
+			os.Exit(1)
+		}
+		defer func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := shutdownTracing(ctx); err != nil {
+				log.Error("Error flushing traces", zap.Error(err))
+			}
+		}()
+		tracingService = cfg.Tracing.ServiceName
+		log.Info("Tracing enabled",
+			zap.String("endpoint", cfg.Tracing.Endpoint),
+			zap.Float64("sample_ratio", cfg.Tracing.SampleRatio),
+		)
+	}
+
 	// Validate Supabase credentials
 	if cfg.Supabase.URL == "" || cfg.Supabase.APIKey == "" {
 		log.Error("Supabase credentials are missing",
@@ -52,11 +94,19 @@ func main() {
 	}
 
 	// Initialize Redis cache service
-	cacheService, err := cache.NewRedisCache(
+	cacheService, err := cache.NewRedisCacheWithPool(
 		cfg.Redis.Host,
 		cfg.Redis.Port,
 		cfg.Redis.Password,
 		cfg.Redis.DB,
+		cache.PoolOptions{
+			PoolSize:     cfg.Redis.PoolSize,
+			MinIdleConns: cfg.Redis.MinIdleConns,
+			MaxRetries:   cfg.Redis.MaxRetries,
+			DialTimeout:  cfg.Redis.DialTimeout,
+			ReadTimeout:  cfg.Redis.ReadTimeout,
+			WriteTimeout: cfg.Redis.WriteTimeout,
+		},
 		log.Logger,
 	)
 	if err != nil {
@@ -64,6 +114,17 @@ func main() {
 		os.Exit(1)
 	}
 	defer cacheService.Close()
+	cacheService.SetKeyPrefix(cfg.Redis.KeyPrefix)
+	cacheService.SetTTLJitter(cfg.Redis.TTLJitter)
+	cacheService.SetSchemaVersion(byte(cfg.Redis.SchemaVersion))
+	cacheService.SetCircuitBreaker(cfg.Redis.BreakerThreshold, cfg.Redis.BreakerCooldown)
+	cacheService.SetMaxValueSize(cfg.Redis.MaxValueSize)
+	if cfg.Tracing.Enabled {
+		if err := cacheService.EnableTracing(); err != nil {
+			log.Error("Failed to instrument Redis for tracing", zap.Error(err))
+			os.Exit(1)
+		}
+	}
 
 	// Test Redis connectivity on startup
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -76,8 +137,85 @@ func main() {
 	}
 	cancel()
 
+	// Initialize PostgreSQL repository
+	pgRepo, err := repository.NewPostgresRepository(cfg.Database.URL, repository.PoolConfig{
+		MaxConns:          cfg.Database.MaxConns,
+		MinConns:          cfg.Database.MinConns,
+		HealthCheckPeriod: cfg.Database.HealthCheckPeriod,
+		StatementTimeout:  cfg.Database.StatementTimeout,
+		Tracing:           cfg.Tracing.Enabled,
+		Tenancy:           cfg.Tenancy.Enabled,
+	}, log.Logger)
+	if err != nil {
+		log.Error("Failed to initialize PostgreSQL repository", zap.Error(err))
+		os.Exit(1)
+	}
+	defer pgRepo.Close()
+	// Refuse to start on a schema this release's queries can't run against
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	err = pgRepo.CheckSchema(ctx)
+	cancel()
+	if err != nil {
+		log.Error("PostgreSQL schema is out of date", zap.Error(err))
+		os.Exit(1)
+	}
+	if cfg.Tenancy.Enabled {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		err := pgRepo.CheckTenancy(ctx)
+		cancel()
+		if err != nil {
+			log.Error("PostgreSQL can't isolate tenants", zap.Error(err))
+			os.Exit(1)
+		}
+		log.Info("Multi-tenancy enabled",
+			zap.String("header", cfg.Tenancy.Header),
+			zap.String("default_tenant", cfg.Tenancy.DefaultTenant),
+		)
+	}
+	pgRepo.SetFuzzySearchThreshold(cfg.Database.FuzzySearchThreshold)
+	pgRepo.SetPushChunkSize(cfg.Database.PushChunkSize)
+	pgRepo.SetRetryMaxAttempts(cfg.Database.RetryMaxAttempts)
+	if cfg.Database.ReadReplicaURL != "" {
+		if err := pgRepo.SetReadReplica(cfg.Database.ReadReplicaURL, cfg.Database.ReplicaMaxLag); err != nil {
+			log.Error("Failed to configure PostgreSQL read replica", zap.Error(err))
+			os.Exit(1)
+		}
+	}
+
+	log.Info("Successfully initialized PostgreSQL repository")
+
 	// Initialize Supabase repository
-	supabaseRepo, err := repository.NewSupabaseRepository(cfg.Supabase.URL, cfg.Supabase.APIKey)
+	supabaseOpts := []repository.SupabaseOption{
+		repository.WithLogger(log.Logger),
+		repository.WithRetry(cfg.Supabase.RetryMaxAttempts),
+		repository.WithCircuitBreaker(cfg.Supabase.BreakerThreshold, cfg.Supabase.BreakerCooldown),
+		repository.WithRequestTimeout(cfg.Supabase.RequestTimeout),
+		repository.WithUserAgent(cfg.Supabase.UserAgent),
+	}
+	if cfg.Supabase.ForwardUserToken {
+		supabaseOpts = append(supabaseOpts, repository.WithUserTokens())
+	}
+	if cfg.Tracing.Enabled {
+		supabaseOpts = append(supabaseOpts, repository.WithTracing())
+	}
+	newSupabaseRepo := func(project config.SupabaseProjectConfig) (repository.SupabaseRepository, error) {
+		repo, err := repository.NewSupabaseRepository(project.URL, project.APIKey,
+			append(supabaseOpts, repository.WithSchema(project.Schema))...)
+		if err != nil {
+			return nil, err
+		}
+		// Only the default project's database is the one the Postgres pool connects to
+		if cfg.Supabase.PostgresFallback && project.URL == cfg.Supabase.URL {
+			repo = repository.NewPostgresFallback(repo,
+				repository.NewPostgresTableReader(pgRepo, project.Schema), log.Logger)
+		}
+		// PostgREST runs as the API key, past RLS, so tenants are kept apart by filter
+		if cfg.Tenancy.Enabled {
+			repo = repository.NewTenantScoped(repo)
+		}
+		return repo, nil
+	}
+	supabaseRepo, err := newSupabaseRepo(cfg.Supabase.Project(""))
 	if err != nil {
 		log.Error("Failed to initialize Supabase repository", zap.Error(err))
 		os.Exit(1)
@@ -85,37 +223,246 @@ func main() {
 
 	log.Info("Successfully initialized Supabase repository",
 		zap.String("url", cfg.Supabase.URL),
+		zap.String("schema", cfg.Supabase.Schema),
+		zap.Bool("forward_user_token", cfg.Supabase.ForwardUserToken),
+		zap.Bool("postgres_fallback", cfg.Supabase.PostgresFallback),
+		zap.Int("retry_max_attempts", cfg.Supabase.RetryMaxAttempts),
+		zap.Int("breaker_threshold", cfg.Supabase.BreakerThreshold),
+		zap.Duration("request_timeout", cfg.Supabase.RequestTimeout),
+	)
+
+	// Domains with a Supabase project or schema of their own get a repository each;
+	// the registry picks one per request from the domain its route attaches
+	domainRepos := make(map[string]repository.SupabaseRepository, len(cfg.Supabase.Domains))
+	for domain := range cfg.Supabase.Domains {
+		project := cfg.Supabase.Project(domain)
+		domainRepos[domain], err = newSupabaseRepo(project)
+		if err != nil {
+			log.Error("Failed to initialize Supabase repository for domain",
+				zap.String("domain", domain),
+				zap.Error(err))
+			os.Exit(1)
+		}
+		log.Info("Initialized Supabase repository for domain",
+			zap.String("domain", domain),
+			zap.String("url", project.URL),
+			zap.String("schema", project.Schema),
+		)
+	}
+	supabaseRegistry := repository.NewSupabaseRegistry(supabaseRepo, domainRepos)
+
+	// Initialize Supabase Storage for product images
+	storageClient, err := repository.NewStorageClient(cfg.Supabase.URL, cfg.Supabase.APIKey,
+		cfg.Supabase.StorageBucket, cfg.Supabase.StoragePublic)
+	if err != nil {
+		log.Error("Failed to initialize Supabase Storage client", zap.Error(err))
+		os.Exit(1)
+	}
+	if cfg.Tracing.Enabled {
+		storageClient.EnableTracing()
+	}
+	log.Info("Product images stored in Supabase Storage",
+		zap.String("bucket", cfg.Supabase.StorageBucket),
+		zap.Bool("public", cfg.Supabase.StoragePublic),
 	)
 
 	// Create domain service instance
+	var serviceOpts []service.Option
+	if cfg.Redis.RefreshAhead {
+		refresher := cache.NewRefresher(cacheService, cache.RefresherOptions{
+			Interval: cfg.Redis.RefreshInterval,
+			MinHits:  cfg.Redis.RefreshMinHits,
+			MaxKeys:  cfg.Redis.RefreshMaxKeys,
+		}, log.Logger)
+		refresher.Start()
+		defer refresher.Stop()
+		serviceOpts = append(serviceOpts, service.WithAccessTracker(refresher))
+	}
+	serviceMetrics := metrics.NewServiceMetrics()
+	// The cache TTL, and the bounds of callers' overrides of it, change on reloads
+	cacheTTL := cache.NewTTL(cfg.Redis.TTL)
+	minTTLOverride := cache.NewTTL(cfg.Redis.MinTTLOverride)
+	maxTTLOverride := cache.NewTTL(cfg.Redis.MaxTTLOverride)
+	serviceOpts = append(serviceOpts,
+		service.WithReloadableTTL(cacheTTL),
+		service.WithStaleCopies(cfg.Redis.StaleGrace),
+		service.WithMetrics(serviceMetrics),
+	)
+
 	_ = service.NewDomainService(
 		cacheService,
-		supabaseRepo,
+		supabaseRegistry,
 		log.Logger,
 		cfg.Redis.TTL,
+		serviceOpts...,
 	)
 
 	log.Info("Domain service initialized",
 		zap.Duration("cache_ttl", cfg.Redis.TTL),
+		zap.String("cache_key_prefix", cfg.Redis.KeyPrefix),
+		zap.Bool("refresh_ahead", cfg.Redis.RefreshAhead),
+		zap.Duration("stale_grace", cfg.Redis.StaleGrace),
 	)
 
-	// Initialize PostgreSQL repository
-	pgRepo, err := repository.NewPostgresRepository(cfg.Database.URL, log.Logger)
+	// Clear cached catalog reads when products change, whoever wrote them, and the push
+	// fingerprints of stores changed by other applications
+	if cfg.Database.ChangeNotifications {
+		listener := pgRepo.NewChangeListener(func(ctx context.Context, change repository.CatalogChange) {
+			cache.InvalidateDomains(ctx, cacheService, log.Logger, service.CatalogChangeDomains(change)...)
+			handlers.ForgetChangedPushes(ctx, cacheService, log.Logger, change)
+		})
+		listener.Start()
+		defer listener.Stop()
+	}
+
+	// Catalog reads are served from Postgres with the same caching as the domain service
+	catalogService := service.NewCatalogService(
+		cacheService,
+		pgRepo,
+		log.Logger,
+		cfg.Redis.TTL,
+		serviceOpts...,
+	)
+
+	// GraphQL queries of the catalog batch their reads through the same cache
+	graphQLSchema, err := catalog.NewSchema(pgRepo, cacheService, cacheTTL, log.Logger)
 	if err != nil {
-		log.Error("Failed to initialize PostgreSQL repository", zap.Error(err))
+		log.Error("Failed to build GraphQL schema", zap.Error(err))
 		os.Exit(1)
 	}
-	defer pgRepo.Close()
 
-	log.Info("Successfully initialized PostgreSQL repository")
+	// Stock updates, pushes and store writes publish their changes over Redis pub/sub, and
+	// the hub relays those of every instance to this one's WebSocket and event stream clients
+	var events *realtime.Publisher
+	var hub *realtime.Hub
+	if cfg.Realtime.Enabled {
+		events = realtime.NewPublisher(cacheService, cacheService, pgRepo, log.Logger)
+		hub = realtime.NewHub(cacheService, cfg.Realtime.MaxConnections, log.Logger)
+		hub.Start()
+	}
+
+	// Writes queue webhook events in Postgres, and the worker sends those due; instances
+	// claim deliveries with SKIP LOCKED, so each is sent by one
+	var dispatcher *webhooks.Dispatcher
+	if cfg.Webhooks.Enabled {
+		dispatcher = webhooks.NewDispatcher(pgRepo, log.Logger)
+		worker := webhooks.NewWorker(pgRepo, webhooks.WorkerOptions{
+			PollInterval: cfg.Webhooks.PollInterval,
+			Timeout:      cfg.Webhooks.Timeout,
+			MaxAttempts:  cfg.Webhooks.MaxAttempts,
+			Concurrency:  cfg.Webhooks.Concurrency,
+			Retention:    cfg.Webhooks.Retention,
+		}, log.Logger)
+		worker.Start()
+		defer worker.Stop()
+	}
+
+	// Background jobs queued on Redis by any instance; job types are registered before Start
+	var jobPool *jobs.Pool
+	if cfg.Jobs.Enabled {
+		jobPool = jobs.NewPool(cacheService, jobs.PoolOptions{
+			Queue:           "jobs",
+			Concurrency:     cfg.Jobs.Concurrency,
+			MaxAttempts:     cfg.Jobs.MaxAttempts,
+			ClaimAfter:      cfg.Jobs.ClaimAfter,
+			ShutdownTimeout: cfg.Jobs.ShutdownTimeout,
+		}, log.Logger)
+		if err := jobPool.Start(); err != nil {
+			log.Error("Failed to start job pool", zap.Error(err))
+			os.Exit(1)
+		}
+	}
+
+	// API keys are validated against the api_keys table, cached in Redis
+	authenticator := auth.NewAuthenticator(pgRepo, cacheService, cfg.Server.APIKeyCacheTTL,
+		cfg.Server.BearerTokens, log.Logger)
+	if cfg.Supabase.JWTSecret != "" {
+		authenticator.EnableJWT(cfg.Supabase.JWTSecret)
+	}
+	if len(cfg.Server.AdminTokens) > 0 {
+		authenticator.EnableAdminTokens(cfg.Server.AdminTokens)
+	}
+
+	// Product pushes and stock updates are accepted from these addresses only, if any
+	pushAllowedCIDRs, err := auth.ParseCIDRs(cfg.Server.PushAllowedCIDRs)
+	if err != nil {
+		log.Error("Invalid push allowed CIDRs", zap.Error(err))
+		os.Exit(1)
+	}
+	if len(pushAllowedCIDRs) > 0 && cfg.Server.TrustedProxies == nil {
+		log.Warn("Push allowed CIDRs are checked against the connection's address, ignoring X-Forwarded-For; behind a proxy, set SERVER_TRUSTED_PROXIES")
+	}
+
+	loggingOptions := []middleware.LoggingOption{
+		middleware.WithSkipPaths(cfg.Logging.SkipPaths...),
+		middleware.WithSampling(cfg.Logging.SampleRatio),
+		middleware.WithRedactedHeaders(cfg.Logging.RedactHeaders...),
+	}
+	if cfg.Logging.RequestHeaders {
+		loggingOptions = append(loggingOptions, middleware.WithHeaders())
+	}
+
+	// Error messages are translated from the built-in catalog and any in the catalog dir
+	var messages *i18n.Catalog
+	if cfg.I18n.Enabled {
+		messages, err = i18n.NewCatalog(cfg.I18n.CatalogDir)
+		if err != nil {
+			log.Error("Failed to load message catalog", zap.Error(err))
+			os.Exit(1)
+		}
+		log.Info("Error messages are translated", zap.Strings("locales", messages.Locales()))
+	}
+
+	corsOrigins := middleware.NewAllowedOrigins(cfg.Server.CORSAllowedOrigins)
+	rateLimiter := middleware.NewRateLimiter(rateLimits(cfg.Server.RateLimit))
 
 	// Set up router with all handlers
 	routerDeps := router.HandlerDependencies{
-		Cache:        cacheService,
-		Repository:   supabaseRepo,
-		PgRepo:       pgRepo,
-		Logger:       log.Logger,
-		BearerTokens: cfg.Server.BearerTokens,
+		Cache:                cacheService,
+		Repository:           supabaseRegistry,
+		PgRepo:               pgRepo,
+		Catalog:              catalogService,
+		GraphQL:              graphQLSchema,
+		Events:               events,
+		Realtime:             hub,
+		RealtimePingInterval: cfg.Realtime.PingInterval,
+		Webhooks:             dispatcher,
+		Jobs:                 jobPool,
+		Logger:               log.Logger,
+		Auth:                 authenticator,
+		ForwardUserTokens:    cfg.Supabase.ForwardUserToken,
+		Storage:              storageClient,
+		StorageMaxUpload:     cfg.Supabase.StorageMaxUpload,
+		StorageSignedURLTTL:  cfg.Supabase.StorageSignedURLTTL,
+		ServiceMetrics:       serviceMetrics,
+		MinTTLOverride:       minTTLOverride,
+		MaxTTLOverride:       maxTTLOverride,
+		CORSOrigins:          corsOrigins,
+		RateLimiter:          rateLimiter,
+		TracingService:       tracingService,
+		LoggingOptions:       loggingOptions,
+		Messages:             messages,
+		PushSigningSecrets:   cfg.Server.PushSigningSecrets,
+		PushAllowedCIDRs:     pushAllowedCIDRs,
+		TrustedProxies:       cfg.Server.TrustedProxies,
+		Tenancy:              cfg.Tenancy.Enabled,
+		TenantHeader:         cfg.Tenancy.Header,
+		DefaultTenant:        cfg.Tenancy.DefaultTenant,
+		CSVImportTemplates:   cfg.Server.CSVImportTemplates,
+		IdempotencyTTL:       cfg.Server.IdempotencyTTL,
+		MaxBodySize:          cfg.Server.MaxBodySize,
+		MaxPushBodySize:      cfg.Server.MaxPushBodySize,
+		GzipResponses:        cfg.Server.GzipResponses,
+		GzipMinSize:          cfg.Server.GzipMinSize,
+		GzipTypes:            cfg.Server.GzipTypes,
+		CacheControl:         cfg.Server.CacheControl,
+		CacheControlMaxAge:   cfg.Server.CacheControlMaxAge,
+		ReadyTimeouts: map[string]time.Duration{
+			"redis":    cfg.Health.RedisTimeout,
+			"postgres": cfg.Health.PostgresTimeout,
+			"supabase": cfg.Health.SupabaseTimeout,
+		},
+		OptionalDependencies: cfg.Health.Optional,
 	}
 	ginRouter := router.SetupRouter(routerDeps, cfg.Server.RequestTimeout)
 
@@ -127,22 +474,149 @@ func main() {
 		WriteTimeout: cfg.Server.WriteTimeout,
 	}
 
+	// Terminate TLS and serve HTTP/2 when there is no load balancer in front to do it
+	var tlsServer *https.TLS
+	if cfg.Server.TLS.Enabled {
+		tlsServer, err = https.New(https.Options{
+			CertFile:         cfg.Server.TLS.CertFile,
+			KeyFile:          cfg.Server.TLS.KeyFile,
+			AutocertDomains:  cfg.Server.TLS.AutocertDomains,
+			AutocertCacheDir: cfg.Server.TLS.AutocertCacheDir,
+			AutocertEmail:    cfg.Server.TLS.AutocertEmail,
+		})
+		if err != nil {
+			log.Error("Failed to set up TLS", zap.Error(err))
+			os.Exit(1)
+		}
+		server.TLSConfig = tlsServer.Config()
+	}
+
 	// Start server in a goroutine
 	go func() {
 		log.Info("HTTP server starting",
 			zap.String("address", server.Addr),
+			zap.Bool("tls", tlsServer != nil),
 			zap.Duration("read_timeout", cfg.Server.ReadTimeout),
 			zap.Duration("write_timeout", cfg.Server.WriteTimeout),
 		)
 
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		var err error
+		if tlsServer != nil {
+			err = server.ListenAndServeTLS("", "")
+		} else {
+			err = server.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			log.Error("HTTP server failed", zap.Error(err))
 			os.Exit(1)
 		}
 	}()
 
+	// Redirect plain HTTP to HTTPS, answering Let's Encrypt's challenges
+	var redirectServer *http.Server
+	if tlsServer != nil && cfg.Server.TLS.RedirectPort != "" {
+		redirectServer = &http.Server{
+			Addr:         ":" + cfg.Server.TLS.RedirectPort,
+			Handler:      tlsServer.RedirectHandler(cfg.Server.Port),
+			ReadTimeout:  cfg.Server.ReadTimeout,
+			WriteTimeout: cfg.Server.WriteTimeout,
+		}
+		go func() {
+			log.Info("HTTPS redirect server starting", zap.String("address", redirectServer.Addr))
+			if err := redirectServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Error("HTTPS redirect server failed", zap.Error(err))
+				os.Exit(1)
+			}
+		}()
+	}
+
+	// Start the gRPC API for ERP connectors on its own port
+	var grpcServer *grpc.Server
+	if cfg.GRPC.Enabled {
+		listener, err := net.Listen("tcp", ":"+cfg.GRPC.Port)
+		if err != nil {
+			log.Error("Failed to listen for gRPC", zap.Error(err))
+			os.Exit(1)
+		}
+		grpcServer = grpcapi.NewServer(grpcapi.Dependencies{
+			PgRepo:             pgRepo,
+			Cache:              cacheService,
+			Logger:             log.Logger,
+			Auth:               authenticator,
+			PushSigningSecrets: cfg.Server.PushSigningSecrets,
+			PushAllowedCIDRs:   pushAllowedCIDRs,
+			Tenancy:            cfg.Tenancy.Enabled,
+			TenantHeader:       cfg.Tenancy.Header,
+			DefaultTenant:      cfg.Tenancy.DefaultTenant,
+			MaxRecvMsgSize:     cfg.GRPC.MaxRecvMsgSize,
+			Events:             events,
+			Webhooks:           dispatcher,
+		})
+
+		go func() {
+			log.Info("gRPC server starting", zap.String("address", listener.Addr().String()))
+			if err := grpcServer.Serve(listener); err != nil {
+				log.Error("gRPC server failed", zap.Error(err))
+				os.Exit(1)
+			}
+		}()
+	}
+
 	log.Info("Server started successfully", zap.String("port", cfg.Server.Port))
 
+	// Reload the log level, cache TTLs, bearer tokens, CORS origins, rate limits and TLS
+	// certificate on SIGHUP, and when the config file changes if it is watched. A
+	// configuration is applied only once it loads and validates in full, and one reload at
+	// a time
+	var reloadMu sync.Mutex
+	reload := func(next *config.Config) {
+		reloadMu.Lock()
+		defer reloadMu.Unlock()
+		if err := log.SetLevel(next.Logging.Level); err != nil {
+			log.Error("Failed to reload configuration", zap.Error(err))
+			return
+		}
+		cacheTTL.Set(next.Redis.TTL)
+		minTTLOverride.Set(next.Redis.MinTTLOverride)
+		maxTTLOverride.Set(next.Redis.MaxTTLOverride)
+		authenticator.SetCacheTTL(next.Server.APIKeyCacheTTL)
+		authenticator.SetBootstrapTokens(next.Server.BearerTokens)
+		corsOrigins.Set(next.Server.CORSAllowedOrigins)
+		rateLimiter.Set(rateLimits(next.Server.RateLimit))
+		if tlsServer != nil {
+			if err := tlsServer.Reload(); err != nil {
+				log.Error("Failed to reload TLS certificate; keeping the current one", zap.Error(err))
+			}
+		}
+		log.Info("Configuration reloaded",
+			zap.String("log_level", next.Logging.Level),
+			zap.Duration("cache_ttl", next.Redis.TTL),
+			zap.Duration("api_key_cache_ttl", next.Server.APIKeyCacheTTL),
+			zap.Int("bearer_tokens", len(next.Server.BearerTokens)),
+			zap.Strings("cors_allowed_origins", next.Server.CORSAllowedOrigins),
+			zap.Float64("rate_limit_requests_per_second", next.Server.RateLimit.RequestsPerSecond),
+			zap.Int("rate_limit_tenants", len(next.Server.RateLimit.Tenants)),
+		)
+	}
+	reloadFailed := func(err error) {
+		log.Error("Failed to reload configuration; keeping the current one", zap.Error(err))
+	}
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	go func() {
+		for range hangup {
+			next, err := config.Load()
+			if err != nil {
+				reloadFailed(err)
+				continue
+			}
+			reload(next)
+		}
+	}()
+	if cfg.Server.WatchConfig && !config.Watch(reload, reloadFailed) {
+		log.Warn("No config file to watch; reload with SIGHUP")
+	}
+
 	// Wait for interrupt signal for graceful shutdown
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -154,6 +628,11 @@ func main() {
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer shutdownCancel()
 
+	// Close WebSocket connections, which the HTTP server's shutdown doesn't wait for
+	if hub != nil {
+		hub.Stop()
+	}
+
 	// Shutdown HTTP server
 	if err := server.Shutdown(shutdownCtx); err != nil {
 		log.Error("Server forced to shutdown", zap.Error(err))
@@ -161,6 +640,33 @@ func main() {
 		log.Info("HTTP server shutdown complete")
 	}
 
+	if redirectServer != nil {
+		if err := redirectServer.Shutdown(shutdownCtx); err != nil {
+			log.Error("HTTPS redirect server forced to shutdown", zap.Error(err))
+		}
+	}
+
+	// Let in-flight gRPC calls finish, unless they outlast the shutdown timeout
+	if grpcServer != nil {
+		stopped := make(chan struct{})
+		go func() {
+			grpcServer.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+			log.Info("gRPC server shutdown complete")
+		case <-shutdownCtx.Done():
+			grpcServer.Stop()
+			log.Error("gRPC server forced to shutdown")
+		}
+	}
+
+	// Let running jobs finish, after the servers so requests can still queue them
+	if jobPool != nil {
+		jobPool.Stop()
+	}
+
 	// Close Redis connections
 	if err := cacheService.Close(); err != nil {
 		log.Error("Error closing Redis connection", zap.Error(err))
@@ -175,3 +681,15 @@ func main() {
 
 	log.Info("Shutdown complete")
 }
+
+// rateLimits returns the limits of a rate limit configuration
+func rateLimits(cfg config.RateLimitConfig) middleware.RateLimits {
+	limits := middleware.RateLimits{
+		Default: middleware.RateLimit{RequestsPerSecond: cfg.RequestsPerSecond, Burst: cfg.Burst},
+		Tenants: make(map[string]middleware.RateLimit, len(cfg.Tenants)),
+	}
+	for id, limit := range cfg.Tenants {
+		limits.Tenants[id] = middleware.RateLimit{RequestsPerSecond: limit.RequestsPerSecond, Burst: limit.Burst}
+	}
+	return limits
+}
@@ -0,0 +1,6 @@
+-- API key IP allowlists
+-- ERP integrations push products and stock from known servers. A key issued with
+-- allowed_cidrs may only push from those addresses; an empty list allows any address, as
+-- every key did before
+
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS allowed_cidrs CIDR[] NOT NULL DEFAULT '{}';
@@ -0,0 +1,21 @@
+-- API key roles
+-- Every key carries a role deciding which route groups it may reach: 'erp' pushes products
+-- and stock, 'store_admin' manages the one store it is bound to, 'consumer' only reads and
+-- 'platform_admin' may do everything. Its scopes narrow what it may do within them
+
+-- 1. Role column, backfilled from the scopes keys were issued with
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS role VARCHAR(20);
+
+UPDATE api_keys
+SET role = CASE WHEN 'admin' = ANY(scopes) THEN 'platform_admin' ELSE 'erp' END
+WHERE role IS NULL;
+
+ALTER TABLE api_keys ALTER COLUMN role SET NOT NULL;
+
+-- 2. Store admins are bound to their store; platform admins to none
+ALTER TABLE api_keys DROP CONSTRAINT IF EXISTS api_keys_role_check;
+ALTER TABLE api_keys ADD CONSTRAINT api_keys_role_check CHECK (
+    role IN ('erp', 'store_admin', 'consumer', 'platform_admin')
+    AND (role <> 'store_admin' OR store_id IS NOT NULL)
+    AND (role <> 'platform_admin' OR store_id IS NULL)
+);
@@ -0,0 +1,24 @@
+-- API keys
+-- Callers authenticate with a bearer API key instead of the static SERVER_BEARER_TOKENS
+-- list. Only the SHA-256 of a key is stored; key_prefix keeps its first characters so
+-- admins can tell keys apart. A key bound to a store may only write that store's data
+
+-- 1. API keys table
+CREATE TABLE IF NOT EXISTS api_keys (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(100) NOT NULL,
+    key_hash CHAR(64) NOT NULL UNIQUE, -- Hex SHA-256 of the key
+    key_prefix VARCHAR(16) NOT NULL,
+    scopes TEXT[] NOT NULL DEFAULT '{}', -- 'read:catalog', 'push:products', 'write:stock', 'write:stores', 'admin'
+    store_id UUID REFERENCES stores(id) ON DELETE CASCADE, -- NULL: any store
+    expires_at TIMESTAMP WITH TIME ZONE, -- NULL: never
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    created_by VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK (store_id IS NULL OR NOT 'admin' = ANY(scopes))
+);
+
+-- 2. Indexes for the admin listing, newest first
+CREATE INDEX IF NOT EXISTS idx_api_keys_created_at ON api_keys(created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_api_keys_store_id ON api_keys(store_id) WHERE store_id IS NOT NULL;
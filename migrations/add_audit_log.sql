@@ -0,0 +1,22 @@
+-- Audit log
+-- One row per mutating API call: product pushes, stock updates, and store detail and
+-- status changes. actor identifies the caller's bearer token by fingerprint, never the
+-- token itself; before/after hold only the fields that changed
+
+-- 1. Audit table
+CREATE TABLE IF NOT EXISTS audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    actor VARCHAR(100) NOT NULL,
+    endpoint VARCHAR(255) NOT NULL,
+    action VARCHAR(50) NOT NULL, -- 'product_push', 'stock_update', 'store_update', 'store_status'
+    entity_ids TEXT[] NOT NULL DEFAULT '{}', -- Store IDs, then external product IDs
+    before JSONB,
+    after JSONB,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 2. Indexes for the admin audit filters, newest first
+CREATE INDEX IF NOT EXISTS idx_audit_log_created_at ON audit_log(created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_log_action ON audit_log(action, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_log_actor ON audit_log(actor, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_log_entity_ids ON audit_log USING gin(entity_ids);
@@ -0,0 +1,49 @@
+-- Brand merges
+-- Merging duplicate brands (e.g. "Nestle" and "Nestlé") moves their products to a
+-- canonical brand and deactivates the duplicates. A merged brand keeps its row with
+-- merged_into set, so find_or_create_brand resolves later pushes of the old name to the
+-- canonical brand instead of recreating the duplicate
+
+-- 1. Merge target
+ALTER TABLE brands ADD COLUMN IF NOT EXISTS merged_into UUID REFERENCES brands(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_brands_merged_into ON brands(merged_into) WHERE merged_into IS NOT NULL;
+
+-- 2. Resolve merged brands when finding a brand by name
+CREATE OR REPLACE FUNCTION find_or_create_brand(
+    p_brand_name TEXT
+)
+RETURNS UUID AS $$
+DECLARE
+    v_brand_id UUID;
+    v_normalized_name TEXT;
+    v_slug TEXT;
+BEGIN
+    IF p_brand_name IS NULL OR TRIM(p_brand_name) = '' THEN
+        RETURN NULL;
+    END IF;
+    
+    v_normalized_name := normalize_product_name(p_brand_name);
+    
+    -- Try exact name match; merged brands resolve to their canonical brand
+    SELECT COALESCE(merged_into, id) INTO v_brand_id FROM brands WHERE name = p_brand_name LIMIT 1;
+    IF v_brand_id IS NOT NULL THEN RETURN v_brand_id; END IF;
+    
+    -- Try normalized name match
+    SELECT COALESCE(merged_into, id) INTO v_brand_id FROM brands WHERE normalized_name = v_normalized_name LIMIT 1;
+    IF v_brand_id IS NOT NULL THEN RETURN v_brand_id; END IF;
+    
+    -- Create new brand
+    v_slug := LOWER(REGEXP_REPLACE(p_brand_name, '[^a-zA-Z0-9]+', '-', 'g'));
+    v_slug := TRIM(BOTH '-' FROM v_slug);
+    IF EXISTS (SELECT 1 FROM brands WHERE slug = v_slug) THEN
+        v_slug := v_slug || '-' || EXTRACT(EPOCH FROM CURRENT_TIMESTAMP)::BIGINT;
+    END IF;
+    
+    INSERT INTO brands (name, slug, normalized_name)
+    VALUES (p_brand_name, v_slug, v_normalized_name)
+    RETURNING id INTO v_brand_id;
+    
+    RETURN v_brand_id;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,48 @@
+-- Catalog change notifications
+-- Writes to products and store_products send a NOTIFY on the catalog_changes channel so
+-- the middleware can clear cached catalog reads, including after writes made directly to
+-- the database by other services. Postgres folds identical notifications raised in one
+-- transaction, so a bulk write sends one per table (and per store for store_products)
+-- Each notification carries the writing session's application_name as its origin, so
+-- the middleware can tell its own writes from those of other services
+
+-- 1. Products: one notification per statement
+CREATE OR REPLACE FUNCTION notify_product_change()
+RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM pg_notify('catalog_changes', json_build_object('table', TG_TABLE_NAME,
+            'origin', current_setting('application_name'))::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trigger_notify_product_change ON products;
+CREATE TRIGGER trigger_notify_product_change
+    AFTER INSERT OR UPDATE OR DELETE OR TRUNCATE ON products
+    FOR EACH STATEMENT
+    EXECUTE FUNCTION notify_product_change();
+
+-- 2. Store products: per row, so the notification names the store
+CREATE OR REPLACE FUNCTION notify_store_product_change()
+RETURNS TRIGGER AS $$
+DECLARE
+    v_store_id UUID;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        v_store_id := OLD.store_id;
+    ELSE
+        v_store_id := NEW.store_id;
+    END IF;
+
+    PERFORM pg_notify('catalog_changes',
+        json_build_object('table', TG_TABLE_NAME, 'store_id', v_store_id,
+            'origin', current_setting('application_name'))::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trigger_notify_store_product_change ON store_products;
+CREATE TRIGGER trigger_notify_store_product_change
+    AFTER INSERT OR UPDATE OR DELETE ON store_products
+    FOR EACH ROW
+    EXECUTE FUNCTION notify_store_product_change();
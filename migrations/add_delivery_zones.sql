@@ -0,0 +1,21 @@
+-- Delivery zones
+-- Each store delivers to one or more zones: a circle of radius_km around a center (the
+-- store's location by default) or a drawn polygon. Both are stored as a geography polygon
+-- in area, so coverage checks are a single GIST-indexed ST_Covers
+
+CREATE TABLE IF NOT EXISTS delivery_zones (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    store_id UUID NOT NULL REFERENCES stores(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    zone_type VARCHAR(20) NOT NULL CHECK (zone_type IN ('radius', 'polygon')),
+    radius_km DECIMAL(6, 2), -- Radius zones only
+    center GEOGRAPHY(POINT, 4326), -- Radius zones only
+    area GEOGRAPHY(POLYGON, 4326) NOT NULL,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK (zone_type = 'polygon' OR (radius_km > 0 AND center IS NOT NULL))
+);
+
+CREATE INDEX IF NOT EXISTS idx_delivery_zones_store_id ON delivery_zones(store_id);
+CREATE INDEX IF NOT EXISTS idx_delivery_zones_area ON delivery_zones USING GIST(area) WHERE is_active = true;
@@ -0,0 +1,93 @@
+-- Multi-tenancy
+-- One deployment serves several marketplace operators (tenants). Every catalog, store,
+-- audit and webhook row belongs to a tenant, and row-level security limits each session
+-- to the rows of the tenant in its app.tenant_id setting, which the middleware sets on
+-- every connection it uses with TENANCY_ENABLED. A session without the setting - the
+-- middleware's background work, PostgREST, psql - sees every tenant, as before.
+-- Rows that predate this migration, and rows written without a tenant, belong to the
+-- default tenant. The policies don't bind superusers or roles with BYPASSRLS, so the
+-- middleware must connect as a role without them
+
+-- 1. Tenants
+CREATE TABLE IF NOT EXISTS tenants (
+    id VARCHAR(64) PRIMARY KEY CHECK (id ~ '^[a-z0-9][a-z0-9_-]{0,63}$'),
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO tenants (id, name) VALUES ('default', 'Default') ON CONFLICT (id) DO NOTHING;
+
+-- 2. The session's tenant, or NULL for a session scoped to none
+CREATE OR REPLACE FUNCTION app_tenant()
+RETURNS VARCHAR AS $$
+    SELECT NULLIF(current_setting('app.tenant_id', true), '')
+$$ LANGUAGE sql STABLE;
+
+-- 3. tenant_id on every tenant-owned table, with its isolation policy
+DO $$
+DECLARE
+    t TEXT;
+BEGIN
+    FOREACH t IN ARRAY ARRAY[
+        'stores', 'store_hours', 'store_holidays', 'delivery_zones', 'categories', 'brands',
+        'products', 'product_images', 'store_products', 'taxes', 'store_product_taxes',
+        'product_variations', 'product_addon_groups', 'product_addon_group_items',
+        'store_product_mappings', 'audit_log', 'webhook_subscriptions', 'webhook_deliveries'
+    ] LOOP
+        IF to_regclass(t) IS NULL THEN
+            CONTINUE;
+        END IF;
+
+        EXECUTE format('ALTER TABLE %I ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(64) REFERENCES tenants(id)', t);
+        EXECUTE format('UPDATE %I SET tenant_id = ''default'' WHERE tenant_id IS NULL', t);
+        EXECUTE format('ALTER TABLE %I ALTER COLUMN tenant_id SET DEFAULT COALESCE(app_tenant(), ''default'')', t);
+        EXECUTE format('ALTER TABLE %I ALTER COLUMN tenant_id SET NOT NULL', t);
+        EXECUTE format('CREATE INDEX IF NOT EXISTS %I ON %I(tenant_id)', 'idx_' || t || '_tenant_id', t);
+
+        EXECUTE format('ALTER TABLE %I ENABLE ROW LEVEL SECURITY', t);
+        EXECUTE format('ALTER TABLE %I FORCE ROW LEVEL SECURITY', t);
+        EXECUTE format('DROP POLICY IF EXISTS tenant_isolation ON %I', t);
+        EXECUTE format('CREATE POLICY tenant_isolation ON %I
+            USING (app_tenant() IS NULL OR tenant_id = app_tenant())
+            WITH CHECK (app_tenant() IS NULL OR tenant_id = app_tenant())', t);
+    END LOOP;
+END $$;
+
+-- 4. API keys: a key without a tenant is a platform key, which acts for any tenant.
+-- Existing platform admin keys stay platform keys; every other key joins the default tenant
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(64) REFERENCES tenants(id);
+UPDATE api_keys SET tenant_id = 'default' WHERE tenant_id IS NULL AND role <> 'platform_admin';
+ALTER TABLE api_keys ALTER COLUMN tenant_id SET DEFAULT app_tenant();
+CREATE INDEX IF NOT EXISTS idx_api_keys_tenant_id ON api_keys(tenant_id);
+
+ALTER TABLE api_keys ENABLE ROW LEVEL SECURITY;
+ALTER TABLE api_keys FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS tenant_isolation ON api_keys;
+CREATE POLICY tenant_isolation ON api_keys
+    USING (app_tenant() IS NULL OR tenant_id = app_tenant())
+    WITH CHECK (app_tenant() IS NULL OR tenant_id = app_tenant());
+
+-- 5. Natural keys are unique per tenant, so two operators may use the same ERP IDs,
+-- slugs, brand names and SKUs. Store and category upserts conflict on (tenant_id, external_id)
+ALTER TABLE stores DROP CONSTRAINT IF EXISTS stores_external_id_key;
+ALTER TABLE stores DROP CONSTRAINT IF EXISTS stores_slug_key;
+CREATE UNIQUE INDEX IF NOT EXISTS stores_tenant_external_id_key ON stores(tenant_id, external_id);
+CREATE UNIQUE INDEX IF NOT EXISTS stores_tenant_slug_key ON stores(tenant_id, slug);
+
+ALTER TABLE categories DROP CONSTRAINT IF EXISTS categories_external_id_key;
+ALTER TABLE categories DROP CONSTRAINT IF EXISTS categories_slug_key;
+CREATE UNIQUE INDEX IF NOT EXISTS categories_tenant_external_id_key ON categories(tenant_id, external_id);
+CREATE UNIQUE INDEX IF NOT EXISTS categories_tenant_slug_key ON categories(tenant_id, slug);
+
+ALTER TABLE brands DROP CONSTRAINT IF EXISTS brands_name_key;
+ALTER TABLE brands DROP CONSTRAINT IF EXISTS brands_slug_key;
+CREATE UNIQUE INDEX IF NOT EXISTS brands_tenant_name_key ON brands(tenant_id, name);
+CREATE UNIQUE INDEX IF NOT EXISTS brands_tenant_slug_key ON brands(tenant_id, slug);
+
+ALTER TABLE products DROP CONSTRAINT IF EXISTS products_sku_key;
+ALTER TABLE products DROP CONSTRAINT IF EXISTS products_urn_key;
+CREATE UNIQUE INDEX IF NOT EXISTS products_tenant_sku_key ON products(tenant_id, sku);
+CREATE UNIQUE INDEX IF NOT EXISTS products_tenant_urn_key ON products(tenant_id, urn);
+
+ALTER TABLE product_variations DROP CONSTRAINT IF EXISTS product_variations_external_id_key;
+CREATE UNIQUE INDEX IF NOT EXISTS product_variations_tenant_external_id_key ON product_variations(tenant_id, external_id);
@@ -0,0 +1,69 @@
+-- Full-text search over products
+-- search_vector weights name highest, then brand, then manufacturer, then description,
+-- and is kept current by triggers on products and brands
+
+-- 1. Add search column
+ALTER TABLE products ADD COLUMN IF NOT EXISTS search_vector TSVECTOR;
+
+-- 2. Function to build a product's search vector
+CREATE OR REPLACE FUNCTION build_product_search_vector(
+    p_name TEXT,
+    p_description TEXT,
+    p_manufacturer TEXT,
+    p_brand_id UUID
+)
+RETURNS TSVECTOR AS $$
+DECLARE
+    v_brand_name TEXT;
+BEGIN
+    IF p_brand_id IS NOT NULL THEN
+        SELECT name INTO v_brand_name FROM brands WHERE id = p_brand_id;
+    END IF;
+
+    RETURN setweight(to_tsvector('english', COALESCE(p_name, '')), 'A')
+        || setweight(to_tsvector('english', COALESCE(v_brand_name, '')), 'B')
+        || setweight(to_tsvector('english', COALESCE(p_manufacturer, '')), 'C')
+        || setweight(to_tsvector('english', COALESCE(p_description, '')), 'D');
+END;
+$$ LANGUAGE plpgsql STABLE;
+
+-- 3. Keep search_vector current when searchable product fields change
+CREATE OR REPLACE FUNCTION update_product_search_vector()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.search_vector := build_product_search_vector(NEW.name, NEW.description, NEW.manufacturer, NEW.brand_id);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trigger_update_product_search_vector ON products;
+CREATE TRIGGER trigger_update_product_search_vector
+    BEFORE INSERT OR UPDATE OF name, description, manufacturer, brand_id ON products
+    FOR EACH ROW
+    EXECUTE FUNCTION update_product_search_vector();
+
+-- 4. Re-index a brand's products when the brand is renamed
+CREATE OR REPLACE FUNCTION refresh_brand_product_search_vectors()
+RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE products
+    SET search_vector = build_product_search_vector(name, description, manufacturer, brand_id)
+    WHERE brand_id = NEW.id;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trigger_refresh_brand_product_search_vectors ON brands;
+CREATE TRIGGER trigger_refresh_brand_product_search_vectors
+    AFTER UPDATE OF name ON brands
+    FOR EACH ROW
+    WHEN (OLD.name IS DISTINCT FROM NEW.name)
+    EXECUTE FUNCTION refresh_brand_product_search_vectors();
+
+-- 5. Backfill existing products
+UPDATE products
+SET search_vector = build_product_search_vector(name, description, manufacturer, brand_id)
+WHERE search_vector IS NULL;
+
+-- 6. Index for @@ queries
+CREATE INDEX IF NOT EXISTS idx_products_search_vector ON products USING gin(search_vector);
@@ -0,0 +1,25 @@
+-- Store opening hours
+-- is_open_now is computed from a store's weekly store_hours windows in its own timezone,
+-- with dated holidays overriding the weekday (closed all day, or special hours).
+-- stores.is_open stays as a manual override that closes the store regardless of hours
+
+-- 1. Store timezone; store_hours times are local to it
+ALTER TABLE stores ADD COLUMN IF NOT EXISTS timezone VARCHAR(64) NOT NULL DEFAULT 'Asia/Kolkata';
+
+-- 2. Holiday exceptions
+CREATE TABLE IF NOT EXISTS store_holidays (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    store_id UUID NOT NULL REFERENCES stores(id) ON DELETE CASCADE,
+    date DATE NOT NULL,
+    is_closed BOOLEAN NOT NULL DEFAULT TRUE,
+    open_time TIME, -- Required unless is_closed
+    close_time TIME,
+    note TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(store_id, date),
+    CHECK (is_closed OR (open_time IS NOT NULL AND close_time IS NOT NULL))
+);
+
+-- 3. Lookups by store
+CREATE INDEX IF NOT EXISTS idx_store_hours_store_id ON store_hours(store_id, day_of_week);
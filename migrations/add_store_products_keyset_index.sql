@@ -0,0 +1,5 @@
+-- Keyset pagination for store listings
+-- Listing endpoints page by (created_at, id), newest first; this index serves both the
+-- ORDER BY and the (created_at, id) < cursor condition without scanning skipped rows
+
+CREATE INDEX IF NOT EXISTS idx_store_products_created_at_id ON store_products(created_at DESC, id DESC);
@@ -10,6 +10,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/yourusername/supabase-redis-middleware/internal/cache"
+	"github.com/yourusername/supabase-redis-middleware/internal/handlers"
 	"github.com/yourusername/supabase-redis-middleware/internal/repository"
 	"github.com/yourusername/supabase-redis-middleware/internal/router"
 	"github.com/yourusername/supabase-redis-middleware/internal/service"
@@ -25,7 +26,7 @@ type mockSupabaseRepo struct {
 	queryDelay    time.Duration
 }
 
-func (m *mockSupabaseRepo) Query(ctx context.Context, table string, filters map[string]interface{}, pagination repository.Pagination) ([]map[string]interface{}, error) {
+func (m *mockSupabaseRepo) Query(ctx context.Context, table string, filters map[string]interface{}, pagination repository.Pagination, opts ...repository.QueryOption) ([]map[string]interface{}, error) {
 	if m.queryDelay > 0 {
 		time.Sleep(m.queryDelay)
 	}
@@ -35,7 +36,28 @@ func (m *mockSupabaseRepo) Query(ctx context.Context, table string, filters map[
 	return m.queryResult, nil
 }
 
-func (m *mockSupabaseRepo) GetByID(ctx context.Context, table string, id string) (map[string]interface{}, error) {
+func (m *mockSupabaseRepo) QueryWithCount(ctx context.Context, table string, filters map[string]interface{}, pagination repository.Pagination, opts ...repository.QueryOption) ([]map[string]interface{}, int64, error) {
+	items, err := m.Query(ctx, table, filters, pagination, opts...)
+	return items, int64(len(items)), err
+}
+
+func (m *mockSupabaseRepo) RPC(ctx context.Context, fn string, params map[string]interface{}) (json.RawMessage, error) {
+	return nil, repository.NewNotFoundError("functions", fn)
+}
+
+func (m *mockSupabaseRepo) Insert(ctx context.Context, table string, record map[string]interface{}) (map[string]interface{}, error) {
+	return record, nil
+}
+
+func (m *mockSupabaseRepo) Update(ctx context.Context, table string, id string, changes map[string]interface{}) (map[string]interface{}, error) {
+	return changes, nil
+}
+
+func (m *mockSupabaseRepo) Delete(ctx context.Context, table string, id string) error {
+	return nil
+}
+
+func (m *mockSupabaseRepo) GetByID(ctx context.Context, table string, id string, opts ...repository.QueryOption) (map[string]interface{}, error) {
 	if m.queryDelay > 0 {
 		time.Sleep(m.queryDelay)
 	}
@@ -80,6 +102,49 @@ func setupTestCache(t *testing.T) cache.CacheService {
 	return cacheService
 }
 
+// TestConditionalRequest tests that a matching If-None-Match short-circuits with 304
+func TestConditionalRequest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	resp := &service.Response{Status: "success", Data: []string{"a"}, ETag: `"abc"`}
+
+	engine := gin.New()
+	engine.GET("/items", func(c *gin.Context) {
+		handlers.WriteServiceResponse(c, resp)
+	})
+
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		wantStatus  int
+	}{
+		{"no header", "", http.StatusOK},
+		{"matching", `"abc"`, http.StatusNotModified},
+		{"weak match in list", `"xyz", W/"abc"`, http.StatusNotModified},
+		{"stale", `"xyz"`, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/items", nil)
+			if tt.ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", tt.ifNoneMatch)
+			}
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if w.Header().Get("ETag") != `"abc"` {
+				t.Errorf("ETag header = %q, want %q", w.Header().Get("ETag"), `"abc"`)
+			}
+			if tt.wantStatus == http.StatusNotModified && w.Body.Len() != 0 {
+				t.Errorf("304 response should have no body, got %q", w.Body.String())
+			}
+		})
+	}
+}
+
 // TestHealthCheckEndpoint tests the /health endpoint
 func TestHealthCheckEndpoint(t *testing.T) {
 	cacheService := setupTestCache(t)
@@ -245,10 +310,7 @@ func TestServiceCacheMissScenario(t *testing.T) {
 		t.Error("GetItems() should be cache miss for new query")
 	}
 
-	items, ok := response.Data.([]map[string]interface{})
-	if !ok {
-		t.Fatal("Response data should be []map[string]interface{}")
-	}
+	items := response.Data
 
 	if len(items) != 1 {
 		t.Errorf("Expected 1 item, got %d", len(items))
@@ -331,8 +393,8 @@ func TestServiceRedisFallback(t *testing.T) {
 		t.Errorf("GetItems() status = %v, want success", response.Status)
 	}
 
-	items, ok := response.Data.([]map[string]interface{})
-	if !ok || len(items) != 1 {
+	items := response.Data
+	if len(items) != 1 {
 		t.Error("GetItems() should return data from repository when Redis fails")
 	}
 }
@@ -458,10 +520,8 @@ func TestCompleteRequestFlow(t *testing.T) {
 
 	// Test placeholder endpoints (they should return 501 Not Implemented)
 	endpoints := []string{
-		"/api/v1/supermarket/products",
 		"/api/v1/supermarket/categories",
 		"/api/v1/movies",
-		"/api/v1/pharmacy/medicines",
 	}
 
 	for _, endpoint := range endpoints {